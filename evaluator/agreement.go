@@ -0,0 +1,342 @@
+package evaluator
+
+import (
+	"llm-tournament/middleware"
+	"math"
+	"sort"
+)
+
+// agreementHotSpots is how many of the most disputed cells each profile lists
+const agreementHotSpots = 5
+
+// AgreementReport summarises how consistently a suite's judges score the same cells.
+// Coefficients are nil when they are undefined, e.g. too few shared cells or no variation.
+type AgreementReport struct {
+	Judges            []string              `json:"judges"`
+	Cells             int                   `json:"cells"` // Cells scored by at least two judges
+	KrippendorffAlpha *float64              `json:"krippendorff_alpha"`
+	Pairs             []JudgePairAgreement  `json:"pairs"`
+	Bias              []JudgeBias           `json:"bias"`
+	Profiles          []ProfileDisagreement `json:"profiles"`
+}
+
+// JudgePairAgreement compares two judges on the cells both have scored
+type JudgePairAgreement struct {
+	JudgeA     string   `json:"judge_a"`
+	JudgeB     string   `json:"judge_b"`
+	Cells      int      `json:"cells"`
+	CohenKappa *float64 `json:"cohen_kappa"` // On scores snapped to the 0/20/.../100 buckets
+	Spearman   *float64 `json:"spearman"`
+}
+
+// JudgeBias is how far a judge sits from the rest of the panel on shared cells
+type JudgeBias struct {
+	Judge     string  `json:"judge"`
+	Cells     int     `json:"cells"`
+	MeanScore float64 `json:"mean_score"`
+	MeanBias  float64 `json:"mean_bias"` // Mean of (judge score - mean of the other judges)
+}
+
+// ProfileDisagreement summarises judge disagreement within one prompt profile
+type ProfileDisagreement struct {
+	Profile           string             `json:"profile"`
+	Cells             int                `json:"cells"`
+	MeanStdDev        float64            `json:"mean_std_dev"`
+	KrippendorffAlpha *float64           `json:"krippendorff_alpha"`
+	HotSpots          []CellDisagreement `json:"hot_spots"`
+}
+
+// CellDisagreement is one model/prompt cell with the judges' scores
+type CellDisagreement struct {
+	ModelID  int            `json:"model_id"`
+	Model    string         `json:"model"`
+	PromptID int            `json:"prompt_id"`
+	Prompt   string         `json:"prompt"`
+	Scores   map[string]int `json:"scores"`
+	StdDev   float64        `json:"std_dev"`
+}
+
+// agreementCell collects every judge's score for one model/prompt pair
+type agreementCell struct {
+	CellDisagreement
+	profile string
+}
+
+// ComputeAgreement measures inter-judge agreement over each judge's latest scores
+func ComputeAgreement(scores []middleware.JudgeScore) AgreementReport {
+	report := AgreementReport{}
+
+	judgeSet := make(map[string]bool)
+	index := make(map[[2]int]int)
+	var cells []*agreementCell
+	for _, s := range scores {
+		judgeSet[s.Judge] = true
+		key := [2]int{s.ModelID, s.PromptID}
+		i, ok := index[key]
+		if !ok {
+			i = len(cells)
+			index[key] = i
+			cells = append(cells, &agreementCell{
+				CellDisagreement: CellDisagreement{
+					ModelID:  s.ModelID,
+					Model:    s.Model,
+					PromptID: s.PromptID,
+					Prompt:   s.Prompt,
+					Scores:   make(map[string]int),
+				},
+				profile: s.Profile,
+			})
+		}
+		cells[i].Scores[s.Judge] = s.Score
+	}
+	for j := range judgeSet {
+		report.Judges = append(report.Judges, j)
+	}
+	sort.Strings(report.Judges)
+
+	// Only cells with two or more judges say anything about agreement
+	shared := cells[:0]
+	for _, c := range cells {
+		if len(c.Scores) >= 2 {
+			c.StdDev = scoreStdDev(c.Scores)
+			shared = append(shared, c)
+		}
+	}
+	report.Cells = len(shared)
+	report.KrippendorffAlpha = krippendorffAlpha(shared)
+	report.Pairs = judgePairAgreements(report.Judges, shared)
+	report.Bias = judgeBiases(report.Judges, shared)
+	report.Profiles = profileDisagreements(shared)
+	return report
+}
+
+// judgePairAgreements computes kappa and Spearman for every pair of judges
+func judgePairAgreements(judges []string, cells []*agreementCell) []JudgePairAgreement {
+	var pairs []JudgePairAgreement
+	for i := 0; i < len(judges); i++ {
+		for j := i + 1; j < len(judges); j++ {
+			var a, b []float64
+			for _, c := range cells {
+				sa, okA := c.Scores[judges[i]]
+				sb, okB := c.Scores[judges[j]]
+				if okA && okB {
+					a = append(a, float64(sa))
+					b = append(b, float64(sb))
+				}
+			}
+			if len(a) == 0 {
+				continue
+			}
+			pairs = append(pairs, JudgePairAgreement{
+				JudgeA:     judges[i],
+				JudgeB:     judges[j],
+				Cells:      len(a),
+				CohenKappa: cohenKappa(a, b),
+				Spearman:   spearman(a, b),
+			})
+		}
+	}
+	return pairs
+}
+
+// judgeBiases compares each judge with the mean of the other judges on the same cells
+func judgeBiases(judges []string, cells []*agreementCell) []JudgeBias {
+	var biases []JudgeBias
+	for _, judge := range judges {
+		b := JudgeBias{Judge: judge}
+		for _, c := range cells {
+			score, ok := c.Scores[judge]
+			if !ok {
+				continue
+			}
+			others := 0.0
+			for other, s := range c.Scores {
+				if other != judge {
+					others += float64(s)
+				}
+			}
+			others /= float64(len(c.Scores) - 1)
+
+			b.Cells++
+			b.MeanScore += float64(score)
+			b.MeanBias += float64(score) - others
+		}
+		if b.Cells == 0 {
+			continue
+		}
+		b.MeanScore /= float64(b.Cells)
+		b.MeanBias /= float64(b.Cells)
+		biases = append(biases, b)
+	}
+	return biases
+}
+
+// profileDisagreements groups cells by profile, most disputed profile first
+func profileDisagreements(cells []*agreementCell) []ProfileDisagreement {
+	byProfile := make(map[string][]*agreementCell)
+	var order []string
+	for _, c := range cells {
+		if _, ok := byProfile[c.profile]; !ok {
+			order = append(order, c.profile)
+		}
+		byProfile[c.profile] = append(byProfile[c.profile], c)
+	}
+
+	profiles := make([]ProfileDisagreement, 0, len(order))
+	for _, name := range order {
+		group := byProfile[name]
+		p := ProfileDisagreement{
+			Profile:           name,
+			Cells:             len(group),
+			KrippendorffAlpha: krippendorffAlpha(group),
+		}
+		for _, c := range group {
+			p.MeanStdDev += c.StdDev
+		}
+		p.MeanStdDev /= float64(len(group))
+
+		sorted := append([]*agreementCell(nil), group...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StdDev > sorted[j].StdDev })
+		for _, c := range sorted {
+			if len(p.HotSpots) == agreementHotSpots || c.StdDev == 0 {
+				break
+			}
+			p.HotSpots = append(p.HotSpots, c.CellDisagreement)
+		}
+		profiles = append(profiles, p)
+	}
+
+	sort.SliceStable(profiles, func(i, j int) bool { return profiles[i].MeanStdDev > profiles[j].MeanStdDev })
+	return profiles
+}
+
+// scoreStdDev is the population standard deviation of the judges' scores
+func scoreStdDev(scores map[string]int) float64 {
+	mean := 0.0
+	for _, s := range scores {
+		mean += float64(s)
+	}
+	mean /= float64(len(scores))
+
+	variance := 0.0
+	for _, s := range scores {
+		variance += (float64(s) - mean) * (float64(s) - mean)
+	}
+	return math.Sqrt(variance / float64(len(scores)))
+}
+
+// cohenKappa measures agreement beyond chance on the valid score buckets
+func cohenKappa(a, b []float64) *float64 {
+	n := float64(len(a))
+	countA := make(map[int]float64)
+	countB := make(map[int]float64)
+	observed := 0.0
+	for i := range a {
+		ba, bb := RoundToValidScore(int(a[i])), RoundToValidScore(int(b[i]))
+		countA[ba]++
+		countB[bb]++
+		if ba == bb {
+			observed++
+		}
+	}
+	observed /= n
+
+	expected := 0.0
+	for bucket, ca := range countA {
+		expected += (ca / n) * (countB[bucket] / n)
+	}
+	if expected == 1 {
+		return nil // Both judges always gave the same single score
+	}
+	kappa := (observed - expected) / (1 - expected)
+	return &kappa
+}
+
+// spearman is the rank correlation of two score series, with ties sharing their mean rank
+func spearman(a, b []float64) *float64 {
+	if len(a) < 2 {
+		return nil
+	}
+	return pearson(ranks(a), ranks(b))
+}
+
+// ranks assigns 1-based ranks, averaging the ranks of tied values
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+
+	r := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			r[order[k]] = avg
+		}
+		i = j + 1
+	}
+	return r
+}
+
+// pearson is the correlation coefficient, nil when either series is constant
+func pearson(a, b []float64) *float64 {
+	n := float64(len(a))
+	meanA, meanB := 0.0, 0.0
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= n
+	meanB /= n
+
+	cov, varA, varB := 0.0, 0.0, 0.0
+	for i := range a {
+		cov += (a[i] - meanA) * (b[i] - meanB)
+		varA += (a[i] - meanA) * (a[i] - meanA)
+		varB += (b[i] - meanB) * (b[i] - meanB)
+	}
+	if varA == 0 || varB == 0 {
+		return nil
+	}
+	r := cov / math.Sqrt(varA*varB)
+	return &r
+}
+
+// krippendorffAlpha computes interval alpha over any number of judges with missing scores.
+// For a set of values, the sum of squared differences over ordered pairs is 2(nΣv² - (Σv)²).
+func krippendorffAlpha(cells []*agreementCell) *float64 {
+	observed := 0.0
+	n, sum, sumSq := 0.0, 0.0, 0.0
+	for _, c := range cells {
+		m, cellSum, cellSq := 0.0, 0.0, 0.0
+		for _, s := range c.Scores {
+			v := float64(s)
+			m++
+			cellSum += v
+			cellSq += v * v
+		}
+		if m < 2 {
+			continue
+		}
+		observed += 2 * (m*cellSq - cellSum*cellSum) / (m - 1)
+		n += m
+		sum += cellSum
+		sumSq += cellSq
+	}
+	if n < 2 {
+		return nil
+	}
+
+	observed /= n
+	expected := 2 * (n*sumSq - sum*sum) / (n * (n - 1))
+	if expected == 0 {
+		return nil
+	}
+	alpha := 1 - observed/expected
+	return &alpha
+}
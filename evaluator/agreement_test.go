@@ -0,0 +1,147 @@
+package evaluator
+
+import (
+	"llm-tournament/middleware"
+	"math"
+	"testing"
+)
+
+// judgeScores builds one cell per row with the given judge scores
+func judgeScores(profile string, judges []string, rows [][]int) []middleware.JudgeScore {
+	var scores []middleware.JudgeScore
+	for i, row := range rows {
+		for j, s := range row {
+			if s < 0 {
+				continue // Judge did not score this cell
+			}
+			scores = append(scores, middleware.JudgeScore{
+				Judge:    judges[j],
+				Score:    s,
+				ModelID:  1,
+				Model:    "m",
+				PromptID: i + 1,
+				Profile:  profile,
+			})
+		}
+	}
+	return scores
+}
+
+func assertCoefficient(t *testing.T, name string, got *float64, want float64) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("%s: expected %.4f, got nil", name, want)
+	}
+	if math.Abs(*got-want) > 1e-3 {
+		t.Errorf("%s: expected %.4f, got %.4f", name, want, *got)
+	}
+}
+
+func TestComputeAgreement_PerfectAgreement(t *testing.T) {
+	scores := judgeScores("", []string{"a", "b"}, [][]int{{0, 0}, {40, 40}, {100, 100}})
+	report := ComputeAgreement(scores)
+
+	if report.Cells != 3 || len(report.Judges) != 2 || len(report.Pairs) != 1 {
+		t.Fatalf("unexpected report shape: %+v", report)
+	}
+	assertCoefficient(t, "alpha", report.KrippendorffAlpha, 1)
+	assertCoefficient(t, "kappa", report.Pairs[0].CohenKappa, 1)
+	assertCoefficient(t, "spearman", report.Pairs[0].Spearman, 1)
+	if len(report.Profiles) != 1 || len(report.Profiles[0].HotSpots) != 0 {
+		t.Errorf("expected no hot spots, got %+v", report.Profiles)
+	}
+}
+
+func TestCohenKappa_KnownValue(t *testing.T) {
+	// 4 of 6 agree; marginals a={0:3,100:3}, b={0:3,100:3} so pe=0.5 and kappa=(2/3-0.5)/0.5
+	a := []float64{0, 0, 0, 100, 100, 100}
+	b := []float64{0, 0, 100, 100, 100, 0}
+	assertCoefficient(t, "kappa", cohenKappa(a, b), 1.0/3)
+
+	if k := cohenKappa([]float64{60, 60}, []float64{60, 60}); k != nil {
+		t.Errorf("expected nil kappa when both judges give one score, got %v", *k)
+	}
+}
+
+func TestSpearman_TiesAndReversal(t *testing.T) {
+	assertCoefficient(t, "reversed", spearman([]float64{10, 20, 30}, []float64{30, 20, 10}), -1)
+
+	// Ranks with ties: a=[1.5,1.5,3], b=[1,2,3]
+	assertCoefficient(t, "ties", spearman([]float64{50, 50, 90}, []float64{10, 20, 30}), math.Sqrt(3)/2)
+
+	if r := spearman([]float64{50, 50}, []float64{10, 90}); r != nil {
+		t.Errorf("expected nil for a constant series, got %v", *r)
+	}
+}
+
+func TestKrippendorffAlpha_KnownValue(t *testing.T) {
+	// Two judges, three cells: (0,20) (40,40) (100,80)
+	// n=6, values 0,20,40,40,100,80; Do=(2*400+0+2*400)/6=266.67
+	// sum=280 sumSq=20000, De=2*(6*20000-280^2)/(6*5)=2773.33, alpha=1-Do/De
+	cells := []*agreementCell{
+		{CellDisagreement: CellDisagreement{Scores: map[string]int{"a": 0, "b": 20}}},
+		{CellDisagreement: CellDisagreement{Scores: map[string]int{"a": 40, "b": 40}}},
+		{CellDisagreement: CellDisagreement{Scores: map[string]int{"a": 100, "b": 80}}},
+	}
+	assertCoefficient(t, "alpha", krippendorffAlpha(cells), 1-(1600.0/6)/(2*(6*20000.0-280*280)/30))
+}
+
+func TestComputeAgreement_BiasAndMissingScores(t *testing.T) {
+	// "harsh" scores 20 below the others; "c" skips the second cell
+	scores := judgeScores("Math", []string{"a", "c", "harsh"}, [][]int{
+		{80, 80, 60},
+		{60, -1, 40},
+		{100, 100, 80},
+	})
+	// A cell with a single judge is not shared
+	scores = append(scores, middleware.JudgeScore{Judge: "a", Score: 0, ModelID: 2, PromptID: 1, Profile: "Math"})
+
+	report := ComputeAgreement(scores)
+	if report.Cells != 3 {
+		t.Fatalf("expected 3 shared cells, got %d", report.Cells)
+	}
+	if len(report.Pairs) != 3 {
+		t.Fatalf("expected 3 judge pairs, got %d", len(report.Pairs))
+	}
+	if report.Pairs[0].JudgeA != "a" || report.Pairs[0].JudgeB != "c" || report.Pairs[0].Cells != 2 {
+		t.Errorf("unexpected first pair: %+v", report.Pairs[0])
+	}
+
+	var harsh JudgeBias
+	for _, b := range report.Bias {
+		if b.Judge == "harsh" {
+			harsh = b
+		}
+	}
+	if harsh.Cells != 3 || math.Abs(harsh.MeanBias+20) > 1e-9 {
+		t.Errorf("expected harsh to be 20 points below the panel, got %+v", harsh)
+	}
+
+	hot := report.Profiles[0].HotSpots
+	if len(hot) != 3 || hot[0].StdDev < hot[len(hot)-1].StdDev {
+		t.Errorf("expected hot spots ordered by spread, got %+v", hot)
+	}
+}
+
+func TestComputeAgreement_ProfilesOrderedByDisagreement(t *testing.T) {
+	scores := judgeScores("Calm", []string{"a", "b"}, [][]int{{80, 80}, {60, 60}})
+	disputed := judgeScores("Disputed", []string{"a", "b"}, [][]int{{0, 100}})
+	for i := range disputed {
+		disputed[i].PromptID = 10
+	}
+	report := ComputeAgreement(append(scores, disputed...))
+
+	if len(report.Profiles) != 2 || report.Profiles[0].Profile != "Disputed" {
+		t.Fatalf("expected the disputed profile first, got %+v", report.Profiles)
+	}
+	if report.Profiles[1].KrippendorffAlpha == nil || *report.Profiles[1].KrippendorffAlpha != 1 {
+		t.Errorf("expected perfect alpha for the calm profile, got %v", report.Profiles[1].KrippendorffAlpha)
+	}
+}
+
+func TestComputeAgreement_Empty(t *testing.T) {
+	report := ComputeAgreement(nil)
+	if report.Cells != 0 || report.KrippendorffAlpha != nil || len(report.Pairs) != 0 {
+		t.Errorf("expected an empty report, got %+v", report)
+	}
+}
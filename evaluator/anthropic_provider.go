@@ -0,0 +1,122 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// anthropicVersion is the Messages API version header value
+const anthropicVersion = "2023-06-01"
+
+// AnthropicProvider judges responses through the Anthropic Messages API
+type AnthropicProvider struct {
+	config     JudgeConfig
+	httpClient *http.Client
+}
+
+// NewAnthropicProvider creates a provider for the Anthropic Messages API
+func NewAnthropicProvider(cfg JudgeConfig) *AnthropicProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultAnthropicBaseURL
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = defaultJudgeMaxTokens
+	}
+	return &AnthropicProvider{
+		config: cfg,
+		httpClient: &http.Client{
+			Timeout: 180 * time.Second,
+		},
+	}
+}
+
+// anthropicRequest is the body of a /messages call
+type anthropicRequest struct {
+	Model         string        `json:"model"`
+	MaxTokens     int           `json:"max_tokens"`
+	System        string        `json:"system,omitempty"`
+	Messages      []chatMessage `json:"messages"`
+	Temperature   *float64      `json:"temperature,omitempty"`
+	TopP          *float64      `json:"top_p,omitempty"`
+	StopSequences []string      `json:"stop_sequences,omitempty"`
+}
+
+// anthropicResponse is the subset of a Messages API response we use
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Name returns the judge name
+func (p *AnthropicProvider) Name() string {
+	return p.config.Name
+}
+
+// Evaluate grades a response with this judge
+func (p *AnthropicProvider) Evaluate(ctx context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	return evaluateWithCompleter(ctx, p.config, p, req)
+}
+
+// complete sends a system+user conversation to the Messages API
+func (p *AnthropicProvider) complete(ctx context.Context, system, user string) (*completion, error) {
+	body := anthropicRequest{
+		Model:       p.config.Model,
+		MaxTokens:   p.config.MaxTokens,
+		System:      system,
+		Messages:    []chatMessage{{Role: "user", Content: user}},
+		Temperature: p.config.Temperature,
+	}
+	return postAnthropicMessages(ctx, p.httpClient, p.config.BaseURL, p.config.APIKey, body)
+}
+
+// postAnthropicMessages sends a request to an Anthropic Messages API endpoint
+func postAnthropicMessages(ctx context.Context, client *http.Client, baseURL, apiKey string, body anthropicRequest) (*completion, error) {
+	jsonData, _ := json.Marshal(body)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(baseURL, "/")+"/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError("messages request", resp)
+	}
+
+	var msgResp anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msgResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range msgResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	return &completion{
+		Text:         text.String(),
+		InputTokens:  msgResp.Usage.InputTokens,
+		OutputTokens: msgResp.Usage.OutputTokens,
+	}, nil
+}
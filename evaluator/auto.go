@@ -0,0 +1,176 @@
+package evaluator
+
+import (
+	"fmt"
+	"llm-tournament/middleware"
+	"log"
+	"sync"
+	"time"
+)
+
+// JobTypeAuto evaluates pairs affected by new models, prompts or responses when the
+// auto_evaluate_new_models setting is on
+const JobTypeAuto = "auto"
+
+// autoEvaluateDelay is how long a suite must go without changes before its auto job
+// is queued, so a bulk import produces one job (replaced in tests)
+var autoEvaluateDelay = 5 * time.Second
+
+// autoTarget selects the pairs a change affects. A zero field matches every model or
+// prompt of the suite.
+type autoTarget struct {
+	modelID  int
+	promptID int
+}
+
+// autoQueue collects the changes of each suite until its debounce timer fires
+type autoQueue struct {
+	mu      sync.Mutex
+	pending map[int]map[autoTarget]bool
+	timers  map[int]*time.Timer
+	stopped bool
+}
+
+// AutoEvaluateModel queues every pair of a newly added model for automatic evaluation.
+// A model without responses has nothing to evaluate yet; its pairs are queued one by
+// one as responses are saved or generated.
+func (e *Evaluator) AutoEvaluateModel(suiteID, modelID int) {
+	var responses int
+	if err := e.db.QueryRow("SELECT COUNT(*) FROM model_responses WHERE model_id = ?", modelID).Scan(&responses); err != nil {
+		log.Printf("Failed to count responses of model %d for auto evaluation: %v", modelID, err)
+		return
+	}
+	if responses == 0 {
+		return
+	}
+	e.autoEvaluate(suiteID, autoTarget{modelID: modelID})
+}
+
+// AutoEvaluatePrompt queues every pair of a newly added prompt for automatic evaluation
+func (e *Evaluator) AutoEvaluatePrompt(suiteID, promptID int) {
+	e.autoEvaluate(suiteID, autoTarget{promptID: promptID})
+}
+
+// AutoEvaluatePair queues a pair whose response was saved for automatic evaluation
+func (e *Evaluator) AutoEvaluatePair(modelID, promptID int) {
+	var suiteID int
+	if err := e.db.QueryRow("SELECT suite_id FROM models WHERE id = ?", modelID).Scan(&suiteID); err != nil {
+		log.Printf("Failed to look up model %d for auto evaluation: %v", modelID, err)
+		return
+	}
+	e.autoEvaluate(suiteID, autoTarget{modelID: modelID, promptID: promptID})
+}
+
+// autoEvaluate records a change and restarts the suite's debounce timer. Nothing is
+// recorded unless auto evaluation is enabled.
+func (e *Evaluator) autoEvaluate(suiteID int, target autoTarget) {
+	if e.setting("auto_evaluate_new_models") != "true" {
+		return
+	}
+
+	q := &e.auto
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopped {
+		return
+	}
+	if q.pending == nil {
+		q.pending = make(map[int]map[autoTarget]bool)
+		q.timers = make(map[int]*time.Timer)
+	}
+	if q.pending[suiteID] == nil {
+		q.pending[suiteID] = make(map[autoTarget]bool)
+	}
+	q.pending[suiteID][target] = true
+
+	if timer, ok := q.timers[suiteID]; ok {
+		timer.Stop()
+	}
+	q.timers[suiteID] = time.AfterFunc(autoEvaluateDelay, func() {
+		if _, err := e.flushAutoEvaluation(suiteID); err != nil {
+			log.Printf("Auto evaluation of suite %d failed: %v", suiteID, err)
+		}
+	})
+}
+
+// flushAutoEvaluation queues one auto job for the pairs changed in a suite since the
+// last flush. Only pairs with a stored response are included, and no job is queued
+// when there are none or the suite's monthly budget cannot cover the estimate.
+// It returns the new job's ID, or 0 when nothing was queued.
+func (e *Evaluator) flushAutoEvaluation(suiteID int) (int, error) {
+	e.auto.mu.Lock()
+	targets := e.auto.pending[suiteID]
+	delete(e.auto.pending, suiteID)
+	delete(e.auto.timers, suiteID)
+	e.auto.mu.Unlock()
+
+	seen := make(map[modelPromptPair]bool)
+	var pairs []modelPromptPair
+	for target := range targets {
+		rows, err := e.db.Query(`
+			SELECT r.model_id, r.prompt_id
+			FROM model_responses r
+			JOIN models m ON m.id = r.model_id
+			JOIN prompts p ON p.id = r.prompt_id
+			WHERE m.suite_id = ? AND p.suite_id = m.suite_id
+				AND (? = 0 OR m.id = ?) AND (? = 0 OR p.id = ?)
+				AND r.response_text IS NOT NULL AND r.response_text != ''
+			ORDER BY p.display_order, m.name
+		`, suiteID, target.modelID, target.modelID, target.promptID, target.promptID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to find affected pairs: %w", err)
+		}
+		for rows.Next() {
+			var p modelPromptPair
+			if err := rows.Scan(&p.modelID, &p.promptID); err != nil {
+				_ = rows.Close()
+				return 0, fmt.Errorf("failed to scan pair: %w", err)
+			}
+			if !seen[p] {
+				seen[p] = true
+				pairs = append(pairs, p)
+			}
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return 0, fmt.Errorf("failed to find affected pairs: %w", err)
+		}
+	}
+	if len(pairs) == 0 {
+		return 0, nil
+	}
+
+	estimatedCost := float64(len(pairs)) * 0.05
+	if limit := parseBudget(e.suiteSetting(suiteID, middleware.SuiteSettingMonthlyBudget)); limit > 0 {
+		if month := e.monthSpend(suiteID, time.Now()); month+estimatedCost > limit {
+			log.Printf("Skipping auto evaluation of %d pairs in suite %d: ~$%.2f would pass the $%.2f monthly budget ($%.2f spent)",
+				len(pairs), suiteID, estimatedCost, limit, month)
+			return 0, nil
+		}
+	}
+
+	job := &EvaluationJob{
+		SuiteID:       suiteID,
+		JobType:       JobTypeAuto,
+		ProgressTotal: len(pairs),
+		EstimatedCost: estimatedCost,
+		JudgePanel:    e.suitePanel(suiteID),
+	}
+	if err := e.jobQueue.enqueue(job, pairs); err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	log.Printf("Queued auto evaluation job %d for %d pairs in suite %d", job.ID, len(pairs), suiteID)
+	return job.ID, nil
+}
+
+// stop cancels the debounce timers; changes still pending are dropped
+func (q *autoQueue) stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stopped = true
+	for _, timer := range q.timers {
+		timer.Stop()
+	}
+	q.pending, q.timers = nil, nil
+}
@@ -0,0 +1,184 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"llm-tournament/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// enableAutoEvaluation turns the setting on and shortens the debounce for the test
+func enableAutoEvaluation(t *testing.T, db *sql.DB) {
+	t.Helper()
+	if _, err := db.Exec("INSERT INTO settings (key, value) VALUES ('auto_evaluate_new_models', 'true')"); err != nil {
+		t.Fatalf("failed to enable auto evaluation: %v", err)
+	}
+	original := autoEvaluateDelay
+	autoEvaluateDelay = 20 * time.Millisecond
+	t.Cleanup(func() { autoEvaluateDelay = original })
+}
+
+func TestAutoEvaluate_DisabledQueuesNothing(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+
+	e := newGeneratorTestEvaluator(db)
+	e.AutoEvaluateModel(1, 1)
+	if len(e.auto.pending) != 0 {
+		t.Errorf("expected no pending changes while the setting is off, got %v", e.auto.pending)
+	}
+}
+
+func TestAutoEvaluate_DebouncesIntoOneJob(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+	enableAutoEvaluation(t, db)
+
+	var m1, m2, p1, p2 int
+	_ = db.QueryRow("SELECT id FROM models WHERE name = 'm1'").Scan(&m1)
+	_ = db.QueryRow("SELECT id FROM models WHERE name = 'm2'").Scan(&m2)
+	_ = db.QueryRow("SELECT id FROM prompts WHERE text = 'p1'").Scan(&p1)
+	_ = db.QueryRow("SELECT id FROM prompts WHERE text = 'p2'").Scan(&p2)
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(&promptFailProvider{})
+	// A burst of changes, as from a bulk import
+	for i := 0; i < 50; i++ {
+		e.AutoEvaluateModel(1, m1)
+		e.AutoEvaluatePair(m2, p1)
+	}
+	e.AutoEvaluatePrompt(1, p2) // m2 × p2 has no response, so this adds nothing new
+
+	var job *EvaluationJob
+	select {
+	case job = <-e.jobQueue.jobs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected an auto job to be queued")
+	}
+	e.jobQueue.jobs <- job
+	runQueuedJob(t, e)
+
+	if job.JobType != JobTypeAuto || job.ProgressTotal != 3 {
+		t.Errorf("expected one auto job for 3 pairs, got %+v", job)
+	}
+	outcomes := outcomesByPair(t, e, job.ID)
+	for _, pair := range []string{"m1/p1", "m1/p2", "m2/p1"} {
+		if outcomes[pair].Outcome != PairScored {
+			t.Errorf("expected %s scored, got %+v", pair, outcomes[pair])
+		}
+	}
+	if _, ok := outcomes["m2/p2"]; ok {
+		t.Error("expected the pair without a response to be left out")
+	}
+
+	time.Sleep(3 * autoEvaluateDelay)
+	var jobs int
+	if err := db.QueryRow("SELECT COUNT(*) FROM evaluation_jobs").Scan(&jobs); err != nil || jobs != 1 {
+		t.Errorf("expected the burst to produce a single job, got %d (%v)", jobs, err)
+	}
+}
+
+func TestFlushAutoEvaluation_RespectsMonthlyBudget(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+	enableAutoEvaluation(t, db)
+
+	if _, err := db.Exec(`
+		INSERT INTO suite_settings (suite_id, key, value) VALUES (1, ?, '5');
+		INSERT INTO cost_tracking (suite_id, date, total_cost_usd, evaluation_count) VALUES (1, ?, 4.95, 1);
+	`, middleware.SuiteSettingMonthlyBudget, time.Now().Format(time.DateOnly)); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	// Flush by hand rather than on the timer
+	e.auto.pending = map[int]map[autoTarget]bool{1: {{modelID: 1}: true}}
+
+	// Two pairs at ~$0.05 each would take $4.95 past $5
+	jobID, err := e.flushAutoEvaluation(1)
+	if err != nil || jobID != 0 {
+		t.Fatalf("expected no job over budget, got job %d (%v)", jobID, err)
+	}
+
+	if _, err := db.Exec("UPDATE suite_settings SET value = '6'"); err != nil {
+		t.Fatalf("failed to raise budget: %v", err)
+	}
+	e.auto.pending = map[int]map[autoTarget]bool{1: {{modelID: 1}: true}}
+	jobID, err = e.flushAutoEvaluation(1)
+	if err != nil || jobID == 0 {
+		t.Fatalf("expected a job once the budget allows it, got %d (%v)", jobID, err)
+	}
+}
+
+func TestAutoEvaluateModel_SkipsModelWithoutResponses(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+	enableAutoEvaluation(t, db)
+	if _, err := db.Exec("INSERT INTO models (name, suite_id) VALUES ('new', 1)"); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	var modelID int
+	_ = db.QueryRow("SELECT id FROM models WHERE name = 'new'").Scan(&modelID)
+
+	e := newGeneratorTestEvaluator(db)
+	e.AutoEvaluateModel(1, modelID)
+	if len(e.auto.pending) != 0 {
+		t.Errorf("expected a model without responses to queue nothing, got %v", e.auto.pending)
+	}
+}
+
+func TestProcessGenerateJob_QueuesAutoEvaluation(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+	enableAutoEvaluation(t, db)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "ok"}}},
+		})
+	}))
+	defer server.Close()
+	if _, err := db.Exec("INSERT INTO model_configs (model_id, base_url, model_name) VALUES (1, ?, 'llama-3')", server.URL); err != nil {
+		t.Fatalf("failed to insert config: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeGenerateModel, TargetID: 1, ProgressTotal: 2}
+	if err := e.processGenerateJob(context.Background(), job); err != nil {
+		t.Fatalf("processGenerateJob failed: %v", err)
+	}
+
+	select {
+	case auto := <-e.jobQueue.jobs:
+		if auto.JobType != JobTypeAuto || auto.ProgressTotal != 2 {
+			t.Errorf("expected an auto job for the 2 generated responses, got %+v", auto)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected generated responses to be queued for auto evaluation")
+	}
+}
+
+func TestAutoEvaluate_StoppedQueuesNothing(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+	enableAutoEvaluation(t, db)
+
+	e := newGeneratorTestEvaluator(db)
+	e.AutoEvaluateModel(1, 1)
+	e.auto.stop()
+	e.AutoEvaluateModel(1, 2)
+
+	time.Sleep(3 * autoEvaluateDelay)
+	if len(e.jobQueue.jobs) != 0 {
+		t.Errorf("expected no job after stopping, got %d", len(e.jobQueue.jobs))
+	}
+}
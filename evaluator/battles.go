@@ -0,0 +1,478 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"llm-tournament/middleware"
+	"log"
+	"strings"
+)
+
+// JobTypeBattleAll asks the judges to decide every pairwise battle in a suite
+const JobTypeBattleAll = "battle_all"
+
+// battleTieMargin is the score gap (0-100) below which score-based verdicts count as a tie
+const battleTieMargin = 10
+
+// PairwiseRequest asks a judge to compare two responses to the same prompt
+type PairwiseRequest struct {
+	Prompt    string
+	Solution  string
+	Type      string
+	Turns     []middleware.Turn // Conversation before Prompt, the final user message
+	ResponseA string
+	ResponseB string
+}
+
+// PairwiseVerdict is a judge's decision on a pairwise request
+type PairwiseVerdict struct {
+	Outcome   string // middleware.BattleWinA, BattleWinB or BattleTie
+	Reasoning string
+	CostUSD   float64
+}
+
+// PairwiseJudge is implemented by providers that can compare two responses directly.
+// Providers without it are asked to score both responses and the scores are compared.
+type PairwiseJudge interface {
+	ComparePair(ctx context.Context, req PairwiseRequest) (*PairwiseVerdict, error)
+}
+
+// RenderPairwisePrompt formats the judge prompt for a pairwise request
+func RenderPairwisePrompt(req PairwiseRequest) (string, error) {
+	var buf bytes.Buffer
+	if err := judgePromptTemplates.ExecuteTemplate(&buf, "pairwise_judge.tmpl", req); err != nil {
+		return "", fmt.Errorf("failed to render pairwise prompt: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ComparePair asks this judge which of two responses is better
+func (p *OpenAIProvider) ComparePair(ctx context.Context, req PairwiseRequest) (*PairwiseVerdict, error) {
+	return compareWithCompleter(ctx, p.config, p, req)
+}
+
+// ComparePair asks this judge which of two responses is better
+func (p *AnthropicProvider) ComparePair(ctx context.Context, req PairwiseRequest) (*PairwiseVerdict, error) {
+	return compareWithCompleter(ctx, p.config, p, req)
+}
+
+// compareWithCompleter runs the pairwise prompt through a chat provider and parses the verdict
+func compareWithCompleter(ctx context.Context, cfg JudgeConfig, c chatCompleter, req PairwiseRequest) (*PairwiseVerdict, error) {
+	prompt, err := RenderPairwisePrompt(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.complete(ctx, judgeSystemPrompt, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict, err := parsePairwiseOutput(out.Text)
+	if err != nil {
+		return nil, fmt.Errorf("judge %s: %w", cfg.Name, err)
+	}
+	verdict.CostUSD = completionCost(cfg, out)
+	return verdict, nil
+}
+
+// parsePairwiseOutput extracts the JSON verdict from a pairwise judge completion
+func parsePairwiseOutput(text string) (*PairwiseVerdict, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in judge output")
+	}
+
+	var raw struct {
+		Winner    string `json:"winner"`
+		Reasoning string `json:"reasoning"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse judge output: %w", err)
+	}
+
+	verdict := &PairwiseVerdict{Reasoning: raw.Reasoning}
+	switch strings.ToLower(strings.TrimSpace(raw.Winner)) {
+	case "a":
+		verdict.Outcome = middleware.BattleWinA
+	case "b":
+		verdict.Outcome = middleware.BattleWinB
+	case "tie":
+		verdict.Outcome = middleware.BattleTie
+	default:
+		return nil, fmt.Errorf("unknown winner %q", raw.Winner)
+	}
+	return verdict, nil
+}
+
+// battleResponse is one model's stored response to a prompt
+type battleResponse struct {
+	modelID int
+	text    string
+}
+
+// battlePrompt is a prompt with every stored response, ready for pairwise judging
+type battlePrompt struct {
+	id        int
+	request   PairwiseRequest
+	responses []battleResponse
+}
+
+// loadBattlePrompts returns the suite's prompts that have at least two responses
+func (e *Evaluator) loadBattlePrompts(suiteID int) ([]battlePrompt, error) {
+	rows, err := e.db.Query(`
+		SELECT p.id, p.text, COALESCE(p.solution, ''), COALESCE(p.type, ''), r.model_id, r.response_text
+		FROM model_responses r
+		JOIN prompts p ON p.id = r.prompt_id
+		JOIN models m ON m.id = r.model_id
+		WHERE p.suite_id = ? AND r.response_text IS NOT NULL AND r.response_text != ''
+		ORDER BY p.display_order, p.id, r.model_id
+	`, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var prompts []battlePrompt
+	for rows.Next() {
+		var promptID int
+		var req PairwiseRequest
+		var resp battleResponse
+		if err := rows.Scan(&promptID, &req.Prompt, &req.Solution, &req.Type, &resp.modelID, &resp.text); err != nil {
+			return nil, err
+		}
+		if len(prompts) == 0 || prompts[len(prompts)-1].id != promptID {
+			prompts = append(prompts, battlePrompt{id: promptID, request: req})
+		}
+		last := &prompts[len(prompts)-1]
+		last.responses = append(last.responses, resp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	eligible := prompts[:0]
+	for _, p := range prompts {
+		if len(p.responses) >= 2 {
+			if p.request.Turns, err = e.promptTurns(p.id); err != nil {
+				return nil, err
+			}
+			eligible = append(eligible, p)
+		}
+	}
+	return eligible, nil
+}
+
+// battlePairCount counts the model pairs a battle job will judge
+func battlePairCount(prompts []battlePrompt) int {
+	total := 0
+	for _, p := range prompts {
+		n := len(p.responses)
+		total += n * (n - 1) / 2
+	}
+	return total
+}
+
+// RunJudgeBattles queues a job in which the judges decide every pairwise battle in a suite
+func (e *Evaluator) RunJudgeBattles(suiteID int) (int, error) {
+	prompts, err := e.loadBattlePrompts(suiteID)
+	if err != nil {
+		return 0, err
+	}
+	total := battlePairCount(prompts)
+	if total == 0 {
+		return 0, fmt.Errorf("no prompt has responses from at least two models")
+	}
+
+	job := &EvaluationJob{
+		SuiteID:       suiteID,
+		JobType:       JobTypeBattleAll,
+		ProgressTotal: total,
+		EstimatedCost: float64(total) * 0.05,
+	}
+	if err := e.jobQueue.Enqueue(job); err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return job.ID, nil
+}
+
+// processBattleJob judges every pair of responses for each prompt. Judged pairs are
+// checkpointed with their battles, so a resumed job neither judges them again nor
+// records their battles twice.
+func (e *Evaluator) processBattleJob(ctx context.Context, job *EvaluationJob) error {
+	prompts, err := e.loadBattlePrompts(job.SuiteID)
+	if err != nil {
+		return err
+	}
+	done, err := e.finishedBattlePairs(job.ID)
+	if err != nil {
+		return err
+	}
+
+	current := 0
+	totalCost := 0.0
+	for _, p := range prompts {
+		for i := 0; i < len(p.responses); i++ {
+			for j := i + 1; j < len(p.responses); j++ {
+				if cost, ok := done[battlePair{p.id, p.responses[i].modelID, p.responses[j].modelID}]; ok {
+					current++
+					totalCost += cost
+				}
+			}
+		}
+	}
+	if current > 0 {
+		log.Printf("Job %d resumes after %d of %d battles", job.ID, current, battlePairCount(prompts))
+		e.reportProgress(job, current, totalCost)
+	}
+
+	spend := newJobSpend(job)
+	spend.resume(current, totalCost)
+	failed := 0
+	n := 0
+	for _, p := range prompts {
+		// Battles are judged by the same panel that scores the prompt
+		set := e.judgesForPrompt(job.SuiteID, p.id)
+		for i := 0; i < len(p.responses); i++ {
+			for j := i + 1; j < len(p.responses); j++ {
+				pair := battlePair{p.id, p.responses[i].modelID, p.responses[j].modelID}
+				// Alternate presentation order to average out position bias
+				a, b := p.responses[i], p.responses[j]
+				if n%2 == 1 {
+					a, b = b, a
+				}
+				n++
+				if _, ok := done[pair]; ok {
+					continue
+				}
+
+				if ctx.Err() != nil {
+					return errJobCancelled
+				}
+				if err := e.waitForJudgeService(ctx, job); err != nil {
+					return err
+				}
+				if err := e.waitForBudget(ctx, job, spend); err != nil {
+					return err
+				}
+
+				spend.start()
+				cost, err := e.judgeBattle(ctx, job.ID, job.SuiteID, set, p, a, b)
+				spend.finish(cost)
+				e.recordSpend(job.SuiteID, cost)
+				totalCost += cost
+				if ctx.Err() != nil {
+					return errJobCancelled
+				}
+				// A pair no judge could decide is not checkpointed, so a resumed job
+				// tries it again
+				if err != nil {
+					log.Printf("Failed to judge battle (prompt %d, models %d vs %d): %v", p.id, a.modelID, b.modelID, err)
+					e.recordPairFailure(job.ID, a.modelID, p.id, fmt.Errorf("battle against model %d: %w", b.modelID, err))
+					failed++
+				}
+
+				current++
+				e.reportProgress(job, current, totalCost)
+			}
+		}
+	}
+
+	if failed > 0 && failed == current {
+		return fmt.Errorf("all %d battles failed", failed)
+	}
+	summarizeFailedPairs(job, failed, current)
+	return nil
+}
+
+// battlePair identifies a pair of a battle job: a prompt and two models, lower ID
+// first whichever was presented first
+type battlePair struct {
+	promptID int
+	modelA   int
+	modelB   int
+}
+
+// finishedBattlePairs returns the cost of each pair an earlier run of the job judged
+func (e *Evaluator) finishedBattlePairs(jobID int) (map[battlePair]float64, error) {
+	rows, err := e.db.Query(`
+		SELECT prompt_id, model_a_id, model_b_id, cost_usd FROM battle_job_pairs WHERE job_id = ?
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query judged battles: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	done := make(map[battlePair]float64)
+	for rows.Next() {
+		var p battlePair
+		var cost float64
+		if err := rows.Scan(&p.promptID, &p.modelA, &p.modelB, &cost); err != nil {
+			return nil, fmt.Errorf("failed to scan judged battle: %w", err)
+		}
+		done[p] = cost
+	}
+	return done, rows.Err()
+}
+
+// judgeBattle asks every provider of the prompt's judges to decide one battle and
+// records a battle per provider. Nothing is recorded when ctx is cancelled part way, so
+// the pair is judged again, nor when every judge fails, which returns the first error.
+func (e *Evaluator) judgeBattle(ctx context.Context, jobID, suiteID int, set judgeSet, p battlePrompt, a, b battleResponse) (float64, error) {
+	req := p.request
+	req.ResponseA, req.ResponseB = a.text, b.text
+
+	_, breaker := e.judgeRetry()
+	release, err := acquireJudgeCall(ctx, breaker)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	native := nativeJudgeNames(set.providers)
+
+	var verdicts []judgedBattle
+	var firstErr error
+	cost := 0.0
+	for _, provider := range set.providers {
+		judges, run := judgesFor(provider, native, set.judges)
+		if !run {
+			continue
+		}
+		verdict, err := e.comparePair(ctx, provider, judges, req)
+		if err != nil {
+			log.Printf("Judge %s failed on battle (prompt %d, models %d vs %d): %v", provider.Name(), p.id, a.modelID, b.modelID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		cost += verdict.CostUSD
+		verdicts = append(verdicts, judgedBattle{provider.Name(), verdict.Outcome})
+	}
+	if ctx.Err() != nil {
+		return cost, ctx.Err()
+	}
+	if len(verdicts) == 0 {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("no judge providers available")
+		}
+		recordJudgeCall(breaker, firstErr)
+		return cost, firstErr
+	}
+	recordJudgeCall(breaker, nil)
+
+	pair := battlePair{p.id, a.modelID, b.modelID}
+	if pair.modelA > pair.modelB {
+		pair.modelA, pair.modelB = pair.modelB, pair.modelA
+	}
+	if err := e.recordBattles(jobID, suiteID, pair, a.modelID, b.modelID, verdicts, cost); err != nil {
+		log.Printf("Failed to record battles (prompt %d, models %d vs %d): %v", p.id, a.modelID, b.modelID, err)
+	}
+	return cost, nil
+}
+
+// judgedBattle is one judge's verdict on a battle
+type judgedBattle struct {
+	judge   string
+	outcome string
+}
+
+// recordBattles stores the verdicts on a pair together with its checkpoint. Battles an
+// earlier run of the job recorded for the pair are replaced, so a restarted job does
+// not count them twice.
+func (e *Evaluator) recordBattles(jobID, suiteID int, pair battlePair, modelA, modelB int, verdicts []judgedBattle, cost float64) error {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`
+		DELETE FROM battles WHERE job_id = ? AND prompt_id = ?
+			AND ((model_a_id = ? AND model_b_id = ?) OR (model_a_id = ? AND model_b_id = ?))
+	`, jobID, pair.promptID, pair.modelA, pair.modelB, pair.modelB, pair.modelA); err != nil {
+		return fmt.Errorf("failed to replace battles: %w", err)
+	}
+	for _, v := range verdicts {
+		if _, err := tx.Exec(`
+			INSERT INTO battles (suite_id, prompt_id, model_a_id, model_b_id, outcome, judge, job_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, suiteID, pair.promptID, modelA, modelB, v.outcome, v.judge, jobID); err != nil {
+			return fmt.Errorf("failed to record battle: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO battle_job_pairs (job_id, prompt_id, model_a_id, model_b_id, cost_usd)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(job_id, prompt_id, model_a_id, model_b_id) DO UPDATE SET
+			cost_usd = excluded.cost_usd,
+			updated_at = CURRENT_TIMESTAMP
+	`, jobID, pair.promptID, pair.modelA, pair.modelB, cost); err != nil {
+		return fmt.Errorf("failed to checkpoint battle: %w", err)
+	}
+	return tx.Commit()
+}
+
+// comparePair decides a battle with one provider, falling back to comparing absolute
+// scores. Calls are retried and rate limited like the evaluation calls of runJudgesWith.
+func (e *Evaluator) comparePair(ctx context.Context, provider JudgeProvider, judges []string, req PairwiseRequest) (*PairwiseVerdict, error) {
+	policy, _ := e.judgeRetry()
+	limiter := e.rateLimiter(provider)
+	if pj, ok := provider.(PairwiseJudge); ok {
+		both := EvaluationRequest{Prompt: req.Prompt, Response: req.ResponseA + req.ResponseB, Solution: req.Solution}
+		var verdict *PairwiseVerdict
+		err := callWithRetry(ctx, provider.Name(), policy, limiter, estimateTokens(both, 1), func() error {
+			var err error
+			verdict, err = pj.ComparePair(ctx, req)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return verdict, nil
+	}
+
+	evalReq := EvaluationRequest{
+		Prompt:   req.Prompt,
+		Solution: req.Solution,
+		Type:     judgeMode(req.Type),
+		Turns:    req.Turns,
+		Judges:   judges,
+	}
+	apiKeys, err := e.getAPIKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	evalReq.APIKeys = apiKeys
+
+	evalReq.Response = req.ResponseA
+	tokens := estimateTokens(evalReq, judgeCount(provider, judges))
+	respA, err := evaluateWithRetry(ctx, provider, evalReq, policy, limiter, tokens)
+	if err != nil {
+		return nil, err
+	}
+	evalReq.Response = req.ResponseB
+	tokens = estimateTokens(evalReq, judgeCount(provider, judges))
+	respB, err := evaluateWithRetry(ctx, provider, evalReq, policy, limiter, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := &PairwiseVerdict{
+		Outcome:   middleware.BattleTie,
+		Reasoning: fmt.Sprintf("scores %d vs %d", respA.ConsensusScore, respB.ConsensusScore),
+		CostUSD:   respA.TotalCostUSD + respB.TotalCostUSD,
+	}
+	diff := respA.ConsensusScore - respB.ConsensusScore
+	if diff >= battleTieMargin {
+		verdict.Outcome = middleware.BattleWinA
+	} else if -diff >= battleTieMargin {
+		verdict.Outcome = middleware.BattleWinB
+	}
+	return verdict, nil
+}
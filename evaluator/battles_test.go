@@ -0,0 +1,361 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"llm-tournament/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupBattleTestDB extends the evaluator schema with battles and three models' responses
+func setupBattleTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := setupEvaluatorTestDB(t)
+	_, err := db.Exec(`
+		CREATE TABLE battles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			suite_id INTEGER NOT NULL,
+			prompt_id INTEGER,
+			model_a_id INTEGER NOT NULL,
+			model_b_id INTEGER NOT NULL,
+			outcome TEXT NOT NULL,
+			judge TEXT NOT NULL DEFAULT 'human',
+			job_id INTEGER,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO models (id, name, suite_id) VALUES (1, 'm1', 1), (2, 'm2', 1), (3, 'm3', 1);
+		INSERT INTO prompts (id, text, suite_id, display_order) VALUES (1, 'Q1', 1, 0), (2, 'Q2', 1, 1);
+		INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES
+			(1, 1, 'good'), (2, 1, 'bad'), (3, 1, 'good'),
+			(1, 2, 'good'), (2, 2, '');
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'battle_all');
+	`)
+	if err != nil {
+		t.Fatalf("failed to extend schema: %v", err)
+	}
+	return db
+}
+
+// scoringProvider scores "good" responses 90 and everything else 20
+type scoringProvider struct{ name string }
+
+func (s *scoringProvider) Name() string { return s.name }
+
+func (s *scoringProvider) Evaluate(_ context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	score := 20
+	if req.Response == "good" {
+		score = 90
+	}
+	return &EvaluationResponse{ConsensusScore: score, TotalCostUSD: 0.01}, nil
+}
+
+func TestParsePairwiseOutput(t *testing.T) {
+	tests := []struct {
+		text    string
+		outcome string
+		wantErr bool
+	}{
+		{`{"winner": "A", "reasoning": "x"}`, middleware.BattleWinA, false},
+		{"Sure: {\"winner\": \"b\"}", middleware.BattleWinB, false},
+		{`{"winner": "Tie"}`, middleware.BattleTie, false},
+		{`{"winner": "both"}`, "", true},
+		{`no json`, "", true},
+	}
+	for _, tt := range tests {
+		verdict, err := parsePairwiseOutput(tt.text)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expected error for %q", tt.text)
+			}
+			continue
+		}
+		if err != nil || verdict.Outcome != tt.outcome {
+			t.Errorf("parsePairwiseOutput(%q) = %+v, %v; want %s", tt.text, verdict, err, tt.outcome)
+		}
+	}
+}
+
+func TestOpenAIProvider_ComparePair(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body openAIChatRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		prompt = body.Messages[1].Content
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": `{"winner": "B", "reasoning": "more complete"}`}}},
+			"usage":   map[string]int{"prompt_tokens": 1000, "completion_tokens": 1000},
+		})
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider(JudgeConfig{Name: "gpt", BaseURL: server.URL, InputCostPer1K: 0.01, OutputCostPer1K: 0.03})
+	verdict, err := p.ComparePair(context.Background(), PairwiseRequest{Prompt: "2+2?", ResponseA: "five", ResponseB: "four"})
+	if err != nil {
+		t.Fatalf("ComparePair failed: %v", err)
+	}
+	if verdict.Outcome != middleware.BattleWinB {
+		t.Errorf("expected B to win, got %s", verdict.Outcome)
+	}
+	if verdict.CostUSD < 0.0399 || verdict.CostUSD > 0.0401 {
+		t.Errorf("expected cost 0.04, got %f", verdict.CostUSD)
+	}
+	if !strings.Contains(prompt, "**RESPONSE A:**\nfive") || !strings.Contains(prompt, "**RESPONSE B:**\nfour") {
+		t.Errorf("pairwise prompt missing responses: %s", prompt)
+	}
+	if strings.Contains(prompt, "EXPECTED SOLUTION") {
+		t.Error("solution section should be omitted when there is no solution")
+	}
+}
+
+func TestRunJudgeBattles_CountsPairs(t *testing.T) {
+	db := setupBattleTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	jobID, err := e.RunJudgeBattles(1)
+	if err != nil {
+		t.Fatalf("RunJudgeBattles failed: %v", err)
+	}
+
+	var total int
+	if err := db.QueryRow("SELECT progress_total FROM evaluation_jobs WHERE id = ?", jobID).Scan(&total); err != nil {
+		t.Fatalf("failed to read job: %v", err)
+	}
+	// Prompt 1 has three responses (3 pairs); prompt 2 has only one non-empty response
+	if total != 3 {
+		t.Errorf("expected 3 pairs, got %d", total)
+	}
+}
+
+func TestRunJudgeBattles_NoPairs(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	if _, err := e.RunJudgeBattles(1); err == nil {
+		t.Error("expected error when no prompt has two responses")
+	}
+}
+
+func TestProcessBattleJob_ScoreFallback(t *testing.T) {
+	db := setupBattleTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	e.judges = []string{"j1"}
+	e.SetJudgeProviders(&scoringProvider{name: "j1"})
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeBattleAll, ProgressTotal: 3}
+	if err := e.processBattleJob(context.Background(), job); err != nil {
+		t.Fatalf("processBattleJob failed: %v", err)
+	}
+
+	rows, err := db.Query("SELECT model_a_id, model_b_id, outcome, judge, job_id FROM battles ORDER BY id")
+	if err != nil {
+		t.Fatalf("failed to query battles: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	wins := map[int]int{}
+	count := 0
+	for rows.Next() {
+		var a, b, jobID int
+		var outcome, judge string
+		if err := rows.Scan(&a, &b, &outcome, &judge, &jobID); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		if judge != "j1" || jobID != 1 {
+			t.Errorf("unexpected judge/job: %s/%d", judge, jobID)
+		}
+		switch outcome {
+		case middleware.BattleWinA:
+			wins[a]++
+		case middleware.BattleWinB:
+			wins[b]++
+		}
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 battles, got %d", count)
+	}
+	// m1 and m3 both beat m2 and tie each other
+	if wins[1] != 1 || wins[3] != 1 || wins[2] != 0 {
+		t.Errorf("unexpected wins: %v", wins)
+	}
+
+	var progress int
+	var cost float64
+	if err := db.QueryRow("SELECT progress_current, actual_cost_usd FROM evaluation_jobs WHERE id = 1").Scan(&progress, &cost); err != nil {
+		t.Fatalf("failed to read job: %v", err)
+	}
+	if progress != 3 || cost < 0.0599 || cost > 0.0601 {
+		t.Errorf("expected progress 3 and cost 0.06, got %d and %f", progress, cost)
+	}
+}
+
+func TestProcessBattleJob_ResumeSkipsJudgedPairs(t *testing.T) {
+	db := setupBattleTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	// An earlier run judged m1 vs m2 on prompt 1 before it was stopped
+	for _, stmt := range []string{
+		"INSERT INTO battles (suite_id, prompt_id, model_a_id, model_b_id, outcome, judge, job_id) VALUES (1, 1, 1, 2, 'a', 'j1', 1)",
+		"INSERT INTO battle_job_pairs (job_id, prompt_id, model_a_id, model_b_id, cost_usd) VALUES (1, 1, 1, 2, 0.02)",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.judges = []string{"j1"}
+	e.SetJudgeProviders(&scoringProvider{name: "j1"})
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeBattleAll, ProgressTotal: 3}
+	if err := e.processBattleJob(context.Background(), job); err != nil {
+		t.Fatalf("processBattleJob failed: %v", err)
+	}
+
+	var battles, m1m2 int
+	_ = db.QueryRow("SELECT COUNT(*) FROM battles").Scan(&battles)
+	_ = db.QueryRow("SELECT COUNT(*) FROM battles WHERE model_a_id + model_b_id = 3").Scan(&m1m2)
+	if battles != 3 || m1m2 != 1 {
+		t.Errorf("expected the judged pair to keep its single battle, got %d battles (%d for m1 vs m2)", battles, m1m2)
+	}
+	var progress int
+	var cost float64
+	_ = db.QueryRow("SELECT progress_current, actual_cost_usd FROM evaluation_jobs WHERE id = 1").Scan(&progress, &cost)
+	if progress != 3 || cost < 0.0599 || cost > 0.0601 {
+		t.Errorf("expected progress 3 and cost 0.06 including the earlier run, got %d and %f", progress, cost)
+	}
+
+	// A restart judges every pair again, replacing the job's battles
+	if err := e.jobQueue.resetJobPairs(1); err != nil {
+		t.Fatalf("resetJobPairs failed: %v", err)
+	}
+	if err := e.processBattleJob(context.Background(), job); err != nil {
+		t.Fatalf("processBattleJob failed: %v", err)
+	}
+	_ = db.QueryRow("SELECT COUNT(*) FROM battles").Scan(&battles)
+	if battles != 3 {
+		t.Errorf("expected a restart to replace the battles, got %d", battles)
+	}
+}
+
+func TestProcessBattleJob_Cancelled(t *testing.T) {
+	db := setupBattleTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(&scoringProvider{name: "j1"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeBattleAll, ProgressTotal: 3}
+	if err := e.processBattleJob(ctx, job); err == nil {
+		t.Error("expected cancellation error")
+	}
+}
+
+func TestProcessBattleJob_UsesJudgePanel(t *testing.T) {
+	db := setupBattleTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	_, err := db.Exec(`
+		INSERT INTO judges (name, provider, model) VALUES ('panelist', 'openai', 'gpt-5-mini');
+		INSERT INTO judge_panels (name) VALUES ('default');
+		INSERT INTO judge_panel_members (panel_id, judge_id) SELECT 1, id FROM judges;
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed panel: %v", err)
+	}
+	original := newJudgeProvider
+	newJudgeProvider = func(cfg JudgeConfig) (JudgeProvider, error) {
+		return &scoringProvider{name: cfg.Name}, nil
+	}
+	defer func() { newJudgeProvider = original }()
+
+	e := newGeneratorTestEvaluator(db)
+	e.judges = []string{"j1"}
+	e.SetJudgeProviders(&scoringProvider{name: "j1"})
+	e.SetAPIKeyLookup(func(provider string) (string, error) { return "sk-" + provider, nil })
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeBattleAll, ProgressTotal: 3}
+	if err := e.processBattleJob(context.Background(), job); err != nil {
+		t.Fatalf("processBattleJob failed: %v", err)
+	}
+
+	var panelist, others int
+	_ = db.QueryRow("SELECT COUNT(*) FROM battles WHERE judge = 'panelist'").Scan(&panelist)
+	_ = db.QueryRow("SELECT COUNT(*) FROM battles WHERE judge != 'panelist'").Scan(&others)
+	if panelist != 3 || others != 0 {
+		t.Errorf("expected the panel judge to decide all 3 battles, got %d (and %d by other judges)", panelist, others)
+	}
+}
+
+func TestProcessBattleJob_AllJudgesFail(t *testing.T) {
+	db := setupBattleTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	e.judges = []string{"j1"}
+	e.SetJudgeProviders(&stubProvider{name: "j1", err: errTest})
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeBattleAll, ProgressTotal: 3}
+	if err := e.processBattleJob(context.Background(), job); err == nil {
+		t.Fatal("expected an error when no battle could be judged")
+	}
+
+	var battles, pairs, failures int
+	_ = db.QueryRow("SELECT COUNT(*) FROM battles").Scan(&battles)
+	_ = db.QueryRow("SELECT COUNT(*) FROM battle_job_pairs WHERE job_id = 1").Scan(&pairs)
+	_ = db.QueryRow("SELECT COUNT(*) FROM evaluation_errors WHERE job_id = 1").Scan(&failures)
+	if battles != 0 || pairs != 0 {
+		t.Errorf("expected nothing recorded or checkpointed, got %d battles and %d pairs", battles, pairs)
+	}
+	if failures != 3 {
+		t.Errorf("expected 3 recorded failures, got %d", failures)
+	}
+}
+
+// flakyScoringProvider fails its first call with a retryable error, then scores like scoringProvider
+type flakyScoringProvider struct {
+	scoringProvider
+	calls int
+}
+
+func (f *flakyScoringProvider) Evaluate(ctx context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	f.calls++
+	if f.calls == 1 {
+		return nil, &StatusError{StatusCode: http.StatusServiceUnavailable}
+	}
+	return f.scoringProvider.Evaluate(ctx, req)
+}
+
+func TestProcessBattleJob_RetriesTransientErrors(t *testing.T) {
+	stubRetrySleep(t)
+	db := setupBattleTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	e.judges = []string{"j1"}
+	e.SetJudgeProviders(&flakyScoringProvider{scoringProvider: scoringProvider{name: "j1"}})
+	e.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeBattleAll, ProgressTotal: 3}
+	if err := e.processBattleJob(context.Background(), job); err != nil {
+		t.Fatalf("processBattleJob failed: %v", err)
+	}
+
+	var battles, failures int
+	_ = db.QueryRow("SELECT COUNT(*) FROM battles").Scan(&battles)
+	_ = db.QueryRow("SELECT COUNT(*) FROM evaluation_errors").Scan(&failures)
+	if battles != 3 || failures != 0 {
+		t.Errorf("expected the retried call to decide all 3 battles, got %d battles and %d failures", battles, failures)
+	}
+}
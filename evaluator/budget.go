@@ -0,0 +1,174 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"llm-tournament/middleware"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// budgetPollInterval is how often a job paused by a spend cap checks whether the cap
+// was raised (replaced in tests)
+var budgetPollInterval = 30 * time.Second
+
+// jobSpend tracks what a running job has spent. The goroutine handing out pairs reads
+// it to decide whether the next pair fits the budget while results are still coming in.
+type jobSpend struct {
+	mu       sync.Mutex
+	perPair  float64 // Expected cost of a pair until one has finished
+	spent    float64
+	started  int
+	finished int
+}
+
+func newJobSpend(job *EvaluationJob) *jobSpend {
+	s := &jobSpend{}
+	if job.ProgressTotal > 0 {
+		s.perPair = job.EstimatedCost / float64(job.ProgressTotal)
+	}
+	return s
+}
+
+// resume counts the pairs an earlier run of the job finished
+func (s *jobSpend) resume(finished int, spent float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started += finished
+	s.finished += finished
+	s.spent += spent
+}
+
+// start counts a pair handed to a worker
+func (s *jobSpend) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started++
+}
+
+// finish records what a finished pair cost
+func (s *jobSpend) finish(cost float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spent += cost
+	s.finished++
+}
+
+// outlook returns the job's spend so far and what the pairs in flight plus one more
+// are expected to add, at the average cost of the pairs finished so far
+func (s *jobSpend) outlook() (spent, next float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	perPair := s.perPair
+	if s.finished > 0 {
+		perPair = s.spent / float64(s.finished)
+	}
+	return s.spent, float64(s.started-s.finished+1) * perPair
+}
+
+// budgetExceeded returns why the next pair would overspend a cap, or "" when it fits.
+// Caps are read on every check so raising one on the settings page resumes the job.
+func (e *Evaluator) budgetExceeded(job *EvaluationJob, spend *jobSpend) string {
+	spent, next := spend.outlook()
+	if limit := parseBudget(e.setting("budget_job_usd")); limit > 0 && spent+next > limit {
+		return fmt.Sprintf("Job budget of $%.2f reached after spending $%.2f; raise it on the settings page to continue", limit, spent)
+	}
+	if limit := parseBudget(e.suiteSetting(job.SuiteID, middleware.SuiteSettingMonthlyBudget)); limit > 0 {
+		if month := e.monthSpend(job.SuiteID, time.Now()); month+next > limit {
+			return fmt.Sprintf("Monthly suite budget of $%.2f reached after spending $%.2f; raise it on the settings page to continue", limit, month)
+		}
+	}
+	return ""
+}
+
+// waitForBudget holds a job while the next pair would take it past the per-job or
+// monthly suite cap, marking it paused until a cap is raised or the job is cancelled
+func (e *Evaluator) waitForBudget(ctx context.Context, job *EvaluationJob, spend *jobSpend) error {
+	reason := e.budgetExceeded(job, spend)
+	if reason == "" {
+		return nil
+	}
+
+	log.Printf("Job %d paused: %s", job.ID, reason)
+	if err := e.jobQueue.setJobStatus(job.ID, "paused", reason); err != nil {
+		log.Printf("Failed to pause job %d: %v", job.ID, err)
+	}
+	for reason != "" {
+		select {
+		case <-ctx.Done():
+			return errJobCancelled
+		case <-time.After(budgetPollInterval):
+		}
+		reason = e.budgetExceeded(job, spend)
+	}
+	if err := e.jobQueue.setJobStatus(job.ID, "running", ""); err != nil {
+		log.Printf("Failed to resume job %d: %v", job.ID, err)
+	}
+	return nil
+}
+
+// recordSpend adds cost to the suite's spend for today and broadcasts a cost alert
+// when it takes the month's spend across the alert threshold
+func (e *Evaluator) recordSpend(suiteID int, cost float64) {
+	if cost <= 0 {
+		return
+	}
+	now := time.Now()
+	before := e.monthSpend(suiteID, now)
+	_, err := e.db.Exec(`
+		INSERT INTO cost_tracking (suite_id, date, total_cost_usd, evaluation_count)
+		VALUES (?, ?, ?, 1)
+		ON CONFLICT(suite_id, date) DO UPDATE SET
+			total_cost_usd = total_cost_usd + excluded.total_cost_usd,
+			evaluation_count = evaluation_count + 1
+	`, suiteID, now.Format(time.DateOnly), cost)
+	if err != nil {
+		log.Printf("Failed to record spend for suite %d: %v", suiteID, err)
+		return
+	}
+
+	threshold := parseBudget(e.setting("cost_alert_threshold_usd"))
+	if after := before + cost; threshold > 0 && before < threshold && after >= threshold {
+		log.Printf("Suite %d has spent $%.2f this month, past the $%.2f alert threshold", suiteID, after, threshold)
+		if alert := e.jobEvents().CostAlert; alert != nil {
+			alert(suiteID, after, threshold)
+		}
+	}
+}
+
+// monthSpend returns what a suite has spent since the start of now's month
+func (e *Evaluator) monthSpend(suiteID int, now time.Time) float64 {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	var total float64
+	err := e.db.QueryRow(`
+		SELECT COALESCE(SUM(total_cost_usd), 0)
+		FROM cost_tracking
+		WHERE suite_id = ? AND date >= ?
+	`, suiteID, monthStart.Format(time.DateOnly)).Scan(&total)
+	if err != nil {
+		log.Printf("Failed to read spend for suite %d: %v", suiteID, err)
+	}
+	return total
+}
+
+// setting reads a global setting ("" when unset)
+func (e *Evaluator) setting(key string) string {
+	var value string
+	err := e.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Failed to read %s: %v", key, err)
+	}
+	return value
+}
+
+// parseBudget reads a dollar amount; empty or invalid values mean no limit
+func parseBudget(value string) float64 {
+	usd, err := strconv.ParseFloat(value, 64)
+	if err != nil || usd < 0 {
+		return 0
+	}
+	return usd
+}
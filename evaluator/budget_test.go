@@ -0,0 +1,188 @@
+package evaluator
+
+import (
+	"context"
+	"llm-tournament/middleware"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestJobSpend_Outlook(t *testing.T) {
+	s := newJobSpend(&EvaluationJob{ProgressTotal: 4, EstimatedCost: 0.2})
+
+	if spent, next := s.outlook(); spent != 0 || math.Abs(next-0.05) > 1e-9 {
+		t.Errorf("expected the job estimate before any pair finished, got spent %f next %f", spent, next)
+	}
+
+	s.start()
+	s.start()
+	s.finish(0.1)
+	// One pair in flight plus the next, at the 0.1 average of the finished pair
+	if spent, next := s.outlook(); spent != 0.1 || math.Abs(next-0.2) > 1e-9 {
+		t.Errorf("expected spent 0.1 and next 0.2, got %f and %f", spent, next)
+	}
+}
+
+func TestRecordSpend_AccumulatesAndAlertsOnce(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	if _, err := db.Exec("INSERT INTO settings (key, value) VALUES ('cost_alert_threshold_usd', '0.05')"); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	var alerts []float64
+	e := newGeneratorTestEvaluator(db)
+	e.SetJobEvents(JobEvents{CostAlert: func(suiteID int, currentCost, threshold float64) {
+		if suiteID != 1 || threshold != 0.05 {
+			t.Errorf("unexpected alert for suite %d at threshold %f", suiteID, threshold)
+		}
+		alerts = append(alerts, currentCost)
+	}})
+	for _, cost := range []float64{0.03, 0, 0.03, 0.03} {
+		e.recordSpend(1, cost)
+	}
+
+	var total float64
+	var count int
+	var date string
+	err := db.QueryRow("SELECT CAST(date AS TEXT), total_cost_usd, evaluation_count FROM cost_tracking WHERE suite_id = 1").Scan(&date, &total, &count)
+	if err != nil {
+		t.Fatalf("failed to query cost_tracking: %v", err)
+	}
+	if date != time.Now().Format(time.DateOnly) || math.Abs(total-0.09) > 1e-9 || count != 3 {
+		t.Errorf("expected one row for today with $0.09 over 3 evaluations, got %s $%f over %d", date, total, count)
+	}
+	if len(alerts) != 1 || math.Abs(alerts[0]-0.06) > 1e-9 {
+		t.Errorf("expected a single alert when spend reached $0.06, got %v", alerts)
+	}
+}
+
+func TestMonthSpend_IgnoresEarlierMonths(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.Local)
+	if _, err := db.Exec(`
+		INSERT INTO cost_tracking (suite_id, date, total_cost_usd, evaluation_count) VALUES
+			(1, '2026-02-28', 5, 1),
+			(1, '2026-03-01', 2, 1),
+			(1, '2026-03-15', 1.5, 1),
+			(2, '2026-03-10', 7, 1)
+	`); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	if got := e.monthSpend(1, now); got != 3.5 {
+		t.Errorf("expected $3.50 spent in March, got %f", got)
+	}
+}
+
+func TestBudgetExceeded(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	e := newGeneratorTestEvaluator(db)
+	job := &EvaluationJob{ID: 1, SuiteID: 1, ProgressTotal: 10, EstimatedCost: 1}
+
+	if reason := e.budgetExceeded(job, newJobSpend(job)); reason != "" {
+		t.Errorf("expected no cap without budgets, got %q", reason)
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO suite_settings (suite_id, key, value) VALUES (1, ?, '5');
+		INSERT INTO cost_tracking (suite_id, date, total_cost_usd, evaluation_count) VALUES (1, ?, 4.95, 1);
+	`, middleware.SuiteSettingMonthlyBudget, time.Now().Format(time.DateOnly)); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if reason := e.budgetExceeded(job, newJobSpend(job)); reason == "" {
+		t.Error("expected the monthly budget to stop a $0.10 pair at $4.95 of $5")
+	}
+
+	if _, err := db.Exec("UPDATE suite_settings SET value = '' WHERE key = ?", middleware.SuiteSettingMonthlyBudget); err != nil {
+		t.Fatalf("failed to clear monthly budget: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO settings (key, value) VALUES ('budget_job_usd', '0.25')"); err != nil {
+		t.Fatalf("failed to set job budget: %v", err)
+	}
+	spend := newJobSpend(job)
+	spend.start()
+	spend.finish(0.1)
+	if reason := e.budgetExceeded(job, spend); reason != "" {
+		t.Errorf("expected a second pair to fit $0.25, got %q", reason)
+	}
+	spend.start()
+	spend.finish(0.1)
+	if reason := e.budgetExceeded(job, spend); reason == "" {
+		t.Error("expected the job budget to stop a third $0.10 pair")
+	}
+}
+
+func TestEvaluatePairs_PausesAtJobBudget(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('m1', 1), ('m2', 1)",
+		"INSERT INTO prompts (text, suite_id, display_order) VALUES ('p1', 1, 0), ('p2', 1, 1)",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) SELECT m.id, p.id, 'r' FROM models m, prompts p",
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+		"INSERT INTO settings (key, value) VALUES ('budget_job_usd', '0.025')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	orig := budgetPollInterval
+	budgetPollInterval = 10 * time.Millisecond
+	defer func() { budgetPollInterval = orig }()
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(&stubProvider{name: "stub", resp: &EvaluationResponse{ConsensusScore: 80, TotalCostUSD: 0.01}})
+	e.SetConcurrency(1)
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 4, EstimatedCost: 0.04}
+	done := make(chan error, 1)
+	go func() { done <- e.processAllJob(context.Background(), job) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	var status, message string
+	var current int
+	for time.Now().Before(deadline) {
+		_ = db.QueryRow("SELECT status, COALESCE(error_message, ''), progress_current FROM evaluation_jobs WHERE id = 1").Scan(&status, &message, &current)
+		if status == "paused" {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status != "paused" || current != 2 {
+		t.Fatalf("expected the job paused after 2 pairs, got status %q after %d pairs", status, current)
+	}
+	if message == "" {
+		t.Error("expected the pause to say which budget was reached")
+	}
+
+	if _, err := db.Exec("UPDATE settings SET value = '1' WHERE key = 'budget_job_usd'"); err != nil {
+		t.Fatalf("failed to raise budget: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("processAllJob failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not resume after the budget was raised")
+	}
+
+	var cost float64
+	_ = db.QueryRow("SELECT status, progress_current, actual_cost_usd FROM evaluation_jobs WHERE id = 1").Scan(&status, &current, &cost)
+	if status != "running" || current != 4 || math.Abs(cost-0.04) > 1e-9 {
+		t.Errorf("expected the job running again with 4 pairs for $0.04, got %q, %d, %f", status, current, cost)
+	}
+	var tracked float64
+	_ = db.QueryRow("SELECT total_cost_usd FROM cost_tracking WHERE suite_id = 1").Scan(&tracked)
+	if math.Abs(tracked-0.04) > 1e-9 {
+		t.Errorf("expected $0.04 recorded in cost_tracking, got %f", tracked)
+	}
+}
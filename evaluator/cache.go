@@ -0,0 +1,117 @@
+package evaluator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"slices"
+)
+
+// evaluationCacheKey hashes everything a judge's verdict depends on: the prompt,
+// its solution, the response, the prompt type, the rubric, the conversation, the
+// judge and the judge prompt version. Any change to one of them gives a new key, so
+// stale verdicts are never reused. Judges graded by the Python service carry the
+// version of its templates in their identity (see judgeCacheIdentity).
+func evaluationCacheKey(req EvaluationRequest, judge string) string {
+	key := []string{req.Prompt, req.Solution, req.Response, req.Type, judge, judgePromptVersion(req.Type)}
+	// Prompts without a rubric, conversation or system prompt keep the keys they had before any existed
+	if len(req.Rubric) > 0 {
+		rubric, _ := json.Marshal(req.Rubric)
+		key = append(key, string(rubric))
+	}
+	if len(req.Turns) > 0 {
+		turns, _ := json.Marshal(req.Turns)
+		key = append(key, "turns", string(turns))
+	}
+	if req.SystemPrompt != "" {
+		key = append(key, "system_prompt", req.SystemPrompt)
+	}
+	fields, _ := json.Marshal(key)
+	sum := sha256.Sum256(fields)
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedVerdicts returns the verdict each judge last gave for the same request, or
+// nil unless every judge of the set has one. Only verdicts a judge actually produced
+// are reused, never copies made from the cache. saved is what those verdicts cost.
+func (e *Evaluator) cachedVerdicts(ctx context.Context, req EvaluationRequest, set judgeSet) (results []JudgeResult, saved float64) {
+	judges := verdictJudges(req.Judges, set.providers)
+	if len(judges) == 0 {
+		return nil, 0
+	}
+	for _, judge := range judges {
+		r := JudgeResult{Judge: judge}
+		var criteria string
+		err := e.db.QueryRow(`
+			SELECT judge_score, judge_confidence, judge_reasoning, cost_usd, criteria
+			FROM evaluation_history
+			WHERE cache_key = ? AND cache_hit = 0
+			ORDER BY id DESC
+			LIMIT 1
+		`, evaluationCacheKey(req, e.judgeCacheIdentity(ctx, set, judge))).Scan(&r.Score, &r.Confidence, &r.Reasoning, &r.CostUSD, &criteria)
+		if err != nil {
+			return nil, 0
+		}
+		if criteria != "" {
+			if err := json.Unmarshal([]byte(criteria), &r.Criteria); err != nil {
+				return nil, 0
+			}
+		}
+		saved += r.CostUSD
+		r.CostUSD = 0
+		results = append(results, r)
+	}
+	return results, saved
+}
+
+// judgeCacheIdentity is what the cache keys a judge's verdicts by. A judge the Python
+// service grades also carries the service's prompt version, as the Go side cannot see
+// those templates.
+func (e *Evaluator) judgeCacheIdentity(ctx context.Context, set judgeSet, judge string) string {
+	id := set.cacheIdentity(judge)
+	if nativeJudgeNames(set.providers)[judge] {
+		return id
+	}
+	for _, p := range set.providers {
+		if c, ok := p.(*LiteLLMClient); ok {
+			return id + "\x00python_prompts:" + c.PromptVersion(ctx)
+		}
+	}
+	return id
+}
+
+// verdictJudges lists the judges that grade a request: the requested judges plus
+// the judge of every native provider, which runs whatever the list says
+func verdictJudges(judges []string, providers []JudgeProvider) []string {
+	all := append([]string(nil), judges...)
+	for _, p := range providers {
+		if _, ok := p.(*LiteLLMClient); !ok && !slices.Contains(all, p.Name()) {
+			all = append(all, p.Name())
+		}
+	}
+	return all
+}
+
+// forceRefresh reports whether a job asked to bypass the evaluation cache
+func (e *Evaluator) forceRefresh(jobID int) bool {
+	var force bool
+	if err := e.db.QueryRow("SELECT force_refresh FROM evaluation_jobs WHERE id = ?", jobID).Scan(&force); err != nil {
+		return false
+	}
+	return force
+}
+
+// recordCacheUse counts a judged pair as a cache hit or miss on its job
+func (e *Evaluator) recordCacheUse(jobID int, hit bool, saved float64) {
+	query := "UPDATE evaluation_jobs SET cache_misses = cache_misses + 1 WHERE id = ?"
+	args := []interface{}{jobID}
+	if hit {
+		query = "UPDATE evaluation_jobs SET cache_hits = cache_hits + 1, cache_saved_usd = cache_saved_usd + ? WHERE id = ?"
+		args = []interface{}{saved, jobID}
+	}
+	if _, err := e.db.Exec(query, args...); err != nil {
+		log.Printf("Failed to record cache use for job %d: %v", jobID, err)
+	}
+}
@@ -0,0 +1,180 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"llm-tournament/middleware"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingJudge is a native judge that scores every response 80 and counts its calls
+type countingJudge struct {
+	calls int
+}
+
+func (c *countingJudge) Name() string { return "counting" }
+
+func (c *countingJudge) Evaluate(_ context.Context, _ EvaluationRequest) (*EvaluationResponse, error) {
+	c.calls++
+	return &EvaluationResponse{
+		Results:        []JudgeResult{{Judge: "counting", Score: 80, Confidence: 0.9, Reasoning: "fine", CostUSD: 0.02}},
+		ConsensusScore: 80,
+		TotalCostUSD:   0.02,
+	}, nil
+}
+
+func TestEvaluationCacheKey(t *testing.T) {
+	req := EvaluationRequest{Prompt: "p", Solution: "s", Response: "r", Type: "objective"}
+	key := evaluationCacheKey(req, "j1")
+	if key != evaluationCacheKey(req, "j1") {
+		t.Fatal("expected the same request and judge to give the same key")
+	}
+
+	req.APIKeys = map[string]string{"openai": "sk"}
+	req.Judges = []string{"other"}
+	if key != evaluationCacheKey(req, "j1") {
+		t.Error("expected API keys and the judge list not to affect the key")
+	}
+
+	changed := []EvaluationRequest{
+		{Prompt: "p2", Solution: "s", Response: "r", Type: "objective"},
+		{Prompt: "p", Solution: "s2", Response: "r", Type: "objective"},
+		{Prompt: "p", Solution: "s", Response: "r2", Type: "objective"},
+		{Prompt: "p", Solution: "s", Response: "r", Type: "creative"},
+		// Fields are delimited, so moving text between them changes the key
+		{Prompt: "ps", Solution: "", Response: "r", Type: "objective"},
+		{Prompt: "p", Solution: "s", Response: "r", Type: "objective", Rubric: []middleware.RubricCriterion{{Name: "clarity", Weight: 1}}},
+	}
+	for _, c := range changed {
+		if evaluationCacheKey(c, "j1") == key {
+			t.Errorf("expected %+v to change the key", c)
+		}
+	}
+	if evaluationCacheKey(req, "j2") == key {
+		t.Error("expected another judge to change the key")
+	}
+	if judgePromptVersion("creative") == judgePromptVersion("objective") {
+		t.Error("expected the creative and objective judge prompts to have different versions")
+	}
+}
+
+func TestJudgeCacheIdentity_PythonPromptVersion(t *testing.T) {
+	version := "v1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"status": "healthy", "prompt_version": %q}`, version)
+	}))
+	defer server.Close()
+
+	e := &Evaluator{}
+	client := NewLiteLLMClient(server.URL)
+	set := judgeSet{providers: []JudgeProvider{client, &countingJudge{}}}
+
+	python := e.judgeCacheIdentity(context.Background(), set, "claude")
+	if !strings.HasSuffix(python, "python_prompts:v1") {
+		t.Errorf("expected the Python judge to carry the service's prompt version, got %q", python)
+	}
+	if native := e.judgeCacheIdentity(context.Background(), set, "counting"); native != "counting" {
+		t.Errorf("expected a native judge to be keyed by name, got %q", native)
+	}
+
+	// An edited template shows up once the service is asked again
+	version = "v2"
+	client.versionChecked = time.Time{}
+	if e.judgeCacheIdentity(context.Background(), set, "claude") == python {
+		t.Error("expected a new prompt version to change the Python judge's identity")
+	}
+}
+
+func TestEvaluateAll_ReusesCachedVerdicts(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+	// Identical responses to a prompt share verdicts, so give each model its own
+	if _, err := db.Exec("UPDATE model_responses SET response_text = 'r' || model_id"); err != nil {
+		t.Fatalf("failed to vary responses: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	judge := &countingJudge{}
+	e.SetJudgeProviders(judge)
+
+	run := func(force bool) *EvaluationJob {
+		t.Helper()
+		if _, err := e.EvaluateAll(1, force); err != nil {
+			t.Fatalf("EvaluateAll failed: %v", err)
+		}
+		job, err := e.GetJobStatus(runQueuedJob(t, e).ID)
+		if err != nil {
+			t.Fatalf("GetJobStatus failed: %v", err)
+		}
+		return job
+	}
+
+	// m2 × p2 has no response, so three pairs are judged
+	first := run(false)
+	if judge.calls != 3 || first.CacheHits != 0 || first.CacheMisses != 3 {
+		t.Fatalf("expected 3 judged misses, got %d calls and %+v", judge.calls, first)
+	}
+
+	second := run(false)
+	if judge.calls != 3 {
+		t.Errorf("expected an unchanged rerun not to call the judge, got %d calls", judge.calls)
+	}
+	if second.CacheHits != 3 || second.CacheMisses != 0 || second.CacheHitRate() != 1 {
+		t.Errorf("expected every pair served from the cache, got %+v", second)
+	}
+	if math.Abs(second.CacheSavedUSD-0.06) > 1e-9 || second.ActualCost != 0 {
+		t.Errorf("expected $0.06 saved at no cost, got saved %.4f cost %.4f", second.CacheSavedUSD, second.ActualCost)
+	}
+	var score int
+	if err := db.QueryRow("SELECT score FROM scores LIMIT 1").Scan(&score); err != nil || score != 80 {
+		t.Errorf("expected cached verdicts to keep the score 80, got %d (%v)", score, err)
+	}
+	var copies int
+	if err := db.QueryRow("SELECT COUNT(*) FROM evaluation_history WHERE job_id = ? AND cache_hit = 1 AND cost_usd = 0", second.ID).Scan(&copies); err != nil || copies != 3 {
+		t.Errorf("expected 3 free history rows marked as cache hits, got %d (%v)", copies, err)
+	}
+
+	// A changed response is judged again
+	if _, err := db.Exec(`UPDATE model_responses SET response_text = 'revised'
+		WHERE model_id = (SELECT id FROM models WHERE name = 'm1')
+		  AND prompt_id = (SELECT id FROM prompts WHERE text = 'p1')`); err != nil {
+		t.Fatalf("failed to revise response: %v", err)
+	}
+	third := run(false)
+	if judge.calls != 4 || third.CacheHits != 2 || third.CacheMisses != 1 {
+		t.Errorf("expected only the revised pair judged, got %d calls and %+v", judge.calls, third)
+	}
+
+	forced := run(true)
+	if judge.calls != 7 || forced.CacheHits != 0 || !forced.ForceRefresh {
+		t.Errorf("expected a forced run to judge every pair, got %d calls and %+v", judge.calls, forced)
+	}
+}
+
+func TestRestartJob_ResetsCacheCounts(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.Exec(`INSERT INTO evaluation_jobs (id, suite_id, job_type, status, cache_hits, cache_misses, cache_saved_usd)
+		VALUES (1, 1, 'all', 'completed', 2, 1, 0.5)`); err != nil {
+		t.Fatalf("failed to seed job: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	if err := e.RestartJob(1); err != nil {
+		t.Fatalf("RestartJob failed: %v", err)
+	}
+	job, err := e.GetJobStatus(1)
+	if err != nil {
+		t.Fatalf("GetJobStatus failed: %v", err)
+	}
+	if job.CacheHits != 0 || job.CacheMisses != 0 || job.CacheSavedUSD != 0 || job.CacheHitRate() != 0 {
+		t.Errorf("expected restart to clear cache counts, got %+v", job)
+	}
+}
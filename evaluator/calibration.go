@@ -0,0 +1,338 @@
+package evaluator
+
+import (
+	"fmt"
+	"llm-tournament/middleware"
+	"log"
+	"math"
+	"sort"
+)
+
+// calibrationMinSamples is how many gold-labelled cells a judge needs before a
+// correction is applied; below that the judge's scores pass through unchanged
+const calibrationMinSamples = 5
+
+// ScoreCorrection maps a judge's raw score onto the gold (manual) scale
+type ScoreCorrection interface {
+	Correct(score int) int
+}
+
+// LinearCorrection is a least-squares fit of gold score on judge score
+type LinearCorrection struct {
+	Slope     float64 `json:"slope"`
+	Intercept float64 `json:"intercept"`
+}
+
+// Correct applies the fitted line, clamped to 0-100
+func (c LinearCorrection) Correct(score int) int {
+	return clampScore(c.Slope*float64(score) + c.Intercept)
+}
+
+// IsotonicCorrection is a monotone step fit; scores between steps are interpolated
+type IsotonicCorrection struct {
+	JudgeScores []float64 `json:"judge_scores"`
+	GoldScores  []float64 `json:"gold_scores"`
+}
+
+// Correct interpolates between the fitted steps, holding the end values outside them
+func (c IsotonicCorrection) Correct(score int) int {
+	x := float64(score)
+	n := len(c.JudgeScores)
+	if n == 0 {
+		return score
+	}
+	if x <= c.JudgeScores[0] {
+		return clampScore(c.GoldScores[0])
+	}
+	if x >= c.JudgeScores[n-1] {
+		return clampScore(c.GoldScores[n-1])
+	}
+	i := sort.SearchFloat64s(c.JudgeScores, x)
+	x0, x1 := c.JudgeScores[i-1], c.JudgeScores[i]
+	y0, y1 := c.GoldScores[i-1], c.GoldScores[i]
+	return clampScore(y0 + (y1-y0)*(x-x0)/(x1-x0))
+}
+
+func clampScore(v float64) int {
+	return int(math.Round(math.Max(0, math.Min(100, v))))
+}
+
+// FitLinearCorrection fits gold = slope*judge + intercept. A judge that always gives
+// the same score gets a plain offset.
+func FitLinearCorrection(samples []middleware.CalibrationSample) LinearCorrection {
+	n := float64(len(samples))
+	if n == 0 {
+		return LinearCorrection{Slope: 1}
+	}
+	meanX, meanY := 0.0, 0.0
+	for _, s := range samples {
+		meanX += float64(s.JudgeScore)
+		meanY += float64(s.GoldScore)
+	}
+	meanX /= n
+	meanY /= n
+
+	cov, varX := 0.0, 0.0
+	for _, s := range samples {
+		dx := float64(s.JudgeScore) - meanX
+		cov += dx * (float64(s.GoldScore) - meanY)
+		varX += dx * dx
+	}
+	if varX == 0 {
+		return LinearCorrection{Slope: 1, Intercept: meanY - meanX}
+	}
+	slope := cov / varX
+	return LinearCorrection{Slope: slope, Intercept: meanY - slope*meanX}
+}
+
+// FitIsotonicCorrection fits a non-decreasing map from judge to gold score with the
+// pool-adjacent-violators algorithm
+func FitIsotonicCorrection(samples []middleware.CalibrationSample) IsotonicCorrection {
+	type block struct {
+		x, y, weight float64
+	}
+
+	// Average gold scores for identical judge scores first
+	byScore := make(map[int]*block)
+	for _, s := range samples {
+		b := byScore[s.JudgeScore]
+		if b == nil {
+			b = &block{x: float64(s.JudgeScore)}
+			byScore[s.JudgeScore] = b
+		}
+		b.y += float64(s.GoldScore)
+		b.weight++
+	}
+	blocks := make([]block, 0, len(byScore))
+	for _, b := range byScore {
+		b.y /= b.weight
+		blocks = append(blocks, *b)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].x < blocks[j].x })
+
+	var pooled []block
+	for _, b := range blocks {
+		pooled = append(pooled, b)
+		for len(pooled) > 1 && pooled[len(pooled)-2].y > pooled[len(pooled)-1].y {
+			last, prev := pooled[len(pooled)-1], pooled[len(pooled)-2]
+			w := prev.weight + last.weight
+			merged := block{
+				x:      (prev.x*prev.weight + last.x*last.weight) / w,
+				y:      (prev.y*prev.weight + last.y*last.weight) / w,
+				weight: w,
+			}
+			pooled = append(pooled[:len(pooled)-2], merged)
+		}
+	}
+
+	c := IsotonicCorrection{}
+	for _, b := range pooled {
+		c.JudgeScores = append(c.JudgeScores, b.x)
+		c.GoldScores = append(c.GoldScores, b.y)
+	}
+	return c
+}
+
+// fitCorrection fits the named method to one judge's samples
+func fitCorrection(method string, samples []middleware.CalibrationSample) (ScoreCorrection, error) {
+	switch method {
+	case middleware.CalibrationLinear:
+		return FitLinearCorrection(samples), nil
+	case middleware.CalibrationIsotonic:
+		return FitIsotonicCorrection(samples), nil
+	default:
+		return nil, fmt.Errorf("unknown calibration method: %q", method)
+	}
+}
+
+// FitCorrections fits a correction for every judge with enough gold-labelled samples
+func FitCorrections(samples []middleware.CalibrationSample, method string) (map[string]ScoreCorrection, error) {
+	corrections := make(map[string]ScoreCorrection)
+	for judge, judgeSamples := range samplesByJudge(samples) {
+		if len(judgeSamples) < calibrationMinSamples {
+			continue
+		}
+		c, err := fitCorrection(method, judgeSamples)
+		if err != nil {
+			return nil, err
+		}
+		corrections[judge] = c
+	}
+	return corrections, nil
+}
+
+// ApplyCorrections returns a copy of results with each judge's score corrected.
+// The bool reports whether any judge had a correction.
+func ApplyCorrections(results []JudgeResult, corrections map[string]ScoreCorrection) ([]JudgeResult, bool) {
+	corrected := make([]JudgeResult, len(results))
+	applied := false
+	for i, r := range results {
+		corrected[i] = r
+		if c, ok := corrections[r.Judge]; ok {
+			corrected[i].Score = c.Correct(r.Score)
+			applied = true
+		}
+	}
+	return corrected, applied
+}
+
+func samplesByJudge(samples []middleware.CalibrationSample) map[string][]middleware.CalibrationSample {
+	byJudge := make(map[string][]middleware.CalibrationSample)
+	for _, s := range samples {
+		byJudge[s.Judge] = append(byJudge[s.Judge], s)
+	}
+	return byJudge
+}
+
+// CalibrationReport measures each judge against the gold labels in a suite
+type CalibrationReport struct {
+	Method     string             `json:"method"` // Correction applied in consensus ("" = off)
+	MinSamples int                `json:"min_samples"`
+	Judges     []JudgeCalibration `json:"judges"`
+	Curves     []CalibrationCurve `json:"curves"`
+}
+
+// JudgeCalibration is one judge's error against gold, before and after each correction.
+// Corrected errors are in-sample, so they flatter small sample sizes.
+type JudgeCalibration struct {
+	Judge       string           `json:"judge"`
+	Samples     int              `json:"samples"`
+	MAE         float64          `json:"mae"`
+	RMSE        float64          `json:"rmse"`
+	Bias        float64          `json:"bias"` // Mean of (judge - gold); positive means lenient
+	Linear      LinearCorrection `json:"linear"`
+	LinearMAE   float64          `json:"linear_mae"`
+	IsotonicMAE float64          `json:"isotonic_mae"`
+	Applied     bool             `json:"applied"` // Enough samples for the correction to be used
+}
+
+// CalibrationCurve is a reliability curve: mean gold score per judge score bucket
+type CalibrationCurve struct {
+	Judge      string             `json:"judge"`
+	PromptType string             `json:"prompt_type"` // "" for all prompt types
+	Points     []CalibrationPoint `json:"points"`
+}
+
+// CalibrationPoint is one bucket of a calibration curve
+type CalibrationPoint struct {
+	JudgeScore int     `json:"judge_score"`
+	MeanGold   float64 `json:"mean_gold"`
+	Count      int     `json:"count"`
+}
+
+// BuildCalibrationReport computes error statistics and curves for every judge
+func BuildCalibrationReport(samples []middleware.CalibrationSample, method string) CalibrationReport {
+	report := CalibrationReport{Method: method, MinSamples: calibrationMinSamples}
+
+	byJudge := samplesByJudge(samples)
+	judges := make([]string, 0, len(byJudge))
+	for judge := range byJudge {
+		judges = append(judges, judge)
+	}
+	sort.Strings(judges)
+
+	for _, judge := range judges {
+		judgeSamples := byJudge[judge]
+		linear := FitLinearCorrection(judgeSamples)
+		jc := JudgeCalibration{
+			Judge:       judge,
+			Samples:     len(judgeSamples),
+			Linear:      linear,
+			LinearMAE:   correctedMAE(judgeSamples, linear),
+			IsotonicMAE: correctedMAE(judgeSamples, FitIsotonicCorrection(judgeSamples)),
+			Applied:     method != middleware.CalibrationOff && len(judgeSamples) >= calibrationMinSamples,
+		}
+		sqErr := 0.0
+		for _, s := range judgeSamples {
+			diff := float64(s.JudgeScore - s.GoldScore)
+			jc.Bias += diff
+			jc.MAE += math.Abs(diff)
+			sqErr += diff * diff
+		}
+		n := float64(len(judgeSamples))
+		jc.Bias /= n
+		jc.MAE /= n
+		jc.RMSE = math.Sqrt(sqErr / n)
+		report.Judges = append(report.Judges, jc)
+
+		report.Curves = append(report.Curves, CalibrationCurve{Judge: judge, Points: calibrationPoints(judgeSamples)})
+		byType := make(map[string][]middleware.CalibrationSample)
+		for _, s := range judgeSamples {
+			byType[s.PromptType] = append(byType[s.PromptType], s)
+		}
+		types := make([]string, 0, len(byType))
+		for t := range byType {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			if t == "" {
+				continue
+			}
+			report.Curves = append(report.Curves, CalibrationCurve{Judge: judge, PromptType: t, Points: calibrationPoints(byType[t])})
+		}
+	}
+	return report
+}
+
+// correctedMAE is the mean absolute error after applying a correction
+func correctedMAE(samples []middleware.CalibrationSample, c ScoreCorrection) float64 {
+	total := 0.0
+	for _, s := range samples {
+		total += math.Abs(float64(c.Correct(s.JudgeScore) - s.GoldScore))
+	}
+	return total / float64(len(samples))
+}
+
+// calibrationPoints buckets judge scores to the valid scores and averages gold per bucket
+func calibrationPoints(samples []middleware.CalibrationSample) []CalibrationPoint {
+	sums := make(map[int]float64)
+	counts := make(map[int]int)
+	for _, s := range samples {
+		bucket := RoundToValidScore(s.JudgeScore)
+		sums[bucket] += float64(s.GoldScore)
+		counts[bucket]++
+	}
+
+	points := make([]CalibrationPoint, 0, len(counts))
+	for bucket, count := range counts {
+		points = append(points, CalibrationPoint{JudgeScore: bucket, MeanGold: sums[bucket] / float64(count), Count: count})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].JudgeScore < points[j].JudgeScore })
+	return points
+}
+
+// judgeCorrections fits the suite's calibration method to its gold-labelled samples
+func (e *Evaluator) judgeCorrections(suiteID int, method string) map[string]ScoreCorrection {
+	rows, err := e.db.Query(`
+		SELECT h.judge_name, COALESCE(h.judge_score, 0), g.score
+		FROM evaluation_history h
+		JOIN gold_scores g ON g.model_id = h.model_id AND g.prompt_id = h.prompt_id
+		JOIN prompts p ON p.id = h.prompt_id
+		WHERE p.suite_id = ? AND h.id IN (
+			SELECT MAX(id) FROM evaluation_history GROUP BY judge_name, model_id, prompt_id
+		)
+	`, suiteID)
+	if err != nil {
+		log.Printf("Failed to load calibration samples for suite %d: %v", suiteID, err)
+		return nil
+	}
+	defer func() { _ = rows.Close() }()
+
+	var samples []middleware.CalibrationSample
+	for rows.Next() {
+		var s middleware.CalibrationSample
+		if err := rows.Scan(&s.Judge, &s.JudgeScore, &s.GoldScore); err != nil {
+			log.Printf("Failed to scan calibration sample: %v", err)
+			return nil
+		}
+		samples = append(samples, s)
+	}
+
+	corrections, err := FitCorrections(samples, method)
+	if err != nil {
+		log.Printf("Suite %d: %v", suiteID, err)
+		return nil
+	}
+	return corrections
+}
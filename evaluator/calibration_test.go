@@ -0,0 +1,167 @@
+package evaluator
+
+import (
+	"context"
+	"llm-tournament/middleware"
+	"math"
+	"testing"
+)
+
+// calibrationSamples pairs judge scores with gold scores for one judge
+func calibrationSamples(judge string, pairs ...[2]int) []middleware.CalibrationSample {
+	samples := make([]middleware.CalibrationSample, len(pairs))
+	for i, p := range pairs {
+		samples[i] = middleware.CalibrationSample{Judge: judge, JudgeScore: p[0], GoldScore: p[1]}
+	}
+	return samples
+}
+
+func TestFitLinearCorrection(t *testing.T) {
+	// A judge that is 20 points too generous everywhere
+	c := FitLinearCorrection(calibrationSamples("j", [2]int{40, 20}, [2]int{60, 40}, [2]int{100, 80}))
+	if math.Abs(c.Slope-1) > 1e-9 || math.Abs(c.Intercept+20) > 1e-9 {
+		t.Errorf("expected slope 1 intercept -20, got %+v", c)
+	}
+	if got := c.Correct(10); got != 0 {
+		t.Errorf("expected corrections to clamp at 0, got %d", got)
+	}
+
+	// A judge that always gives the same score gets an offset
+	c = FitLinearCorrection(calibrationSamples("j", [2]int{80, 60}, [2]int{80, 40}))
+	if c.Slope != 1 || c.Intercept != -30 {
+		t.Errorf("expected an offset of -30, got %+v", c)
+	}
+}
+
+func TestFitIsotonicCorrection_PoolsViolators(t *testing.T) {
+	// Gold drops between judge 40 and 60, so those two are pooled
+	c := FitIsotonicCorrection(calibrationSamples("j",
+		[2]int{20, 0}, [2]int{40, 60}, [2]int{60, 40}, [2]int{100, 100}))
+
+	wantX := []float64{20, 50, 100}
+	wantY := []float64{0, 50, 100}
+	if len(c.JudgeScores) != len(wantX) {
+		t.Fatalf("expected %d steps, got %+v", len(wantX), c)
+	}
+	for i := range wantX {
+		if c.JudgeScores[i] != wantX[i] || c.GoldScores[i] != wantY[i] {
+			t.Errorf("step %d: expected (%v, %v), got (%v, %v)", i, wantX[i], wantY[i], c.JudgeScores[i], c.GoldScores[i])
+		}
+	}
+
+	for score, want := range map[int]int{0: 0, 20: 0, 35: 25, 50: 50, 75: 75, 100: 100} {
+		if got := c.Correct(score); got != want {
+			t.Errorf("Correct(%d) = %d, want %d", score, got, want)
+		}
+	}
+}
+
+func TestFitCorrections_RequiresMinimumSamples(t *testing.T) {
+	samples := calibrationSamples("few", [2]int{80, 60}, [2]int{60, 40})
+	samples = append(samples, calibrationSamples("many",
+		[2]int{80, 60}, [2]int{60, 40}, [2]int{40, 20}, [2]int{100, 80}, [2]int{20, 0})...)
+
+	corrections, err := FitCorrections(samples, middleware.CalibrationLinear)
+	if err != nil {
+		t.Fatalf("FitCorrections failed: %v", err)
+	}
+	if _, ok := corrections["few"]; ok {
+		t.Error("expected no correction for a judge below the sample minimum")
+	}
+	if _, ok := corrections["many"]; !ok {
+		t.Fatal("expected a correction for a judge with enough samples")
+	}
+
+	results, applied := ApplyCorrections([]JudgeResult{{Judge: "many", Score: 80}, {Judge: "few", Score: 80}}, corrections)
+	if !applied || results[0].Score != 60 || results[1].Score != 80 {
+		t.Errorf("unexpected corrected results: %+v (applied=%v)", results, applied)
+	}
+
+	if _, err := FitCorrections(samples, "quantile"); err == nil {
+		t.Error("expected error for unknown method")
+	}
+}
+
+func TestBuildCalibrationReport(t *testing.T) {
+	samples := calibrationSamples("lenient", [2]int{80, 60}, [2]int{100, 80}, [2]int{60, 40})
+	samples[0].PromptType = "objective"
+	samples[1].PromptType = "creative"
+	samples = append(samples, calibrationSamples("exact", [2]int{60, 60})...)
+
+	report := BuildCalibrationReport(samples, middleware.CalibrationIsotonic)
+	if len(report.Judges) != 2 || report.Judges[0].Judge != "exact" {
+		t.Fatalf("expected judges sorted by name, got %+v", report.Judges)
+	}
+	lenient := report.Judges[1]
+	if lenient.Samples != 3 || lenient.Bias != 20 || lenient.MAE != 20 || lenient.RMSE != 20 {
+		t.Errorf("unexpected error statistics: %+v", lenient)
+	}
+	if lenient.LinearMAE != 0 || lenient.IsotonicMAE != 0 {
+		t.Errorf("expected both corrections to remove a constant offset, got %+v", lenient)
+	}
+	if lenient.Applied {
+		t.Error("expected no correction with fewer than the minimum samples")
+	}
+
+	// lenient: overall + creative + objective (untyped samples have no curve of their own); exact: overall
+	if len(report.Curves) != 4 {
+		t.Fatalf("expected 4 curves, got %+v", report.Curves)
+	}
+	if report.Curves[1].Judge != "lenient" || report.Curves[1].PromptType != "" || len(report.Curves[1].Points) != 3 {
+		t.Errorf("unexpected overall curve: %+v", report.Curves[1])
+	}
+	if report.Curves[2].PromptType != "creative" || report.Curves[2].Points[0].JudgeScore != 100 {
+		t.Errorf("unexpected prompt type curve: %+v", report.Curves[2])
+	}
+}
+
+func TestEvaluateModelPromptPair_AppliesCalibration(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1)",
+		"INSERT INTO prompts (text, suite_id, display_order) VALUES ('p1', 1, 0), ('p2', 1, 1), ('p3', 1, 2), ('p4', 1, 3), ('p5', 1, 4), ('p6', 1, 5)",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 6, 'response')",
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+		"INSERT INTO suite_settings (suite_id, key, value) VALUES (1, 'judge_calibration', 'linear')",
+		// The judge has scored 20 above the manual grade on five gold cells
+		"INSERT INTO gold_scores (model_id, prompt_id, score) VALUES (1, 1, 0), (1, 2, 20), (1, 3, 40), (1, 4, 60), (1, 5, 80)",
+		`INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, judge_confidence)
+			VALUES (1, 1, 1, 'generous', 20, 1), (1, 1, 2, 'generous', 40, 1), (1, 1, 3, 'generous', 60, 1),
+			       (1, 1, 4, 'generous', 80, 1), (1, 1, 5, 'generous', 100, 1)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(&stubProvider{name: "stub", resp: &EvaluationResponse{
+		Results: []JudgeResult{{Judge: "generous", Score: 100, Confidence: 1}},
+	}})
+
+	if _, err := e.evaluateModelPromptPair(context.Background(), 1, 1, 6); err != nil {
+		t.Fatalf("evaluateModelPromptPair failed: %v", err)
+	}
+
+	var score int
+	if err := db.QueryRow("SELECT score FROM scores WHERE model_id = 1 AND prompt_id = 6").Scan(&score); err != nil {
+		t.Fatalf("failed to query score: %v", err)
+	}
+	if score != 80 {
+		t.Errorf("expected calibrated score 80, got %d", score)
+	}
+
+	var calibration string
+	var judgeScore int
+	if err := db.QueryRow("SELECT calibration FROM evaluation_results WHERE prompt_id = 6").Scan(&calibration); err != nil {
+		t.Fatalf("failed to query evaluation result: %v", err)
+	}
+	if err := db.QueryRow("SELECT judge_score FROM evaluation_history WHERE prompt_id = 6").Scan(&judgeScore); err != nil {
+		t.Fatalf("failed to query history: %v", err)
+	}
+	if calibration != "linear" || judgeScore != 100 {
+		t.Errorf("expected linear calibration recorded and the raw judge score kept, got %q / %d", calibration, judgeScore)
+	}
+}
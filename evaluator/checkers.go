@@ -0,0 +1,391 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Checker scores a response locally against the prompt's solution. An error means the
+// checker could not be applied (e.g. a bad pattern or schema), not that the response failed.
+type Checker interface {
+	Name() string
+	Description() string
+	Check(response, solution, config string) (CheckResult, error)
+}
+
+// CheckResult is the verdict of a deterministic checker
+type CheckResult struct {
+	Passed    bool
+	Reasoning string
+}
+
+// Score maps the verdict onto the 0-100 scale
+func (r CheckResult) Score() int {
+	if r.Passed {
+		return 100
+	}
+	return 0
+}
+
+type checkerFunc struct {
+	name        string
+	description string
+	check       func(response, solution, config string) (CheckResult, error)
+}
+
+func (c checkerFunc) Name() string        { return c.name }
+func (c checkerFunc) Description() string { return c.description }
+
+func (c checkerFunc) Check(response, solution, config string) (CheckResult, error) {
+	return c.check(response, solution, config)
+}
+
+// checkers lists the available checkers in the order the prompt forms show them
+var checkers = []Checker{
+	checkerFunc{"exact", "Response equals the solution, ignoring surrounding whitespace", checkExact},
+	checkerFunc{"normalized", "Response equals the solution, ignoring case, punctuation and spacing", checkNormalized},
+	checkerFunc{"regex", "Response matches the regular expression in the config (or the solution)", checkRegex},
+	checkerFunc{"numeric", "Last number in the response is within the config tolerance of the solution (e.g. 0.01 or 1%)", checkNumeric},
+	checkerFunc{"set", "Same items as the solution in any order (comma or newline separated)", checkSet},
+	checkerFunc{"list", "Same items as the solution in the same order (comma or newline separated)", checkList},
+	checkerFunc{"choice", "Option letter the response picks (e.g. \"Answer: B\" or \"(B)\") equals the one the solution starts with", checkChoice},
+	checkerFunc{"json_schema", "Response is JSON valid against the schema in the config (or the solution)", checkJSONSchema},
+}
+
+// Checkers returns every available checker
+func Checkers() []Checker {
+	return append([]Checker(nil), checkers...)
+}
+
+// GetChecker looks up a checker by name
+func GetChecker(name string) (Checker, error) {
+	for _, c := range checkers {
+		if c.Name() == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown checker: %q", name)
+}
+
+func verdict(passed bool, format string, args ...interface{}) (CheckResult, error) {
+	return CheckResult{Passed: passed, Reasoning: fmt.Sprintf(format, args...)}, nil
+}
+
+func checkExact(response, solution, _ string) (CheckResult, error) {
+	if strings.TrimSpace(response) == strings.TrimSpace(solution) {
+		return verdict(true, "Response matches the solution exactly")
+	}
+	return verdict(false, "Response does not match the solution exactly")
+}
+
+func checkNormalized(response, solution, _ string) (CheckResult, error) {
+	if normalizeAnswer(response) == normalizeAnswer(solution) {
+		return verdict(true, "Response matches the solution after normalization")
+	}
+	return verdict(false, "Response %q does not match solution %q after normalization", normalizeAnswer(response), normalizeAnswer(solution))
+}
+
+// normalizeAnswer lowercases, drops punctuation and collapses whitespace
+func normalizeAnswer(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func checkRegex(response, solution, config string) (CheckResult, error) {
+	pattern := config
+	if pattern == "" {
+		pattern = solution
+	}
+	if pattern == "" {
+		return CheckResult{}, fmt.Errorf("regex checker needs a pattern")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return CheckResult{}, fmt.Errorf("invalid regex %q: %w", pattern, err)
+	}
+	if re.MatchString(response) {
+		return verdict(true, "Response matches /%s/", pattern)
+	}
+	return verdict(false, "Response does not match /%s/", pattern)
+}
+
+var numberPattern = regexp.MustCompile(`[-+]?(?:\d[\d,]*\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// lastNumber extracts the final number in s, ignoring thousands separators
+func lastNumber(s string) (float64, bool) {
+	matches := numberPattern.FindAllString(s, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		if v, err := strconv.ParseFloat(strings.ReplaceAll(matches[i], ",", ""), 64); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func checkNumeric(response, solution, config string) (CheckResult, error) {
+	expected, ok := lastNumber(solution)
+	if !ok {
+		return CheckResult{}, fmt.Errorf("numeric checker: solution %q has no number", solution)
+	}
+
+	tolerance := 1e-9
+	if config = strings.TrimSpace(config); config != "" {
+		relative := strings.HasSuffix(config, "%")
+		t, err := strconv.ParseFloat(strings.TrimSuffix(config, "%"), 64)
+		if err != nil || t < 0 {
+			return CheckResult{}, fmt.Errorf("numeric checker: invalid tolerance %q", config)
+		}
+		if relative {
+			t = math.Abs(expected) * t / 100
+		}
+		tolerance = math.Max(t, tolerance)
+	}
+
+	actual, ok := lastNumber(response)
+	if !ok {
+		return verdict(false, "Response contains no number (expected %g)", expected)
+	}
+	if diff := math.Abs(actual - expected); diff <= tolerance {
+		return verdict(true, "%g is within %g of %g", actual, tolerance, expected)
+	}
+	return verdict(false, "%g is not within %g of %g", actual, tolerance, expected)
+}
+
+// splitItems splits an answer on commas, semicolons and newlines into normalized items
+func splitItems(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' || r == '\n' })
+	items := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if item := normalizeAnswer(f); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func checkSet(response, solution, _ string) (CheckResult, error) {
+	got, want := uniqueSorted(splitItems(response)), uniqueSorted(splitItems(solution))
+	if strings.Join(got, "\n") == strings.Join(want, "\n") {
+		return verdict(true, "Response has the same %d items as the solution", len(want))
+	}
+	return verdict(false, "Response items %v differ from solution items %v", got, want)
+}
+
+func uniqueSorted(items []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func checkList(response, solution, _ string) (CheckResult, error) {
+	got, want := splitItems(response), splitItems(solution)
+	if strings.Join(got, "\n") == strings.Join(want, "\n") {
+		return verdict(true, "Response lists the same %d items in order", len(want))
+	}
+	return verdict(false, "Response items %v differ from solution items %v", got, want)
+}
+
+var (
+	optionPattern       = regexp.MustCompile(`^\(?([A-Za-z])(?:\)|[.:]|\s|$)`)
+	answerOptionPattern = regexp.MustCompile(`(?i:answer)[\s:*]*(?:(?i:is)\s+)?(?:(?i:option)\s+)?\(?([A-Z])\b`)
+	leadOptionPattern   = regexp.MustCompile(`^[\s*]*\(?([A-Za-z])(?:[).:]|\s*$)`)
+)
+
+// chosenOption reads the option a response picks: the last "answer: X", or a
+// response that starts with the option label. The result is upper case.
+func chosenOption(response string) (string, bool) {
+	if m := answerOptionPattern.FindAllStringSubmatch(response, -1); m != nil {
+		return m[len(m)-1][1], true
+	}
+	if m := leadOptionPattern.FindStringSubmatch(response); m != nil {
+		return strings.ToUpper(m[1]), true
+	}
+	return "", false
+}
+
+func checkChoice(response, solution, _ string) (CheckResult, error) {
+	m := optionPattern.FindStringSubmatch(strings.TrimSpace(solution))
+	if m == nil {
+		return CheckResult{}, fmt.Errorf("choice checker: solution %q does not start with an option letter", solution)
+	}
+	want := strings.ToUpper(m[1])
+	got, ok := chosenOption(response)
+	if !ok {
+		return CheckResult{}, fmt.Errorf("choice checker: no option found in the response")
+	}
+	if got == want {
+		return verdict(true, "Response picks option %s", got)
+	}
+	return verdict(false, "Response picks option %s, expected %s", got, want)
+}
+
+func checkJSONSchema(response, solution, config string) (CheckResult, error) {
+	schemaText := config
+	if schemaText == "" {
+		schemaText = solution
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(schemaText), &schema); err != nil {
+		return CheckResult{}, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(extractJSON(response)), &value); err != nil {
+		return verdict(false, "Response is not valid JSON: %v", err)
+	}
+	if problem := validateSchema(value, schema, "$"); problem != "" {
+		return verdict(false, "Response does not match the schema: %s", problem)
+	}
+	return verdict(true, "Response is valid against the schema")
+}
+
+// extractJSON strips a surrounding markdown code fence, if any
+func extractJSON(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```")
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			s = s[i+1:] // Drop the language tag line
+		}
+		s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	}
+	return strings.TrimSpace(s)
+}
+
+// validateSchema checks the subset of JSON Schema the suites use: type, enum, const,
+// required, properties, additionalProperties, items, minItems/maxItems, minimum/maximum
+// and pattern. It returns a description of the first violation, or "" when valid.
+func validateSchema(value interface{}, schema map[string]interface{}, path string) string {
+	if t, ok := schema["type"]; ok && !matchesSchemaType(value, t) {
+		return fmt.Sprintf("%s should be %v", path, t)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range enum {
+			if jsonEqual(value, option) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("%s is not one of %v", path, enum)
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(value, c) {
+		return fmt.Sprintf("%s should be %v", path, c)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if key, ok := name.(string); ok {
+					if _, present := v[key]; !present {
+						return fmt.Sprintf("%s is missing %q", path, key)
+					}
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, child := range v {
+			if propSchema, ok := properties[key].(map[string]interface{}); ok {
+				if problem := validateSchema(child, propSchema, path+"."+key); problem != "" {
+					return problem
+				}
+			} else if allowed, ok := schema["additionalProperties"].(bool); ok && !allowed {
+				return fmt.Sprintf("%s has unexpected property %q", path, key)
+			}
+		}
+	case []interface{}:
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(v)) < minItems {
+			return fmt.Sprintf("%s needs at least %g items", path, minItems)
+		}
+		if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(v)) > maxItems {
+			return fmt.Sprintf("%s allows at most %g items", path, maxItems)
+		}
+		if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if problem := validateSchema(item, itemSchema, fmt.Sprintf("%s[%d]", path, i)); problem != "" {
+					return problem
+				}
+			}
+		}
+	case float64:
+		if minimum, ok := schema["minimum"].(float64); ok && v < minimum {
+			return fmt.Sprintf("%s should be at least %g", path, minimum)
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && v > maximum {
+			return fmt.Sprintf("%s should be at most %g", path, maximum)
+		}
+	case string:
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil || !re.MatchString(v) {
+				return fmt.Sprintf("%s does not match pattern %q", path, pattern)
+			}
+		}
+	}
+	return ""
+}
+
+// matchesSchemaType accepts a single type name or a list of them
+func matchesSchemaType(value interface{}, t interface{}) bool {
+	if types, ok := t.([]interface{}); ok {
+		for _, option := range types {
+			if matchesSchemaType(value, option) {
+				return true
+			}
+		}
+		return false
+	}
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true // Unknown type names are not enforced
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
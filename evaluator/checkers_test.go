@@ -0,0 +1,274 @@
+package evaluator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCheckers(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["name", "tags"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "pattern": "^[A-Z]"},
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "items": {"enum": ["a", "b"]}, "minItems": 1}
+		}
+	}`
+
+	tests := []struct {
+		checker  string
+		response string
+		solution string
+		config   string
+		want     bool
+	}{
+		{"exact", "  Paris\n", "Paris", "", true},
+		{"exact", "paris", "Paris", "", false},
+		{"normalized", "The answer is: PARIS!", "the answer is paris", "", true},
+		{"normalized", "Paris, France", "Paris", "", false},
+		{"regex", "Final answer: 42", "", `answer:\s*42\b`, true},
+		{"regex", "Final answer: 420", "", `answer:\s*42\b`, false},
+		{"regex", "B", `^[Bb]$`, "", true},
+		{"numeric", "So the total is 3,141.6 dollars", "3141.59", "0.05", true},
+		{"numeric", "The result is 3.2", "3.14", "0.01", false},
+		{"numeric", "About 105 units", "100", "5%", true},
+		{"numeric", "About 106 units", "100", "5%", false},
+		{"numeric", "x = -2", "-2", "", true},
+		{"numeric", "I don't know", "7", "", false},
+		{"set", "Blue, red\ngreen.", "red, green, blue", "", true},
+		{"set", "red, green", "red, green, blue", "", false},
+		{"list", "1. one; 2. two", "1 one, 2 two", "", true},
+		{"list", "two, one", "one, two", "", false},
+		{"choice", "Paris is the capital.\n\n**Answer:** B", "B) Paris", "", true},
+		{"choice", "(c) Lyon", "B", "", false},
+		{"choice", "B. Paris", "b", "", true},
+		{"choice", "A good guess is not enough; the answer is D", "D", "", true},
+		{"json_schema", `{"name": "Ada", "age": 36, "tags": ["a"]}`, "", schema, true},
+		{"json_schema", "```json\n{\"name\": \"Ada\", \"tags\": [\"b\"]}\n```", "", schema, true},
+		{"json_schema", `{"name": "ada", "tags": ["a"]}`, "", schema, false},
+		{"json_schema", `{"name": "Ada"}`, "", schema, false},
+		{"json_schema", `{"name": "Ada", "tags": ["c"]}`, "", schema, false},
+		{"json_schema", `{"name": "Ada", "tags": ["a"], "extra": 1}`, "", schema, false},
+		{"json_schema", `{"name": "Ada", "age": 3.5, "tags": ["a"]}`, "", schema, false},
+		{"json_schema", `not json`, "", schema, false},
+		{"json_schema", `[1, 2]`, `{"type": "array", "items": {"type": "number"}}`, "", true},
+	}
+
+	for _, tt := range tests {
+		checker, err := GetChecker(tt.checker)
+		if err != nil {
+			t.Fatalf("GetChecker(%q) failed: %v", tt.checker, err)
+		}
+		result, err := checker.Check(tt.response, tt.solution, tt.config)
+		if err != nil {
+			t.Errorf("%s(%q) returned error: %v", tt.checker, tt.response, err)
+			continue
+		}
+		if result.Passed != tt.want {
+			t.Errorf("%s(%q): expected passed=%v, got %v (%s)", tt.checker, tt.response, tt.want, result.Passed, result.Reasoning)
+		}
+		if result.Reasoning == "" {
+			t.Errorf("%s(%q): expected reasoning", tt.checker, tt.response)
+		}
+	}
+}
+
+func TestCheckers_RejectBadConfig(t *testing.T) {
+	tests := []struct {
+		checker  string
+		solution string
+		config   string
+	}{
+		{"regex", "", ""},
+		{"regex", "", "(unclosed"},
+		{"numeric", "no number here", ""},
+		{"numeric", "5", "abc"},
+		{"numeric", "5", "-1"},
+		{"json_schema", "", "{not a schema"},
+		{"choice", "Paris", ""},
+		{"choice", "B", ""},
+	}
+	for _, tt := range tests {
+		checker, _ := GetChecker(tt.checker)
+		if _, err := checker.Check("response 1", tt.solution, tt.config); err == nil {
+			t.Errorf("%s with solution %q, config %q: expected error", tt.checker, tt.solution, tt.config)
+		}
+	}
+}
+
+func TestGetChecker_Unknown(t *testing.T) {
+	if _, err := GetChecker("fuzzy"); err == nil || !strings.Contains(err.Error(), "fuzzy") {
+		t.Errorf("expected unknown checker error, got %v", err)
+	}
+	if len(Checkers()) != 8 {
+		t.Errorf("expected 8 checkers, got %d", len(Checkers()))
+	}
+}
+
+func TestCheckResult_Score(t *testing.T) {
+	if (CheckResult{Passed: true}).Score() != 100 || (CheckResult{}).Score() != 0 {
+		t.Error("expected pass to score 100 and fail to score 0")
+	}
+}
+
+func TestEvaluateModelPromptPair_UsesChecker(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1)",
+		"INSERT INTO prompts (text, solution, suite_id, display_order, checker, checker_config) VALUES ('2+2?', '4', 1, 0, 'numeric', '')",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 1, 'The answer is 4.')",
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	judge := &stubProvider{name: "stub", err: errTest}
+	e.SetJudgeProviders(judge)
+
+	cost, err := e.evaluateModelPromptPair(context.Background(), 1, 1, 1)
+	if err != nil {
+		t.Fatalf("evaluateModelPromptPair failed: %v", err)
+	}
+	if cost != 0 {
+		t.Errorf("expected zero cost, got %f", cost)
+	}
+	if judge.judges != nil {
+		t.Error("expected judges not to be called")
+	}
+
+	var score int
+	if err := db.QueryRow("SELECT score FROM scores WHERE model_id = 1 AND prompt_id = 1").Scan(&score); err != nil {
+		t.Fatalf("failed to query score: %v", err)
+	}
+	if score != 100 {
+		t.Errorf("expected score 100, got %d", score)
+	}
+
+	var judgeName, reasoning, checker string
+	if err := db.QueryRow("SELECT judge_name, judge_reasoning FROM evaluation_history WHERE prompt_id = 1").Scan(&judgeName, &reasoning); err != nil {
+		t.Fatalf("failed to query history: %v", err)
+	}
+	if judgeName != "checker:numeric" || reasoning == "" {
+		t.Errorf("expected checker history with reasoning, got %q / %q", judgeName, reasoning)
+	}
+	if err := db.QueryRow("SELECT checker FROM evaluation_results WHERE prompt_id = 1").Scan(&checker); err != nil {
+		t.Fatalf("failed to query evaluation result: %v", err)
+	}
+	if checker != "numeric" {
+		t.Errorf("expected numeric checker recorded, got %q", checker)
+	}
+
+	var note string
+	if err := db.QueryRow("SELECT note FROM score_history WHERE job_id = 1 AND prompt_id = 1").Scan(&note); err != nil {
+		t.Fatalf("failed to query score history: %v", err)
+	}
+	if note != "checker:numeric" {
+		t.Errorf("expected the score change noted with its checker, got %q", note)
+	}
+}
+
+func TestEvaluateModelPromptPair_CheckerFallsBackToJudges(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1)",
+		"INSERT INTO prompts (text, solution, suite_id, display_order, checker, checker_config) VALUES ('p1', 's1', 1, 0, 'regex', '(unclosed')",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 1, 'response')",
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(&stubProvider{name: "stub", resp: &EvaluationResponse{
+		Results:      []JudgeResult{{Judge: "stub", Score: 60, Confidence: 1, CostUSD: 0.02}},
+		TotalCostUSD: 0.02,
+	}})
+
+	cost, err := e.evaluateModelPromptPair(context.Background(), 1, 1, 1)
+	if err != nil {
+		t.Fatalf("evaluateModelPromptPair failed: %v", err)
+	}
+	if cost != 0.02 {
+		t.Errorf("expected judge cost 0.02, got %f", cost)
+	}
+
+	var score int
+	if err := db.QueryRow("SELECT score FROM scores WHERE model_id = 1 AND prompt_id = 1").Scan(&score); err != nil {
+		t.Fatalf("failed to query score: %v", err)
+	}
+	if score != 60 {
+		t.Errorf("expected judge score 60, got %d", score)
+	}
+}
+
+func TestJudgedPromptCount_SkipsCheckedPrompts(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1), ('model2', 1)",
+		"INSERT INTO prompts (text, suite_id, display_order, checker) VALUES ('p1', 1, 0, 'exact'), ('p2', 1, 1, '')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	if got := e.judgedPromptCount(1, 2); got != 1 {
+		t.Errorf("expected 1 judged prompt, got %d", got)
+	}
+}
+
+func TestEvaluateModelPromptPair_StoresScoresOnSuiteScale(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range []string{
+		"INSERT INTO suite_settings (suite_id, key, value) VALUES (1, 'score_scale', 'likert')",
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1)",
+		"INSERT INTO prompts (text, solution, suite_id, display_order, checker, checker_config) VALUES ('2+2?', '4', 1, 0, 'numeric', '')",
+		"INSERT INTO prompts (text, solution, suite_id, display_order) VALUES ('Write a poem', 'A poem', 1, 1)",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 1, 'The answer is 4.')",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 2, 'Roses are red')",
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(&stubProvider{name: "stub", resp: &EvaluationResponse{Results: []JudgeResult{{Judge: "stub", Score: 80, Confidence: 1}}}})
+
+	// The checker's 100 and the judges' 80 land on the likert scale; the raw 0-100
+	// scores are kept alongside
+	for promptID, want := range map[int][2]int{1: {5, 100}, 2: {4, 80}} {
+		if _, err := e.evaluateModelPromptPair(context.Background(), 1, 1, promptID); err != nil {
+			t.Fatalf("evaluateModelPromptPair failed: %v", err)
+		}
+		var score, resultScore, rawScore int
+		if err := db.QueryRow("SELECT score FROM scores WHERE model_id = 1 AND prompt_id = ?", promptID).Scan(&score); err != nil {
+			t.Fatalf("failed to query score: %v", err)
+		}
+		if err := db.QueryRow("SELECT score, raw_score FROM evaluation_results WHERE prompt_id = ?", promptID).Scan(&resultScore, &rawScore); err != nil {
+			t.Fatalf("failed to query evaluation result: %v", err)
+		}
+		if score != want[0] || resultScore != want[0] || rawScore != want[1] {
+			t.Errorf("prompt %d: expected %d on the scale from %d, got score %d, result %d, raw %d",
+				promptID, want[0], want[1], score, resultScore, rawScore)
+		}
+	}
+}
@@ -1,39 +1,190 @@
 package evaluator
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"sort"
+)
 
-// CalculateConsensusScore calculates weighted average score by confidence
-func CalculateConsensusScore(results []JudgeResult) int {
-	if len(results) == 0 {
+// DefaultConsensusStrategy is used by suites that have not chosen a strategy
+const DefaultConsensusStrategy = "weighted_mean"
+
+// Outlier rejection drops judges further than outlierMADs median absolute
+// deviations from the median; the floor stops near-unanimous panels from
+// rejecting a judge over a handful of points.
+const (
+	outlierMADs     = 2.5
+	outlierMADFloor = 10.0
+)
+
+// ConsensusStrategy combines the scores of several judges into one 0-100 score
+type ConsensusStrategy interface {
+	Name() string
+	Description() string
+	Combine(results []JudgeResult) int
+}
+
+type consensusFunc struct {
+	name        string
+	description string
+	combine     func(valid []JudgeResult) int
+}
+
+func (c consensusFunc) Name() string        { return c.name }
+func (c consensusFunc) Description() string { return c.description }
+
+// Combine drops invalid results before applying the strategy; with none left the score is 0
+func (c consensusFunc) Combine(results []JudgeResult) int {
+	valid := validJudgeResults(results)
+	if len(valid) == 0 {
 		return 0
 	}
+	return c.combine(valid)
+}
 
-	// Filter out invalid results
-	validResults := make([]JudgeResult, 0)
-	for _, r := range results {
-		if r.Confidence > 0 && r.Score >= 0 && r.Score <= 100 {
-			validResults = append(validResults, r)
+// consensusStrategies lists the available strategies in the order the settings page shows them
+var consensusStrategies = []ConsensusStrategy{
+	consensusFunc{DefaultConsensusStrategy, "Confidence-weighted mean of all judges", weightedMean},
+	consensusFunc{"median", "Median judge score", medianScore},
+	consensusFunc{"trimmed_mean", "Mean after dropping the highest and lowest score (3+ judges)", trimmedMean},
+	consensusFunc{"majority_vote", "Most common score bucket, ties broken by total confidence", majorityVote},
+	consensusFunc{"strict", "Lowest judge score", minScore},
+	consensusFunc{"lenient", "Highest judge score", maxScore},
+	consensusFunc{"outlier_rejected", "Weighted mean after rejecting judges far from the median", outlierRejectedMean},
+}
+
+// ConsensusStrategies returns every available strategy
+func ConsensusStrategies() []ConsensusStrategy {
+	return append([]ConsensusStrategy(nil), consensusStrategies...)
+}
+
+// GetConsensusStrategy looks up a strategy by name; "" selects the default
+func GetConsensusStrategy(name string) (ConsensusStrategy, error) {
+	if name == "" {
+		name = DefaultConsensusStrategy
+	}
+	for _, s := range consensusStrategies {
+		if s.Name() == name {
+			return s, nil
 		}
 	}
+	return nil, fmt.Errorf("unknown consensus strategy: %q", name)
+}
 
-	if len(validResults) == 0 {
-		return 0
+// CalculateConsensusScore calculates weighted average score by confidence
+func CalculateConsensusScore(results []JudgeResult) int {
+	strategy, _ := GetConsensusStrategy(DefaultConsensusStrategy)
+	return strategy.Combine(results)
+}
+
+// validJudgeResults filters out results with no confidence or an out-of-range score
+func validJudgeResults(results []JudgeResult) []JudgeResult {
+	valid := make([]JudgeResult, 0, len(results))
+	for _, r := range results {
+		if r.Confidence > 0 && r.Score >= 0 && r.Score <= 100 {
+			valid = append(valid, r)
+		}
 	}
+	return valid
+}
 
-	// Calculate weighted average
-	// Note: totalWeight is always > 0 since validResults only contains items with Confidence > 0
+// weightedMean averages scores weighted by confidence and panel weight.
+// totalWeight is always > 0 since valid results have Confidence > 0.
+func weightedMean(valid []JudgeResult) int {
 	weightedSum := 0.0
 	totalWeight := 0.0
+	for _, result := range valid {
+		weight := result.Confidence * result.weight()
+		weightedSum += float64(result.Score) * weight
+		totalWeight += weight
+	}
+	return int(math.Round(weightedSum / totalWeight))
+}
 
-	for _, result := range validResults {
-		weightedSum += float64(result.Score) * result.Confidence
-		totalWeight += result.Confidence
+// sortedScores returns the judges' scores in ascending order
+func sortedScores(valid []JudgeResult) []float64 {
+	scores := make([]float64, len(valid))
+	for i, r := range valid {
+		scores[i] = float64(r.Score)
 	}
+	sort.Float64s(scores)
+	return scores
+}
 
-	return int(math.Round(weightedSum / totalWeight))
+func medianScore(valid []JudgeResult) int {
+	return int(math.Round(percentile(sortedScores(valid), 0.5)))
+}
+
+func trimmedMean(valid []JudgeResult) int {
+	scores := sortedScores(valid)
+	if len(scores) >= 3 {
+		scores = scores[1 : len(scores)-1]
+	}
+	sum := 0.0
+	for _, s := range scores {
+		sum += s
+	}
+	return int(math.Round(sum / float64(len(scores))))
+}
+
+// majorityVote snaps each judge to the nearest valid score and picks the bucket with
+// the most votes (each judge casting its panel weight), then the most total
+// confidence, then the lower score
+func majorityVote(valid []JudgeResult) int {
+	votes := make(map[int]float64)
+	confidence := make(map[int]float64)
+	for _, r := range valid {
+		bucket := RoundToValidScore(r.Score)
+		votes[bucket] += r.weight()
+		confidence[bucket] += r.Confidence
+	}
+
+	best := -1
+	for bucket := range votes {
+		switch {
+		case best < 0, votes[bucket] > votes[best]:
+			best = bucket
+		case votes[bucket] < votes[best]:
+		case confidence[bucket] > confidence[best]:
+			best = bucket
+		case confidence[bucket] == confidence[best] && bucket < best:
+			best = bucket
+		}
+	}
+	return best
+}
+
+func minScore(valid []JudgeResult) int {
+	return int(sortedScores(valid)[0])
+}
+
+func maxScore(valid []JudgeResult) int {
+	scores := sortedScores(valid)
+	return int(scores[len(scores)-1])
+}
+
+func outlierRejectedMean(valid []JudgeResult) int {
+	scores := sortedScores(valid)
+	median := percentile(scores, 0.5)
+
+	deviations := make([]float64, len(scores))
+	for i, s := range scores {
+		deviations[i] = math.Abs(s - median)
+	}
+	sort.Float64s(deviations)
+	mad := math.Max(percentile(deviations, 0.5), outlierMADFloor)
+
+	kept := make([]JudgeResult, 0, len(valid))
+	for _, r := range valid {
+		if math.Abs(float64(r.Score)-median) <= outlierMADs*mad {
+			kept = append(kept, r)
+		}
+	}
+	return weightedMean(kept)
 }
 
-// RoundToValidScore rounds score to nearest valid value [0, 20, 40, 60, 80, 100]
+// RoundToValidScore rounds a 0-100 judge score to the nearest of [0, 20, 40, 60, 80, 100],
+// the buckets judges are compared in. Grid scores use the suite's ScoreScale instead.
 func RoundToValidScore(score int) int {
 	validScores := []int{0, 20, 40, 60, 80, 100}
 
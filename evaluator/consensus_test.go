@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"context"
 	"testing"
 )
 
@@ -289,3 +290,173 @@ func TestAbs(t *testing.T) {
 		})
 	}
 }
+
+func TestConsensusStrategies_Combine(t *testing.T) {
+	// One judge far below the rest, to tell the strategies apart
+	results := []JudgeResult{
+		{Score: 80, Confidence: 0.9},
+		{Score: 85, Confidence: 0.8},
+		{Score: 75, Confidence: 0.7},
+		{Score: 10, Confidence: 0.6},
+	}
+
+	tests := []struct {
+		strategy string
+		want     int
+	}{
+		{"weighted_mean", 66}, // (72+68+52.5+6)/3.0 = 66.2
+		{"median", 78},        // (75+80)/2 = 77.5
+		{"trimmed_mean", 78},  // (75+80)/2 = 77.5
+		{"majority_vote", 80}, // 80, 85 and 75 all snap to 80
+		{"strict", 10},
+		{"lenient", 85},
+		{"outlier_rejected", 80}, // 10 is dropped: (72+68+52.5)/2.4 = 80.2
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			s, err := GetConsensusStrategy(tt.strategy)
+			if err != nil {
+				t.Fatalf("GetConsensusStrategy failed: %v", err)
+			}
+			if s.Name() != tt.strategy {
+				t.Errorf("Name() = %q, want %q", s.Name(), tt.strategy)
+			}
+			if got := s.Combine(results); got != tt.want {
+				t.Errorf("Combine() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsensusStrategies_IgnoreInvalidResults(t *testing.T) {
+	results := []JudgeResult{
+		{Score: 60, Confidence: 0.5},
+		{Score: 150, Confidence: 0.9},
+		{Score: 0, Confidence: 0},
+	}
+
+	for _, s := range ConsensusStrategies() {
+		if got := s.Combine(results); got != 60 {
+			t.Errorf("%s: Combine() = %d, want 60", s.Name(), got)
+		}
+		if got := s.Combine(nil); got != 0 {
+			t.Errorf("%s: Combine(nil) = %d, want 0", s.Name(), got)
+		}
+		if s.Description() == "" {
+			t.Errorf("%s: empty description", s.Name())
+		}
+	}
+}
+
+func TestGetConsensusStrategy_DefaultAndUnknown(t *testing.T) {
+	s, err := GetConsensusStrategy("")
+	if err != nil {
+		t.Fatalf("GetConsensusStrategy failed: %v", err)
+	}
+	if s.Name() != DefaultConsensusStrategy {
+		t.Errorf("expected default strategy, got %q", s.Name())
+	}
+
+	if _, err := GetConsensusStrategy("mode"); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
+
+func TestMajorityVote_TieBreaks(t *testing.T) {
+	// Two votes each for 40 and 80; 80 has more confidence
+	results := []JudgeResult{
+		{Score: 40, Confidence: 0.5},
+		{Score: 42, Confidence: 0.5},
+		{Score: 80, Confidence: 0.6},
+		{Score: 78, Confidence: 0.6},
+	}
+	if got := majorityVote(results); got != 80 {
+		t.Errorf("expected confidence to break the tie at 80, got %d", got)
+	}
+
+	// Equal votes and confidence fall to the lower bucket
+	results = []JudgeResult{
+		{Score: 100, Confidence: 0.5},
+		{Score: 20, Confidence: 0.5},
+	}
+	if got := majorityVote(results); got != 20 {
+		t.Errorf("expected the lower bucket, got %d", got)
+	}
+}
+
+func TestOutlierRejectedMean_KeepsSpreadPanels(t *testing.T) {
+	// A split panel has no outlier: both camps are equally far from the median
+	results := []JudgeResult{
+		{Score: 0, Confidence: 1},
+		{Score: 100, Confidence: 1},
+	}
+	if got := outlierRejectedMean(results); got != 50 {
+		t.Errorf("expected 50, got %d", got)
+	}
+}
+
+func TestEvaluateModelPromptPair_UsesSuiteConsensusStrategy(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1)",
+		"INSERT INTO prompts (text, suite_id, display_order) VALUES ('prompt1', 1, 0)",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 1, 'response')",
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+		"INSERT INTO suite_settings (suite_id, key, value) VALUES (1, 'consensus_strategy', 'strict')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(&stubProvider{name: "stub", resp: &EvaluationResponse{
+		Results: []JudgeResult{
+			{Judge: "a", Score: 90, Confidence: 1},
+			{Judge: "b", Score: 45, Confidence: 1},
+		},
+		ConsensusScore: 68,
+	}})
+
+	if _, err := e.evaluateModelPromptPair(context.Background(), 1, 1, 1); err != nil {
+		t.Fatalf("evaluateModelPromptPair failed: %v", err)
+	}
+
+	var score int
+	if err := db.QueryRow("SELECT score FROM scores WHERE model_id = 1 AND prompt_id = 1").Scan(&score); err != nil {
+		t.Fatalf("failed to query score: %v", err)
+	}
+	if score != 40 {
+		t.Errorf("expected strict score 40, got %d", score)
+	}
+
+	var rawScore, judgeCount int
+	var strategy string
+	err := db.QueryRow("SELECT raw_score, consensus_strategy, judge_count FROM evaluation_results WHERE job_id = 1").Scan(&rawScore, &strategy, &judgeCount)
+	if err != nil {
+		t.Fatalf("failed to query evaluation result: %v", err)
+	}
+	if rawScore != 45 || strategy != "strict" || judgeCount != 2 {
+		t.Errorf("unexpected evaluation result: raw=%d strategy=%q judges=%d", rawScore, strategy, judgeCount)
+	}
+}
+
+func TestConsensusStrategyForSuite_FallsBackToDefault(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := &Evaluator{db: db}
+	if got := e.consensusStrategy(1).Name(); got != DefaultConsensusStrategy {
+		t.Errorf("unset suite: expected %q, got %q", DefaultConsensusStrategy, got)
+	}
+
+	if _, err := db.Exec("INSERT INTO suite_settings (suite_id, key, value) VALUES (1, 'consensus_strategy', 'retired')"); err != nil {
+		t.Fatalf("failed to insert setting: %v", err)
+	}
+	if got := e.consensusStrategy(1).Name(); got != DefaultConsensusStrategy {
+		t.Errorf("unknown strategy: expected %q, got %q", DefaultConsensusStrategy, got)
+	}
+}
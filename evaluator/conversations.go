@@ -0,0 +1,48 @@
+package evaluator
+
+import (
+	"fmt"
+	"llm-tournament/middleware"
+	"strings"
+)
+
+// promptTurns loads the earlier turns of a conversation prompt; nil for a single-turn prompt
+func (e *Evaluator) promptTurns(promptID int) ([]middleware.Turn, error) {
+	rows, err := e.db.Query("SELECT role, content FROM prompt_turns WHERE prompt_id = ? ORDER BY position", promptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt turns: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var turns []middleware.Turn
+	for rows.Next() {
+		var turn middleware.Turn
+		if err := rows.Scan(&turn.Role, &turn.Content); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt turn: %w", err)
+		}
+		turns = append(turns, turn)
+	}
+	return turns, rows.Err()
+}
+
+// conversationMessages lays a prompt out as chat messages: the conversation's turns
+// followed by the prompt text as the final user message. System turns are returned
+// separately for APIs that take the system prompt outside the messages.
+func conversationMessages(turns []middleware.Turn, promptText string) (system []string, messages []chatMessage) {
+	for _, turn := range turns {
+		if turn.Role == middleware.TurnRoleSystem {
+			system = append(system, turn.Content)
+			continue
+		}
+		messages = append(messages, chatMessage{Role: turn.Role, Content: turn.Content})
+	}
+	return system, append(messages, chatMessage{Role: middleware.TurnRoleUser, Content: promptText})
+}
+
+// joinSystemPrompts combines the endpoint's system prompt with a conversation's system turns
+func joinSystemPrompts(endpointPrompt string, turns []string) string {
+	if endpointPrompt != "" {
+		turns = append([]string{endpointPrompt}, turns...)
+	}
+	return strings.Join(turns, "\n\n")
+}
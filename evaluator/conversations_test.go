@@ -0,0 +1,123 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"llm-tournament/middleware"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// seedConversation turns prompt 2 of the generator fixture into a conversation
+func seedConversation(t *testing.T, e *Evaluator) {
+	t.Helper()
+	_, err := e.db.Exec(`INSERT INTO prompt_turns (prompt_id, position, role, content) VALUES
+		(2, 0, 'system', 'Answer in French.'),
+		(2, 1, 'user', 'Hello'),
+		(2, 2, 'assistant', 'Bonjour')`)
+	if err != nil {
+		t.Fatalf("failed to seed turns: %v", err)
+	}
+}
+
+func TestGenerateResponse_SendsConversation(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	var openAI openAIChatRequest
+	var anthropic anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/messages" {
+			_ = json.NewDecoder(r.Body).Decode(&anthropic)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"content": []map[string]string{{"type": "text", "text": "Au revoir"}}})
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&openAI)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "Au revoir"}}},
+		})
+	}))
+	defer server.Close()
+
+	_, err := db.Exec(`
+		INSERT INTO models (id, name, suite_id) VALUES (3, 'claude', 1);
+		INSERT INTO model_configs (model_id, provider, base_url, model_name, system_prompt) VALUES
+			(1, 'openai', ?1, 'llama-3', 'Be terse.'), (3, 'anthropic', ?1, 'claude-test', 'Be terse.');
+	`, server.URL)
+	if err != nil {
+		t.Fatalf("failed to insert configs: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	seedConversation(t, e)
+	for _, modelID := range []int{1, 3} {
+		if err := e.generateResponse(context.Background(), modelID, 2); err != nil {
+			t.Fatalf("generateResponse failed: %v", err)
+		}
+	}
+
+	want := []chatMessage{
+		{Role: "system", Content: "Be terse.\n\nAnswer in French."},
+		{Role: "user", Content: "Hello"},
+		{Role: "assistant", Content: "Bonjour"},
+		{Role: "user", Content: "Say bye"},
+	}
+	if !reflect.DeepEqual(openAI.Messages, want) {
+		t.Errorf("expected the conversation before the prompt, got %+v", openAI.Messages)
+	}
+	if anthropic.System != want[0].Content || !reflect.DeepEqual(anthropic.Messages, want[1:]) {
+		t.Errorf("expected system turns in the system prompt, got %q / %+v", anthropic.System, anthropic.Messages)
+	}
+}
+
+// turnsJudge records the conversation it was asked to judge
+type turnsJudge struct {
+	turns []middleware.Turn
+}
+
+func (j *turnsJudge) Name() string { return "stub" }
+
+func (j *turnsJudge) Evaluate(_ context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	j.turns = req.Turns
+	return &EvaluationResponse{Results: []JudgeResult{{Judge: "stub", Score: 80, Confidence: 1}}}, nil
+}
+
+func TestEvaluateModelPromptPair_SendsConversationToJudges(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	seedConversation(t, e)
+	if _, err := db.Exec("INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 2, 'Au revoir')"); err != nil {
+		t.Fatalf("failed to insert response: %v", err)
+	}
+	judge := &turnsJudge{}
+	e.SetJudgeProviders(judge)
+
+	if _, err := e.evaluateModelPromptPair(context.Background(), 0, 1, 2); err != nil {
+		t.Fatalf("evaluateModelPromptPair failed: %v", err)
+	}
+	if len(judge.turns) != 3 || judge.turns[2].Content != "Bonjour" {
+		t.Errorf("expected the judges to receive the three earlier turns, got %+v", judge.turns)
+	}
+
+	prompt, err := RenderJudgePrompt(EvaluationRequest{Prompt: "Say bye", Response: "Au revoir", Turns: judge.turns})
+	if err != nil {
+		t.Fatalf("RenderJudgePrompt failed: %v", err)
+	}
+	if !strings.Contains(prompt, "**CONVERSATION SO FAR:**\n[system]\nAnswer in French.\n\n[user]\nHello") {
+		t.Errorf("expected the transcript before the prompt, got:\n%s", prompt)
+	}
+	single := EvaluationRequest{Prompt: "Say bye", Response: "Au revoir"}
+	if plain, _ := RenderJudgePrompt(single); strings.Contains(plain, "CONVERSATION") {
+		t.Error("expected no transcript for a single-turn prompt")
+	}
+	conversation := single
+	conversation.Turns = judge.turns
+	if evaluationCacheKey(single, "stub") == evaluationCacheKey(conversation, "stub") {
+		t.Error("expected the conversation to be part of the cache key")
+	}
+}
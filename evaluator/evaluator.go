@@ -1,9 +1,15 @@
 package evaluator
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"llm-tournament/middleware"
 	"log"
+	"sync"
+	"time"
 )
 
 // Evaluator orchestrates LLM evaluations
@@ -12,14 +18,32 @@ type Evaluator struct {
 	litellmClient *LiteLLMClient
 	jobQueue      *JobQueue
 	judges        []string
+	providersMu   sync.RWMutex
+	providers     []JudgeProvider         // Empty means the Python service handles every judge
+	sandbox       *Sandbox                // Runs hidden tests; nil uses DefaultSandbox
+	retryPolicy   RetryPolicy             // Zero value makes a single attempt
+	breaker       *CircuitBreaker         // Nil never pauses jobs
+	concurrency   int                     // Pairs each job evaluates at once; below 1 means one at a time
+	rateLimits    map[string]RateLimit    // Keyed by provider kind
+	limiters      map[string]*RateLimiter // Keyed by rate limit bucket and shared by every job
+	events        JobEvents
+	auto          autoQueue
+	keys          apiKeys // Finds the API keys of natively-called panel judges
 }
 
+// DefaultConcurrency is how many pairs a job evaluates at once until the settings page
+// configures another number
+const DefaultConcurrency = 4
+
 // NewEvaluator creates a new evaluator instance
 func NewEvaluator(db *sql.DB, pythonServiceURL string) *Evaluator {
 	evaluator := &Evaluator{
 		db:            db,
 		litellmClient: NewLiteLLMClient(pythonServiceURL),
 		judges:        []string{"claude_opus_4.5", "gpt_5.2", "gemini_3_pro"},
+		retryPolicy:   DefaultRetryPolicy,
+		breaker:       NewCircuitBreaker(DefaultBreakerThreshold, DefaultBreakerCooldown),
+		concurrency:   DefaultConcurrency,
 	}
 
 	// Initialize job queue with 3 concurrent workers
@@ -28,8 +52,9 @@ func NewEvaluator(db *sql.DB, pythonServiceURL string) *Evaluator {
 	return evaluator
 }
 
-// EvaluateAll evaluates all models against all prompts in a suite
-func (e *Evaluator) EvaluateAll(suiteID int) (int, error) {
+// EvaluateAll evaluates all models against all prompts in a suite. With forceRefresh the judges
+// run again even for pairs the evaluation cache has verdicts for.
+func (e *Evaluator) EvaluateAll(suiteID int, forceRefresh bool) (int, error) {
 	// Get prompt and model counts
 	var promptCount, modelCount int
 	err := e.db.QueryRow("SELECT COUNT(*) FROM prompts WHERE suite_id = ?", suiteID).Scan(&promptCount)
@@ -44,8 +69,8 @@ func (e *Evaluator) EvaluateAll(suiteID int) (int, error) {
 
 	total := promptCount * modelCount
 
-	// Estimate cost
-	estimatedCost := float64(total) * 0.05 // ~$0.05 per evaluation
+	// Estimate cost; prompts with a checker or hidden tests are scored locally for free
+	estimatedCost := float64(e.judgedPromptCount(suiteID, promptCount)*modelCount) * 0.05 // ~$0.05 per evaluation
 
 	// Create job
 	job := &EvaluationJob{
@@ -53,6 +78,8 @@ func (e *Evaluator) EvaluateAll(suiteID int) (int, error) {
 		JobType:       "all",
 		ProgressTotal: total,
 		EstimatedCost: estimatedCost,
+		ForceRefresh:  forceRefresh,
+		JudgePanel:    e.suitePanel(suiteID),
 	}
 
 	if err := e.jobQueue.Enqueue(job); err != nil {
@@ -62,8 +89,9 @@ func (e *Evaluator) EvaluateAll(suiteID int) (int, error) {
 	return job.ID, nil
 }
 
-// EvaluateModel evaluates one model against all prompts
-func (e *Evaluator) EvaluateModel(modelID int) (int, error) {
+// EvaluateModel evaluates one model against all prompts. With forceRefresh the judges
+// run again even for pairs the evaluation cache has verdicts for.
+func (e *Evaluator) EvaluateModel(modelID int, forceRefresh bool) (int, error) {
 	// Get suite ID and prompt count
 	var suiteID, promptCount int
 	err := e.db.QueryRow("SELECT suite_id FROM models WHERE id = ?", modelID).Scan(&suiteID)
@@ -76,7 +104,7 @@ func (e *Evaluator) EvaluateModel(modelID int) (int, error) {
 		return 0, fmt.Errorf("failed to count prompts: %w", err)
 	}
 
-	estimatedCost := float64(promptCount) * 0.05
+	estimatedCost := float64(e.judgedPromptCount(suiteID, promptCount)) * 0.05
 
 	job := &EvaluationJob{
 		SuiteID:       suiteID,
@@ -84,6 +112,8 @@ func (e *Evaluator) EvaluateModel(modelID int) (int, error) {
 		TargetID:      modelID,
 		ProgressTotal: promptCount,
 		EstimatedCost: estimatedCost,
+		ForceRefresh:  forceRefresh,
+		JudgePanel:    e.suitePanel(suiteID),
 	}
 
 	if err := e.jobQueue.Enqueue(job); err != nil {
@@ -93,8 +123,9 @@ func (e *Evaluator) EvaluateModel(modelID int) (int, error) {
 	return job.ID, nil
 }
 
-// EvaluatePrompt evaluates all models for one prompt
-func (e *Evaluator) EvaluatePrompt(promptID int) (int, error) {
+// EvaluatePrompt evaluates all models for one prompt. With forceRefresh the judges
+// run again even for pairs the evaluation cache has verdicts for.
+func (e *Evaluator) EvaluatePrompt(promptID int, forceRefresh bool) (int, error) {
 	// Get suite ID and model count
 	var suiteID, modelCount int
 	err := e.db.QueryRow("SELECT suite_id FROM prompts WHERE id = ?", promptID).Scan(&suiteID)
@@ -108,6 +139,10 @@ func (e *Evaluator) EvaluatePrompt(promptID int) (int, error) {
 	}
 
 	estimatedCost := float64(modelCount) * 0.05
+	var localOnly bool
+	if err := e.db.QueryRow("SELECT NOT ("+judgedPromptCondition+") FROM prompts WHERE id = ?", promptID).Scan(&localOnly); err == nil && localOnly {
+		estimatedCost = 0
+	}
 
 	job := &EvaluationJob{
 		SuiteID:       suiteID,
@@ -115,6 +150,8 @@ func (e *Evaluator) EvaluatePrompt(promptID int) (int, error) {
 		TargetID:      promptID,
 		ProgressTotal: modelCount,
 		EstimatedCost: estimatedCost,
+		ForceRefresh:  forceRefresh,
+		JudgePanel:    e.suitePanel(suiteID),
 	}
 
 	if err := e.jobQueue.Enqueue(job); err != nil {
@@ -124,24 +161,31 @@ func (e *Evaluator) EvaluatePrompt(promptID int) (int, error) {
 	return job.ID, nil
 }
 
-// processJob processes an evaluation job (called by worker)
-func (e *Evaluator) processJob(job *EvaluationJob, cancelChan chan bool) error {
+// processJob processes an evaluation job (called by worker). Cancelling ctx stops the
+// job and aborts any judge or model request in flight.
+func (e *Evaluator) processJob(ctx context.Context, job *EvaluationJob) error {
 	log.Printf("Processing job %d (type: %s)", job.ID, job.JobType)
 
 	switch job.JobType {
 	case "all":
-		return e.processAllJob(job, cancelChan)
+		return e.processAllJob(ctx, job)
 	case "model":
-		return e.processModelJob(job, cancelChan)
+		return e.processModelJob(ctx, job)
 	case "prompt":
-		return e.processPromptJob(job, cancelChan)
+		return e.processPromptJob(ctx, job)
+	case JobTypeGenerateAll, JobTypeGenerateModel:
+		return e.processGenerateJob(ctx, job)
+	case JobTypeBattleAll:
+		return e.processBattleJob(ctx, job)
+	case JobTypeRetryFailed, JobTypeAuto:
+		return e.processRecordedPairsJob(ctx, job)
 	default:
 		return fmt.Errorf("unknown job type: %s", job.JobType)
 	}
 }
 
 // processAllJob evaluates all models × all prompts
-func (e *Evaluator) processAllJob(job *EvaluationJob, cancelChan chan bool) error {
+func (e *Evaluator) processAllJob(ctx context.Context, job *EvaluationJob) error {
 	// Get all models
 	modelRows, err := e.db.Query("SELECT id FROM models WHERE suite_id = ?", job.SuiteID)
 	if err != nil {
@@ -174,40 +218,17 @@ func (e *Evaluator) processAllJob(job *EvaluationJob, cancelChan chan bool) erro
 		promptIDs = append(promptIDs, promptID)
 	}
 
-	// Process each combination
-	current := 0
-	totalCost := 0.0
-
+	pairs := make([]modelPromptPair, 0, len(modelIDs)*len(promptIDs))
 	for _, modelID := range modelIDs {
 		for _, promptID := range promptIDs {
-			// Check for cancellation
-			select {
-			case <-cancelChan:
-				return fmt.Errorf("job cancelled")
-			default:
-			}
-
-			cost, err := e.evaluateModelPromptPair(job.ID, modelID, promptID)
-			if err != nil {
-				log.Printf("Failed to evaluate model %d, prompt %d: %v", modelID, promptID, err)
-				// Continue with next evaluation
-			}
-
-			totalCost += cost
-			current++
-
-			// Update progress
-			if err := e.jobQueue.UpdateJobProgress(job.ID, current, job.ProgressTotal, totalCost); err != nil {
-				log.Printf("Failed to update progress: %v", err)
-			}
+			pairs = append(pairs, modelPromptPair{modelID, promptID})
 		}
 	}
-
-	return nil
+	return e.evaluatePairs(ctx, job, pairs)
 }
 
 // processModelJob evaluates one model × all prompts
-func (e *Evaluator) processModelJob(job *EvaluationJob, cancelChan chan bool) error {
+func (e *Evaluator) processModelJob(ctx context.Context, job *EvaluationJob) error {
 	// Get all prompts
 	promptRows, err := e.db.Query("SELECT id FROM prompts WHERE suite_id = ?", job.SuiteID)
 	if err != nil {
@@ -224,34 +245,15 @@ func (e *Evaluator) processModelJob(job *EvaluationJob, cancelChan chan bool) er
 		promptIDs = append(promptIDs, promptID)
 	}
 
-	current := 0
-	totalCost := 0.0
-
-	for _, promptID := range promptIDs {
-		select {
-		case <-cancelChan:
-			return fmt.Errorf("job cancelled")
-		default:
-		}
-
-		cost, err := e.evaluateModelPromptPair(job.ID, job.TargetID, promptID)
-		if err != nil {
-			log.Printf("Failed to evaluate prompt %d: %v", promptID, err)
-		}
-
-		totalCost += cost
-		current++
-
-		if err := e.jobQueue.UpdateJobProgress(job.ID, current, job.ProgressTotal, totalCost); err != nil {
-			log.Printf("Failed to update progress: %v", err)
-		}
+	pairs := make([]modelPromptPair, len(promptIDs))
+	for i, promptID := range promptIDs {
+		pairs[i] = modelPromptPair{job.TargetID, promptID}
 	}
-
-	return nil
+	return e.evaluatePairs(ctx, job, pairs)
 }
 
 // processPromptJob evaluates all models × one prompt
-func (e *Evaluator) processPromptJob(job *EvaluationJob, cancelChan chan bool) error {
+func (e *Evaluator) processPromptJob(ctx context.Context, job *EvaluationJob) error {
 	// Get all models
 	modelRows, err := e.db.Query("SELECT id FROM models WHERE suite_id = ?", job.SuiteID)
 	if err != nil {
@@ -268,42 +270,165 @@ func (e *Evaluator) processPromptJob(job *EvaluationJob, cancelChan chan bool) e
 		modelIDs = append(modelIDs, modelID)
 	}
 
+	pairs := make([]modelPromptPair, len(modelIDs))
+	for i, modelID := range modelIDs {
+		pairs[i] = modelPromptPair{modelID, job.TargetID}
+	}
+	return e.evaluatePairs(ctx, job, pairs)
+}
+
+// modelPromptPair is one cell of the results grid
+type modelPromptPair struct {
+	modelID  int
+	promptID int
+}
+
+// pairResult is a finished pair reported back to the job's goroutine
+type pairResult struct {
+	pair     modelPromptPair
+	outcome  string
+	cost     float64
+	duration time.Duration
+	err      error
+}
+
+// evaluatePairs evaluates the pairs on a pool of workers sized by the evaluator's
+// concurrency. Results are collected on the calling goroutine, so progress and cost
+// are written one pair at a time and the count never goes backwards. Pairs an earlier
+// run of the job finished are counted but not evaluated again.
+func (e *Evaluator) evaluatePairs(ctx context.Context, job *EvaluationJob, pairs []modelPromptPair) error {
+	if err := e.jobQueue.addJobPairs(job.ID, pairs); err != nil {
+		log.Printf("Failed to record pairs of job %d: %v", job.ID, err)
+	}
+	done, err := e.jobQueue.finishedPairs(job.ID)
+	if err != nil {
+		return err
+	}
+
 	current := 0
+	failed := 0
 	totalCost := 0.0
-
-	for _, modelID := range modelIDs {
-		select {
-		case <-cancelChan:
-			return fmt.Errorf("job cancelled")
-		default:
+	todo := make([]modelPromptPair, 0, len(pairs))
+	for _, pair := range pairs {
+		f, ok := done[pair]
+		if !ok {
+			todo = append(todo, pair)
+			continue
+		}
+		current++
+		totalCost += f.cost
+		if f.outcome == PairFailed {
+			failed++
 		}
+	}
+	if current > 0 {
+		log.Printf("Job %d resumes after %d of %d pairs", job.ID, current, len(pairs))
+		e.reportProgress(job, current, totalCost)
+	}
+	pairs = todo
+
+	pending := make(chan modelPromptPair)
+	results := make(chan pairResult)
+
+	var workers sync.WaitGroup
+	for i := 0; i < min(e.pairConcurrency(), len(pairs)); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for pair := range pending {
+				start := time.Now()
+				cost, err := e.evaluateModelPromptPair(ctx, job.ID, pair.modelID, pair.promptID)
+				r := pairResult{pair: pair, outcome: PairScored, cost: cost, duration: time.Since(start), err: err}
+				switch {
+				case errors.Is(err, errNoResponse):
+					r.outcome, r.err = PairSkipped, nil
+				case err != nil:
+					r.outcome = PairFailed
+				}
+				results <- r
+			}
+		}()
+	}
 
-		cost, err := e.evaluateModelPromptPair(job.ID, modelID, job.TargetID)
-		if err != nil {
-			log.Printf("Failed to evaluate model %d: %v", modelID, err)
+	// Hand out pairs, holding back while the circuit breaker is open or the next
+	// pair would go over budget
+	spend := newJobSpend(job)
+	spend.resume(current, totalCost)
+	var dispatchErr error
+	go func() {
+		defer close(pending)
+		for _, pair := range pairs {
+			if ctx.Err() != nil {
+				dispatchErr = errJobCancelled
+				return
+			}
+			if err := e.waitForJudgeService(ctx, job); err != nil {
+				dispatchErr = err
+				return
+			}
+			if err := e.waitForBudget(ctx, job, spend); err != nil {
+				dispatchErr = err
+				return
+			}
+			spend.start()
+			select {
+			case pending <- pair:
+			case <-ctx.Done():
+				dispatchErr = errJobCancelled
+				return
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		if ctx.Err() != nil {
+			continue // Aborted pairs are neither progress nor failures
+		}
+		if r.err != nil {
+			log.Printf("Failed to evaluate model %d, prompt %d: %v", r.pair.modelID, r.pair.promptID, r.err)
+			e.recordPairFailure(job.ID, r.pair.modelID, r.pair.promptID, r.err)
+			failed++
+			// Continue with next evaluation
 		}
 
-		totalCost += cost
+		e.jobQueue.setPairOutcome(job.ID, r)
+		spend.finish(r.cost)
+		e.recordSpend(job.SuiteID, r.cost)
+		totalCost += r.cost
 		current++
-
-		if err := e.jobQueue.UpdateJobProgress(job.ID, current, job.ProgressTotal, totalCost); err != nil {
-			log.Printf("Failed to update progress: %v", err)
-		}
+		e.reportProgress(job, current, totalCost)
 	}
 
+	if ctx.Err() != nil {
+		return errJobCancelled
+	}
+	if dispatchErr != nil {
+		return dispatchErr
+	}
+	summarizeFailedPairs(job, failed, current)
 	return nil
 }
 
+// errNoResponse is returned for pairs skipped because the model has no stored response
+var errNoResponse = errors.New("no response stored")
+
 // evaluateModelPromptPair evaluates a single model-prompt pair
-func (e *Evaluator) evaluateModelPromptPair(jobID, modelID, promptID int) (float64, error) {
+func (e *Evaluator) evaluateModelPromptPair(ctx context.Context, jobID, modelID, promptID int) (float64, error) {
 	// Get prompt data
-	var promptText, solution, promptType string
+	var promptText, solution, promptType, checker, checkerConfig, language, testCases, promptRubric, profileRubric string
+	var suiteID int
 	var solutionNull sql.NullString
 	err := e.db.QueryRow(`
-		SELECT text, solution, type
-		FROM prompts
-		WHERE id = ?
-	`, promptID).Scan(&promptText, &solutionNull, &promptType)
+		SELECT p.text, p.solution, p.type, p.suite_id, p.checker, p.checker_config, p.language, p.test_cases,
+			p.rubric, COALESCE(pr.rubric, '')
+		FROM prompts p
+		LEFT JOIN profiles pr ON pr.id = p.profile_id
+		WHERE p.id = ?
+	`, promptID).Scan(&promptText, &solutionNull, &promptType, &suiteID, &checker, &checkerConfig, &language, &testCases, &promptRubric, &profileRubric)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get prompt: %w", err)
 	}
@@ -311,70 +436,640 @@ func (e *Evaluator) evaluateModelPromptPair(jobID, modelID, promptID int) (float
 	if solutionNull.Valid {
 		solution = solutionNull.String
 	}
+	scale := e.scoreScale(suiteID)
 
 	// Get model response (from model_responses table or generate placeholder)
 	var response string
+	var systemPromptVersion sql.NullInt64
 	err = e.db.QueryRow(`
-		SELECT response_text
+		SELECT response_text, system_prompt_version_id
 		FROM model_responses
 		WHERE model_id = ? AND prompt_id = ?
-	`, modelID, promptID).Scan(&response)
+	`, modelID, promptID).Scan(&response, &systemPromptVersion)
 
 	if err == sql.ErrNoRows {
 		// No response stored - skip evaluation
 		log.Printf("No response for model %d, prompt %d - skipping", modelID, promptID)
-		return 0, nil
+		return 0, errNoResponse
 	} else if err != nil {
 		return 0, fmt.Errorf("failed to get model response: %w", err)
 	}
 
+	turns, err := e.promptTurns(promptID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Programming prompts with hidden tests are scored by running the code
+	if language != "" && testCases != "" {
+		scored, err := e.scoreWithTests(ctx, jobID, modelID, promptID, scale, language, testCases, response)
+		if err != nil {
+			return 0, err
+		}
+		if scored {
+			return 0, nil
+		}
+	}
+
+	// Prompts with a deterministic checker, their own or their type's, are scored
+	// locally at no cost; the judges only run when the checker cannot be applied
+	if checker == "" {
+		if t, err := GetPromptType(promptType); err == nil {
+			checker = t.Checker
+		}
+	}
+	if checker != "" {
+		scored, err := e.scoreWithChecker(jobID, modelID, promptID, scale, checker, checkerConfig, response, solution)
+		if err != nil {
+			return 0, err
+		}
+		if scored {
+			return 0, nil
+		}
+	}
+
+	// The judges see the system prompt the response was produced under
+	system, err := e.responseSystemPrompt(modelID, promptID, systemPromptVersion)
+	if err != nil {
+		return 0, err
+	}
+
 	// Get API keys from settings
 	apiKeys, err := e.getAPIKeys()
 	if err != nil {
 		return 0, fmt.Errorf("failed to get API keys: %w", err)
 	}
 
-	// Call Python evaluation service
+	// Fan out to the providers of the prompt's judge panel
+	set := e.judgesForPrompt(suiteID, promptID)
 	evalReq := EvaluationRequest{
-		Prompt:   promptText,
-		Response: response,
-		Solution: solution,
-		Type:     promptType,
-		Judges:   e.judges,
-		APIKeys:  apiKeys,
+		Prompt:       promptText,
+		Response:     response,
+		Solution:     solution,
+		Type:         judgeMode(promptType),
+		Judges:       set.judges,
+		APIKeys:      apiKeys,
+		Rubric:       effectiveRubric(promptID, promptRubric, profileRubric),
+		Turns:        turns,
+		SystemPrompt: system.content,
 	}
 
-	evalResp, err := e.litellmClient.Evaluate(evalReq)
-	if err != nil {
-		return 0, fmt.Errorf("evaluation failed: %w", err)
+	// Reuse the judges' earlier verdicts when nothing they depend on has changed
+	var evalResp *EvaluationResponse
+	cached, saved := []JudgeResult(nil), 0.0
+	if !e.forceRefresh(jobID) {
+		cached, saved = e.cachedVerdicts(ctx, evalReq, set)
+	}
+	if cached != nil {
+		evalResp = &EvaluationResponse{Results: cached, ConsensusScore: CalculateConsensusScore(cached)}
+	} else {
+		evalResp, err = e.runJudgesWith(ctx, evalReq, set.providers)
+		if err != nil {
+			return 0, fmt.Errorf("evaluation failed: %w", err)
+		}
+	}
+	e.recordCacheUse(jobID, cached != nil, saved)
+	for i := range evalResp.Results {
+		if w, ok := set.weights[evalResp.Results[i].Judge]; ok {
+			evalResp.Results[i].Weight = w
+		}
 	}
 
-	// Calculate consensus score
-	consensusScore := RoundToValidScore(evalResp.ConsensusScore)
+	// Combine the judges with the suite's consensus strategy, after rolling each
+	// judge's criterion scores up into its score and correcting it against gold
+	// labels if the suite asks for it. Responses without per-judge results keep the
+	// score the provider already computed. The 0-100 result is stored on the suite's
+	// scale.
+	strategy := e.consensusStrategy(suiteID)
+	results := rollUpCriteria(evalResp.Results, evalReq.Rubric)
+	calibration := ""
+	if method := e.suiteSetting(suiteID, middleware.SuiteSettingCalibration); method != "" && len(results) > 0 {
+		var applied bool
+		results, applied = ApplyCorrections(results, e.judgeCorrections(suiteID, method))
+		if applied {
+			calibration = method
+		}
+	}
+	rawScore := evalResp.ConsensusScore
+	if len(results) > 0 {
+		rawScore = strategy.Combine(results)
+	}
+	consensusScore := scale.FromPercent(float64(rawScore))
 
 	// Update score in database
-	_, err = e.db.Exec(`
-		INSERT OR REPLACE INTO scores (model_id, prompt_id, score)
-		VALUES (?, ?, ?)
-	`, modelID, promptID, consensusScore)
+	err = middleware.WriteScore(e.db, modelID, promptID, consensusScore, middleware.ScoreChange{
+		Source: middleware.ScoreSourceJudge,
+		JobID:  jobID,
+		Note:   strategy.Name(),
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to update score: %w", err)
 	}
+	e.recordCriterionScores(modelID, promptID, criterionConsensus(evalResp.Results, evalReq.Rubric, strategy))
 
 	// Save evaluation history
 	for _, result := range evalResp.Results {
+		// Failed verdicts get no key so the cache never serves them
+		cacheKey := ""
+		if result.Error == "" {
+			cacheKey = evaluationCacheKey(evalReq, e.judgeCacheIdentity(ctx, set, result.Judge))
+		}
 		_, err = e.db.Exec(`
-			INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, judge_confidence, judge_reasoning, cost_usd)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		`, jobID, modelID, promptID, result.Judge, result.Score, result.Confidence, result.Reasoning, result.CostUSD)
+			INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, judge_confidence, judge_reasoning, cost_usd, cache_key, cache_hit, criteria)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, jobID, modelID, promptID, result.Judge, result.Score, result.Confidence, result.Reasoning, result.CostUSD, cacheKey, cached != nil, encodeCriteria(result.Criteria))
 		if err != nil {
 			log.Printf("Failed to save evaluation history: %v", err)
 		}
 	}
 
+	// Record which strategy produced the score so it can be explained later
+	_, err = e.db.Exec(`
+		INSERT INTO evaluation_results (job_id, model_id, prompt_id, score, raw_score, consensus_strategy, calibration, judge_count, judge_panel, system_prompt_version_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, jobID, modelID, promptID, consensusScore, rawScore, strategy.Name(), calibration, len(evalResp.Results), set.panel, system.versionID)
+	if err != nil {
+		log.Printf("Failed to save evaluation result: %v", err)
+	}
+
 	return evalResp.TotalCostUSD, nil
 }
 
+// judgedPromptCondition matches prompts scored by the judges rather than a checker or hidden tests
+const judgedPromptCondition = "checker = '' AND (language = '' OR test_cases = '')"
+
+// judgedPromptCount counts the suite's prompts that need judges.
+// If the count fails the estimate assumes every prompt is judged.
+func (e *Evaluator) judgedPromptCount(suiteID, promptCount int) int {
+	var judged int
+	if err := e.db.QueryRow("SELECT COUNT(*) FROM prompts WHERE suite_id = ? AND "+judgedPromptCondition, suiteID).Scan(&judged); err != nil {
+		return promptCount
+	}
+	return judged
+}
+
+// scoreWithChecker scores a pair with the prompt's checker and records it like a
+// one-judge evaluation. It returns false, so the judges take over, when the checker
+// is unknown or rejects its configuration.
+func (e *Evaluator) scoreWithChecker(jobID, modelID, promptID int, scale middleware.ScoreScale, name, config, response, solution string) (bool, error) {
+	checker, err := GetChecker(name)
+	if err != nil {
+		log.Printf("Prompt %d: %v, falling back to judges", promptID, err)
+		return false, nil
+	}
+	result, err := checker.Check(response, solution, config)
+	if err != nil {
+		log.Printf("Prompt %d: %s checker failed, falling back to judges: %v", promptID, name, err)
+		return false, nil
+	}
+	if err := e.recordLocalScore(jobID, modelID, promptID, scale, name, result.Score(), result.Reasoning, ""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// scoreWithTests runs the response against the prompt's hidden tests in the sandbox and
+// scores the share that pass. It returns false, so the prompt is scored as if it had
+// no tests, when the tests are unreadable or the sandbox cannot run the language.
+func (e *Evaluator) scoreWithTests(ctx context.Context, jobID, modelID, promptID int, scale middleware.ScoreScale, language, testCases, response string) (bool, error) {
+	var tests []middleware.TestCase
+	if err := json.Unmarshal([]byte(testCases), &tests); err != nil || len(tests) == 0 {
+		log.Printf("Prompt %d: unreadable test cases, skipping code execution: %v", promptID, err)
+		return false, nil
+	}
+
+	sandbox := DefaultSandbox
+	if e.sandbox != nil {
+		sandbox = *e.sandbox
+	}
+	results, err := sandbox.RunTests(ctx, language, response, tests)
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil {
+		log.Printf("Prompt %d: cannot run %s tests, skipping code execution: %v", promptID, language, err)
+		return false, nil
+	}
+
+	score, passed := testPassRatioScore(results)
+	reasoning := fmt.Sprintf("%d/%d hidden tests passed", passed, len(results))
+	for _, r := range results {
+		if !r.Passed {
+			reasoning += fmt.Sprintf("; %s: %s", r.Name, r.Error)
+		}
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode test results: %w", err)
+	}
+
+	if err := e.recordLocalScore(jobID, modelID, promptID, scale, "code:"+language, score, reasoning, string(data)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// recordLocalScore stores a 0-100 score produced without judges: the grid score on the
+// suite's scale, one history row standing in for the judges (with any test output) and
+// the evaluation result
+func (e *Evaluator) recordLocalScore(jobID, modelID, promptID int, scale middleware.ScoreScale, checker string, score int, reasoning, testResults string) error {
+	gridScore := scale.FromPercent(float64(score))
+	err := middleware.WriteScore(e.db, modelID, promptID, gridScore, middleware.ScoreChange{
+		Source: middleware.ScoreSourceJudge,
+		JobID:  jobID,
+		Note:   "checker:" + checker,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update score: %w", err)
+	}
+
+	_, err = e.db.Exec(`
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, judge_confidence, judge_reasoning, cost_usd, test_results)
+		VALUES (?, ?, ?, ?, ?, 1.0, ?, 0, ?)
+	`, jobID, modelID, promptID, "checker:"+checker, score, reasoning, testResults)
+	if err != nil {
+		log.Printf("Failed to save evaluation history: %v", err)
+	}
+
+	_, err = e.db.Exec(`
+		INSERT INTO evaluation_results (job_id, model_id, prompt_id, score, raw_score, consensus_strategy, checker)
+		VALUES (?, ?, ?, ?, ?, '', ?)
+	`, jobID, modelID, promptID, gridScore, score, checker)
+	if err != nil {
+		log.Printf("Failed to save evaluation result: %v", err)
+	}
+	return nil
+}
+
+// waitForJudgeService holds a job while the circuit breaker is open, marking it paused
+// so the progress endpoint can say why nothing is happening
+func (e *Evaluator) waitForJudgeService(ctx context.Context, job *EvaluationJob) error {
+	_, breaker := e.judgeRetry()
+	if breaker == nil {
+		return nil
+	}
+	wait := breaker.Wait()
+	if wait == 0 {
+		return nil
+	}
+
+	log.Printf("Job %d paused for %s: judge service unavailable", job.ID, wait.Round(time.Second))
+	if err := e.jobQueue.setJobStatus(job.ID, "paused", "Judge service unavailable; retrying shortly"); err != nil {
+		log.Printf("Failed to pause job %d: %v", job.ID, err)
+	}
+	for wait > 0 {
+		select {
+		case <-ctx.Done():
+			return errJobCancelled
+		case <-time.After(wait):
+		}
+		wait = breaker.Wait()
+	}
+	if err := e.jobQueue.setJobStatus(job.ID, "running", ""); err != nil {
+		log.Printf("Failed to resume job %d: %v", job.ID, err)
+	}
+	return nil
+}
+
+// recordPairFailure stores why a pair could not be scored, so failed pairs can be
+// told apart from pairs that were never evaluated
+func (e *Evaluator) recordPairFailure(jobID, modelID, promptID int, err error) {
+	attempts := 1
+	var judgeErr *JudgeError
+	if errors.As(err, &judgeErr) {
+		attempts = judgeErr.Attempts
+	}
+	_, dbErr := e.db.Exec(`
+		INSERT INTO evaluation_errors (job_id, model_id, prompt_id, error_kind, error_message, attempts)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, jobID, modelID, promptID, ClassifyError(err), err.Error(), attempts)
+	if dbErr != nil {
+		log.Printf("Failed to save evaluation error: %v", dbErr)
+	}
+}
+
+// summarizeFailedPairs notes on a finished job how many pairs could not be scored
+func summarizeFailedPairs(job *EvaluationJob, failed, total int) {
+	if failed > 0 {
+		job.ErrorMessage = fmt.Sprintf("%d of %d pairs failed", failed, total)
+	}
+}
+
+// suiteSetting reads a per-suite setting ("" when unset)
+func (e *Evaluator) suiteSetting(suiteID int, key string) string {
+	var value string
+	err := e.db.QueryRow("SELECT value FROM suite_settings WHERE suite_id = ? AND key = ?", suiteID, key).Scan(&value)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Failed to read %s for suite %d: %v", key, suiteID, err)
+	}
+	return value
+}
+
+// consensusStrategy returns the strategy selected for a suite, or the default
+// when none is set or the stored name is no longer known
+func (e *Evaluator) consensusStrategy(suiteID int) ConsensusStrategy {
+	name := e.suiteSetting(suiteID, middleware.SuiteSettingConsensusStrategy)
+	strategy, err := GetConsensusStrategy(name)
+	if err != nil {
+		log.Printf("Suite %d: %v, using %s", suiteID, err, DefaultConsensusStrategy)
+		strategy, _ = GetConsensusStrategy(DefaultConsensusStrategy)
+	}
+	return strategy
+}
+
+// scoreScale returns the scale a suite's grid scores are stored on
+func (e *Evaluator) scoreScale(suiteID int) middleware.ScoreScale {
+	return middleware.ResolveScoreScale(e.suiteSetting(suiteID, middleware.SuiteSettingScoreScale))
+}
+
+// SetJudgeProviders replaces the providers used for evaluation.
+// Passing no providers restores the Python service as the only provider.
+func (e *Evaluator) SetJudgeProviders(providers ...JudgeProvider) {
+	e.providersMu.Lock()
+	defer e.providersMu.Unlock()
+	e.providers = providers
+}
+
+// SetRetryPolicy changes how failed judge calls are retried
+func (e *Evaluator) SetRetryPolicy(policy RetryPolicy) {
+	e.providersMu.Lock()
+	defer e.providersMu.Unlock()
+	e.retryPolicy = policy
+}
+
+// SetCircuitBreaker replaces the breaker that pauses jobs while the judges are down.
+// A threshold below 1 disables it.
+func (e *Evaluator) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	e.providersMu.Lock()
+	defer e.providersMu.Unlock()
+	if threshold < 1 {
+		e.breaker = nil
+		return
+	}
+	e.breaker = NewCircuitBreaker(threshold, cooldown)
+}
+
+// SetConcurrency changes how many pairs each job evaluates at once. Jobs already
+// running keep the number they started with.
+func (e *Evaluator) SetConcurrency(n int) {
+	e.providersMu.Lock()
+	defer e.providersMu.Unlock()
+	e.concurrency = n
+}
+
+// SetRateLimits replaces the rate limits, keyed by provider kind ("openai", "python",
+// ...). Kinds without an entry are not limited.
+func (e *Evaluator) SetRateLimits(limits map[string]RateLimit) {
+	e.providersMu.Lock()
+	defer e.providersMu.Unlock()
+	e.rateLimits = limits
+	e.limiters = make(map[string]*RateLimiter)
+}
+
+// pairConcurrency returns how many pairs a job evaluates at once
+func (e *Evaluator) pairConcurrency() int {
+	e.providersMu.RLock()
+	defer e.providersMu.RUnlock()
+	if e.concurrency < 1 {
+		return 1
+	}
+	return e.concurrency
+}
+
+// rateLimiter returns the limiter of a provider's bucket, or nil when it is unlimited.
+// Providers sharing a bucket share the limiter, so every judge of one account draws
+// on the same quota.
+func (e *Evaluator) rateLimiter(p JudgeProvider) *RateLimiter {
+	kind, bucket := rateLimitBucket(p)
+	e.providersMu.RLock()
+	limiter, ok := e.limiters[bucket]
+	e.providersMu.RUnlock()
+	if ok {
+		return limiter
+	}
+
+	e.providersMu.Lock()
+	defer e.providersMu.Unlock()
+	if limiter, ok := e.limiters[bucket]; ok {
+		return limiter
+	}
+	limiter = NewRateLimiter(e.rateLimits[kind])
+	if e.limiters == nil {
+		e.limiters = make(map[string]*RateLimiter)
+	}
+	e.limiters[bucket] = limiter
+	return limiter
+}
+
+// rateLimitBucket returns the kind a provider's limits are configured under and the
+// bucket its requests count against: the kind and the endpoint it calls
+func rateLimitBucket(p JudgeProvider) (kind, bucket string) {
+	switch p := p.(type) {
+	case *OpenAIProvider:
+		return p.config.Provider, p.config.Provider + " " + p.config.BaseURL
+	case *AnthropicProvider:
+		return p.config.Provider, p.config.Provider + " " + p.config.BaseURL
+	case *LiteLLMClient:
+		return p.Name(), p.Name() + " " + p.baseURL
+	default:
+		return p.Name(), p.Name()
+	}
+}
+
+// judgeRetry returns the active retry policy and circuit breaker
+func (e *Evaluator) judgeRetry() (RetryPolicy, *CircuitBreaker) {
+	e.providersMu.RLock()
+	defer e.providersMu.RUnlock()
+	return e.retryPolicy, e.breaker
+}
+
+// judgeProviders returns the active providers
+func (e *Evaluator) judgeProviders() []JudgeProvider {
+	e.providersMu.RLock()
+	defer e.providersMu.RUnlock()
+	if len(e.providers) == 0 {
+		return []JudgeProvider{e.litellmClient}
+	}
+	return append([]JudgeProvider(nil), e.providers...)
+}
+
+// JudgeProviderNames lists the active providers by name
+func (e *Evaluator) JudgeProviderNames() []string {
+	var names []string
+	for _, p := range e.judgeProviders() {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// runJudges sends the request to every active provider in parallel and merges the verdicts
+func (e *Evaluator) runJudges(ctx context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	return e.runJudgesWith(ctx, req, e.judgeProviders())
+}
+
+// runJudgesWith sends the request to the given providers in parallel and merges the verdicts.
+// Multi-judge providers (the Python service) skip judges a native provider already covers.
+func (e *Evaluator) runJudgesWith(ctx context.Context, req EvaluationRequest, providers []JudgeProvider) (*EvaluationResponse, error) {
+	policy, breaker := e.judgeRetry()
+	native := nativeJudgeNames(providers)
+
+	release, err := acquireJudgeCall(ctx, breaker)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	responses := make([]*EvaluationResponse, len(providers))
+	errs := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		providerReq := req
+		var run bool
+		providerReq.Judges, run = judgesFor(p, native, req.Judges)
+		if !run {
+			continue
+		}
+
+		limiter, tokens := e.rateLimiter(p), estimateTokens(providerReq, judgeCount(p, providerReq.Judges))
+
+		wg.Add(1)
+		go func(i int, p JudgeProvider, r EvaluationRequest) {
+			defer wg.Done()
+			responses[i], errs[i] = evaluateWithRetry(ctx, p, r, policy, limiter, tokens)
+		}(i, p, providerReq)
+	}
+	wg.Wait()
+
+	var succeeded []*EvaluationResponse
+	var firstErr error
+	for i, resp := range responses {
+		if errs[i] != nil {
+			log.Printf("Judge provider %s failed: %v", providers[i].Name(), errs[i])
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		if resp != nil {
+			succeeded = append(succeeded, resp)
+		}
+	}
+
+	if len(succeeded) == 0 {
+		if firstErr == nil {
+			return nil, fmt.Errorf("no judge providers available")
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		recordJudgeCall(breaker, firstErr)
+		return nil, firstErr
+	}
+	recordJudgeCall(breaker, nil)
+	if len(succeeded) == 1 {
+		return succeeded[0], nil
+	}
+
+	return mergeEvaluationResponses(succeeded), nil
+}
+
+// acquireJudgeCall holds a pair's judge calls while the circuit breaker is open. Pairs
+// handed out before the circuit opened wait here, and once it is half open only one of
+// them probes the service. The returned release must be called once the calls are
+// done and recorded.
+func acquireJudgeCall(ctx context.Context, breaker *CircuitBreaker) (release func(), err error) {
+	var trial bool
+	for breaker != nil {
+		var ok bool
+		if ok, trial = breaker.Acquire(); ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(breaker.Wait()):
+		}
+	}
+	if !trial {
+		return func() {}, nil
+	}
+	// A trial that was neither recorded as a success nor a failure (a rejected
+	// request, a cancelled job) lets the next call try instead
+	return breaker.Release, nil
+}
+
+// recordJudgeCall tells the circuit breaker how a pair's judge calls went: err is nil
+// when any judge answered. Only transient failures count towards opening the
+// circuit; a rejected request means the service is up.
+func recordJudgeCall(breaker *CircuitBreaker, err error) {
+	if breaker == nil {
+		return
+	}
+	if err == nil {
+		breaker.RecordSuccess()
+		return
+	}
+	if ClassifyError(err) == ErrorKindRetryable && breaker.RecordFailure() {
+		log.Printf("Judge service unavailable, pausing evaluations for %s", breaker.Wait().Round(time.Second))
+	}
+}
+
+// nativeJudgeNames lists the judges handled by single-judge (non-Python) providers
+func nativeJudgeNames(providers []JudgeProvider) map[string]bool {
+	native := make(map[string]bool)
+	for _, p := range providers {
+		if _, ok := p.(*LiteLLMClient); !ok {
+			native[p.Name()] = true
+		}
+	}
+	return native
+}
+
+// judgeCount is how many judges a provider runs for a request; native providers run
+// their own judge whatever the list says
+func judgeCount(p JudgeProvider, judges []string) int {
+	if _, ok := p.(*LiteLLMClient); ok {
+		return len(judges)
+	}
+	return 1
+}
+
+// judgesFor returns the judges a provider should run and whether to call it at all.
+// The Python service skips judges a native provider already covers; native providers ignore the list.
+func judgesFor(p JudgeProvider, native map[string]bool, judges []string) ([]string, bool) {
+	if _, ok := p.(*LiteLLMClient); !ok || len(native) == 0 {
+		return judges, true
+	}
+	var remaining []string
+	for _, judge := range judges {
+		if !native[judge] {
+			remaining = append(remaining, judge)
+		}
+	}
+	return remaining, len(remaining) > 0
+}
+
+// mergeEvaluationResponses combines verdicts from several providers into one response
+func mergeEvaluationResponses(responses []*EvaluationResponse) *EvaluationResponse {
+	merged := &EvaluationResponse{}
+	for _, resp := range responses {
+		merged.Results = append(merged.Results, resp.Results...)
+		merged.TotalCostUSD += resp.TotalCostUSD
+	}
+
+	merged.ConsensusScore = CalculateConsensusScore(merged.Results)
+
+	confidenceSum := 0.0
+	for _, r := range merged.Results {
+		confidenceSum += r.Confidence
+	}
+	if len(merged.Results) > 0 {
+		merged.AvgConfidence = confidenceSum / float64(len(merged.Results))
+	}
+
+	return merged
+}
+
 // getAPIKeys retrieves API keys from settings
 func (e *Evaluator) getAPIKeys() (map[string]string, error) {
 	rows, err := e.db.Query(`
@@ -409,3 +1104,25 @@ func (e *Evaluator) GetJobStatus(jobID int) (*EvaluationJob, error) {
 func (e *Evaluator) CancelJob(jobID int) error {
 	return e.jobQueue.CancelJob(jobID)
 }
+
+// ResumeJob queues a stopped job again, skipping the pairs it already finished
+func (e *Evaluator) ResumeJob(jobID int) error {
+	return e.jobQueue.ResumeJob(jobID)
+}
+
+// RestartJob queues a stopped job to evaluate every pair again
+func (e *Evaluator) RestartJob(jobID int) error {
+	return e.jobQueue.RestartJob(jobID)
+}
+
+// RemoveJob deletes a job that has not started yet
+func (e *Evaluator) RemoveJob(jobID int) error {
+	return e.jobQueue.RemoveJob(jobID)
+}
+
+// Shutdown cancels running jobs, aborting their outstanding requests, and waits up to
+// timeout for them to stop. The jobs resume when the server next starts.
+func (e *Evaluator) Shutdown(timeout time.Duration) {
+	e.auto.stop()
+	e.jobQueue.Shutdown(timeout)
+}
@@ -1,11 +1,14 @@
 package evaluator
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +22,8 @@ func setupEvaluatorTestDB(t *testing.T) *sql.DB {
 	if err != nil {
 		t.Fatalf("failed to open test database: %v", err)
 	}
+	// Every connection to :memory: is a separate database; jobs use the pool from several goroutines
+	db.SetMaxOpenConns(1)
 
 	schema := `
 		CREATE TABLE IF NOT EXISTS suites (
@@ -32,6 +37,7 @@ func setupEvaluatorTestDB(t *testing.T) *sql.DB {
 			name TEXT NOT NULL,
 			description TEXT DEFAULT '',
 			suite_id INTEGER NOT NULL,
+			rubric TEXT DEFAULT '',
 			FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE,
 			UNIQUE(name, suite_id)
 		);
@@ -44,10 +50,25 @@ func setupEvaluatorTestDB(t *testing.T) *sql.DB {
 			suite_id INTEGER NOT NULL,
 			display_order INTEGER DEFAULT 0,
 			type TEXT DEFAULT 'objective',
+			checker TEXT DEFAULT '',
+			checker_config TEXT DEFAULT '',
+			language TEXT DEFAULT '',
+			test_cases TEXT DEFAULT '',
+			rubric TEXT DEFAULT '',
 			FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE,
 			FOREIGN KEY (profile_id) REFERENCES profiles(id) ON DELETE SET NULL
 		);
 
+		CREATE TABLE IF NOT EXISTS prompt_turns (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			prompt_id INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			content TEXT NOT NULL,
+			FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE,
+			UNIQUE(prompt_id, position)
+		);
+
 		CREATE TABLE IF NOT EXISTS models (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			name TEXT NOT NULL,
@@ -66,6 +87,35 @@ func setupEvaluatorTestDB(t *testing.T) *sql.DB {
 			UNIQUE(model_id, prompt_id)
 		);
 
+		CREATE TABLE IF NOT EXISTS score_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			model_id INTEGER,
+			prompt_id INTEGER,
+			model_name TEXT NOT NULL DEFAULT '',
+			prompt_text TEXT NOT NULL DEFAULT '',
+			old_score INTEGER,
+			new_score INTEGER,
+			source TEXT NOT NULL,
+			job_id INTEGER,
+			note TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE SET NULL,
+			FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE SET NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS criterion_scores (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			model_id INTEGER NOT NULL,
+			prompt_id INTEGER NOT NULL,
+			criterion TEXT NOT NULL,
+			score INTEGER NOT NULL,
+			weight REAL DEFAULT 1,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
+			FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE,
+			UNIQUE(model_id, prompt_id, criterion)
+		);
+
 		CREATE TABLE IF NOT EXISTS settings (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			key TEXT UNIQUE NOT NULL,
@@ -85,6 +135,11 @@ func setupEvaluatorTestDB(t *testing.T) *sql.DB {
 			estimated_cost_usd REAL DEFAULT 0,
 			actual_cost_usd REAL DEFAULT 0,
 			error_message TEXT DEFAULT '',
+			force_refresh INTEGER NOT NULL DEFAULT 0,
+			cache_hits INTEGER NOT NULL DEFAULT 0,
+			cache_misses INTEGER NOT NULL DEFAULT 0,
+			cache_saved_usd REAL NOT NULL DEFAULT 0,
+			judge_panel TEXT NOT NULL DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			started_at DATETIME,
 			completed_at DATETIME,
@@ -98,6 +153,7 @@ func setupEvaluatorTestDB(t *testing.T) *sql.DB {
 			response_text TEXT DEFAULT '',
 			response_source TEXT DEFAULT '',
 			api_config TEXT DEFAULT '',
+			system_prompt_version_id INTEGER,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
@@ -114,12 +170,160 @@ func setupEvaluatorTestDB(t *testing.T) *sql.DB {
 			judge_confidence REAL DEFAULT 0,
 			judge_reasoning TEXT DEFAULT '',
 			cost_usd REAL DEFAULT 0,
+			test_results TEXT DEFAULT '',
+			cache_key TEXT NOT NULL DEFAULT '',
+			cache_hit INTEGER NOT NULL DEFAULT 0,
+			criteria TEXT DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (job_id) REFERENCES evaluation_jobs(id) ON DELETE CASCADE,
 			FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
 			FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE
 		);
 
+		CREATE TABLE IF NOT EXISTS suite_settings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			suite_id INTEGER NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE,
+			UNIQUE(suite_id, key)
+		);
+
+		CREATE TABLE IF NOT EXISTS evaluation_results (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_id INTEGER NOT NULL,
+			model_id INTEGER NOT NULL,
+			prompt_id INTEGER NOT NULL,
+			score INTEGER NOT NULL,
+			raw_score INTEGER NOT NULL,
+			consensus_strategy TEXT NOT NULL,
+			calibration TEXT NOT NULL DEFAULT '',
+			judge_count INTEGER NOT NULL DEFAULT 0,
+			checker TEXT NOT NULL DEFAULT '',
+			judge_panel TEXT NOT NULL DEFAULT '',
+			system_prompt_version_id INTEGER,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS evaluation_errors (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_id INTEGER NOT NULL,
+			model_id INTEGER NOT NULL,
+			prompt_id INTEGER NOT NULL,
+			error_kind TEXT NOT NULL,
+			error_message TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 1,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS cost_tracking (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			suite_id INTEGER NOT NULL,
+			date DATE NOT NULL,
+			total_cost_usd REAL DEFAULT 0.0,
+			evaluation_count INTEGER DEFAULT 0,
+			UNIQUE(suite_id, date)
+		);
+
+		CREATE TABLE IF NOT EXISTS evaluation_job_pairs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_id INTEGER NOT NULL,
+			model_id INTEGER NOT NULL,
+			prompt_id INTEGER NOT NULL,
+			outcome TEXT NOT NULL DEFAULT 'pending',
+			cost_usd REAL NOT NULL DEFAULT 0.0,
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(job_id, model_id, prompt_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS battle_job_pairs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_id INTEGER NOT NULL,
+			prompt_id INTEGER NOT NULL,
+			model_a_id INTEGER NOT NULL,
+			model_b_id INTEGER NOT NULL,
+			cost_usd REAL NOT NULL DEFAULT 0.0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(job_id, prompt_id, model_a_id, model_b_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS gold_scores (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			model_id INTEGER NOT NULL,
+			prompt_id INTEGER NOT NULL,
+			score INTEGER NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(model_id, prompt_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS judges (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE NOT NULL,
+			provider TEXT NOT NULL DEFAULT 'backend',
+			model TEXT NOT NULL DEFAULT '',
+			base_url TEXT NOT NULL DEFAULT '',
+			temperature REAL,
+			max_tokens INTEGER NOT NULL DEFAULT 0,
+			weight REAL NOT NULL DEFAULT 1
+		);
+
+		CREATE TABLE IF NOT EXISTS judge_panels (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS judge_panel_members (
+			panel_id INTEGER NOT NULL,
+			judge_id INTEGER NOT NULL,
+			PRIMARY KEY (panel_id, judge_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS profile_judge_panels (
+			suite_id INTEGER NOT NULL,
+			profile_name TEXT NOT NULL,
+			panel_name TEXT NOT NULL,
+			PRIMARY KEY (suite_id, profile_name)
+		);
+
+		CREATE TABLE IF NOT EXISTS model_configs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			model_id INTEGER NOT NULL UNIQUE,
+			base_url TEXT NOT NULL DEFAULT '',
+			model_name TEXT NOT NULL DEFAULT '',
+			api_key TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL DEFAULT 'openai',
+			temperature REAL,
+			top_p REAL,
+			max_tokens INTEGER NOT NULL DEFAULT 0,
+			system_prompt TEXT NOT NULL DEFAULT '',
+			system_prompt_name TEXT NOT NULL DEFAULT '',
+			stop_sequences TEXT NOT NULL DEFAULT '[]'
+		);
+
+		CREATE TABLE IF NOT EXISTS system_prompts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE NOT NULL,
+			description TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE TABLE IF NOT EXISTS system_prompt_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			system_prompt_id INTEGER NOT NULL,
+			version INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(system_prompt_id, version)
+		);
+
+		CREATE TABLE IF NOT EXISTS profile_system_prompts (
+			suite_id INTEGER NOT NULL,
+			profile_name TEXT NOT NULL,
+			system_prompt_name TEXT NOT NULL,
+			PRIMARY KEY (suite_id, profile_name)
+		);
+
 		INSERT INTO suites (name, is_current) VALUES ('default', 1);
 		INSERT INTO settings (key, value) VALUES ('api_key_anthropic', '');
 		INSERT INTO settings (key, value) VALUES ('api_key_openai', '');
@@ -185,13 +389,13 @@ func TestEvaluateAll_NoData(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
 	// With no prompts/models, total should be 0
-	jobID, err := evaluator.EvaluateAll(1)
+	jobID, err := evaluator.EvaluateAll(1, false)
 	if err != nil {
 		t.Fatalf("EvaluateAll failed: %v", err)
 	}
@@ -237,12 +441,12 @@ func TestEvaluateAll_WithData(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
-	jobID, err := evaluator.EvaluateAll(1)
+	jobID, err := evaluator.EvaluateAll(1, false)
 	if err != nil {
 		t.Fatalf("EvaluateAll failed: %v", err)
 	}
@@ -279,12 +483,12 @@ func TestEvaluateAll_CountPromptsError(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
-	_, err := e.EvaluateAll(1)
+	_, err := e.EvaluateAll(1, false)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -310,12 +514,12 @@ func TestEvaluateAll_CountModelsError(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
-	_, err := e.EvaluateAll(1)
+	_, err := e.EvaluateAll(1, false)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -341,12 +545,12 @@ func TestEvaluateAll_EnqueueError(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
-	_, err := e.EvaluateAll(1)
+	_, err := e.EvaluateAll(1, false)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -383,12 +587,12 @@ func TestEvaluateModel(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
-	jobID, err := evaluator.EvaluateModel(int(modelID))
+	jobID, err := evaluator.EvaluateModel(int(modelID), false)
 	if err != nil {
 		t.Fatalf("EvaluateModel failed: %v", err)
 	}
@@ -434,12 +638,12 @@ func TestEvaluateModel_CountPromptsError(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
-	_, err = e.EvaluateModel(int(modelID))
+	_, err = e.EvaluateModel(int(modelID), false)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -474,12 +678,12 @@ func TestEvaluateModel_EnqueueError(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
-	_, err = e.EvaluateModel(int(modelID))
+	_, err = e.EvaluateModel(int(modelID), false)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -514,12 +718,12 @@ func TestEvaluatePrompt_CountModelsError(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
-	_, err = e.EvaluatePrompt(int(promptID))
+	_, err = e.EvaluatePrompt(int(promptID), false)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -554,12 +758,12 @@ func TestEvaluatePrompt_EnqueueError(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
-	_, err = e.EvaluatePrompt(int(promptID))
+	_, err = e.EvaluatePrompt(int(promptID), false)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -624,14 +828,14 @@ func TestEvaluateModelPromptPair_NoResponse_SkipsEvaluation(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
-	cost, err := e.evaluateModelPromptPair(int(jobID), int(modelID), int(promptID))
-	if err != nil {
-		t.Fatalf("expected no error, got %v", err)
+	cost, err := e.evaluateModelPromptPair(context.Background(), int(jobID), int(modelID), int(promptID))
+	if !errors.Is(err, errNoResponse) {
+		t.Fatalf("expected errNoResponse, got %v", err)
 	}
 	if cost != 0 {
 		t.Fatalf("expected cost 0, got %f", cost)
@@ -701,12 +905,12 @@ func TestEvaluateModelPromptPair_Success_WritesScoreAndHistory(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
-	cost, err := e.evaluateModelPromptPair(int(jobID), int(modelID), int(promptID))
+	cost, err := e.evaluateModelPromptPair(context.Background(), int(jobID), int(modelID), int(promptID))
 	if err != nil {
 		t.Fatalf("evaluateModelPromptPair failed: %v", err)
 	}
@@ -731,6 +935,18 @@ func TestEvaluateModelPromptPair_Success_WritesScoreAndHistory(t *testing.T) {
 	if historyCount != 1 {
 		t.Fatalf("expected 1 history record, got %d", historyCount)
 	}
+
+	var source string
+	var changeJobID, newScore int
+	var oldScore sql.NullInt64
+	err = db.QueryRow("SELECT source, job_id, old_score, new_score FROM score_history WHERE model_id = ? AND prompt_id = ?",
+		modelID, promptID).Scan(&source, &changeJobID, &oldScore, &newScore)
+	if err != nil {
+		t.Fatalf("failed to query score history: %v", err)
+	}
+	if source != "judge" || changeJobID != int(jobID) || oldScore.Valid || newScore != 60 {
+		t.Errorf("expected the judge change of job %d from unscored to 60, got %s job %d %v→%d", jobID, source, changeJobID, oldScore, newScore)
+	}
 }
 
 func TestProcessAllJob_Cancelled(t *testing.T) {
@@ -754,7 +970,7 @@ func TestProcessAllJob_Cancelled(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
@@ -769,9 +985,9 @@ func TestProcessAllJob_Cancelled(t *testing.T) {
 	}
 	job := &EvaluationJob{ID: int(jobID), SuiteID: 1, JobType: "all", ProgressTotal: 1}
 
-	cancelChan := make(chan bool)
-	close(cancelChan)
-	if err := e.processAllJob(job, cancelChan); err == nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.processAllJob(ctx, job); err == nil {
 		t.Fatal("expected error for cancelled job")
 	}
 }
@@ -793,13 +1009,13 @@ func TestProcessAllJob_QueryModelsError(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 0}
-	err := e.processAllJob(job, make(chan bool))
+	err := e.processAllJob(context.Background(), job)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -828,13 +1044,13 @@ func TestProcessAllJob_QueryPromptsError(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 0}
-	err := e.processAllJob(job, make(chan bool))
+	err := e.processAllJob(context.Background(), job)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -867,13 +1083,13 @@ func TestProcessAllJob_EvaluatePairError_Continues(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 1}
-	if err := e.processAllJob(job, make(chan bool)); err != nil {
+	if err := e.processAllJob(context.Background(), job); err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 }
@@ -903,13 +1119,13 @@ func TestProcessAllJob_UpdateProgressError_Continues(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	e.jobQueue.evaluator = e
 
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 1}
-	if err := e.processAllJob(job, make(chan bool)); err != nil {
+	if err := e.processAllJob(context.Background(), job); err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 }
@@ -935,7 +1151,7 @@ func TestEvaluateModelPromptPair_ModelResponseQueryError(t *testing.T) {
 	}
 
 	e := &Evaluator{db: db, litellmClient: NewLiteLLMClient("http://localhost:8001"), judges: []string{"claude"}}
-	_, err = e.evaluateModelPromptPair(1, int(modelID), int(promptID))
+	_, err = e.evaluateModelPromptPair(context.Background(), 1, int(modelID), int(promptID))
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -970,7 +1186,7 @@ func TestEvaluateModelPromptPair_GetAPIKeysError(t *testing.T) {
 	}
 
 	e := &Evaluator{db: db, litellmClient: NewLiteLLMClient("http://localhost:8001"), judges: []string{"claude"}}
-	_, err = e.evaluateModelPromptPair(1, int(modelID), int(promptID))
+	_, err = e.evaluateModelPromptPair(context.Background(), 1, int(modelID), int(promptID))
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -1006,7 +1222,7 @@ func TestEvaluateModelPromptPair_EvaluateError(t *testing.T) {
 	}
 
 	e := &Evaluator{db: db, litellmClient: NewLiteLLMClient(server.URL), judges: []string{"claude"}}
-	_, err = e.evaluateModelPromptPair(1, int(modelID), int(promptID))
+	_, err = e.evaluateModelPromptPair(context.Background(), 1, int(modelID), int(promptID))
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -1052,7 +1268,7 @@ func TestEvaluateModelPromptPair_UpdateScoreError(t *testing.T) {
 	}
 
 	e := &Evaluator{db: db, litellmClient: NewLiteLLMClient(server.URL), judges: []string{"claude"}}
-	_, err = e.evaluateModelPromptPair(1, int(modelID), int(promptID))
+	_, err = e.evaluateModelPromptPair(context.Background(), 1, int(modelID), int(promptID))
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -1098,7 +1314,7 @@ func TestEvaluateModelPromptPair_HistoryInsertError_IsLogged(t *testing.T) {
 	}
 
 	e := &Evaluator{db: db, litellmClient: NewLiteLLMClient(server.URL), judges: []string{"claude"}}
-	cost, err := e.evaluateModelPromptPair(1, int(modelID), int(promptID))
+	cost, err := e.evaluateModelPromptPair(context.Background(), 1, int(modelID), int(promptID))
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -1135,12 +1351,12 @@ func TestEvaluateModel_NotFound(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
-	_, err := evaluator.EvaluateModel(999)
+	_, err := evaluator.EvaluateModel(999, false)
 	if err == nil {
 		t.Error("expected error for non-existent model")
 	}
@@ -1175,12 +1391,12 @@ func TestEvaluatePrompt(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
-	jobID, err := evaluator.EvaluatePrompt(int(promptID))
+	jobID, err := evaluator.EvaluatePrompt(int(promptID), false)
 	if err != nil {
 		t.Fatalf("EvaluatePrompt failed: %v", err)
 	}
@@ -1213,12 +1429,12 @@ func TestEvaluatePrompt_NotFound(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
-	_, err := evaluator.EvaluatePrompt(999)
+	_, err := evaluator.EvaluatePrompt(999, false)
 	if err == nil {
 		t.Error("expected error for non-existent prompt")
 	}
@@ -1237,13 +1453,13 @@ func TestGetJobStatus(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
 	// Create a job
-	jobID, err := evaluator.EvaluateAll(1)
+	jobID, err := evaluator.EvaluateAll(1, false)
 	if err != nil {
 		t.Fatalf("EvaluateAll failed: %v", err)
 	}
@@ -1275,21 +1491,33 @@ func TestCancelJob(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
 	// Create a job
-	jobID, err := evaluator.EvaluateAll(1)
+	jobID, err := evaluator.EvaluateAll(1, false)
 	if err != nil {
 		t.Fatalf("EvaluateAll failed: %v", err)
 	}
 
-	// Try to cancel (will fail because job isn't "running")
+	// A pending job can be cancelled before a worker picks it up
+	if err := evaluator.CancelJob(jobID); err != nil {
+		t.Fatalf("CancelJob on pending job failed: %v", err)
+	}
+	var status string
+	if err := db.QueryRow("SELECT status FROM evaluation_jobs WHERE id = ?", jobID).Scan(&status); err != nil {
+		t.Fatalf("failed to query job: %v", err)
+	}
+	if status != "cancelled" {
+		t.Errorf("expected status 'cancelled', got %q", status)
+	}
+
+	// Once cancelled it is neither running nor pending
 	err = evaluator.CancelJob(jobID)
 	if err == nil {
-		t.Error("expected error when cancelling non-running job")
+		t.Error("expected error when cancelling a job that is no longer queued")
 	}
 }
 
@@ -1306,7 +1534,7 @@ func TestProcessJob_UnknownType(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1315,8 +1543,7 @@ func TestProcessJob_UnknownType(t *testing.T) {
 		JobType: "unknown",
 	}
 
-	cancelChan := make(chan bool)
-	err := evaluator.processJob(job, cancelChan)
+	err := evaluator.processJob(context.Background(), job)
 	if err == nil {
 		t.Error("expected error for unknown job type")
 	}
@@ -1335,7 +1562,7 @@ func TestProcessAllJob_NoData(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1346,8 +1573,7 @@ func TestProcessAllJob_NoData(t *testing.T) {
 		JobType: "all",
 	}
 
-	cancelChan := make(chan bool)
-	err := evaluator.processAllJob(job, cancelChan)
+	err := evaluator.processAllJob(context.Background(), job)
 	// Should succeed with no data (nothing to process)
 	if err != nil {
 		t.Errorf("processAllJob with no data failed: %v", err)
@@ -1373,7 +1599,7 @@ func TestProcessModelJob_NoPrompts(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1385,8 +1611,7 @@ func TestProcessModelJob_NoPrompts(t *testing.T) {
 		TargetID: 1,
 	}
 
-	cancelChan := make(chan bool)
-	err = evaluator.processModelJob(job, cancelChan)
+	err = evaluator.processModelJob(context.Background(), job)
 	// Should succeed with no prompts
 	if err != nil {
 		t.Errorf("processModelJob with no prompts failed: %v", err)
@@ -1412,7 +1637,7 @@ func TestProcessPromptJob_NoModels(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1424,8 +1649,7 @@ func TestProcessPromptJob_NoModels(t *testing.T) {
 		TargetID: 1,
 	}
 
-	cancelChan := make(chan bool)
-	err = evaluator.processPromptJob(job, cancelChan)
+	err = evaluator.processPromptJob(context.Background(), job)
 	// Should succeed with no models
 	if err != nil {
 		t.Errorf("processPromptJob with no models failed: %v", err)
@@ -1484,9 +1708,9 @@ func TestEvaluateModelPromptPair_NoResponse(t *testing.T) {
 	}
 
 	// Without a model response, it should skip evaluation and return 0 cost
-	cost, err := evaluator.evaluateModelPromptPair(1, 1, 1)
-	if err != nil {
-		t.Errorf("expected no error for missing response, got: %v", err)
+	cost, err := evaluator.evaluateModelPromptPair(context.Background(), 1, 1, 1)
+	if !errors.Is(err, errNoResponse) {
+		t.Errorf("expected errNoResponse for missing response, got: %v", err)
 	}
 	if cost != 0 {
 		t.Errorf("expected 0 cost for skipped evaluation, got: %f", cost)
@@ -1504,7 +1728,7 @@ func TestEvaluateModelPromptPair_PromptNotFound(t *testing.T) {
 	}
 
 	// Non-existent prompt should return error
-	_, err := evaluator.evaluateModelPromptPair(1, 1, 999)
+	_, err := evaluator.evaluateModelPromptPair(context.Background(), 1, 1, 999)
 	if err == nil {
 		t.Error("expected error for non-existent prompt")
 	}
@@ -1533,7 +1757,7 @@ func TestProcessAllJob_Cancellation(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1546,10 +1770,10 @@ func TestProcessAllJob_Cancellation(t *testing.T) {
 	}
 
 	// Create a pre-closed cancel channel
-	cancelChan := make(chan bool, 1)
-	cancelChan <- true
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	err = evaluator.processAllJob(job, cancelChan)
+	err = evaluator.processAllJob(ctx, job)
 	if err == nil || err.Error() != "job cancelled" {
 		t.Errorf("expected 'job cancelled' error, got: %v", err)
 	}
@@ -1578,7 +1802,7 @@ func TestProcessModelJob_Cancellation(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1592,10 +1816,10 @@ func TestProcessModelJob_Cancellation(t *testing.T) {
 	}
 
 	// Create a pre-closed cancel channel
-	cancelChan := make(chan bool, 1)
-	cancelChan <- true
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	err = evaluator.processModelJob(job, cancelChan)
+	err = evaluator.processModelJob(ctx, job)
 	if err == nil || err.Error() != "job cancelled" {
 		t.Errorf("expected 'job cancelled' error, got: %v", err)
 	}
@@ -1624,7 +1848,7 @@ func TestProcessPromptJob_Cancellation(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1638,10 +1862,10 @@ func TestProcessPromptJob_Cancellation(t *testing.T) {
 	}
 
 	// Create a pre-closed cancel channel
-	cancelChan := make(chan bool, 1)
-	cancelChan <- true
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	err = evaluator.processPromptJob(job, cancelChan)
+	err = evaluator.processPromptJob(ctx, job)
 	if err == nil || err.Error() != "job cancelled" {
 		t.Errorf("expected 'job cancelled' error, got: %v", err)
 	}
@@ -1716,12 +1940,12 @@ func TestEvaluateModelPromptPair_WithMockServer_Success(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
-	cost, err := evaluator.evaluateModelPromptPair(1, 1, 1)
+	cost, err := evaluator.evaluateModelPromptPair(context.Background(), 1, 1, 1)
 	if err != nil {
 		t.Fatalf("evaluateModelPromptPair failed: %v", err)
 	}
@@ -1770,7 +1994,7 @@ func TestEvaluateModelPromptPair_WithMockServer_HTTPError(t *testing.T) {
 		judges:        []string{"claude"},
 	}
 
-	_, err := evaluator.evaluateModelPromptPair(1, 1, 1)
+	_, err := evaluator.evaluateModelPromptPair(context.Background(), 1, 1, 1)
 	if err == nil {
 		t.Error("expected error for HTTP 500 response")
 	}
@@ -1807,12 +2031,12 @@ func TestEvaluateModelPromptPair_WithMockServer_LowScore(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
 
-	_, err := evaluator.evaluateModelPromptPair(1, 1, 1)
+	_, err := evaluator.evaluateModelPromptPair(context.Background(), 1, 1, 1)
 	if err != nil {
 		t.Fatalf("evaluateModelPromptPair failed: %v", err)
 	}
@@ -1857,7 +2081,7 @@ func TestProcessAllJob_WithMockServer(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1869,8 +2093,7 @@ func TestProcessAllJob_WithMockServer(t *testing.T) {
 		ProgressTotal: 2,
 	}
 
-	cancelChan := make(chan bool)
-	err := evaluator.processAllJob(job, cancelChan)
+	err := evaluator.processAllJob(context.Background(), job)
 	if err != nil {
 		t.Errorf("processAllJob failed: %v", err)
 	}
@@ -1913,7 +2136,7 @@ func TestProcessModelJob_WithMockServer(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1926,8 +2149,7 @@ func TestProcessModelJob_WithMockServer(t *testing.T) {
 		ProgressTotal: 1,
 	}
 
-	cancelChan := make(chan bool)
-	err := evaluator.processModelJob(job, cancelChan)
+	err := evaluator.processModelJob(context.Background(), job)
 	if err != nil {
 		t.Errorf("processModelJob failed: %v", err)
 	}
@@ -1972,7 +2194,7 @@ func TestProcessPromptJob_WithMockServer(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -1985,8 +2207,7 @@ func TestProcessPromptJob_WithMockServer(t *testing.T) {
 		ProgressTotal: 2,
 	}
 
-	cancelChan := make(chan bool)
-	err := evaluator.processPromptJob(job, cancelChan)
+	err := evaluator.processPromptJob(context.Background(), job)
 	if err != nil {
 		t.Errorf("processPromptJob failed: %v", err)
 	}
@@ -2031,7 +2252,7 @@ func TestWorker_ProcessesJob(t *testing.T) {
 		jobs:      make(chan *EvaluationJob, 100),
 		workers:   1,
 		running:   make(map[int]bool),
-		cancel:    make(map[int]chan bool),
+		cancel:    make(map[int]context.CancelFunc),
 		evaluator: evaluator,
 	}
 	evaluator.jobQueue = jq
@@ -2087,7 +2308,7 @@ func TestWorker_FailedJob(t *testing.T) {
 		jobs:      make(chan *EvaluationJob, 100),
 		workers:   1,
 		running:   make(map[int]bool),
-		cancel:    make(map[int]chan bool),
+		cancel:    make(map[int]context.CancelFunc),
 		evaluator: evaluator,
 	}
 	evaluator.jobQueue = jq
@@ -2161,13 +2382,14 @@ func TestJobQueue_CancelRunningJob(t *testing.T) {
 		db:      db,
 		jobs:    make(chan *EvaluationJob, 100),
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	// Simulate a running job
+	ctx, cancel := context.WithCancel(context.Background())
 	jq.mu.Lock()
 	jq.running[1] = true
-	jq.cancel[1] = make(chan bool, 1)
+	jq.cancel[1] = cancel
 	jq.mu.Unlock()
 
 	// Create job in DB
@@ -2181,6 +2403,9 @@ func TestJobQueue_CancelRunningJob(t *testing.T) {
 	if err != nil {
 		t.Errorf("CancelJob failed: %v", err)
 	}
+	if ctx.Err() == nil {
+		t.Error("expected the job's context to be cancelled")
+	}
 
 	// Verify status updated
 	var status string
@@ -2209,7 +2434,7 @@ func TestLiteLLMClient_Evaluate_Success(t *testing.T) {
 		Judges:   []string{"claude"},
 	}
 
-	resp, err := client.Evaluate(req)
+	resp, err := client.Evaluate(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Evaluate failed: %v", err)
 	}
@@ -2230,7 +2455,7 @@ func TestLiteLLMClient_Evaluate_Error(t *testing.T) {
 		Judges:   []string{"claude"},
 	}
 
-	_, err := client.Evaluate(req)
+	_, err := client.Evaluate(context.Background(), req)
 	if err == nil {
 		t.Error("expected error for HTTP 500")
 	}
@@ -2241,7 +2466,7 @@ func TestLiteLLMClient_HealthCheck(t *testing.T) {
 	defer server.Close()
 
 	client := NewLiteLLMClient(server.URL)
-	err := client.HealthCheck()
+	err := client.HealthCheck(context.Background())
 	if err != nil {
 		t.Errorf("HealthCheck failed: %v", err)
 	}
@@ -2254,7 +2479,7 @@ func TestLiteLLMClient_HealthCheck_Unhealthy(t *testing.T) {
 	defer server.Close()
 
 	client := NewLiteLLMClient(server.URL)
-	err := client.HealthCheck()
+	err := client.HealthCheck(context.Background())
 	if err == nil {
 		t.Error("expected error for unhealthy service")
 	}
@@ -2279,7 +2504,7 @@ func TestLiteLLMClient_EstimateCost(t *testing.T) {
 		Judges:   []string{"claude", "gpt"},
 	}
 
-	resp, err := client.EstimateCost(req)
+	resp, err := client.EstimateCost(context.Background(), req)
 	if err != nil {
 		t.Fatalf("EstimateCost failed: %v", err)
 	}
@@ -2301,7 +2526,7 @@ func TestLiteLLMClient_EstimateCost_Error(t *testing.T) {
 		Judges: []string{"claude"},
 	}
 
-	_, err := client.EstimateCost(req)
+	_, err := client.EstimateCost(context.Background(), req)
 	if err == nil {
 		t.Error("expected error for bad request")
 	}
@@ -2360,7 +2585,7 @@ func TestResumePendingJobs_WithPendingJobs(t *testing.T) {
 		jobs:        make(chan *EvaluationJob, 100),
 		workers:     0,
 		running:     make(map[int]bool),
-		cancel:      make(map[int]chan bool),
+		cancel:      make(map[int]context.CancelFunc),
 		evaluator:   evaluator,
 		resumeDelay: 0,
 	}
@@ -2412,7 +2637,7 @@ func TestResumePendingJobs_ScanError(t *testing.T) {
 		jobs:        make(chan *EvaluationJob, 100),
 		workers:     0,
 		running:     make(map[int]bool),
-		cancel:      make(map[int]chan bool),
+		cancel:      make(map[int]context.CancelFunc),
 		evaluator:   evaluator,
 		resumeDelay: 0,
 	}
@@ -2490,7 +2715,7 @@ func TestWorker_UpdateJobError(t *testing.T) {
 		jobs:        make(chan *EvaluationJob, 100),
 		workers:     0,
 		running:     make(map[int]bool),
-		cancel:      make(map[int]chan bool),
+		cancel:      make(map[int]context.CancelFunc),
 		evaluator:   evaluator,
 		resumeDelay: 0,
 	}
@@ -2529,7 +2754,7 @@ func TestProcessJob_UnknownJobType(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -2540,8 +2765,7 @@ func TestProcessJob_UnknownJobType(t *testing.T) {
 		JobType: "unknown_type",
 	}
 
-	cancelChan := make(chan bool)
-	err := evaluator.processJob(job, cancelChan)
+	err := evaluator.processJob(context.Background(), job)
 	if err == nil {
 		t.Error("expected error for unknown job type")
 	}
@@ -2569,7 +2793,7 @@ func TestProcessJob_AllType(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -2581,8 +2805,7 @@ func TestProcessJob_AllType(t *testing.T) {
 		ProgressTotal: 0,
 	}
 
-	cancelChan := make(chan bool)
-	err = evaluator.processJob(job, cancelChan)
+	err = evaluator.processJob(context.Background(), job)
 	// With no models/prompts, should complete without error
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -2608,7 +2831,7 @@ func TestProcessJob_ModelType(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -2621,8 +2844,7 @@ func TestProcessJob_ModelType(t *testing.T) {
 		ProgressTotal: 0,
 	}
 
-	cancelChan := make(chan bool)
-	err = evaluator.processJob(job, cancelChan)
+	err = evaluator.processJob(context.Background(), job)
 	// With no prompts, should complete without error
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -2648,7 +2870,7 @@ func TestProcessJob_PromptType(t *testing.T) {
 			jobs:    make(chan *EvaluationJob, 100),
 			workers: 0,
 			running: make(map[int]bool),
-			cancel:  make(map[int]chan bool),
+			cancel:  make(map[int]context.CancelFunc),
 		},
 	}
 	evaluator.jobQueue.evaluator = evaluator
@@ -2661,10 +2883,105 @@ func TestProcessJob_PromptType(t *testing.T) {
 		ProgressTotal: 0,
 	}
 
-	cancelChan := make(chan bool)
-	err = evaluator.processJob(job, cancelChan)
+	err = evaluator.processJob(context.Background(), job)
 	// With no models, should complete without error
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+// gatedProvider holds each call until width calls are in flight, recording the most seen at once
+type gatedProvider struct {
+	width   int
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+func (g *gatedProvider) Name() string { return "gated" }
+
+func (g *gatedProvider) Evaluate(_ context.Context, _ EvaluationRequest) (*EvaluationResponse, error) {
+	g.mu.Lock()
+	g.active++
+	if g.active > g.maxSeen {
+		g.maxSeen = g.active
+	}
+	deadline := time.Now().Add(time.Second)
+	for g.maxSeen < g.width && time.Now().Before(deadline) {
+		g.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		g.mu.Lock()
+	}
+	g.active--
+	g.mu.Unlock()
+	return &EvaluationResponse{ConsensusScore: 80, TotalCostUSD: 0.01}, nil
+}
+
+func TestProcessAllJob_EvaluatesPairsConcurrently(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('m1', 1), ('m2', 1)",
+		"INSERT INTO prompts (text, suite_id, display_order) VALUES ('p1', 1, 0), ('p2', 1, 1), ('p3', 1, 2)",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) SELECT m.id, p.id, 'r' FROM models m, prompts p",
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	judge := &gatedProvider{width: 3}
+	e.SetJudgeProviders(judge)
+	e.SetConcurrency(3)
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 6}
+	if err := e.processAllJob(context.Background(), job); err != nil {
+		t.Fatalf("processAllJob failed: %v", err)
+	}
+	if judge.maxSeen != 3 {
+		t.Errorf("expected 3 pairs in flight at once, saw %d", judge.maxSeen)
+	}
+
+	var current, total int
+	var cost float64
+	if err := db.QueryRow("SELECT progress_current, progress_total, actual_cost_usd FROM evaluation_jobs WHERE id = 1").Scan(&current, &total, &cost); err != nil {
+		t.Fatalf("failed to query job: %v", err)
+	}
+	if current != 6 || total != 6 || cost < 0.0599 || cost > 0.0601 {
+		t.Errorf("expected progress 6/6 and cost 0.06, got %d/%d and %f", current, total, cost)
+	}
+
+	var scored int
+	_ = db.QueryRow("SELECT COUNT(*) FROM scores WHERE score = 80").Scan(&scored)
+	if scored != 6 {
+		t.Errorf("expected all 6 pairs scored, got %d", scored)
+	}
+}
+
+func TestEvaluatePairs_CancelStopsDispatch(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.Exec("INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')"); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetConcurrency(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pairs := []modelPromptPair{{1, 1}, {1, 2}, {2, 1}}
+	if err := e.evaluatePairs(ctx, &EvaluationJob{ID: 1, ProgressTotal: 3}, pairs); err != errJobCancelled {
+		t.Errorf("expected errJobCancelled, got %v", err)
+	}
+
+	var current int
+	_ = db.QueryRow("SELECT progress_current FROM evaluation_jobs WHERE id = 1").Scan(&current)
+	if current != 0 {
+		t.Errorf("expected no progress for a cancelled job, got %d", current)
+	}
+}
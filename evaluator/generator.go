@@ -0,0 +1,357 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"llm-tournament/middleware"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Job types for response generation
+const (
+	JobTypeGenerateAll   = "generate_all"
+	JobTypeGenerateModel = "generate_model"
+)
+
+// errNoModelConfig is returned when a model has no endpoint configured
+var errNoModelConfig = errors.New("model has no endpoint configured")
+
+// decryptAPIKey decrypts stored model credentials (overridable for tests)
+var decryptAPIKey = middleware.DecryptAPIKey
+
+// generationHTTPClient calls model endpoints; local models can be slow, so the timeout is generous
+var generationHTTPClient = &http.Client{Timeout: 10 * time.Minute}
+
+// modelEndpoint is a model's decrypted generation configuration
+type modelEndpoint struct {
+	Provider      string
+	BaseURL       string
+	ModelName     string
+	APIKey        string
+	Temperature   *float64
+	TopP          *float64
+	MaxTokens     int
+	SystemPrompt  string
+	StopSequences []string
+}
+
+// generationRecord is stored in model_responses.api_config to make responses reproducible
+type generationRecord struct {
+	Provider string      `json:"provider"`
+	BaseURL  string      `json:"base_url"`
+	Request  interface{} `json:"request"` // Exact request body sent to the endpoint
+}
+
+// GenerateAll queues response generation for every configured model × all prompts in a suite
+func (e *Evaluator) GenerateAll(suiteID int) (int, error) {
+	modelIDs, err := e.configuredModelIDs(suiteID)
+	if err != nil {
+		return 0, err
+	}
+	if len(modelIDs) == 0 {
+		return 0, fmt.Errorf("no models in suite have an endpoint configured")
+	}
+
+	var promptCount int
+	if err := e.db.QueryRow("SELECT COUNT(*) FROM prompts WHERE suite_id = ?", suiteID).Scan(&promptCount); err != nil {
+		return 0, fmt.Errorf("failed to count prompts: %w", err)
+	}
+
+	job := &EvaluationJob{
+		SuiteID:       suiteID,
+		JobType:       JobTypeGenerateAll,
+		ProgressTotal: promptCount * len(modelIDs),
+	}
+	if err := e.jobQueue.Enqueue(job); err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return job.ID, nil
+}
+
+// GenerateModel queues response generation for one model × all prompts
+func (e *Evaluator) GenerateModel(modelID int) (int, error) {
+	var suiteID, promptCount int
+	if err := e.db.QueryRow("SELECT suite_id FROM models WHERE id = ?", modelID).Scan(&suiteID); err != nil {
+		return 0, fmt.Errorf("failed to get model suite: %w", err)
+	}
+
+	if _, err := e.loadModelEndpoint(modelID); err != nil {
+		return 0, err
+	}
+
+	if err := e.db.QueryRow("SELECT COUNT(*) FROM prompts WHERE suite_id = ?", suiteID).Scan(&promptCount); err != nil {
+		return 0, fmt.Errorf("failed to count prompts: %w", err)
+	}
+
+	job := &EvaluationJob{
+		SuiteID:       suiteID,
+		JobType:       JobTypeGenerateModel,
+		TargetID:      modelID,
+		ProgressTotal: promptCount,
+	}
+	if err := e.jobQueue.Enqueue(job); err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return job.ID, nil
+}
+
+// processGenerateJob generates responses for the job's models × all prompts
+func (e *Evaluator) processGenerateJob(ctx context.Context, job *EvaluationJob) error {
+	modelIDs := []int{job.TargetID}
+	if job.JobType == JobTypeGenerateAll {
+		var err error
+		modelIDs, err = e.configuredModelIDs(job.SuiteID)
+		if err != nil {
+			return err
+		}
+	}
+
+	promptRows, err := e.db.Query("SELECT id FROM prompts WHERE suite_id = ? ORDER BY display_order", job.SuiteID)
+	if err != nil {
+		return fmt.Errorf("failed to query prompts: %w", err)
+	}
+	defer func() { _ = promptRows.Close() }()
+
+	var promptIDs []int
+	for promptRows.Next() {
+		var promptID int
+		if err := promptRows.Scan(&promptID); err != nil {
+			return err
+		}
+		promptIDs = append(promptIDs, promptID)
+	}
+
+	var pairs []modelPromptPair
+	for _, modelID := range modelIDs {
+		for _, promptID := range promptIDs {
+			pairs = append(pairs, modelPromptPair{modelID: modelID, promptID: promptID})
+		}
+	}
+	// Pairs are checkpointed like evaluations, so a resumed job does not pay for the
+	// responses it already generated
+	if err := e.jobQueue.addJobPairs(job.ID, pairs); err != nil {
+		log.Printf("Failed to record pairs of job %d: %v", job.ID, err)
+	}
+	done, err := e.jobQueue.finishedPairs(job.ID)
+	if err != nil {
+		return err
+	}
+
+	current := 0
+	failed := 0
+	for _, pair := range pairs {
+		if f, ok := done[pair]; ok {
+			current++
+			if f.outcome == PairFailed {
+				failed++
+			}
+		}
+	}
+	if current > 0 {
+		log.Printf("Job %d resumes after %d of %d pairs", job.ID, current, len(pairs))
+		e.reportProgress(job, current, 0)
+	}
+
+	// New responses are queued for auto evaluation together once the job stops, so a
+	// whole generation run becomes one evaluation job
+	var generated []modelPromptPair
+	defer func() {
+		for _, pair := range generated {
+			e.autoEvaluate(job.SuiteID, autoTarget{modelID: pair.modelID, promptID: pair.promptID})
+		}
+	}()
+	for _, pair := range pairs {
+		if _, ok := done[pair]; ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			return errJobCancelled
+		}
+
+		start := time.Now()
+		r := pairResult{pair: pair, outcome: PairScored}
+		if err := e.generateResponse(ctx, pair.modelID, pair.promptID); err != nil {
+			if ctx.Err() != nil {
+				return errJobCancelled
+			}
+			log.Printf("Failed to generate response for model %d, prompt %d: %v", pair.modelID, pair.promptID, err)
+			e.recordPairFailure(job.ID, pair.modelID, pair.promptID, err)
+			r.outcome = PairFailed
+			failed++
+		} else {
+			generated = append(generated, pair)
+		}
+		r.duration = time.Since(start)
+		e.jobQueue.setPairOutcome(job.ID, r)
+
+		current++
+		e.reportProgress(job, current, 0)
+	}
+
+	// A job that produced nothing failed; one that produced some responses completes
+	// with the count of pairs that did not
+	if failed > 0 && failed == current {
+		return fmt.Errorf("all %d generations failed", failed)
+	}
+	summarizeFailedPairs(job, failed, current)
+	return nil
+}
+
+// generateResponse calls a model's endpoint for one prompt and stores the output along
+// with the library version of the system prompt it was sent with
+func (e *Evaluator) generateResponse(ctx context.Context, modelID, promptID int) error {
+	endpoint, err := e.loadModelEndpoint(modelID)
+	if err != nil {
+		return err
+	}
+
+	var promptText string
+	if err := e.db.QueryRow("SELECT text FROM prompts WHERE id = ?", promptID).Scan(&promptText); err != nil {
+		return fmt.Errorf("failed to get prompt: %w", err)
+	}
+	turns, err := e.promptTurns(promptID)
+	if err != nil {
+		return err
+	}
+	system, err := e.resolveSystemPrompt(modelID, promptID)
+	if err != nil {
+		return err
+	}
+	endpoint.SystemPrompt = system.content
+
+	out, request, err := callModelEndpoint(ctx, endpoint, turns, promptText)
+	if err != nil {
+		return fmt.Errorf("generation failed: %w", err)
+	}
+
+	apiConfig, _ := json.Marshal(generationRecord{Provider: endpoint.Provider, BaseURL: endpoint.BaseURL, Request: request})
+
+	_, err = e.db.Exec(`
+		INSERT INTO model_responses (model_id, prompt_id, response_text, response_source, api_config, system_prompt_version_id)
+		VALUES (?, ?, ?, 'api', ?, ?)
+		ON CONFLICT(model_id, prompt_id) DO UPDATE SET
+			response_text = excluded.response_text,
+			response_source = excluded.response_source,
+			api_config = excluded.api_config,
+			system_prompt_version_id = excluded.system_prompt_version_id,
+			updated_at = CURRENT_TIMESTAMP
+	`, modelID, promptID, out.Text, string(apiConfig), system.versionID)
+	if err != nil {
+		return fmt.Errorf("failed to save response: %w", err)
+	}
+
+	return nil
+}
+
+// callModelEndpoint sends a prompt, after the turns of its conversation if it has any,
+// to a model endpoint and returns the completion and the request sent
+func callModelEndpoint(ctx context.Context, endpoint *modelEndpoint, turns []middleware.Turn, promptText string) (*completion, interface{}, error) {
+	systemTurns, conversation := conversationMessages(turns, promptText)
+	if endpoint.Provider == "anthropic" {
+		maxTokens := endpoint.MaxTokens
+		if maxTokens <= 0 {
+			maxTokens = defaultJudgeMaxTokens
+		}
+		body := anthropicRequest{
+			Model:         endpoint.ModelName,
+			MaxTokens:     maxTokens,
+			System:        joinSystemPrompts(endpoint.SystemPrompt, systemTurns),
+			Messages:      conversation,
+			Temperature:   endpoint.Temperature,
+			TopP:          endpoint.TopP,
+			StopSequences: endpoint.StopSequences,
+		}
+		out, err := postAnthropicMessages(ctx, generationHTTPClient, endpoint.BaseURL, endpoint.APIKey, body)
+		return out, body, err
+	}
+
+	var messages []chatMessage
+	if system := joinSystemPrompts(endpoint.SystemPrompt, systemTurns); system != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: system})
+	}
+	messages = append(messages, conversation...)
+
+	body := openAIChatRequest{
+		Model:       endpoint.ModelName,
+		Messages:    messages,
+		MaxTokens:   endpoint.MaxTokens,
+		Temperature: endpoint.Temperature,
+		TopP:        endpoint.TopP,
+		Stop:        endpoint.StopSequences,
+	}
+	out, err := postChatCompletion(ctx, generationHTTPClient, endpoint.BaseURL, endpoint.APIKey, body)
+	return out, body, err
+}
+
+// loadModelEndpoint reads and decrypts a model's endpoint configuration
+func (e *Evaluator) loadModelEndpoint(modelID int) (*modelEndpoint, error) {
+	endpoint := &modelEndpoint{}
+	var apiKey, stopSequences string
+	var temperature, topP sql.NullFloat64
+
+	err := e.db.QueryRow(`
+		SELECT provider, base_url, model_name, api_key, temperature, top_p, max_tokens, system_prompt, stop_sequences
+		FROM model_configs
+		WHERE model_id = ?
+	`, modelID).Scan(&endpoint.Provider, &endpoint.BaseURL, &endpoint.ModelName, &apiKey, &temperature, &topP,
+		&endpoint.MaxTokens, &endpoint.SystemPrompt, &stopSequences)
+	if err == sql.ErrNoRows || (err == nil && endpoint.BaseURL == "") {
+		return nil, errNoModelConfig
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get model config: %w", err)
+	}
+
+	if temperature.Valid {
+		endpoint.Temperature = &temperature.Float64
+	}
+	if topP.Valid {
+		endpoint.TopP = &topP.Float64
+	}
+	if stopSequences != "" {
+		if err := json.Unmarshal([]byte(stopSequences), &endpoint.StopSequences); err != nil {
+			return nil, fmt.Errorf("failed to decode stop sequences: %w", err)
+		}
+	}
+	if apiKey != "" {
+		endpoint.APIKey, err = decryptAPIKey(apiKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt model API key: %w", err)
+		}
+	}
+
+	return endpoint, nil
+}
+
+// configuredModelIDs lists the suite's models that have an endpoint configured
+func (e *Evaluator) configuredModelIDs(suiteID int) ([]int, error) {
+	rows, err := e.db.Query(`
+		SELECT m.id
+		FROM models m
+		JOIN model_configs c ON c.model_id = m.id
+		WHERE m.suite_id = ? AND c.base_url != ''
+		ORDER BY m.id
+	`, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query models: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var modelIDs []int
+	for rows.Next() {
+		var modelID int
+		if err := rows.Scan(&modelID); err != nil {
+			return nil, err
+		}
+		modelIDs = append(modelIDs, modelID)
+	}
+
+	return modelIDs, nil
+}
@@ -0,0 +1,333 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// setupGeneratorTestDB extends the evaluator schema with models and prompts to generate for
+func setupGeneratorTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := setupEvaluatorTestDB(t)
+	_, err := db.Exec(`
+		CREATE UNIQUE INDEX idx_model_responses_unique ON model_responses(model_id, prompt_id);
+		INSERT INTO models (id, name, suite_id) VALUES (1, 'local-llama', 1), (2, 'unconfigured', 1);
+		INSERT INTO prompts (id, text, suite_id, display_order) VALUES (1, 'Say hi', 1, 0), (2, 'Say bye', 1, 1);
+	`)
+	if err != nil {
+		t.Fatalf("failed to extend schema: %v", err)
+	}
+	return db
+}
+
+func newGeneratorTestEvaluator(db *sql.DB) *Evaluator {
+	e := &Evaluator{
+		db:            db,
+		litellmClient: NewLiteLLMClient("http://localhost:8001"),
+		jobQueue: &JobQueue{
+			db:      db,
+			jobs:    make(chan *EvaluationJob, 100),
+			running: make(map[int]bool),
+			cancel:  make(map[int]context.CancelFunc),
+		},
+	}
+	e.jobQueue.evaluator = e
+	return e
+}
+
+func TestGenerateResponse_StoresAPIResponse(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	var received openAIChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer plain-key" {
+			t.Errorf("expected decrypted key, got %q", r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "hi!"}}},
+		})
+	}))
+	defer server.Close()
+
+	originalDecrypt := decryptAPIKey
+	decryptAPIKey = func(string) (string, error) { return "plain-key", nil }
+	defer func() { decryptAPIKey = originalDecrypt }()
+
+	if _, err := db.Exec("INSERT INTO model_configs (model_id, base_url, model_name, api_key, temperature, max_tokens) VALUES (1, ?, 'llama-3', 'enc', 0.3, 64)", server.URL); err != nil {
+		t.Fatalf("failed to insert config: %v", err)
+	}
+	// A manual response is replaced by the generated one
+	if _, err := db.Exec("INSERT INTO model_responses (model_id, prompt_id, response_text, response_source) VALUES (1, 1, 'old', 'manual')"); err != nil {
+		t.Fatalf("failed to insert response: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	if err := e.generateResponse(context.Background(), 1, 1); err != nil {
+		t.Fatalf("generateResponse failed: %v", err)
+	}
+
+	if received.Model != "llama-3" || received.MaxTokens != 64 || received.Temperature == nil || *received.Temperature != 0.3 {
+		t.Errorf("unexpected request: %+v", received)
+	}
+	if len(received.Messages) != 1 || received.Messages[0].Content != "Say hi" {
+		t.Errorf("unexpected messages: %+v", received.Messages)
+	}
+
+	var text, source, apiConfig string
+	err := db.QueryRow("SELECT response_text, response_source, api_config FROM model_responses WHERE model_id = 1 AND prompt_id = 1").Scan(&text, &source, &apiConfig)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if text != "hi!" || source != "api" {
+		t.Errorf("expected generated api response, got %q (%s)", text, source)
+	}
+	var record struct {
+		Provider string            `json:"provider"`
+		BaseURL  string            `json:"base_url"`
+		Request  openAIChatRequest `json:"request"`
+	}
+	if err := json.Unmarshal([]byte(apiConfig), &record); err != nil {
+		t.Fatalf("api_config is not valid JSON: %v", err)
+	}
+	if record.Provider != "openai" || record.BaseURL != server.URL || record.Request.Model != "llama-3" {
+		t.Errorf("unexpected api_config: %+v", record)
+	}
+}
+
+func TestGenerateModel_RequiresConfig(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	if _, err := e.GenerateModel(2); err != errNoModelConfig {
+		t.Fatalf("expected errNoModelConfig, got %v", err)
+	}
+	if _, err := e.GenerateAll(1); err == nil {
+		t.Fatal("expected error when no model is configured")
+	}
+}
+
+func TestGenerateAll_OnlyConfiguredModels(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "ok"}}},
+		})
+	}))
+	defer server.Close()
+
+	if _, err := db.Exec("INSERT INTO model_configs (model_id, base_url, model_name) VALUES (1, ?, 'llama-3')", server.URL); err != nil {
+		t.Fatalf("failed to insert config: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	jobID, err := e.GenerateAll(1)
+	if err != nil {
+		t.Fatalf("GenerateAll failed: %v", err)
+	}
+	job := <-e.jobQueue.jobs
+	if job.ID != jobID || job.ProgressTotal != 2 {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+
+	if err := e.processJob(context.Background(), job); err != nil {
+		t.Fatalf("processJob failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 generation calls, got %d", calls)
+	}
+
+	var current int
+	if err := db.QueryRow("SELECT progress_current FROM evaluation_jobs WHERE id = ?", jobID).Scan(&current); err != nil {
+		t.Fatalf("failed to read progress: %v", err)
+	}
+	if current != 2 {
+		t.Errorf("expected progress 2, got %d", current)
+	}
+}
+
+func TestProcessGenerateJob_RecordsFailures(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	failAll := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIChatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if failAll || req.Messages[0].Content == "Say bye" {
+			http.Error(w, "model overloaded", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "ok"}}},
+		})
+	}))
+	defer server.Close()
+	if _, err := db.Exec("INSERT INTO model_configs (model_id, base_url, model_name) VALUES (1, ?, 'llama-3')", server.URL); err != nil {
+		t.Fatalf("failed to insert config: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeGenerateModel, TargetID: 1, ProgressTotal: 2}
+	if err := e.processGenerateJob(context.Background(), job); err != nil {
+		t.Fatalf("expected a partly failed job to complete, got %v", err)
+	}
+	if job.ErrorMessage != "1 of 2 pairs failed" {
+		t.Errorf("expected the failure count on the job, got %q", job.ErrorMessage)
+	}
+	var promptID int
+	var message string
+	if err := db.QueryRow("SELECT prompt_id, error_message FROM evaluation_errors WHERE job_id = 1").Scan(&promptID, &message); err != nil {
+		t.Fatalf("expected the failure recorded: %v", err)
+	}
+	if promptID != 2 || message == "" {
+		t.Errorf("expected the failure of prompt 2 recorded, got %d: %q", promptID, message)
+	}
+
+	failAll = true
+	job = &EvaluationJob{ID: 2, SuiteID: 1, JobType: JobTypeGenerateModel, TargetID: 1, ProgressTotal: 2}
+	if err := e.processGenerateJob(context.Background(), job); err == nil || err.Error() != "all 2 generations failed" {
+		t.Errorf("expected the job to fail when every generation fails, got %v", err)
+	}
+}
+
+func TestProcessGenerateJob_ResumeSkipsGeneratedPairs(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIChatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		calls = append(calls, req.Messages[len(req.Messages)-1].Content)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "ok"}}},
+		})
+	}))
+	defer server.Close()
+	for _, stmt := range []string{
+		"INSERT INTO model_configs (model_id, base_url, model_name) VALUES (1, '" + server.URL + "', 'llama-3')",
+		"INSERT INTO evaluation_jobs (id, suite_id, job_type, status) VALUES (1, 1, 'generate_model', 'running')",
+		"INSERT INTO evaluation_job_pairs (job_id, model_id, prompt_id, outcome) VALUES (1, 1, 1, 'scored')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeGenerateModel, TargetID: 1, ProgressTotal: 2}
+	if err := e.processGenerateJob(context.Background(), job); err != nil {
+		t.Fatalf("processGenerateJob failed: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "Say bye" {
+		t.Errorf("expected only the second prompt to be generated, got %v", calls)
+	}
+	var pending int
+	_ = db.QueryRow("SELECT COUNT(*) FROM evaluation_job_pairs WHERE job_id = 1 AND outcome != 'scored'").Scan(&pending)
+	if job.ProgressCurrent != 2 || pending != 0 {
+		t.Errorf("expected both pairs done, got progress %d with %d unfinished", job.ProgressCurrent, pending)
+	}
+}
+
+func TestProcessGenerateJob_Cancelled(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: JobTypeGenerateModel, TargetID: 1, ProgressTotal: 2}
+	if err := e.processGenerateJob(ctx, job); err == nil || err.Error() != "job cancelled" {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+}
+
+func TestGenerateResponse_SamplingAndSystemPrompt(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	var received openAIChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "ok"}}},
+		})
+	}))
+	defer server.Close()
+
+	_, err := db.Exec(`INSERT INTO model_configs (model_id, base_url, model_name, top_p, system_prompt, stop_sequences)
+		VALUES (1, ?, 'llama-3', 0.9, 'Be terse.', '["</s>"]')`, server.URL)
+	if err != nil {
+		t.Fatalf("failed to insert config: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	if err := e.generateResponse(context.Background(), 1, 2); err != nil {
+		t.Fatalf("generateResponse failed: %v", err)
+	}
+
+	if len(received.Messages) != 2 || received.Messages[0].Role != "system" || received.Messages[0].Content != "Be terse." {
+		t.Errorf("expected system prompt first, got %+v", received.Messages)
+	}
+	if received.TopP == nil || *received.TopP != 0.9 || received.Temperature != nil {
+		t.Errorf("unexpected sampling: top_p=%v temperature=%v", received.TopP, received.Temperature)
+	}
+	if len(received.Stop) != 1 || received.Stop[0] != "</s>" {
+		t.Errorf("unexpected stop sequences: %v", received.Stop)
+	}
+}
+
+func TestGenerateResponse_AnthropicProvider(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	var received anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			t.Errorf("expected /messages, got %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": "bonjour"}},
+		})
+	}))
+	defer server.Close()
+
+	_, err := db.Exec(`INSERT INTO model_configs (model_id, provider, base_url, model_name, system_prompt, stop_sequences)
+		VALUES (1, 'anthropic', ?, 'claude-test', 'French only.', '["STOP"]')`, server.URL)
+	if err != nil {
+		t.Fatalf("failed to insert config: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	if err := e.generateResponse(context.Background(), 1, 1); err != nil {
+		t.Fatalf("generateResponse failed: %v", err)
+	}
+
+	if received.System != "French only." || received.MaxTokens != defaultJudgeMaxTokens {
+		t.Errorf("unexpected request: %+v", received)
+	}
+	if len(received.StopSequences) != 1 || received.StopSequences[0] != "STOP" {
+		t.Errorf("unexpected stop sequences: %v", received.StopSequences)
+	}
+
+	var text string
+	if err := db.QueryRow("SELECT response_text FROM model_responses WHERE model_id = 1 AND prompt_id = 1").Scan(&text); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if text != "bonjour" {
+		t.Errorf("expected 'bonjour', got %q", text)
+	}
+}
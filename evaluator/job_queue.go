@@ -1,7 +1,9 @@
 package evaluator
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -14,12 +16,21 @@ type JobQueue struct {
 	jobs        chan *EvaluationJob
 	workers     int
 	mu          sync.Mutex
-	running     map[int]bool // Track running jobs
-	cancel      map[int]chan bool
+	running     map[int]bool               // Track running jobs
+	cancel      map[int]context.CancelFunc // Cancels a running job's context
+	queued      map[int]bool               // Jobs waiting in the channel for a worker
+	dropped     map[int]bool               // Queued jobs cancelled or removed before a worker reached them
+	requeuing   map[int]bool               // Stopped jobs a resume or restart is putting back on the queue
 	evaluator   *Evaluator
-	resumeDelay time.Duration // Delay before resuming pending jobs (configurable for testing)
+	resumeDelay time.Duration   // Delay before resuming pending jobs (configurable for testing)
+	ctx         context.Context // Parent of every job's context; nil means context.Background()
+	stop        context.CancelFunc
+	active      sync.WaitGroup // Jobs being processed, for Shutdown
 }
 
+// errJobCancelled is returned by job processors when the job's context is cancelled
+var errJobCancelled = errors.New("job cancelled")
+
 var lastInsertID = func(result sql.Result) (int64, error) { return result.LastInsertId() }
 
 // NewJobQueue creates a new job queue with the specified number of workers
@@ -29,14 +40,20 @@ func NewJobQueue(db *sql.DB, workers int, evaluator *Evaluator) *JobQueue {
 
 // NewJobQueueWithDelay creates a new job queue with a configurable resume delay (for testing)
 func NewJobQueueWithDelay(db *sql.DB, workers int, evaluator *Evaluator, resumeDelay time.Duration) *JobQueue {
+	ctx, stop := context.WithCancel(context.Background())
 	jq := &JobQueue{
 		db:          db,
 		jobs:        make(chan *EvaluationJob, 100),
 		workers:     workers,
 		running:     make(map[int]bool),
-		cancel:      make(map[int]chan bool),
+		cancel:      make(map[int]context.CancelFunc),
+		queued:      make(map[int]bool),
+		dropped:     make(map[int]bool),
+		requeuing:   make(map[int]bool),
 		evaluator:   evaluator,
 		resumeDelay: resumeDelay,
+		ctx:         ctx,
+		stop:        stop,
 	}
 
 	// Start worker goroutines
@@ -50,62 +67,176 @@ func NewJobQueueWithDelay(db *sql.DB, workers int, evaluator *Evaluator, resumeD
 	return jq
 }
 
+// baseContext is the context every job's context derives from
+func (jq *JobQueue) baseContext() context.Context {
+	if jq.ctx == nil {
+		return context.Background()
+	}
+	return jq.ctx
+}
+
 // worker processes jobs from the queue
 func (jq *JobQueue) worker(id int) {
 	log.Printf("Worker %d started", id)
 	for job := range jq.jobs {
-		log.Printf("Worker %d processing job %d", id, job.ID)
+		ctx, ok := jq.claim(job)
+		if !ok {
+			continue
+		}
+		jq.runJob(id, ctx, job)
+	}
+}
+
+// claim takes a job off the queue and gives it a cancellable context, unless the job
+// was cancelled or removed while waiting or the queue is shutting down
+func (jq *JobQueue) claim(job *EvaluationJob) (context.Context, bool) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	delete(jq.queued, job.ID)
+	if jq.dropped[job.ID] {
+		delete(jq.dropped, job.ID)
+		log.Printf("Skipping job %d: cancelled before it started", job.ID)
+		return nil, false
+	}
+	parent := jq.baseContext()
+	if parent.Err() != nil {
+		return nil, false // Stays pending in the database and resumes on the next start
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	jq.running[job.ID] = true
+	jq.cancel[job.ID] = cancel
+	jq.active.Add(1)
+	return ctx, true
+}
 
-		// Mark job as running
+// runJob processes a claimed job and records how it ended
+func (jq *JobQueue) runJob(workerID int, ctx context.Context, job *EvaluationJob) {
+	defer jq.active.Done()
+	defer func() {
 		jq.mu.Lock()
-		jq.running[job.ID] = true
-		cancelChan := make(chan bool, 1)
-		jq.cancel[job.ID] = cancelChan
+		if cancel, ok := jq.cancel[job.ID]; ok {
+			cancel()
+		}
+		delete(jq.running, job.ID)
+		delete(jq.cancel, job.ID)
 		jq.mu.Unlock()
+	}()
 
-		// Update job status to running
-		now := time.Now()
-		job.StartedAt = &now
-		job.Status = "running"
-		if err := jq.updateJob(job); err != nil {
-			log.Printf("Failed to update job status: %v", err)
-		}
+	log.Printf("Worker %d processing job %d", workerID, job.ID)
+
+	// Update job status to running
+	now := time.Now()
+	job.StartedAt = &now
+	job.Status = "running"
+	if err := jq.updateJob(job); err != nil {
+		log.Printf("Failed to update job status: %v", err)
+	}
+
+	// Process the job
+	err := jq.evaluator.processJob(ctx, job)
+
+	if jq.baseContext().Err() != nil {
+		// Leave the job running in the database so resumePendingJobs picks it up again
+		log.Printf("Job %d interrupted by shutdown", job.ID)
+		return
+	}
 
-		// Process the job
-		err := jq.evaluator.processJob(job, cancelChan)
+	// Update job status
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+
+	switch {
+	case ctx.Err() != nil:
+		job.Status = "cancelled"
+		job.ErrorMessage = errJobCancelled.Error()
+		jq.cancelPendingPairs(job.ID)
+		log.Printf("Job %d cancelled", job.ID)
+	case err != nil:
+		job.Status = "failed"
+		job.ErrorMessage = err.Error()
+		log.Printf("Job %d failed: %v", job.ID, err)
+	default:
+		job.Status = "completed"
+		log.Printf("Job %d completed successfully", job.ID)
+	}
 
-		// Update job status
-		completedAt := time.Now()
-		job.CompletedAt = &completedAt
+	if err := jq.updateJob(job); err != nil {
+		log.Printf("Failed to update job completion: %v", err)
+	}
 
-		if err != nil {
-			job.Status = "failed"
-			job.ErrorMessage = err.Error()
-			log.Printf("Job %d failed: %v", job.ID, err)
-		} else {
-			job.Status = "completed"
-			log.Printf("Job %d completed successfully", job.ID)
+	events := jq.evaluator.jobEvents()
+	if job.Status == "completed" {
+		if events.Completed != nil {
+			events.Completed(job.ID, job.ActualCost)
 		}
+	} else if events.Failed != nil {
+		events.Failed(job.ID, job.ErrorMessage)
+	}
+}
+
+// markQueued records that a job is waiting in the channel
+func (jq *JobQueue) markQueued(jobID int) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	if jq.queued == nil {
+		jq.queued = make(map[int]bool)
+	}
+	jq.queued[jobID] = true
+}
+
+// reserve claims a stopped job for requeue unless it is already queued, running or
+// being requeued
+func (jq *JobQueue) reserve(jobID int) bool {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	if jq.running[jobID] || jq.queued[jobID] || jq.requeuing[jobID] {
+		return false
+	}
+	if jq.requeuing == nil {
+		jq.requeuing = make(map[int]bool)
+	}
+	jq.requeuing[jobID] = true
+	return true
+}
 
-		if err := jq.updateJob(job); err != nil {
-			log.Printf("Failed to update job completion: %v", err)
+// release ends a requeue's reservation, marking the job queued when it is about to be
+// sent to the workers
+func (jq *JobQueue) release(jobID int, queued bool) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	delete(jq.requeuing, jobID)
+	if queued {
+		if jq.queued == nil {
+			jq.queued = make(map[int]bool)
 		}
+		jq.queued[jobID] = true
+	}
+}
 
-		// Clean up
-		jq.mu.Lock()
-		delete(jq.running, job.ID)
-		delete(jq.cancel, job.ID)
-		jq.mu.Unlock()
+// drop makes workers skip a queued job; callers hold jq.mu
+func (jq *JobQueue) drop(jobID int) {
+	if jq.dropped == nil {
+		jq.dropped = make(map[int]bool)
 	}
+	jq.dropped[jobID] = true
+	delete(jq.queued, jobID)
 }
 
 // Enqueue adds a job to the queue
 func (jq *JobQueue) Enqueue(job *EvaluationJob) error {
+	return jq.enqueue(job, nil)
+}
+
+// enqueue adds a job to the queue. Pairs, when given, are recorded as the job's
+// pending pairs before a worker can pick it up.
+func (jq *JobQueue) enqueue(job *EvaluationJob, pairs []modelPromptPair) error {
 	// Insert job into database
 	result, err := jq.db.Exec(`
-		INSERT INTO evaluation_jobs (suite_id, job_type, target_id, status, progress_total, estimated_cost_usd)
-		VALUES (?, ?, ?, 'pending', ?, ?)
-	`, job.SuiteID, job.JobType, job.TargetID, job.ProgressTotal, job.EstimatedCost)
+		INSERT INTO evaluation_jobs (suite_id, job_type, target_id, status, progress_total, estimated_cost_usd, force_refresh, judge_panel)
+		VALUES (?, ?, ?, 'pending', ?, ?, ?, ?)
+	`, job.SuiteID, job.JobType, job.TargetID, job.ProgressTotal, job.EstimatedCost, job.ForceRefresh, job.JudgePanel)
 	if err != nil {
 		return fmt.Errorf("failed to insert job: %w", err)
 	}
@@ -119,24 +250,85 @@ func (jq *JobQueue) Enqueue(job *EvaluationJob) error {
 	job.Status = "pending"
 	job.CreatedAt = time.Now()
 
+	if len(pairs) > 0 {
+		if err := jq.addJobPairs(job.ID, pairs); err != nil {
+			_, _ = jq.db.Exec("DELETE FROM evaluation_jobs WHERE id = ?", job.ID)
+			return err
+		}
+	}
+
 	// Add to queue
+	jq.markQueued(job.ID)
 	jq.jobs <- job
 
 	return nil
 }
 
-// CancelJob cancels a running job
+// CancelJob cancels a running job, aborting its in-flight requests, or a pending job
+// before a worker picks it up
 func (jq *JobQueue) CancelJob(jobID int) error {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 
-	if cancelChan, ok := jq.cancel[jobID]; ok {
-		cancelChan <- true
+	if cancel, ok := jq.cancel[jobID]; ok {
+		cancel()
 		_, err := jq.db.Exec(`UPDATE evaluation_jobs SET status = 'cancelled' WHERE id = ?`, jobID)
 		return err
 	}
 
-	return fmt.Errorf("job %d not running", jobID)
+	if jq.queued[jobID] {
+		jq.drop(jobID)
+		_, err := jq.db.Exec(`
+			UPDATE evaluation_jobs SET status = 'cancelled', error_message = ?, completed_at = ? WHERE id = ?
+		`, errJobCancelled.Error(), time.Now(), jobID)
+		return err
+	}
+
+	return fmt.Errorf("job %d not running or pending", jobID)
+}
+
+// RemoveJob deletes a job that never started, dropping it from the queue if it is
+// still waiting. Started jobs keep their row so their evaluation history survives.
+func (jq *JobQueue) RemoveJob(jobID int) error {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	if jq.running[jobID] {
+		return fmt.Errorf("job %d is running; cancel it first", jobID)
+	}
+
+	result, err := jq.db.Exec(`DELETE FROM evaluation_jobs WHERE id = ? AND started_at IS NULL`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to remove job: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("job %d not found or already started", jobID)
+	}
+
+	if jq.queued[jobID] {
+		jq.drop(jobID)
+	}
+	return nil
+}
+
+// Shutdown cancels every running job and waits up to timeout for the workers to let
+// go of them. Interrupted jobs keep their pending or running status in the database
+// and resume on the next start.
+func (jq *JobQueue) Shutdown(timeout time.Duration) {
+	if jq.stop != nil {
+		jq.stop()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		jq.active.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("Timed out waiting for evaluation jobs to stop")
+	}
 }
 
 // GetJob retrieves a job by ID
@@ -145,14 +337,16 @@ func (jq *JobQueue) GetJob(jobID int) (*EvaluationJob, error) {
 	var startedAt, completedAt sql.NullTime
 
 	err := jq.db.QueryRow(`
-		SELECT id, suite_id, job_type, target_id, status, progress_current, progress_total,
-		       estimated_cost_usd, actual_cost_usd, error_message, created_at, started_at, completed_at
+		SELECT id, suite_id, job_type, COALESCE(target_id, 0), status, progress_current, progress_total,
+		       estimated_cost_usd, actual_cost_usd, COALESCE(error_message, ''), created_at, started_at, completed_at,
+		       force_refresh, cache_hits, cache_misses, cache_saved_usd, judge_panel
 		FROM evaluation_jobs
 		WHERE id = ?
 	`, jobID).Scan(
 		&job.ID, &job.SuiteID, &job.JobType, &job.TargetID, &job.Status,
 		&job.ProgressCurrent, &job.ProgressTotal, &job.EstimatedCost, &job.ActualCost,
 		&job.ErrorMessage, &job.CreatedAt, &startedAt, &completedAt,
+		&job.ForceRefresh, &job.CacheHits, &job.CacheMisses, &job.CacheSavedUSD, &job.JudgePanel,
 	)
 	if err != nil {
 		return nil, err
@@ -179,6 +373,12 @@ func (jq *JobQueue) UpdateJobProgress(jobID, current, total int, cost float64) e
 	return err
 }
 
+// setJobStatus changes a job's status and message without touching its progress
+func (jq *JobQueue) setJobStatus(jobID int, status, message string) error {
+	_, err := jq.db.Exec(`UPDATE evaluation_jobs SET status = ?, error_message = ? WHERE id = ?`, status, message, jobID)
+	return err
+}
+
 // updateJob updates a job in the database
 func (jq *JobQueue) updateJob(job *EvaluationJob) error {
 	_, err := jq.db.Exec(`
@@ -194,16 +394,86 @@ func (jq *JobQueue) updateJob(job *EvaluationJob) error {
 	return err
 }
 
+// ResumeJob queues a cancelled or failed job again. Pairs it already finished keep
+// their outcome and cost and are not evaluated again.
+func (jq *JobQueue) ResumeJob(jobID int) error {
+	return jq.requeue(jobID, false)
+}
+
+// RestartJob queues a finished, cancelled or failed job to run from scratch, setting
+// all of its pairs back to pending
+func (jq *JobQueue) RestartJob(jobID int) error {
+	return jq.requeue(jobID, true)
+}
+
+// requeue puts a stopped job back on the queue. The job is reserved before the
+// database is touched, so a second resume or restart of it fails instead of queueing
+// it twice.
+func (jq *JobQueue) requeue(jobID int, restart bool) error {
+	if !jq.reserve(jobID) {
+		return fmt.Errorf("job %d is still queued or running", jobID)
+	}
+	job, err := jq.resetForRequeue(jobID, restart)
+	jq.release(jobID, err == nil)
+	if err != nil {
+		return err
+	}
+	jq.jobs <- job
+	return nil
+}
+
+// resetForRequeue sets a stopped job back to pending, from scratch when restarting
+func (jq *JobQueue) resetForRequeue(jobID int, restart bool) (*EvaluationJob, error) {
+	job, err := jq.GetJob(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job %d: %w", jobID, err)
+	}
+	switch {
+	case job.Status == "pending" || job.Status == "running" || job.Status == "paused":
+		return nil, fmt.Errorf("job %d is %s", jobID, job.Status)
+	case job.Status == "completed" && !restart:
+		return nil, fmt.Errorf("job %d already completed; restart it instead", jobID)
+	}
+
+	if restart {
+		if err := jq.resetJobPairs(jobID); err != nil {
+			return nil, err
+		}
+		if _, err := jq.db.Exec(`
+			UPDATE evaluation_jobs SET cache_hits = 0, cache_misses = 0, cache_saved_usd = 0 WHERE id = ?
+		`, jobID); err != nil {
+			return nil, fmt.Errorf("failed to reset cache counts of job %d: %w", jobID, err)
+		}
+		job.ProgressCurrent = 0
+		job.ActualCost = 0
+		job.CacheHits, job.CacheMisses, job.CacheSavedUSD = 0, 0, 0
+	}
+	job.Status = "pending"
+	job.ErrorMessage = ""
+	job.StartedAt = nil
+	job.CompletedAt = nil
+	if err := jq.updateJob(job); err != nil {
+		return nil, fmt.Errorf("failed to update job %d: %w", jobID, err)
+	}
+	return job, nil
+}
+
 // resumePendingJobs resumes jobs that were interrupted
 func (jq *JobQueue) resumePendingJobs() {
 	if jq.resumeDelay > 0 {
-		time.Sleep(jq.resumeDelay) // Wait for initialization
+		// Wait for initialization
+		select {
+		case <-time.After(jq.resumeDelay):
+		case <-jq.baseContext().Done():
+			return
+		}
 	}
 
 	rows, err := jq.db.Query(`
-		SELECT id, suite_id, job_type, target_id, progress_total, estimated_cost_usd
+		SELECT id, suite_id, job_type, COALESCE(target_id, 0), progress_current, progress_total,
+		       estimated_cost_usd, actual_cost_usd, force_refresh
 		FROM evaluation_jobs
-		WHERE status = 'pending' OR status = 'running'
+		WHERE status IN ('pending', 'running', 'paused')
 		ORDER BY created_at
 	`)
 	if err != nil {
@@ -216,12 +486,13 @@ func (jq *JobQueue) resumePendingJobs() {
 	for rows.Next() {
 		job := &EvaluationJob{}
 		if err := rows.Scan(&job.ID, &job.SuiteID, &job.JobType, &job.TargetID,
-			&job.ProgressTotal, &job.EstimatedCost); err != nil {
+			&job.ProgressCurrent, &job.ProgressTotal, &job.EstimatedCost, &job.ActualCost, &job.ForceRefresh); err != nil {
 			log.Printf("Failed to scan job: %v", err)
 			continue
 		}
 
 		job.Status = "pending"
+		jq.markQueued(job.ID)
 		jq.jobs <- job
 		count++
 	}
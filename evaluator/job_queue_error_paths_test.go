@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"io"
@@ -22,7 +23,7 @@ func TestJobQueue_Enqueue_InsertError_ReturnsError(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 1),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	job := &EvaluationJob{
@@ -50,7 +51,7 @@ func TestJobQueue_Enqueue_LastInsertIDError_ReturnsError(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 1),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	original := lastInsertID
@@ -91,7 +92,7 @@ func TestWorker_ProcessJobError_SetsFailedAndCleansUp(t *testing.T) {
 		jobs:      make(chan *EvaluationJob, 1),
 		workers:   0,
 		running:   make(map[int]bool),
-		cancel:    make(map[int]chan bool),
+		cancel:    make(map[int]context.CancelFunc),
 		evaluator: e,
 	}
 
@@ -1,7 +1,11 @@
 package evaluator
 
 import (
+	"context"
 	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -15,6 +19,8 @@ func setupTestJobQueueDB(t *testing.T) *sql.DB {
 	if err != nil {
 		t.Fatalf("failed to open test database: %v", err)
 	}
+	// Every connection to :memory: is a separate database; jobs use the pool from several goroutines
+	db.SetMaxOpenConns(1)
 
 	// Create schema
 	schema := `
@@ -35,12 +41,29 @@ func setupTestJobQueueDB(t *testing.T) *sql.DB {
 			estimated_cost_usd REAL DEFAULT 0.0,
 			actual_cost_usd REAL DEFAULT 0.0,
 			error_message TEXT DEFAULT '',
+			force_refresh INTEGER NOT NULL DEFAULT 0,
+			cache_hits INTEGER NOT NULL DEFAULT 0,
+			cache_misses INTEGER NOT NULL DEFAULT 0,
+			cache_saved_usd REAL NOT NULL DEFAULT 0,
+			judge_panel TEXT NOT NULL DEFAULT '',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			started_at TIMESTAMP,
 			completed_at TIMESTAMP,
 			FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE
 		);
 
+		CREATE TABLE IF NOT EXISTS evaluation_job_pairs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			job_id INTEGER NOT NULL,
+			model_id INTEGER NOT NULL,
+			prompt_id INTEGER NOT NULL,
+			outcome TEXT NOT NULL DEFAULT 'pending',
+			cost_usd REAL NOT NULL DEFAULT 0.0,
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(job_id, model_id, prompt_id)
+		);
+
 		INSERT INTO suites (name, is_current) VALUES ('default', 1);
 	`
 	_, err = db.Exec(schema)
@@ -85,7 +108,7 @@ func TestJobQueue_Enqueue(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	job := &EvaluationJob{
@@ -127,7 +150,7 @@ func TestJobQueue_GetJob(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	// Enqueue a job
@@ -169,7 +192,7 @@ func TestJobQueue_GetJob_WithStartedAndCompletedTimes(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	startedAt := time.Now().Add(-2 * time.Second)
@@ -209,7 +232,7 @@ func TestJobQueue_GetJob_NotFound(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	_, err := jq.GetJob(999)
@@ -227,7 +250,7 @@ func TestJobQueue_CancelJob_NotRunning(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	err := jq.CancelJob(999)
@@ -245,7 +268,7 @@ func TestJobQueue_CancelJob_Running(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	// Enqueue a job first
@@ -259,9 +282,10 @@ func TestJobQueue_CancelJob_Running(t *testing.T) {
 	}
 
 	// Simulate job running
+	ctx, cancel := context.WithCancel(context.Background())
 	jq.mu.Lock()
 	jq.running[job.ID] = true
-	jq.cancel[job.ID] = make(chan bool, 1)
+	jq.cancel[job.ID] = cancel
 	jq.mu.Unlock()
 
 	// Cancel the job
@@ -269,6 +293,9 @@ func TestJobQueue_CancelJob_Running(t *testing.T) {
 	if err != nil {
 		t.Fatalf("CancelJob failed: %v", err)
 	}
+	if ctx.Err() == nil {
+		t.Error("expected the job's context to be cancelled")
+	}
 
 	// Verify job is cancelled in database
 	var status string
@@ -290,7 +317,7 @@ func TestJobQueue_UpdateJobProgress(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	// Enqueue a job
@@ -369,7 +396,7 @@ func TestJobQueue_MultipleJobs(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	// Enqueue multiple jobs
@@ -416,7 +443,7 @@ func TestJobQueue_UpdateJob_StatusChange(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	// Enqueue a job
@@ -456,7 +483,7 @@ func TestJobQueue_UpdateJob_Completed(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	// Enqueue a job
@@ -501,7 +528,7 @@ func TestJobQueue_UpdateJob_WithError(t *testing.T) {
 		jobs:    make(chan *EvaluationJob, 100),
 		workers: 0,
 		running: make(map[int]bool),
-		cancel:  make(map[int]chan bool),
+		cancel:  make(map[int]context.CancelFunc),
 	}
 
 	// Enqueue a job
@@ -532,3 +559,285 @@ func TestJobQueue_UpdateJob_WithError(t *testing.T) {
 		t.Errorf("expected error message 'Test error message', got %q", errorMsg)
 	}
 }
+
+// newBlockingJudgeEvaluator returns an evaluator whose judge service holds every
+// request open until the client gives up, and a channel signalled when a request arrives
+func newBlockingJudgeEvaluator(t *testing.T, db *sql.DB) (*Evaluator, <-chan struct{}) {
+	t.Helper()
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1)",
+		"INSERT INTO prompts (text, suite_id, display_order) VALUES ('p1', 1, 0)",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 1, 'r1')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			_, _ = w.Write([]byte(`{"status": "healthy", "prompt_version": "v1"}`))
+			return
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	e := newGeneratorTestEvaluator(db)
+	e.litellmClient = NewLiteLLMClient(server.URL)
+	e.judges = []string{"claude"}
+	return e, started
+}
+
+func waitForJudgeRequest(t *testing.T, started <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the judge request")
+	}
+}
+
+func TestJobQueue_CancelJob_AbortsInFlightRequest(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e, started := newBlockingJudgeEvaluator(t, db)
+	jq := e.jobQueue
+
+	done := make(chan struct{})
+	go func() {
+		jq.worker(0)
+		close(done)
+	}()
+	defer func() {
+		close(jq.jobs)
+		<-done
+	}()
+
+	job := &EvaluationJob{SuiteID: 1, JobType: "all", ProgressTotal: 1}
+	if err := jq.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	waitForJudgeRequest(t, started)
+
+	if err := jq.CancelJob(job.ID); err != nil {
+		t.Fatalf("CancelJob failed: %v", err)
+	}
+
+	// The judge request is still open on the server side, so the job can only
+	// finish this quickly if cancelling aborted it
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		jq.mu.Lock()
+		running := jq.running[job.ID]
+		jq.mu.Unlock()
+		if !running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job still running after cancel")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var status, message string
+	var completedAt sql.NullTime
+	if err := db.QueryRow("SELECT status, error_message, completed_at FROM evaluation_jobs WHERE id = ?", job.ID).Scan(&status, &message, &completedAt); err != nil {
+		t.Fatalf("failed to query job: %v", err)
+	}
+	if status != "cancelled" || message != errJobCancelled.Error() || !completedAt.Valid {
+		t.Errorf("expected cancelled job with completion time, got %q / %q / %v", status, message, completedAt.Valid)
+	}
+
+	var failures int
+	_ = db.QueryRow("SELECT COUNT(*) FROM evaluation_errors WHERE job_id = ?", job.ID).Scan(&failures)
+	if failures != 0 {
+		t.Errorf("expected the aborted pair not to be recorded as a failure, got %d", failures)
+	}
+}
+
+func TestJobQueue_CancelJob_Pending(t *testing.T) {
+	db := setupTestJobQueueDB(t)
+	defer func() { _ = db.Close() }()
+
+	jq := &JobQueue{
+		db:      db,
+		jobs:    make(chan *EvaluationJob, 100),
+		running: make(map[int]bool),
+		cancel:  make(map[int]context.CancelFunc),
+	}
+
+	job := &EvaluationJob{SuiteID: 1, JobType: "all"}
+	if err := jq.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if err := jq.CancelJob(job.ID); err != nil {
+		t.Fatalf("CancelJob failed: %v", err)
+	}
+
+	var status string
+	var completedAt sql.NullTime
+	if err := db.QueryRow("SELECT status, completed_at FROM evaluation_jobs WHERE id = ?", job.ID).Scan(&status, &completedAt); err != nil {
+		t.Fatalf("failed to query job: %v", err)
+	}
+	if status != "cancelled" || !completedAt.Valid {
+		t.Errorf("expected cancelled job with completion time, got %q / %v", status, completedAt.Valid)
+	}
+
+	// The worker that reaches the job skips it
+	if _, ok := jq.claim(<-jq.jobs); ok {
+		t.Error("expected cancelled job to be skipped")
+	}
+	if len(jq.running) != 0 || len(jq.dropped) != 0 {
+		t.Errorf("expected no bookkeeping left, got running=%v dropped=%v", jq.running, jq.dropped)
+	}
+}
+
+func TestJobQueue_RemoveJob(t *testing.T) {
+	db := setupTestJobQueueDB(t)
+	defer func() { _ = db.Close() }()
+
+	jq := &JobQueue{
+		db:      db,
+		jobs:    make(chan *EvaluationJob, 100),
+		running: make(map[int]bool),
+		cancel:  make(map[int]context.CancelFunc),
+	}
+
+	job := &EvaluationJob{SuiteID: 1, JobType: "all"}
+	if err := jq.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if err := jq.RemoveJob(job.ID); err != nil {
+		t.Fatalf("RemoveJob failed: %v", err)
+	}
+
+	var count int
+	_ = db.QueryRow("SELECT COUNT(*) FROM evaluation_jobs WHERE id = ?", job.ID).Scan(&count)
+	if count != 0 {
+		t.Error("expected job to be deleted")
+	}
+	if _, ok := jq.claim(<-jq.jobs); ok {
+		t.Error("expected removed job to be skipped")
+	}
+	if err := jq.RemoveJob(job.ID); err == nil {
+		t.Error("expected error removing a job twice")
+	}
+}
+
+func TestJobQueue_RemoveJob_Started(t *testing.T) {
+	db := setupTestJobQueueDB(t)
+	defer func() { _ = db.Close() }()
+
+	jq := &JobQueue{
+		db:      db,
+		jobs:    make(chan *EvaluationJob, 100),
+		running: make(map[int]bool),
+		cancel:  make(map[int]context.CancelFunc),
+	}
+
+	if _, err := db.Exec("INSERT INTO evaluation_jobs (suite_id, job_type, status, started_at, completed_at) VALUES (1, 'all', 'completed', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)"); err != nil {
+		t.Fatalf("failed to insert job: %v", err)
+	}
+	if err := jq.RemoveJob(1); err == nil {
+		t.Error("expected error removing a job that already ran")
+	}
+
+	jq.running[2] = true
+	if err := jq.RemoveJob(2); err == nil {
+		t.Error("expected error removing a running job")
+	}
+}
+
+func TestJobQueue_Shutdown_LeavesJobToResume(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e, started := newBlockingJudgeEvaluator(t, db)
+	jq := e.jobQueue
+	jq.ctx, jq.stop = context.WithCancel(context.Background())
+	go jq.worker(0)
+	defer close(jq.jobs)
+
+	job := &EvaluationJob{SuiteID: 1, JobType: "all", ProgressTotal: 1}
+	if err := jq.Enqueue(job); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	waitForJudgeRequest(t, started)
+
+	begin := time.Now()
+	jq.Shutdown(5 * time.Second)
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Errorf("expected shutdown to abort the judge request, took %s", elapsed)
+	}
+
+	var status string
+	var completedAt sql.NullTime
+	if err := db.QueryRow("SELECT status, completed_at FROM evaluation_jobs WHERE id = ?", job.ID).Scan(&status, &completedAt); err != nil {
+		t.Fatalf("failed to query job: %v", err)
+	}
+	if status != "running" || completedAt.Valid {
+		t.Errorf("expected job left running for resume, got %q (completed=%v)", status, completedAt.Valid)
+	}
+
+	// Jobs reaching a worker after shutdown stay pending
+	pending := &EvaluationJob{ID: 99}
+	if _, ok := jq.claim(pending); ok {
+		t.Error("expected no jobs to start after shutdown")
+	}
+}
+
+func TestJobQueue_ConcurrentResumeQueuesOnce(t *testing.T) {
+	db := setupTestJobQueueDB(t)
+	defer func() { _ = db.Close() }()
+
+	jq := &JobQueue{db: db, jobs: make(chan *EvaluationJob, 100), running: make(map[int]bool)}
+	if _, err := db.Exec(`INSERT INTO evaluation_jobs (id, suite_id, job_type, status) VALUES (1, 1, 'all', 'failed')`); err != nil {
+		t.Fatalf("failed to insert job: %v", err)
+	}
+
+	// Holding the only connection stalls every resume that gets as far as the database
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("failed to hold connection: %v", err)
+	}
+	const attempts = 8
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		go func() { errs <- jq.ResumeJob(1) }()
+	}
+	time.Sleep(50 * time.Millisecond)
+	_ = conn.Close()
+
+	resumed := 0
+	for i := 0; i < attempts; i++ {
+		if <-errs == nil {
+			resumed++
+		}
+	}
+	if resumed != 1 || len(jq.jobs) != 1 {
+		t.Fatalf("expected one resume to queue the job once, got %d resumes and %d queued", resumed, len(jq.jobs))
+	}
+
+	// A refused requeue gives up its reservation
+	<-jq.jobs
+	jq.queued = nil
+	if _, err := db.Exec(`UPDATE evaluation_jobs SET status = 'completed' WHERE id = 1`); err != nil {
+		t.Fatalf("failed to complete job: %v", err)
+	}
+	if err := jq.ResumeJob(1); err == nil {
+		t.Fatal("expected resuming a completed job to fail")
+	}
+	if jq.requeuing[1] || jq.queued[1] {
+		t.Errorf("expected the failed resume to release the job, got requeuing=%v queued=%v", jq.requeuing, jq.queued)
+	}
+}
@@ -0,0 +1,360 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// JobTypeRetryFailed re-evaluates the failed pairs of an earlier job, whose ID is the target
+const JobTypeRetryFailed = "retry_failed"
+
+// Outcomes recorded for each model × prompt pair of a job
+const (
+	PairPending   = "pending" // Not evaluated yet
+	PairScored    = "scored"
+	PairSkipped   = "skipped" // No stored response to judge
+	PairFailed    = "failed"
+	PairCancelled = "cancelled"
+)
+
+// JobEvents receives job progress as it happens, for example to push it to the
+// browser over the websocket. Nil functions are skipped.
+type JobEvents struct {
+	Progress  func(jobID, current, total int, cost float64)
+	Completed func(jobID int, cost float64)
+	Failed    func(jobID int, message string)
+	CostAlert func(suiteID int, monthCost, threshold float64)
+}
+
+// SetJobEvents replaces the functions told about job progress
+func (e *Evaluator) SetJobEvents(events JobEvents) {
+	e.providersMu.Lock()
+	defer e.providersMu.Unlock()
+	e.events = events
+}
+
+// jobEvents returns the active event functions
+func (e *Evaluator) jobEvents() JobEvents {
+	e.providersMu.RLock()
+	defer e.providersMu.RUnlock()
+	return e.events
+}
+
+// reportProgress records how far a job has got, on the job itself, in the database
+// and to the progress listener
+func (e *Evaluator) reportProgress(job *EvaluationJob, current int, cost float64) {
+	job.ProgressCurrent = current
+	job.ActualCost = cost
+	if err := e.jobQueue.UpdateJobProgress(job.ID, current, job.ProgressTotal, cost); err != nil {
+		log.Printf("Failed to update progress: %v", err)
+	}
+	if progress := e.jobEvents().Progress; progress != nil {
+		progress(job.ID, current, job.ProgressTotal, cost)
+	}
+}
+
+// JobFilter narrows the job list. Zero fields match every job.
+type JobFilter struct {
+	Status  string
+	SuiteID int
+	JobType string
+	Since   time.Time // Created at or after
+	Until   time.Time // Created before
+	Limit   int       // Most recent jobs first; 0 means DefaultJobListLimit
+}
+
+// DefaultJobListLimit caps the job list when the filter sets no limit
+const DefaultJobListLimit = 200
+
+// JobSummary is a job as shown in the job list
+type JobSummary struct {
+	EvaluationJob
+	SuiteName   string `json:"suite_name"`
+	FailedPairs int    `json:"failed_pairs"`
+}
+
+// ListJobs returns the jobs matching filter, newest first
+func (e *Evaluator) ListJobs(filter JobFilter) ([]JobSummary, error) {
+	var where []string
+	var args []interface{}
+	if filter.Status != "" {
+		where = append(where, "j.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.SuiteID != 0 {
+		where = append(where, "j.suite_id = ?")
+		args = append(args, filter.SuiteID)
+	}
+	if filter.JobType != "" {
+		where = append(where, "j.job_type = ?")
+		args = append(args, filter.JobType)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "j.created_at >= ?")
+		args = append(args, filter.Since.UTC().Format(time.DateTime))
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "j.created_at < ?")
+		args = append(args, filter.Until.UTC().Format(time.DateTime))
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultJobListLimit
+	}
+
+	query := `
+		SELECT j.id, j.suite_id, COALESCE(s.name, ''), j.job_type, COALESCE(j.target_id, 0), j.status,
+		       j.progress_current, j.progress_total, j.estimated_cost_usd, j.actual_cost_usd,
+		       COALESCE(j.error_message, ''), j.created_at, j.started_at, j.completed_at,
+		       j.force_refresh, j.cache_hits, j.cache_misses, j.cache_saved_usd, j.judge_panel,
+		       (SELECT COUNT(*) FROM evaluation_job_pairs p WHERE p.job_id = j.id AND p.outcome = ?)
+		FROM evaluation_jobs j
+		LEFT JOIN suites s ON s.id = j.suite_id`
+	args = append([]interface{}{PairFailed}, args...)
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	query += "\n\t\tORDER BY j.created_at DESC, j.id DESC\n\t\tLIMIT ?"
+	args = append(args, limit)
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var jobs []JobSummary
+	for rows.Next() {
+		var j JobSummary
+		var startedAt, completedAt sql.NullTime
+		if err := rows.Scan(&j.ID, &j.SuiteID, &j.SuiteName, &j.JobType, &j.TargetID, &j.Status,
+			&j.ProgressCurrent, &j.ProgressTotal, &j.EstimatedCost, &j.ActualCost,
+			&j.ErrorMessage, &j.CreatedAt, &startedAt, &completedAt,
+			&j.ForceRefresh, &j.CacheHits, &j.CacheMisses, &j.CacheSavedUSD, &j.JudgePanel, &j.FailedPairs); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		if startedAt.Valid {
+			j.StartedAt = &startedAt.Time
+		}
+		if completedAt.Valid {
+			j.CompletedAt = &completedAt.Time
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// PairOutcome is how one model × prompt pair of a job ended
+type PairOutcome struct {
+	ModelID    int     `json:"model_id"`
+	Model      string  `json:"model"`
+	PromptID   int     `json:"prompt_id"`
+	Prompt     string  `json:"prompt"`
+	Outcome    string  `json:"outcome"`
+	ErrorKind  string  `json:"error_kind,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	Attempts   int     `json:"attempts,omitempty"`
+	CostUSD    float64 `json:"cost_usd"`
+	DurationMS int64   `json:"duration_ms"`
+}
+
+// JobPairs lists every pair of a job in grid order with its outcome. Failed pairs
+// carry the error recorded for them.
+func (e *Evaluator) JobPairs(jobID int) ([]PairOutcome, error) {
+	rows, err := e.db.Query(`
+		SELECT p.model_id, COALESCE(m.name, ''), p.prompt_id, COALESCE(pr.text, ''), p.outcome,
+		       COALESCE(err.error_kind, ''), COALESCE(err.error_message, ''), COALESCE(err.attempts, 0),
+		       p.cost_usd, p.duration_ms
+		FROM evaluation_job_pairs p
+		LEFT JOIN models m ON m.id = p.model_id
+		LEFT JOIN prompts pr ON pr.id = p.prompt_id
+		LEFT JOIN evaluation_errors err ON err.id = (
+			SELECT MAX(id) FROM evaluation_errors
+			WHERE job_id = p.job_id AND model_id = p.model_id AND prompt_id = p.prompt_id
+		)
+		WHERE p.job_id = ?
+		ORDER BY m.name, pr.display_order, p.prompt_id
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job pairs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var pairs []PairOutcome
+	for rows.Next() {
+		var p PairOutcome
+		if err := rows.Scan(&p.ModelID, &p.Model, &p.PromptID, &p.Prompt, &p.Outcome,
+			&p.ErrorKind, &p.Error, &p.Attempts, &p.CostUSD, &p.DurationMS); err != nil {
+			return nil, fmt.Errorf("failed to scan job pair: %w", err)
+		}
+		if p.Outcome != PairFailed {
+			p.ErrorKind, p.Error, p.Attempts = "", "", 0
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, rows.Err()
+}
+
+// RetryFailedPairs queues a job that evaluates only the pairs that failed in an
+// earlier job
+func (e *Evaluator) RetryFailedPairs(jobID int) (int, error) {
+	source, err := e.jobQueue.GetJob(jobID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get job %d: %w", jobID, err)
+	}
+	pairs, err := e.jobQueue.jobPairs(jobID, PairFailed)
+	if err != nil {
+		return 0, err
+	}
+	if len(pairs) == 0 {
+		return 0, fmt.Errorf("job %d has no failed pairs", jobID)
+	}
+
+	job := &EvaluationJob{
+		SuiteID:       source.SuiteID,
+		JobType:       JobTypeRetryFailed,
+		TargetID:      jobID,
+		ProgressTotal: len(pairs),
+		EstimatedCost: float64(len(pairs)) * 0.05,
+		ForceRefresh:  source.ForceRefresh,
+		JudgePanel:    source.JudgePanel,
+	}
+	if err := e.jobQueue.enqueue(job, pairs); err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return job.ID, nil
+}
+
+// processRecordedPairsJob evaluates the pairs recorded for the job when it was queued
+func (e *Evaluator) processRecordedPairsJob(ctx context.Context, job *EvaluationJob) error {
+	pairs, err := e.jobQueue.jobPairs(job.ID, "")
+	if err != nil {
+		return err
+	}
+	return e.evaluatePairs(ctx, job, pairs)
+}
+
+// addJobPairs records pairs as pending for a job. Pairs an earlier run of the job
+// already recorded keep their outcome, which is what lets a resumed job skip them.
+func (jq *JobQueue) addJobPairs(jobID int, pairs []modelPromptPair) error {
+	tx, err := jq.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO evaluation_job_pairs (job_id, model_id, prompt_id, outcome)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(job_id, model_id, prompt_id) DO NOTHING
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, p := range pairs {
+		if _, err := stmt.Exec(jobID, p.modelID, p.promptID, PairPending); err != nil {
+			return fmt.Errorf("failed to record pair: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// resetJobPairs sets every pair of a job back to pending so a restart evaluates them
+// again, and forgets the battles it judged
+func (jq *JobQueue) resetJobPairs(jobID int) error {
+	_, err := jq.db.Exec(`
+		UPDATE evaluation_job_pairs
+		SET outcome = ?, cost_usd = 0, duration_ms = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE job_id = ?
+	`, PairPending, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to reset pairs of job %d: %w", jobID, err)
+	}
+	if _, err := jq.db.Exec("DELETE FROM battle_job_pairs WHERE job_id = ?", jobID); err != nil {
+		return fmt.Errorf("failed to reset battles of job %d: %w", jobID, err)
+	}
+	return nil
+}
+
+// finishedPair is the checkpoint of a pair an earlier run of a job got through
+type finishedPair struct {
+	outcome string
+	cost    float64
+}
+
+// finishedPairs returns the pairs of a job that were scored, skipped or failed.
+// Pending and cancelled pairs still need evaluating.
+func (jq *JobQueue) finishedPairs(jobID int) (map[modelPromptPair]finishedPair, error) {
+	rows, err := jq.db.Query(`
+		SELECT model_id, prompt_id, outcome, cost_usd FROM evaluation_job_pairs
+		WHERE job_id = ? AND outcome IN (?, ?, ?)
+	`, jobID, PairScored, PairSkipped, PairFailed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query finished pairs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	done := make(map[modelPromptPair]finishedPair)
+	for rows.Next() {
+		var p modelPromptPair
+		var f finishedPair
+		if err := rows.Scan(&p.modelID, &p.promptID, &f.outcome, &f.cost); err != nil {
+			return nil, fmt.Errorf("failed to scan finished pair: %w", err)
+		}
+		done[p] = f
+	}
+	return done, rows.Err()
+}
+
+// setPairOutcome records how a pair of a job ended
+func (jq *JobQueue) setPairOutcome(jobID int, r pairResult) {
+	_, err := jq.db.Exec(`
+		UPDATE evaluation_job_pairs
+		SET outcome = ?, cost_usd = ?, duration_ms = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE job_id = ? AND model_id = ? AND prompt_id = ?
+	`, r.outcome, r.cost, r.duration.Milliseconds(), jobID, r.pair.modelID, r.pair.promptID)
+	if err != nil {
+		log.Printf("Failed to record outcome of model %d, prompt %d: %v", r.pair.modelID, r.pair.promptID, err)
+	}
+}
+
+// cancelPendingPairs marks the pairs a cancelled job never finished
+func (jq *JobQueue) cancelPendingPairs(jobID int) {
+	_, err := jq.db.Exec(`
+		UPDATE evaluation_job_pairs SET outcome = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE job_id = ? AND outcome = ?
+	`, PairCancelled, jobID, PairPending)
+	if err != nil {
+		log.Printf("Failed to cancel pairs of job %d: %v", jobID, err)
+	}
+}
+
+// jobPairs returns a job's pairs with the given outcome, or all of them for ""
+func (jq *JobQueue) jobPairs(jobID int, outcome string) ([]modelPromptPair, error) {
+	rows, err := jq.db.Query(`
+		SELECT model_id, prompt_id FROM evaluation_job_pairs
+		WHERE job_id = ? AND (? = '' OR outcome = ?)
+		ORDER BY id
+	`, jobID, outcome, outcome)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job pairs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var pairs []modelPromptPair
+	for rows.Next() {
+		var p modelPromptPair
+		if err := rows.Scan(&p.modelID, &p.promptID); err != nil {
+			return nil, fmt.Errorf("failed to scan job pair: %w", err)
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, rows.Err()
+}
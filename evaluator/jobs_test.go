@@ -0,0 +1,374 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+)
+
+// promptFailProvider fails the prompts listed in fail and scores the rest
+type promptFailProvider struct {
+	fail  map[string]bool
+	calls int
+}
+
+func (p *promptFailProvider) Name() string { return "prompt-fail" }
+
+func (p *promptFailProvider) Evaluate(_ context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	p.calls++
+	if p.fail[req.Prompt] {
+		return nil, errTest
+	}
+	return &EvaluationResponse{ConsensusScore: 80, TotalCostUSD: 0.01}, nil
+}
+
+// seedJobGrid adds models m1, m2 and prompts p1, p2 to suite 1 with responses for every
+// pair but m2 × p2
+func seedJobGrid(t *testing.T, db *sql.DB) {
+	t.Helper()
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('m1', 1), ('m2', 1)",
+		"INSERT INTO prompts (text, suite_id, display_order) VALUES ('p1', 1, 0), ('p2', 1, 1)",
+		`INSERT INTO model_responses (model_id, prompt_id, response_text)
+		 SELECT m.id, p.id, 'r' FROM models m, prompts p WHERE NOT (m.name = 'm2' AND p.text = 'p2')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+}
+
+// runQueuedJob runs the next job in the queue on the calling goroutine
+func runQueuedJob(t *testing.T, e *Evaluator) *EvaluationJob {
+	t.Helper()
+	select {
+	case job := <-e.jobQueue.jobs:
+		ctx, ok := e.jobQueue.claim(job)
+		if !ok {
+			t.Fatalf("job %d was not claimed", job.ID)
+		}
+		e.jobQueue.runJob(0, ctx, job)
+		return job
+	default:
+		t.Fatal("expected a queued job")
+		return nil
+	}
+}
+
+func outcomesByPair(t *testing.T, e *Evaluator, jobID int) map[string]PairOutcome {
+	t.Helper()
+	pairs, err := e.JobPairs(jobID)
+	if err != nil {
+		t.Fatalf("JobPairs failed: %v", err)
+	}
+	byPair := make(map[string]PairOutcome)
+	for _, p := range pairs {
+		byPair[p.Model+"/"+p.Prompt] = p
+	}
+	return byPair
+}
+
+func TestRunJob_RecordsPairOutcomesAndEvents(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(&promptFailProvider{fail: map[string]bool{"p1": true}})
+	e.SetConcurrency(1)
+
+	var progress []int
+	var completedCost float64
+	e.SetJobEvents(JobEvents{
+		Progress:  func(jobID, current, total int, cost float64) { progress = append(progress, current) },
+		Completed: func(jobID int, cost float64) { completedCost = cost },
+		Failed:    func(jobID int, message string) { t.Errorf("unexpected failure event: %s", message) },
+	})
+
+	if _, err := e.EvaluateAll(1, false); err != nil {
+		t.Fatalf("EvaluateAll failed: %v", err)
+	}
+	job := runQueuedJob(t, e)
+
+	if len(progress) != 4 || progress[3] != 4 {
+		t.Errorf("expected a progress event per pair, got %v", progress)
+	}
+	if math.Abs(completedCost-0.01) > 1e-9 {
+		t.Errorf("expected the completion event to carry $0.01, got %f", completedCost)
+	}
+
+	stored, err := e.GetJobStatus(job.ID)
+	if err != nil {
+		t.Fatalf("GetJobStatus failed: %v", err)
+	}
+	if stored.Status != "completed" || stored.ProgressCurrent != 4 || math.Abs(stored.ActualCost-0.01) > 1e-9 {
+		t.Errorf("expected the completed job to keep its progress and cost, got %q %d $%f",
+			stored.Status, stored.ProgressCurrent, stored.ActualCost)
+	}
+
+	byPair := outcomesByPair(t, e, job.ID)
+	for key, want := range map[string]string{
+		"m1/p1": PairFailed,
+		"m2/p1": PairFailed,
+		"m1/p2": PairScored,
+		"m2/p2": PairSkipped,
+	} {
+		if got := byPair[key].Outcome; got != want {
+			t.Errorf("expected %s %s, got %q", key, want, got)
+		}
+	}
+	if p := byPair["m1/p1"]; p.Error == "" || p.ErrorKind == "" || p.Attempts == 0 {
+		t.Errorf("expected the failed pair to carry its error, got %+v", p)
+	}
+	if p := byPair["m1/p2"]; math.Abs(p.CostUSD-0.01) > 1e-9 || p.Error != "" {
+		t.Errorf("expected the scored pair to cost $0.01 without an error, got %+v", p)
+	}
+}
+
+func TestRetryFailedPairs_EvaluatesOnlyFailedPairs(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+
+	provider := &promptFailProvider{fail: map[string]bool{"p1": true}}
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(provider)
+	e.SetConcurrency(1)
+
+	if _, err := e.EvaluateAll(1, false); err != nil {
+		t.Fatalf("EvaluateAll failed: %v", err)
+	}
+	first := runQueuedJob(t, e)
+
+	provider.fail = nil
+	retryID, err := e.RetryFailedPairs(first.ID)
+	if err != nil {
+		t.Fatalf("RetryFailedPairs failed: %v", err)
+	}
+	retry := runQueuedJob(t, e)
+	if retry.ID != retryID || retry.JobType != JobTypeRetryFailed || retry.TargetID != first.ID {
+		t.Fatalf("expected retry job %d targeting job %d, got %+v", retryID, first.ID, retry)
+	}
+
+	byPair := outcomesByPair(t, e, retryID)
+	if len(byPair) != 2 || byPair["m1/p1"].Outcome != PairScored || byPair["m2/p1"].Outcome != PairScored {
+		t.Errorf("expected the retry to score just the two failed pairs, got %+v", byPair)
+	}
+
+	if _, err := e.RetryFailedPairs(retryID); err == nil {
+		t.Error("expected an error retrying a job without failed pairs")
+	}
+}
+
+func TestRunJob_CancelledLeavesPendingPairsCancelled(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+
+	e := newGeneratorTestEvaluator(db)
+	var failedMessage string
+	e.SetJobEvents(JobEvents{Failed: func(jobID int, message string) { failedMessage = message }})
+
+	job := &EvaluationJob{SuiteID: 1, JobType: "all", ProgressTotal: 1}
+	if err := e.jobQueue.enqueue(job, []modelPromptPair{{modelID: 1, promptID: 1}}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	<-e.jobQueue.jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e.jobQueue.active.Add(1)
+	e.jobQueue.runJob(0, ctx, job)
+
+	if failedMessage != errJobCancelled.Error() {
+		t.Errorf("expected a failure event for the cancellation, got %q", failedMessage)
+	}
+	if p := outcomesByPair(t, e, job.ID)["m1/p1"]; p.Outcome != PairCancelled {
+		t.Errorf("expected the unfinished pair cancelled, got %q", p.Outcome)
+	}
+}
+
+func TestListJobs_Filters(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.Exec(`
+		INSERT INTO suites (id, name) VALUES (2, 'other');
+		INSERT INTO evaluation_jobs (id, suite_id, job_type, status, created_at) VALUES
+			(1, 1, 'all', 'completed', '2026-03-01 10:00:00'),
+			(2, 1, 'model', 'failed', '2026-03-05 10:00:00'),
+			(3, 2, 'all', 'completed', '2026-03-10 10:00:00');
+		INSERT INTO evaluation_job_pairs (job_id, model_id, prompt_id, outcome) VALUES
+			(2, 1, 1, 'failed'), (2, 1, 2, 'failed'), (2, 2, 1, 'scored');
+	`); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	e := newGeneratorTestEvaluator(db)
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+	tests := []struct {
+		name   string
+		filter JobFilter
+		want   []int
+	}{
+		{"all newest first", JobFilter{}, []int{3, 2, 1}},
+		{"status", JobFilter{Status: "completed"}, []int{3, 1}},
+		{"suite", JobFilter{SuiteID: 1}, []int{2, 1}},
+		{"type", JobFilter{JobType: "all"}, []int{3, 1}},
+		{"dates", JobFilter{Since: day("2026-03-02"), Until: day("2026-03-10")}, []int{2}},
+		{"limit", JobFilter{Limit: 1}, []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := e.ListJobs(tt.filter)
+			if err != nil {
+				t.Fatalf("ListJobs failed: %v", err)
+			}
+			var got []int
+			for _, j := range jobs {
+				got = append(got, j.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected jobs %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected jobs %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	jobs, _ := e.ListJobs(JobFilter{Status: "failed"})
+	if len(jobs) != 1 || jobs[0].FailedPairs != 2 || jobs[0].SuiteName == "" {
+		t.Errorf("expected job 2 with its suite name and 2 failed pairs, got %+v", jobs)
+	}
+}
+
+func TestEvaluatePairs_ResumeSkipsFinishedPairs(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+
+	// An earlier run scored m1 × p1 and failed m2 × p1 before the server stopped
+	if _, err := db.Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type, status, progress_current, progress_total, actual_cost_usd)
+		VALUES (1, 1, 'all', 'running', 2, 4, 0.5);
+		INSERT INTO evaluation_job_pairs (job_id, model_id, prompt_id, outcome, cost_usd) VALUES
+			(1, 1, 1, 'scored', 0.5), (1, 2, 1, 'failed', 0), (1, 1, 2, 'pending', 0);
+	`); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	provider := &promptFailProvider{}
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(provider)
+	e.SetConcurrency(1)
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 4, ActualCost: 0.5}
+	if err := e.processAllJob(context.Background(), job); err != nil {
+		t.Fatalf("processAllJob failed: %v", err)
+	}
+
+	// m2 × p2 has no response, so only m1 × p2 reaches the judge
+	if provider.calls != 1 {
+		t.Errorf("expected only the unfinished pair with a response judged, got %d calls", provider.calls)
+	}
+	if job.ProgressCurrent != 4 || math.Abs(job.ActualCost-0.51) > 1e-9 {
+		t.Errorf("expected 4 pairs for $0.51 including the earlier run, got %d for $%f", job.ProgressCurrent, job.ActualCost)
+	}
+	if job.ErrorMessage != "1 of 4 pairs failed" {
+		t.Errorf("expected the earlier failure counted, got %q", job.ErrorMessage)
+	}
+	byPair := outcomesByPair(t, e, 1)
+	if byPair["m1/p1"].CostUSD != 0.5 || byPair["m2/p1"].Outcome != PairFailed || byPair["m2/p2"].Outcome != PairSkipped {
+		t.Errorf("expected earlier outcomes kept and the rest evaluated, got %+v", byPair)
+	}
+}
+
+func TestResumePendingJobs_KeepsProgressAndCost(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	if _, err := db.Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type, status, progress_current, progress_total, actual_cost_usd)
+		VALUES (1, 1, 'all', 'running', 900, 1000, 4.5)
+	`); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.jobQueue.resumePendingJobs()
+
+	select {
+	case job := <-e.jobQueue.jobs:
+		if job.ProgressCurrent != 900 || job.ActualCost != 4.5 {
+			t.Errorf("expected the resumed job to keep 900 pairs and $4.50, got %d and $%f", job.ProgressCurrent, job.ActualCost)
+		}
+	default:
+		t.Fatal("expected the interrupted job queued")
+	}
+}
+
+func TestResumeAndRestartJob(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+
+	provider := &promptFailProvider{}
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(provider)
+	e.SetConcurrency(1)
+
+	if _, err := db.Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type, status, progress_current, progress_total, actual_cost_usd, error_message)
+		VALUES (1, 1, 'all', 'cancelled', 1, 4, 0.01, 'job cancelled');
+		INSERT INTO evaluation_job_pairs (job_id, model_id, prompt_id, outcome, cost_usd) VALUES
+			(1, 1, 1, 'scored', 0.01), (1, 2, 1, 'cancelled', 0);
+	`); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	if err := e.ResumeJob(1); err != nil {
+		t.Fatalf("ResumeJob failed: %v", err)
+	}
+	if err := e.ResumeJob(1); err == nil {
+		t.Error("expected resuming a queued job to fail")
+	}
+	job := runQueuedJob(t, e)
+	// m2 × p1 was cancelled and m1 × p2 never started; m2 × p2 has no response
+	if provider.calls != 2 {
+		t.Errorf("expected the resume to judge 2 pairs, got %d", provider.calls)
+	}
+	if job.Status != "completed" || job.ProgressCurrent != 4 || math.Abs(job.ActualCost-0.03) > 1e-9 {
+		t.Errorf("expected the resumed job completed with 4 pairs for $0.03, got %q %d $%f", job.Status, job.ProgressCurrent, job.ActualCost)
+	}
+
+	if err := e.ResumeJob(1); err == nil {
+		t.Error("expected resuming a completed job to fail")
+	}
+
+	provider.calls = 0
+	if err := e.RestartJob(1); err != nil {
+		t.Fatalf("RestartJob failed: %v", err)
+	}
+	stored, _ := e.GetJobStatus(1)
+	if stored.Status != "pending" || stored.ProgressCurrent != 0 || stored.ActualCost != 0 || stored.CompletedAt != nil {
+		t.Errorf("expected the restarted job pending from scratch, got %+v", stored)
+	}
+	runQueuedJob(t, e)
+	if provider.calls != 3 {
+		t.Errorf("expected the restart to judge all 3 pairs with responses, got %d", provider.calls)
+	}
+
+	if _, err := db.Exec("UPDATE evaluation_jobs SET status = 'running' WHERE id = 1"); err != nil {
+		t.Fatalf("failed to mark job running: %v", err)
+	}
+	if err := e.RestartJob(1); err == nil {
+		t.Error("expected restarting a running job to fail")
+	}
+}
@@ -0,0 +1,50 @@
+package evaluator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"text/template"
+)
+
+// Judge prompt templates mirror python_service/prompts so that native
+// providers and the Python service grade responses identically.
+//
+//go:embed prompts/*.tmpl
+var judgePromptFS embed.FS
+
+var judgePromptTemplates = template.Must(template.ParseFS(judgePromptFS, "prompts/*.tmpl"))
+
+// judgeSystemPrompt is sent as the system message to chat-style judges
+const judgeSystemPrompt = "You are an expert evaluator. Respond only with JSON."
+
+// judgeTemplateName returns the template used for a prompt type
+func judgeTemplateName(promptType string) string {
+	if judgeMode(promptType) == JudgeModeCreative {
+		return "creative_judge.tmpl"
+	}
+	return "objective_judge.tmpl"
+}
+
+// RenderJudgePrompt formats the judge prompt for an evaluation request
+func RenderJudgePrompt(req EvaluationRequest) (string, error) {
+	var buf bytes.Buffer
+	if err := judgePromptTemplates.ExecuteTemplate(&buf, judgeTemplateName(req.Type), req); err != nil {
+		return "", fmt.Errorf("failed to render judge prompt: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// judgePromptVersion identifies the judge prompt a prompt type is graded with. It
+// changes whenever the template or system prompt is edited.
+func judgePromptVersion(promptType string) string {
+	name := judgeTemplateName(promptType)
+	tmpl, err := judgePromptFS.ReadFile("prompts/" + name)
+	if err != nil {
+		tmpl = []byte(name)
+	}
+	sum := sha256.Sum256(append([]byte(judgeSystemPrompt+"\x00"), tmpl...))
+	return hex.EncodeToString(sum[:6])
+}
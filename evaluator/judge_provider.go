@@ -0,0 +1,147 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Default endpoints for native judge providers
+const (
+	DefaultOpenAIBaseURL    = "https://api.openai.com/v1"
+	DefaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+	DefaultGeminiBaseURL    = "https://generativelanguage.googleapis.com/v1beta/openai"
+	defaultJudgeMaxTokens   = 4096
+)
+
+// JudgeProvider scores a model response with one or more judges
+type JudgeProvider interface {
+	// Name identifies the provider in logs
+	Name() string
+	// Evaluate grades the response described by req; cancelling ctx aborts the call
+	Evaluate(ctx context.Context, req EvaluationRequest) (*EvaluationResponse, error)
+}
+
+// JudgeConfig describes a single natively-called judge model
+type JudgeConfig struct {
+	Name            string   // Judge name recorded in evaluation_history
+	Provider        string   // 'openai', 'anthropic' or 'gemini'
+	BaseURL         string   // API root, e.g. https://api.openai.com/v1
+	Model           string   // Provider model identifier
+	APIKey          string   // Decrypted API key
+	MaxTokens       int      // Completion token limit
+	Temperature     *float64 // Sampling temperature (nil leaves the provider default)
+	InputCostPer1K  float64  // USD per 1K prompt tokens
+	OutputCostPer1K float64  // USD per 1K completion tokens
+}
+
+// NewJudgeProvider builds a native provider from its configuration
+func NewJudgeProvider(cfg JudgeConfig) (JudgeProvider, error) {
+	switch cfg.Provider {
+	case "openai":
+		return NewOpenAIProvider(cfg), nil
+	case "anthropic":
+		return NewAnthropicProvider(cfg), nil
+	case "gemini":
+		// Gemini serves an OpenAI-compatible chat completions API
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = DefaultGeminiBaseURL
+		}
+		return NewOpenAIProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown judge provider: %s", cfg.Provider)
+	}
+}
+
+// completion is the raw output of a single chat call
+type completion struct {
+	Text         string
+	InputTokens  int
+	OutputTokens int
+}
+
+// chatCompleter is implemented by providers that expose a raw chat call
+type chatCompleter interface {
+	complete(ctx context.Context, system, user string) (*completion, error)
+}
+
+// completionCost prices a completion using per-1K token rates
+func completionCost(cfg JudgeConfig, c *completion) float64 {
+	return float64(c.InputTokens)/1000*cfg.InputCostPer1K + float64(c.OutputTokens)/1000*cfg.OutputCostPer1K
+}
+
+// evaluateWithCompleter runs the judge prompt through a chat provider and parses the verdict
+func evaluateWithCompleter(ctx context.Context, cfg JudgeConfig, c chatCompleter, req EvaluationRequest) (*EvaluationResponse, error) {
+	judgePrompt, err := RenderJudgePrompt(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.complete(ctx, judgeSystemPrompt, judgePrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := completionCost(cfg, out)
+	result, err := parseJudgeOutput(out.Text)
+	if err != nil {
+		return nil, fmt.Errorf("judge %s: %w", cfg.Name, err)
+	}
+	result.Judge = cfg.Name
+	result.CostUSD = cost
+
+	return &EvaluationResponse{
+		Results:        []JudgeResult{result},
+		TotalCostUSD:   cost,
+		ConsensusScore: result.Score,
+		AvgConfidence:  result.Confidence,
+	}, nil
+}
+
+// parseJudgeOutput extracts the JSON verdict from a judge completion
+func parseJudgeOutput(text string) (JudgeResult, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return JudgeResult{}, fmt.Errorf("no JSON object in judge output")
+	}
+
+	var verdict struct {
+		Score      float64            `json:"score"`
+		Confidence float64            `json:"confidence"`
+		Reasoning  string             `json:"reasoning"`
+		Criteria   map[string]float64 `json:"criteria"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &verdict); err != nil {
+		return JudgeResult{}, fmt.Errorf("failed to parse judge output: %w", err)
+	}
+
+	score := int(verdict.Score + 0.5)
+	if score < 0 {
+		score = 0
+	} else if score > 100 {
+		score = 100
+	}
+	confidence := verdict.Confidence
+	if confidence < 0 {
+		confidence = 0
+	} else if confidence > 1 {
+		confidence = 1
+	}
+
+	var criteria map[string]int
+	if len(verdict.Criteria) > 0 {
+		criteria = make(map[string]int, len(verdict.Criteria))
+		for name, v := range verdict.Criteria {
+			criteria[name] = clampScore(v)
+		}
+	}
+
+	return JudgeResult{
+		Score:      score,
+		Confidence: confidence,
+		Reasoning:  verdict.Reasoning,
+		Criteria:   criteria,
+	}, nil
+}
@@ -0,0 +1,262 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newOpenAIStub(t *testing.T, content string, status int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("expected /chat/completions, got %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("expected bearer auth, got %q", got)
+		}
+		var req openAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" {
+			t.Errorf("expected system+user messages, got %+v", req.Messages)
+		}
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": content}},
+			},
+			"usage": map[string]int{"prompt_tokens": 1000, "completion_tokens": 1000},
+		})
+	}))
+}
+
+func TestOpenAIProvider_Evaluate(t *testing.T) {
+	server := newOpenAIStub(t, `{"score": 80, "confidence": 0.9, "reasoning": "close"}`, http.StatusOK)
+	defer server.Close()
+
+	provider := NewOpenAIProvider(JudgeConfig{
+		Name: "gpt", BaseURL: server.URL, Model: "gpt-test", APIKey: "sk-test",
+		InputCostPer1K: 0.01, OutputCostPer1K: 0.03,
+	})
+
+	resp, err := provider.Evaluate(context.Background(), EvaluationRequest{Prompt: "2+2?", Solution: "4", Response: "4", Type: "objective"})
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Judge != "gpt" {
+		t.Fatalf("unexpected results: %+v", resp.Results)
+	}
+	if resp.ConsensusScore != 80 {
+		t.Errorf("expected consensus 80, got %d", resp.ConsensusScore)
+	}
+	if resp.TotalCostUSD < 0.0399 || resp.TotalCostUSD > 0.0401 {
+		t.Errorf("expected cost 0.04, got %f", resp.TotalCostUSD)
+	}
+}
+
+func TestOpenAIProvider_HTTPError(t *testing.T) {
+	server := newOpenAIStub(t, "", http.StatusTooManyRequests)
+	defer server.Close()
+
+	provider := NewOpenAIProvider(JudgeConfig{Name: "gpt", BaseURL: server.URL, APIKey: "sk-test"})
+	if _, err := provider.Evaluate(context.Background(), EvaluationRequest{Prompt: "p", Response: "r"}); err == nil {
+		t.Fatal("expected error for non-200 status")
+	}
+}
+
+func TestAnthropicProvider_Evaluate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			t.Errorf("expected /messages, got %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "ak-test" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("missing anthropic headers: %v", r.Header)
+		}
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.MaxTokens != defaultJudgeMaxTokens {
+			t.Errorf("expected default max tokens, got %d", req.MaxTokens)
+		}
+		if req.Temperature == nil || *req.Temperature != 0 {
+			t.Errorf("expected an explicit zero temperature to be sent, got %v", req.Temperature)
+		}
+		if !strings.Contains(req.Messages[0].Content, "creative") {
+			t.Errorf("expected creative judge prompt, got %q", req.Messages[0].Content)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]string{
+				{"type": "thinking", "text": "ignored"},
+				{"type": "text", "text": "```json\n{\"score\": 57, \"confidence\": 0.6, \"reasoning\": \"fine\"}\n```"},
+			},
+			"usage": map[string]int{"input_tokens": 10, "output_tokens": 10},
+		})
+	}))
+	defer server.Close()
+
+	zero := 0.0
+	provider := NewAnthropicProvider(JudgeConfig{Name: "claude", BaseURL: server.URL, APIKey: "ak-test", Temperature: &zero})
+	resp, err := provider.Evaluate(context.Background(), EvaluationRequest{Prompt: "Write a poem", Response: "roses", Type: "creative"})
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if resp.Results[0].Score != 57 || resp.Results[0].Judge != "claude" {
+		t.Errorf("unexpected result: %+v", resp.Results[0])
+	}
+}
+
+func TestNewJudgeProvider(t *testing.T) {
+	if p, err := NewJudgeProvider(JudgeConfig{Name: "a", Provider: "openai"}); err != nil || p.Name() != "a" {
+		t.Errorf("expected openai provider, got %v, %v", p, err)
+	}
+	if _, err := NewJudgeProvider(JudgeConfig{Name: "b", Provider: "anthropic"}); err != nil {
+		t.Errorf("expected anthropic provider, got %v", err)
+	}
+	if p, err := NewJudgeProvider(JudgeConfig{Name: "gemini", Provider: "gemini"}); err != nil || p.(*OpenAIProvider).config.BaseURL != DefaultGeminiBaseURL {
+		t.Errorf("expected gemini through its OpenAI-compatible endpoint, got %v, %v", p, err)
+	}
+	if _, err := NewJudgeProvider(JudgeConfig{Name: "c", Provider: "bogus"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+func TestParseJudgeOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantScore int
+		wantConf  float64
+		wantErr   bool
+	}{
+		{"plain", `{"score": 60, "confidence": 0.5, "reasoning": "ok"}`, 60, 0.5, false},
+		{"wrapped", "Verdict:\n{\"score\": 101, \"confidence\": 2, \"reasoning\": \"x\"}\nthanks", 100, 1, false},
+		{"negative", `{"score": -5, "confidence": -1, "reasoning": "x"}`, 0, 0, false},
+		{"no json", "no verdict", 0, 0, true},
+		{"bad json", "{score: }", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJudgeOutput(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wantErr=%v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Score != tt.wantScore || got.Confidence != tt.wantConf {
+				t.Errorf("got score=%d confidence=%f", got.Score, got.Confidence)
+			}
+		})
+	}
+}
+
+func TestRenderJudgePrompt(t *testing.T) {
+	objective, err := RenderJudgePrompt(EvaluationRequest{Prompt: "P?", Solution: "S!", Response: "R.", Type: "objective"})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	for _, want := range []string{"P?", "S!", "R.", "EXPECTED SOLUTION", `"score": <integer 0-100>`} {
+		if !strings.Contains(objective, want) {
+			t.Errorf("objective prompt missing %q", want)
+		}
+	}
+
+	creative, err := RenderJudgePrompt(EvaluationRequest{Prompt: "P?", Response: "R.", Type: "creative"})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if strings.Contains(creative, "EXPECTED SOLUTION") {
+		t.Error("creative prompt should not include the solution section")
+	}
+}
+
+var errTest = errors.New("provider down")
+
+// stubProvider is a JudgeProvider returning canned verdicts
+type stubProvider struct {
+	name   string
+	resp   *EvaluationResponse
+	err    error
+	judges []string
+}
+
+func (s *stubProvider) Name() string { return s.name }
+
+func (s *stubProvider) Evaluate(_ context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	s.judges = req.Judges
+	return s.resp, s.err
+}
+
+func TestRunJudges_MergesProviders(t *testing.T) {
+	e := &Evaluator{litellmClient: NewLiteLLMClient("http://unused"), judges: []string{"a", "b"}}
+	e.SetJudgeProviders(
+		&stubProvider{name: "a", resp: &EvaluationResponse{Results: []JudgeResult{{Judge: "a", Score: 100, Confidence: 1, CostUSD: 0.1}}, TotalCostUSD: 0.1}},
+		&stubProvider{name: "b", resp: &EvaluationResponse{Results: []JudgeResult{{Judge: "b", Score: 0, Confidence: 1, CostUSD: 0.2}}, TotalCostUSD: 0.2}},
+		&stubProvider{name: "c", err: errTest},
+	)
+
+	resp, err := e.runJudges(context.Background(), EvaluationRequest{Judges: e.judges})
+	if err != nil {
+		t.Fatalf("runJudges failed: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(resp.Results))
+	}
+	if resp.ConsensusScore != 50 {
+		t.Errorf("expected consensus 50, got %d", resp.ConsensusScore)
+	}
+	if resp.TotalCostUSD < 0.2999 || resp.TotalCostUSD > 0.3001 {
+		t.Errorf("expected total cost 0.3, got %f", resp.TotalCostUSD)
+	}
+}
+
+func TestRunJudges_AllFail(t *testing.T) {
+	e := &Evaluator{litellmClient: NewLiteLLMClient("http://unused")}
+	e.SetJudgeProviders(&stubProvider{name: "a", err: errTest})
+
+	if _, err := e.runJudges(context.Background(), EvaluationRequest{}); err != errTest {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+}
+
+func TestRunJudges_HybridFiltersPythonJudges(t *testing.T) {
+	var received EvaluationRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_ = json.NewEncoder(w).Encode(EvaluationResponse{
+			Results:        []JudgeResult{{Judge: "gemini", Score: 60, Confidence: 1}},
+			ConsensusScore: 60,
+		})
+	}))
+	defer server.Close()
+
+	native := &stubProvider{name: "gpt", resp: &EvaluationResponse{Results: []JudgeResult{{Judge: "gpt", Score: 80, Confidence: 1}}}}
+	e := &Evaluator{litellmClient: NewLiteLLMClient("http://unused"), judges: []string{"gpt", "gemini"}}
+	e.SetJudgeProviders(native, NewLiteLLMClient(server.URL))
+
+	resp, err := e.runJudges(context.Background(), EvaluationRequest{Judges: e.judges})
+	if err != nil {
+		t.Fatalf("runJudges failed: %v", err)
+	}
+	if len(received.Judges) != 1 || received.Judges[0] != "gemini" {
+		t.Errorf("expected python service to receive only gemini, got %v", received.Judges)
+	}
+	if resp.ConsensusScore != 70 {
+		t.Errorf("expected consensus 70, got %d", resp.ConsensusScore)
+	}
+}
+
+func TestJudgeProviders_DefaultsToPythonService(t *testing.T) {
+	e := &Evaluator{litellmClient: NewLiteLLMClient("http://unused")}
+	providers := e.judgeProviders()
+	if len(providers) != 1 || providers[0].Name() != "python" {
+		t.Fatalf("expected python provider, got %v", providers)
+	}
+}
@@ -2,10 +2,12 @@ package evaluator
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -13,8 +15,16 @@ import (
 type LiteLLMClient struct {
 	baseURL    string
 	httpClient *http.Client
+
+	versionMu      sync.Mutex
+	promptVersion  string
+	versionChecked time.Time
 }
 
+// promptVersionTTL is how long the service's prompt version is trusted before it is
+// asked again, which picks up a restarted service with edited templates
+const promptVersionTTL = time.Minute
+
 // NewLiteLLMClient creates a new LiteLLM client
 func NewLiteLLMClient(baseURL string) *LiteLLMClient {
 	return &LiteLLMClient{
@@ -25,23 +35,23 @@ func NewLiteLLMClient(baseURL string) *LiteLLMClient {
 	}
 }
 
+// Name identifies the Python service as a judge provider
+func (c *LiteLLMClient) Name() string {
+	return "python"
+}
+
 // Evaluate sends an evaluation request to the Python service
-func (c *LiteLLMClient) Evaluate(req EvaluationRequest) (*EvaluationResponse, error) {
+func (c *LiteLLMClient) Evaluate(ctx context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
 	jsonData, _ := json.Marshal(req)
 
-	resp, err := c.httpClient.Post(
-		c.baseURL+"/evaluate",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	resp, err := c.post(ctx, "/evaluate", jsonData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("evaluation failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newStatusError("evaluation", resp)
 	}
 
 	var evalResp EvaluationResponse
@@ -53,14 +63,10 @@ func (c *LiteLLMClient) Evaluate(req EvaluationRequest) (*EvaluationResponse, er
 }
 
 // EstimateCost estimates the cost of an evaluation
-func (c *LiteLLMClient) EstimateCost(req CostEstimateRequest) (*CostEstimateResponse, error) {
+func (c *LiteLLMClient) EstimateCost(ctx context.Context, req CostEstimateRequest) (*CostEstimateResponse, error) {
 	jsonData, _ := json.Marshal(req)
 
-	resp, err := c.httpClient.Post(
-		c.baseURL+"/estimate_cost",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	resp, err := c.post(ctx, "/estimate_cost", jsonData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -80,8 +86,12 @@ func (c *LiteLLMClient) EstimateCost(req CostEstimateRequest) (*CostEstimateResp
 }
 
 // HealthCheck checks if the Python service is healthy
-func (c *LiteLLMClient) HealthCheck() error {
-	resp, err := c.httpClient.Get(c.baseURL + "/health")
+func (c *LiteLLMClient) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("health check failed: %w", err)
 	}
@@ -93,3 +103,49 @@ func (c *LiteLLMClient) HealthCheck() error {
 
 	return nil
 }
+
+// PromptVersion returns the hash of the judge prompt templates the service runs, or
+// "unknown" when it cannot be read, which no cached verdict is keyed by
+func (c *LiteLLMClient) PromptVersion(ctx context.Context) string {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	if !c.versionChecked.IsZero() && time.Since(c.versionChecked) < promptVersionTTL {
+		return c.promptVersion
+	}
+
+	c.promptVersion = "unknown"
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(checkCtx, http.MethodGet, c.baseURL+"/health", nil)
+	if err != nil {
+		return c.promptVersion
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// A cancelled job says nothing about the service, so ask again next time
+		if ctx.Err() == nil {
+			c.versionChecked = time.Now()
+		}
+		return c.promptVersion
+	}
+	defer func() { _ = resp.Body.Close() }()
+	c.versionChecked = time.Now()
+
+	var health struct {
+		PromptVersion string `json:"prompt_version"`
+	}
+	if resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&health) == nil && health.PromptVersion != "" {
+		c.promptVersion = health.PromptVersion
+	}
+	return c.promptVersion
+}
+
+// post sends a JSON body to a service endpoint; cancelling ctx aborts the request
+func (c *LiteLLMClient) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.httpClient.Do(req)
+}
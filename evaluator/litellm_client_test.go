@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -62,7 +63,7 @@ func TestEvaluate_Success(t *testing.T) {
 		Judges:   []string{"claude"},
 	}
 
-	resp, err := client.Evaluate(req)
+	resp, err := client.Evaluate(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Evaluate failed: %v", err)
 	}
@@ -90,7 +91,7 @@ func TestEvaluate_ServerError(t *testing.T) {
 		Prompt: "Test prompt",
 	}
 
-	_, err := client.Evaluate(req)
+	_, err := client.Evaluate(context.Background(), req)
 	if err == nil {
 		t.Error("expected error for server error response")
 	}
@@ -107,7 +108,7 @@ func TestEvaluate_InvalidJSON(t *testing.T) {
 		Prompt: "Test prompt",
 	}
 
-	_, err := client.Evaluate(req)
+	_, err := client.Evaluate(context.Background(), req)
 	if err == nil {
 		t.Error("expected error for invalid JSON response")
 	}
@@ -119,7 +120,7 @@ func TestEvaluate_ConnectionError(t *testing.T) {
 		Prompt: "Test prompt",
 	}
 
-	_, err := client.Evaluate(req)
+	_, err := client.Evaluate(context.Background(), req)
 	if err == nil {
 		t.Error("expected error for connection failure")
 	}
@@ -153,7 +154,7 @@ func TestEstimateCost_Success(t *testing.T) {
 		Judges:   []string{"claude", "gpt", "gemini"},
 	}
 
-	resp, err := client.EstimateCost(req)
+	resp, err := client.EstimateCost(context.Background(), req)
 	if err != nil {
 		t.Fatalf("EstimateCost failed: %v", err)
 	}
@@ -176,7 +177,7 @@ func TestEstimateCost_ServerError(t *testing.T) {
 	client := NewLiteLLMClient(server.URL)
 	req := CostEstimateRequest{}
 
-	_, err := client.EstimateCost(req)
+	_, err := client.EstimateCost(context.Background(), req)
 	if err == nil {
 		t.Error("expected error for server error response")
 	}
@@ -193,7 +194,7 @@ func TestEstimateCost_InvalidJSON(t *testing.T) {
 		Prompt: "Test prompt",
 	}
 
-	_, err := client.EstimateCost(req)
+	_, err := client.EstimateCost(context.Background(), req)
 	if err == nil {
 		t.Error("expected error for invalid JSON response")
 	}
@@ -205,7 +206,7 @@ func TestEstimateCost_ConnectionError(t *testing.T) {
 		Prompt: "Test prompt",
 	}
 
-	_, err := client.EstimateCost(req)
+	_, err := client.EstimateCost(context.Background(), req)
 	if err == nil {
 		t.Error("expected error for connection failure")
 	}
@@ -225,7 +226,7 @@ func TestHealthCheck_Success(t *testing.T) {
 	defer server.Close()
 
 	client := NewLiteLLMClient(server.URL)
-	err := client.HealthCheck()
+	err := client.HealthCheck(context.Background())
 	if err != nil {
 		t.Fatalf("HealthCheck failed: %v", err)
 	}
@@ -238,7 +239,7 @@ func TestHealthCheck_Unhealthy(t *testing.T) {
 	defer server.Close()
 
 	client := NewLiteLLMClient(server.URL)
-	err := client.HealthCheck()
+	err := client.HealthCheck(context.Background())
 	if err == nil {
 		t.Error("expected error for unhealthy service")
 	}
@@ -246,7 +247,7 @@ func TestHealthCheck_Unhealthy(t *testing.T) {
 
 func TestHealthCheck_ConnectionError(t *testing.T) {
 	client := NewLiteLLMClient("http://localhost:99999")
-	err := client.HealthCheck()
+	err := client.HealthCheck(context.Background())
 	if err == nil {
 		t.Error("expected error for connection failure")
 	}
@@ -276,7 +277,7 @@ func TestEvaluate_MultipleJudges(t *testing.T) {
 		APIKeys:  map[string]string{"anthropic": "sk-ant", "openai": "sk-oai"},
 	}
 
-	resp, err := client.Evaluate(req)
+	resp, err := client.Evaluate(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Evaluate failed: %v", err)
 	}
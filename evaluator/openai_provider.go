@@ -0,0 +1,119 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider judges responses through an OpenAI-compatible chat completions API
+type OpenAIProvider struct {
+	config     JudgeConfig
+	httpClient *http.Client
+}
+
+// NewOpenAIProvider creates a provider for an OpenAI-compatible endpoint
+func NewOpenAIProvider(cfg JudgeConfig) *OpenAIProvider {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultOpenAIBaseURL
+	}
+	return &OpenAIProvider{
+		config: cfg,
+		httpClient: &http.Client{
+			Timeout: 180 * time.Second,
+		},
+	}
+}
+
+// chatMessage is a single message in a chat completion request
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAIChatRequest is the body of a /chat/completions call
+type openAIChatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
+}
+
+// openAIChatResponse is the subset of a chat completion response we use
+type openAIChatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Name returns the judge name
+func (p *OpenAIProvider) Name() string {
+	return p.config.Name
+}
+
+// Evaluate grades a response with this judge
+func (p *OpenAIProvider) Evaluate(ctx context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	return evaluateWithCompleter(ctx, p.config, p, req)
+}
+
+// complete sends a system+user conversation to the chat completions endpoint
+func (p *OpenAIProvider) complete(ctx context.Context, system, user string) (*completion, error) {
+	body := openAIChatRequest{
+		Model: p.config.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: user},
+		},
+		MaxTokens:   p.config.MaxTokens,
+		Temperature: p.config.Temperature,
+	}
+	return postChatCompletion(ctx, p.httpClient, p.config.BaseURL, p.config.APIKey, body)
+}
+
+// postChatCompletion sends a chat completion request to an OpenAI-compatible endpoint
+func postChatCompletion(ctx context.Context, client *http.Client, baseURL, apiKey string, body openAIChatRequest) (*completion, error) {
+	jsonData, _ := json.Marshal(body)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(baseURL, "/")+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError("chat completion", resp)
+	}
+
+	var chatResp openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("chat completion returned no choices")
+	}
+
+	return &completion{
+		Text:         chatResp.Choices[0].Message.Content,
+		InputTokens:  chatResp.Usage.PromptTokens,
+		OutputTokens: chatResp.Usage.CompletionTokens,
+	}, nil
+}
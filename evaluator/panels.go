@@ -0,0 +1,210 @@
+package evaluator
+
+import (
+	"database/sql"
+	"fmt"
+	"llm-tournament/middleware"
+	"log"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// judgeSet is who grades a pair: the judge names sent with the request, the providers
+// that run them, and each judge's cache identity and consensus weight
+type judgeSet struct {
+	panel     string // "" for the built-in judges
+	judges    []string
+	providers []JudgeProvider
+	identity  map[string]string  // Judges missing here are identified by name
+	weights   map[string]float64 // Judges missing here weigh 1
+}
+
+// cacheIdentity is what the evaluation cache keys a judge's verdicts by
+func (s judgeSet) cacheIdentity(judge string) string {
+	if id, ok := s.identity[judge]; ok {
+		return id
+	}
+	return judge
+}
+
+// Default per-1K token rates of natively-called panel judges
+var panelJudgeRates = map[string][2]float64{
+	middleware.JudgeProviderOpenAI:    {0.010, 0.030},
+	middleware.JudgeProviderAnthropic: {0.015, 0.075},
+	middleware.JudgeProviderGemini:    {0.002, 0.012},
+}
+
+// newJudgeProvider builds the provider of a panel's native judge (replaced in tests)
+var newJudgeProvider = NewJudgeProvider
+
+// APIKeyLookup returns the decrypted API key stored for a provider
+type APIKeyLookup func(provider string) (string, error)
+
+// apiKeys holds the lookup used for panel judges called natively
+type apiKeys struct {
+	mu     sync.RWMutex
+	lookup APIKeyLookup
+}
+
+// SetAPIKeyLookup sets how the API keys of natively-called panel judges are found
+func (e *Evaluator) SetAPIKeyLookup(lookup APIKeyLookup) {
+	e.keys.mu.Lock()
+	defer e.keys.mu.Unlock()
+	e.keys.lookup = lookup
+}
+
+func (e *Evaluator) apiKey(provider string) (string, error) {
+	e.keys.mu.RLock()
+	lookup := e.keys.lookup
+	e.keys.mu.RUnlock()
+	if lookup == nil {
+		return "", fmt.Errorf("no API key source configured")
+	}
+	key, err := lookup(provider)
+	if err == nil && key == "" {
+		err = fmt.Errorf("no %s API key stored", provider)
+	}
+	return key, err
+}
+
+// judgesForPrompt returns the judges that grade a prompt: the panel of the prompt's
+// profile, else the suite's panel, else the default panel. Without any of them the
+// built-in judge list and the configured providers are used.
+func (e *Evaluator) judgesForPrompt(suiteID, promptID int) judgeSet {
+	var candidates []string
+	var profilePanel string
+	err := e.db.QueryRow(`
+		SELECT pj.panel_name
+		FROM prompts p
+		JOIN profiles pr ON pr.id = p.profile_id
+		JOIN profile_judge_panels pj ON pj.suite_id = p.suite_id AND pj.profile_name = pr.name
+		WHERE p.id = ?
+	`, promptID).Scan(&profilePanel)
+	if err == nil {
+		candidates = append(candidates, profilePanel)
+	}
+	candidates = append(candidates, e.suiteSetting(suiteID, middleware.SuiteSettingJudgePanel), middleware.DefaultJudgePanel)
+
+	for _, name := range candidates {
+		if panel := e.loadPanel(name); panel != nil {
+			return e.panelJudges(panel)
+		}
+	}
+	return judgeSet{judges: e.judges, providers: e.judgeProviders()}
+}
+
+// suitePanel names the panel that judges a suite's prompts without a profile
+// override, or "" when the built-in judges do
+func (e *Evaluator) suitePanel(suiteID int) string {
+	for _, name := range []string{e.suiteSetting(suiteID, middleware.SuiteSettingJudgePanel), middleware.DefaultJudgePanel} {
+		if e.loadPanel(name) != nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// loadPanel reads a panel and its judges; nil when it does not exist or is empty
+func (e *Evaluator) loadPanel(name string) *middleware.JudgePanel {
+	if name == "" {
+		return nil
+	}
+	rows, err := e.db.Query(`
+		SELECT j.name, j.provider, j.model, j.base_url, j.temperature, j.max_tokens, j.weight
+		FROM judge_panels p
+		JOIN judge_panel_members m ON m.panel_id = p.id
+		JOIN judges j ON j.id = m.judge_id
+		WHERE p.name = ?
+		ORDER BY j.name
+	`, name)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = rows.Close() }()
+
+	panel := &middleware.JudgePanel{Name: name}
+	for rows.Next() {
+		var j middleware.Judge
+		var temperature sql.NullFloat64
+		if err := rows.Scan(&j.Name, &j.Provider, &j.Model, &j.BaseURL, &temperature, &j.MaxTokens, &j.Weight); err != nil {
+			log.Printf("Failed to read judge panel %s: %v", name, err)
+			return nil
+		}
+		if temperature.Valid {
+			j.Temperature = &temperature.Float64
+		}
+		panel.Judges = append(panel.Judges, j)
+	}
+	if rows.Err() != nil || len(panel.Judges) == 0 {
+		return nil
+	}
+	return panel
+}
+
+// panelJudges turns a panel into the providers that run it. Backend judges go to the
+// configured providers by name; native judges get a provider of their own, and a
+// judge whose provider cannot be built is left out.
+func (e *Evaluator) panelJudges(panel *middleware.JudgePanel) judgeSet {
+	set := judgeSet{
+		panel:    panel.Name,
+		identity: make(map[string]string),
+		weights:  make(map[string]float64),
+	}
+	var backend []string
+	for _, j := range panel.Judges {
+		set.judges = append(set.judges, j.Name)
+		set.weights[j.Name] = j.Weight
+		if j.Provider == middleware.JudgeProviderBackend {
+			backend = append(backend, j.Name)
+			continue
+		}
+
+		// Verdicts depend on the model and sampling, not just the judge's name
+		temperature := "default"
+		if j.Temperature != nil {
+			temperature = strconv.FormatFloat(*j.Temperature, 'g', -1, 64)
+		}
+		set.identity[j.Name] = fmt.Sprintf("%s|%s|%s|%s|%s|%d", j.Name, j.Provider, j.Model, j.BaseURL, temperature, j.MaxTokens)
+		provider, err := e.panelProvider(j)
+		if err != nil {
+			log.Printf("Skipping judge %s of panel %s: %v", j.Name, panel.Name, err)
+			continue
+		}
+		set.providers = append(set.providers, provider)
+	}
+
+	if len(backend) > 0 {
+		for _, p := range e.judgeProviders() {
+			if _, ok := p.(*LiteLLMClient); ok || slices.Contains(backend, p.Name()) {
+				set.providers = append(set.providers, p)
+			}
+		}
+	}
+	return set
+}
+
+// panelProvider builds the native provider of a panel judge
+func (e *Evaluator) panelProvider(j middleware.Judge) (JudgeProvider, error) {
+	// The settings page stores the Gemini key as "google"
+	keyName := j.Provider
+	if j.Provider == middleware.JudgeProviderGemini {
+		keyName = "google"
+	}
+	key, err := e.apiKey(keyName)
+	if err != nil {
+		return nil, err
+	}
+	rates := panelJudgeRates[j.Provider]
+	return newJudgeProvider(JudgeConfig{
+		Name:            j.Name,
+		Provider:        j.Provider,
+		BaseURL:         j.BaseURL,
+		Model:           j.Model,
+		APIKey:          key,
+		MaxTokens:       j.MaxTokens,
+		Temperature:     j.Temperature,
+		InputCostPer1K:  rates[0],
+		OutputCostPer1K: rates[1],
+	})
+}
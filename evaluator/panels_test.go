@@ -0,0 +1,245 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"llm-tournament/middleware"
+	"testing"
+)
+
+// seedPanels defines a judge per panel: "default" holds a, "suite" holds b and
+// "math" holds c, and prompt p1 belongs to the Math profile
+func seedPanels(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec(`
+		INSERT INTO judges (name) VALUES ('a'), ('b'), ('c');
+		INSERT INTO judge_panels (name) VALUES ('default'), ('suite'), ('math');
+		INSERT INTO judge_panel_members (panel_id, judge_id)
+		SELECT p.id, j.id FROM judge_panels p, judges j
+		WHERE (p.name, j.name) IN (VALUES ('default', 'a'), ('suite', 'b'), ('math', 'c'));
+		INSERT INTO profiles (name, suite_id) VALUES ('Math', 1);
+		INSERT INTO profile_judge_panels (suite_id, profile_name, panel_name) VALUES (1, 'Math', 'math');
+		UPDATE prompts SET profile_id = (SELECT id FROM profiles WHERE name = 'Math') WHERE text = 'p1';
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed panels: %v", err)
+	}
+}
+
+func TestJudgesForPrompt_Resolution(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+
+	var p1, p2 int
+	_ = db.QueryRow("SELECT id FROM prompts WHERE text = 'p1'").Scan(&p1)
+	_ = db.QueryRow("SELECT id FROM prompts WHERE text = 'p2'").Scan(&p2)
+
+	e := newGeneratorTestEvaluator(db)
+	if set := e.judgesForPrompt(1, p1); set.panel != "" || len(set.providers) != 1 || set.providers[0] != e.litellmClient {
+		t.Errorf("expected the built-in judges without panels, got %+v", set)
+	}
+
+	seedPanels(t, db)
+	tests := []struct {
+		promptID int
+		panel    string
+		judge    string
+	}{
+		{p1, "math", "c"},
+		{p2, "default", "a"},
+	}
+	for _, tt := range tests {
+		set := e.judgesForPrompt(1, tt.promptID)
+		if set.panel != tt.panel || len(set.judges) != 1 || set.judges[0] != tt.judge {
+			t.Errorf("prompt %d: expected panel %s judged by %s, got %+v", tt.promptID, tt.panel, tt.judge, set)
+		}
+	}
+
+	if _, err := db.Exec("INSERT INTO suite_settings (suite_id, key, value) VALUES (1, ?, 'suite')", middleware.SuiteSettingJudgePanel); err != nil {
+		t.Fatalf("failed to set suite panel: %v", err)
+	}
+	if set := e.judgesForPrompt(1, p2); set.panel != "suite" {
+		t.Errorf("expected the suite panel once assigned, got %+v", set)
+	}
+	if set := e.judgesForPrompt(1, p1); set.panel != "math" {
+		t.Errorf("expected the profile panel to win over the suite panel, got %+v", set)
+	}
+	if panel := e.suitePanel(1); panel != "suite" {
+		t.Errorf("expected the job to record the suite panel, got %q", panel)
+	}
+
+	// An empty panel is skipped
+	if _, err := db.Exec("DELETE FROM judge_panel_members WHERE panel_id = (SELECT id FROM judge_panels WHERE name = 'math')"); err != nil {
+		t.Fatalf("failed to empty panel: %v", err)
+	}
+	if set := e.judgesForPrompt(1, p1); set.panel != "suite" {
+		t.Errorf("expected an empty profile panel to fall back to the suite panel, got %+v", set)
+	}
+}
+
+// panelJudge is a native judge built from a panel's config that scores by judge name
+type panelJudge struct {
+	cfg JudgeConfig
+}
+
+func (p *panelJudge) Name() string { return p.cfg.Name }
+
+func (p *panelJudge) Evaluate(_ context.Context, _ EvaluationRequest) (*EvaluationResponse, error) {
+	score := map[string]int{"strict": 0, "lenient": 100}[p.cfg.Name]
+	return &EvaluationResponse{
+		Results:      []JudgeResult{{Judge: p.cfg.Name, Score: score, Confidence: 0.9, CostUSD: 0.01}},
+		TotalCostUSD: 0.01,
+	}, nil
+}
+
+func TestEvaluateAll_NativePanelJudgesWithWeights(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+
+	_, err := db.Exec(`
+		INSERT INTO judges (name, provider, model, temperature, weight) VALUES
+			('strict', 'anthropic', 'claude-haiku', 0, 1),
+			('lenient', 'openai', 'gpt-5-mini', 0.7, 3),
+			('keyless', 'openai', 'gpt-5', 0, 1);
+		INSERT INTO judge_panels (name) VALUES ('default');
+		INSERT INTO judge_panel_members (panel_id, judge_id) SELECT 1, id FROM judges;
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed panel: %v", err)
+	}
+
+	var built []JudgeConfig
+	original := newJudgeProvider
+	newJudgeProvider = func(cfg JudgeConfig) (JudgeProvider, error) {
+		built = append(built, cfg)
+		return &panelJudge{cfg: cfg}, nil
+	}
+	defer func() { newJudgeProvider = original }()
+
+	e := newGeneratorTestEvaluator(db)
+	e.SetAPIKeyLookup(func(provider string) (string, error) {
+		return map[string]string{"anthropic": "sk-ant"}[provider], nil
+	})
+	// Only the anthropic key is stored, so the openai judges are skipped until it is
+	if _, err := e.EvaluateAll(1, false); err != nil {
+		t.Fatalf("EvaluateAll failed: %v", err)
+	}
+	runQueuedJob(t, e)
+	var score int
+	if err := db.QueryRow("SELECT score FROM scores LIMIT 1").Scan(&score); err != nil || score != 0 {
+		t.Errorf("expected the strict judge alone to score 0, got %d (%v)", score, err)
+	}
+
+	e.SetAPIKeyLookup(func(provider string) (string, error) {
+		return "sk-" + provider, nil
+	})
+	built = nil
+	if _, err := e.EvaluateAll(1, false); err != nil {
+		t.Fatalf("EvaluateAll failed: %v", err)
+	}
+	job := runQueuedJob(t, e)
+
+	if len(built) == 0 || built[0].Model == "" || built[0].APIKey == "" {
+		t.Fatalf("expected panel judges built with their model and key, got %+v", built)
+	}
+	for _, cfg := range built {
+		if cfg.Name == "lenient" && (cfg.Temperature == nil || *cfg.Temperature != 0.7 || cfg.InputCostPer1K == 0) {
+			t.Errorf("expected the lenient judge's temperature and rates, got %+v", cfg)
+		}
+	}
+
+	// strict and keyless (0, weight 1 each) and lenient (100, weight 3) average to
+	// 60; unweighted they would give 40
+	if err := db.QueryRow("SELECT score FROM scores LIMIT 1").Scan(&score); err != nil || score != 60 {
+		t.Errorf("expected the weighted panel to score 60, got %d (%v)", score, err)
+	}
+
+	var panel string
+	if err := db.QueryRow("SELECT judge_panel FROM evaluation_results WHERE job_id = ? LIMIT 1", job.ID).Scan(&panel); err != nil || panel != "default" {
+		t.Errorf("expected results to record the default panel, got %q (%v)", panel, err)
+	}
+	status, err := e.GetJobStatus(job.ID)
+	if err != nil || status.JudgePanel != "default" {
+		t.Errorf("expected the job to record the default panel, got %+v (%v)", status, err)
+	}
+
+	// Retuning a judge changes its cache identity, so its verdicts are not reused
+	set := e.judgesForPrompt(1, 1)
+	before := set.cacheIdentity("lenient")
+	if _, err := db.Exec("UPDATE judges SET temperature = 0.2 WHERE name = 'lenient'"); err != nil {
+		t.Fatalf("failed to retune judge: %v", err)
+	}
+	if after := e.judgesForPrompt(1, 1).cacheIdentity("lenient"); after == before {
+		t.Errorf("expected a new cache identity after retuning, got %s", after)
+	}
+	if id := set.cacheIdentity("unknown"); id != "unknown" {
+		t.Errorf("expected judges outside the panel to be identified by name, got %s", id)
+	}
+}
+
+func TestPanelJudges_BackendJudgesUseConfiguredProviders(t *testing.T) {
+	e := &Evaluator{litellmClient: NewLiteLLMClient("http://unused")}
+	litellm := NewLiteLLMClient("http://python")
+	gpt := &stubProvider{name: "gpt_5.2"}
+	e.SetJudgeProviders(gpt, &stubProvider{name: "claude_opus_4.5"}, litellm)
+
+	set := e.panelJudges(&middleware.JudgePanel{Name: "p", Judges: []middleware.Judge{
+		{Name: "gpt_5.2", Provider: middleware.JudgeProviderBackend, Weight: 2},
+		{Name: "gemini_3_pro", Provider: middleware.JudgeProviderBackend, Weight: 1},
+	}})
+
+	names := fmt.Sprint(set.judges)
+	if names != "[gpt_5.2 gemini_3_pro]" || len(set.providers) != 2 || set.providers[0] != gpt || set.providers[1] != litellm {
+		t.Errorf("expected the gpt provider and the Python service for %s, got %+v", names, set.providers)
+	}
+	if set.weights["gpt_5.2"] != 2 || set.cacheIdentity("gpt_5.2") != "gpt_5.2" {
+		t.Errorf("expected backend judges weighted and identified by name, got %+v", set)
+	}
+}
+
+func TestConsensus_JudgeWeights(t *testing.T) {
+	results := []JudgeResult{
+		{Judge: "a", Score: 100, Confidence: 1, Weight: 1},
+		{Judge: "b", Score: 0, Confidence: 1, Weight: 1},
+		{Judge: "c", Score: 0, Confidence: 1, Weight: 1},
+	}
+	if got := majorityVote(results); got != 0 {
+		t.Errorf("expected two votes for 0 to win, got %d", got)
+	}
+	results[0].Weight = 3
+	if got := majorityVote(results); got != 100 {
+		t.Errorf("expected a weight of 3 to outvote two judges, got %d", got)
+	}
+	if got := weightedMean(results); got != 60 {
+		t.Errorf("expected a weighted mean of 60, got %d", got)
+	}
+	// A zero weight counts as one
+	results[0].Weight = 0
+	if got := weightedMean(results); got != 33 {
+		t.Errorf("expected an unweighted mean of 33, got %d", got)
+	}
+}
+
+func TestPanelProvider_Gemini(t *testing.T) {
+	var built JudgeConfig
+	original := newJudgeProvider
+	newJudgeProvider = func(cfg JudgeConfig) (JudgeProvider, error) {
+		built = cfg
+		return &stubProvider{name: cfg.Name}, nil
+	}
+	defer func() { newJudgeProvider = original }()
+
+	e := &Evaluator{}
+	e.SetAPIKeyLookup(func(provider string) (string, error) {
+		return map[string]string{"google": "gk-test"}[provider], nil
+	})
+	if _, err := e.panelProvider(middleware.Judge{Name: "flash", Provider: middleware.JudgeProviderGemini, Model: "gemini-3-flash"}); err != nil {
+		t.Fatalf("panelProvider failed: %v", err)
+	}
+	if built.APIKey != "gk-test" || built.InputCostPer1K == 0 || built.Temperature != nil {
+		t.Errorf("expected the google key, gemini rates and the default temperature, got %+v", built)
+	}
+}
@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"context"
 	"io"
 	"log"
 	"strings"
@@ -18,7 +19,7 @@ func TestProcessModelJob_QueryPromptsError_ReturnsError(t *testing.T) {
 	e := &Evaluator{db: db}
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "model", TargetID: 1, ProgressTotal: 0}
 
-	err := e.processModelJob(job, make(chan bool))
+	err := e.processModelJob(context.Background(), job)
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -38,7 +39,7 @@ func TestProcessPromptJob_QueryModelsError_ReturnsError(t *testing.T) {
 	e := &Evaluator{db: db}
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "prompt", TargetID: 1, ProgressTotal: 0}
 
-	err := e.processPromptJob(job, make(chan bool))
+	err := e.processPromptJob(context.Background(), job)
 	if err == nil {
 		t.Fatalf("expected error")
 	}
@@ -75,7 +76,7 @@ func TestProcessModelJob_EvaluateAndProgressErrors_Continue(t *testing.T) {
 
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "model", TargetID: 1, ProgressTotal: 1}
 
-	if err := e.processModelJob(job, make(chan bool)); err != nil {
+	if err := e.processModelJob(context.Background(), job); err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 }
@@ -111,7 +112,7 @@ func TestProcessPromptJob_EvaluateAndProgressErrors_Continue(t *testing.T) {
 
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "prompt", TargetID: 1, ProgressTotal: 1}
 
-	if err := e.processPromptJob(job, make(chan bool)); err != nil {
+	if err := e.processPromptJob(context.Background(), job); err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 }
@@ -1,6 +1,9 @@
 package evaluator
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestProcessModelJob_PromptScanError_ReturnsError(t *testing.T) {
 	db := setupEvaluatorTestDB(t)
@@ -22,7 +25,7 @@ func TestProcessModelJob_PromptScanError_ReturnsError(t *testing.T) {
 	e := &Evaluator{db: db}
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "model", TargetID: 1}
 
-	if err := e.processModelJob(job, make(chan bool)); err == nil {
+	if err := e.processModelJob(context.Background(), job); err == nil {
 		t.Fatalf("expected scan error")
 	}
 }
@@ -47,7 +50,7 @@ func TestProcessPromptJob_ModelScanError_ReturnsError(t *testing.T) {
 	e := &Evaluator{db: db}
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "prompt", TargetID: 1}
 
-	if err := e.processPromptJob(job, make(chan bool)); err == nil {
+	if err := e.processPromptJob(context.Background(), job); err == nil {
 		t.Fatalf("expected scan error")
 	}
 }
@@ -72,7 +75,7 @@ func TestProcessAllJob_ModelScanError_ReturnsError(t *testing.T) {
 	e := &Evaluator{db: db}
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 0}
 
-	if err := e.processAllJob(job, make(chan bool)); err == nil {
+	if err := e.processAllJob(context.Background(), job); err == nil {
 		t.Fatalf("expected scan error")
 	}
 }
@@ -101,7 +104,7 @@ func TestProcessAllJob_PromptScanError_ReturnsError(t *testing.T) {
 	e := &Evaluator{db: db}
 	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 0}
 
-	if err := e.processAllJob(job, make(chan bool)); err == nil {
+	if err := e.processAllJob(context.Background(), job); err == nil {
 		t.Fatalf("expected scan error")
 	}
 }
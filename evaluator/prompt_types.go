@@ -0,0 +1,60 @@
+package evaluator
+
+import (
+	"fmt"
+	"llm-tournament/middleware"
+)
+
+// Judge modes select the judge prompt responses are graded with
+const (
+	JudgeModeObjective = "objective" // Compared with the solution
+	JudgeModeCreative  = "creative"  // Rated on quality; the solution is only a reference
+)
+
+// PromptType is a kind of prompt and the strategy its responses are evaluated with
+type PromptType struct {
+	Name        string
+	Label       string
+	Description string
+	JudgeMode   string // Judge prompt used when the judges score the prompt
+	Checker     string // Checker tried first when the prompt has none of its own ("" = judges)
+}
+
+// promptTypes lists the prompt types in the order the prompt forms show them.
+// Hidden tests and a prompt's own checker always take precedence over the type.
+var promptTypes = []PromptType{
+	{"objective", "Objective", "One correct answer; judges compare the response with the solution", JudgeModeObjective, ""},
+	{"creative", "Creative", "Judges rate quality and craft; the solution is only a reference", JudgeModeCreative, ""},
+	{"multiple_choice", "Multiple choice", "The chosen option is compared with the solution (e.g. \"B\"); judges decide when no option can be read", JudgeModeObjective, "choice"},
+	{"code", "Code", "Hidden tests run the program when the prompt has them; otherwise judges review it against the solution", JudgeModeObjective, ""},
+	{"math", "Math", "The final number is compared with the solution; judges grade answers without one, such as proofs", JudgeModeObjective, "numeric"},
+	{"translation", "Translation", "Judges compare the meaning with the reference translation", JudgeModeObjective, ""},
+	{"open_ended", "Open-ended", "No single right answer; judged on quality like creative prompts", JudgeModeCreative, ""},
+}
+
+// PromptTypes returns every prompt type
+func PromptTypes() []PromptType {
+	return append([]PromptType(nil), promptTypes...)
+}
+
+// GetPromptType looks up a prompt type by name; "" is the default type
+func GetPromptType(name string) (PromptType, error) {
+	if name == "" {
+		name = middleware.DefaultPromptType
+	}
+	for _, t := range promptTypes {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return PromptType{}, fmt.Errorf("unknown prompt type: %q", name)
+}
+
+// judgeMode returns the judge mode of a prompt type. Unknown types are judged
+// objectively, as they were before types had modes.
+func judgeMode(promptType string) string {
+	if t, err := GetPromptType(promptType); err == nil {
+		return t.JudgeMode
+	}
+	return JudgeModeObjective
+}
@@ -0,0 +1,99 @@
+package evaluator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGetPromptType(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		mode    string
+		checker string
+	}{
+		{"", "objective", JudgeModeObjective, ""},
+		{"creative", "creative", JudgeModeCreative, ""},
+		{"multiple_choice", "multiple_choice", JudgeModeObjective, "choice"},
+		{"math", "math", JudgeModeObjective, "numeric"},
+		{"open_ended", "open_ended", JudgeModeCreative, ""},
+	}
+	for _, tt := range tests {
+		got, err := GetPromptType(tt.name)
+		if err != nil {
+			t.Fatalf("GetPromptType(%q) failed: %v", tt.name, err)
+		}
+		if got.Name != tt.want || got.JudgeMode != tt.mode || got.Checker != tt.checker {
+			t.Errorf("GetPromptType(%q): expected %s judged %s with checker %q, got %+v", tt.name, tt.want, tt.mode, tt.checker, got)
+		}
+	}
+
+	if _, err := GetPromptType("essay"); err == nil || !strings.Contains(err.Error(), "essay") {
+		t.Errorf("expected unknown type error, got %v", err)
+	}
+	for _, pt := range PromptTypes() {
+		if _, err := GetChecker(pt.Checker); pt.Checker != "" && err != nil {
+			t.Errorf("%s: %v", pt.Name, err)
+		}
+	}
+	if judgeMode("essay") != JudgeModeObjective || judgeTemplateName("open_ended") != "creative_judge.tmpl" {
+		t.Error("expected unknown types judged objectively and open-ended prompts creatively")
+	}
+}
+
+// typeJudge records the judge mode it was asked to grade with
+type typeJudge struct {
+	modes []string
+}
+
+func (j *typeJudge) Name() string { return "stub" }
+
+func (j *typeJudge) Evaluate(_ context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	j.modes = append(j.modes, req.Type)
+	return &EvaluationResponse{Results: []JudgeResult{{Judge: "stub", Score: 80, Confidence: 1}}}, nil
+}
+
+func TestEvaluateModelPromptPair_PromptTypeStrategy(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1)",
+		`INSERT INTO prompts (text, solution, suite_id, display_order, type) VALUES
+			('2+2?', '4', 1, 0, 'math'),
+			('Capital of France? A) Lyon B) Paris', 'B', 1, 1, 'multiple_choice'),
+			('Prove it', 'By induction', 1, 2, 'math'),
+			('Write a haiku', '', 1, 3, 'open_ended')`,
+		`INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES
+			(1, 1, 'The answer is 5.'), (1, 2, 'Answer: B'), (1, 3, 'Trivially true'), (1, 4, 'Autumn moon')`,
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	judge := &typeJudge{}
+	e.SetJudgeProviders(judge)
+
+	for promptID := 1; promptID <= 4; promptID++ {
+		if _, err := e.evaluateModelPromptPair(context.Background(), 1, 1, promptID); err != nil {
+			t.Fatalf("prompt %d: evaluateModelPromptPair failed: %v", promptID, err)
+		}
+	}
+
+	// The math and multiple-choice answers are checked locally; the proof has no
+	// number to compare, so it falls back to the judges like the haiku
+	want := map[int]int{1: 0, 2: 100, 3: 80, 4: 80}
+	for promptID, score := range want {
+		var got int
+		if err := db.QueryRow("SELECT score FROM scores WHERE prompt_id = ?", promptID).Scan(&got); err != nil || got != score {
+			t.Errorf("prompt %d: expected score %d, got %d (%v)", promptID, score, got, err)
+		}
+	}
+	if strings.Join(judge.modes, ",") != "objective,creative" {
+		t.Errorf("expected the proof judged objectively and the haiku creatively, got %v", judge.modes)
+	}
+}
@@ -0,0 +1,121 @@
+package evaluator
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit caps how fast one judge provider is called. Zero fields are unlimited.
+type RateLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// tokenBucket holds up to capacity units and refills at capacity per minute. Callers
+// may take more than is available; the debt is paid off before the next caller goes.
+type tokenBucket struct {
+	capacity  float64
+	available float64
+	last      time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{capacity: float64(perMinute), available: float64(perMinute), last: now}
+}
+
+// take removes n units and returns how long the caller must wait for them.
+// A nil bucket is unlimited.
+func (b *tokenBucket) take(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	if n > b.capacity {
+		n = b.capacity // Oversized calls would otherwise never fit
+	}
+	perSecond := b.capacity / 60
+	b.available += now.Sub(b.last).Seconds() * perSecond
+	if b.available > b.capacity {
+		b.available = b.capacity
+	}
+	b.last = now
+	b.available -= n
+	if b.available >= 0 {
+		return 0
+	}
+	return time.Duration(-b.available / perSecond * float64(time.Second))
+}
+
+// refund returns units taken by a caller that gave up waiting
+func (b *tokenBucket) refund(n float64) {
+	if b == nil {
+		return
+	}
+	if n > b.capacity {
+		n = b.capacity
+	}
+	b.available += n
+}
+
+// RateLimiter spaces out calls to one judge provider. Every running job shares the
+// provider's limiter, so the limits hold however many pairs are in flight.
+type RateLimiter struct {
+	mu       sync.Mutex
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+// NewRateLimiter returns a limiter for limit, or nil when limit is unlimited
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	if limit.RequestsPerMinute <= 0 && limit.TokensPerMinute <= 0 {
+		return nil
+	}
+	now := time.Now()
+	return &RateLimiter{
+		requests: newTokenBucket(limit.RequestsPerMinute, now),
+		tokens:   newTokenBucket(limit.TokensPerMinute, now),
+	}
+}
+
+// Wait blocks until one request of about the given number of tokens fits within the
+// limits, or ctx is cancelled. A nil limiter never waits.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.requests.take(1, now)
+	if w := l.tokens.take(float64(tokens), now); w > wait {
+		wait = w
+	}
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, wait); err != nil {
+		l.mu.Lock()
+		l.requests.refund(1)
+		l.tokens.refund(float64(tokens))
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// judgeOverheadTokens approximates the rubric, instructions and verdict around each
+// judge call, on top of the prompt and response text
+const judgeOverheadTokens = 500
+
+// estimateTokens guesses the tokens a request will use across the given number of
+// judges, at about four characters per token
+func estimateTokens(req EvaluationRequest, judges int) int {
+	if judges < 1 {
+		judges = 1
+	}
+	return ((len(req.Prompt)+len(req.Response)+len(req.Solution))/4 + judgeOverheadTokens) * judges
+}
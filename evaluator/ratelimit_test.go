@@ -0,0 +1,113 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Take(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(60, now) // One unit per second
+
+	if wait := b.take(60, now); wait != 0 {
+		t.Errorf("expected a full bucket to cover 60 units, waited %s", wait)
+	}
+	if wait := b.take(1, now); wait != time.Second {
+		t.Errorf("expected 1s wait for the next unit, got %s", wait)
+	}
+	// The debt carries over to the next caller
+	if wait := b.take(1, now); wait != 2*time.Second {
+		t.Errorf("expected 2s wait behind the first caller, got %s", wait)
+	}
+	if wait := b.take(1, now.Add(3*time.Second)); wait != 0 {
+		t.Errorf("expected the bucket to refill after 3s, waited %s", wait)
+	}
+	// Oversized calls are capped at the capacity so they can go eventually
+	if wait := b.take(1000, now.Add(63*time.Second)); wait != 0 {
+		t.Errorf("expected an oversized call on a full bucket to go at once, waited %s", wait)
+	}
+
+	var unlimited *tokenBucket
+	if wait := unlimited.take(1e9, now); wait != 0 {
+		t.Errorf("expected nil bucket never to wait, got %s", wait)
+	}
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	if NewRateLimiter(RateLimit{}) != nil {
+		t.Error("expected no limiter for an unlimited provider")
+	}
+	var unlimited *RateLimiter
+	if err := unlimited.Wait(context.Background(), 1e6); err != nil {
+		t.Errorf("expected nil limiter not to wait, got %v", err)
+	}
+
+	l := NewRateLimiter(RateLimit{RequestsPerMinute: 1, TokensPerMinute: 1000})
+	if err := l.Wait(context.Background(), 500); err != nil {
+		t.Fatalf("first call should go at once: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, 500); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected second request of the minute to wait, got %v", err)
+	}
+	// A caller that gives up returns what it took
+	if l.requests.available < -0.01 || l.tokens.available < 499 {
+		t.Errorf("expected abandoned reservation to be refunded, got %v requests and %v tokens", l.requests.available, l.tokens.available)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	req := EvaluationRequest{Prompt: "12345678", Response: "1234", Solution: ""}
+	if got := estimateTokens(req, 1); got != 3+judgeOverheadTokens {
+		t.Errorf("expected %d tokens, got %d", 3+judgeOverheadTokens, got)
+	}
+	if got := estimateTokens(req, 3); got != 3*(3+judgeOverheadTokens) {
+		t.Errorf("expected three judges to triple the estimate, got %d", got)
+	}
+	if got := estimateTokens(req, 0); got != 3+judgeOverheadTokens {
+		t.Errorf("expected at least one judge, got %d", got)
+	}
+}
+
+func TestRunJudges_RateLimited(t *testing.T) {
+	e := &Evaluator{litellmClient: NewLiteLLMClient("http://unused")}
+	e.SetJudgeProviders(&stubProvider{name: "stub", resp: &EvaluationResponse{ConsensusScore: 80}})
+	e.SetRateLimits(map[string]RateLimit{"stub": {RequestsPerMinute: 1}, "other": {}})
+
+	if _, err := e.runJudges(context.Background(), EvaluationRequest{}); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := e.runJudges(ctx, EvaluationRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the second call to wait for the rate limit, got %v", err)
+	}
+	if e.rateLimiter(&stubProvider{name: "other"}) != nil {
+		t.Error("expected an unlimited provider to have no limiter")
+	}
+}
+
+func TestRateLimiter_SharedPerProviderEndpoint(t *testing.T) {
+	e := &Evaluator{litellmClient: NewLiteLLMClient("http://unused")}
+	e.SetRateLimits(map[string]RateLimit{"openai": {RequestsPerMinute: 10}})
+
+	gpt := NewOpenAIProvider(JudgeConfig{Name: "gpt_5.2", Provider: "openai"})
+	panelist := NewOpenAIProvider(JudgeConfig{Name: "panelist", Provider: "openai"})
+	local := NewOpenAIProvider(JudgeConfig{Name: "local", Provider: "openai", BaseURL: "http://localhost:8080/v1"})
+
+	limiter := e.rateLimiter(gpt)
+	if limiter == nil || e.rateLimiter(panelist) != limiter {
+		t.Error("expected judges of one provider endpoint to share a limiter")
+	}
+	if other := e.rateLimiter(local); other == nil || other == limiter {
+		t.Error("expected another endpoint of the provider to get a limiter of its own")
+	}
+	if e.rateLimiter(NewAnthropicProvider(JudgeConfig{Name: "claude", Provider: "anthropic"})) != nil {
+		t.Error("expected a provider without limits to be unlimited")
+	}
+}
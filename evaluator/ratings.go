@@ -0,0 +1,189 @@
+package evaluator
+
+import (
+	"llm-tournament/middleware"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Rating scale parameters shared by Elo and Bradley-Terry so both read alike
+const (
+	ratingBase            = 1000.0
+	ratingScale           = 400.0 // A 400-point gap means 10:1 expected odds
+	eloK                  = 32.0
+	ratingBootstrapRounds = 200
+	ratingBootstrapSeed   = 42 // Fixed so the leaderboard does not jitter between page loads
+	bradleyTerryMaxIter   = 200
+	bradleyTerryTolerance = 1e-6
+)
+
+// Rating is a model's position on a pairwise leaderboard with a 95% bootstrap interval
+type Rating struct {
+	Model   string  `json:"model"`
+	Rating  float64 `json:"rating"`
+	Lower   float64 `json:"lower"`
+	Upper   float64 `json:"upper"`
+	Battles int     `json:"battles"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Ties    int     `json:"ties"`
+}
+
+// EloRatings replays battles in order with online Elo updates
+func EloRatings(battles []middleware.Battle) []Rating {
+	return bootstrapRatings(battles, eloPoint)
+}
+
+// BradleyTerryRatings fits a Bradley-Terry model to all battles at once.
+// Unlike Elo the result does not depend on the order battles were played.
+func BradleyTerryRatings(battles []middleware.Battle) []Rating {
+	return bootstrapRatings(battles, bradleyTerryPoint)
+}
+
+// scoreForA converts an outcome to model A's score (1 win, 0.5 tie, 0 loss)
+func scoreForA(outcome string) float64 {
+	switch outcome {
+	case middleware.BattleWinA:
+		return 1
+	case middleware.BattleWinB:
+		return 0
+	default:
+		return 0.5
+	}
+}
+
+// eloPoint computes Elo ratings for one ordering of battles
+func eloPoint(battles []middleware.Battle) map[string]float64 {
+	ratings := make(map[string]float64)
+	for _, b := range battles {
+		for _, m := range []string{b.ModelA, b.ModelB} {
+			if _, ok := ratings[m]; !ok {
+				ratings[m] = ratingBase
+			}
+		}
+		ra, rb := ratings[b.ModelA], ratings[b.ModelB]
+		expectedA := 1 / (1 + math.Pow(10, (rb-ra)/ratingScale))
+		delta := eloK * (scoreForA(b.Outcome) - expectedA)
+		ratings[b.ModelA] = ra + delta
+		ratings[b.ModelB] = rb - delta
+	}
+	return ratings
+}
+
+// bradleyTerryPoint fits strengths with the MM algorithm (Hunter 2004).
+// Every model also plays one virtual tie against an anchor of strength 1,
+// which keeps undefeated or winless models finite and pins the scale at ratingBase.
+func bradleyTerryPoint(battles []middleware.Battle) map[string]float64 {
+	wins := make(map[string]float64)
+	games := make(map[[2]string]float64)
+	for _, b := range battles {
+		s := scoreForA(b.Outcome)
+		wins[b.ModelA] += s
+		wins[b.ModelB] += 1 - s
+		games[[2]string{b.ModelA, b.ModelB}]++
+		games[[2]string{b.ModelB, b.ModelA}]++
+	}
+
+	strength := make(map[string]float64, len(wins))
+	for m := range wins {
+		strength[m] = 1
+	}
+
+	for iter := 0; iter < bradleyTerryMaxIter; iter++ {
+		next := make(map[string]float64, len(strength))
+		maxChange := 0.0
+		for i := range strength {
+			denom := 1 / (strength[i] + 1) // Anchor game
+			for j := range strength {
+				if n := games[[2]string{i, j}]; n > 0 {
+					denom += n / (strength[i] + strength[j])
+				}
+			}
+			next[i] = (wins[i] + 0.5) / denom
+			maxChange = math.Max(maxChange, math.Abs(next[i]-strength[i]))
+		}
+		strength = next
+		if maxChange < bradleyTerryTolerance {
+			break
+		}
+	}
+
+	ratings := make(map[string]float64, len(strength))
+	for m, p := range strength {
+		ratings[m] = ratingBase + ratingScale*math.Log10(p)
+	}
+	return ratings
+}
+
+// bootstrapRatings computes point ratings and 95% intervals by resampling battles
+func bootstrapRatings(battles []middleware.Battle, point func([]middleware.Battle) map[string]float64) []Rating {
+	if len(battles) == 0 {
+		return nil
+	}
+
+	records := make(map[string]*Rating)
+	for _, b := range battles {
+		for _, m := range []string{b.ModelA, b.ModelB} {
+			if records[m] == nil {
+				records[m] = &Rating{Model: m}
+			}
+			records[m].Battles++
+		}
+		switch b.Outcome {
+		case middleware.BattleWinA:
+			records[b.ModelA].Wins++
+			records[b.ModelB].Losses++
+		case middleware.BattleWinB:
+			records[b.ModelB].Wins++
+			records[b.ModelA].Losses++
+		default:
+			records[b.ModelA].Ties++
+			records[b.ModelB].Ties++
+		}
+	}
+
+	rng := rand.New(rand.NewSource(ratingBootstrapSeed))
+	samples := make(map[string][]float64)
+	sample := make([]middleware.Battle, len(battles))
+	for round := 0; round < ratingBootstrapRounds; round++ {
+		for i := range sample {
+			sample[i] = battles[rng.Intn(len(battles))]
+		}
+		for m, r := range point(sample) {
+			samples[m] = append(samples[m], r)
+		}
+	}
+
+	base := point(battles)
+	ratings := make([]Rating, 0, len(records))
+	for m, rec := range records {
+		rec.Rating = base[m]
+		rec.Lower, rec.Upper = rec.Rating, rec.Rating
+		if s := samples[m]; len(s) > 0 {
+			sort.Float64s(s)
+			rec.Lower = percentile(s, 0.025)
+			rec.Upper = percentile(s, 0.975)
+		}
+		ratings = append(ratings, *rec)
+	}
+
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].Rating != ratings[j].Rating {
+			return ratings[i].Rating > ratings[j].Rating
+		}
+		return ratings[i].Model < ratings[j].Model
+	})
+	return ratings
+}
+
+// percentile returns the p-th quantile of sorted values using linear interpolation
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
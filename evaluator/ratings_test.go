@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"llm-tournament/middleware"
+	"testing"
+)
+
+// battlesOf builds n identical battles
+func battlesOf(n int, a, b, outcome string) []middleware.Battle {
+	battles := make([]middleware.Battle, n)
+	for i := range battles {
+		battles[i] = middleware.Battle{ModelA: a, ModelB: b, Outcome: outcome}
+	}
+	return battles
+}
+
+func TestEloRatings_Empty(t *testing.T) {
+	if ratings := EloRatings(nil); ratings != nil {
+		t.Errorf("expected nil ratings, got %v", ratings)
+	}
+}
+
+func TestEloRatings_WinnerRanksFirst(t *testing.T) {
+	battles := append(battlesOf(8, "strong", "weak", middleware.BattleWinA), battlesOf(2, "weak", "strong", middleware.BattleWinA)...)
+	ratings := EloRatings(battles)
+
+	if len(ratings) != 2 {
+		t.Fatalf("expected 2 ratings, got %d", len(ratings))
+	}
+	if ratings[0].Model != "strong" {
+		t.Errorf("expected strong first, got %s", ratings[0].Model)
+	}
+	if ratings[0].Wins != 8 || ratings[0].Losses != 2 || ratings[0].Battles != 10 {
+		t.Errorf("unexpected record: %+v", ratings[0])
+	}
+	// Elo is zero-sum, so the pair averages to the base rating
+	if sum := ratings[0].Rating + ratings[1].Rating; sum < 1999.999 || sum > 2000.001 {
+		t.Errorf("expected ratings to sum to 2000, got %f", sum)
+	}
+	for _, r := range ratings {
+		if r.Lower > r.Upper {
+			t.Errorf("lower bound above upper bound for %s", r.Model)
+		}
+	}
+}
+
+func TestBradleyTerryRatings_MatchesWinRate(t *testing.T) {
+	// 3:1 record means strength ratio 3 (up to the anchor's regularisation)
+	battles := append(battlesOf(30, "a", "b", middleware.BattleWinA), battlesOf(10, "a", "b", middleware.BattleWinB)...)
+	ratings := BradleyTerryRatings(battles)
+
+	if ratings[0].Model != "a" {
+		t.Fatalf("expected a first, got %s", ratings[0].Model)
+	}
+	gap := ratings[0].Rating - ratings[1].Rating
+	// 400*log10(3) ≈ 191; the anchor pulls both towards 1000 slightly
+	if gap < 170 || gap > 195 {
+		t.Errorf("expected a gap near 191 points, got %f", gap)
+	}
+	if ratings[0].Lower >= ratings[0].Upper {
+		t.Errorf("expected a non-degenerate interval, got [%f, %f]", ratings[0].Lower, ratings[0].Upper)
+	}
+}
+
+func TestBradleyTerryRatings_OrderIndependentAndFinite(t *testing.T) {
+	battles := []middleware.Battle{
+		{ModelA: "a", ModelB: "b", Outcome: middleware.BattleWinA},
+		{ModelA: "b", ModelB: "c", Outcome: middleware.BattleTie},
+		{ModelA: "a", ModelB: "c", Outcome: middleware.BattleWinA},
+	}
+	reversed := []middleware.Battle{battles[2], battles[1], battles[0]}
+
+	forward := bradleyTerryPoint(battles)
+	backward := bradleyTerryPoint(reversed)
+	for model, r := range forward {
+		if diff := r - backward[model]; diff > 1e-6 || diff < -1e-6 {
+			t.Errorf("rating for %s depends on order: %f vs %f", model, r, backward[model])
+		}
+	}
+	// Undefeated model stays finite thanks to the anchor game
+	if forward["a"] > 2000 {
+		t.Errorf("expected finite rating for undefeated model, got %f", forward["a"])
+	}
+	if forward["b"] != forward["c"] {
+		t.Errorf("expected b and c to tie, got %f and %f", forward["b"], forward["c"])
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5}
+	if p := percentile(values, 0.5); p != 3 {
+		t.Errorf("expected median 3, got %f", p)
+	}
+	if p := percentile(values, 0.125); p != 1.5 {
+		t.Errorf("expected 1.5, got %f", p)
+	}
+	if p := percentile([]float64{7}, 0.975); p != 7 {
+		t.Errorf("expected 7, got %f", p)
+	}
+}
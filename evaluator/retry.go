@@ -0,0 +1,294 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error kinds recorded for pairs the judges could not score
+const (
+	ErrorKindRetryable = "retryable" // Network failure, rate limit or server error; a later run may succeed
+	ErrorKindPermanent = "permanent" // Bad request, rejected key or unreadable verdict; retrying will not help
+)
+
+// StatusError is a non-200 response from a judge endpoint
+type StatusError struct {
+	Op         string // What failed, e.g. "evaluation" or "chat completion"
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // Parsed Retry-After header; 0 when absent
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s failed with status %d: %s", e.Op, e.StatusCode, e.Body)
+}
+
+// newStatusError reads a failed response into a StatusError
+func newStatusError(op string, resp *http.Response) *StatusError {
+	body, _ := io.ReadAll(resp.Body)
+	return &StatusError{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter accepts both forms of the header: delay-seconds and an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// ClassifyError reports whether a failed judge call is worth retrying. Transport
+// errors, timeouts, 408, 429 and 5xx responses are retryable; everything else,
+// including other 4xx responses and unparseable verdicts, is permanent.
+func ClassifyError(err error) string {
+	var judgeErr *JudgeError
+	if errors.As(err, &judgeErr) {
+		return judgeErr.Kind
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch code := statusErr.StatusCode; {
+		case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+			return ErrorKindRetryable
+		case code >= 500 && code != http.StatusNotImplemented:
+			return ErrorKindRetryable
+		}
+		return ErrorKindPermanent
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorKindRetryable
+	}
+	return ErrorKindPermanent
+}
+
+// JudgeError is a judge call that was retried and still failed. Calls that fail on the
+// first attempt return the provider's error unchanged.
+type JudgeError struct {
+	Kind     string // ErrorKindRetryable or ErrorKindPermanent
+	Attempts int
+	Err      error
+}
+
+func (e *JudgeError) Error() string { return e.Err.Error() }
+func (e *JudgeError) Unwrap() error { return e.Err }
+
+// RetryPolicy controls how a failed judge call is retried
+type RetryPolicy struct {
+	MaxAttempts   int           // Calls per provider and pair, including the first; 1 disables retries
+	BaseDelay     time.Duration // Backoff before the first retry; doubles on each further retry
+	MaxDelay      time.Duration // Cap on the backoff
+	MaxRetryAfter time.Duration // Longest Retry-After the client will honour
+	Jitter        float64       // Fraction of each backoff that is randomised, 0-1
+}
+
+// DefaultRetryPolicy is used until the settings page configures another
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:   4,
+	BaseDelay:     2 * time.Second,
+	MaxDelay:      30 * time.Second,
+	MaxRetryAfter: 2 * time.Minute,
+	Jitter:        0.5,
+}
+
+// Backoff returns the delay before the given retry (1 for the first). A Retry-After
+// sent with err takes precedence over the exponential schedule.
+func (p RetryPolicy) Backoff(retry int, err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		if p.MaxRetryAfter > 0 && statusErr.RetryAfter > p.MaxRetryAfter {
+			return p.MaxRetryAfter
+		}
+		return statusErr.RetryAfter
+	}
+
+	delay := p.MaxDelay
+	if retry < 1 {
+		retry = 1
+	}
+	if retry <= 30 {
+		if d := p.BaseDelay << (retry - 1); d > 0 && d < delay {
+			delay = d
+		}
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// retrySleep waits between attempts (replaced in tests)
+var retrySleep = sleepContext
+
+// sleepContext waits for d, returning early with the context's error when it is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// evaluateWithRetry calls a provider until it succeeds, fails permanently, runs out
+// of attempts or ctx is cancelled. Every attempt first waits for the provider's rate
+// limiter, which may be nil.
+func evaluateWithRetry(ctx context.Context, p JudgeProvider, req EvaluationRequest, policy RetryPolicy, limiter *RateLimiter, tokens int) (*EvaluationResponse, error) {
+	var resp *EvaluationResponse
+	err := callWithRetry(ctx, p.Name(), policy, limiter, tokens, func() error {
+		var err error
+		resp, err = p.Evaluate(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// callWithRetry runs one judge call of the named provider with evaluateWithRetry's
+// retries and rate limiting
+func callWithRetry(ctx context.Context, name string, policy RetryPolicy, limiter *RateLimiter, tokens int, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		if err := limiter.Wait(ctx, tokens); err != nil {
+			return err
+		}
+		err := call()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		kind := ClassifyError(err)
+		if kind != ErrorKindRetryable || attempt >= policy.MaxAttempts {
+			if attempt == 1 {
+				return err
+			}
+			return &JudgeError{Kind: kind, Attempts: attempt, Err: err}
+		}
+		delay := policy.Backoff(attempt, err)
+		log.Printf("Judge provider %s failed (attempt %d of %d), retrying in %s: %v", name, attempt, policy.MaxAttempts, delay.Round(time.Millisecond), err)
+		if err := retrySleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// CircuitBreaker stops judge calls after several pairs in a row fail with retryable
+// errors, so a job waits for the service to come back instead of failing every
+// remaining pair. Once the cooldown passes a single trial call is let through while
+// the others wait: success closes the circuit and another failure opens it again.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool // A trial call is in flight
+}
+
+// Default circuit breaker settings
+const (
+	DefaultBreakerThreshold = 3
+	DefaultBreakerCooldown  = time.Minute
+)
+
+// breakerTrialPoll is how often callers held back by a trial call check on it
+const breakerTrialPoll = time.Second
+
+// NewCircuitBreaker opens after threshold consecutive failures and stays open for cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Wait returns how long until judge calls may resume; 0 when the circuit is closed
+// or ready for a trial call
+func (b *CircuitBreaker) Wait() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return wait
+	}
+	if b.trial {
+		return breakerTrialPoll
+	}
+	return 0
+}
+
+// Acquire reports whether a judge call may go ahead, and whether it is the trial call
+// that decides if the circuit closes. The trial must end with RecordSuccess,
+// RecordFailure or Release.
+func (b *CircuitBreaker) Acquire() (ok, trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true, false
+	}
+	if b.trial || time.Now().Before(b.openUntil) {
+		return false, false
+	}
+	b.trial = true
+	return true, true
+}
+
+// Release ends a trial call that did not show whether the service is back, letting
+// the next caller try; after RecordSuccess or RecordFailure it changes nothing
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// RecordSuccess closes the circuit
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.trial = false
+}
+
+// RecordFailure counts a retryable failure and reports whether it opened the circuit
+func (b *CircuitBreaker) RecordFailure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures < b.threshold {
+		return false
+	}
+	b.openUntil = time.Now().Add(b.cooldown)
+	b.trial = false
+	return true
+}
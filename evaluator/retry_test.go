@@ -0,0 +1,369 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails with each error in turn, then succeeds
+type flakyProvider struct {
+	failures []error
+	resp     *EvaluationResponse
+	calls    int
+}
+
+func (f *flakyProvider) Name() string { return "flaky" }
+
+func (f *flakyProvider) Evaluate(context.Context, EvaluationRequest) (*EvaluationResponse, error) {
+	f.calls++
+	if f.calls <= len(f.failures) {
+		return nil, f.failures[f.calls-1]
+	}
+	return f.resp, nil
+}
+
+func stubRetrySleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	original := retrySleep
+	retrySleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	t.Cleanup(func() { retrySleep = original })
+	return &delays
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+// blockingProvider counts calls and holds each one until release is closed
+type blockingProvider struct {
+	name    string
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (p *blockingProvider) Name() string { return p.name }
+
+func (p *blockingProvider) Evaluate(ctx context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	p.calls.Add(1)
+	<-p.release
+	return &EvaluationResponse{ConsensusScore: 80}, nil
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, ErrorKindRetryable},
+		{&StatusError{StatusCode: http.StatusServiceUnavailable}, ErrorKindRetryable},
+		{&StatusError{StatusCode: http.StatusRequestTimeout}, ErrorKindRetryable},
+		{&StatusError{StatusCode: http.StatusBadRequest}, ErrorKindPermanent},
+		{&StatusError{StatusCode: http.StatusUnauthorized}, ErrorKindPermanent},
+		{&StatusError{StatusCode: http.StatusNotImplemented}, ErrorKindPermanent},
+		{fmt.Errorf("failed to send request: %w", &url.Error{Op: "Post", URL: "http://judge", Err: errors.New("connection reset by peer")}), ErrorKindRetryable},
+		{fmt.Errorf("evaluation failed: %w", &StatusError{StatusCode: http.StatusBadGateway}), ErrorKindRetryable},
+		{errors.New("no JSON object in judge output"), ErrorKindPermanent},
+		{&JudgeError{Kind: ErrorKindRetryable, Err: errTest}, ErrorKindRetryable},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second, MaxRetryAfter: time.Minute}
+	for retry, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := policy.Backoff(retry+1, errTest); got != want {
+			t.Errorf("retry %d: expected %s, got %s", retry+1, want, got)
+		}
+	}
+	if got := policy.Backoff(100, errTest); got != 5*time.Second {
+		t.Errorf("expected large retry counts to hit the cap, got %s", got)
+	}
+
+	if got := policy.Backoff(1, &StatusError{StatusCode: 429, RetryAfter: 20 * time.Second}); got != 20*time.Second {
+		t.Errorf("expected Retry-After to override backoff, got %s", got)
+	}
+	if got := policy.Backoff(1, &StatusError{StatusCode: 429, RetryAfter: time.Hour}); got != time.Minute {
+		t.Errorf("expected Retry-After capped at a minute, got %s", got)
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 50; i++ {
+		if got := policy.Backoff(3, errTest); got < 2*time.Second || got > 4*time.Second {
+			t.Fatalf("jittered backoff %s outside [2s, 4s]", got)
+		}
+	}
+}
+
+func TestEvaluateWithRetry_RecoversFromTransientErrors(t *testing.T) {
+	delays := stubRetrySleep(t)
+	p := &flakyProvider{
+		failures: []error{
+			&StatusError{StatusCode: http.StatusServiceUnavailable},
+			&StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second},
+		},
+		resp: &EvaluationResponse{ConsensusScore: 80},
+	}
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	resp, err := evaluateWithRetry(context.Background(), p, EvaluationRequest{}, policy, nil, 0)
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if resp.ConsensusScore != 80 || p.calls != 3 {
+		t.Errorf("expected third call to succeed, got score %d after %d calls", resp.ConsensusScore, p.calls)
+	}
+	if len(*delays) != 2 || (*delays)[0] != time.Second || (*delays)[1] != 3*time.Second {
+		t.Errorf("expected delays [1s 3s], got %v", *delays)
+	}
+}
+
+func TestEvaluateWithRetry_GivesUp(t *testing.T) {
+	stubRetrySleep(t)
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	down := &StatusError{Op: "evaluation", StatusCode: http.StatusBadGateway}
+	p := &flakyProvider{failures: []error{down, down, down, down}}
+	_, err := evaluateWithRetry(context.Background(), p, EvaluationRequest{}, policy, nil, 0)
+	var judgeErr *JudgeError
+	if !errors.As(err, &judgeErr) || judgeErr.Kind != ErrorKindRetryable || judgeErr.Attempts != 3 {
+		t.Fatalf("expected retryable failure after 3 attempts, got %#v", err)
+	}
+	if !strings.Contains(err.Error(), "status 502") {
+		t.Errorf("expected the provider error message, got %q", err.Error())
+	}
+
+	p = &flakyProvider{failures: []error{&StatusError{StatusCode: http.StatusUnauthorized}}}
+	_, err = evaluateWithRetry(context.Background(), p, EvaluationRequest{}, policy, nil, 0)
+	if err != p.failures[0] || ClassifyError(err) != ErrorKindPermanent || p.calls != 1 {
+		t.Errorf("expected the permanent error without retries, got %v after %d calls", err, p.calls)
+	}
+}
+
+func TestLiteLLMClient_Evaluate_RetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "12")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("slow down"))
+	}))
+	defer server.Close()
+
+	_, err := NewLiteLLMClient(server.URL).Evaluate(context.Background(), EvaluationRequest{})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected a StatusError, got %v", err)
+	}
+	if statusErr.RetryAfter != 12*time.Second || statusErr.Body != "slow down" {
+		t.Errorf("unexpected status error: %+v", statusErr)
+	}
+	if err.Error() != "evaluation failed with status 429: slow down" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	b := NewCircuitBreaker(2, 30*time.Millisecond)
+	if b.RecordFailure() || b.Wait() != 0 {
+		t.Fatal("expected circuit to stay closed below the threshold")
+	}
+	if !b.RecordFailure() || b.Wait() == 0 {
+		t.Fatal("expected circuit to open at the threshold")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if b.Wait() != 0 {
+		t.Fatal("expected a trial call after the cooldown")
+	}
+	if ok, trial := b.Acquire(); !ok || !trial {
+		t.Fatal("expected the first caller to get the trial call")
+	}
+	if ok, _ := b.Acquire(); ok || b.Wait() == 0 {
+		t.Fatal("expected other callers to wait for the trial call")
+	}
+	b.Release()
+	if ok, trial := b.Acquire(); !ok || !trial {
+		t.Fatal("expected a released trial to pass to the next caller")
+	}
+	if !b.RecordFailure() {
+		t.Error("expected a failed trial call to reopen the circuit")
+	}
+
+	if ok, _ := b.Acquire(); ok {
+		t.Error("expected a failed trial to hold calls back again")
+	}
+
+	b.RecordSuccess()
+	if b.Wait() != 0 || b.RecordFailure() {
+		t.Error("expected success to close the circuit and reset the count")
+	}
+	if ok, trial := b.Acquire(); !ok || trial {
+		t.Error("expected calls to go ahead freely on a closed circuit")
+	}
+}
+
+func TestRunJudges_SingleTrialWhenHalfOpen(t *testing.T) {
+	e := &Evaluator{litellmClient: NewLiteLLMClient("http://unused"), breaker: NewCircuitBreaker(1, time.Millisecond)}
+	e.breaker.RecordFailure()
+	time.Sleep(5 * time.Millisecond)
+
+	release := make(chan struct{})
+	provider := &blockingProvider{name: "stub", release: release}
+	e.SetJudgeProviders(provider)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = e.runJudges(context.Background(), EvaluationRequest{})
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := provider.calls.Load(); n != 1 {
+		t.Errorf("expected only the trial call to reach the judge, got %d", n)
+	}
+	close(release)
+	wg.Wait()
+	if n := provider.calls.Load(); n != 3 {
+		t.Errorf("expected the waiting calls to go ahead once the trial succeeded, got %d", n)
+	}
+}
+
+func TestRunJudges_OpensCircuitOnRetryableFailures(t *testing.T) {
+	e := &Evaluator{litellmClient: NewLiteLLMClient("http://unused"), breaker: NewCircuitBreaker(2, time.Minute)}
+
+	e.SetJudgeProviders(&stubProvider{name: "stub", err: &StatusError{StatusCode: http.StatusUnauthorized}})
+	for i := 0; i < 3; i++ {
+		_, _ = e.runJudges(context.Background(), EvaluationRequest{})
+	}
+	if e.breaker.Wait() != 0 {
+		t.Fatal("expected permanent errors not to open the circuit")
+	}
+
+	e.SetJudgeProviders(&stubProvider{name: "stub", err: &StatusError{StatusCode: http.StatusServiceUnavailable}})
+	for i := 0; i < 2; i++ {
+		_, _ = e.runJudges(context.Background(), EvaluationRequest{})
+	}
+	if e.breaker.Wait() == 0 {
+		t.Error("expected repeated 503s to open the circuit")
+	}
+}
+
+func TestWaitForJudgeService_PausesJob(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	if _, err := db.Exec("INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')"); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.breaker = NewCircuitBreaker(1, 150*time.Millisecond)
+	e.breaker.RecordFailure()
+
+	done := make(chan error, 1)
+	go func() { done <- e.waitForJudgeService(context.Background(), &EvaluationJob{ID: 1}) }()
+
+	var status string
+	deadline := time.Now().Add(time.Second)
+	for status != "paused" && time.Now().Before(deadline) {
+		_ = db.QueryRow("SELECT status FROM evaluation_jobs WHERE id = 1").Scan(&status)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status != "paused" {
+		t.Fatalf("expected job to be paused, got %q", status)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("waitForJudgeService failed: %v", err)
+	}
+	var message string
+	_ = db.QueryRow("SELECT status, error_message FROM evaluation_jobs WHERE id = 1").Scan(&status, &message)
+	if status != "running" || message != "" {
+		t.Errorf("expected job to resume, got %q / %q", status, message)
+	}
+}
+
+func TestWaitForJudgeService_Cancel(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	e := newGeneratorTestEvaluator(db)
+	e.breaker = NewCircuitBreaker(1, time.Hour)
+	e.breaker.RecordFailure()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.waitForJudgeService(ctx, &EvaluationJob{ID: 1}); err == nil || err.Error() != "job cancelled" {
+		t.Errorf("expected cancellation while paused, got %v", err)
+	}
+}
+
+func TestProcessAllJob_RecordsFailedPairs(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	stubRetrySleep(t)
+
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1)",
+		"INSERT INTO prompts (text, suite_id, display_order) VALUES ('p1', 1, 0), ('p2', 1, 1)",
+		"INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 1, 'r1'), (1, 2, 'r2')",
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.retryPolicy = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	e.SetJudgeProviders(&stubProvider{name: "stub", err: &StatusError{Op: "evaluation", StatusCode: http.StatusServiceUnavailable}})
+
+	job := &EvaluationJob{ID: 1, SuiteID: 1, JobType: "all", ProgressTotal: 2}
+	if err := e.processAllJob(context.Background(), job); err != nil {
+		t.Fatalf("processAllJob failed: %v", err)
+	}
+	if job.ErrorMessage != "2 of 2 pairs failed" {
+		t.Errorf("unexpected job message %q", job.ErrorMessage)
+	}
+
+	var count, attempts int
+	var kind string
+	if err := db.QueryRow("SELECT COUNT(*), MAX(error_kind), MAX(attempts) FROM evaluation_errors WHERE job_id = 1").Scan(&count, &kind, &attempts); err != nil {
+		t.Fatalf("failed to query evaluation errors: %v", err)
+	}
+	if count != 2 || kind != ErrorKindRetryable || attempts != 2 {
+		t.Errorf("expected 2 retryable failures after 2 attempts, got %d %q %d", count, kind, attempts)
+	}
+}
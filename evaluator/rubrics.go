@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"llm-tournament/middleware"
+	"log"
+	"math"
+)
+
+// criterionScore is the consensus of the judges on one rubric criterion of a cell
+type criterionScore struct {
+	name   string
+	score  int
+	weight float64
+}
+
+// effectiveRubric returns the prompt's rubric, or its profile's when the prompt has
+// none. Unreadable rubrics are ignored so the prompt is still judged as a whole.
+func effectiveRubric(promptID int, promptRubric, profileRubric string) []middleware.RubricCriterion {
+	for _, data := range []string{promptRubric, profileRubric} {
+		rubric, err := middleware.ParseRubric(data)
+		if err != nil {
+			log.Printf("Ignoring unreadable rubric for prompt %d: %v", promptID, err)
+			continue
+		}
+		if len(rubric) > 0 {
+			return rubric
+		}
+	}
+	return nil
+}
+
+// rollUpCriteria replaces the score of every judge that scored the rubric with the
+// weighted average of its criterion scores, so the cell score follows the criteria
+// whatever overall score the judge reported. Criteria a judge skipped are left out
+// of its average. The input is not modified.
+func rollUpCriteria(results []JudgeResult, rubric []middleware.RubricCriterion) []JudgeResult {
+	rolled := append([]JudgeResult(nil), results...)
+	if len(rubric) == 0 {
+		return rolled
+	}
+	for i, r := range rolled {
+		sum, total := 0.0, 0.0
+		for _, c := range rubric {
+			if score, ok := r.Criteria[c.Name]; ok {
+				sum += float64(score) * c.Weight
+				total += c.Weight
+			}
+		}
+		if total > 0 {
+			rolled[i].Score = int(math.Round(sum / total))
+		}
+	}
+	return rolled
+}
+
+// criterionConsensus combines the judges' scores on each rubric criterion with the
+// suite's consensus strategy. Criteria no valid judge scored are left out.
+func criterionConsensus(results []JudgeResult, rubric []middleware.RubricCriterion, strategy ConsensusStrategy) []criterionScore {
+	var scores []criterionScore
+	for _, c := range rubric {
+		var verdicts []JudgeResult
+		for _, r := range results {
+			if score, ok := r.Criteria[c.Name]; ok && r.Error == "" {
+				verdicts = append(verdicts, JudgeResult{Judge: r.Judge, Score: score, Confidence: r.Confidence, Weight: r.Weight})
+			}
+		}
+		if len(validJudgeResults(verdicts)) == 0 {
+			continue
+		}
+		scores = append(scores, criterionScore{name: c.Name, score: strategy.Combine(verdicts), weight: c.Weight})
+	}
+	return scores
+}
+
+// recordCriterionScores replaces the criterion scores of a cell
+func (e *Evaluator) recordCriterionScores(modelID, promptID int, scores []criterionScore) {
+	if _, err := e.db.Exec("DELETE FROM criterion_scores WHERE model_id = ? AND prompt_id = ?", modelID, promptID); err != nil {
+		log.Printf("Failed to clear criterion scores: %v", err)
+		return
+	}
+	for _, s := range scores {
+		_, err := e.db.Exec(`
+			INSERT INTO criterion_scores (model_id, prompt_id, criterion, score, weight)
+			VALUES (?, ?, ?, ?, ?)
+		`, modelID, promptID, s.name, s.score, s.weight)
+		if err != nil {
+			log.Printf("Failed to save criterion score: %v", err)
+		}
+	}
+}
+
+// encodeCriteria stores a judge's criterion scores in its evaluation history
+func encodeCriteria(criteria map[string]int) string {
+	if len(criteria) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(criteria)
+	return string(data)
+}
@@ -0,0 +1,188 @@
+package evaluator
+
+import (
+	"context"
+	"llm-tournament/middleware"
+	"strings"
+	"testing"
+)
+
+// rubricJudge is a native judge that reports fixed criterion scores and counts its calls
+type rubricJudge struct {
+	name     string
+	score    int
+	criteria map[string]int
+	calls    int
+}
+
+func (j *rubricJudge) Name() string { return j.name }
+
+func (j *rubricJudge) Evaluate(_ context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	j.calls++
+	result := JudgeResult{Judge: j.name, Score: j.score, Confidence: 0.9, CostUSD: 0.01}
+	if len(req.Rubric) > 0 {
+		result.Criteria = j.criteria
+	}
+	return &EvaluationResponse{Results: []JudgeResult{result}, TotalCostUSD: 0.01}, nil
+}
+
+func TestEvaluateAll_RubricCriteriaRollUp(t *testing.T) {
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	seedJobGrid(t, db)
+
+	// p1 inherits its profile's rubric; p2 has none and is judged as a whole
+	_, err := db.Exec(`
+		INSERT INTO profiles (name, suite_id, rubric) VALUES ('Math', 1, '[{"name":"correctness","weight":3},{"name":"clarity","weight":1}]');
+		UPDATE prompts SET profile_id = (SELECT id FROM profiles WHERE name = 'Math') WHERE text = 'p1';
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed rubric: %v", err)
+	}
+
+	a := &rubricJudge{name: "a", score: 90, criteria: map[string]int{"correctness": 100, "clarity": 0}}
+	b := &rubricJudge{name: "b", score: 50, criteria: map[string]int{"correctness": 60}}
+	e := newGeneratorTestEvaluator(db)
+	e.SetJudgeProviders(a, b)
+
+	if _, err := e.EvaluateAll(1, false); err != nil {
+		t.Fatalf("EvaluateAll failed: %v", err)
+	}
+	runQueuedJob(t, e)
+
+	// a rolls up to (100*3 + 0*1)/4 = 75 and b, which skipped clarity, to 60; their
+	// mean of 67.5 snaps to 60 rather than the 70 of the overall scores
+	var score int
+	err = db.QueryRow(`
+		SELECT s.score FROM scores s JOIN models m ON m.id = s.model_id JOIN prompts p ON p.id = s.prompt_id
+		WHERE m.name = 'm1' AND p.text = 'p1'
+	`).Scan(&score)
+	if err != nil || score != 60 {
+		t.Errorf("expected the criteria to roll up to 60, got %d (%v)", score, err)
+	}
+
+	rows, err := db.Query(`
+		SELECT c.criterion, c.score, c.weight FROM criterion_scores c
+		JOIN models m ON m.id = c.model_id JOIN prompts p ON p.id = c.prompt_id
+		WHERE m.name = 'm1' AND p.text = 'p1' ORDER BY c.id
+	`)
+	if err != nil {
+		t.Fatalf("failed to query criterion scores: %v", err)
+	}
+	got := map[string]int{}
+	for rows.Next() {
+		var c criterionScore
+		if err := rows.Scan(&c.name, &c.score, &c.weight); err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		got[c.name] = c.score
+	}
+	_ = rows.Close()
+	if len(got) != 2 || got["correctness"] != 80 || got["clarity"] != 0 {
+		t.Errorf("expected correctness 80 from both judges and clarity 0 from a, got %v", got)
+	}
+
+	var unruled int
+	_ = db.QueryRow("SELECT COUNT(*) FROM criterion_scores c JOIN prompts p ON p.id = c.prompt_id WHERE p.text = 'p2'").Scan(&unruled)
+	if unruled != 0 {
+		t.Errorf("expected no criterion scores for the prompt without a rubric, got %d", unruled)
+	}
+
+	// Cached verdicts keep their criteria
+	if _, err := db.Exec("DELETE FROM criterion_scores"); err != nil {
+		t.Fatalf("failed to clear criterion scores: %v", err)
+	}
+	calls := a.calls
+	if _, err := e.EvaluateAll(1, false); err != nil {
+		t.Fatalf("EvaluateAll failed: %v", err)
+	}
+	runQueuedJob(t, e)
+	var count int
+	_ = db.QueryRow("SELECT COUNT(*) FROM criterion_scores").Scan(&count)
+	if a.calls != calls || count != 4 {
+		t.Errorf("expected cached verdicts to restore both models' criterion scores without calling the judges, got %d calls and %d scores", a.calls-calls, count)
+	}
+
+	// Editing the rubric invalidates the cache
+	if _, err := db.Exec(`UPDATE profiles SET rubric = '[{"name":"correctness","weight":1}]'`); err != nil {
+		t.Fatalf("failed to edit rubric: %v", err)
+	}
+	if _, err := e.EvaluateAll(1, false); err != nil {
+		t.Fatalf("EvaluateAll failed: %v", err)
+	}
+	runQueuedJob(t, e)
+	if a.calls == calls {
+		t.Error("expected a new rubric to be judged afresh")
+	}
+	_ = db.QueryRow("SELECT COUNT(*) FROM criterion_scores").Scan(&count)
+	if count != 2 {
+		t.Errorf("expected the dropped criterion's score to be replaced, got %d scores", count)
+	}
+}
+
+func TestEffectiveRubric(t *testing.T) {
+	own := `[{"name":"format","weight":1}]`
+	profile := `[{"name":"correctness","weight":2}]`
+	if r := effectiveRubric(1, own, profile); len(r) != 1 || r[0].Name != "format" {
+		t.Errorf("expected the prompt's own rubric, got %+v", r)
+	}
+	if r := effectiveRubric(1, "", profile); len(r) != 1 || r[0].Name != "correctness" {
+		t.Errorf("expected the profile's rubric, got %+v", r)
+	}
+	if r := effectiveRubric(1, "not json", profile); len(r) != 1 || r[0].Name != "correctness" {
+		t.Errorf("expected an unreadable rubric to fall back to the profile's, got %+v", r)
+	}
+	if r := effectiveRubric(1, "", ""); r != nil {
+		t.Errorf("expected no rubric, got %+v", r)
+	}
+}
+
+func TestRollUpCriteria(t *testing.T) {
+	rubric := []middleware.RubricCriterion{{Name: "correctness", Weight: 3}, {Name: "clarity", Weight: 1}}
+	results := []JudgeResult{
+		{Judge: "a", Score: 10, Criteria: map[string]int{"correctness": 80, "clarity": 40, "unknown": 0}},
+		{Judge: "b", Score: 30},
+	}
+	rolled := rollUpCriteria(results, rubric)
+	if rolled[0].Score != 70 || rolled[1].Score != 30 {
+		t.Errorf("expected 70 for a and b's own score, got %+v", rolled)
+	}
+	if results[0].Score != 10 {
+		t.Error("expected the judges' results to be left unchanged")
+	}
+}
+
+func TestParseJudgeOutput_Criteria(t *testing.T) {
+	got, err := parseJudgeOutput(`{"score": 70, "confidence": 0.8, "reasoning": "ok", "criteria": {"correctness": 120, "clarity": 40.4}}`)
+	if err != nil {
+		t.Fatalf("parseJudgeOutput failed: %v", err)
+	}
+	if len(got.Criteria) != 2 || got.Criteria["correctness"] != 100 || got.Criteria["clarity"] != 40 {
+		t.Errorf("expected clamped criterion scores, got %v", got.Criteria)
+	}
+}
+
+func TestRenderJudgePrompt_Rubric(t *testing.T) {
+	req := EvaluationRequest{Prompt: "P?", Response: "R.", Type: "objective"}
+	plain, err := RenderJudgePrompt(req)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if strings.Contains(plain, "RUBRIC") {
+		t.Error("expected no rubric section without a rubric")
+	}
+
+	req.Rubric = []middleware.RubricCriterion{{Name: "correctness", Weight: 3, Descriptor: "Matches the solution"}, {Name: "clarity", Weight: 0.5}}
+	for _, promptType := range []string{"objective", "creative"} {
+		req.Type = promptType
+		rendered, err := RenderJudgePrompt(req)
+		if err != nil {
+			t.Fatalf("render failed: %v", err)
+		}
+		for _, want := range []string{"**RUBRIC:**", "- **correctness** (weight 3): Matches the solution\n", "- **clarity** (weight 0.5)\n", `"criteria" object`} {
+			if !strings.Contains(rendered, want) {
+				t.Errorf("%s prompt missing %q", promptType, want)
+			}
+		}
+	}
+}
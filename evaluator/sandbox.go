@@ -0,0 +1,379 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llm-tournament/middleware"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Sandbox runs model-written programs in a subprocess with a private read-only root,
+// no network access, its own PID namespace, resource limits and a wall-clock timeout
+type Sandbox struct {
+	Timeout        time.Duration // Wall-clock limit for each test run
+	BuildTimeout   time.Duration // Wall-clock limit for compiling, for languages that need it
+	CPUSeconds     int
+	MemoryMB       int // Address-space limit; interpreters like node need around 1 GB
+	MaxFileMB      int // Largest file the program may write
+	MaxOutputBytes int // stdout and stderr are each truncated to this size
+	MaxProcesses   int // Processes and threads the program may run at once, so a fork loop fails
+}
+
+// DefaultSandbox is the sandbox used by automated evaluations
+var DefaultSandbox = Sandbox{
+	Timeout:        10 * time.Second,
+	BuildTimeout:   60 * time.Second,
+	CPUSeconds:     10,
+	MemoryMB:       1024,
+	MaxFileMB:      16,
+	MaxOutputBytes: 64 << 10,
+	MaxProcesses:   256,
+}
+
+// TestResult is the outcome of one hidden test case
+type TestResult struct {
+	Name       string `json:"name"`
+	Passed     bool   `json:"passed"`
+	ExitCode   int    `json:"exit_code"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"` // Why the test failed: build error, timeout, signal or wrong output
+}
+
+// codeLanguage describes how to build and run a program in one language
+type codeLanguage struct {
+	name    string
+	aliases []string // Code fence tags that select this language
+	source  string   // File the extracted program is written to
+	harness string   // Separate file for test code; "" appends test code to the source
+	files   map[string]string
+	build   []string
+	run     []string
+	prepare func(code string) string
+}
+
+var codeLanguages = []codeLanguage{
+	{name: "python", aliases: []string{"python", "py", "python3"}, source: "main.py", run: []string{"python3", "main.py"}},
+	{name: "javascript", aliases: []string{"javascript", "js", "node"}, source: "main.js", run: []string{"node", "main.js"}},
+	{name: "bash", aliases: []string{"bash", "sh", "shell"}, source: "main.sh", run: []string{"bash", "main.sh"}},
+	{
+		name:    "go",
+		aliases: []string{"go", "golang"},
+		source:  "main.go",
+		harness: "harness_main.go",
+		files:   map[string]string{"go.mod": "module sandbox\n\ngo 1.21\n"},
+		build:   []string{"go", "build", "-o", "prog", "."},
+		run:     []string{"./prog"},
+		prepare: withGoPackage,
+	},
+}
+
+// CodeLanguages lists the languages hidden tests can be written for
+func CodeLanguages() []string {
+	names := make([]string, len(codeLanguages))
+	for i, l := range codeLanguages {
+		names[i] = l.name
+	}
+	return names
+}
+
+func getCodeLanguage(name string) (codeLanguage, error) {
+	for _, l := range codeLanguages {
+		if l.name == name {
+			return l, nil
+		}
+	}
+	return codeLanguage{}, fmt.Errorf("unsupported language: %q", name)
+}
+
+var goPackageClause = regexp.MustCompile(`(?m)^package\s+\w+`)
+
+// withGoPackage adds "package main" to Go snippets that omit it
+func withGoPackage(code string) string {
+	if goPackageClause.MatchString(code) {
+		return code
+	}
+	return "package main\n\n" + code
+}
+
+var codeFence = regexp.MustCompile("(?s)```([\\w+#-]*)[^\\n]*\\n(.*?)```")
+
+// extractCode picks the program out of a response: the first fenced block tagged
+// with the language, else the first fenced block, else the whole response
+func extractCode(response string, lang codeLanguage) string {
+	blocks := codeFence.FindAllStringSubmatch(response, -1)
+	for _, b := range blocks {
+		tag := strings.ToLower(b[1])
+		for _, alias := range lang.aliases {
+			if tag == alias {
+				return b[2]
+			}
+		}
+	}
+	if len(blocks) > 0 {
+		return blocks[0][2]
+	}
+	return strings.TrimSpace(response)
+}
+
+// RunTests runs the program in a response against each hidden test case. A test passes
+// when the program exits 0 and, if the test expects output, prints it. The error is
+// only for problems with the sandbox itself, such as a missing runtime, or for a
+// cancelled ctx, which kills the running program.
+func (s Sandbox) RunTests(ctx context.Context, language, response string, tests []middleware.TestCase) ([]TestResult, error) {
+	lang, err := getCodeLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+	code := extractCode(response, lang)
+
+	// Builds of one response share a cache, so later tests compile quickly, but no
+	// response sees what another one compiled
+	var cache string
+	if len(lang.build) > 0 {
+		cache, err = os.MkdirTemp("", "llm-sandbox-cache-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sandbox build cache: %w", err)
+		}
+		defer func() { _ = os.RemoveAll(cache) }()
+	}
+
+	results := make([]TestResult, 0, len(tests))
+	for i, tc := range tests {
+		name := tc.Name
+		if name == "" {
+			name = fmt.Sprintf("test %d", i+1)
+		}
+		result, err := s.runTest(ctx, lang, code, tc, cache)
+		if err != nil {
+			return nil, err
+		}
+		result.Name = name
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s Sandbox) runTest(ctx context.Context, lang codeLanguage, code string, tc middleware.TestCase, cache string) (TestResult, error) {
+	dir, err := os.MkdirTemp("", "llm-sandbox-")
+	if err != nil {
+		return TestResult{}, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	layout := sandboxLayout{
+		Root:  filepath.Join(dir, "root"),
+		Work:  filepath.Join(dir, "work"),
+		Cache: cache,
+		TmpMB: 4 * s.MaxFileMB,
+	}
+	for _, d := range []string{layout.Root, layout.Work} {
+		if err := os.Mkdir(d, 0o700); err != nil {
+			return TestResult{}, fmt.Errorf("failed to create sandbox directory: %w", err)
+		}
+	}
+
+	files := map[string]string{lang.source: code}
+	if tc.Code != "" {
+		if lang.harness != "" {
+			files[lang.harness] = tc.Code
+		} else {
+			files[lang.source] = code + "\n\n" + tc.Code + "\n"
+		}
+	}
+	for name, content := range lang.files {
+		files[name] = content
+	}
+	for name, content := range files {
+		if lang.prepare != nil && (name == lang.source || name == lang.harness) {
+			content = lang.prepare(content)
+		}
+		if err := os.WriteFile(filepath.Join(layout.Work, name), []byte(content), 0o600); err != nil {
+			return TestResult{}, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if len(lang.build) > 0 {
+		build, err := s.exec(ctx, layout, lang.build, "", s.BuildTimeout, int(s.BuildTimeout.Seconds()))
+		if err != nil {
+			return TestResult{}, err
+		}
+		if build.err != "" {
+			return TestResult{
+				ExitCode:   build.exitCode,
+				Stdout:     build.stdout,
+				Stderr:     build.stderr,
+				DurationMS: build.duration.Milliseconds(),
+				Error:      "build failed: " + build.err,
+			}, nil
+		}
+	}
+
+	run, err := s.exec(ctx, layout, lang.run, tc.Input, s.Timeout, s.CPUSeconds)
+	if err != nil {
+		return TestResult{}, err
+	}
+	result := TestResult{
+		ExitCode:   run.exitCode,
+		Stdout:     run.stdout,
+		Stderr:     run.stderr,
+		DurationMS: run.duration.Milliseconds(),
+		Error:      run.err,
+	}
+	if result.Error == "" && tc.Expected != "" && strings.TrimSpace(run.stdout) != strings.TrimSpace(tc.Expected) {
+		result.Error = "unexpected output"
+	}
+	result.Passed = result.Error == ""
+	return result, nil
+}
+
+// execResult is a finished sandboxed process; err describes a failed run
+type execResult struct {
+	exitCode int
+	stdout   string
+	stderr   string
+	duration time.Duration
+	err      string
+}
+
+// sandboxLayout is the part of the host file system a sandboxed program can reach,
+// and who it runs as
+type sandboxLayout struct {
+	Root         string   // Empty host directory the private root is assembled on
+	Work         string   // Program directory, writable at /sandbox
+	Cache        string   // Build cache, writable at /cache ("" for none)
+	TmpMB        int      // Size of the tmpfs at /tmp
+	ReadOnly     []string // Runtime install prefixes shown read-only at the same path
+	Unprivileged bool     // Run the program as an unprivileged user rather than the namespace's root
+}
+
+// A sandbox that cannot be set up exits with sandboxSetupExit and a message starting
+// with sandboxSetupPrefix, which is not a failure of the program
+const (
+	sandboxSetupExit   = 125
+	sandboxSetupPrefix = "sandbox setup: "
+)
+
+// exec runs argv in the layout's program directory under the sandbox limits
+func (s Sandbox) exec(parent context.Context, layout sandboxLayout, argv []string, stdin string, timeout time.Duration, cpuSeconds int) (execResult, error) {
+	program := argv[0]
+	if !strings.Contains(program, "/") {
+		path, err := exec.LookPath(program)
+		if err != nil {
+			return execResult{}, fmt.Errorf("%s is not installed: %w", program, err)
+		}
+		program = path
+	}
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		return execResult{}, fmt.Errorf("sh is not installed: %w", err)
+	}
+	// ulimit -f counts 512-byte blocks and -v kilobytes
+	limits := fmt.Sprintf("ulimit -t %d; ulimit -f %d;", cpuSeconds, s.MaxFileMB*2048)
+	if s.MemoryMB > 0 {
+		limits += fmt.Sprintf(" ulimit -v %d;", s.MemoryMB*1024)
+	}
+	// bash and busybox call the process limit -u, dash -p
+	if s.MaxProcesses > 0 {
+		limits += fmt.Sprintf(" ulimit -u %d 2>/dev/null || ulimit -p %d;", s.MaxProcesses, s.MaxProcesses)
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	cmd, err := sandboxCommand(ctx, layout, append([]string{shell, "-c", limits + ` exec "$@"`, "sandbox", program}, argv[1:]...), sandboxEnv())
+	if err != nil {
+		return execResult{}, err
+	}
+	cmd.Stdin = strings.NewReader(stdin)
+	stdout := &cappedBuffer{limit: s.MaxOutputBytes}
+	stderr := &cappedBuffer{limit: s.MaxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	result := execResult{stdout: stdout.String(), stderr: stderr.String(), duration: time.Since(start)}
+
+	var exitErr *exec.ExitError
+	switch {
+	case parent.Err() != nil:
+		return execResult{}, parent.Err()
+	case ctx.Err() == context.DeadlineExceeded:
+		result.exitCode = -1
+		result.err = fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == sandboxSetupExit && strings.HasPrefix(result.stderr, sandboxSetupPrefix):
+		return execResult{}, errors.New(strings.TrimSpace(result.stderr))
+	case errors.As(err, &exitErr):
+		result.exitCode = exitErr.ExitCode()
+		result.err = exitErr.String()
+	case err != nil:
+		return execResult{}, fmt.Errorf("failed to start sandbox: %w", err)
+	}
+	return result, nil
+}
+
+// sandboxEnv keeps the program away from the server's environment, which holds API
+// keys. Paths are as seen inside the sandbox.
+func sandboxEnv() []string {
+	return []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=/sandbox",
+		"TMPDIR=/tmp",
+		"LANG=C.UTF-8",
+		"GOCACHE=/cache/go",
+		"GOPATH=/tmp/gopath",
+		"GOTOOLCHAIN=local",
+		"CGO_ENABLED=0",
+	}
+}
+
+// cappedBuffer keeps the first limit bytes written to it and drops the rest
+type cappedBuffer struct {
+	limit     int
+	buf       []byte
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.buf); room > 0 {
+		if len(p) > room {
+			b.buf = append(b.buf, p[:room]...)
+			b.truncated = true
+		} else {
+			b.buf = append(b.buf, p...)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return string(b.buf) + "\n[output truncated]"
+	}
+	return string(b.buf)
+}
+
+// testPassRatioScore is the percentage of passing tests, left for the suite's scale to
+// round
+func testPassRatioScore(results []TestResult) (int, int) {
+	passed := 0
+	for _, r := range results {
+		if r.Passed {
+			passed++
+		}
+	}
+	if len(results) == 0 {
+		return 0, 0
+	}
+	return int(math.Round(float64(passed) * 100 / float64(len(results)))), passed
+}
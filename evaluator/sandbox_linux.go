@@ -0,0 +1,349 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// sandboxInitEnv hands the layout to the server binary re-executed inside the
+// namespaces, which assembles the private root before starting the program
+const sandboxInitEnv = "LLM_TOURNAMENT_SANDBOX"
+
+// sandboxSystemPaths are the host directories and files a sandboxed program sees,
+// read-only: enough to run the interpreters and compilers, nothing of the server's
+// data. /etc is limited to what the dynamic loader and the runtimes read.
+var sandboxSystemPaths = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d",
+	"/etc/localtime", "/etc/ssl", "/etc/passwd", "/etc/group", "/etc/nsswitch.conf",
+}
+
+// sandboxDevices are bound into the sandbox's /dev
+var sandboxDevices = []string{"null", "zero", "full", "random", "urandom"}
+
+// A server running as root starts programs as sandboxUser, which is nobody on the
+// host: the kernel does not apply the process limit to the host's root
+const (
+	sandboxUser     = 1
+	sandboxHostUser = 65534
+)
+
+func init() {
+	if spec, ok := os.LookupEnv(sandboxInitEnv); ok {
+		enterSandbox(spec)
+	}
+}
+
+// sandboxCommand starts argv in new user, mount, network and PID namespaces through
+// the server binary, which sets up the layout and then runs argv with no capabilities.
+// The program runs as the namespace's root, which is the server's user on the host (or
+// as sandboxUser when that is root), but its root directory is a read-only tmpfs holding only sandboxSystemPaths, the runtime's
+// install prefix, the writable program directory at /sandbox, the build cache at /cache
+// and an empty /tmp. It sees only a loopback interface, and every process it forks dies
+// with it.
+func sandboxCommand(ctx context.Context, layout sandboxLayout, argv []string, env []string) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the server binary: %w", err)
+	}
+	for _, program := range argv {
+		if !filepath.IsAbs(program) {
+			continue
+		}
+		root, err := runtimeRoot(program)
+		if err != nil {
+			return nil, err
+		}
+		if root != "" {
+			layout.ReadOnly = append(layout.ReadOnly, root)
+		}
+	}
+	uids := []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+	gids := []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	if os.Getuid() == 0 {
+		uids = append(uids, syscall.SysProcIDMap{ContainerID: sandboxUser, HostID: sandboxHostUser, Size: 1})
+		gids = append(gids, syscall.SysProcIDMap{ContainerID: sandboxUser, HostID: sandboxHostUser, Size: 1})
+		layout.Unprivileged = true
+	}
+	spec, err := json.Marshal(layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sandbox layout: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, self, argv...)
+	cmd.Dir = layout.Work
+	cmd.Env = append(env, sandboxInitEnv+"="+string(spec))
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET | syscall.CLONE_NEWPID,
+		UidMappings: uids,
+		GidMappings: gids,
+		Pdeathsig:   syscall.SIGKILL,
+		// Only a root server maps sandboxUser, and the sandbox then drops root's groups
+		GidMappingsEnableSetgroups: layout.Unprivileged,
+	}
+	return cmd, nil
+}
+
+// runtimeRoot is the install prefix of a program outside the system directories, such
+// as /root/.pyenv for a pyenv shim, which the sandbox shows read-only as well. It is ""
+// for system programs. A prefix holding the server's working directory or a whole home
+// directory is refused rather than exposed.
+func runtimeRoot(program string) (string, error) {
+	path, err := filepath.EvalSymlinks(program)
+	if err != nil {
+		path = program
+	}
+	for _, p := range sandboxSystemPaths {
+		if withinPath(path, p) {
+			return "", nil
+		}
+	}
+
+	root := filepath.Dir(filepath.Dir(path))
+	cwd, _ := os.Getwd()
+	home, _ := os.UserHomeDir()
+	if root == "/" || root == home || (cwd != "" && withinPath(cwd, root)) {
+		return "", fmt.Errorf("%s is installed under %s, which the sandbox does not expose", program, root)
+	}
+	return root, nil
+}
+
+// withinPath reports whether path is dir or inside it
+func withinPath(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/")
+}
+
+// enterSandbox runs in the re-executed server binary: it assembles the sandbox and
+// replaces itself with the program in os.Args. It never returns.
+func enterSandbox(spec string) {
+	var layout sandboxLayout
+	err := json.Unmarshal([]byte(spec), &layout)
+	if err == nil {
+		err = setupSandbox(layout)
+	}
+	if err == nil {
+		env := make([]string, 0, len(os.Environ()))
+		for _, kv := range os.Environ() {
+			if !strings.HasPrefix(kv, sandboxInitEnv+"=") {
+				env = append(env, kv)
+			}
+		}
+		err = syscall.Exec(os.Args[1], os.Args[1:], env)
+	}
+	fmt.Fprintf(os.Stderr, "%s%v\n", sandboxSetupPrefix, err)
+	os.Exit(sandboxSetupExit)
+}
+
+// setupSandbox builds the private root on layout.Root, switches to it, detaches the
+// host's file system and drops every capability
+func setupSandbox(layout sandboxLayout) error {
+	root := layout.Root
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=0755,size=1m"); err != nil {
+		return fmt.Errorf("failed to mount the sandbox root: %w", err)
+	}
+
+	for _, p := range append(sandboxSystemPaths, layout.ReadOnly...) {
+		if err := exposePath(p, filepath.Join(root, p)); err != nil {
+			return err
+		}
+	}
+	if err := bindMount(layout.Work, filepath.Join(root, "sandbox"), true); err != nil {
+		return err
+	}
+	if layout.Cache != "" {
+		if err := bindMount(layout.Cache, filepath.Join(root, "cache"), true); err != nil {
+			return err
+		}
+	}
+	tmp := filepath.Join(root, "tmp")
+	if err := os.Mkdir(tmp, 0o755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("tmpfs", tmp, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, fmt.Sprintf("mode=1777,size=%dm", layout.TmpMB)); err != nil {
+		return fmt.Errorf("failed to mount /tmp: %w", err)
+	}
+	if err := setupDevices(filepath.Join(root, "dev")); err != nil {
+		return err
+	}
+	// A fresh /proc shows only the sandbox's processes; kernels that refuse it inside a
+	// user namespace leave /proc out
+	proc := filepath.Join(root, "proc")
+	if err := os.Mkdir(proc, 0o555); err != nil {
+		return err
+	}
+	_ = syscall.Mount("proc", proc, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, "")
+
+	old := filepath.Join(root, ".old")
+	if err := os.Mkdir(old, 0o700); err != nil {
+		return err
+	}
+	if err := syscall.PivotRoot(root, old); err != nil {
+		return fmt.Errorf("failed to switch root: %w", err)
+	}
+	if err := syscall.Chdir("/"); err != nil {
+		return err
+	}
+	if err := syscall.Unmount("/.old", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("failed to detach the host file system: %w", err)
+	}
+	if err := os.Remove("/.old"); err != nil {
+		return err
+	}
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("failed to make the sandbox root read-only: %w", err)
+	}
+	if err := syscall.Chdir("/sandbox"); err != nil {
+		return err
+	}
+	if err := dropCapabilities(); err != nil {
+		return err
+	}
+	if layout.Unprivileged {
+		return becomeSandboxUser(layout.Cache != "")
+	}
+	return nil
+}
+
+// becomeSandboxUser hands the writable directories to sandboxUser and switches to it,
+// which also clears the capabilities the namespace's root still holds
+func becomeSandboxUser(cache bool) error {
+	dirs := []string{"/sandbox"}
+	if cache {
+		dirs = append(dirs, "/cache")
+	}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, _ os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			return os.Lchown(path, sandboxUser, sandboxUser)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to hand %s to the sandbox user: %w", dir, err)
+		}
+	}
+	if err := syscall.Setgroups(nil); err != nil {
+		return fmt.Errorf("failed to drop groups: %w", err)
+	}
+	if err := syscall.Setgid(sandboxUser); err != nil {
+		return fmt.Errorf("failed to switch group: %w", err)
+	}
+	if err := syscall.Setuid(sandboxUser); err != nil {
+		return fmt.Errorf("failed to switch user: %w", err)
+	}
+	return nil
+}
+
+// exposePath shows a host path read-only at target. Symlinks, such as /lib on merged-usr
+// systems, are recreated rather than bound; missing paths are skipped.
+func exposePath(path, target string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	}
+	return bindMount(path, target, false)
+}
+
+// bindMount binds source onto target, creating the mount point. Read-only binds keep
+// the flags the kernel locks for the source, which a user namespace cannot clear.
+func bindMount(source, target string, writable bool) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		err = os.MkdirAll(target, 0o755)
+	} else if err = os.MkdirAll(filepath.Dir(target), 0o755); err == nil {
+		err = os.WriteFile(target, nil, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	if err := syscall.Mount(source, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("failed to bind %s: %w", source, err)
+	}
+	if writable {
+		return nil
+	}
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(target, &st); err != nil {
+		return err
+	}
+	flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY | syscall.MS_NOSUID | syscall.MS_NODEV)
+	flags |= uintptr(st.Flags) & (syscall.MS_NOEXEC | syscall.MS_NOATIME | syscall.MS_NODIRATIME)
+	if st.Flags&stRelatime != 0 {
+		flags |= syscall.MS_RELATIME
+	}
+	if err := syscall.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("failed to make %s read-only: %w", source, err)
+	}
+	return nil
+}
+
+// stRelatime is ST_RELATIME in statfs flags, which differs from MS_RELATIME
+const stRelatime = 0x1000
+
+// setupDevices gives the sandbox the harmless character devices and the /dev/fd links
+func setupDevices(dev string) error {
+	if err := os.Mkdir(dev, 0o755); err != nil {
+		return err
+	}
+	for _, name := range sandboxDevices {
+		if err := bindMount(filepath.Join("/dev", name), filepath.Join(dev, name), true); err != nil {
+			return err
+		}
+	}
+	for name, link := range map[string]string{
+		"fd": "/proc/self/fd", "stdin": "/proc/self/fd/0", "stdout": "/proc/self/fd/1", "stderr": "/proc/self/fd/2",
+	} {
+		if err := os.Symlink(link, filepath.Join(dev, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropCapabilities empties the bounding set, so the program starts without the
+// capabilities the namespace's root would get, and stops it gaining any back
+func dropCapabilities() error {
+	for c := uintptr(0); ; c++ {
+		_, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_CAPBSET_DROP, c, 0)
+		if errno == syscall.EINVAL {
+			break
+		}
+		if errno != 0 {
+			return fmt.Errorf("failed to drop capability %d: %w", c, errno)
+		}
+	}
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to set no_new_privs: %w", errno)
+	}
+	return nil
+}
+
+// prSetNoNewPrivs is PR_SET_NO_NEW_PRIVS, which the syscall package does not define
+const prSetNoNewPrivs = 38
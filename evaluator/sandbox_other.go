@@ -0,0 +1,15 @@
+//go:build !linux
+
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// sandboxCommand reports that code execution is unavailable: without Linux
+// namespaces the program could not be kept off the network and the server's files
+func sandboxCommand(ctx context.Context, layout sandboxLayout, argv []string, env []string) (*exec.Cmd, error) {
+	return nil, errors.New("sandboxed code execution requires Linux")
+}
@@ -0,0 +1,320 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"llm-tournament/middleware"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// requireSandbox skips tests that execute code when the runtime or Linux
+// namespaces are not available on this machine
+func requireSandbox(t *testing.T, language string) Sandbox {
+	t.Helper()
+	lang, err := getCodeLanguage(language)
+	if err != nil {
+		t.Fatalf("getCodeLanguage failed: %v", err)
+	}
+	tool := lang.run[0]
+	if len(lang.build) > 0 {
+		tool = lang.build[0]
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		t.Skipf("%s not installed", tool)
+	}
+	s := DefaultSandbox
+	s.Timeout = 5 * time.Second
+	if _, err := s.RunTests(context.Background(), "bash", "true", []middleware.TestCase{{}}); err != nil {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+	return s
+}
+
+func TestExtractCode(t *testing.T) {
+	python, _ := getCodeLanguage("python")
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"tagged block wins", "Here:\n```text\nnot code\n```\n```py\nprint(1)\n```", "print(1)\n"},
+		{"first block without a matching tag", "```\nprint(2)\n```\n```js\nx\n```", "print(2)\n"},
+		{"no fences uses the whole response", "  print(3)\n", "print(3)"},
+	}
+	for _, tt := range tests {
+		if got := extractCode(tt.response, python); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestWithGoPackage(t *testing.T) {
+	if got := withGoPackage("func Max() {}"); !strings.HasPrefix(got, "package main\n") {
+		t.Errorf("expected package clause to be added, got %q", got)
+	}
+	if got := withGoPackage("package main\n\nfunc main() {}"); strings.Count(got, "package") != 1 {
+		t.Errorf("expected existing package clause to be kept, got %q", got)
+	}
+}
+
+func TestTestPassRatioScore(t *testing.T) {
+	results := []TestResult{{Passed: true}, {Passed: true}, {Passed: false}}
+	score, passed := testPassRatioScore(results)
+	if score != 67 || passed != 2 {
+		t.Errorf("expected 2 passed scoring 67, got %d passed scoring %d", passed, score)
+	}
+	if scale, _ := middleware.GetScoreScale(middleware.ScoreScaleContinuous); scale.FromPercent(float64(score)) != 67 {
+		t.Error("expected a continuous suite to keep the exact pass rate")
+	}
+	if score, _ := testPassRatioScore(nil); score != 0 {
+		t.Errorf("expected 0 for no tests, got %d", score)
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	b := &cappedBuffer{limit: 4}
+	n, err := b.Write([]byte("abcdef"))
+	if err != nil || n != 6 {
+		t.Fatalf("expected the full write to be accepted, got %d, %v", n, err)
+	}
+	_, _ = b.Write([]byte("gh"))
+	if got := b.String(); got != "abcd\n[output truncated]" {
+		t.Errorf("unexpected capped output %q", got)
+	}
+}
+
+func TestSandbox_RunTests_Python(t *testing.T) {
+	s := requireSandbox(t, "python")
+
+	response := "Here is my solution:\n```python\na, b = map(int, input().split())\nprint(a + b)\n```"
+	results, err := s.RunTests(context.Background(), "python", response, []middleware.TestCase{
+		{Name: "small", Input: "2 3", Expected: "5"},
+		{Name: "negative", Input: "-4 1", Expected: "-3"},
+		{Name: "wrong", Input: "1 1", Expected: "3"},
+	})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if !results[0].Passed || !results[1].Passed {
+		t.Errorf("expected the first two tests to pass, got %+v", results[:2])
+	}
+	if results[2].Passed || results[2].Error != "unexpected output" || strings.TrimSpace(results[2].Stdout) != "2" {
+		t.Errorf("expected the third test to fail on output, got %+v", results[2])
+	}
+}
+
+func TestSandbox_RunTests_AppendedTestCode(t *testing.T) {
+	s := requireSandbox(t, "python")
+
+	response := "```python\ndef add(a, b):\n    return a + b\n```"
+	results, err := s.RunTests(context.Background(), "python", response, []middleware.TestCase{
+		{Code: "assert add(2, 2) == 4"},
+		{Code: "assert add(2, 2) == 5, 'bad sum'"},
+	})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if !results[0].Passed || results[0].Name != "test 1" {
+		t.Errorf("expected passing test named 'test 1', got %+v", results[0])
+	}
+	if results[1].Passed || results[1].ExitCode == 0 || !strings.Contains(results[1].Stderr, "bad sum") {
+		t.Errorf("expected failing assertion with stderr, got %+v", results[1])
+	}
+}
+
+func TestSandbox_EnforcesLimits(t *testing.T) {
+	s := requireSandbox(t, "python")
+	s.Timeout = time.Second
+
+	timeout, err := s.RunTests(context.Background(), "python", "import time\ntime.sleep(30)", []middleware.TestCase{{}})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if timeout[0].Passed || !strings.Contains(timeout[0].Error, "timed out") {
+		t.Errorf("expected a timeout, got %+v", timeout[0])
+	}
+
+	network := "import socket\nsocket.create_connection(('1.1.1.1', 80), timeout=2)"
+	offline, err := s.RunTests(context.Background(), "python", network, []middleware.TestCase{{}})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if offline[0].Passed {
+		t.Errorf("expected the network to be unreachable, got %+v", offline[0])
+	}
+
+	t.Setenv("OPENAI_API_KEY", "secret")
+	env, err := s.RunTests(context.Background(), "bash", `test -z "$OPENAI_API_KEY"`, []middleware.TestCase{{}})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if !env[0].Passed {
+		t.Errorf("expected the server environment to be hidden, got %+v", env[0])
+	}
+}
+
+func TestSandbox_HidesServerFiles(t *testing.T) {
+	s := requireSandbox(t, "bash")
+
+	// Stands in for the tournament database, which lives in the server's directory
+	secret, err := os.CreateTemp(".", "sandbox-secret-*.db")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Remove(secret.Name()) })
+	if _, err := secret.WriteString("api-key-ciphertext"); err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+	_ = secret.Close()
+	path, err := filepath.Abs(secret.Name())
+	if err != nil {
+		t.Fatalf("Abs failed: %v", err)
+	}
+
+	results, err := s.RunTests(context.Background(), "bash", "cat "+path, []middleware.TestCase{{}})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if results[0].Passed || strings.Contains(results[0].Stdout, "api-key-ciphertext") {
+		t.Errorf("expected the database to be unreadable, got %+v", results[0])
+	}
+
+	results, err = s.RunTests(context.Background(), "bash", "touch /usr/sandbox-was-here", []middleware.TestCase{{}})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if results[0].Passed {
+		t.Errorf("expected system directories to be read-only, got %+v", results[0])
+	}
+
+	results, err = s.RunTests(context.Background(), "bash", "echo ok > /tmp/x && echo ok > out && cat /tmp/x out", []middleware.TestCase{{Expected: "ok\nok"}})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if !results[0].Passed {
+		t.Errorf("expected /tmp and the program directory to be writable, got %+v", results[0])
+	}
+}
+
+func TestSandbox_LimitsProcesses(t *testing.T) {
+	s := requireSandbox(t, "python")
+	s.MaxProcesses = 32
+
+	// Stops at 1000 children so a sandbox without the limit does not take the host down
+	forkLoop := `import os, sys, time
+for n in range(1000):
+    try:
+        pid = os.fork()
+    except OSError:
+        print("limited after", n)
+        sys.exit(1)
+    if pid == 0:
+        time.sleep(30)
+        os._exit(0)
+print("forked", n + 1)`
+	results, err := s.RunTests(context.Background(), "python", forkLoop, []middleware.TestCase{{}})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if results[0].Passed || !strings.Contains(results[0].Stdout, "limited after") {
+		t.Errorf("expected the fork loop to hit the process limit and fail, got %+v", results[0])
+	}
+}
+
+func TestSandbox_UnsupportedLanguage(t *testing.T) {
+	if _, err := DefaultSandbox.RunTests(context.Background(), "cobol", "x", []middleware.TestCase{{}}); err == nil {
+		t.Error("expected error for unsupported language")
+	}
+}
+
+func TestEvaluateModelPromptPair_RunsHiddenTests(t *testing.T) {
+	s := requireSandbox(t, "python")
+
+	tests, _ := json.Marshal([]middleware.TestCase{
+		{Name: "double 2", Input: "2", Expected: "4"},
+		{Name: "double 5", Input: "5", Expected: "10"},
+	})
+	db := setupEvaluatorTestDB(t)
+	defer func() { _ = db.Close() }()
+	for _, stmt := range []string{
+		"INSERT INTO models (name, suite_id) VALUES ('model1', 1)",
+		"INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (1, 'all', 'running')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("setup failed on %q: %v", stmt, err)
+		}
+	}
+	if _, err := db.Exec("INSERT INTO prompts (text, suite_id, display_order, language, test_cases) VALUES ('Double n', 1, 0, 'python', ?)", string(tests)); err != nil {
+		t.Fatalf("failed to insert prompt: %v", err)
+	}
+	// Doubles correctly only for 2
+	if _, err := db.Exec("INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 1, ?)", "```python\nn = int(input())\nprint(n + 2)\n```"); err != nil {
+		t.Fatalf("failed to insert response: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	e.sandbox = &s
+	e.SetJudgeProviders(&stubProvider{name: "stub", err: errTest})
+
+	cost, err := e.evaluateModelPromptPair(context.Background(), 1, 1, 1)
+	if err != nil {
+		t.Fatalf("evaluateModelPromptPair failed: %v", err)
+	}
+	if cost != 0 {
+		t.Errorf("expected zero cost, got %f", cost)
+	}
+
+	var score int
+	if err := db.QueryRow("SELECT score FROM scores WHERE model_id = 1 AND prompt_id = 1").Scan(&score); err != nil {
+		t.Fatalf("failed to query score: %v", err)
+	}
+	if score != 40 {
+		t.Errorf("expected half the tests to score 40 (ties round down), got %d", score)
+	}
+
+	var judgeName, reasoning, stored string
+	if err := db.QueryRow("SELECT judge_name, judge_reasoning, test_results FROM evaluation_history WHERE prompt_id = 1").Scan(&judgeName, &reasoning, &stored); err != nil {
+		t.Fatalf("failed to query history: %v", err)
+	}
+	var results []TestResult
+	if err := json.Unmarshal([]byte(stored), &results); err != nil {
+		t.Fatalf("stored test results are not JSON: %v", err)
+	}
+	if judgeName != "checker:code:python" || !strings.HasPrefix(reasoning, "1/2 hidden tests passed") {
+		t.Errorf("unexpected history row %q / %q", judgeName, reasoning)
+	}
+	if len(results) != 2 || strings.TrimSpace(results[1].Stdout) != "7" {
+		t.Errorf("expected stdout of each test to be stored, got %+v", results)
+	}
+}
+
+func TestSandbox_RunTests_GoHarness(t *testing.T) {
+	s := requireSandbox(t, "go")
+	if testing.Short() {
+		t.Skip("compiles Go programs")
+	}
+
+	response := "```go\nfunc Max(a, b int) int {\n\tif a > b {\n\t\treturn a\n\t}\n\treturn b\n}\n```"
+	harness := "import \"fmt\"\n\nfunc main() { fmt.Println(Max(3, 7), Max(-1, -5)) }"
+	results, err := s.RunTests(context.Background(), "go", response, []middleware.TestCase{
+		{Code: harness, Expected: "7 -1"},
+		{Code: "func main() { undefined() }"},
+	})
+	if err != nil {
+		t.Fatalf("RunTests failed: %v", err)
+	}
+	if !results[0].Passed {
+		t.Errorf("expected the harness to pass, got %+v", results[0])
+	}
+	if results[1].Passed || !strings.HasPrefix(results[1].Error, "build failed") {
+		t.Errorf("expected a build failure, got %+v", results[1])
+	}
+}
@@ -0,0 +1,75 @@
+package evaluator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// systemPrompt is the system prompt a model answers under. versionID identifies the
+// library version it came from and is invalid for a model's inline system prompt.
+type systemPrompt struct {
+	content   string
+	versionID sql.NullInt64
+}
+
+// resolveSystemPrompt picks the system prompt a model is given for a prompt, in the
+// same order as middleware.ResolveSystemPrompt: the library prompt of the prompt's
+// profile, then the one assigned to the model's configuration, then the
+// configuration's inline system prompt
+func (e *Evaluator) resolveSystemPrompt(modelID, promptID int) (systemPrompt, error) {
+	var profilePrompt, modelPrompt, inline string
+	err := e.db.QueryRow(`
+		SELECT COALESCE((
+			SELECT ps.system_prompt_name
+			FROM prompts p
+			JOIN profiles pr ON pr.id = p.profile_id
+			JOIN profile_system_prompts ps ON ps.suite_id = p.suite_id AND ps.profile_name = pr.name
+			WHERE p.id = ?
+		), ''),
+		COALESCE((SELECT system_prompt_name FROM model_configs WHERE model_id = ?), ''),
+		COALESCE((SELECT system_prompt FROM model_configs WHERE model_id = ?), '')
+	`, promptID, modelID, modelID).Scan(&profilePrompt, &modelPrompt, &inline)
+	if err != nil {
+		return systemPrompt{}, fmt.Errorf("failed to resolve system prompt: %w", err)
+	}
+
+	for _, name := range []string{profilePrompt, modelPrompt} {
+		if name == "" {
+			continue
+		}
+		var sp systemPrompt
+		err := e.db.QueryRow(`
+			SELECT v.id, v.content
+			FROM system_prompt_versions v
+			JOIN system_prompts s ON s.id = v.system_prompt_id
+			WHERE s.name = ?
+			ORDER BY v.version DESC
+			LIMIT 1
+		`, name).Scan(&sp.versionID, &sp.content)
+		if err == sql.ErrNoRows {
+			continue // Assigned prompt was deleted from the library
+		}
+		if err != nil {
+			return systemPrompt{}, fmt.Errorf("failed to get system prompt %s: %w", name, err)
+		}
+		return sp, nil
+	}
+	return systemPrompt{content: inline}, nil
+}
+
+// responseSystemPrompt returns the system prompt a stored response was produced under:
+// the library version recorded when it was generated or, for responses entered by
+// hand or generated without a library prompt, what the model would be given now
+func (e *Evaluator) responseSystemPrompt(modelID, promptID int, versionID sql.NullInt64) (systemPrompt, error) {
+	if versionID.Valid {
+		sp := systemPrompt{versionID: versionID}
+		err := e.db.QueryRow("SELECT content FROM system_prompt_versions WHERE id = ?", versionID.Int64).Scan(&sp.content)
+		if err == nil {
+			return sp, nil
+		}
+		if err != sql.ErrNoRows {
+			return systemPrompt{}, fmt.Errorf("failed to get system prompt version: %w", err)
+		}
+	}
+	return e.resolveSystemPrompt(modelID, promptID)
+}
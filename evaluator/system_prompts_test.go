@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// seedSystemPrompts adds a library prompt with two versions and assigns it to model 1
+func seedSystemPrompts(t *testing.T, db *sql.DB) {
+	t.Helper()
+	_, err := db.Exec(`
+		INSERT INTO system_prompts (id, name) VALUES (1, 'programming');
+		INSERT INTO system_prompt_versions (id, system_prompt_id, version, content) VALUES
+			(1, 1, 1, 'You write Go.'), (2, 1, 2, 'You write idiomatic Go.');
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed system prompts: %v", err)
+	}
+}
+
+func TestGenerateResponse_SendsLibrarySystemPrompt(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	var sent openAIChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "hi"}}},
+		})
+	}))
+	defer server.Close()
+
+	seedSystemPrompts(t, db)
+	_, err := db.Exec(`INSERT INTO model_configs (model_id, base_url, model_name, system_prompt, system_prompt_name)
+		VALUES (1, ?, 'llama-3', 'Be terse.', 'programming')`, server.URL)
+	if err != nil {
+		t.Fatalf("failed to insert config: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	if err := e.generateResponse(context.Background(), 1, 1); err != nil {
+		t.Fatalf("generateResponse failed: %v", err)
+	}
+	if len(sent.Messages) != 2 || sent.Messages[0].Content != "You write idiomatic Go." {
+		t.Errorf("expected the latest library version instead of the inline prompt, got %+v", sent.Messages)
+	}
+
+	var versionID sql.NullInt64
+	if err := db.QueryRow("SELECT system_prompt_version_id FROM model_responses WHERE model_id = 1 AND prompt_id = 1").Scan(&versionID); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if versionID.Int64 != 2 {
+		t.Errorf("expected the response tied to version 2, got %v", versionID)
+	}
+
+	// Without a library prompt the inline one is sent and no version is recorded
+	if _, err := db.Exec("UPDATE model_configs SET system_prompt_name = ''"); err != nil {
+		t.Fatalf("failed to clear assignment: %v", err)
+	}
+	if err := e.generateResponse(context.Background(), 1, 1); err != nil {
+		t.Fatalf("generateResponse failed: %v", err)
+	}
+	_ = db.QueryRow("SELECT system_prompt_version_id FROM model_responses WHERE model_id = 1 AND prompt_id = 1").Scan(&versionID)
+	if sent.Messages[0].Content != "Be terse." || versionID.Valid {
+		t.Errorf("expected the inline prompt without a version, got %+v / %v", sent.Messages, versionID)
+	}
+}
+
+// systemPromptJudge records the system prompt it was shown
+type systemPromptJudge struct {
+	systemPrompt string
+}
+
+func (j *systemPromptJudge) Name() string { return "stub" }
+
+func (j *systemPromptJudge) Evaluate(_ context.Context, req EvaluationRequest) (*EvaluationResponse, error) {
+	j.systemPrompt = req.SystemPrompt
+	return &EvaluationResponse{Results: []JudgeResult{{Judge: "stub", Score: 80, Confidence: 1}}}, nil
+}
+
+func TestEvaluateModelPromptPair_SendsSystemPromptToJudges(t *testing.T) {
+	db := setupGeneratorTestDB(t)
+	defer func() { _ = db.Close() }()
+
+	seedSystemPrompts(t, db)
+	_, err := db.Exec(`
+		INSERT INTO model_configs (model_id, system_prompt_name) VALUES (1, 'programming');
+		INSERT INTO model_responses (model_id, prompt_id, response_text, system_prompt_version_id) VALUES (1, 1, 'hi', 1);
+		INSERT INTO model_responses (model_id, prompt_id, response_text) VALUES (1, 2, 'bye');
+	`)
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	e := newGeneratorTestEvaluator(db)
+	judge := &systemPromptJudge{}
+	e.SetJudgeProviders(judge)
+
+	// The generated response is judged against the version it was produced with, the
+	// hand-entered one against what the model would be given now
+	for promptID, want := range map[int]string{1: "You write Go.", 2: "You write idiomatic Go."} {
+		if _, err := e.evaluateModelPromptPair(context.Background(), 0, 1, promptID); err != nil {
+			t.Fatalf("evaluateModelPromptPair failed: %v", err)
+		}
+		if judge.systemPrompt != want {
+			t.Errorf("prompt %d: expected the judges to see %q, got %q", promptID, want, judge.systemPrompt)
+		}
+		var versionID sql.NullInt64
+		if err := db.QueryRow("SELECT system_prompt_version_id FROM evaluation_results WHERE prompt_id = ?", promptID).Scan(&versionID); err != nil || !versionID.Valid {
+			t.Errorf("prompt %d: expected the result tied to a system prompt version, got %v (%v)", promptID, versionID, err)
+		}
+	}
+
+	req := EvaluationRequest{Prompt: "Say hi", Response: "hi"}
+	plain, _ := RenderJudgePrompt(req)
+	withSystem := req
+	withSystem.SystemPrompt = "You write Go."
+	rendered, err := RenderJudgePrompt(withSystem)
+	if err != nil {
+		t.Fatalf("RenderJudgePrompt failed: %v", err)
+	}
+	if strings.Contains(plain, "SYSTEM PROMPT") || !strings.Contains(rendered, "**SYSTEM PROMPT GIVEN TO THE MODEL:**\nYou write Go.\n") {
+		t.Errorf("expected the system prompt block only when there is one, got:\n%s", rendered)
+	}
+	if evaluationCacheKey(req, "stub") == evaluationCacheKey(withSystem, "stub") {
+		t.Error("expected the system prompt to be part of the cache key")
+	}
+}
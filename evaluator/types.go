@@ -1,42 +1,72 @@
 package evaluator
 
-import "time"
+import (
+	"llm-tournament/middleware"
+	"time"
+)
 
 // EvaluationJob represents an evaluation job
 type EvaluationJob struct {
-	ID              int
-	SuiteID         int
-	JobType         string // 'all', 'model', 'prompt'
-	TargetID        int
-	Status          string
-	ProgressCurrent int
-	ProgressTotal   int
-	EstimatedCost   float64
-	ActualCost      float64
-	ErrorMessage    string
-	CreatedAt       time.Time
-	StartedAt       *time.Time
-	CompletedAt     *time.Time
+	ID              int        `json:"id"`
+	SuiteID         int        `json:"suite_id"`
+	JobType         string     `json:"job_type"` // 'all', 'model', 'prompt', 'generate_all', 'generate_model', 'battle_all', 'retry_failed', 'auto'
+	TargetID        int        `json:"target_id"`
+	Status          string     `json:"status"`
+	ProgressCurrent int        `json:"progress_current"`
+	ProgressTotal   int        `json:"progress_total"`
+	EstimatedCost   float64    `json:"estimated_cost"`
+	ActualCost      float64    `json:"actual_cost"`
+	ErrorMessage    string     `json:"error"`
+	ForceRefresh    bool       `json:"force_refresh"` // Bypass the evaluation cache
+	CacheHits       int        `json:"cache_hits"`
+	CacheMisses     int        `json:"cache_misses"`
+	CacheSavedUSD   float64    `json:"cache_saved_usd"`
+	JudgePanel      string     `json:"judge_panel"` // Panel judging prompts without a profile override; "" for the built-in judges
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+}
+
+// CacheHitRate is the share of the job's judged pairs served from the evaluation
+// cache, from 0 to 1
+func (j EvaluationJob) CacheHitRate() float64 {
+	if j.CacheHits+j.CacheMisses == 0 {
+		return 0
+	}
+	return float64(j.CacheHits) / float64(j.CacheHits+j.CacheMisses)
 }
 
 // JudgeResult represents the result from a single judge
 type JudgeResult struct {
-	Judge      string  `json:"judge"`
-	Score      int     `json:"score"`
-	Confidence float64 `json:"confidence"`
-	Reasoning  string  `json:"reasoning"`
-	CostUSD    float64 `json:"cost_usd"`
-	Error      string  `json:"error,omitempty"`
+	Judge      string         `json:"judge"`
+	Score      int            `json:"score"`
+	Confidence float64        `json:"confidence"`
+	Reasoning  string         `json:"reasoning"`
+	CostUSD    float64        `json:"cost_usd"`
+	Error      string         `json:"error,omitempty"`
+	Weight     float64        `json:"weight,omitempty"`   // Panel weight in the consensus; 0 counts as 1
+	Criteria   map[string]int `json:"criteria,omitempty"` // Scores per rubric criterion, when the prompt has a rubric
+}
+
+// weight is the judge's say in the consensus score
+func (r JudgeResult) weight() float64 {
+	if r.Weight <= 0 {
+		return 1
+	}
+	return r.Weight
 }
 
 // EvaluationRequest represents a request to the Python service
 type EvaluationRequest struct {
-	Prompt   string            `json:"prompt"`
-	Response string            `json:"response"`
-	Solution string            `json:"solution"`
-	Type     string            `json:"type"`
-	Judges   []string          `json:"judges"`
-	APIKeys  map[string]string `json:"api_keys"`
+	Prompt       string                       `json:"prompt"`
+	Response     string                       `json:"response"`
+	Solution     string                       `json:"solution"`
+	Type         string                       `json:"type"` // Judge mode of the prompt type: "objective" or "creative"
+	Judges       []string                     `json:"judges"`
+	APIKeys      map[string]string            `json:"api_keys"`
+	Rubric       []middleware.RubricCriterion `json:"rubric,omitempty"`        // Criteria the judges also score separately
+	Turns        []middleware.Turn            `json:"turns,omitempty"`         // Conversation before Prompt, the final user message
+	SystemPrompt string                       `json:"system_prompt,omitempty"` // System prompt the model answered under
 }
 
 // EvaluationResponse represents a response from the Python service
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"llm-tournament/evaluator"
+	"llm-tournament/middleware"
+	"llm-tournament/templates"
+	"log"
+	"net/http"
+)
+
+// AgreementHandler displays inter-judge agreement for the current suite (backward compatible wrapper)
+func AgreementHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.Agreement(w, r)
+}
+
+// AgreementJSONHandler returns inter-judge agreement as JSON (backward compatible wrapper)
+func AgreementJSONHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.AgreementJSON(w, r)
+}
+
+// agreementReport computes the agreement report for the current suite
+func (h *Handler) agreementReport() (evaluator.AgreementReport, error) {
+	scores, err := h.DataStore.ListJudgeScores(h.DataStore.GetCurrentSuiteName())
+	if err != nil {
+		return evaluator.AgreementReport{}, err
+	}
+	return evaluator.ComputeAgreement(scores), nil
+}
+
+// Agreement renders the judge agreement page
+func (h *Handler) Agreement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := h.agreementReport()
+	if err != nil {
+		log.Printf("Error computing judge agreement: %v", err)
+		http.Error(w, "Error computing judge agreement", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		PageName    string
+		Report      evaluator.AgreementReport
+		CurrentPath string
+	}{
+		PageName:    "Agreement",
+		Report:      report,
+		CurrentPath: "/agreement",
+	}
+
+	funcMap := template.FuncMap{}
+	for name, fn := range templates.FuncMap {
+		funcMap[name] = fn
+	}
+	funcMap["coefficient"] = func(v *float64) string {
+		if v == nil {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.2f", *v)
+	}
+
+	err = h.Renderer.Render(w, "agreement.html", funcMap, data, "templates/agreement.html", "templates/nav.html")
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// AgreementJSON returns the judge agreement report for the current suite
+func (h *Handler) AgreementJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := h.agreementReport()
+	if err != nil {
+		log.Printf("Error computing judge agreement: %v", err)
+		http.Error(w, "Error computing judge agreement", http.StatusInternalServerError)
+		return
+	}
+
+	middleware.RespondJSON(w, report)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"llm-tournament/evaluator"
+	"llm-tournament/middleware"
+	"llm-tournament/testutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var agreementTestScores = []middleware.JudgeScore{
+	{Judge: "claude", Score: 80, ModelID: 1, Model: "alpha", PromptID: 1, Profile: "Math"},
+	{Judge: "gpt", Score: 20, ModelID: 1, Model: "alpha", PromptID: 1, Profile: "Math"},
+	{Judge: "claude", Score: 60, ModelID: 2, Model: "beta", PromptID: 1, Profile: "Math"},
+	{Judge: "gpt", Score: 60, ModelID: 2, Model: "beta", PromptID: 1, Profile: "Math"},
+}
+
+func TestAgreement_GET_RendersReport(t *testing.T) {
+	renderer := &testutil.MockRenderer{}
+	handler := NewHandlerWithDeps(&MockDataStore{JudgeScores: agreementTestScores}, renderer)
+
+	rr := httptest.NewRecorder()
+	handler.Agreement(rr, httptest.NewRequest(http.MethodGet, "/agreement", nil))
+
+	if len(renderer.RenderCalls) != 1 || renderer.RenderCalls[0].Name != "agreement.html" {
+		t.Fatalf("expected agreement.html to be rendered, got %+v", renderer.RenderCalls)
+	}
+	report := reflect.ValueOf(renderer.RenderCalls[0].Data).FieldByName("Report").Interface().(evaluator.AgreementReport)
+	if report.Cells != 2 || len(report.Pairs) != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestAgreementJSON(t *testing.T) {
+	handler := NewHandlerWithDeps(&MockDataStore{JudgeScores: agreementTestScores}, &testutil.MockRenderer{})
+
+	rr := httptest.NewRecorder()
+	handler.AgreementJSON(rr, httptest.NewRequest(http.MethodGet, "/agreement/json", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var report evaluator.AgreementReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(report.Bias) != 2 || report.Bias[0].Judge != "claude" || report.Bias[0].MeanBias != 30 {
+		t.Errorf("unexpected bias: %+v", report.Bias)
+	}
+	if len(report.Profiles) != 1 || len(report.Profiles[0].HotSpots) != 1 || report.Profiles[0].HotSpots[0].Model != "alpha" {
+		t.Errorf("unexpected hot spots: %+v", report.Profiles)
+	}
+}
+
+func TestAgreement_MethodNotAllowed(t *testing.T) {
+	handler := NewHandlerWithDeps(&MockDataStore{}, &testutil.MockRenderer{})
+
+	for _, fn := range []http.HandlerFunc{handler.Agreement, handler.AgreementJSON} {
+		rr := httptest.NewRecorder()
+		fn(rr, httptest.NewRequest(http.MethodPost, "/agreement", nil))
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+		}
+	}
+}
+
+func TestAgreementTemplate_Renders(t *testing.T) {
+	restoreDir := changeToProjectRootStats(t)
+	defer restoreDir()
+
+	cleanup := setupStatsTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePromptSuite("default", []middleware.Prompt{{Text: "Which is larger, 9.9 or 9.11?"}}); err != nil {
+		t.Fatalf("failed to write prompts: %v", err)
+	}
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"alpha": {Scores: []int{0}}}); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+	_, err := middleware.GetDB().Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all');
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, judge_confidence)
+		SELECT 1, m.id, p.id, 'claude', 100, 1 FROM models m, prompts p;
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, judge_confidence)
+		SELECT 1, m.id, p.id, 'gpt', 20, 1 FROM models m, prompts p;
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed history: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	AgreementHandler(rr, httptest.NewRequest(http.MethodGet, "/agreement", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "claude / gpt") || !strings.Contains(body, "(no profile)") || !strings.Contains(body, "80.0") {
+		t.Error("expected the agreement page to show judge pairs, bias and profiles")
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"llm-tournament/middleware"
+	"llm-tournament/templates"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// BattleHandler handles the pairwise battle page (backward compatible wrapper)
+func BattleHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.Battle(w, r)
+}
+
+// Battle shows two anonymised responses to the same prompt (GET) and records the human verdict (POST)
+func (h *Handler) Battle(w http.ResponseWriter, r *http.Request) {
+	suiteName := h.DataStore.GetCurrentSuiteName()
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			middleware.HandleFormError(w, err)
+			return
+		}
+
+		promptID, _ := strconv.Atoi(r.FormValue("prompt_id"))
+		battle := middleware.Battle{
+			PromptID: promptID,
+			ModelA:   r.FormValue("model_a"),
+			ModelB:   r.FormValue("model_b"),
+			Outcome:  r.FormValue("outcome"),
+			Judge:    middleware.BattleJudgeHuman,
+		}
+		if battle.ModelA == "" || battle.ModelB == "" || !middleware.IsBattleOutcome(battle.Outcome) {
+			http.Error(w, "Two models and an outcome (a, b or tie) are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.DataStore.RecordBattle(suiteName, battle); err != nil {
+			log.Printf("Error recording battle: %v", err)
+			http.Error(w, "Error recording battle", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/battle", http.StatusSeeOther)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	candidate, err := h.DataStore.GetBattleCandidate(suiteName)
+	if err != nil {
+		log.Printf("Error picking battle: %v", err)
+		http.Error(w, "Error picking battle", http.StatusInternalServerError)
+		return
+	}
+
+	battles, err := h.DataStore.ListBattles(suiteName)
+	if err != nil {
+		log.Printf("Error listing battles: %v", err)
+		battles = nil
+	}
+	humanBattles := 0
+	for _, b := range battles {
+		if b.Judge == middleware.BattleJudgeHuman {
+			humanBattles++
+		}
+	}
+
+	data := struct {
+		PageName     string
+		Candidate    *middleware.BattleCandidate
+		TotalBattles int
+		HumanBattles int
+		CurrentPath  string
+	}{
+		PageName:     "Battle",
+		Candidate:    candidate,
+		TotalBattles: len(battles),
+		HumanBattles: humanBattles,
+		CurrentPath:  "/battle",
+	}
+
+	err = h.Renderer.Render(w, "battle.html", templates.FuncMap, data, "templates/battle.html", "templates/nav.html")
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// JudgeBattlesHandler queues a job in which the judges decide every pairwise battle in the current suite
+func JudgeBattlesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	suiteID, err := middleware.GetCurrentSuiteID()
+	if err != nil {
+		log.Printf("Error getting current suite: %v", err)
+		http.Error(w, "Failed to get current suite", http.StatusInternalServerError)
+		return
+	}
+
+	jobID, err := globalEvaluator.RunJudgeBattles(suiteID)
+	if err != nil {
+		log.Printf("Error starting judge battles: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to start judge battles: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"success": true,
+		"job_id":  jobID,
+		"message": "Judge battles started",
+	})
+}
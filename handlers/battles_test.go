@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"llm-tournament/middleware"
+	"llm-tournament/testutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBattle_POST_RecordsHumanVerdict(t *testing.T) {
+	ds := &MockDataStore{}
+	handler := NewHandlerWithDeps(ds, &testutil.MockRenderer{})
+
+	form := url.Values{"prompt_id": {"7"}, "model_a": {"alpha"}, "model_b": {"beta"}, "outcome": {"b"}}
+	req := httptest.NewRequest(http.MethodPost, "/battle", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	handler.Battle(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if len(ds.Battles) != 1 {
+		t.Fatalf("expected 1 battle, got %d", len(ds.Battles))
+	}
+	b := ds.Battles[0]
+	if b.PromptID != 7 || b.ModelA != "alpha" || b.ModelB != "beta" || b.Outcome != middleware.BattleWinB || b.Judge != middleware.BattleJudgeHuman {
+		t.Errorf("unexpected battle: %+v", b)
+	}
+}
+
+func TestBattle_POST_InvalidOutcome(t *testing.T) {
+	ds := &MockDataStore{}
+	handler := NewHandlerWithDeps(ds, &testutil.MockRenderer{})
+
+	form := url.Values{"model_a": {"alpha"}, "model_b": {"beta"}, "outcome": {"draw"}}
+	req := httptest.NewRequest(http.MethodPost, "/battle", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	handler.Battle(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if len(ds.Battles) != 0 {
+		t.Error("no battle should be recorded")
+	}
+}
+
+func TestBattle_GET_RendersCandidate(t *testing.T) {
+	ds := &MockDataStore{
+		Candidate: &middleware.BattleCandidate{PromptID: 1, Prompt: "Q", ModelA: "alpha", ModelB: "beta"},
+		Battles: []middleware.Battle{
+			{ModelA: "alpha", ModelB: "beta", Outcome: middleware.BattleWinA, Judge: middleware.BattleJudgeHuman},
+			{ModelA: "alpha", ModelB: "beta", Outcome: middleware.BattleTie, Judge: "gpt_5.2"},
+		},
+	}
+	renderer := &testutil.MockRenderer{}
+	handler := NewHandlerWithDeps(ds, renderer)
+
+	rr := httptest.NewRecorder()
+	handler.Battle(rr, httptest.NewRequest(http.MethodGet, "/battle", nil))
+
+	if len(renderer.RenderCalls) != 1 || renderer.RenderCalls[0].Name != "battle.html" {
+		t.Fatalf("expected battle.html to be rendered, got %+v", renderer.RenderCalls)
+	}
+	data := reflect.ValueOf(renderer.RenderCalls[0].Data)
+	if data.FieldByName("TotalBattles").Int() != 2 || data.FieldByName("HumanBattles").Int() != 1 {
+		t.Errorf("unexpected battle counts: %v / %v", data.FieldByName("HumanBattles"), data.FieldByName("TotalBattles"))
+	}
+	if data.FieldByName("Candidate").IsNil() {
+		t.Error("expected candidate to be passed to the template")
+	}
+}
+
+func TestBattle_MethodNotAllowed(t *testing.T) {
+	handler := NewHandlerWithDeps(&MockDataStore{}, &testutil.MockRenderer{})
+	rr := httptest.NewRecorder()
+	handler.Battle(rr, httptest.NewRequest(http.MethodDelete, "/battle", nil))
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+func TestJudgeBattlesHandler_MethodNotAllowed(t *testing.T) {
+	rr := httptest.NewRecorder()
+	JudgeBattlesHandler(rr, httptest.NewRequest(http.MethodGet, "/battle/judge", nil))
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+	}
+}
+
+func TestStats_IncludesPairwiseRatings(t *testing.T) {
+	cleanup := setupStatsTestDB(t)
+	defer cleanup()
+
+	ds := &MockDataStore{
+		Results: map[string]middleware.Result{"alpha": {Scores: []int{100}}, "beta": {Scores: []int{20}}},
+		Battles: []middleware.Battle{
+			{ModelA: "alpha", ModelB: "beta", Outcome: middleware.BattleWinA},
+			{ModelA: "beta", ModelB: "alpha", Outcome: middleware.BattleWinB},
+		},
+	}
+	renderer := &testutil.MockRenderer{}
+	handler := NewHandlerWithDeps(ds, renderer)
+
+	rr := httptest.NewRecorder()
+	handler.Stats(rr, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	if len(renderer.RenderCalls) != 1 {
+		t.Fatalf("expected 1 render call, got %d", len(renderer.RenderCalls))
+	}
+	data := reflect.ValueOf(renderer.RenderCalls[0].Data)
+	if data.FieldByName("BattleCount").Int() != 2 {
+		t.Errorf("expected 2 battles, got %v", data.FieldByName("BattleCount"))
+	}
+	for _, field := range []string{"EloRatings", "BradleyTerry"} {
+		ratings := data.FieldByName(field)
+		if ratings.Len() != 2 || ratings.Index(0).FieldByName("Model").String() != "alpha" {
+			t.Errorf("expected alpha to lead %s, got %v", field, ratings)
+		}
+	}
+}
+
+func TestBattleAndStatsTemplates_RenderWithBattles(t *testing.T) {
+	restoreDir := changeToProjectRootStats(t)
+	defer restoreDir()
+
+	cleanup := setupStatsTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePromptSuite("default", []middleware.Prompt{{Text: "Which is larger, 9.9 or 9.11?"}}); err != nil {
+		t.Fatalf("failed to write prompts: %v", err)
+	}
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"alpha": {Scores: []int{100}}, "beta": {Scores: []int{0}}}); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+	_, err := middleware.GetDB().Exec(`INSERT INTO model_responses (model_id, prompt_id, response_text)
+		SELECT m.id, p.id, 'answer from ' || m.name FROM models m, prompts p`)
+	if err != nil {
+		t.Fatalf("failed to insert responses: %v", err)
+	}
+	if err := middleware.RecordBattle("default", middleware.Battle{ModelA: "alpha", ModelB: "beta", Outcome: middleware.BattleWinA}); err != nil {
+		t.Fatalf("RecordBattle failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	BattleHandler(rr, httptest.NewRequest(http.MethodGet, "/battle", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "9.9 or 9.11") || !strings.Contains(rr.Body.String(), "Response B") {
+		t.Error("expected the battle page to show the prompt and both responses")
+	}
+
+	rr = httptest.NewRecorder()
+	StatsHandler(rr, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "Bradley-Terry") {
+		t.Error("expected the stats page to show the pairwise leaderboard")
+	}
+}
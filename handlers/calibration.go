@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"llm-tournament/evaluator"
+	"llm-tournament/middleware"
+	"llm-tournament/templates"
+	"log"
+	"net/http"
+)
+
+// CalibrationHandler displays judge calibration against manual scores (backward compatible wrapper)
+func CalibrationHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.Calibration(w, r)
+}
+
+// CalibrationJSONHandler returns judge calibration as JSON (backward compatible wrapper)
+func CalibrationJSONHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.CalibrationJSON(w, r)
+}
+
+// calibrationReport builds the calibration report for the current suite
+func (h *Handler) calibrationReport() (evaluator.CalibrationReport, error) {
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	samples, err := h.DataStore.ListCalibrationSamples(suiteName)
+	if err != nil {
+		return evaluator.CalibrationReport{}, err
+	}
+	method, _ := h.DataStore.GetSuiteSetting(suiteName, middleware.SuiteSettingCalibration)
+	return evaluator.BuildCalibrationReport(samples, method), nil
+}
+
+// Calibration renders the judge calibration page
+func (h *Handler) Calibration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := h.calibrationReport()
+	if err != nil {
+		log.Printf("Error computing judge calibration: %v", err)
+		http.Error(w, "Error computing judge calibration", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		PageName    string
+		Report      evaluator.CalibrationReport
+		CurrentPath string
+	}{
+		PageName:    "Calibration",
+		Report:      report,
+		CurrentPath: "/calibration",
+	}
+
+	funcMap := template.FuncMap{}
+	for name, fn := range templates.FuncMap {
+		funcMap[name] = fn
+	}
+	funcMap["json"] = func(v interface{}) template.JS {
+		a, _ := json.Marshal(v)
+		return template.JS(a)
+	}
+
+	err = h.Renderer.Render(w, "calibration.html", funcMap, data, "templates/calibration.html", "templates/nav.html")
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CalibrationJSON returns the judge calibration report for the current suite
+func (h *Handler) CalibrationJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := h.calibrationReport()
+	if err != nil {
+		log.Printf("Error computing judge calibration: %v", err)
+		http.Error(w, "Error computing judge calibration", http.StatusInternalServerError)
+		return
+	}
+
+	middleware.RespondJSON(w, report)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"llm-tournament/evaluator"
+	"llm-tournament/middleware"
+	"llm-tournament/testutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var calibrationTestSamples = []middleware.CalibrationSample{
+	{Judge: "claude", JudgeScore: 80, GoldScore: 60, PromptType: "objective"},
+	{Judge: "claude", JudgeScore: 100, GoldScore: 80, PromptType: "creative"},
+	{Judge: "gpt", JudgeScore: 40, GoldScore: 60, PromptType: "objective"},
+}
+
+func TestCalibration_GET_RendersReport(t *testing.T) {
+	ds := &MockDataStore{
+		Samples:       calibrationTestSamples,
+		SuiteSettings: map[string]string{middleware.SuiteSettingCalibration: middleware.CalibrationLinear},
+	}
+	renderer := &testutil.MockRenderer{}
+	handler := NewHandlerWithDeps(ds, renderer)
+
+	rr := httptest.NewRecorder()
+	handler.Calibration(rr, httptest.NewRequest(http.MethodGet, "/calibration", nil))
+
+	if len(renderer.RenderCalls) != 1 || renderer.RenderCalls[0].Name != "calibration.html" {
+		t.Fatalf("expected calibration.html to be rendered, got %+v", renderer.RenderCalls)
+	}
+	report := reflect.ValueOf(renderer.RenderCalls[0].Data).FieldByName("Report").Interface().(evaluator.CalibrationReport)
+	if report.Method != middleware.CalibrationLinear || len(report.Judges) != 2 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestCalibrationJSON(t *testing.T) {
+	handler := NewHandlerWithDeps(&MockDataStore{Samples: calibrationTestSamples}, &testutil.MockRenderer{})
+
+	rr := httptest.NewRecorder()
+	handler.CalibrationJSON(rr, httptest.NewRequest(http.MethodGet, "/calibration/json", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var report evaluator.CalibrationReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if report.Judges[0].Judge != "claude" || report.Judges[0].Bias != 20 || report.Judges[1].Bias != -20 {
+		t.Errorf("unexpected judge calibration: %+v", report.Judges)
+	}
+	// claude: overall, creative, objective; gpt: overall, objective
+	if len(report.Curves) != 5 {
+		t.Errorf("expected 5 curves, got %d", len(report.Curves))
+	}
+}
+
+func TestCalibration_MethodNotAllowed(t *testing.T) {
+	handler := NewHandlerWithDeps(&MockDataStore{}, &testutil.MockRenderer{})
+
+	for _, fn := range []http.HandlerFunc{handler.Calibration, handler.CalibrationJSON} {
+		rr := httptest.NewRecorder()
+		fn(rr, httptest.NewRequest(http.MethodPost, "/calibration", nil))
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+		}
+	}
+}
+
+func TestEvaluateResult_POST_SavesGoldScore(t *testing.T) {
+	ds := &MockDataStore{Prompts: []middleware.Prompt{{Text: "Q1"}, {Text: "Q2"}}}
+	handler := &Handler{DataStore: ds, Renderer: &MockRenderer{}}
+
+	form := url.Values{"score": {"60"}}
+	req := httptest.NewRequest("POST", "/evaluate?model=alpha&prompt=1", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	handler.EvaluateResultHandler(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if got, ok := ds.GoldScores["alpha/1"]; !ok || got != 60 {
+		t.Errorf("expected gold score 60 for alpha/1, got %v", ds.GoldScores)
+	}
+}
+
+func TestEvaluateResult_POST_SnapsToSuiteScale(t *testing.T) {
+	ds := &MockDataStore{
+		Prompts:       []middleware.Prompt{{Text: "Q1"}},
+		SuiteSettings: map[string]string{middleware.SuiteSettingScoreScale: middleware.ScoreScaleLikert},
+	}
+	handler := &Handler{DataStore: ds, Renderer: &MockRenderer{}}
+
+	form := url.Values{"score": {"7"}}
+	req := httptest.NewRequest("POST", "/evaluate?model=alpha&prompt=0", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	handler.EvaluateResultHandler(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if got := ds.Results["alpha"].Scores; len(got) != 1 || got[0] != 5 {
+		t.Errorf("expected the score clamped to 5 on the likert scale, got %v", got)
+	}
+	// Gold labels stay on the judges' 0-100 range
+	if got := ds.GoldScores["alpha/0"]; got != 100 {
+		t.Errorf("expected gold score 100, got %d", got)
+	}
+}
+
+func TestUpdateSettings_JudgeCalibration(t *testing.T) {
+	mock := &MockDataStore{SuiteSettings: map[string]string{middleware.SuiteSettingCalibration: middleware.CalibrationLinear}}
+	h := &Handler{DataStore: mock, Renderer: &MockRenderer{}}
+
+	post := func(form url.Values) int {
+		req := httptest.NewRequest("POST", "/settings/update", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		h.UpdateSettings(rr, req)
+		return rr.Code
+	}
+
+	// Forms without the field leave the setting alone
+	if code := post(url.Values{"python_service_url": {"http://localhost:8001"}}); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if got := mock.SuiteSettings[middleware.SuiteSettingCalibration]; got != middleware.CalibrationLinear {
+		t.Errorf("expected calibration to stay linear, got %q", got)
+	}
+
+	// Choosing Off clears it
+	if code := post(url.Values{"judge_calibration": {""}}); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if got := mock.SuiteSettings[middleware.SuiteSettingCalibration]; got != "" {
+		t.Errorf("expected calibration off, got %q", got)
+	}
+
+	if code := post(url.Values{"judge_calibration": {"platt"}}); code != http.StatusBadRequest {
+		t.Errorf("expected status %d for unknown method, got %d", http.StatusBadRequest, code)
+	}
+}
+
+func TestCalibrationTemplate_Renders(t *testing.T) {
+	restoreDir := changeToProjectRootStats(t)
+	defer restoreDir()
+
+	cleanup := setupStatsTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePromptSuite("default", []middleware.Prompt{{Text: "Which is larger, 9.9 or 9.11?"}}); err != nil {
+		t.Fatalf("failed to write prompts: %v", err)
+	}
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"alpha": {Scores: []int{0}}}); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+	if err := middleware.SaveGoldScore("default", "alpha", 0, 20); err != nil {
+		t.Fatalf("SaveGoldScore failed: %v", err)
+	}
+	_, err := middleware.GetDB().Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all');
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, judge_confidence)
+		SELECT 1, m.id, p.id, 'claude', 100, 1 FROM models m, prompts p;
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed history: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	CalibrationHandler(rr, httptest.NewRequest(http.MethodGet, "/calibration", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "Error Against Gold") || !strings.Contains(body, "claude") || !strings.Contains(body, `"prompt_type":"objective"`) {
+		t.Error("expected the calibration page to show the judge table and curve data")
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"html/template"
+	"llm-tournament/middleware"
+	"llm-tournament/templates"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultCostDays is how many days the cost dashboard covers unless ?days= says otherwise
+const defaultCostDays = 30
+
+// CostsHandler displays judge spend for the current suite (backward compatible wrapper)
+func CostsHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.Costs(w, r)
+}
+
+// CostsJSONHandler returns judge spend as JSON (backward compatible wrapper)
+func CostsJSONHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.CostsJSON(w, r)
+}
+
+// costSummary reads the spend covered by the request's ?days= window
+func (h *Handler) costSummary(r *http.Request) (middleware.CostSummary, int, error) {
+	days := defaultCostDays
+	if n, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && n > 0 && n <= 366 {
+		days = n
+	}
+	since := time.Now().AddDate(0, 0, 1-days)
+	summary, err := h.DataStore.GetCostSummary(h.DataStore.GetCurrentSuiteName(), since)
+	return summary, days, err
+}
+
+// Costs renders the cost dashboard
+func (h *Handler) Costs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	summary, days, err := h.costSummary(r)
+	if err != nil {
+		log.Printf("Error loading cost summary: %v", err)
+		http.Error(w, "Error loading costs", http.StatusInternalServerError)
+		return
+	}
+
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	jobBudget, _ := h.DataStore.GetSetting("budget_job_usd")
+	monthlyBudget, _ := h.DataStore.GetSuiteSetting(suiteName, middleware.SuiteSettingMonthlyBudget)
+	threshold, _ := h.DataStore.GetSetting("cost_alert_threshold_usd")
+
+	data := struct {
+		PageName      string
+		Summary       middleware.CostSummary
+		Days          int
+		DayOptions    []int
+		SuiteName     string
+		JobBudget     string
+		MonthlyBudget string
+		Threshold     string
+		CurrentPath   string
+	}{
+		PageName:      "Costs",
+		Summary:       summary,
+		Days:          days,
+		DayOptions:    []int{7, 30, 90, 365},
+		SuiteName:     suiteName,
+		JobBudget:     jobBudget,
+		MonthlyBudget: monthlyBudget,
+		Threshold:     threshold,
+		CurrentPath:   "/costs",
+	}
+
+	funcMap := template.FuncMap{}
+	for name, fn := range templates.FuncMap {
+		funcMap[name] = fn
+	}
+
+	err = h.Renderer.Render(w, "costs.html", funcMap, data, "templates/costs.html", "templates/nav.html")
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CostsJSON returns the cost summary for the current suite
+func (h *Handler) CostsJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	summary, _, err := h.costSummary(r)
+	if err != nil {
+		log.Printf("Error loading cost summary: %v", err)
+		http.Error(w, "Error loading costs", http.StatusInternalServerError)
+		return
+	}
+
+	middleware.RespondJSON(w, summary)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"llm-tournament/middleware"
+	"llm-tournament/testutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var costsTestSummary = middleware.CostSummary{
+	Since:          "2026-10-01",
+	SuiteTotalUSD:  1.5,
+	MonthToDateUSD: 1.5,
+	BySuite:        []middleware.CostRow{{Label: "default", CostUSD: 1.5, Evaluations: 30}},
+	ByDay:          []middleware.CostRow{{Label: "2026-10-02", CostUSD: 1, Evaluations: 20}, {Label: "2026-10-01", CostUSD: 0.5, Evaluations: 10}},
+	ByJudge:        []middleware.CostRow{{Label: "claude", CostUSD: 1.2, Evaluations: 30}},
+}
+
+// costsDataStore records the window the handler asked for
+type costsDataStore struct {
+	MockDataStore
+	since time.Time
+}
+
+func (c *costsDataStore) GetCostSummary(suiteName string, since time.Time) (middleware.CostSummary, error) {
+	c.since = since
+	return c.Costs, nil
+}
+
+func TestCosts_GET_RendersSummary(t *testing.T) {
+	renderer := &testutil.MockRenderer{}
+	mock := &MockDataStore{
+		Costs:         costsTestSummary,
+		Settings:      map[string]string{"budget_job_usd": "5"},
+		SuiteSettings: map[string]string{middleware.SuiteSettingMonthlyBudget: "50"},
+	}
+	handler := NewHandlerWithDeps(mock, renderer)
+
+	rr := httptest.NewRecorder()
+	handler.Costs(rr, httptest.NewRequest(http.MethodGet, "/costs", nil))
+
+	if len(renderer.RenderCalls) != 1 || renderer.RenderCalls[0].Name != "costs.html" {
+		t.Fatalf("expected costs.html to be rendered, got %+v", renderer.RenderCalls)
+	}
+	data := reflect.ValueOf(renderer.RenderCalls[0].Data)
+	if summary := data.FieldByName("Summary").Interface().(middleware.CostSummary); len(summary.ByDay) != 2 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if data.FieldByName("Days").Int() != defaultCostDays {
+		t.Errorf("expected the default window of %d days, got %d", defaultCostDays, data.FieldByName("Days").Int())
+	}
+	if data.FieldByName("JobBudget").String() != "5" || data.FieldByName("MonthlyBudget").String() != "50" {
+		t.Errorf("expected both budgets on the page, got %q and %q", data.FieldByName("JobBudget").String(), data.FieldByName("MonthlyBudget").String())
+	}
+}
+
+func TestCostsJSON_Days(t *testing.T) {
+	store := &costsDataStore{MockDataStore: MockDataStore{Costs: costsTestSummary}}
+	handler := NewHandlerWithDeps(store, &testutil.MockRenderer{})
+
+	rr := httptest.NewRecorder()
+	handler.CostsJSON(rr, httptest.NewRequest(http.MethodGet, "/costs/json?days=7", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var summary middleware.CostSummary
+	if err := json.Unmarshal(rr.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(summary.ByJudge) != 1 || summary.ByJudge[0].Label != "claude" {
+		t.Errorf("unexpected judges: %+v", summary.ByJudge)
+	}
+	if want := time.Now().AddDate(0, 0, -6).Format(time.DateOnly); store.since.Format(time.DateOnly) != want {
+		t.Errorf("expected 7 days to start on %s, got %s", want, store.since.Format(time.DateOnly))
+	}
+}
+
+func TestCosts_MethodNotAllowed(t *testing.T) {
+	handler := NewHandlerWithDeps(&MockDataStore{}, &testutil.MockRenderer{})
+
+	for _, fn := range []http.HandlerFunc{handler.Costs, handler.CostsJSON} {
+		rr := httptest.NewRecorder()
+		fn(rr, httptest.NewRequest(http.MethodPost, "/costs", nil))
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, rr.Code)
+		}
+	}
+}
+
+func TestUpdateSettings_Budgets(t *testing.T) {
+	mock := &MockDataStore{
+		Settings:      map[string]string{"budget_job_usd": "5"},
+		SuiteSettings: map[string]string{middleware.SuiteSettingMonthlyBudget: "50"},
+	}
+	h := &Handler{DataStore: mock, Renderer: &MockRenderer{}}
+
+	post := func(form url.Values) int {
+		req := httptest.NewRequest("POST", "/settings/update", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		h.UpdateSettings(rr, req)
+		return rr.Code
+	}
+
+	// Forms without the fields leave the budgets alone
+	if code := post(url.Values{"python_service_url": {"http://localhost:8001"}}); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if mock.Settings["budget_job_usd"] != "5" || mock.SuiteSettings[middleware.SuiteSettingMonthlyBudget] != "50" {
+		t.Errorf("expected budgets unchanged, got %q and %q", mock.Settings["budget_job_usd"], mock.SuiteSettings[middleware.SuiteSettingMonthlyBudget])
+	}
+
+	// Empty values remove the caps
+	if code := post(url.Values{"budget_job_usd": {"2.5"}, "monthly_budget_usd": {""}}); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if mock.Settings["budget_job_usd"] != "2.5" || mock.SuiteSettings[middleware.SuiteSettingMonthlyBudget] != "" {
+		t.Errorf("expected job budget 2.5 and no monthly budget, got %q and %q", mock.Settings["budget_job_usd"], mock.SuiteSettings[middleware.SuiteSettingMonthlyBudget])
+	}
+
+	for _, bad := range []string{"-1", "lots"} {
+		if code := post(url.Values{"monthly_budget_usd": {bad}}); code != http.StatusBadRequest {
+			t.Errorf("expected status %d for budget %q, got %d", http.StatusBadRequest, bad, code)
+		}
+	}
+}
+
+func TestCostsTemplate_Renders(t *testing.T) {
+	restoreDir := changeToProjectRootStats(t)
+	defer restoreDir()
+
+	cleanup := setupStatsTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePromptSuite("default", []middleware.Prompt{{Text: "Which is larger, 9.9 or 9.11?"}}); err != nil {
+		t.Fatalf("failed to write prompts: %v", err)
+	}
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"alpha": {Scores: []int{0}}}); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+	_, err := middleware.GetDB().Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all');
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, cost_usd)
+		SELECT 1, m.id, p.id, 'claude', 100, 0.125 FROM models m, prompts p;
+		INSERT INTO cost_tracking (suite_id, date, total_cost_usd, evaluation_count) VALUES (1, ?, 0.125, 1);
+	`, time.Now().Format(time.DateOnly))
+	if err != nil {
+		t.Fatalf("failed to seed spend: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	CostsHandler(rr, httptest.NewRequest(http.MethodGet, "/costs", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "claude") || !strings.Contains(body, time.Now().Format(time.DateOnly)) || !strings.Contains(body, "0.1250") {
+		t.Error("expected the costs page to show spend per judge and per day")
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"llm-tournament/middleware"
+	"sort"
+)
+
+// criterionRow holds one model's formatted scores, one per column; "" marks a
+// column the judges never scored
+type criterionRow struct {
+	Model  string
+	Scores []string
+}
+
+// criterionBreakdown is each model's mean score on every rubric criterion of a suite
+type criterionBreakdown struct {
+	Criteria []string // In order of first appearance
+	Rows     []criterionRow
+}
+
+// newCriterionBreakdown averages the criterion scores of each model over the prompts
+// that were judged on the criterion. Models are listed by name.
+func newCriterionBreakdown(scores []middleware.CriterionScore) criterionBreakdown {
+	type total struct {
+		sum   float64
+		count int
+	}
+	var b criterionBreakdown
+	seen := make(map[string]bool)
+	totals := make(map[string]map[string]*total)
+	for _, s := range scores {
+		if !seen[s.Criterion] {
+			seen[s.Criterion] = true
+			b.Criteria = append(b.Criteria, s.Criterion)
+		}
+		if totals[s.Model] == nil {
+			totals[s.Model] = make(map[string]*total)
+		}
+		t := totals[s.Model][s.Criterion]
+		if t == nil {
+			t = &total{}
+			totals[s.Model][s.Criterion] = t
+		}
+		t.sum += float64(s.Score)
+		t.count++
+	}
+
+	models := make([]string, 0, len(totals))
+	for model := range totals {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		row := criterionRow{Model: model, Scores: make([]string, len(b.Criteria))}
+		for i, criterion := range b.Criteria {
+			if t := totals[model][criterion]; t != nil {
+				row.Scores[i] = fmt.Sprintf("%.0f", t.sum/float64(t.count))
+			}
+		}
+		b.Rows = append(b.Rows, row)
+	}
+	return b
+}
+
+// criterionCells lays one criterion's scores out like the results grid: a row per
+// model and a column per prompt
+func criterionCells(scores []middleware.CriterionScore, criterion string, prompts []string, models []string) []criterionRow {
+	column := make(map[string]int, len(prompts))
+	for i, text := range prompts {
+		column[text] = i
+	}
+	cells := make(map[string][]string, len(models))
+	for _, model := range models {
+		cells[model] = make([]string, len(prompts))
+	}
+	for _, s := range scores {
+		i, ok := column[s.Prompt]
+		if s.Criterion != criterion || !ok || cells[s.Model] == nil {
+			continue
+		}
+		cells[s.Model][i] = fmt.Sprint(s.Score)
+	}
+
+	rows := make([]criterionRow, 0, len(models))
+	for _, model := range models {
+		rows = append(rows, criterionRow{Model: model, Scores: cells[model]})
+	}
+	return rows
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"llm-tournament/middleware"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testCriterionScores = []middleware.CriterionScore{
+	{Model: "m2", Prompt: "p1", Criterion: "correctness", Score: 80},
+	{Model: "m2", Prompt: "p1", Criterion: "clarity", Score: 40},
+	{Model: "m1", Prompt: "p1", Criterion: "correctness", Score: 100},
+	{Model: "m1", Prompt: "p2", Criterion: "correctness", Score: 41},
+}
+
+func TestNewCriterionBreakdown(t *testing.T) {
+	b := newCriterionBreakdown(testCriterionScores)
+	if !reflect.DeepEqual(b.Criteria, []string{"correctness", "clarity"}) {
+		t.Errorf("expected criteria in order of appearance, got %v", b.Criteria)
+	}
+	want := []criterionRow{
+		{Model: "m1", Scores: []string{"70", ""}},
+		{Model: "m2", Scores: []string{"80", "40"}},
+	}
+	if !reflect.DeepEqual(b.Rows, want) {
+		t.Errorf("expected %+v, got %+v", want, b.Rows)
+	}
+}
+
+func TestCriterionCells(t *testing.T) {
+	rows := criterionCells(testCriterionScores, "correctness", []string{"p1", "p2"}, []string{"m2", "m1"})
+	want := []criterionRow{
+		{Model: "m2", Scores: []string{"80", ""}},
+		{Model: "m1", Scores: []string{"100", "41"}},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("expected %+v, got %+v", want, rows)
+	}
+}
+
+func TestCriterionBreakdownTemplates_Render(t *testing.T) {
+	defer changeToProjectRootStats(t)()
+	defer setupStatsTestDB(t)()
+
+	rubric := []middleware.RubricCriterion{{Name: "correctness", Weight: 3}, {Name: "clarity", Weight: 1}}
+	if err := middleware.WritePromptSuite("default", []middleware.Prompt{{Text: "p1", Rubric: rubric}}); err != nil {
+		t.Fatalf("failed to write prompts: %v", err)
+	}
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"m1": {Scores: []int{80}}}); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+	_, err := middleware.GetDB().Exec(`
+		INSERT INTO criterion_scores (model_id, prompt_id, criterion, score, weight)
+		SELECT m.id, p.id, 'correctness', 90, 3 FROM models m, prompts p
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed criterion scores: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	StatsHandler(rr, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "By Criterion") || !strings.Contains(rr.Body.String(), "<th>correctness</th>") {
+		t.Errorf("expected the stats page to break scores down by criterion, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	ResultsHandler(rr, httptest.NewRequest(http.MethodGet, "/results?criterion=correctness", nil))
+	body := rr.Body.String()
+	for _, want := range []string{`id="criterion-breakdown"`, "correctness by prompt", `<option value="correctness" selected>`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the results page to contain %q", want)
+		}
+	}
+}
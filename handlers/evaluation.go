@@ -9,6 +9,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 var globalEvaluator *evaluator.Evaluator
@@ -20,9 +21,194 @@ func InitEvaluator(db *sql.DB) {
 		pythonURL = "http://localhost:8001"
 	}
 	globalEvaluator = evaluator.NewEvaluator(db, pythonURL)
+	globalEvaluator.SetJobEvents(evaluator.JobEvents{
+		Progress:  middleware.BroadcastEvaluationProgress,
+		Completed: middleware.BroadcastEvaluationCompleted,
+		Failed:    middleware.BroadcastEvaluationFailed,
+		CostAlert: middleware.BroadcastCostAlert,
+	})
+	globalEvaluator.SetAPIKeyLookup(middleware.GetAPIKey)
+	configureJudgeProviders()
 	log.Printf("Evaluator initialized with Python service URL: %s", pythonURL)
 }
 
+// ShutdownEvaluator cancels running evaluation jobs so the server can exit. Their
+// status is left as is and they resume on the next start.
+func ShutdownEvaluator() {
+	if globalEvaluator == nil {
+		return
+	}
+	globalEvaluator.Shutdown(10 * time.Second)
+}
+
+// configureJudgeProviders applies the judge_backend setting to the global evaluator.
+// "python" routes every judge through the Python service, "native" calls OpenAI,
+// Anthropic and Gemini directly, and "hybrid" calls them directly while the Python
+// service handles the remaining judges.
+func configureJudgeProviders() {
+	if globalEvaluator == nil {
+		return
+	}
+	configureJudgeRetry()
+	configureJudgeThroughput()
+
+	backend, _ := middleware.GetSetting("judge_backend")
+	if backend == "" || backend == "python" {
+		globalEvaluator.SetJudgeProviders()
+		return
+	}
+
+	var providers []evaluator.JudgeProvider
+	for _, cfg := range nativeJudgeConfigs() {
+		provider, err := evaluator.NewJudgeProvider(cfg)
+		if err != nil {
+			log.Printf("Error creating judge provider %s: %v", cfg.Name, err)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+
+	if backend == "hybrid" {
+		pythonURL, _ := middleware.GetSetting("python_service_url")
+		if pythonURL == "" {
+			pythonURL = "http://localhost:8001"
+		}
+		providers = append(providers, evaluator.NewLiteLLMClient(pythonURL))
+	}
+
+	if len(providers) == 0 {
+		log.Printf("No native judge providers configured, falling back to Python service")
+	}
+	globalEvaluator.SetJudgeProviders(providers...)
+}
+
+// configureJudgeRetry applies the retry and circuit breaker settings to the global
+// evaluator. Unset or unreadable values keep the defaults.
+func configureJudgeRetry() {
+	policy := evaluator.DefaultRetryPolicy
+	policy.MaxAttempts = intSetting("judge_retry_attempts", policy.MaxAttempts)
+	policy.BaseDelay = durationSetting("judge_retry_base_delay", policy.BaseDelay)
+	policy.MaxDelay = durationSetting("judge_retry_max_delay", policy.MaxDelay)
+	globalEvaluator.SetRetryPolicy(policy)
+
+	globalEvaluator.SetCircuitBreaker(
+		intSetting("judge_breaker_threshold", evaluator.DefaultBreakerThreshold),
+		durationSetting("judge_breaker_cooldown", evaluator.DefaultBreakerCooldown),
+	)
+}
+
+// configureJudgeThroughput applies the pair concurrency and the per-provider rate
+// limits. Limits are stored per provider kind (judge_openai_rpm, judge_python_tpm, ...);
+// the evaluator shares each one between every judge calling the same provider
+// endpoint, panel judges included. Unset limits leave a provider unlimited.
+func configureJudgeThroughput() {
+	globalEvaluator.SetConcurrency(intSetting("judge_concurrency", evaluator.DefaultConcurrency))
+
+	limits := make(map[string]evaluator.RateLimit)
+	for _, provider := range []string{"python", "openai", "anthropic", "gemini"} {
+		limits[provider] = rateLimitSetting(provider)
+	}
+	globalEvaluator.SetRateLimits(limits)
+}
+
+func rateLimitSetting(provider string) evaluator.RateLimit {
+	return evaluator.RateLimit{
+		RequestsPerMinute: intSetting("judge_"+provider+"_rpm", 0),
+		TokensPerMinute:   intSetting("judge_"+provider+"_tpm", 0),
+	}
+}
+
+func intSetting(key string, fallback int) int {
+	value, _ := middleware.GetSetting(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Ignoring invalid %s %q", key, value)
+		return fallback
+	}
+	return n
+}
+
+func durationSetting(key string, fallback time.Duration) time.Duration {
+	value, _ := middleware.GetSetting(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("Ignoring invalid %s %q", key, value)
+		return fallback
+	}
+	return d
+}
+
+// nativeJudgeConfigs builds judge configs for every provider with a stored API key
+func nativeJudgeConfigs() []evaluator.JudgeConfig {
+	var configs []evaluator.JudgeConfig
+
+	if key, err := middleware.GetAPIKey("openai"); err == nil && key != "" {
+		baseURL, _ := middleware.GetSetting("judge_openai_base_url")
+		model, _ := middleware.GetSetting("judge_openai_model")
+		if model == "" {
+			model = "gpt-5.2"
+		}
+		configs = append(configs, evaluator.JudgeConfig{
+			Name:            "gpt_5.2",
+			Provider:        "openai",
+			BaseURL:         baseURL,
+			Model:           model,
+			APIKey:          key,
+			InputCostPer1K:  0.010,
+			OutputCostPer1K: 0.030,
+		})
+	}
+
+	if key, err := middleware.GetAPIKey("anthropic"); err == nil && key != "" {
+		baseURL, _ := middleware.GetSetting("judge_anthropic_base_url")
+		model, _ := middleware.GetSetting("judge_anthropic_model")
+		if model == "" {
+			model = "claude-opus-4-5"
+		}
+		configs = append(configs, evaluator.JudgeConfig{
+			Name:            "claude_opus_4.5",
+			Provider:        "anthropic",
+			BaseURL:         baseURL,
+			Model:           model,
+			APIKey:          key,
+			InputCostPer1K:  0.015,
+			OutputCostPer1K: 0.075,
+		})
+	}
+
+	if key, err := middleware.GetAPIKey("google"); err == nil && key != "" {
+		baseURL, _ := middleware.GetSetting("judge_gemini_base_url")
+		model, _ := middleware.GetSetting("judge_gemini_model")
+		if model == "" {
+			model = "gemini-3-pro-preview"
+		}
+		configs = append(configs, evaluator.JudgeConfig{
+			Name:            "gemini_3_pro",
+			Provider:        "gemini",
+			BaseURL:         baseURL,
+			Model:           model,
+			APIKey:          key,
+			InputCostPer1K:  0.002,
+			OutputCostPer1K: 0.012,
+		})
+	}
+
+	return configs
+}
+
+// forceRefresh reports whether an evaluation request sets ?force=true to bypass the
+// evaluation cache
+func forceRefresh(r *http.Request) bool {
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	return force
+}
+
 // EvaluateAllHandler triggers evaluation of all models × all prompts
 func EvaluateAllHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -37,7 +223,7 @@ func EvaluateAllHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobID, err := globalEvaluator.EvaluateAll(suiteID)
+	jobID, err := globalEvaluator.EvaluateAll(suiteID, forceRefresh(r))
 	if err != nil {
 		log.Printf("Error starting evaluation: %v", err)
 		http.Error(w, fmt.Sprintf("Failed to start evaluation: %v", err), http.StatusInternalServerError)
@@ -70,7 +256,7 @@ func EvaluateModelHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobID, err := globalEvaluator.EvaluateModel(modelID)
+	jobID, err := globalEvaluator.EvaluateModel(modelID, forceRefresh(r))
 	if err != nil {
 		log.Printf("Error starting model evaluation: %v", err)
 		http.Error(w, fmt.Sprintf("Failed to start evaluation: %v", err), http.StatusInternalServerError)
@@ -103,7 +289,7 @@ func EvaluatePromptHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobID, err := globalEvaluator.EvaluatePrompt(promptID)
+	jobID, err := globalEvaluator.EvaluatePrompt(promptID, forceRefresh(r))
 	if err != nil {
 		log.Printf("Error starting prompt evaluation: %v", err)
 		http.Error(w, fmt.Sprintf("Failed to start evaluation: %v", err), http.StatusInternalServerError)
@@ -180,6 +366,72 @@ func CancelEvaluationHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// RemoveEvaluationJobHandler deletes a queued job before it starts
+func RemoveEvaluationJobHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jobIDStr := r.URL.Query().Get("id")
+	if jobIDStr == "" {
+		http.Error(w, "Job ID required", http.StatusBadRequest)
+		return
+	}
+
+	jobID, err := strconv.Atoi(jobIDStr)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := globalEvaluator.RemoveJob(jobID); err != nil {
+		log.Printf("Error removing job: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to remove job: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"success": true,
+		"message": "Job removed",
+	})
+}
+
+// autoEvaluate passes the current suite to queue, which records a change for
+// automatic evaluation. The evaluator ignores it unless auto_evaluate_new_models is on.
+func (h *Handler) autoEvaluate(queue func(suiteID int)) {
+	if globalEvaluator == nil {
+		return
+	}
+	suiteID, err := h.DataStore.GetCurrentSuiteID()
+	if err != nil {
+		log.Printf("Error getting current suite for auto evaluation: %v", err)
+		return
+	}
+	queue(suiteID)
+}
+
+// autoEvaluateModel queues a new model's pairs for automatic evaluation
+func autoEvaluateModel(suiteID int, suiteName, model string) {
+	modelID, err := middleware.GetModelID(suiteName, model)
+	if err != nil {
+		log.Printf("Error finding model %q for auto evaluation: %v", model, err)
+		return
+	}
+	globalEvaluator.AutoEvaluateModel(suiteID, modelID)
+}
+
+// autoEvaluatePrompt queues the pairs of the prompt at a position of the suite for
+// automatic evaluation
+func autoEvaluatePrompt(suiteID int, suiteName string, index int) {
+	promptID, err := middleware.GetPromptID(suiteName, index)
+	if err != nil {
+		log.Printf("Error finding prompt %d for auto evaluation: %v", index, err)
+		return
+	}
+	globalEvaluator.AutoEvaluatePrompt(suiteID, promptID)
+}
+
 // SaveModelResponseHandler saves or updates a model's response for a prompt
 func SaveModelResponseHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -214,6 +466,9 @@ func SaveModelResponseHandler(w http.ResponseWriter, r *http.Request) {
 		VALUES (?, ?, ?, 'manual')
 		ON CONFLICT(model_id, prompt_id) DO UPDATE SET
 			response_text = excluded.response_text,
+			response_source = excluded.response_source,
+			api_config = NULL,
+			system_prompt_version_id = NULL,
 			updated_at = CURRENT_TIMESTAMP
 	`
 	_, err := db.Exec(query, reqBody.ModelID, reqBody.PromptID, reqBody.ResponseText)
@@ -223,6 +478,10 @@ func SaveModelResponseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if globalEvaluator != nil {
+		globalEvaluator.AutoEvaluatePair(reqBody.ModelID, reqBody.PromptID)
+	}
+
 	// Return success
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"llm-tournament/evaluator"
 	"llm-tournament/middleware"
 	"log"
 	"net/http"
@@ -184,6 +185,62 @@ func TestCancelEvaluationHandler_InvalidID(t *testing.T) {
 	}
 }
 
+func TestRemoveEvaluationJobHandler_BadRequests(t *testing.T) {
+	cleanup := setupEvaluationTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		method string
+		target string
+		want   int
+	}{
+		{"GET", "/evaluation/remove?id=1", http.StatusMethodNotAllowed},
+		{"POST", "/evaluation/remove", http.StatusBadRequest},
+		{"POST", "/evaluation/remove?id=invalid", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		RemoveEvaluationJobHandler(rr, httptest.NewRequest(tt.method, tt.target, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.want, rr.Code)
+		}
+	}
+}
+
+func TestRemoveEvaluationJobHandler_PendingJob(t *testing.T) {
+	cleanup := setupEvaluationTestDB(t)
+	defer cleanup()
+
+	db := middleware.GetDB()
+	InitEvaluator(db)
+
+	suiteID, _ := middleware.GetSuiteID(middleware.GetCurrentSuiteName())
+	result, err := db.Exec("INSERT INTO evaluation_jobs (suite_id, job_type, status) VALUES (?, 'all', 'pending')", suiteID)
+	if err != nil {
+		t.Fatalf("failed to insert job: %v", err)
+	}
+	jobID, _ := result.LastInsertId()
+
+	rr := httptest.NewRecorder()
+	RemoveEvaluationJobHandler(rr, httptest.NewRequest("POST", fmt.Sprintf("/evaluation/remove?id=%d", jobID), nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var count int
+	_ = db.QueryRow("SELECT COUNT(*) FROM evaluation_jobs WHERE id = ?", jobID).Scan(&count)
+	if count != 0 {
+		t.Error("expected job to be removed")
+	}
+
+	// A second remove finds nothing
+	rr = httptest.NewRecorder()
+	RemoveEvaluationJobHandler(rr, httptest.NewRequest("POST", fmt.Sprintf("/evaluation/remove?id=%d", jobID), nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
+
 func TestInitEvaluator(t *testing.T) {
 	cleanup := setupEvaluationTestDB(t)
 	defer cleanup()
@@ -242,6 +299,35 @@ func TestEvaluateAllHandler_WithEvaluator(t *testing.T) {
 	}
 }
 
+func TestEvaluateAllHandler_ForceRefresh(t *testing.T) {
+	cleanup := setupEvaluationTestDB(t)
+	defer cleanup()
+
+	db := middleware.GetDB()
+	InitEvaluator(db)
+
+	for query, want := range map[string]bool{"": false, "?force=true": true, "?force=nope": false} {
+		rr := httptest.NewRecorder()
+		EvaluateAllHandler(rr, httptest.NewRequest("POST", "/evaluate/all"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%q: expected status %d, got %d", query, http.StatusOK, rr.Code)
+		}
+		var resp struct {
+			JobID int `json:"job_id"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%q: failed to decode response: %v", query, err)
+		}
+		var force bool
+		if err := db.QueryRow("SELECT force_refresh FROM evaluation_jobs WHERE id = ?", resp.JobID).Scan(&force); err != nil {
+			t.Fatalf("%q: failed to read job: %v", query, err)
+		}
+		if force != want {
+			t.Errorf("%q: expected force_refresh %v, got %v", query, want, force)
+		}
+	}
+}
+
 func TestEvaluateModelHandler_WithEvaluator(t *testing.T) {
 	cleanup := setupEvaluationTestDB(t)
 	defer cleanup()
@@ -477,7 +563,7 @@ func TestCancelEvaluationHandler_SuccessResponse(t *testing.T) {
 		}
 	}
 
-	jobID, err := globalEvaluator.EvaluateAll(suiteID)
+	jobID, err := globalEvaluator.EvaluateAll(suiteID, false)
 	if err != nil {
 		t.Fatalf("EvaluateAll failed: %v", err)
 	}
@@ -629,3 +715,86 @@ func TestSaveModelResponseHandler_ErrorCases(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigureJudgeProviders_NativeBackend(t *testing.T) {
+	cleanup := setupEvaluationTestDB(t)
+	defer cleanup()
+	t.Setenv("ENCRYPTION_KEY", strings.Repeat("ab", 32))
+
+	originalEvaluator := globalEvaluator
+	defer func() { globalEvaluator = originalEvaluator }()
+
+	InitEvaluator(middleware.GetDB())
+	if names := globalEvaluator.JudgeProviderNames(); len(names) != 1 || names[0] != "python" {
+		t.Fatalf("expected python provider by default, got %v", names)
+	}
+
+	if err := middleware.SetAPIKey("openai", "sk-test"); err != nil {
+		t.Fatalf("failed to set API key: %v", err)
+	}
+	_ = middleware.SetSetting("judge_backend", "native")
+	configureJudgeProviders()
+	if names := globalEvaluator.JudgeProviderNames(); len(names) != 1 || names[0] != "gpt_5.2" {
+		t.Fatalf("expected native gpt provider, got %v", names)
+	}
+
+	_ = middleware.SetSetting("judge_backend", "hybrid")
+	configureJudgeProviders()
+	if names := globalEvaluator.JudgeProviderNames(); len(names) != 2 || names[1] != "python" {
+		t.Fatalf("expected gpt and python providers, got %v", names)
+	}
+
+	if err := middleware.SetAPIKey("google", "g-test"); err != nil {
+		t.Fatalf("failed to set API key: %v", err)
+	}
+	_ = middleware.SetSetting("judge_backend", "native")
+	configureJudgeProviders()
+	if names := globalEvaluator.JudgeProviderNames(); len(names) != 2 || names[1] != "gemini_3_pro" {
+		t.Fatalf("expected native gpt and gemini providers, got %v", names)
+	}
+}
+
+func TestJudgeRetrySettings(t *testing.T) {
+	cleanup := setupEvaluationTestDB(t)
+	defer cleanup()
+
+	_ = middleware.SetSetting("judge_retry_attempts", "6")
+	_ = middleware.SetSetting("judge_breaker_threshold", "many")
+	_ = middleware.SetSetting("judge_breaker_cooldown", "90s")
+	_ = middleware.SetSetting("judge_retry_max_delay", "-5s")
+
+	if got := intSetting("judge_retry_attempts", 4); got != 6 {
+		t.Errorf("expected 6 attempts, got %d", got)
+	}
+	if got := intSetting("judge_breaker_threshold", 3); got != 3 {
+		t.Errorf("expected invalid threshold to fall back to 3, got %d", got)
+	}
+	if got := durationSetting("judge_breaker_cooldown", time.Minute); got != 90*time.Second {
+		t.Errorf("expected 90s cooldown, got %s", got)
+	}
+	if got := durationSetting("judge_retry_max_delay", 30*time.Second); got != 30*time.Second {
+		t.Errorf("expected negative delay to fall back to 30s, got %s", got)
+	}
+	if got := durationSetting("judge_retry_base_delay", 2*time.Second); got != 2*time.Second {
+		t.Errorf("expected unset delay to fall back to 2s, got %s", got)
+	}
+}
+
+func TestJudgeThroughputSettings(t *testing.T) {
+	cleanup := setupEvaluationTestDB(t)
+	defer cleanup()
+
+	_ = middleware.SetSetting("judge_openai_rpm", "60")
+	_ = middleware.SetSetting("judge_openai_tpm", "lots")
+	_ = middleware.SetSetting("judge_python_tpm", "90000")
+
+	if got := rateLimitSetting("openai"); got != (evaluator.RateLimit{RequestsPerMinute: 60}) {
+		t.Errorf("expected 60 rpm and no token limit, got %+v", got)
+	}
+	if got := rateLimitSetting("python"); got != (evaluator.RateLimit{TokensPerMinute: 90000}) {
+		t.Errorf("expected 90000 tpm and no request limit, got %+v", got)
+	}
+	if got := rateLimitSetting("anthropic"); got != (evaluator.RateLimit{}) {
+		t.Errorf("expected anthropic to be unlimited, got %+v", got)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"llm-tournament/middleware"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// GenerateAllHandler queues response generation for every configured model × all prompts
+func GenerateAllHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	suiteID, err := middleware.GetCurrentSuiteID()
+	if err != nil {
+		log.Printf("Error getting current suite: %v", err)
+		http.Error(w, "Failed to get current suite", http.StatusInternalServerError)
+		return
+	}
+
+	jobID, err := globalEvaluator.GenerateAll(suiteID)
+	if err != nil {
+		log.Printf("Error starting generation: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to start generation: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"success": true,
+		"job_id":  jobID,
+		"message": "Generation started",
+	})
+}
+
+// GenerateModelHandler queues response generation for one model × all prompts
+func GenerateModelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	modelID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid model ID", http.StatusBadRequest)
+		return
+	}
+
+	jobID, err := globalEvaluator.GenerateModel(modelID)
+	if err != nil {
+		log.Printf("Error starting model generation: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to start generation: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"success": true,
+		"job_id":  jobID,
+		"message": "Model generation started",
+	})
+}
+
+// ModelConfigHandler returns (GET) or saves (POST, JSON body) a model's endpoint configuration
+func ModelConfigHandler(w http.ResponseWriter, r *http.Request) {
+	modelID, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid model ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		cfg, err := middleware.GetModelConfigByID(modelID)
+		if err != nil {
+			log.Printf("Error getting model config: %v", err)
+			http.Error(w, "Failed to get model config", http.StatusInternalServerError)
+			return
+		}
+		if cfg == nil {
+			cfg = &middleware.ModelConfig{ModelID: modelID}
+		}
+		middleware.RespondJSON(w, cfg)
+	case http.MethodPost:
+		var cfg middleware.ModelConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		cfg.ModelID = modelID
+		if err := middleware.SaveModelConfigByID(cfg); err != nil {
+			if errors.Is(err, middleware.ErrAPIKeyConflict) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Error saving model config: %v", err)
+			http.Error(w, "Failed to save model config", http.StatusInternalServerError)
+			return
+		}
+		middleware.RespondJSON(w, map[string]interface{}{
+			"success": true,
+			"message": "Model config saved",
+		})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
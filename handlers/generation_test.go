@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"llm-tournament/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGenerateHandlers_MethodNotAllowed(t *testing.T) {
+	for path, handler := range map[string]http.HandlerFunc{
+		"/generate/all":   GenerateAllHandler,
+		"/generate/model": GenerateModelHandler,
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: expected 405, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestGenerateModelHandler_InvalidID(t *testing.T) {
+	req := httptest.NewRequest("POST", "/generate/model?id=abc", nil)
+	rr := httptest.NewRecorder()
+	GenerateModelHandler(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestGenerateModelHandler_NoConfig(t *testing.T) {
+	cleanup := setupEvaluationTestDB(t)
+	defer cleanup()
+
+	originalEvaluator := globalEvaluator
+	defer func() { globalEvaluator = originalEvaluator }()
+	InitEvaluator(middleware.GetDB())
+
+	if _, err := middleware.GetDB().Exec("INSERT INTO models (name, suite_id) VALUES ('m1', 1)"); err != nil {
+		t.Fatalf("failed to insert model: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/generate/model?id=1", nil)
+	rr := httptest.NewRecorder()
+	GenerateModelHandler(rr, req)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for unconfigured model, got %d", rr.Code)
+	}
+}
+
+func TestModelConfigHandler_RoundTrip(t *testing.T) {
+	cleanup := setupEvaluationTestDB(t)
+	defer cleanup()
+
+	if _, err := middleware.GetDB().Exec("INSERT INTO models (name, suite_id) VALUES ('m1', 1)"); err != nil {
+		t.Fatalf("failed to insert model: %v", err)
+	}
+
+	body := `{"base_url": "http://localhost:8081/v1", "model_name": "qwen", "max_tokens": 256}`
+	req := httptest.NewRequest("POST", "/model_config?id=1", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	ModelConfigHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/model_config?id=1", nil)
+	rr = httptest.NewRecorder()
+	ModelConfigHandler(rr, req)
+	var cfg middleware.ModelConfig
+	if err := json.NewDecoder(rr.Body).Decode(&cfg); err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	if cfg.BaseURL != "http://localhost:8081/v1" || cfg.ModelName != "qwen" || cfg.MaxTokens != 256 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestModelConfigHandler_BadRequests(t *testing.T) {
+	cleanup := setupEvaluationTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		method, url, body string
+		want              int
+	}{
+		{"GET", "/model_config", "", http.StatusBadRequest},
+		{"POST", "/model_config?id=1", "not json", http.StatusBadRequest},
+		{"POST", "/model_config?id=1", `{"api_key": "sk-new", "clear_api_key": true}`, http.StatusBadRequest},
+		{"DELETE", "/model_config?id=1", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		rr := httptest.NewRecorder()
+		ModelConfigHandler(rr, req)
+		if rr.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.url, tt.want, rr.Code)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"llm-tournament/middleware"
+	"log"
 )
 
 // Handler holds dependencies for HTTP handlers
@@ -28,3 +29,12 @@ func NewHandlerWithDeps(ds middleware.DataStore, r middleware.TemplateRenderer)
 
 // DefaultHandler is the default handler instance used by HTTP routes
 var DefaultHandler = NewHandler()
+
+// scoreScale returns the scale the current suite grades on
+func (h *Handler) scoreScale() middleware.ScoreScale {
+	name, err := h.DataStore.GetSuiteSetting(h.DataStore.GetCurrentSuiteName(), middleware.SuiteSettingScoreScale)
+	if err != nil {
+		log.Printf("Error loading score scale: %v", err)
+	}
+	return middleware.ResolveScoreScale(name)
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"llm-tournament/evaluator"
+	"llm-tournament/middleware"
+	"llm-tournament/templates"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// JobsHandler displays evaluation jobs (backward compatible wrapper)
+func JobsHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.Jobs(w, r)
+}
+
+// JobsJSONHandler returns evaluation jobs as JSON (backward compatible wrapper)
+func JobsJSONHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.JobsJSON(w, r)
+}
+
+// JobDetailHandler displays the pairs of one evaluation job (backward compatible wrapper)
+func JobDetailHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.JobDetail(w, r)
+}
+
+// JobDetailJSONHandler returns the pairs of one evaluation job as JSON (backward compatible wrapper)
+func JobDetailJSONHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.JobDetailJSON(w, r)
+}
+
+// jobFilter reads the job list filters from the query string. Dates are YYYY-MM-DD
+// in local time and both ends are inclusive.
+func (h *Handler) jobFilter(q url.Values) (evaluator.JobFilter, error) {
+	filter := evaluator.JobFilter{
+		Status:  q.Get("status"),
+		JobType: q.Get("type"),
+	}
+	if suite := q.Get("suite"); suite != "" {
+		if !h.DataStore.SuiteExists(suite) {
+			return filter, fmt.Errorf("unknown suite %q", suite)
+		}
+		suiteID, err := middleware.GetSuiteID(suite)
+		if err != nil {
+			return filter, fmt.Errorf("unknown suite %q", suite)
+		}
+		filter.SuiteID = suiteID
+	}
+	if since := q.Get("since"); since != "" {
+		day, err := time.ParseInLocation(time.DateOnly, since, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since date %q", since)
+		}
+		filter.Since = day
+	}
+	if until := q.Get("until"); until != "" {
+		day, err := time.ParseInLocation(time.DateOnly, until, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid until date %q", until)
+		}
+		filter.Until = day.AddDate(0, 0, 1)
+	}
+	return filter, nil
+}
+
+// jobID reads the ?id= of a job request, writing the error response when it is missing or invalid
+func jobID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	jobIDStr := r.URL.Query().Get("id")
+	if jobIDStr == "" {
+		http.Error(w, "Job ID required", http.StatusBadRequest)
+		return 0, false
+	}
+	id, err := strconv.Atoi(jobIDStr)
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// jobsFuncMap is the template FuncMap plus the helpers used by the job pages
+func jobsFuncMap() template.FuncMap {
+	funcMap := template.FuncMap{}
+	for name, fn := range templates.FuncMap {
+		funcMap[name] = fn
+	}
+	funcMap["statusBadge"] = func(status string) string {
+		switch status {
+		case "completed", evaluator.PairScored:
+			return "badge-success"
+		case "running":
+			return "badge-info"
+		case "paused", evaluator.PairSkipped:
+			return "badge-warning"
+		case "failed":
+			return "badge-error"
+		default:
+			return "badge-ghost"
+		}
+	}
+	funcMap["percent"] = func(f float64) string {
+		return fmt.Sprintf("%.0f%%", f*100)
+	}
+	funcMap["timestamp"] = func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Local().Format(time.DateTime)
+	}
+	return funcMap
+}
+
+// Jobs renders the evaluation job list
+func (h *Handler) Jobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	filter, err := h.jobFilter(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jobs, err := globalEvaluator.ListJobs(filter)
+	if err != nil {
+		log.Printf("Error listing jobs: %v", err)
+		http.Error(w, "Error listing jobs", http.StatusInternalServerError)
+		return
+	}
+	suites, err := h.DataStore.ListSuites()
+	if err != nil {
+		log.Printf("Error listing suites: %v", err)
+	}
+
+	data := struct {
+		PageName    string
+		Jobs        []evaluator.JobSummary
+		SuiteNames  []string
+		Statuses    []string
+		JobTypes    []string
+		Query       url.Values
+		CurrentPath string
+	}{
+		PageName:   "Jobs",
+		Jobs:       jobs,
+		SuiteNames: suites,
+		Statuses:   []string{"pending", "running", "paused", "completed", "failed", "cancelled"},
+		JobTypes: []string{"all", "model", "prompt", "generate_all", "generate_model", evaluator.JobTypeBattleAll,
+			evaluator.JobTypeRetryFailed, evaluator.JobTypeAuto},
+		Query:       q,
+		CurrentPath: "/jobs",
+	}
+
+	err = h.Renderer.Render(w, "jobs.html", jobsFuncMap(), data, "templates/jobs.html", "templates/nav.html")
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// JobsJSON returns the evaluation jobs matching the request's filters
+func (h *Handler) JobsJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := h.jobFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jobs, err := globalEvaluator.ListJobs(filter)
+	if err != nil {
+		log.Printf("Error listing jobs: %v", err)
+		http.Error(w, "Error listing jobs", http.StatusInternalServerError)
+		return
+	}
+	if jobs == nil {
+		jobs = []evaluator.JobSummary{}
+	}
+
+	middleware.RespondJSON(w, jobs)
+}
+
+// jobDetail loads a job and its pairs
+func jobDetail(jobID int) (*evaluator.EvaluationJob, []evaluator.PairOutcome, error) {
+	job, err := globalEvaluator.GetJobStatus(jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	pairs, err := globalEvaluator.JobPairs(jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pairs == nil {
+		pairs = []evaluator.PairOutcome{}
+	}
+	return job, pairs, nil
+}
+
+// JobDetail renders one job with the outcome of each of its pairs
+func (h *Handler) JobDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := jobID(w, r)
+	if !ok {
+		return
+	}
+
+	job, pairs, err := jobDetail(id)
+	if err != nil {
+		log.Printf("Error loading job %d: %v", id, err)
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	counts := map[string]int{}
+	for _, p := range pairs {
+		counts[p.Outcome]++
+	}
+
+	changes, err := h.DataStore.ListJobScoreChanges(id)
+	if err != nil {
+		log.Printf("Warning: failed to list score changes of job %d: %v", id, err)
+	}
+
+	data := struct {
+		PageName     string
+		Job          *evaluator.EvaluationJob
+		Pairs        []evaluator.PairOutcome
+		Counts       map[string]int
+		ScoreChanges []middleware.ScoreHistoryEntry
+		CurrentPath  string
+	}{
+		PageName:     "Jobs",
+		Job:          job,
+		Pairs:        pairs,
+		Counts:       counts,
+		ScoreChanges: changes,
+		CurrentPath:  "/jobs",
+	}
+
+	err = h.Renderer.Render(w, "job_detail.html", jobsFuncMap(), data, "templates/job_detail.html", "templates/nav.html")
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// JobDetailJSON returns one job with the outcome of each of its pairs
+func (h *Handler) JobDetailJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := jobID(w, r)
+	if !ok {
+		return
+	}
+
+	job, pairs, err := jobDetail(id)
+	if err != nil {
+		log.Printf("Error loading job %d: %v", id, err)
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"job":   job,
+		"pairs": pairs,
+	})
+}
+
+// RetryFailedPairsHandler queues a job that re-evaluates the failed pairs of a job
+func RetryFailedPairsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := jobID(w, r)
+	if !ok {
+		return
+	}
+
+	retryID, err := globalEvaluator.RetryFailedPairs(id)
+	if err != nil {
+		log.Printf("Error retrying failed pairs of job %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to retry job: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"success": true,
+		"job_id":  retryID,
+		"message": "Retrying failed pairs",
+	})
+}
+
+// ResumeJobHandler queues a cancelled or failed job again, skipping the pairs it already finished
+func ResumeJobHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := jobID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := globalEvaluator.ResumeJob(id); err != nil {
+		log.Printf("Error resuming job %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to resume job: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"success": true,
+		"job_id":  id,
+		"message": "Job resumed",
+	})
+}
+
+// RestartJobHandler queues a stopped or finished job to evaluate every pair again
+func RestartJobHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := jobID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := globalEvaluator.RestartJob(id); err != nil {
+		log.Printf("Error restarting job %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to restart job: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"success": true,
+		"job_id":  id,
+		"message": "Job restarted",
+	})
+}
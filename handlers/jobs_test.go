@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"llm-tournament/evaluator"
+	"llm-tournament/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupJobsTest starts an evaluator over a test database holding one finished job
+// with a scored and a failed pair
+func setupJobsTest(t *testing.T) func() {
+	t.Helper()
+	restoreDir := changeToProjectRootStats(t)
+	cleanup := setupEvaluationTestDB(t)
+
+	originalEvaluator := globalEvaluator
+	InitEvaluator(middleware.GetDB())
+
+	if err := middleware.WritePromptSuite("default", []middleware.Prompt{{Text: "p1"}, {Text: "p2"}}); err != nil {
+		t.Fatalf("failed to write prompts: %v", err)
+	}
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"alpha": {Scores: []int{0, 0}}}); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+	_, err := middleware.GetDB().Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type, status, progress_current, progress_total, actual_cost_usd)
+		SELECT 7, id, 'all', 'completed', 2, 2, 0.02 FROM suites WHERE name = 'default';
+		INSERT INTO evaluation_job_pairs (job_id, model_id, prompt_id, outcome, cost_usd, duration_ms)
+		SELECT 7, m.id, p.id, CASE p.text WHEN 'p1' THEN 'scored' ELSE 'failed' END, 0.01, 1200
+		FROM models m, prompts p;
+		INSERT INTO evaluation_errors (job_id, model_id, prompt_id, error_kind, error_message, attempts)
+		SELECT 7, m.id, p.id, 'rate_limit', 'slow down', 3 FROM models m, prompts p WHERE p.text = 'p2';
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed job: %v", err)
+	}
+
+	return func() {
+		globalEvaluator = originalEvaluator
+		cleanup()
+		restoreDir()
+	}
+}
+
+func TestJobsJSON_Filters(t *testing.T) {
+	defer setupJobsTest(t)()
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 1},
+		{"?status=completed&suite=default&type=all", 1},
+		{"?status=failed", 0},
+		{"?since=2000-01-01&until=2000-01-02", 0},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		JobsJSONHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs/json"+tt.query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", tt.query, http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var jobs []evaluator.JobSummary
+		if err := json.Unmarshal(rr.Body.Bytes(), &jobs); err != nil {
+			t.Fatalf("%s: failed to decode jobs: %v", tt.query, err)
+		}
+		if len(jobs) != tt.want {
+			t.Errorf("%s: expected %d jobs, got %d", tt.query, tt.want, len(jobs))
+		}
+		if len(jobs) == 1 && (jobs[0].ID != 7 || jobs[0].FailedPairs != 1 || jobs[0].SuiteName != "default") {
+			t.Errorf("%s: unexpected job %+v", tt.query, jobs[0])
+		}
+	}
+
+	for _, query := range []string{"?since=yesterday", "?suite=missing"} {
+		rr := httptest.NewRecorder()
+		JobsJSONHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs/json"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, rr.Code)
+		}
+	}
+	if middleware.SuiteExists("missing") {
+		t.Error("expected filtering by an unknown suite not to create it")
+	}
+}
+
+func TestJobs_GET_RendersList(t *testing.T) {
+	defer setupJobsTest(t)()
+
+	rr := httptest.NewRecorder()
+	JobsHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs?status=completed", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, `href="/jobs/detail?id=7"`) || !strings.Contains(body, "Retry failed") {
+		t.Error("expected the job list to link the job and offer a retry of its failed pair")
+	}
+}
+
+func TestJobDetail_ShowsPairOutcomes(t *testing.T) {
+	defer setupJobsTest(t)()
+
+	rr := httptest.NewRecorder()
+	JobDetailJSONHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs/detail/json?id=7", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var detail struct {
+		Job   evaluator.EvaluationJob `json:"job"`
+		Pairs []evaluator.PairOutcome `json:"pairs"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("failed to decode job detail: %v", err)
+	}
+	if detail.Job.ID != 7 || detail.Job.ProgressCurrent != 2 || len(detail.Pairs) != 2 {
+		t.Fatalf("unexpected job detail %+v", detail)
+	}
+	if p := detail.Pairs[1]; p.Outcome != evaluator.PairFailed || p.ErrorKind != "rate_limit" || p.Attempts != 3 {
+		t.Errorf("expected the failed pair with its error, got %+v", p)
+	}
+
+	rr = httptest.NewRecorder()
+	JobDetailHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs/detail?id=7", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if body := rr.Body.String(); !strings.Contains(body, "slow down") || !strings.Contains(body, "1200 ms") {
+		t.Error("expected the detail page to show the pair error and duration")
+	}
+
+	rr = httptest.NewRecorder()
+	JobDetailHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs/detail?id=99", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a missing job, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestRetryFailedPairsHandler_BadRequests(t *testing.T) {
+	defer setupJobsTest(t)()
+
+	if _, err := middleware.GetDB().Exec("UPDATE evaluation_job_pairs SET outcome = 'scored'"); err != nil {
+		t.Fatalf("failed to clear failures: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		target string
+		want   int
+	}{
+		{http.MethodGet, "/jobs/retry_failed?id=7", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/jobs/retry_failed", http.StatusBadRequest},
+		{http.MethodPost, "/jobs/retry_failed?id=abc", http.StatusBadRequest},
+		{http.MethodPost, "/jobs/retry_failed?id=7", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		RetryFailedPairsHandler(rr, httptest.NewRequest(tt.method, tt.target, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.want, rr.Code)
+		}
+	}
+}
+
+func TestResumeAndRestartJobHandlers(t *testing.T) {
+	defer setupJobsTest(t)()
+
+	tests := []struct {
+		handler http.HandlerFunc
+		method  string
+		target  string
+		want    int
+	}{
+		{ResumeJobHandler, http.MethodGet, "/jobs/resume?id=7", http.StatusMethodNotAllowed},
+		{ResumeJobHandler, http.MethodPost, "/jobs/resume", http.StatusBadRequest},
+		{ResumeJobHandler, http.MethodPost, "/jobs/resume?id=7", http.StatusBadRequest}, // Completed jobs can only restart
+		{RestartJobHandler, http.MethodGet, "/jobs/restart?id=7", http.StatusMethodNotAllowed},
+		{RestartJobHandler, http.MethodPost, "/jobs/restart?id=abc", http.StatusBadRequest},
+		{RestartJobHandler, http.MethodPost, "/jobs/restart?id=99", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		tt.handler(rr, httptest.NewRequest(tt.method, tt.target, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.want, rr.Code)
+		}
+	}
+
+	// Every pair of the cancelled job already finished, so resuming it completes
+	// without calling a judge
+	db := middleware.GetDB()
+	if _, err := db.Exec("UPDATE evaluation_jobs SET status = 'cancelled' WHERE id = 7"); err != nil {
+		t.Fatalf("failed to cancel job: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	ResumeJobHandler(rr, httptest.NewRequest(http.MethodPost, "/jobs/resume?id=7", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var status string
+	var current int
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = db.QueryRow("SELECT status, progress_current FROM evaluation_jobs WHERE id = 7").Scan(&status, &current)
+		if status == "completed" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status != "completed" || current != 2 {
+		t.Errorf("expected the resumed job completed with its 2 finished pairs, got %q after %d", status, current)
+	}
+}
+
+func TestJobs_ShowCacheUse(t *testing.T) {
+	defer setupJobsTest(t)()
+
+	if _, err := middleware.GetDB().Exec(`
+		UPDATE evaluation_jobs SET cache_hits = 1, cache_misses = 1, cache_saved_usd = 0.03 WHERE id = 7
+	`); err != nil {
+		t.Fatalf("failed to record cache use: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	JobsJSONHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs/json", nil))
+	var jobs []evaluator.JobSummary
+	if err := json.Unmarshal(rr.Body.Bytes(), &jobs); err != nil || len(jobs) != 1 {
+		t.Fatalf("failed to decode jobs: %v (%s)", err, rr.Body.String())
+	}
+	if jobs[0].CacheHits != 1 || jobs[0].CacheHitRate() != 0.5 || jobs[0].CacheSavedUSD != 0.03 {
+		t.Errorf("expected the job's cache use in the list, got %+v", jobs[0])
+	}
+
+	rr = httptest.NewRecorder()
+	JobsHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "50% hits, saved $0.0300") {
+		t.Error("expected the job list to show the cache hit rate and savings")
+	}
+
+	rr = httptest.NewRecorder()
+	JobDetailHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs/detail?id=7", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "Cache: 1 of 2 pairs (50%), saved $0.0300") {
+		t.Error("expected the job detail to show the cache hit rate and savings")
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"html/template"
+	"llm-tournament/middleware"
+	"llm-tournament/templates"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// profilePanelField prefixes the form fields that assign a panel to a profile
+const profilePanelField = "profile:"
+
+// JudgesHandler displays the judge library and panels (backward compatible wrapper)
+func JudgesHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.Judges(w, r)
+}
+
+// SaveJudgeHandler creates or updates a judge (backward compatible wrapper)
+func SaveJudgeHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.SaveJudge(w, r)
+}
+
+// DeleteJudgeHandler removes a judge (backward compatible wrapper)
+func DeleteJudgeHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.DeleteJudge(w, r)
+}
+
+// SavePanelHandler creates or updates a judge panel (backward compatible wrapper)
+func SavePanelHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.SavePanel(w, r)
+}
+
+// DeletePanelHandler removes a judge panel (backward compatible wrapper)
+func DeletePanelHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.DeletePanel(w, r)
+}
+
+// AssignPanelsHandler assigns panels to the current suite and its profiles (backward compatible wrapper)
+func AssignPanelsHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.AssignPanels(w, r)
+}
+
+// Judges renders the judge library, the panels and the current suite's assignments
+func (h *Handler) Judges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	judges, err := middleware.ListJudges()
+	if err != nil {
+		log.Printf("Error loading judges: %v", err)
+		http.Error(w, "Error loading judges", http.StatusInternalServerError)
+		return
+	}
+	panels, err := middleware.ListJudgePanels()
+	if err != nil {
+		log.Printf("Error loading judge panels: %v", err)
+		http.Error(w, "Error loading judge panels", http.StatusInternalServerError)
+		return
+	}
+
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	suitePanel, _ := h.DataStore.GetSuiteSetting(suiteName, middleware.SuiteSettingJudgePanel)
+	profilePanels, err := middleware.GetProfileJudgePanels(suiteName)
+	if err != nil {
+		log.Printf("Error loading profile panels: %v", err)
+		http.Error(w, "Error loading judge panels", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		PageName      string
+		Judges        []middleware.Judge
+		Panels        []middleware.JudgePanel
+		Providers     []string
+		DefaultPanel  string
+		SuiteName     string
+		SuitePanel    string
+		Profiles      []middleware.Profile
+		ProfilePanels map[string]string
+		CurrentPath   string
+	}{
+		PageName:      "Judges",
+		Judges:        judges,
+		Panels:        panels,
+		Providers:     middleware.JudgeProviders,
+		DefaultPanel:  middleware.DefaultJudgePanel,
+		SuiteName:     suiteName,
+		SuitePanel:    suitePanel,
+		Profiles:      h.DataStore.ReadProfiles(),
+		ProfilePanels: profilePanels,
+		CurrentPath:   "/judges",
+	}
+
+	funcMap := template.FuncMap{}
+	for name, fn := range templates.FuncMap {
+		funcMap[name] = fn
+	}
+	funcMap["inPanel"] = func(panel middleware.JudgePanel, judge string) bool {
+		return slices.ContainsFunc(panel.Judges, func(j middleware.Judge) bool { return j.Name == judge })
+	}
+
+	err = h.Renderer.Render(w, "judges.html", funcMap, data, "templates/judges.html", "templates/nav.html")
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// SaveJudge creates a judge or updates the one with the same name
+func (h *Handler) SaveJudge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Error parsing form: %v", err)
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	judge := middleware.Judge{
+		Name:     strings.TrimSpace(r.FormValue("name")),
+		Provider: r.FormValue("provider"),
+		Model:    strings.TrimSpace(r.FormValue("model")),
+		BaseURL:  strings.TrimSpace(r.FormValue("base_url")),
+		Weight:   1,
+	}
+	var err error
+	if judge.Temperature, err = parseOptionalFloat(r.FormValue("temperature")); err != nil {
+		http.Error(w, "Temperature must be a number", http.StatusBadRequest)
+		return
+	}
+	if v := r.FormValue("max_tokens"); v != "" {
+		if judge.MaxTokens, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Max tokens must be a whole number", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.FormValue("weight"); v != "" {
+		if judge.Weight, err = strconv.ParseFloat(v, 64); err != nil {
+			http.Error(w, "Weight must be a number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := middleware.SaveJudge(judge); err != nil {
+		log.Printf("Error saving judge: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Panel judges called natively are rate limited by their name
+	if globalEvaluator != nil {
+		configureJudgeThroughput()
+	}
+
+	http.Redirect(w, r, "/judges", http.StatusSeeOther)
+}
+
+// DeleteJudge removes a judge from the library and its panels
+func (h *Handler) DeleteJudge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := middleware.DeleteJudge(r.FormValue("name")); err != nil {
+		log.Printf("Error deleting judge: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/judges", http.StatusSeeOther)
+}
+
+// SavePanel creates a panel or replaces the judges of the one with the same name
+func (h *Handler) SavePanel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Error parsing form: %v", err)
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	if err := middleware.SaveJudgePanel(strings.TrimSpace(r.FormValue("name")), r.Form["judges"]); err != nil {
+		log.Printf("Error saving judge panel: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/judges", http.StatusSeeOther)
+}
+
+// DeletePanel removes a panel; suites and profiles assigned to it fall back to the default panel
+func (h *Handler) DeletePanel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := middleware.DeleteJudgePanel(r.FormValue("name")); err != nil {
+		log.Printf("Error deleting judge panel: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/judges", http.StatusSeeOther)
+}
+
+// AssignPanels sets the panel of the current suite and the panel overrides of its
+// profiles. Empty values fall back to the default panel and the suite's panel.
+func (h *Handler) AssignPanels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Error parsing form: %v", err)
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	panels, err := middleware.ListJudgePanels()
+	if err != nil {
+		log.Printf("Error loading judge panels: %v", err)
+		http.Error(w, "Error loading judge panels", http.StatusInternalServerError)
+		return
+	}
+	known := func(name string) bool {
+		return name == "" || slices.ContainsFunc(panels, func(p middleware.JudgePanel) bool { return p.Name == name })
+	}
+
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	suitePanel := r.FormValue("suite_panel")
+	if !known(suitePanel) {
+		http.Error(w, "Unknown judge panel", http.StatusBadRequest)
+		return
+	}
+	if err := h.DataStore.SetSuiteSetting(suiteName, middleware.SuiteSettingJudgePanel, suitePanel); err != nil {
+		log.Printf("Error saving suite panel: %v", err)
+		http.Error(w, "Error saving judge panels", http.StatusInternalServerError)
+		return
+	}
+
+	for field, values := range r.PostForm {
+		profile, ok := strings.CutPrefix(field, profilePanelField)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if !known(values[0]) {
+			http.Error(w, "Unknown judge panel", http.StatusBadRequest)
+			return
+		}
+		if err := middleware.SetProfileJudgePanel(suiteName, profile, values[0]); err != nil {
+			log.Printf("Error saving panel of profile %s: %v", profile, err)
+			http.Error(w, "Error saving judge panels", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.Redirect(w, r, "/judges", http.StatusSeeOther)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"llm-tournament/middleware"
+	"llm-tournament/testutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func postJudgesForm(t *testing.T, handle http.HandlerFunc, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	handle(rr, req)
+	return rr
+}
+
+func TestJudges_SaveAndAssignPanels(t *testing.T) {
+	defer setupEvaluationTestDB(t)()
+	handler := NewHandlerWithDeps(&middleware.SQLiteDataStore{}, &testutil.MockRenderer{})
+
+	rr := postJudgesForm(t, handler.SaveJudge, url.Values{
+		"name": {"mini"}, "provider": {"openai"}, "model": {"gpt-5-mini"}, "temperature": {"0.3"}, "max_tokens": {"400"}, "weight": {"2"},
+	})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after saving the judge, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := postJudgesForm(t, handler.SaveJudge, url.Values{"name": {"bad"}, "provider": {"openai"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a native judge without a model to be rejected, got %d", rr.Code)
+	}
+	if rr := postJudgesForm(t, handler.SaveJudge, url.Values{"name": {"bad"}, "weight": {"heavy"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a non-numeric weight to be rejected, got %d", rr.Code)
+	}
+
+	rr = postJudgesForm(t, handler.SavePanel, url.Values{"name": {"cheap"}, "judges": {"mini", "gpt_5.2"}})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after saving the panel, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := postJudgesForm(t, handler.AssignPanels, url.Values{"suite_panel": {"missing"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown panel to be rejected, got %d", rr.Code)
+	}
+	rr = postJudgesForm(t, handler.AssignPanels, url.Values{"suite_panel": {"cheap"}, "profile:Math": {"default"}, "profile:Poetry": {""}})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after assigning panels, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if value, _ := middleware.GetSuiteSetting("default", middleware.SuiteSettingJudgePanel); value != "cheap" {
+		t.Errorf("expected the suite to use cheap, got %q", value)
+	}
+	if panels, _ := middleware.GetProfileJudgePanels("default"); len(panels) != 1 || panels["Math"] != "default" {
+		t.Errorf("expected only Math to override the suite panel, got %v", panels)
+	}
+
+	renderer := &testutil.MockRenderer{}
+	handler = NewHandlerWithDeps(&middleware.SQLiteDataStore{}, renderer)
+	rr = httptest.NewRecorder()
+	handler.Judges(rr, httptest.NewRequest(http.MethodGet, "/judges", nil))
+	if len(renderer.RenderCalls) != 1 || renderer.RenderCalls[0].Name != "judges.html" {
+		t.Fatalf("expected judges.html to be rendered, got %+v", renderer.RenderCalls)
+	}
+	data := reflect.ValueOf(renderer.RenderCalls[0].Data)
+	if judges := data.FieldByName("Judges").Interface().([]middleware.Judge); len(judges) != 4 {
+		t.Errorf("expected the three seeded judges and mini, got %+v", judges)
+	}
+	if panels := data.FieldByName("Panels").Interface().([]middleware.JudgePanel); len(panels) != 2 || len(panels[0].Judges) != 2 {
+		t.Errorf("expected the cheap and default panels, got %+v", panels)
+	}
+	if data.FieldByName("SuitePanel").String() != "cheap" {
+		t.Errorf("expected the suite panel on the page, got %q", data.FieldByName("SuitePanel").String())
+	}
+
+	if rr := postJudgesForm(t, handler.DeletePanel, url.Values{"name": {"cheap"}}); rr.Code != http.StatusSeeOther {
+		t.Errorf("expected a redirect after deleting the panel, got %d", rr.Code)
+	}
+	if rr := postJudgesForm(t, handler.DeleteJudge, url.Values{"name": {"mini"}}); rr.Code != http.StatusSeeOther {
+		t.Errorf("expected a redirect after deleting the judge, got %d", rr.Code)
+	}
+	if rr := postJudgesForm(t, handler.DeleteJudge, url.Values{"name": {"mini"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected deleting a missing judge to fail, got %d", rr.Code)
+	}
+}
+
+func TestJudges_MethodNotAllowed(t *testing.T) {
+	handler := NewHandlerWithDeps(&MockDataStore{}, &testutil.MockRenderer{})
+	for name, handle := range map[string]http.HandlerFunc{
+		"Judges":       handler.Judges,
+		"SaveJudge":    handler.SaveJudge,
+		"DeleteJudge":  handler.DeleteJudge,
+		"SavePanel":    handler.SavePanel,
+		"DeletePanel":  handler.DeletePanel,
+		"AssignPanels": handler.AssignPanels,
+	} {
+		method := http.MethodGet
+		if name == "Judges" {
+			method = http.MethodPost
+		}
+		rr := httptest.NewRecorder()
+		handle(rr, httptest.NewRequest(method, "/", nil))
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: expected %d, got %d", name, http.StatusMethodNotAllowed, rr.Code)
+		}
+	}
+}
+
+func TestJudgesTemplate_Renders(t *testing.T) {
+	defer changeToProjectRootStats(t)()
+	defer setupEvaluationTestDB(t)()
+	if err := middleware.WriteProfiles([]middleware.Profile{{Name: "Math"}}); err != nil {
+		t.Fatalf("failed to write profiles: %v", err)
+	}
+	if err := middleware.SetProfileJudgePanel("default", "Math", "default"); err != nil {
+		t.Fatalf("failed to assign panel: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	JudgesHandler(rr, httptest.NewRequest(http.MethodGet, "/judges", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"Judge Library", "claude_opus_4.5", `name="profile:Math"`, `value="default" selected`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the page to contain %q", want)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"fmt"
 	"html/template"
 	"llm-tournament/middleware"
 	"net/http"
+	"time"
 )
 
 // MockDataStore implements middleware.DataStore for handler testing with error injection
@@ -15,13 +17,35 @@ type MockDataStore struct {
 	BroadcastResultsFunc func()
 	GetMaskedAPIKeysFunc func() (map[string]string, error)
 	SetAPIKeyFunc        func(provider, key string) error
+	SaveModelConfigFunc  func(suiteName, modelName string, cfg middleware.ModelConfig) error
+	RecordBattleFunc     func(suiteName string, b middleware.Battle) error
 
 	// Mock data
-	Prompts      []middleware.Prompt
-	Profiles     []middleware.Profile
-	Results      map[string]middleware.Result
-	Settings     map[string]string
-	CurrentSuite string
+	Prompts       []middleware.Prompt
+	Profiles      []middleware.Profile
+	Results       map[string]middleware.Result
+	Settings      map[string]string
+	SuiteSettings map[string]string
+	ModelConfigs  map[string]middleware.ModelConfig
+	Battles       []middleware.Battle
+	Candidate     *middleware.BattleCandidate
+	JudgeScores   []middleware.JudgeScore
+	Criteria      []middleware.CriterionScore
+	GoldScores    map[string]int // "model/promptIndex" -> score
+	Samples       []middleware.CalibrationSample
+	Costs         middleware.CostSummary
+	CurrentSuite  string
+	ScoreChanges  []middleware.ScoreChange // Provenance of every WriteResultsWithChange
+	ScoreHistory  []middleware.ScoreHistoryEntry
+	JobRevert     middleware.ScoreRevert
+	Reverted      []int // IDs of reverted score changes
+	RevertedJobs  []int
+	HistoryErr    error
+	Snapshots     []middleware.SuiteSnapshot // Every snapshot taken, auto or named
+	SnapshotDiff  *middleware.SnapshotDiff
+	Restored      []int // IDs of restored snapshots
+	SnapshotErr   error
+	ScaleErr      error // Fails SetScoreScale before it changes anything
 }
 
 func (m *MockDataStore) GetCurrentSuiteID() (int, error) { return 1, nil }
@@ -77,6 +101,126 @@ func (m *MockDataStore) WriteResults(suiteName string, results map[string]middle
 	return nil
 }
 
+func (m *MockDataStore) WriteResultsWithChange(suiteName string, results map[string]middleware.Result, change middleware.ScoreChange) error {
+	m.ScoreChanges = append(m.ScoreChanges, change)
+	return m.WriteResults(suiteName, results)
+}
+
+func (m *MockDataStore) ListScoreHistory(suiteName, modelName string, promptIndex int) ([]middleware.ScoreHistoryEntry, error) {
+	return m.ScoreHistory, m.HistoryErr
+}
+
+func (m *MockDataStore) ListJobScoreChanges(jobID int) ([]middleware.ScoreHistoryEntry, error) {
+	return m.ScoreHistory, m.HistoryErr
+}
+
+func (m *MockDataStore) RevertScoreChange(id int) error {
+	m.Reverted = append(m.Reverted, id)
+	return m.HistoryErr
+}
+
+func (m *MockDataStore) RevertJobScores(jobID int) (middleware.ScoreRevert, error) {
+	m.RevertedJobs = append(m.RevertedJobs, jobID)
+	return m.JobRevert, m.HistoryErr
+}
+
+func (m *MockDataStore) CreateSuiteSnapshot(suiteName, name string, auto bool) (int, error) {
+	if m.SnapshotErr != nil {
+		return 0, m.SnapshotErr
+	}
+	m.Snapshots = append(m.Snapshots, middleware.SuiteSnapshot{ID: len(m.Snapshots) + 1, Name: name, Auto: auto})
+	return len(m.Snapshots), nil
+}
+
+func (m *MockDataStore) ListSuiteSnapshots(suiteName string) ([]middleware.SuiteSnapshot, error) {
+	return m.Snapshots, m.SnapshotErr
+}
+
+func (m *MockDataStore) CompareSuiteSnapshots(id, otherID int) (*middleware.SnapshotDiff, error) {
+	return m.SnapshotDiff, m.SnapshotErr
+}
+
+func (m *MockDataStore) RestoreSuiteSnapshot(id int) error {
+	m.Restored = append(m.Restored, id)
+	return m.SnapshotErr
+}
+
+func (m *MockDataStore) DeleteSuiteSnapshot(id int) error {
+	return m.SnapshotErr
+}
+
+func (m *MockDataStore) SetScoreScale(suiteName string, from, to middleware.ScoreScale, convert bool) error {
+	if m.ScaleErr != nil {
+		return m.ScaleErr
+	}
+	for _, result := range m.Results {
+		for i, score := range result.Scores {
+			if convert && result.IsScored(i) {
+				result.Scores[i] = middleware.ConvertScore(score, from, to)
+			}
+		}
+	}
+	return m.SetSuiteSetting(suiteName, middleware.SuiteSettingScoreScale, to.Name)
+}
+
+func (m *MockDataStore) GetModelConfig(suiteName, modelName string) (*middleware.ModelConfig, error) {
+	if cfg, ok := m.ModelConfigs[modelName]; ok {
+		return &cfg, nil
+	}
+	return nil, nil
+}
+
+func (m *MockDataStore) SaveModelConfig(suiteName, modelName string, cfg middleware.ModelConfig) error {
+	if m.SaveModelConfigFunc != nil {
+		return m.SaveModelConfigFunc(suiteName, modelName, cfg)
+	}
+	if m.ModelConfigs == nil {
+		m.ModelConfigs = make(map[string]middleware.ModelConfig)
+	}
+	m.ModelConfigs[modelName] = cfg
+	return nil
+}
+
+func (m *MockDataStore) RecordBattle(suiteName string, b middleware.Battle) error {
+	if m.RecordBattleFunc != nil {
+		return m.RecordBattleFunc(suiteName, b)
+	}
+	m.Battles = append(m.Battles, b)
+	return nil
+}
+
+func (m *MockDataStore) ListBattles(suiteName string) ([]middleware.Battle, error) {
+	return m.Battles, nil
+}
+
+func (m *MockDataStore) GetBattleCandidate(suiteName string) (*middleware.BattleCandidate, error) {
+	return m.Candidate, nil
+}
+
+func (m *MockDataStore) ListJudgeScores(suiteName string) ([]middleware.JudgeScore, error) {
+	return m.JudgeScores, nil
+}
+
+func (m *MockDataStore) ListCriterionScores(suiteName string) ([]middleware.CriterionScore, error) {
+	return m.Criteria, nil
+}
+
+func (m *MockDataStore) SaveGoldScore(suiteName, modelName string, promptIndex, score int) error {
+	if m.GoldScores == nil {
+		m.GoldScores = make(map[string]int)
+	}
+	m.GoldScores[fmt.Sprintf("%s/%d", modelName, promptIndex)] = score
+	return nil
+}
+
+func (m *MockDataStore) ListCalibrationSamples(suiteName string) ([]middleware.CalibrationSample, error) {
+	return m.Samples, nil
+}
+
+func (m *MockDataStore) GetCostSummary(suiteName string, since time.Time) (middleware.CostSummary, error) {
+	return m.Costs, nil
+}
+
 func (m *MockDataStore) GetSetting(key string) (string, error) {
 	if m.Settings != nil {
 		return m.Settings[key], nil
@@ -91,6 +235,16 @@ func (m *MockDataStore) SetSetting(key, value string) error {
 	m.Settings[key] = value
 	return nil
 }
+func (m *MockDataStore) GetSuiteSetting(suiteName, key string) (string, error) {
+	return m.SuiteSettings[key], nil
+}
+func (m *MockDataStore) SetSuiteSetting(suiteName, key, value string) error {
+	if m.SuiteSettings == nil {
+		m.SuiteSettings = make(map[string]string)
+	}
+	m.SuiteSettings[key] = value
+	return nil
+}
 func (m *MockDataStore) GetAPIKey(provider string) (string, error) { return "", nil }
 func (m *MockDataStore) SetAPIKey(provider, key string) error {
 	if m.SetAPIKeyFunc != nil {
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"llm-tournament/middleware"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 // AddModelHandler handles adding a model (backward compatible wrapper)
@@ -44,7 +48,8 @@ func (h *Handler) AddModel(w http.ResponseWriter, r *http.Request) {
 	if results == nil {
 		results = make(map[string]middleware.Result)
 	}
-	if _, ok := results[modelName]; !ok {
+	_, exists := results[modelName]
+	if !exists {
 		results[modelName] = middleware.Result{Scores: make([]int, len(h.DataStore.ReadPrompts()))}
 	}
 	suiteName := h.DataStore.GetCurrentSuiteName()
@@ -55,11 +60,16 @@ func (h *Handler) AddModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Println("Model added successfully")
+	if !exists {
+		h.autoEvaluate(func(suiteID int) { autoEvaluateModel(suiteID, suiteName, modelName) })
+	}
 	h.DataStore.BroadcastResults()
 	http.Redirect(w, r, "/results", http.StatusSeeOther)
 }
 
-// EditModel handles editing a model
+// EditModel handles editing a model's name and endpoint configuration.
+// Submitting with action=variant saves the configuration under a new model instead,
+// so the same weights can compete with different sampling settings.
 func (h *Handler) EditModel(w http.ResponseWriter, r *http.Request) {
 	log.Println("Handling edit model")
 	modelName := r.URL.Query().Get("model")
@@ -68,6 +78,13 @@ func (h *Handler) EditModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	cfg, err := h.DataStore.GetModelConfig(suiteName, modelName)
+	if err != nil {
+		log.Printf("Error getting model config: %v", err)
+		cfg = nil
+	}
+
 	if r.Method == "POST" {
 		newModelName := r.FormValue("new_model_name")
 		if newModelName == "" {
@@ -75,19 +92,66 @@ func (h *Handler) EditModel(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if _, ok := r.PostForm["base_url"]; ok {
+			cfg, err = parseModelConfigForm(r, cfg)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
 		results := h.DataStore.ReadResults()
-		if _, exists := results[newModelName]; exists {
-			http.Error(w, "Model with this name already exists", http.StatusBadRequest)
+
+		if r.FormValue("action") == "variant" {
+			variantName := r.FormValue("variant_name")
+			if variantName == "" {
+				http.Error(w, "Variant name cannot be empty", http.StatusBadRequest)
+				return
+			}
+			if _, exists := results[variantName]; exists {
+				http.Error(w, "Model with this name already exists", http.StatusBadRequest)
+				return
+			}
+			results[variantName] = middleware.Result{Scores: make([]int, len(h.DataStore.ReadPrompts()))}
+			if err := h.DataStore.WriteResults(suiteName, results); err != nil {
+				log.Printf("Error writing results: %v", err)
+				http.Error(w, "Error writing results", http.StatusInternalServerError)
+				return
+			}
+			if cfg != nil {
+				if err := h.DataStore.SaveModelConfig(suiteName, variantName, *cfg); err != nil {
+					log.Printf("Error saving model config: %v", err)
+					http.Error(w, "Error saving model config", http.StatusInternalServerError)
+					return
+				}
+			}
+			h.DataStore.BroadcastResults()
+			http.Redirect(w, r, "/edit_model?model="+url.QueryEscape(variantName), http.StatusSeeOther)
 			return
 		}
 
-		results[newModelName] = results[modelName]
-		delete(results, modelName)
-		suiteName := h.DataStore.GetCurrentSuiteName()
-		if err := h.DataStore.WriteResults(suiteName, results); err != nil {
-			log.Printf("Error writing results: %v", err)
-			http.Error(w, "Error writing results", http.StatusInternalServerError)
-			return
+		if newModelName != modelName {
+			if _, exists := results[newModelName]; exists {
+				http.Error(w, "Model with this name already exists", http.StatusBadRequest)
+				return
+			}
+
+			results[newModelName] = results[modelName]
+			delete(results, modelName)
+			if err := h.DataStore.WriteResults(suiteName, results); err != nil {
+				log.Printf("Error writing results: %v", err)
+				http.Error(w, "Error writing results", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Renaming recreates the model row, so the configuration is saved under the new name
+		if cfg != nil {
+			if err := h.DataStore.SaveModelConfig(suiteName, newModelName, *cfg); err != nil {
+				log.Printf("Error saving model config: %v", err)
+				http.Error(w, "Error saving model config", http.StatusInternalServerError)
+				return
+			}
 		}
 
 		h.DataStore.BroadcastResults()
@@ -100,13 +164,103 @@ func (h *Handler) EditModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if cfg == nil {
+		cfg = &middleware.ModelConfig{Provider: "openai"}
+	}
+	systemPrompts, err := middleware.ListSystemPrompts()
+	if err != nil {
+		log.Printf("Error loading system prompts: %v", err)
+	}
+	data := struct {
+		Model         string
+		Config        *middleware.ModelConfig
+		Providers     []string
+		SystemPrompts []middleware.SystemPrompt
+	}{
+		Model:         modelName,
+		Config:        cfg,
+		Providers:     middleware.ModelProviders,
+		SystemPrompts: systemPrompts,
+	}
+
 	// Render the edit model form
-	if err := h.Renderer.RenderTemplateSimple(w, "edit_model.html", map[string]string{"Model": modelName}); err != nil {
+	if err := h.Renderer.RenderTemplateSimple(w, "edit_model.html", data); err != nil {
 		log.Printf("Error rendering template: %v", err)
 		http.Error(w, "Error rendering template", http.StatusInternalServerError)
 	}
 }
 
+// parseModelConfigForm applies the edit model form's endpoint fields on top of base
+func parseModelConfigForm(r *http.Request, base *middleware.ModelConfig) (*middleware.ModelConfig, error) {
+	cfg := middleware.ModelConfig{}
+	if base != nil {
+		cfg = *base
+	}
+
+	cfg.Provider = r.FormValue("provider")
+	cfg.BaseURL = strings.TrimSpace(r.FormValue("base_url"))
+	cfg.ModelName = strings.TrimSpace(r.FormValue("endpoint_model"))
+	cfg.APIKey = r.FormValue("api_key")
+	cfg.ClearAPIKey = r.FormValue("clear_api_key") != ""
+	if cfg.ClearAPIKey && cfg.APIKey != "" {
+		return nil, middleware.ErrAPIKeyConflict
+	}
+	cfg.SystemPrompt = r.FormValue("system_prompt")
+	cfg.SystemPromptName = r.FormValue("system_prompt_name")
+	if cfg.SystemPromptName != "" {
+		if sp, err := middleware.GetSystemPrompt(cfg.SystemPromptName); err != nil || sp == nil {
+			return nil, fmt.Errorf("unknown system prompt %q", cfg.SystemPromptName)
+		}
+	}
+
+	var err error
+	if cfg.Temperature, err = formOptionalFloat(r, "temperature", cfg.Temperature); err != nil {
+		return nil, fmt.Errorf("invalid temperature")
+	}
+	if cfg.TopP, err = formOptionalFloat(r, "top_p", cfg.TopP); err != nil {
+		return nil, fmt.Errorf("invalid top_p")
+	}
+
+	cfg.MaxTokens = 0
+	if maxTokens := strings.TrimSpace(r.FormValue("max_tokens")); maxTokens != "" {
+		if cfg.MaxTokens, err = strconv.Atoi(maxTokens); err != nil || cfg.MaxTokens < 0 {
+			return nil, fmt.Errorf("invalid max tokens")
+		}
+	}
+
+	cfg.StopSequences = nil
+	for _, line := range strings.Split(r.FormValue("stop_sequences"), "\n") {
+		if stop := strings.TrimRight(line, "\r"); strings.TrimSpace(stop) != "" {
+			cfg.StopSequences = append(cfg.StopSequences, stop)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// formOptionalFloat reads an optional float field of the form. A submitted value is
+// parsed, so "0" is kept as an explicit 0; an empty field clears the setting (nil) and
+// a field left out of the form keeps current.
+func formOptionalFloat(r *http.Request, field string, current *float64) (*float64, error) {
+	if _, ok := r.Form[field]; !ok {
+		return current, nil
+	}
+	return parseOptionalFloat(r.Form.Get(field))
+}
+
+// parseOptionalFloat parses a float form value, returning nil for an empty field
+func parseOptionalFloat(value string) (*float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
 // DeleteModel handles deleting a model
 func (h *Handler) DeleteModel(w http.ResponseWriter, r *http.Request) {
 	log.Println("Handling delete model")
@@ -128,6 +282,10 @@ func (h *Handler) DeleteModel(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// The model's scores, responses and evaluations go with it
+		if !h.snapshotBefore(w, "deleting model "+modelName) {
+			return
+		}
 		results := h.DataStore.ReadResults()
 		delete(results, modelName)
 		suiteName := h.DataStore.GetCurrentSuiteName()
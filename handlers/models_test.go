@@ -657,3 +657,248 @@ func TestDeleteModelHandler_GET_RenderError(t *testing.T) {
 		t.Errorf("expected status %d on render error, got %d", http.StatusInternalServerError, rr.Code)
 	}
 }
+
+// addTestModel adds a model through the handler so it exists in the current suite
+func addTestModel(t *testing.T, name string) {
+	t.Helper()
+	form := url.Values{}
+	form.Add("model", name)
+	req := httptest.NewRequest("POST", "/add_model", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	AddModelHandler(httptest.NewRecorder(), req)
+}
+
+func TestEditModelHandler_POST_SavesConfig(t *testing.T) {
+	cleanup := setupModelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ENCRYPTION_KEY", strings.Repeat("ab", 32))
+	addTestModel(t, "Local")
+
+	form := url.Values{}
+	form.Add("new_model_name", "Local")
+	form.Add("provider", "openai")
+	form.Add("base_url", "http://localhost:8080/v1")
+	form.Add("endpoint_model", "qwen2.5-7b")
+	form.Add("api_key", "sk-test")
+	form.Add("temperature", "0.3")
+	form.Add("top_p", "")
+	form.Add("max_tokens", "1024")
+	form.Add("system_prompt", "You are helpful.")
+	form.Add("stop_sequences", "###\r\n\r\nEND")
+
+	req := httptest.NewRequest("POST", "/edit_model?model=Local", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	EditModelHandler(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+	}
+
+	cfg, err := middleware.GetModelConfig("default", "Local")
+	if err != nil || cfg == nil {
+		t.Fatalf("expected saved config, got %+v, %v", cfg, err)
+	}
+	if cfg.BaseURL != "http://localhost:8080/v1" || cfg.ModelName != "qwen2.5-7b" || cfg.MaxTokens != 1024 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Temperature == nil || *cfg.Temperature != 0.3 || cfg.TopP != nil {
+		t.Errorf("unexpected sampling: temperature=%v top_p=%v", cfg.Temperature, cfg.TopP)
+	}
+	if !cfg.HasAPIKey {
+		t.Error("expected API key to be stored")
+	}
+	if len(cfg.StopSequences) != 2 || cfg.StopSequences[0] != "###" || cfg.StopSequences[1] != "END" {
+		t.Errorf("unexpected stop sequences: %v", cfg.StopSequences)
+	}
+}
+
+func TestEditModelHandler_POST_ExplicitZeroTemperature(t *testing.T) {
+	restoreDir := changeToProjectRootModels(t)
+	defer restoreDir()
+	cleanup := setupModelsTestDB(t)
+	defer cleanup()
+	addTestModel(t, "Local")
+
+	post := func(form url.Values) {
+		t.Helper()
+		form.Set("new_model_name", "Local")
+		form.Set("base_url", "http://localhost:8080/v1")
+		req := httptest.NewRequest("POST", "/edit_model?model=Local", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		EditModelHandler(rr, req)
+		if rr.Code != http.StatusSeeOther {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+		}
+	}
+
+	post(url.Values{"temperature": {"0"}, "top_p": {"0"}})
+	cfg, err := middleware.GetModelConfig("default", "Local")
+	if err != nil || cfg == nil || cfg.Temperature == nil || *cfg.Temperature != 0 || cfg.TopP == nil || *cfg.TopP != 0 {
+		t.Fatalf("expected temperature and top_p stored as 0, got %+v, %v", cfg, err)
+	}
+
+	rr := httptest.NewRecorder()
+	EditModelHandler(rr, httptest.NewRequest("GET", "/edit_model?model=Local", nil))
+	if body := rr.Body.String(); !strings.Contains(body, `name="temperature"`) || !strings.Contains(body, `value="0"`) {
+		t.Error("expected the form to show the stored 0")
+	}
+
+	// Fields left out of the form keep their value; empty fields clear it
+	post(url.Values{"top_p": {""}})
+	cfg, _ = middleware.GetModelConfig("default", "Local")
+	if cfg.Temperature == nil || *cfg.Temperature != 0 || cfg.TopP != nil {
+		t.Errorf("expected temperature kept at 0 and top_p cleared, got temperature=%v top_p=%v", cfg.Temperature, cfg.TopP)
+	}
+}
+
+func TestEditModelHandler_POST_ClearAPIKey(t *testing.T) {
+	cleanup := setupModelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ENCRYPTION_KEY", strings.Repeat("ab", 32))
+	addTestModel(t, "Local")
+
+	post := func(form url.Values) int {
+		t.Helper()
+		form.Set("new_model_name", "Local")
+		form.Set("base_url", "http://localhost:8080/v1")
+		req := httptest.NewRequest("POST", "/edit_model?model=Local", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		EditModelHandler(rr, req)
+		return rr.Code
+	}
+
+	if code := post(url.Values{"api_key": {"sk-test"}}); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if code := post(url.Values{"api_key": {"sk-other"}, "clear_api_key": {"1"}}); code != http.StatusBadRequest {
+		t.Errorf("expected a new key together with clearing to be rejected, got %d", code)
+	}
+	if cfg, _ := middleware.GetModelConfig("default", "Local"); cfg == nil || !cfg.HasAPIKey {
+		t.Fatalf("expected the key kept, got %+v", cfg)
+	}
+
+	if code := post(url.Values{"clear_api_key": {"1"}}); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if cfg, _ := middleware.GetModelConfig("default", "Local"); cfg == nil || cfg.HasAPIKey {
+		t.Errorf("expected the key removed, got %+v", cfg)
+	}
+}
+
+func TestEditModelHandler_POST_InvalidTemperature(t *testing.T) {
+	cleanup := setupModelsTestDB(t)
+	defer cleanup()
+	addTestModel(t, "Local")
+
+	form := url.Values{}
+	form.Add("new_model_name", "Local")
+	form.Add("base_url", "http://localhost:8080/v1")
+	form.Add("temperature", "hot")
+
+	req := httptest.NewRequest("POST", "/edit_model?model=Local", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	EditModelHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestEditModelHandler_POST_LibrarySystemPrompt(t *testing.T) {
+	cleanup := setupModelsTestDB(t)
+	defer cleanup()
+	addTestModel(t, "Local")
+	if _, err := middleware.SaveSystemPrompt("programming", "", "You write Go."); err != nil {
+		t.Fatalf("failed to save system prompt: %v", err)
+	}
+
+	for name, want := range map[string]int{"missing": http.StatusBadRequest, "programming": http.StatusSeeOther} {
+		form := url.Values{}
+		form.Add("new_model_name", "Local")
+		form.Add("base_url", "http://localhost:8080/v1")
+		form.Add("system_prompt_name", name)
+
+		req := httptest.NewRequest("POST", "/edit_model?model=Local", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		EditModelHandler(rr, req)
+		if rr.Code != want {
+			t.Errorf("%s: expected status %d, got %d: %s", name, want, rr.Code, rr.Body.String())
+		}
+	}
+
+	cfg, err := middleware.GetModelConfig("default", "Local")
+	if err != nil || cfg == nil || cfg.SystemPromptName != "programming" {
+		t.Errorf("expected the model assigned the programming prompt, got %+v (%v)", cfg, err)
+	}
+}
+
+func TestEditModelHandler_POST_RenameKeepsConfig(t *testing.T) {
+	cleanup := setupModelsTestDB(t)
+	defer cleanup()
+	addTestModel(t, "Before")
+
+	if err := middleware.SaveModelConfig("default", "Before", middleware.ModelConfig{BaseURL: "http://x/v1", ModelName: "m"}); err != nil {
+		t.Fatalf("SaveModelConfig failed: %v", err)
+	}
+
+	form := url.Values{}
+	form.Add("new_model_name", "After")
+	req := httptest.NewRequest("POST", "/edit_model?model=Before", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	EditModelHandler(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+
+	cfg, err := middleware.GetModelConfig("default", "After")
+	if err != nil || cfg == nil || cfg.BaseURL != "http://x/v1" {
+		t.Errorf("expected config to follow rename, got %+v, %v", cfg, err)
+	}
+}
+
+func TestEditModelHandler_POST_SaveAsVariant(t *testing.T) {
+	cleanup := setupModelsTestDB(t)
+	defer cleanup()
+	addTestModel(t, "Base")
+
+	form := url.Values{}
+	form.Add("new_model_name", "Base")
+	form.Add("action", "variant")
+	form.Add("variant_name", "Base (t=0.9)")
+	form.Add("base_url", "http://localhost:8080/v1")
+	form.Add("endpoint_model", "llama")
+	form.Add("temperature", "0.9")
+
+	req := httptest.NewRequest("POST", "/edit_model?model=Base", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	EditModelHandler(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+	}
+
+	results := middleware.ReadResults()
+	if _, ok := results["Base"]; !ok {
+		t.Error("original model should still exist")
+	}
+	if _, ok := results["Base (t=0.9)"]; !ok {
+		t.Fatal("variant model should exist")
+	}
+
+	variant, err := middleware.GetModelConfig("default", "Base (t=0.9)")
+	if err != nil || variant == nil || variant.Temperature == nil || *variant.Temperature != 0.9 {
+		t.Errorf("expected variant config with temperature 0.9, got %+v, %v", variant, err)
+	}
+	base, err := middleware.GetModelConfig("default", "Base")
+	if err != nil || base != nil {
+		t.Errorf("original model config should be untouched, got %+v, %v", base, err)
+	}
+}
@@ -117,11 +117,13 @@ func (h *Handler) EditProfile(w http.ResponseWriter, r *http.Request) {
 		if index >= 0 && index < len(profiles) {
 			funcMap := templates.FuncMap
 			err := h.Renderer.Render(w, "edit_profile.html", funcMap, struct {
-				Index   int
-				Profile middleware.Profile
+				Index      int
+				Profile    middleware.Profile
+				RubricJSON string
 			}{
-				Index:   index,
-				Profile: profiles[index],
+				Index:      index,
+				Profile:    profiles[index],
+				RubricJSON: rubricJSON(profiles[index].Rubric),
 			}, "templates/edit_profile.html")
 			if err != nil {
 				log.Printf("Error rendering template: %v", err)
@@ -150,11 +152,16 @@ func (h *Handler) EditProfile(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Profile name cannot be empty", http.StatusBadRequest)
 			return
 		}
+		rubric, ok := formRubric(w, r)
+		if !ok {
+			return
+		}
 		profiles := h.DataStore.ReadProfiles()
 		if index >= 0 && index < len(profiles) {
 			oldProfileName := profiles[index].Name
 			profiles[index].Name = editedProfileName
 			profiles[index].Description = editedProfileDescription
+			profiles[index].Rubric = rubric
 
 			// Update prompts that reference this profile
 			prompts := h.DataStore.ReadPrompts()
@@ -233,6 +240,10 @@ func (h *Handler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
 		}
 		profiles := h.DataStore.ReadProfiles()
 		if index >= 0 && index < len(profiles) {
+			// The profile's prompts lose it
+			if !h.snapshotBefore(w, "deleting profile "+profiles[index].Name) {
+				return
+			}
 			profiles = append(profiles[:index], profiles[index+1:]...)
 		}
 		err = h.DataStore.WriteProfiles(profiles)
@@ -258,6 +269,9 @@ func (h *Handler) ResetProfiles(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Error rendering template", http.StatusInternalServerError)
 		}
 	case http.MethodPost:
+		if !h.snapshotBefore(w, "resetting profiles") {
+			return
+		}
 		err := h.DataStore.WriteProfiles([]middleware.Profile{})
 		if err != nil {
 			log.Printf("Error writing profiles: %v", err)
@@ -2,12 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"llm-tournament/evaluator"
 	"llm-tournament/middleware"
 	"llm-tournament/templates"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 )
 
 var readAll = io.ReadAll
@@ -136,6 +140,8 @@ func (h *Handler) PromptList(w http.ResponseWriter, r *http.Request) {
 		OrderFilter   int
 		ProfileFilter string
 		SearchQuery   string
+		PromptTypes   []evaluator.PromptType
+		Checkers      []evaluator.Checker
 		Suites        []string
 		CurrentSuite  string
 		CurrentPath   string
@@ -144,6 +150,8 @@ func (h *Handler) PromptList(w http.ResponseWriter, r *http.Request) {
 		Prompts:       promptTexts,
 		PromptIndices: promptIndices,
 		Profiles:      profiles,
+		PromptTypes:   evaluator.PromptTypes(),
+		Checkers:      evaluator.Checkers(),
 		OrderFilter:   orderFilterInt,
 		ProfileFilter: profileFilter,
 		SearchQuery:   searchQuery,
@@ -210,6 +218,22 @@ func (h *Handler) AddPrompt(w http.ResponseWriter, r *http.Request) {
 	}
 	solutionText := r.Form.Get("solution")
 	profile := r.Form.Get("profile")
+	promptType, ok := formPromptType(w, r)
+	if !ok {
+		return
+	}
+	checker, checkerConfig, ok := promptChecker(w, r)
+	if !ok {
+		return
+	}
+	language, tests, ok := promptTests(w, r)
+	if !ok {
+		return
+	}
+	rubric, ok := formRubric(w, r)
+	if !ok {
+		return
+	}
 
 	currentSuite := h.DataStore.GetCurrentSuiteName()
 	if currentSuite == "" {
@@ -223,7 +247,17 @@ func (h *Handler) AddPrompt(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prompts = append(prompts, middleware.Prompt{Text: promptText, Solution: solutionText, Profile: profile})
+	prompts = append(prompts, middleware.Prompt{
+		Text:          promptText,
+		Solution:      solutionText,
+		Profile:       profile,
+		Type:          promptType,
+		Checker:       checker,
+		CheckerConfig: checkerConfig,
+		Language:      language,
+		Tests:         tests,
+		Rubric:        rubric,
+	})
 	err = h.DataStore.WritePromptSuite(currentSuite, prompts)
 	if err != nil {
 		log.Printf("Error writing prompts: %v", err)
@@ -231,10 +265,99 @@ func (h *Handler) AddPrompt(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Println("Prompt added successfully")
+	h.autoEvaluate(func(suiteID int) { autoEvaluatePrompt(suiteID, currentSuite, len(prompts)-1) })
 	h.DataStore.BroadcastResults()
 	http.Redirect(w, r, "/prompts", http.StatusSeeOther)
 }
 
+// formPromptType reads the type field of a prompt form, rejecting unknown types.
+// An empty field is the default type.
+func formPromptType(w http.ResponseWriter, r *http.Request) (string, bool) {
+	t, err := evaluator.GetPromptType(r.Form.Get("type"))
+	if err != nil {
+		log.Printf("Invalid prompt type: %v", err)
+		http.Error(w, "Invalid prompt type", http.StatusBadRequest)
+		return "", false
+	}
+	return t.Name, true
+}
+
+// promptChecker reads the checker fields of a prompt form, rejecting unknown checkers
+func promptChecker(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	checker := r.Form.Get("checker")
+	if checker != "" {
+		if _, err := evaluator.GetChecker(checker); err != nil {
+			log.Printf("Invalid checker: %v", err)
+			http.Error(w, "Invalid checker", http.StatusBadRequest)
+			return "", "", false
+		}
+	}
+	return checker, r.Form.Get("checker_config"), true
+}
+
+// promptTests reads the hidden tests of a prompt form: a language and a JSON array of test cases
+func promptTests(w http.ResponseWriter, r *http.Request) (string, []middleware.TestCase, bool) {
+	language := r.Form.Get("language")
+	testsJSON := strings.TrimSpace(r.Form.Get("tests"))
+	if testsJSON == "" {
+		return language, nil, true
+	}
+
+	var tests []middleware.TestCase
+	if err := json.Unmarshal([]byte(testsJSON), &tests); err != nil {
+		log.Printf("Invalid test cases: %v", err)
+		http.Error(w, "Invalid test cases: expected a JSON array of {name, input, expected, code}", http.StatusBadRequest)
+		return "", nil, false
+	}
+	if !slices.Contains(evaluator.CodeLanguages(), language) {
+		log.Printf("Invalid test language: %q", language)
+		http.Error(w, "Hidden tests need a supported language", http.StatusBadRequest)
+		return "", nil, false
+	}
+	return language, tests, true
+}
+
+// formRubric reads the rubric field of a prompt or profile form: a JSON array of
+// {name, weight, descriptor}. An empty field means no rubric.
+func formRubric(w http.ResponseWriter, r *http.Request) ([]middleware.RubricCriterion, bool) {
+	rubric, err := middleware.ParseRubric(r.Form.Get("rubric"))
+	if err != nil {
+		log.Printf("Invalid rubric: %v", err)
+		http.Error(w, "Invalid rubric: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return rubric, true
+}
+
+// formTurns reads the turns field of a prompt form: a JSON array of {role, content}
+// making the prompt a conversation. An empty field means a single-turn prompt.
+func formTurns(w http.ResponseWriter, r *http.Request) ([]middleware.Turn, bool) {
+	data := strings.TrimSpace(r.Form.Get("turns"))
+	if data == "" {
+		return nil, true
+	}
+	var turns []middleware.Turn
+	err := json.Unmarshal([]byte(data), &turns)
+	if err == nil {
+		err = middleware.ValidateTurns(turns)
+	}
+	if err != nil {
+		log.Printf("Invalid turns: %v", err)
+		http.Error(w, "Invalid conversation turns: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return turns, true
+}
+
+// rubricJSON formats a rubric for the rubric field of an edit form
+func rubricJSON(rubric []middleware.RubricCriterion) string {
+	if len(rubric) == 0 {
+		return ""
+	}
+	data, _ := json.MarshalIndent(rubric, "", "  ")
+	return string(data)
+}
+
 // ExportPrompts handles exporting prompts
 func (h *Handler) ExportPrompts(w http.ResponseWriter, r *http.Request) {
 	log.Println("Handling export prompts")
@@ -297,8 +420,32 @@ func (h *Handler) ImportPrompts(w http.ResponseWriter, r *http.Request) {
 			http.Redirect(w, r, "/import_error", http.StatusSeeOther)
 			return
 		}
+		for _, prompt := range prompts {
+			if _, err := evaluator.GetPromptType(prompt.Type); err != nil {
+				log.Printf("Invalid type in imported prompts: %v", err)
+				http.Redirect(w, r, "/import_error", http.StatusSeeOther)
+				return
+			}
+			if err := middleware.ValidateRubric(prompt.Rubric); err != nil {
+				log.Printf("Invalid rubric in imported prompts: %v", err)
+				http.Redirect(w, r, "/import_error", http.StatusSeeOther)
+				return
+			}
+			if err := middleware.ValidateTurns(prompt.Turns); err != nil {
+				log.Printf("Invalid turns in imported prompts: %v", err)
+				http.Redirect(w, r, "/import_error", http.StatusSeeOther)
+				return
+			}
+		}
 
 		// Write the imported prompts
+		if !h.snapshotBefore(w, "importing prompts") {
+			return
+		}
+		existing := make(map[string]bool)
+		for _, p := range h.DataStore.ReadPrompts() {
+			existing[p.Text] = true
+		}
 		err = h.DataStore.WritePrompts(prompts)
 		if err != nil {
 			log.Printf("Error writing prompts: %v", err)
@@ -307,10 +454,20 @@ func (h *Handler) ImportPrompts(w http.ResponseWriter, r *http.Request) {
 		}
 
 		log.Println("Prompts imported successfully from JSON")
+		h.autoEvaluate(func(suiteID int) {
+			suiteName := h.DataStore.GetCurrentSuiteName()
+			for i, p := range prompts {
+				if !existing[p.Text] {
+					autoEvaluatePrompt(suiteID, suiteName, i)
+				}
+			}
+		})
 		h.DataStore.BroadcastResults()
 		http.Redirect(w, r, "/prompts", http.StatusSeeOther)
 	case http.MethodGet:
-		if err := h.Renderer.RenderTemplateSimple(w, "import_prompts.html", nil); err != nil {
+		if err := h.Renderer.RenderTemplateSimple(w, "import_prompts.html", struct {
+			PromptTypes []evaluator.PromptType
+		}{evaluator.PromptTypes()}); err != nil {
 			log.Printf("Error rendering template: %v", err)
 			http.Error(w, "Error rendering template", http.StatusInternalServerError)
 		}
@@ -324,7 +481,7 @@ func (h *Handler) ImportResults(w http.ResponseWriter, r *http.Request) {
 	log.Println("Handling import results")
 	switch r.Method {
 	case http.MethodPost:
-		file, _, err := r.FormFile("results_file")
+		file, header, err := r.FormFile("results_file")
 		if err != nil {
 			log.Printf("Error uploading file: %v", err)
 			http.Redirect(w, r, "/import_error", http.StatusSeeOther)
@@ -341,13 +498,19 @@ func (h *Handler) ImportResults(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Parse JSON data
-		var results map[string]middleware.Result
-		err = json.Unmarshal(data, &results)
+		export, err := parseResultsExport(data)
 		if err != nil {
 			log.Printf("Error parsing JSON: %v", err)
 			http.Redirect(w, r, "/import_error", http.StatusSeeOther)
 			return
 		}
+		from, err := middleware.GetScoreScale(export.Scale)
+		if err != nil {
+			log.Printf("Error importing results: %v", err)
+			http.Redirect(w, r, "/import_error", http.StatusSeeOther)
+			return
+		}
+		results := export.Results
 
 		// Validate imported results
 		if len(results) == 0 {
@@ -356,20 +519,36 @@ func (h *Handler) ImportResults(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Ensure scores arrays match prompts length
+		// Convert the scores onto the suite's scale and make their arrays match the
+		// prompts' length
 		prompts := h.DataStore.ReadPrompts()
+		to := h.scoreScale()
 		for model, result := range results {
+			if from.Name != to.Name {
+				for i, score := range result.Scores {
+					if result.IsScored(i) {
+						result.SetScore(i, middleware.ConvertScore(score, from, to))
+					}
+				}
+			}
 			if len(result.Scores) < len(prompts) {
 				newScores := make([]int, len(prompts))
 				copy(newScores, result.Scores)
 				result.Scores = newScores
-				results[model] = result
 			}
+			results[model] = result
 		}
 
 		// Write the imported results
+		if !h.snapshotBefore(w, "importing "+header.Filename) {
+			return
+		}
+		existing := h.DataStore.ReadResults()
 		suiteName := h.DataStore.GetCurrentSuiteName()
-		err = h.DataStore.WriteResults(suiteName, results)
+		err = h.DataStore.WriteResultsWithChange(suiteName, results, middleware.ScoreChange{
+			Source: middleware.ScoreSourceImport,
+			Note:   header.Filename,
+		})
 		if err != nil {
 			log.Printf("Error writing results: %v", err)
 			http.Error(w, "Error writing results", http.StatusInternalServerError)
@@ -377,6 +556,13 @@ func (h *Handler) ImportResults(w http.ResponseWriter, r *http.Request) {
 		}
 
 		log.Println("Results imported successfully from JSON")
+		h.autoEvaluate(func(suiteID int) {
+			for model := range results {
+				if _, ok := existing[model]; !ok {
+					autoEvaluateModel(suiteID, suiteName, model)
+				}
+			}
+		})
 		h.DataStore.BroadcastResults()
 		http.Redirect(w, r, "/results", http.StatusSeeOther)
 	case http.MethodGet:
@@ -417,14 +603,36 @@ func (h *Handler) EditPrompt(w http.ResponseWriter, r *http.Request) {
 		if index >= 0 && index < len(prompts) {
 			funcMap := templates.FuncMap
 			profiles := h.DataStore.ReadProfiles()
+			testsJSON := ""
+			if len(prompts[index].Tests) > 0 {
+				data, _ := json.MarshalIndent(prompts[index].Tests, "", "  ")
+				testsJSON = string(data)
+			}
+			turnsJSON := ""
+			if len(prompts[index].Turns) > 0 {
+				data, _ := json.MarshalIndent(prompts[index].Turns, "", "  ")
+				turnsJSON = string(data)
+			}
 			err := h.Renderer.Render(w, "edit_prompt.html", funcMap, struct {
-				Index    int
-				Prompt   middleware.Prompt
-				Profiles []middleware.Profile
+				Index       int
+				Prompt      middleware.Prompt
+				Profiles    []middleware.Profile
+				PromptTypes []evaluator.PromptType
+				Checkers    []evaluator.Checker
+				Languages   []string
+				TestsJSON   string
+				RubricJSON  string
+				TurnsJSON   string
 			}{
-				Index:    index,
-				Prompt:   prompts[index],
-				Profiles: profiles,
+				Index:       index,
+				Prompt:      prompts[index],
+				Profiles:    profiles,
+				PromptTypes: evaluator.PromptTypes(),
+				Checkers:    evaluator.Checkers(),
+				Languages:   evaluator.CodeLanguages(),
+				TestsJSON:   testsJSON,
+				RubricJSON:  rubricJSON(prompts[index].Rubric),
+				TurnsJSON:   turnsJSON,
 			}, "templates/edit_prompt.html")
 			if err != nil {
 				log.Printf("Error rendering template: %v", err)
@@ -454,11 +662,38 @@ func (h *Handler) EditPrompt(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Prompt text cannot be empty", http.StatusBadRequest)
 			return
 		}
+		promptType, ok := formPromptType(w, r)
+		if !ok {
+			return
+		}
+		checker, checkerConfig, ok := promptChecker(w, r)
+		if !ok {
+			return
+		}
+		language, tests, ok := promptTests(w, r)
+		if !ok {
+			return
+		}
+		rubric, ok := formRubric(w, r)
+		if !ok {
+			return
+		}
+		turns, ok := formTurns(w, r)
+		if !ok {
+			return
+		}
 		prompts := h.DataStore.ReadPrompts()
 		if index >= 0 && index < len(prompts) {
 			prompts[index].Text = editedPrompt
 			prompts[index].Solution = editedSolution
 			prompts[index].Profile = editedProfile
+			prompts[index].Type = promptType
+			prompts[index].Checker = checker
+			prompts[index].CheckerConfig = checkerConfig
+			prompts[index].Language = language
+			prompts[index].Tests = tests
+			prompts[index].Rubric = rubric
+			prompts[index].Turns = turns
 		}
 		err = h.DataStore.WritePrompts(prompts)
 		if err != nil {
@@ -569,6 +804,9 @@ func (h *Handler) BulkDeletePrompts(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if !h.snapshotBefore(w, fmt.Sprintf("deleting %d of %d prompts", len(prompts)-len(filteredPrompts), len(prompts))) {
+		return
+	}
 	err = h.DataStore.WritePrompts(filteredPrompts)
 	if err != nil {
 		log.Printf("Error writing prompts: %v", err)
@@ -631,6 +869,10 @@ func (h *Handler) DeletePrompt(w http.ResponseWriter, r *http.Request) {
 		}
 		prompts := h.DataStore.ReadPrompts()
 		if index >= 0 && index < len(prompts) {
+			// The prompt's scores, responses and evaluations go with it
+			if !h.snapshotBefore(w, fmt.Sprintf("deleting prompt %d", index+1)) {
+				return
+			}
 			prompts = append(prompts[:index], prompts[index+1:]...)
 		}
 		err = h.DataStore.WritePrompts(prompts)
@@ -737,6 +979,9 @@ func (h *Handler) ResetPrompts(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Error rendering template", http.StatusInternalServerError)
 		}
 	case "POST":
+		if !h.snapshotBefore(w, "resetting prompts") {
+			return
+		}
 		err := h.DataStore.WritePrompts([]middleware.Prompt{})
 		if err != nil {
 			log.Printf("Error writing prompts: %v", err)
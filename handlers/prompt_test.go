@@ -12,6 +12,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 
@@ -75,6 +76,83 @@ func TestAddPromptHandler_Success(t *testing.T) {
 	}
 }
 
+func TestAddPromptHandler_WithChecker(t *testing.T) {
+	cleanup := setupPromptTestDB(t)
+	defer cleanup()
+
+	form := url.Values{}
+	form.Add("prompt", "What is 2+2?")
+	form.Add("solution", "4")
+	form.Add("checker", "numeric")
+	form.Add("checker_config", "0.5")
+
+	req := httptest.NewRequest("POST", "/add_prompt", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+	AddPromptHandler(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	prompts := middleware.ReadPrompts()
+	if len(prompts) != 1 || prompts[0].Checker != "numeric" || prompts[0].CheckerConfig != "0.5" {
+		t.Errorf("expected numeric checker with config 0.5, got %+v", prompts)
+	}
+}
+
+func TestAddPromptHandler_UnknownChecker(t *testing.T) {
+	cleanup := setupPromptTestDB(t)
+	defer cleanup()
+
+	form := url.Values{}
+	form.Add("prompt", "What is 2+2?")
+	form.Add("checker", "fuzzy")
+
+	req := httptest.NewRequest("POST", "/add_prompt", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+	AddPromptHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if len(middleware.ReadPrompts()) != 0 {
+		t.Error("expected no prompt to be added")
+	}
+}
+
+func TestAddPromptHandler_TypeExported(t *testing.T) {
+	cleanup := setupPromptTestDB(t)
+	defer cleanup()
+
+	add := func(promptType string) int {
+		form := url.Values{"prompt": {"What is 2+2?"}, "solution": {"4"}, "type": {promptType}}
+		req := httptest.NewRequest("POST", "/add_prompt", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		AddPromptHandler(rr, req)
+		return rr.Code
+	}
+	if code := add("essay"); code != http.StatusBadRequest {
+		t.Errorf("expected an unknown type to be rejected, got %d", code)
+	}
+	if code := add("math"); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+
+	rr := httptest.NewRecorder()
+	ExportPromptsHandler(rr, httptest.NewRequest("GET", "/export_prompts", nil))
+	var prompts []middleware.Prompt
+	if err := json.Unmarshal(rr.Body.Bytes(), &prompts); err != nil {
+		t.Fatalf("failed to unmarshal exported JSON: %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].Type != "math" {
+		t.Errorf("expected the math prompt to be exported with its type, got %+v", prompts)
+	}
+}
+
 func TestAddPromptHandler_EmptyText(t *testing.T) {
 	cleanup := setupPromptTestDB(t)
 	defer cleanup()
@@ -145,6 +223,158 @@ func TestEditPromptHandler_POST_Success(t *testing.T) {
 	}
 }
 
+func TestEditPromptHandler_POST_SetsChecker(t *testing.T) {
+	cleanup := setupPromptTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePrompts([]middleware.Prompt{{Text: "Capital of France?", Solution: "Paris"}}); err != nil {
+		t.Fatalf("WritePrompts failed: %v", err)
+	}
+
+	editForm := url.Values{}
+	editForm.Add("index", "0")
+	editForm.Add("prompt", "Capital of France?")
+	editForm.Add("solution", "Paris")
+	editForm.Add("checker", "normalized")
+
+	editReq := httptest.NewRequest("POST", "/edit_prompt", strings.NewReader(editForm.Encode()))
+	editReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	editRR := httptest.NewRecorder()
+	EditPromptHandler(editRR, editReq)
+
+	if editRR.Code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, editRR.Code)
+	}
+	if prompts := middleware.ReadPrompts(); prompts[0].Checker != "normalized" {
+		t.Errorf("expected normalized checker, got %q", prompts[0].Checker)
+	}
+
+	// Clearing the checker hands the prompt back to the judges
+	editForm.Set("checker", "")
+	editReq = httptest.NewRequest("POST", "/edit_prompt", strings.NewReader(editForm.Encode()))
+	editReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	EditPromptHandler(httptest.NewRecorder(), editReq)
+	if prompts := middleware.ReadPrompts(); prompts[0].Checker != "" {
+		t.Errorf("expected checker to be cleared, got %q", prompts[0].Checker)
+	}
+}
+
+func TestEditPromptHandler_POST_HiddenTests(t *testing.T) {
+	cleanup := setupPromptTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePrompts([]middleware.Prompt{{Text: "Add two numbers"}}); err != nil {
+		t.Fatalf("WritePrompts failed: %v", err)
+	}
+
+	post := func(language, tests string) int {
+		form := url.Values{}
+		form.Add("index", "0")
+		form.Add("prompt", "Add two numbers")
+		form.Add("language", language)
+		form.Add("tests", tests)
+		req := httptest.NewRequest("POST", "/edit_prompt", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		EditPromptHandler(rr, req)
+		return rr.Code
+	}
+
+	if code := post("python", `[{"name": "adds", "input": "2 3", "expected": "5"}]`); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	prompts := middleware.ReadPrompts()
+	if prompts[0].Language != "python" || len(prompts[0].Tests) != 1 || prompts[0].Tests[0].Expected != "5" {
+		t.Errorf("expected python prompt with one test, got %+v", prompts[0])
+	}
+
+	if code := post("python", "not json"); code != http.StatusBadRequest {
+		t.Errorf("expected status %d for invalid JSON, got %d", http.StatusBadRequest, code)
+	}
+	if code := post("", `[{"input": "1"}]`); code != http.StatusBadRequest {
+		t.Errorf("expected status %d for tests without a language, got %d", http.StatusBadRequest, code)
+	}
+}
+
+func TestEditPromptHandler_POST_Rubric(t *testing.T) {
+	cleanup := setupPromptTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePrompts([]middleware.Prompt{{Text: "Explain recursion"}}); err != nil {
+		t.Fatalf("WritePrompts failed: %v", err)
+	}
+
+	post := func(rubric string) int {
+		form := url.Values{}
+		form.Add("index", "0")
+		form.Add("prompt", "Explain recursion")
+		form.Add("rubric", rubric)
+		req := httptest.NewRequest("POST", "/edit_prompt", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		EditPromptHandler(rr, req)
+		return rr.Code
+	}
+
+	if code := post(`[{"name": "correctness", "weight": 3}, {"name": "clarity", "weight": 1, "descriptor": "No jargon"}]`); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	prompts := middleware.ReadPrompts()
+	if len(prompts[0].Rubric) != 2 || prompts[0].Rubric[1].Descriptor != "No jargon" {
+		t.Errorf("expected a two-criterion rubric, got %+v", prompts[0].Rubric)
+	}
+
+	for _, bad := range []string{"not json", `[{"name": "clarity", "weight": 0}]`} {
+		if code := post(bad); code != http.StatusBadRequest {
+			t.Errorf("expected status %d for %s, got %d", http.StatusBadRequest, bad, code)
+		}
+	}
+	if code := post(""); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if prompts := middleware.ReadPrompts(); prompts[0].Rubric != nil {
+		t.Errorf("expected a blank rubric to clear it, got %+v", prompts[0].Rubric)
+	}
+}
+
+func TestEditPromptHandler_POST_Turns(t *testing.T) {
+	cleanup := setupPromptTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePrompts([]middleware.Prompt{{Text: "What is my name?"}}); err != nil {
+		t.Fatalf("WritePrompts failed: %v", err)
+	}
+
+	post := func(turns string) int {
+		form := url.Values{"index": {"0"}, "prompt": {"What is my name?"}, "turns": {turns}}
+		req := httptest.NewRequest("POST", "/edit_prompt", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		EditPromptHandler(rr, req)
+		return rr.Code
+	}
+
+	if code := post(`[{"role": "user", "content": "I am Ada."}, {"role": "assistant", "content": "Hi Ada."}]`); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	prompts := middleware.ReadPrompts()
+	if len(prompts[0].Turns) != 2 || prompts[0].Turns[1].Content != "Hi Ada." {
+		t.Errorf("expected a two-turn conversation, got %+v", prompts[0].Turns)
+	}
+
+	for _, bad := range []string{"not json", `[{"role": "user", "content": "Hi"}]`} {
+		if code := post(bad); code != http.StatusBadRequest {
+			t.Errorf("expected status %d for %s, got %d", http.StatusBadRequest, bad, code)
+		}
+	}
+	if code := post(""); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if prompts := middleware.ReadPrompts(); prompts[0].Turns != nil {
+		t.Errorf("expected blank turns to make it a single-turn prompt again, got %+v", prompts[0].Turns)
+	}
+}
+
 func TestEditPromptHandler_POST_EmptyText(t *testing.T) {
 	cleanup := setupPromptTestDB(t)
 	defer cleanup()
@@ -958,6 +1188,26 @@ func TestImportPromptsHandler_POST_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestImportPromptsHandler_POST_UnknownType(t *testing.T) {
+	cleanup := setupPromptTestDB(t)
+	defer cleanup()
+
+	jsonData, _ := json.Marshal([]middleware.Prompt{{Text: "Imported Prompt", Type: "essay"}})
+	body, contentType := createMultipartFormFile(t, "prompts_file", "prompts.json", jsonData)
+	req := httptest.NewRequest("POST", "/import_prompts", body)
+	req.Header.Set("Content-Type", contentType)
+
+	rr := httptest.NewRecorder()
+	ImportPromptsHandler(rr, req)
+
+	if location := rr.Header().Get("Location"); !strings.Contains(location, "import_error") {
+		t.Errorf("expected redirect to import_error, got %q", location)
+	}
+	if len(middleware.ReadPrompts()) != 0 {
+		t.Error("expected no prompts to be imported")
+	}
+}
+
 func TestImportPromptsHandler_ReadAllError(t *testing.T) {
 	cleanup := setupPromptTestDB(t)
 	defer cleanup()
@@ -2647,3 +2897,50 @@ func TestImportResultsHandler_POST_ShorterScores(t *testing.T) {
 		t.Errorf("expected status %d (redirect), got %d", http.StatusSeeOther, rr.Code)
 	}
 }
+
+func TestImportResults_ConvertsExportedScale(t *testing.T) {
+	source := &MockDataStore{
+		Results:       map[string]middleware.Result{"Model A": {Scores: []int{80, 0}}},
+		SuiteSettings: map[string]string{},
+	}
+	rr := httptest.NewRecorder()
+	(&Handler{DataStore: source, Renderer: &MockRenderer{}}).ExportResults(rr, httptest.NewRequest("GET", "/export_results", nil))
+	if !strings.Contains(rr.Body.String(), `"scale": "fifths"`) {
+		t.Fatalf("expected the export to name its scale, got %s", rr.Body.String())
+	}
+
+	target := &MockDataStore{
+		Prompts:       []middleware.Prompt{{Text: "Prompt 1"}, {Text: "Prompt 2"}},
+		SuiteSettings: map[string]string{middleware.SuiteSettingScoreScale: middleware.ScoreScaleLikert},
+	}
+	importInto := func(data []byte) int {
+		body, contentType := createMultipartFormFile(t, "results_file", "results.json", data)
+		req := httptest.NewRequest("POST", "/import_results", body)
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		(&Handler{DataStore: target, Renderer: &MockRenderer{}}).ImportResults(rr, req)
+		return rr.Code
+	}
+
+	if code := importInto(rr.Body.Bytes()); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if got := target.Results["Model A"].Scores; !slices.Equal(got, []int{4, 0}) {
+		t.Errorf("expected the scores converted to likert, got %v", got)
+	}
+
+	// Bare maps from before exports named their scale are read as fifths
+	if code := importInto([]byte(`{"Model B": {"scores": [100, 20]}}`)); code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, code)
+	}
+	if got := target.Results["Model B"].Scores; !slices.Equal(got, []int{5, 2}) {
+		t.Errorf("expected the legacy scores converted to likert, got %v", got)
+	}
+
+	if code := importInto([]byte(`{"scale": "stars", "results": {"Model C": {"scores": [1]}}}`)); code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect for an unknown scale, got %d", code)
+	}
+	if _, ok := target.Results["Model C"]; ok {
+		t.Error("expected results on an unknown scale to be rejected")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"llm-tournament/middleware"
 	"llm-tournament/templates"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
 	"sort"
@@ -40,6 +41,7 @@ func initRand() *rand.Rand {
 type GroupedPrompt struct {
 	Index       int
 	Text        string
+	Type        string
 	ProfileID   string
 	ProfileName string
 }
@@ -136,6 +138,7 @@ func (h *Handler) Results(w http.ResponseWriter, r *http.Request) {
 		orderedPrompts = append(orderedPrompts, GroupedPrompt{
 			Index:       i,
 			Text:        prompt.Text,
+			Type:        prompt.Type,
 			ProfileID:   group.ID,
 			ProfileName: profileName,
 		})
@@ -182,35 +185,25 @@ func (h *Handler) Results(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pageName := templates.PageNameResults
+	scale := h.scoreScale()
 	promptTexts := make([]string, len(prompts))
 	for i, prompt := range prompts {
 		promptTexts[i] = prompt.Text
 	}
 	resultsForTemplate := make(map[string]middleware.Result)
 	for model, result := range filteredResults {
-		// Initialize scores array if nil
-		if result.Scores == nil {
-			result.Scores = make([]int, len(prompts))
-		}
-
-		// Ensure scores array matches prompts length
-		if len(result.Scores) != len(prompts) {
-			newScores := make([]int, len(prompts))
-			copy(newScores, result.Scores)
-			result.Scores = newScores
-		}
-
-		// Ensure all scores are valid (0-100)
-		for i, score := range result.Scores {
-			if score < 0 || score > 100 {
-				result.Scores[i] = 0
+		// Match the prompts and keep only the cells that hold a score on the suite's
+		// scale; anything else shows as unscored
+		display := middleware.Result{Scores: make([]int, len(prompts)), Scored: make([]bool, len(prompts))}
+		for i := range display.Scores {
+			if !result.IsScored(i) {
+				continue
+			}
+			if score := result.Scores[i]; score >= scale.Min && score <= scale.Max {
+				display.SetScore(i, scale.Snap(score))
 			}
 		}
-
-		// Create a new Result struct to ensure proper initialization
-		resultsForTemplate[model] = middleware.Result{
-			Scores: result.Scores,
-		}
+		resultsForTemplate[model] = display
 	}
 	modelPassPercentages := make(map[string]float64)
 	modelTotalScores := make(map[string]int)
@@ -225,13 +218,31 @@ func (h *Handler) Results(w http.ResponseWriter, r *http.Request) {
 		}
 		// Avoid division by zero when there are no prompts
 		if len(prompts) > 0 {
-			modelPassPercentages[model] = float64(totalScore) / float64(len(prompts)*100) * 100
+			modelPassPercentages[model] = float64(totalScore) / float64(scale.MaxTotal(len(prompts))) * 100
 		} else {
 			modelPassPercentages[model] = 0
 		}
 		modelTotalScores[model] = totalScore
 	}
 
+	// Break the scores down by rubric criterion; a selected criterion is also shown
+	// prompt by prompt for the listed models
+	criterionScores, err := h.DataStore.ListCriterionScores(h.DataStore.GetCurrentSuiteName())
+	if err != nil {
+		log.Printf("Warning: failed to list criterion scores: %v", err)
+	}
+	criterion := r.FormValue("criterion")
+	var criterionRows []criterionRow
+	if criterion != "" {
+		listed := make([]string, 0, len(filteredResults))
+		for _, model := range models {
+			if _, ok := filteredResults[model]; ok {
+				listed = append(listed, model)
+			}
+		}
+		criterionRows = criterionCells(criterionScores, criterion, promptTexts, listed)
+	}
+
 	// Log the data we're about to send to the template for debugging
 	if len(models) > 0 && len(promptTexts) > 0 {
 		log.Printf("First model: %s, scores: %v", models[0], resultsForTemplate[models[0]].Scores)
@@ -249,6 +260,10 @@ func (h *Handler) Results(w http.ResponseWriter, r *http.Request) {
 		SearchQuery     string
 		ProfileGroups   []*middleware.ProfileGroup
 		OrderedPrompts  []GroupedPrompt
+		ByCriterion     criterionBreakdown
+		Criterion       string
+		CriterionRows   []criterionRow
+		Scale           middleware.ScoreScale
 		CurrentPath     string
 	}{
 		PageName:        pageName,
@@ -262,10 +277,14 @@ func (h *Handler) Results(w http.ResponseWriter, r *http.Request) {
 		SearchQuery:     searchQuery,
 		ProfileGroups:   profileGroups,
 		OrderedPrompts:  orderedPrompts,
+		ByCriterion:     newCriterionBreakdown(criterionScores),
+		Criterion:       criterion,
+		CriterionRows:   criterionRows,
+		Scale:           scale,
 		CurrentPath:     "/results",
 	}
 
-	err := h.Renderer.Render(w, "results.html", templates.FuncMap, templateData, "templates/results.html", "templates/nav.html")
+	err = h.Renderer.Render(w, "results.html", templates.FuncMap, templateData, "templates/results.html", "templates/nav.html")
 	if err != nil {
 		log.Printf("Error rendering template: %v", err)
 		http.Error(w, "Error rendering template", http.StatusInternalServerError)
@@ -306,14 +325,18 @@ func (h *Handler) UpdateResult(w http.ResponseWriter, r *http.Request) {
 		result.Scores = append(result.Scores, make([]int, len(prompts)-len(result.Scores))...)
 	}
 	if promptIndex >= 0 && promptIndex < len(result.Scores) {
+		scale := h.scoreScale()
 		if pass {
-			result.Scores[promptIndex] = 100
+			result.SetScore(promptIndex, scale.Max)
 		} else {
-			result.Scores[promptIndex] = 0
+			result.SetScore(promptIndex, scale.Min)
 		}
 	}
 	results[model] = result
-	err = h.DataStore.WriteResults(suiteName, results)
+	err = h.DataStore.WriteResultsWithChange(suiteName, results, middleware.ScoreChange{
+		Source: middleware.ScoreSourceManual,
+		Note:   r.Form.Get("note"),
+	})
 	if err != nil {
 		log.Printf("Error writing results: %v", err)
 		http.Error(w, "Error writing results", http.StatusInternalServerError)
@@ -341,6 +364,9 @@ func (h *Handler) ResetResults(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Error rendering template", http.StatusInternalServerError)
 		}
 	case "POST":
+		if !h.snapshotBefore(w, "resetting results") {
+			return
+		}
 		emptyResults := make(map[string]middleware.Result)
 		suiteName := h.DataStore.GetCurrentSuiteName()
 		err := h.DataStore.WriteResults(suiteName, emptyResults)
@@ -367,6 +393,9 @@ func (h *Handler) ConfirmRefreshResults(w http.ResponseWriter, r *http.Request)
 			http.Error(w, "Error rendering template", http.StatusInternalServerError)
 		}
 	case "POST":
+		if !h.snapshotBefore(w, "refreshing results") {
+			return
+		}
 		results := h.DataStore.ReadResults()
 		for model := range results {
 			results[model] = middleware.Result{
@@ -374,7 +403,10 @@ func (h *Handler) ConfirmRefreshResults(w http.ResponseWriter, r *http.Request)
 			}
 		}
 		suiteName := h.DataStore.GetCurrentSuiteName()
-		err := h.DataStore.WriteResults(suiteName, results)
+		err := h.DataStore.WriteResultsWithChange(suiteName, results, middleware.ScoreChange{
+			Source: middleware.ScoreSourceManual,
+			Note:   "Refreshed results",
+		})
 		if err != nil {
 			log.Printf("Error writing results: %v", err)
 			http.Error(w, "Error writing results", http.StatusInternalServerError)
@@ -398,12 +430,18 @@ func (h *Handler) RefreshResults(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Error rendering template", http.StatusInternalServerError)
 		}
 	case "POST":
+		if !h.snapshotBefore(w, "refreshing results") {
+			return
+		}
 		results := h.DataStore.ReadResults()
 		for model := range results {
 			results[model] = middleware.Result{Scores: make([]int, len(h.DataStore.ReadPrompts()))}
 		}
 		suiteName := h.DataStore.GetCurrentSuiteName()
-		err := h.DataStore.WriteResults(suiteName, results)
+		err := h.DataStore.WriteResultsWithChange(suiteName, results, middleware.ScoreChange{
+			Source: middleware.ScoreSourceManual,
+			Note:   "Refreshed results",
+		})
 		if err != nil {
 			log.Printf("Error writing results: %v", err)
 			http.Error(w, "Error writing results", http.StatusInternalServerError)
@@ -455,22 +493,29 @@ func (h *Handler) EvaluateResultHandler(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		// Update the score (ensure it's within 0-100 range)
-		if score < 0 {
-			score = 0
-		} else if score > 100 {
-			score = 100
-		}
-		result.Scores[index] = score
+		// Keep the score on the suite's scale
+		scale := h.scoreScale()
+		score = scale.Snap(score)
+		result.SetScore(index, score)
 		results[model] = result
 
 		// Write updated results
-		err = h.DataStore.WriteResults(h.DataStore.GetCurrentSuiteName(), results)
+		suiteName := h.DataStore.GetCurrentSuiteName()
+		err = h.DataStore.WriteResultsWithChange(suiteName, results, middleware.ScoreChange{
+			Source: middleware.ScoreSourceManual,
+			Note:   r.FormValue("note"),
+		})
 		if err != nil {
 			http.Error(w, "Failed to save results", http.StatusInternalServerError)
 			return
 		}
 
+		// Hand-graded cells are the gold labels judges are calibrated against, kept on
+		// the judges' 0-100 range
+		if err := h.DataStore.SaveGoldScore(suiteName, model, index, int(math.Round(scale.Percent(score)))); err != nil {
+			log.Printf("Error saving gold score: %v", err)
+		}
+
 		// Broadcast updated results to all clients
 		h.DataStore.BroadcastResults()
 
@@ -484,6 +529,7 @@ func (h *Handler) EvaluateResultHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get current score for this model/prompt
+	scale := h.scoreScale()
 	results := h.DataStore.ReadResults()
 	currentScore := 0
 	if result, exists := results[model]; exists {
@@ -495,10 +541,12 @@ func (h *Handler) EvaluateResultHandler(w http.ResponseWriter, r *http.Request)
 	// Get the prompt text and solution for display
 	prompts := h.DataStore.ReadPrompts()
 	var promptText, solution string
+	var turns []middleware.Turn
 	promptIndex, err := strconv.Atoi(promptIndexStr)
 	if err == nil && promptIndex >= 0 && promptIndex < len(prompts) {
 		promptText = prompts[promptIndex].Text
 		solution = prompts[promptIndex].Solution
+		turns = prompts[promptIndex].Turns
 	}
 
 	// Get model response if available
@@ -522,32 +570,53 @@ func (h *Handler) EvaluateResultHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	var lastEvaluation *middleware.EvaluationResult
+	var systemPrompt *middleware.ResolvedSystemPrompt
+	if modelID > 0 && promptID > 0 {
+		lastEvaluation, err = middleware.GetLatestEvaluationResult(modelID, promptID)
+		if err != nil {
+			log.Printf("Error loading last evaluation: %v", err)
+		}
+		systemPrompt, err = middleware.ResponseSystemPrompt(modelID, promptID)
+		if err != nil {
+			log.Printf("Error loading system prompt: %v", err)
+		}
+	}
+
 	data := struct {
-		PageName      string
-		Model         string
-		PromptIndex   string
-		ScoreOptions  map[string]int
-		CurrentScore  int
-		PromptText    string
-		Solution      string
-		TotalPrompts  int
-		ModelResponse string
-		ModelID       int
-		PromptID      int
-		CurrentPath   string
+		PageName       string
+		Model          string
+		PromptIndex    string
+		Scale          middleware.ScoreScale
+		ScoreOptions   []middleware.ScoreOption
+		CurrentScore   int
+		PromptText     string
+		Turns          []middleware.Turn
+		Solution       string
+		TotalPrompts   int
+		ModelResponse  string
+		ModelID        int
+		PromptID       int
+		LastEvaluation *middleware.EvaluationResult
+		SystemPrompt   *middleware.ResolvedSystemPrompt
+		CurrentPath    string
 	}{
-		PageName:      templates.PageNameEvaluate,
-		Model:         model,
-		PromptIndex:   promptIndexStr,
-		ScoreOptions:  templates.ScoreOptions,
-		CurrentScore:  currentScore,
-		PromptText:    promptText,
-		Solution:      solution,
-		TotalPrompts:  len(prompts),
-		ModelResponse: modelResponse,
-		ModelID:       modelID,
-		PromptID:      promptID,
-		CurrentPath:   "/evaluate",
+		PageName:       templates.PageNameEvaluate,
+		Model:          model,
+		PromptIndex:    promptIndexStr,
+		Scale:          scale,
+		ScoreOptions:   scale.Options(),
+		CurrentScore:   currentScore,
+		PromptText:     promptText,
+		Turns:          turns,
+		Solution:       solution,
+		TotalPrompts:   len(prompts),
+		ModelResponse:  modelResponse,
+		ModelID:        modelID,
+		PromptID:       promptID,
+		LastEvaluation: lastEvaluation,
+		SystemPrompt:   systemPrompt,
+		CurrentPath:    "/evaluate",
 	}
 
 	err = h.Renderer.Render(w, "evaluate.html", templates.FuncMap, data, "templates/evaluate.html", "templates/nav.html")
@@ -558,13 +627,35 @@ func (h *Handler) EvaluateResultHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// resultsExport is the file written by ExportResults: the grid scores along with the
+// scale they are on, so importing them into a suite on another scale converts them
+type resultsExport struct {
+	Scale   string                       `json:"scale"`
+	Results map[string]middleware.Result `json:"results"`
+}
+
+// parseResultsExport reads an exported results file. Files from before exports recorded
+// their scale are a bare map of results on the default scale.
+func parseResultsExport(data []byte) (resultsExport, error) {
+	var export resultsExport
+	if err := json.Unmarshal(data, &export); err == nil && export.Scale != "" {
+		return export, nil
+	}
+	export = resultsExport{}
+	err := json.Unmarshal(data, &export.Results)
+	return export, err
+}
+
 // ExportResults handles export results
 func (h *Handler) ExportResults(w http.ResponseWriter, r *http.Request) {
 	log.Println("Handling export results")
-	results := h.DataStore.ReadResults()
+	export := resultsExport{
+		Scale:   h.scoreScale().Name,
+		Results: h.DataStore.ReadResults(),
+	}
 
 	// Convert results to JSON
-	jsonData, _ := json.MarshalIndent(results, "", "  ")
+	jsonData, _ := json.MarshalIndent(export, "", "  ")
 
 	// Set headers for JSON download
 	w.Header().Set("Content-Type", "application/json")
@@ -614,6 +705,10 @@ func (h *Handler) UpdateMockResults(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.snapshotBefore(w, "loading mock results") {
+		return
+	}
+
 	// Use client-provided scores instead of generating new ones
 	log.Println("Using client-provided scores for mock data")
 
@@ -888,8 +983,10 @@ func (h *Handler) UpdateMockResults(w http.ResponseWriter, r *http.Request) {
 						tierIndex := (len(secondSuiteModels) - 1) / len(secondSuiteTiers)
 						for _, promptID := range promptIDs {
 							score := GetRandomScoreForTierWrapper(tierIndex)
-							_, err = db.Exec("INSERT INTO scores (model_id, prompt_id, score) VALUES (?, ?, ?)",
-								modelID, promptID, score)
+							err = middleware.WriteScore(db, modelID, promptID, score, middleware.ScoreChange{
+								Source: middleware.ScoreSourceRandomize,
+								Note:   "Mock data",
+							})
 							if err != nil {
 								log.Printf("Error inserting score for model %s prompt %d: %v", modelName, promptID, err)
 							}
@@ -929,27 +1026,38 @@ func (h *Handler) UpdateMockResults(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Validate that all scores are legitimate values: 0, 20, 40, 60, 80, 100
+	// Validate that all scores are legitimate values: 0, 20, 40, 60, 80, 100. Mock
+	// scores are generated on the default scale and converted onto the suite's.
+	scale := h.scoreScale()
+	defaultScale := middleware.ResolveScoreScale(middleware.DefaultScoreScale)
 	for model, result := range results {
+		converted := middleware.Result{Scores: make([]int, len(result.Scores)), Scored: make([]bool, len(result.Scores))}
 		for i, score := range result.Scores {
+			if !result.IsScored(i) {
+				continue
+			}
 			// Only allow valid score values
 			switch score {
 			case 0, 20, 40, 60, 80, 100:
 				// Valid score, keep it
 			default:
-				// Invalid score, set to 0
-				log.Printf("Correcting invalid score %d for model %s prompt %d", score, model, i)
-				result.Scores[i] = 0
+				// Invalid score, leave the cell unscored
+				log.Printf("Dropping invalid score %d for model %s prompt %d", score, model, i)
+				continue
 			}
+			converted.SetScore(i, middleware.ConvertScore(score, defaultScale, scale))
 		}
-		results[model] = result
+		results[model] = converted
 	}
 
 	// Skip the evenly distributed tier generation since we're using client scores
 
 	// Save the evenly distributed mock results
 	suiteName := h.DataStore.GetCurrentSuiteName()
-	err = h.DataStore.WriteResults(suiteName, results)
+	err = h.DataStore.WriteResultsWithChange(suiteName, results, middleware.ScoreChange{
+		Source: middleware.ScoreSourceRandomize,
+		Note:   "Mock data",
+	})
 	if err != nil {
 		log.Printf("Error writing mock results: %v", err)
 		http.Error(w, "Error saving mock results", http.StatusInternalServerError)
@@ -1004,7 +1112,7 @@ func (h *Handler) UpdateMockResults(w http.ResponseWriter, r *http.Request) {
 				"INSERT INTO model_responses (model_id, prompt_id, response_text, response_source) "+
 					"VALUES (?, ?, ?, 'mock') "+
 					"ON CONFLICT(model_id, prompt_id) DO UPDATE SET "+
-					"response_text = excluded.response_text, response_source = 'mock', updated_at = CURRENT_TIMESTAMP",
+					"response_text = excluded.response_text, response_source = 'mock', system_prompt_version_id = NULL, updated_at = CURRENT_TIMESTAMP",
 				modelID, promptID, mockResponse)
 			if err != nil {
 				log.Printf("Error inserting mock response for model %s prompt %d: %v", modelName, promptIdx, err)
@@ -1028,7 +1136,7 @@ func (h *Handler) UpdateMockResults(w http.ResponseWriter, r *http.Request) {
 		totalScores[model] = totalScore
 		// Avoid division by zero when there are no prompts
 		if len(prompts) > 0 {
-			passPercentages[model] = float64(totalScore) / float64(len(prompts)*100) * 100
+			passPercentages[model] = float64(totalScore) / float64(scale.MaxTotal(len(prompts))) * 100
 		} else {
 			passPercentages[model] = 0
 		}
@@ -1126,6 +1234,9 @@ func (h *Handler) RandomizeScores(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if !h.snapshotBefore(w, "randomizing scores") {
+		return
+	}
 
 	db := middleware.GetDB()
 	suiteID, err := middleware.GetCurrentSuiteID()
@@ -1175,6 +1286,9 @@ func (h *Handler) RandomizeScores(w http.ResponseWriter, r *http.Request) {
 		promptIDs = append(promptIDs, id)
 	}
 
+	// Scores are spread over the default scale and converted onto the suite's
+	scale := h.scoreScale()
+	defaultScale := middleware.ResolveScoreScale(middleware.DefaultScoreScale)
 	rng := initRand()
 	numPrompts := len(promptIDs)
 	maxScore := numPrompts * 100
@@ -1210,10 +1324,11 @@ func (h *Handler) RandomizeScores(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
-			_, _ = db.Exec(
-				"INSERT INTO scores (model_id, prompt_id, score) VALUES (?, ?, ?) "+
-					"ON CONFLICT(model_id, prompt_id) DO UPDATE SET score = excluded.score",
-				model.ID, promptID, score)
+			err := middleware.WriteScore(db, model.ID, promptID, middleware.ConvertScore(score, defaultScale, scale),
+				middleware.ScoreChange{Source: middleware.ScoreSourceRandomize})
+			if err != nil {
+				log.Printf("Error randomizing score of model %s: %v", model.Name, err)
+			}
 			remaining -= score
 		}
 	}
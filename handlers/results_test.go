@@ -690,6 +690,40 @@ func TestEvaluateResult_GET_WithTemplate(t *testing.T) {
 	}
 }
 
+func TestEvaluateResult_GET_RendersSuiteScale(t *testing.T) {
+	restoreDir := changeToProjectRootResults(t)
+	defer restoreDir()
+
+	cleanup := setupResultsTestDB(t)
+	defer cleanup()
+
+	_ = middleware.WritePrompts([]middleware.Prompt{{Text: "Scale prompt"}})
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"ScaleModel": {Scores: []int{0}}}); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+
+	render := func() string {
+		rr := httptest.NewRecorder()
+		EvaluateResult(rr, httptest.NewRequest("GET", "/evaluate_result?model=ScaleModel&prompt=0", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		return rr.Body.String()
+	}
+
+	_ = middleware.SetSuiteSetting("default", middleware.SuiteSettingScoreScale, middleware.ScoreScaleLikert)
+	body := render()
+	if !strings.Contains(body, `data-score="5"`) || !strings.Contains(body, "3/5") || strings.Contains(body, `data-score="100"`) {
+		t.Error("expected a button per likert point")
+	}
+
+	_ = middleware.SetSuiteSetting("default", middleware.SuiteSettingScoreScale, middleware.ScoreScaleContinuous)
+	body = render()
+	if strings.Contains(body, `data-score="1"`) || !strings.Contains(body, `type="number"`) {
+		t.Error("expected a number input instead of buttons on a continuous scale")
+	}
+}
+
 func TestEvaluateResult_POST_NewModel(t *testing.T) {
 	cleanup := setupResultsTestDB(t)
 	defer cleanup()
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"llm-tournament/middleware"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// ScoreHistoryHandler returns the changes of one grid cell as JSON (backward compatible wrapper)
+func ScoreHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.ScoreHistory(w, r)
+}
+
+// RevertScoreChangeHandler undoes one score change (backward compatible wrapper)
+func RevertScoreChangeHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.RevertScoreChange(w, r)
+}
+
+// RevertJobScoresHandler undoes the score changes of an evaluation job (backward compatible wrapper)
+func RevertJobScoresHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.RevertJobScores(w, r)
+}
+
+// ScoreHistory returns the changes of the cell at ?model= and ?prompt= (its index in
+// the current suite), newest first
+func (h *Handler) ScoreHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	model := r.URL.Query().Get("model")
+	promptIndex, err := strconv.Atoi(r.URL.Query().Get("prompt"))
+	if model == "" || err != nil || promptIndex < 0 {
+		http.Error(w, "Model and prompt index required", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.DataStore.ListScoreHistory(h.DataStore.GetCurrentSuiteName(), model, promptIndex)
+	if err != nil {
+		log.Printf("Error listing score history of %s prompt %d: %v", model, promptIndex, err)
+		http.Error(w, "Error listing score history", http.StatusNotFound)
+		return
+	}
+	if entries == nil {
+		entries = []middleware.ScoreHistoryEntry{}
+	}
+	middleware.RespondJSON(w, entries)
+}
+
+// RevertScoreChange puts the cell of the change at ?id= back to its score from before it
+func (h *Handler) RevertScoreChange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid score change ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DataStore.RevertScoreChange(id); err != nil {
+		log.Printf("Error reverting score change %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to revert score change: %v", err), http.StatusBadRequest)
+		return
+	}
+	h.DataStore.BroadcastResults()
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Reverted change #%d", id),
+	})
+}
+
+// RevertJobScores puts back every score the job at ?id= changed, leaving cells that
+// changed again after it
+func (h *Handler) RevertJobScores(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := jobID(w, r)
+	if !ok {
+		return
+	}
+
+	revert, err := h.DataStore.RevertJobScores(id)
+	if err != nil {
+		log.Printf("Error reverting scores of job %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to revert job scores: %v", err), http.StatusBadRequest)
+		return
+	}
+	h.DataStore.BroadcastResults()
+
+	middleware.RespondJSON(w, map[string]interface{}{
+		"success":  true,
+		"job_id":   id,
+		"reverted": revert.Reverted,
+		"skipped":  revert.Skipped,
+		"message":  fmt.Sprintf("Reverted %d scores, kept %d changed since", revert.Reverted, revert.Skipped),
+	})
+}
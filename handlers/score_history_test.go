@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"llm-tournament/middleware"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScoreHistory_ListAndRevert(t *testing.T) {
+	defer setupJobsTest(t)()
+
+	// Job 7 scores both cells of alpha
+	db := middleware.GetDB()
+	rows, err := db.Query("SELECT m.id, p.id FROM models m, prompts p ORDER BY p.display_order, p.id")
+	if err != nil {
+		t.Fatalf("failed to read cells: %v", err)
+	}
+	var cells [][2]int
+	for rows.Next() {
+		var c [2]int
+		if err := rows.Scan(&c[0], &c[1]); err != nil {
+			t.Fatalf("failed to scan cell: %v", err)
+		}
+		cells = append(cells, c)
+	}
+	_ = rows.Close()
+	for _, c := range cells {
+		if err := middleware.WriteScore(db, c[0], c[1], 60, middleware.ScoreChange{Source: middleware.ScoreSourceJudge, JobID: 7}); err != nil {
+			t.Fatalf("WriteScore failed: %v", err)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	ScoreHistoryHandler(rr, httptest.NewRequest(http.MethodGet, "/score_history?model=alpha&prompt=0", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var entries []middleware.ScoreHistoryEntry
+	if err := json.Unmarshal(rr.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("failed to decode score history: %v", err)
+	}
+	if len(entries) != 1 || entries[0].JobID != 7 || entries[0].NewScore == nil || *entries[0].NewScore != 60 {
+		t.Fatalf("expected the judge change of job 7, got %+v", entries)
+	}
+
+	rr = httptest.NewRecorder()
+	JobDetailHandler(rr, httptest.NewRequest(http.MethodGet, "/jobs/detail?id=7", nil))
+	if body := rr.Body.String(); !strings.Contains(body, "Revert 2 score changes") || !strings.Contains(body, "– → 60") {
+		t.Error("expected the detail page to list the job's score changes with a revert button")
+	}
+
+	rr = httptest.NewRecorder()
+	RevertScoreChangeHandler(rr, httptest.NewRequest(http.MethodPost, "/score_history/revert?id="+strconv.Itoa(entries[0].ID), nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if scores := middleware.ReadResults()["alpha"].Scores; !reflect.DeepEqual(scores, []int{0, 60}) {
+		t.Errorf("expected the first cell reverted, got %v", scores)
+	}
+
+	rr = httptest.NewRecorder()
+	RevertJobScoresHandler(rr, httptest.NewRequest(http.MethodPost, "/jobs/revert_scores?id=7", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["reverted"] != float64(1) || resp["skipped"] != float64(1) {
+		t.Errorf("expected the reverted cell kept and the other put back, got %v", resp)
+	}
+	if scores := middleware.ReadResults()["alpha"].Scores; !reflect.DeepEqual(scores, []int{0, 0}) {
+		t.Errorf("expected every score from before the job, got %v", scores)
+	}
+}
+
+func TestScoreHistory_BadRequests(t *testing.T) {
+	handler := &Handler{
+		DataStore: &MockDataStore{HistoryErr: errors.New("no such cell")},
+		Renderer:  &MockRenderer{},
+	}
+
+	tests := []struct {
+		handler http.HandlerFunc
+		method  string
+		target  string
+		want    int
+	}{
+		{handler.ScoreHistory, http.MethodPost, "/score_history?model=a&prompt=0", http.StatusMethodNotAllowed},
+		{handler.ScoreHistory, http.MethodGet, "/score_history?prompt=0", http.StatusBadRequest},
+		{handler.ScoreHistory, http.MethodGet, "/score_history?model=a&prompt=x", http.StatusBadRequest},
+		{handler.ScoreHistory, http.MethodGet, "/score_history?model=a&prompt=0", http.StatusNotFound},
+		{handler.RevertScoreChange, http.MethodGet, "/score_history/revert?id=1", http.StatusMethodNotAllowed},
+		{handler.RevertScoreChange, http.MethodPost, "/score_history/revert?id=x", http.StatusBadRequest},
+		{handler.RevertScoreChange, http.MethodPost, "/score_history/revert?id=1", http.StatusBadRequest},
+		{handler.RevertJobScores, http.MethodGet, "/jobs/revert_scores?id=1", http.StatusMethodNotAllowed},
+		{handler.RevertJobScores, http.MethodPost, "/jobs/revert_scores", http.StatusBadRequest},
+		{handler.RevertJobScores, http.MethodPost, "/jobs/revert_scores?id=1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		tt.handler(rr, httptest.NewRequest(tt.method, tt.target, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.want, rr.Code)
+		}
+	}
+}
+
+func TestImportResults_RecordsImportSource(t *testing.T) {
+	store := &MockDataStore{Prompts: []middleware.Prompt{{Text: "Prompt 1"}}, CurrentSuite: "default"}
+	handler := &Handler{DataStore: store, Renderer: &MockRenderer{}}
+
+	jsonData, _ := json.Marshal(map[string]middleware.Result{"Model1": {Scores: []int{80}}})
+	body, contentType := createMultipartFormFile(t, "results_file", "march.json", jsonData)
+	req := httptest.NewRequest(http.MethodPost, "/import_results", body)
+	req.Header.Set("Content-Type", contentType)
+	handler.ImportResults(httptest.NewRecorder(), req)
+
+	want := []middleware.ScoreChange{{Source: middleware.ScoreSourceImport, Note: "march.json"}}
+	if !reflect.DeepEqual(store.ScoreChanges, want) {
+		t.Errorf("expected the import recorded with its file name, got %+v", store.ScoreChanges)
+	}
+	if len(store.Snapshots) != 1 || store.Snapshots[0].Name != "Before importing march.json" {
+		t.Errorf("expected a snapshot before the import, got %+v", store.Snapshots)
+	}
+}
@@ -1,12 +1,38 @@
 package handlers
 
 import (
+	"llm-tournament/evaluator"
 	"llm-tournament/middleware"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
+// judgeSettingKeys are the free-form settings for native judge providers
+var judgeSettingKeys = []string{
+	"judge_openai_base_url",
+	"judge_openai_model",
+	"judge_anthropic_base_url",
+	"judge_anthropic_model",
+	"judge_gemini_base_url",
+	"judge_gemini_model",
+	"judge_retry_attempts",
+	"judge_retry_base_delay",
+	"judge_retry_max_delay",
+	"judge_breaker_threshold",
+	"judge_breaker_cooldown",
+	"judge_concurrency",
+	"judge_python_rpm",
+	"judge_python_tpm",
+	"judge_openai_rpm",
+	"judge_openai_tpm",
+	"judge_anthropic_rpm",
+	"judge_anthropic_tpm",
+	"judge_gemini_rpm",
+	"judge_gemini_tpm",
+}
+
 // SettingsHandler displays the settings page (backward compatible wrapper)
 func SettingsHandler(w http.ResponseWriter, r *http.Request) {
 	DefaultHandler.Settings(w, r)
@@ -38,6 +64,24 @@ func (h *Handler) Settings(w http.ResponseWriter, r *http.Request) {
 	threshold, _ := h.DataStore.GetSetting("cost_alert_threshold_usd")
 	autoEval, _ := h.DataStore.GetSetting("auto_evaluate_new_models")
 	pythonURL, _ := h.DataStore.GetSetting("python_service_url")
+	judgeBackend, _ := h.DataStore.GetSetting("judge_backend")
+	if judgeBackend == "" {
+		judgeBackend = "python"
+	}
+	judgeSettings := make(map[string]string)
+	for _, key := range judgeSettingKeys {
+		judgeSettings[key], _ = h.DataStore.GetSetting(key)
+	}
+
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	consensus, _ := h.DataStore.GetSuiteSetting(suiteName, middleware.SuiteSettingConsensusStrategy)
+	if consensus == "" {
+		consensus = evaluator.DefaultConsensusStrategy
+	}
+	calibration, _ := h.DataStore.GetSuiteSetting(suiteName, middleware.SuiteSettingCalibration)
+	scoreScale := h.scoreScale()
+	jobBudget, _ := h.DataStore.GetSetting("budget_job_usd")
+	monthlyBudget, _ := h.DataStore.GetSuiteSetting(suiteName, middleware.SuiteSettingMonthlyBudget)
 
 	// Parse threshold as float
 	thresholdFloat, _ := strconv.ParseFloat(threshold, 64)
@@ -51,6 +95,16 @@ func (h *Handler) Settings(w http.ResponseWriter, r *http.Request) {
 		Threshold     float64
 		AutoEvaluate  bool
 		PythonURL     string
+		JudgeBackend  string
+		JudgeSettings map[string]string
+		SuiteName     string
+		Consensus     string
+		Strategies    []evaluator.ConsensusStrategy
+		Calibration   string
+		ScoreScale    string
+		ScoreScales   []middleware.ScoreScale
+		JobBudget     string
+		MonthlyBudget string
 		CurrentPath   string
 	}{
 		PageName:      "Settings",
@@ -58,6 +112,16 @@ func (h *Handler) Settings(w http.ResponseWriter, r *http.Request) {
 		Threshold:     thresholdFloat,
 		AutoEvaluate:  autoEval == "true",
 		PythonURL:     pythonURL,
+		JudgeBackend:  judgeBackend,
+		JudgeSettings: judgeSettings,
+		SuiteName:     suiteName,
+		Consensus:     consensus,
+		Strategies:    evaluator.ConsensusStrategies(),
+		Calibration:   calibration,
+		ScoreScale:    scoreScale.Name,
+		ScoreScales:   middleware.ScoreScales(),
+		JobBudget:     jobBudget,
+		MonthlyBudget: monthlyBudget,
 		CurrentPath:   "/settings",
 	}
 
@@ -80,6 +144,34 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	consensus := r.FormValue("consensus_strategy")
+	if consensus != "" {
+		if _, err := evaluator.GetConsensusStrategy(consensus); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	calibration := r.FormValue("judge_calibration")
+	if !middleware.IsCalibrationMethod(calibration) {
+		http.Error(w, "Unknown judge calibration method", http.StatusBadRequest)
+		return
+	}
+	scoreScale := r.FormValue("score_scale")
+	if scoreScale != "" {
+		if _, err := middleware.GetScoreScale(scoreScale); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	jobBudget := strings.TrimSpace(r.FormValue("budget_job_usd"))
+	monthlyBudget := strings.TrimSpace(r.FormValue("monthly_budget_usd"))
+	for _, budget := range []string{jobBudget, monthlyBudget} {
+		if usd, err := strconv.ParseFloat(budget, 64); budget != "" && (err != nil || usd < 0) {
+			http.Error(w, "Budgets must be a non-negative amount in USD", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Update API keys (only if not empty)
 	apiKeys := map[string]string{
 		"anthropic": r.FormValue("api_key_anthropic"),
@@ -121,6 +213,62 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	switch backend := r.FormValue("judge_backend"); backend {
+	case "python", "native", "hybrid":
+		if err := h.DataStore.SetSetting("judge_backend", backend); err != nil {
+			log.Printf("Error setting judge backend: %v", err)
+		}
+	}
+
+	for _, key := range judgeSettingKeys {
+		if value := r.FormValue(key); value != "" {
+			if err := h.DataStore.SetSetting(key, value); err != nil {
+				log.Printf("Error setting %s: %v", key, err)
+			}
+		}
+	}
+
+	if consensus != "" {
+		suiteName := h.DataStore.GetCurrentSuiteName()
+		if err := h.DataStore.SetSuiteSetting(suiteName, middleware.SuiteSettingConsensusStrategy, consensus); err != nil {
+			log.Printf("Error setting consensus strategy: %v", err)
+		}
+	}
+	// "Off" is the empty value, so only update calibration when the form includes the field
+	if r.Form.Has("judge_calibration") {
+		suiteName := h.DataStore.GetCurrentSuiteName()
+		if err := h.DataStore.SetSuiteSetting(suiteName, middleware.SuiteSettingCalibration, calibration); err != nil {
+			log.Printf("Error setting judge calibration: %v", err)
+		}
+	}
+
+	// A new scale converts the suite's existing scores onto it unless the form opts out
+	if current := h.scoreScale(); scoreScale != "" && scoreScale != current.Name {
+		suiteName := h.DataStore.GetCurrentSuiteName()
+		next, _ := middleware.GetScoreScale(scoreScale)
+		if err := h.DataStore.SetScoreScale(suiteName, current, next, r.FormValue("convert_scores") == "on"); err != nil {
+			log.Printf("Error changing score scale to %s: %v", next.Name, err)
+			http.Error(w, "Failed to change score scale", http.StatusInternalServerError)
+			return
+		}
+		h.DataStore.BroadcastResults()
+	}
+
+	// An empty budget removes the cap, so only update budgets when the form includes them
+	if r.Form.Has("budget_job_usd") {
+		if err := h.DataStore.SetSetting("budget_job_usd", jobBudget); err != nil {
+			log.Printf("Error setting job budget: %v", err)
+		}
+	}
+	if r.Form.Has("monthly_budget_usd") {
+		suiteName := h.DataStore.GetCurrentSuiteName()
+		if err := h.DataStore.SetSuiteSetting(suiteName, middleware.SuiteSettingMonthlyBudget, monthlyBudget); err != nil {
+			log.Printf("Error setting monthly budget: %v", err)
+		}
+	}
+
+	configureJudgeProviders()
+
 	log.Println("Settings updated successfully")
 
 	// Redirect back to settings page
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 
@@ -492,3 +493,79 @@ func TestUpdateSettings_ParseFormError(t *testing.T) {
 		t.Fatalf("expected parse form error message, got %q", rr.Body.String())
 	}
 }
+
+func TestUpdateSettings_ConsensusStrategy(t *testing.T) {
+	mock := &MockDataStore{}
+	h := &Handler{DataStore: mock, Renderer: &MockRenderer{}}
+
+	form := url.Values{"consensus_strategy": {"median"}}
+	req := httptest.NewRequest("POST", "/settings/update", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if got := mock.SuiteSettings[middleware.SuiteSettingConsensusStrategy]; got != "median" {
+		t.Errorf("expected consensus strategy 'median', got %q", got)
+	}
+}
+
+func TestUpdateSettings_UnknownConsensusStrategy(t *testing.T) {
+	mock := &MockDataStore{}
+	h := &Handler{DataStore: mock, Renderer: &MockRenderer{}}
+
+	form := url.Values{"consensus_strategy": {"mode"}, "api_key_openai": {"sk-new"}}
+	req := httptest.NewRequest("POST", "/settings/update", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if len(mock.SuiteSettings) != 0 {
+		t.Errorf("expected nothing saved, got %v", mock.SuiteSettings)
+	}
+}
+
+func TestUpdateSettings_ScoreScale(t *testing.T) {
+	tests := []struct {
+		name   string
+		form   url.Values
+		status int
+		scale  string
+		scores []int
+	}{
+		{"converts scores", url.Values{"score_scale": {"likert"}, "convert_scores": {"on"}}, http.StatusSeeOther, "likert", []int{4, 0}},
+		{"keeps scores", url.Values{"score_scale": {"likert"}}, http.StatusSeeOther, "likert", []int{80, 0}},
+		{"unchanged scale", url.Values{"score_scale": {"fifths"}, "convert_scores": {"on"}}, http.StatusSeeOther, "", []int{80, 0}},
+		{"unknown scale", url.Values{"score_scale": {"stars"}, "convert_scores": {"on"}}, http.StatusBadRequest, "", []int{80, 0}},
+		{"failed change", url.Values{"score_scale": {"likert"}, "convert_scores": {"on"}}, http.StatusInternalServerError, "", []int{80, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockDataStore{Results: map[string]middleware.Result{"llama": {Scores: []int{80, 0}}}}
+			if tt.status == http.StatusInternalServerError {
+				mock.ScaleErr = errors.New("database is locked")
+			}
+			h := &Handler{DataStore: mock, Renderer: &MockRenderer{}}
+
+			req := httptest.NewRequest("POST", "/settings/update", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rr := httptest.NewRecorder()
+			h.UpdateSettings(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if got := mock.SuiteSettings[middleware.SuiteSettingScoreScale]; got != tt.scale {
+				t.Errorf("expected score scale %q, got %q", tt.scale, got)
+			}
+			if got := mock.Results["llama"].Scores; !slices.Equal(got, tt.scores) {
+				t.Errorf("expected scores %v, got %v", tt.scores, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"llm-tournament/middleware"
+	"llm-tournament/templates"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// SnapshotsHandler lists the current suite's snapshots (backward compatible wrapper)
+func SnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.Snapshots(w, r)
+}
+
+// CreateSnapshotHandler takes a named snapshot of the current suite (backward compatible wrapper)
+func CreateSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.CreateSnapshot(w, r)
+}
+
+// RestoreSnapshotHandler restores a snapshot (backward compatible wrapper)
+func RestoreSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.RestoreSnapshot(w, r)
+}
+
+// DeleteSnapshotHandler removes a snapshot (backward compatible wrapper)
+func DeleteSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.DeleteSnapshot(w, r)
+}
+
+// snapshotBefore keeps an automatic snapshot of the current suite ahead of a destructive
+// operation. The operation must not go ahead when it returns false.
+func (h *Handler) snapshotBefore(w http.ResponseWriter, operation string) bool {
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	if _, err := h.DataStore.CreateSuiteSnapshot(suiteName, "Before "+operation, true); err != nil {
+		log.Printf("Error taking snapshot of suite %s before %s: %v", suiteName, operation, err)
+		http.Error(w, "Error taking snapshot", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// Snapshots renders the current suite's snapshots. With ?compare= it also shows how that
+// snapshot differs from the one at ?with=, or from the current state when with is empty.
+func (h *Handler) Snapshots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	snapshots, err := h.DataStore.ListSuiteSnapshots(suiteName)
+	if err != nil {
+		log.Printf("Error listing snapshots: %v", err)
+		http.Error(w, "Error listing snapshots", http.StatusInternalServerError)
+		return
+	}
+
+	var diff *middleware.SnapshotDiff
+	compareID, withID := 0, 0
+	if compare := r.URL.Query().Get("compare"); compare != "" {
+		if compareID, err = strconv.Atoi(compare); err != nil {
+			http.Error(w, "Invalid snapshot ID", http.StatusBadRequest)
+			return
+		}
+		if with := r.URL.Query().Get("with"); with != "" {
+			if withID, err = strconv.Atoi(with); err != nil {
+				http.Error(w, "Invalid snapshot ID", http.StatusBadRequest)
+				return
+			}
+		}
+		if diff, err = h.DataStore.CompareSuiteSnapshots(compareID, withID); err != nil {
+			log.Printf("Error comparing snapshot %d with %d: %v", compareID, withID, err)
+			http.Error(w, fmt.Sprintf("Error comparing snapshots: %v", err), http.StatusNotFound)
+			return
+		}
+	}
+
+	data := struct {
+		PageName    string
+		SuiteName   string
+		Snapshots   []middleware.SuiteSnapshot
+		Diff        *middleware.SnapshotDiff
+		CompareID   int
+		WithID      int
+		CurrentPath string
+	}{
+		PageName:    "Snapshots",
+		SuiteName:   suiteName,
+		Snapshots:   snapshots,
+		Diff:        diff,
+		CompareID:   compareID,
+		WithID:      withID,
+		CurrentPath: "/snapshots",
+	}
+
+	err = h.Renderer.Render(w, "snapshots.html", templates.FuncMap, data, "templates/snapshots.html", "templates/nav.html")
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// CreateSnapshot stores a snapshot of the current suite under the form's name
+func (h *Handler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	if _, err := h.DataStore.CreateSuiteSnapshot(suiteName, r.FormValue("name"), false); err != nil {
+		log.Printf("Error taking snapshot of suite %s: %v", suiteName, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/snapshots", http.StatusSeeOther)
+}
+
+// RestoreSnapshot puts the suite of the snapshot with the form's id back to the state it
+// was captured in
+func (h *Handler) RestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid snapshot ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DataStore.RestoreSuiteSnapshot(id); err != nil {
+		log.Printf("Error restoring snapshot %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to restore snapshot: %v", err), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Snapshot %d restored", id)
+	h.DataStore.BroadcastResults()
+
+	http.Redirect(w, r, "/snapshots", http.StatusSeeOther)
+}
+
+// DeleteSnapshot removes the snapshot with the form's id
+func (h *Handler) DeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid snapshot ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DataStore.DeleteSuiteSnapshot(id); err != nil {
+		log.Printf("Error deleting snapshot %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to delete snapshot: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/snapshots", http.StatusSeeOther)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"llm-tournament/middleware"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSnapshots_TakeCompareAndRestore(t *testing.T) {
+	defer changeToProjectRootStats(t)()
+	defer setupEvaluationTestDB(t)()
+
+	if err := middleware.WritePromptSuite("default", []middleware.Prompt{{Text: "p1"}, {Text: "p2"}}); err != nil {
+		t.Fatalf("failed to write prompts: %v", err)
+	}
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"alpha": {Scores: []int{60, 80}}}); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+
+	if rr := postJudgesForm(t, CreateSnapshotHandler, url.Values{"name": {"baseline"}}); rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after taking a snapshot, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := postJudgesForm(t, CreateSnapshotHandler, url.Values{}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a snapshot without a name to be rejected, got %d", rr.Code)
+	}
+
+	rr := httptest.NewRecorder()
+	ResetResultsHandler(rr, httptest.NewRequest(http.MethodPost, "/reset_results", nil))
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after resetting, got %d", rr.Code)
+	}
+	if results := middleware.ReadResults(); len(results) != 0 {
+		t.Fatalf("expected the results reset, got %+v", results)
+	}
+
+	snapshots, err := middleware.ListSuiteSnapshots("default")
+	if err != nil || len(snapshots) != 2 {
+		t.Fatalf("expected the named snapshot and one taken before the reset, got %+v (%v)", snapshots, err)
+	}
+	if s := snapshots[0]; !s.Auto || s.Name != "Before resetting results" || s.Models != 1 {
+		t.Errorf("expected an automatic snapshot of the results before the reset, got %+v", s)
+	}
+	baseline := strconv.Itoa(snapshots[1].ID)
+
+	rr = httptest.NewRecorder()
+	SnapshotsHandler(rr, httptest.NewRequest(http.MethodGet, "/snapshots?compare="+baseline, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "Before resetting results") || !strings.Contains(body, "Models removed") || !strings.Contains(body, "alpha") {
+		t.Error("expected the page to list the snapshots and show alpha removed since the baseline")
+	}
+
+	if rr := postJudgesForm(t, RestoreSnapshotHandler, url.Values{"id": {baseline}}); rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after restoring, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if scores := middleware.ReadResults()["alpha"].Scores; !reflect.DeepEqual(scores, []int{60, 80}) {
+		t.Errorf("expected alpha's scores restored, got %v", scores)
+	}
+
+	if rr := postJudgesForm(t, DeleteSnapshotHandler, url.Values{"id": {baseline}}); rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after deleting, got %d", rr.Code)
+	}
+	if rr := postJudgesForm(t, RestoreSnapshotHandler, url.Values{"id": {baseline}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected restoring a deleted snapshot to fail, got %d", rr.Code)
+	}
+}
+
+func TestSnapshots_BadRequests(t *testing.T) {
+	handler := &Handler{
+		DataStore: &MockDataStore{SnapshotErr: errors.New("no such snapshot")},
+		Renderer:  &MockRenderer{},
+	}
+
+	tests := []struct {
+		handler http.HandlerFunc
+		method  string
+		target  string
+		want    int
+	}{
+		{handler.Snapshots, http.MethodPost, "/snapshots", http.StatusMethodNotAllowed},
+		{handler.Snapshots, http.MethodGet, "/snapshots", http.StatusInternalServerError},
+		{handler.CreateSnapshot, http.MethodGet, "/snapshots/create", http.StatusMethodNotAllowed},
+		{handler.CreateSnapshot, http.MethodPost, "/snapshots/create?name=x", http.StatusBadRequest},
+		{handler.RestoreSnapshot, http.MethodGet, "/snapshots/restore?id=1", http.StatusMethodNotAllowed},
+		{handler.RestoreSnapshot, http.MethodPost, "/snapshots/restore?id=x", http.StatusBadRequest},
+		{handler.RestoreSnapshot, http.MethodPost, "/snapshots/restore?id=1", http.StatusBadRequest},
+		{handler.DeleteSnapshot, http.MethodGet, "/snapshots/delete?id=1", http.StatusMethodNotAllowed},
+		{handler.DeleteSnapshot, http.MethodPost, "/snapshots/delete", http.StatusBadRequest},
+		{handler.DeleteSnapshot, http.MethodPost, "/snapshots/delete?id=1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		tt.handler(rr, httptest.NewRequest(tt.method, tt.target, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.want, rr.Code)
+		}
+	}
+
+	handler.DataStore = &MockDataStore{}
+	for _, target := range []string{"/snapshots?compare=x", "/snapshots?compare=1&with=x"} {
+		rr := httptest.NewRecorder()
+		handler.Snapshots(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: expected status %d, got %d", target, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
+
+func TestDestructiveHandlers_SnapshotFirst(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(h *Handler, w http.ResponseWriter)
+		snapshot string
+	}{
+		{"reset results", func(h *Handler, w http.ResponseWriter) {
+			h.ResetResults(w, httptest.NewRequest(http.MethodPost, "/reset_results", nil))
+		}, "Before resetting results"},
+		{"refresh results", func(h *Handler, w http.ResponseWriter) {
+			h.RefreshResults(w, httptest.NewRequest(http.MethodPost, "/refresh_results", nil))
+		}, "Before refreshing results"},
+		{"reset prompts", func(h *Handler, w http.ResponseWriter) {
+			h.ResetPrompts(w, httptest.NewRequest(http.MethodPost, "/reset_prompts", nil))
+		}, "Before resetting prompts"},
+		{"reset profiles", func(h *Handler, w http.ResponseWriter) {
+			h.ResetProfiles(w, httptest.NewRequest(http.MethodPost, "/reset_profiles", nil))
+		}, "Before resetting profiles"},
+		{"bulk delete prompts", func(h *Handler, w http.ResponseWriter) {
+			body := bytes.NewBufferString(`{"indices":[0]}`)
+			h.BulkDeletePrompts(w, httptest.NewRequest(http.MethodPost, "/bulk_delete_prompts", body))
+		}, "Before deleting 1 of 2 prompts"},
+		{"delete prompt", func(h *Handler, w http.ResponseWriter) {
+			h.DeletePrompt(w, httptest.NewRequest(http.MethodPost, "/delete_prompt?index=1", nil))
+		}, "Before deleting prompt 2"},
+		{"delete model", func(h *Handler, w http.ResponseWriter) {
+			h.DeleteModel(w, httptest.NewRequest(http.MethodPost, "/delete_model?model=alpha", nil))
+		}, "Before deleting model alpha"},
+		{"delete profile", func(h *Handler, w http.ResponseWriter) {
+			h.DeleteProfile(w, httptest.NewRequest(http.MethodPost, "/delete_profile?index=0", nil))
+		}, "Before deleting profile Math"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &MockDataStore{
+				Prompts:  []middleware.Prompt{{Text: "p1"}, {Text: "p2"}},
+				Profiles: []middleware.Profile{{Name: "Math"}},
+				Results:  map[string]middleware.Result{"alpha": {Scores: []int{60, 80}}},
+			}
+			handler := &Handler{DataStore: store, Renderer: &MockRenderer{}}
+			tt.call(handler, httptest.NewRecorder())
+			if len(store.Snapshots) != 1 || store.Snapshots[0].Name != tt.snapshot || !store.Snapshots[0].Auto {
+				t.Errorf("expected an automatic snapshot %q, got %+v", tt.snapshot, store.Snapshots)
+			}
+
+			// Without a snapshot nothing is touched
+			store = &MockDataStore{
+				Prompts:     []middleware.Prompt{{Text: "p1"}, {Text: "p2"}},
+				Profiles:    []middleware.Profile{{Name: "Math"}},
+				Results:     map[string]middleware.Result{"alpha": {Scores: []int{60, 80}}},
+				SnapshotErr: errors.New("disk full"),
+			}
+			handler = &Handler{DataStore: store, Renderer: &MockRenderer{}}
+			rr := httptest.NewRecorder()
+			tt.call(handler, rr)
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("expected status %d when the snapshot fails, got %d", http.StatusInternalServerError, rr.Code)
+			}
+			if len(store.Prompts) != 2 || len(store.Profiles) != 1 || store.Results["alpha"].Scores[0] != 60 {
+				t.Errorf("expected the suite untouched, got %+v / %+v / %+v", store.Prompts, store.Profiles, store.Results)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"llm-tournament/evaluator"
 	"llm-tournament/middleware"
 	"log"
 	"net/http"
@@ -87,37 +88,36 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 	log.Println("Handling stats page")
 	results := h.DataStore.ReadResults()
 
-	// Calculate score breakdowns
+	// Calculate score breakdowns: the points each model earned in each bucket of the
+	// suite's scale
+	scale := h.scoreScale()
+	buckets := scale.Buckets()
 	type ScoreStats struct {
-		TotalScore int `json:"TotalScore"`
-		Count20    int `json:"Count20"`
-		Count40    int `json:"Count40"`
-		Count60    int `json:"Count60"`
-		Count80    int `json:"Count80"`
-		Count100   int `json:"Count100"`
+		TotalScore int   `json:"TotalScore"`
+		Points     []int `json:"Points"`
 	}
 
 	scoreStats := make(map[string]ScoreStats)
 	for model, result := range results {
-		stats := ScoreStats{}
-		for _, score := range result.Scores {
+		stats := ScoreStats{Points: make([]int, len(buckets))}
+		for i, score := range result.Scores {
+			if !result.IsScored(i) {
+				continue
+			}
 			stats.TotalScore += score
-			switch score {
-			case 20:
-				stats.Count20++
-			case 40:
-				stats.Count40++
-			case 60:
-				stats.Count60++
-			case 80:
-				stats.Count80++
-			case 100:
-				stats.Count100++
+			for i, b := range buckets {
+				if score >= b.Low && score <= b.High {
+					stats.Points[i] += score
+					break
+				}
 			}
 		}
 
 		// Double-check total score calculation to ensure consistency
-		calculatedTotal := stats.Count20*20 + stats.Count40*40 + stats.Count60*60 + stats.Count80*80 + stats.Count100*100
+		calculatedTotal := 0
+		for _, points := range stats.Points {
+			calculatedTotal += points
+		}
 		if calculatedTotal != stats.TotalScore {
 			log.Printf("Warning: Score mismatch for %s: calculated=%d, summed=%d", model, calculatedTotal, stats.TotalScore)
 			// Fix the total score if there's a discrepancy
@@ -132,34 +132,55 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 		totalScores[model] = stats.TotalScore
 	}
 
-	// Get prompt count to calculate dynamic max score
-	db := middleware.GetDB()
-	var promptCount int
-	err := db.QueryRow("SELECT COUNT(*) FROM prompts").Scan(&promptCount)
+	// Tiers split the totals the current suite's prompts allow on its scale
+	prompts, err := h.DataStore.ReadPromptSuite(h.DataStore.GetCurrentSuiteName())
+	promptCount := len(prompts)
 	if err != nil {
 		log.Printf("Warning: failed to get prompt count: %v, using default 50", err)
 		promptCount = 50
 	}
-	maxScore := promptCount * 100
+	maxScore := scale.MaxTotal(promptCount)
+	tiers, tierRanges := calculateTiersInRange(totalScores, scale.MinTotal(promptCount), maxScore)
+
+	// Pairwise leaderboards from recorded battles
+	battles, err := h.DataStore.ListBattles(h.DataStore.GetCurrentSuiteName())
+	if err != nil {
+		log.Printf("Warning: failed to list battles: %v", err)
+	}
 
-	// Calculate tiers with dynamic max score
-	tiers, tierRanges := calculateTiersWithMaxScore(totalScores, maxScore)
+	// Per-criterion means from prompts judged against a rubric
+	criterionScores, err := h.DataStore.ListCriterionScores(h.DataStore.GetCurrentSuiteName())
+	if err != nil {
+		log.Printf("Warning: failed to list criterion scores: %v", err)
+	}
 
 	// Prepare template data
 	templateData := struct {
 		PageName     string
 		MaxScore     int
+		Scale        middleware.ScoreScale
+		Buckets      []middleware.ScoreBucket
 		TotalScores  map[string]ScoreStats
 		Tiers        map[string][]string
 		TierRanges   map[string]string
 		OrderedTiers []string
+		BattleCount  int
+		EloRatings   []evaluator.Rating
+		BradleyTerry []evaluator.Rating
+		ByCriterion  criterionBreakdown
 		CurrentPath  string
 	}{
-		PageName:    "Statistics",
-		MaxScore:    maxScore,
-		TotalScores: scoreStats,
-		Tiers:       tiers,
-		TierRanges:  tierRanges,
+		PageName:     "Statistics",
+		MaxScore:     maxScore,
+		Scale:        scale,
+		Buckets:      buckets,
+		TotalScores:  scoreStats,
+		Tiers:        tiers,
+		TierRanges:   tierRanges,
+		BattleCount:  len(battles),
+		EloRatings:   evaluator.EloRatings(battles),
+		BradleyTerry: evaluator.BradleyTerryRatings(battles),
+		ByCriterion:  newCriterionBreakdown(criterionScores),
 		OrderedTiers: []string{
 			"transcendental",
 			"cosmic",
@@ -201,6 +222,12 @@ func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 
 // calculateTiersWithMaxScore calculates tiers based on total scores with dynamic max score
 func calculateTiersWithMaxScore(totalScores map[string]int, maxScore int) (map[string][]string, map[string]string) {
+	return calculateTiersInRange(totalScores, 0, maxScore)
+}
+
+// calculateTiersInRange splits the totals between minScore and maxScore evenly into 12
+// tiers, so a scale that starts above zero does not push every model up the tiers
+func calculateTiersInRange(totalScores map[string]int, minScore, maxScore int) (map[string][]string, map[string]string) {
 	tiers := map[string][]string{
 		"transcendental": {},
 		"cosmic":         {},
@@ -217,34 +244,27 @@ func calculateTiersWithMaxScore(totalScores map[string]int, maxScore int) (map[s
 	}
 
 	// Calculate even distribution across 12 tiers
+	span := maxScore - minScore
 	thresholds := map[string]int{
-		"transcendental": (maxScore * 11) / 12,
-		"cosmic":         (maxScore * 10) / 12,
-		"divine":         (maxScore * 9) / 12,
-		"celestial":      (maxScore * 8) / 12,
-		"ascendant":      (maxScore * 7) / 12,
-		"ethereal":       (maxScore * 6) / 12,
-		"mystic":         (maxScore * 5) / 12,
-		"astral":         (maxScore * 4) / 12,
-		"spiritual":      (maxScore * 3) / 12,
-		"primal":         (maxScore * 2) / 12,
-		"mortal":         (maxScore * 1) / 12,
+		"transcendental": minScore + (span*11)/12,
+		"cosmic":         minScore + (span*10)/12,
+		"divine":         minScore + (span*9)/12,
+		"celestial":      minScore + (span*8)/12,
+		"ascendant":      minScore + (span*7)/12,
+		"ethereal":       minScore + (span*6)/12,
+		"mystic":         minScore + (span*5)/12,
+		"astral":         minScore + (span*4)/12,
+		"spiritual":      minScore + (span*3)/12,
+		"primal":         minScore + (span*2)/12,
+		"mortal":         minScore + (span*1)/12,
 	}
 
-	// Calculate percentages for display
-	percentages := map[string]float64{
-		"transcendental": float64(thresholds["transcendental"]) * 100 / float64(maxScore),
-		"cosmic":         float64(thresholds["cosmic"]) * 100 / float64(maxScore),
-		"divine":         float64(thresholds["divine"]) * 100 / float64(maxScore),
-		"celestial":      float64(thresholds["celestial"]) * 100 / float64(maxScore),
-		"ascendant":      float64(thresholds["ascendant"]) * 100 / float64(maxScore),
-		"ethereal":       float64(thresholds["ethereal"]) * 100 / float64(maxScore),
-		"mystic":         float64(thresholds["mystic"]) * 100 / float64(maxScore),
-		"astral":         float64(thresholds["astral"]) * 100 / float64(maxScore),
-		"spiritual":      float64(thresholds["spiritual"]) * 100 / float64(maxScore),
-		"primal":         float64(thresholds["primal"]) * 100 / float64(maxScore),
-		"mortal":         float64(thresholds["mortal"]) * 100 / float64(maxScore),
-		"primordial":     0.0,
+	// Calculate percentages of the range for display
+	percentages := map[string]float64{"primordial": 0.0}
+	for tier, threshold := range thresholds {
+		if span > 0 {
+			percentages[tier] = float64(threshold-minScore) * 100 / float64(span)
+		}
 	}
 
 	tierRanges := map[string]string{
@@ -259,7 +279,7 @@ func calculateTiersWithMaxScore(totalScores map[string]int, maxScore int) (map[s
 		"spiritual":      fmt.Sprintf("%d-%d (%.1f%%-%.1f%%)", thresholds["spiritual"], thresholds["astral"]-1, percentages["spiritual"], percentages["astral"]),
 		"primal":         fmt.Sprintf("%d-%d (%.1f%%-%.1f%%)", thresholds["primal"], thresholds["spiritual"]-1, percentages["primal"], percentages["spiritual"]),
 		"mortal":         fmt.Sprintf("%d-%d (%.1f%%-%.1f%%)", thresholds["mortal"], thresholds["primal"]-1, percentages["mortal"], percentages["primal"]),
-		"primordial":     fmt.Sprintf("0-%d (0-%.1f%%)", thresholds["mortal"]-1, percentages["mortal"]),
+		"primordial":     fmt.Sprintf("%d-%d (0-%.1f%%)", minScore, thresholds["mortal"]-1, percentages["mortal"]),
 	}
 
 	for model, score := range totalScores {
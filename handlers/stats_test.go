@@ -658,3 +658,93 @@ func TestStatsHandler_PromptCountQueryError(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
 	}
 }
+
+func TestStatsHandler_BucketsOnSuiteScale(t *testing.T) {
+	cleanup := setupStatsTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePromptSuite("default", []middleware.Prompt{{Text: "P1"}, {Text: "P2"}, {Text: "P3"}}); err != nil {
+		t.Fatalf("failed to write test prompts: %v", err)
+	}
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"ModelX": {Scores: []int{5, 3, 3}}}); err != nil {
+		t.Fatalf("failed to write test results: %v", err)
+	}
+	if err := middleware.SetSuiteSetting("default", middleware.SuiteSettingScoreScale, middleware.ScoreScaleLikert); err != nil {
+		t.Fatalf("failed to set score scale: %v", err)
+	}
+	renderer := &testutil.MockRenderer{}
+	handler := NewHandlerWithDeps(&middleware.SQLiteDataStore{}, renderer)
+
+	rr := httptest.NewRecorder()
+	handler.Stats(rr, httptest.NewRequest("GET", "/stats", nil))
+	if rr.Code != http.StatusOK || len(renderer.RenderCalls) != 1 {
+		t.Fatalf("expected one render with status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	val := reflect.ValueOf(renderer.RenderCalls[0].Data)
+	if got := val.FieldByName("MaxScore").Int(); got != 15 {
+		t.Errorf("expected a max score of 15 over three likert prompts, got %d", got)
+	}
+	buckets := val.FieldByName("Buckets").Interface().([]middleware.ScoreBucket)
+	if len(buckets) != 5 || buckets[0].Label != "1/5" {
+		t.Fatalf("expected a bucket per likert point, got %+v", buckets)
+	}
+	stats := val.FieldByName("TotalScores").MapIndex(reflect.ValueOf("ModelX"))
+	total, points := stats.FieldByName("TotalScore").Int(), stats.FieldByName("Points").Interface()
+	if total != 11 || !reflect.DeepEqual(points, []int{0, 0, 6, 0, 5}) {
+		t.Errorf("expected a total of 11 split into the 3 and 5 buckets, got %d %v", total, points)
+	}
+}
+
+func TestCalculateTiersInRange_ScaleAboveZero(t *testing.T) {
+	// Ten prompts on a 1-5 scale total between 10 and 50
+	tiers, tierRanges := calculateTiersInRange(map[string]int{"floor": 10, "half": 29, "top": 47}, 10, 50)
+
+	if !reflect.DeepEqual(tiers["primordial"], []string{"floor"}) {
+		t.Errorf("expected the lowest possible total in primordial, got %v", tiers["primordial"])
+	}
+	if !reflect.DeepEqual(tiers["mystic"], []string{"half"}) {
+		t.Errorf("expected a total just under half the range in mystic, got %v", tiers)
+	}
+	if !reflect.DeepEqual(tiers["transcendental"], []string{"top"}) {
+		t.Errorf("expected a near-perfect total in transcendental, got %v", tiers["transcendental"])
+	}
+	if got := tierRanges["primordial"]; got != "10-12 (0-7.5%)" {
+		t.Errorf("expected primordial to start at the minimum total, got %q", got)
+	}
+}
+
+func TestStatsHandler_CountsCurrentSuitePrompts(t *testing.T) {
+	cleanup := setupStatsTestDB(t)
+	defer cleanup()
+
+	if err := middleware.WritePromptSuite("other", []middleware.Prompt{{Text: "O1"}, {Text: "O2"}, {Text: "O3"}, {Text: "O4"}}); err != nil {
+		t.Fatalf("failed to write other suite: %v", err)
+	}
+	if err := middleware.WritePromptSuite("default", []middleware.Prompt{{Text: "P1"}, {Text: "P2"}, {Text: "P3"}}); err != nil {
+		t.Fatalf("failed to write test prompts: %v", err)
+	}
+	if err := middleware.WriteResults("default", map[string]middleware.Result{"ModelX": {Scores: []int{1, 1, 1}}}); err != nil {
+		t.Fatalf("failed to write test results: %v", err)
+	}
+	if err := middleware.SetSuiteSetting("default", middleware.SuiteSettingScoreScale, middleware.ScoreScaleLikert); err != nil {
+		t.Fatalf("failed to set score scale: %v", err)
+	}
+	renderer := &testutil.MockRenderer{}
+	handler := NewHandlerWithDeps(&middleware.SQLiteDataStore{}, renderer)
+
+	rr := httptest.NewRecorder()
+	handler.Stats(rr, httptest.NewRequest("GET", "/stats", nil))
+	if rr.Code != http.StatusOK || len(renderer.RenderCalls) != 1 {
+		t.Fatalf("expected one render with status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	val := reflect.ValueOf(renderer.RenderCalls[0].Data)
+	if got := val.FieldByName("MaxScore").Int(); got != 15 {
+		t.Errorf("expected a max score of 15 from the current suite's three prompts, got %d", got)
+	}
+	tiers := val.FieldByName("Tiers").Interface().(map[string][]string)
+	if !reflect.DeepEqual(tiers["primordial"], []string{"ModelX"}) {
+		t.Errorf("expected the lowest likert total in primordial, got %v", tiers)
+	}
+}
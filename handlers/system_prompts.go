@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"llm-tournament/middleware"
+	"llm-tournament/templates"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// profileSystemPromptField prefixes the form fields that assign a system prompt to a profile
+const profileSystemPromptField = "profile:"
+
+// bundledSystemPromptDir holds the system prompt XML files shipped with the repository
+var bundledSystemPromptDir = "."
+
+// SystemPromptsHandler displays the system prompt library (backward compatible wrapper)
+func SystemPromptsHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.SystemPrompts(w, r)
+}
+
+// SaveSystemPromptHandler creates a system prompt or adds a version (backward compatible wrapper)
+func SaveSystemPromptHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.SaveSystemPrompt(w, r)
+}
+
+// DeleteSystemPromptHandler removes a system prompt (backward compatible wrapper)
+func DeleteSystemPromptHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.DeleteSystemPrompt(w, r)
+}
+
+// ImportSystemPromptsHandler imports XML system prompts (backward compatible wrapper)
+func ImportSystemPromptsHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.ImportSystemPrompts(w, r)
+}
+
+// AssignSystemPromptsHandler assigns system prompts to profiles (backward compatible wrapper)
+func AssignSystemPromptsHandler(w http.ResponseWriter, r *http.Request) {
+	DefaultHandler.AssignSystemPrompts(w, r)
+}
+
+// SystemPrompts renders the system prompt library with each prompt's versions and the
+// current suite's profile assignments
+func (h *Handler) SystemPrompts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	prompts, err := middleware.ListSystemPrompts()
+	if err != nil {
+		log.Printf("Error loading system prompts: %v", err)
+		http.Error(w, "Error loading system prompts", http.StatusInternalServerError)
+		return
+	}
+	versions := make(map[string][]middleware.SystemPromptVersion, len(prompts))
+	for _, p := range prompts {
+		if versions[p.Name], err = middleware.ListSystemPromptVersions(p.Name); err != nil {
+			log.Printf("Error loading versions of system prompt %s: %v", p.Name, err)
+			http.Error(w, "Error loading system prompts", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	profilePrompts, err := middleware.GetProfileSystemPrompts(suiteName)
+	if err != nil {
+		log.Printf("Error loading profile system prompts: %v", err)
+		http.Error(w, "Error loading system prompts", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		PageName       string
+		SystemPrompts  []middleware.SystemPrompt
+		Versions       map[string][]middleware.SystemPromptVersion
+		BundledPattern string
+		SuiteName      string
+		Profiles       []middleware.Profile
+		ProfilePrompts map[string]string
+		CurrentPath    string
+	}{
+		PageName:       "System Prompts",
+		SystemPrompts:  prompts,
+		Versions:       versions,
+		BundledPattern: middleware.BundledSystemPromptPattern,
+		SuiteName:      suiteName,
+		Profiles:       h.DataStore.ReadProfiles(),
+		ProfilePrompts: profilePrompts,
+		CurrentPath:    "/system_prompts",
+	}
+
+	err = h.Renderer.Render(w, "system_prompts.html", templates.FuncMap, data, "templates/system_prompts.html", "templates/nav.html")
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// SaveSystemPrompt creates a system prompt or stores changed content as its next version
+func (h *Handler) SaveSystemPrompt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Error parsing form: %v", err)
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	content := strings.ReplaceAll(r.FormValue("content"), "\r\n", "\n")
+	if _, err := middleware.SaveSystemPrompt(name, strings.TrimSpace(r.FormValue("description")), content); err != nil {
+		log.Printf("Error saving system prompt: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/system_prompts", http.StatusSeeOther)
+}
+
+// DeleteSystemPrompt removes a system prompt that no stored response or result used
+func (h *Handler) DeleteSystemPrompt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := middleware.DeleteSystemPrompt(r.FormValue("name")); err != nil {
+		log.Printf("Error deleting system prompt: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/system_prompts", http.StatusSeeOther)
+}
+
+// ImportSystemPrompts imports an uploaded XML system prompt, named after the form's
+// name field or the file, or without an upload the XML files bundled with the app
+func (h *Handler) ImportSystemPrompts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	file, header, err := r.FormFile("xml_file")
+	if err == http.ErrMissingFile || err == http.ErrNotMultipart {
+		names, err := middleware.ImportBundledSystemPrompts(bundledSystemPromptDir)
+		if err != nil {
+			log.Printf("Error importing bundled system prompts: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Imported bundled system prompts: %s", strings.Join(names, ", "))
+		http.Redirect(w, r, "/system_prompts", http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		log.Printf("Error uploading file: %v", err)
+		http.Error(w, "Failed to read uploaded file", http.StatusBadRequest)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := readAll(file)
+	if err != nil {
+		log.Printf("Error reading file: %v", err)
+		http.Error(w, "Error reading file", http.StatusInternalServerError)
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		name = middleware.SystemPromptNameFromFile(header.Filename)
+	}
+	if _, err := middleware.ImportSystemPromptXML(name, data); err != nil {
+		log.Printf("Error importing system prompt %s: %v", name, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/system_prompts", http.StatusSeeOther)
+}
+
+// AssignSystemPrompts sets the system prompts of the current suite's profiles. An
+// empty value leaves the profile's prompts to their models' system prompts.
+func (h *Handler) AssignSystemPrompts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Error parsing form: %v", err)
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	prompts, err := middleware.ListSystemPrompts()
+	if err != nil {
+		log.Printf("Error loading system prompts: %v", err)
+		http.Error(w, "Error loading system prompts", http.StatusInternalServerError)
+		return
+	}
+
+	suiteName := h.DataStore.GetCurrentSuiteName()
+	for field, values := range r.PostForm {
+		profile, ok := strings.CutPrefix(field, profileSystemPromptField)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if values[0] != "" && !slices.ContainsFunc(prompts, func(p middleware.SystemPrompt) bool { return p.Name == values[0] }) {
+			http.Error(w, "Unknown system prompt", http.StatusBadRequest)
+			return
+		}
+		if err := middleware.SetProfileSystemPrompt(suiteName, profile, values[0]); err != nil {
+			log.Printf("Error saving system prompt of profile %s: %v", profile, err)
+			http.Error(w, "Error saving system prompts", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.Redirect(w, r, "/system_prompts", http.StatusSeeOther)
+}
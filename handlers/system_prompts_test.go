@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"llm-tournament/middleware"
+	"llm-tournament/testutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSystemPrompts_SaveImportAndAssign(t *testing.T) {
+	defer changeToProjectRootStats(t)()
+	defer setupEvaluationTestDB(t)()
+	handler := NewHandlerWithDeps(&middleware.SQLiteDataStore{}, &testutil.MockRenderer{})
+
+	// Without an upload the bundled XML files are imported
+	if rr := postJudgesForm(t, handler.ImportSystemPrompts, url.Values{}); rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after importing the bundled prompts, got %d: %s", rr.Code, rr.Body.String())
+	}
+	prompts, _ := middleware.ListSystemPrompts()
+	if len(prompts) != 3 {
+		t.Fatalf("expected the three bundled prompts, got %+v", prompts)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("xml_file", "system_prompt_reviewer.xml")
+	_, _ = part.Write([]byte("<role><description>Code reviewer</description></role>"))
+	_ = form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/system_prompts/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rr := httptest.NewRecorder()
+	handler.ImportSystemPrompts(rr, req)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after uploading, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if reviewer, _ := middleware.GetSystemPrompt("reviewer"); reviewer == nil || reviewer.Description != "Code reviewer" {
+		t.Errorf("expected the upload stored as reviewer, got %+v", reviewer)
+	}
+
+	rr = postJudgesForm(t, handler.SaveSystemPrompt, url.Values{"name": {"reviewer"}, "content": {"Review strictly."}})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after saving, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if versions, _ := middleware.ListSystemPromptVersions("reviewer"); len(versions) != 2 {
+		t.Errorf("expected the edit stored as a second version, got %+v", versions)
+	}
+	if rr := postJudgesForm(t, handler.SaveSystemPrompt, url.Values{"name": {"empty"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a prompt without content to be rejected, got %d", rr.Code)
+	}
+
+	if rr := postJudgesForm(t, handler.AssignSystemPrompts, url.Values{"profile:Code": {"missing"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown system prompt to be rejected, got %d", rr.Code)
+	}
+	rr = postJudgesForm(t, handler.AssignSystemPrompts, url.Values{"profile:Code": {"programming"}, "profile:Poetry": {""}})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after assigning, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if assigned, _ := middleware.GetProfileSystemPrompts("default"); len(assigned) != 1 || assigned["Code"] != "programming" {
+		t.Errorf("expected only Code to have a system prompt, got %v", assigned)
+	}
+
+	if rr := postJudgesForm(t, handler.DeleteSystemPrompt, url.Values{"name": {"reviewer"}}); rr.Code != http.StatusSeeOther {
+		t.Errorf("expected a redirect after deleting, got %d", rr.Code)
+	}
+	if rr := postJudgesForm(t, handler.DeleteSystemPrompt, url.Values{"name": {"reviewer"}}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected deleting a missing prompt to fail, got %d", rr.Code)
+	}
+}
+
+func TestSystemPrompts_MethodNotAllowed(t *testing.T) {
+	handler := NewHandlerWithDeps(&MockDataStore{}, &testutil.MockRenderer{})
+	for name, handle := range map[string]http.HandlerFunc{
+		"SystemPrompts":       handler.SystemPrompts,
+		"SaveSystemPrompt":    handler.SaveSystemPrompt,
+		"DeleteSystemPrompt":  handler.DeleteSystemPrompt,
+		"ImportSystemPrompts": handler.ImportSystemPrompts,
+		"AssignSystemPrompts": handler.AssignSystemPrompts,
+	} {
+		method := http.MethodGet
+		if name == "SystemPrompts" {
+			method = http.MethodPost
+		}
+		rr := httptest.NewRecorder()
+		handle(rr, httptest.NewRequest(method, "/", nil))
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: expected %d, got %d", name, http.StatusMethodNotAllowed, rr.Code)
+		}
+	}
+}
+
+func TestSystemPromptsTemplate_Renders(t *testing.T) {
+	defer changeToProjectRootStats(t)()
+	defer setupEvaluationTestDB(t)()
+	if err := middleware.WriteProfiles([]middleware.Profile{{Name: "Code"}}); err != nil {
+		t.Fatalf("failed to write profiles: %v", err)
+	}
+	if _, err := middleware.SaveSystemPrompt("programming", "Go expert", "You write Go."); err != nil {
+		t.Fatalf("failed to save system prompt: %v", err)
+	}
+	if err := middleware.SetProfileSystemPrompt("default", "Code", "programming"); err != nil {
+		t.Fatalf("failed to assign system prompt: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	SystemPromptsHandler(rr, httptest.NewRequest(http.MethodGet, "/system_prompts", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{"Go expert", "You write Go.", `name="profile:Code"`, `value="programming" selected`, "system_prompt_*.xml"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the page to contain %q", want)
+		}
+	}
+}
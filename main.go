@@ -36,6 +36,8 @@ var routes = map[string]http.HandlerFunc{
 	"/update_mock_results":     handlers.UpdateMockResultsHandler,
 	"/randomize_scores":        handlers.RandomizeScoresHandler,
 	"/evaluate":                handlers.EvaluateResult,
+	"/score_history":           handlers.ScoreHistoryHandler,
+	"/score_history/revert":    handlers.RevertScoreChangeHandler,
 	"/profiles":                handlers.ProfilesHandler,
 	"/add_profile":             handlers.AddProfileHandler,
 	"/edit_profile":            handlers.EditProfileHandler,
@@ -43,15 +45,50 @@ var routes = map[string]http.HandlerFunc{
 	"/reset_profiles":          handlers.ResetProfilesHandler,
 	"/stats":                   handlers.StatsHandler,
 	// New evaluation routes
-	"/settings":            handlers.SettingsHandler,
-	"/settings/update":     handlers.UpdateSettingsHandler,
-	"/settings/test_key":   handlers.TestAPIKeyHandler,
-	"/evaluate/all":        handlers.EvaluateAllHandler,
-	"/evaluate/model":      handlers.EvaluateModelHandler,
-	"/evaluate/prompt":     handlers.EvaluatePromptHandler,
-	"/evaluation/progress": handlers.EvaluationProgressHandler,
-	"/evaluation/cancel":   handlers.CancelEvaluationHandler,
-	"/save_model_response": handlers.SaveModelResponseHandler,
+	"/settings":              handlers.SettingsHandler,
+	"/settings/update":       handlers.UpdateSettingsHandler,
+	"/settings/test_key":     handlers.TestAPIKeyHandler,
+	"/evaluate/all":          handlers.EvaluateAllHandler,
+	"/evaluate/model":        handlers.EvaluateModelHandler,
+	"/evaluate/prompt":       handlers.EvaluatePromptHandler,
+	"/evaluation/progress":   handlers.EvaluationProgressHandler,
+	"/evaluation/cancel":     handlers.CancelEvaluationHandler,
+	"/evaluation/remove":     handlers.RemoveEvaluationJobHandler,
+	"/jobs":                  handlers.JobsHandler,
+	"/jobs/json":             handlers.JobsJSONHandler,
+	"/jobs/detail":           handlers.JobDetailHandler,
+	"/jobs/detail/json":      handlers.JobDetailJSONHandler,
+	"/jobs/retry_failed":     handlers.RetryFailedPairsHandler,
+	"/jobs/resume":           handlers.ResumeJobHandler,
+	"/jobs/restart":          handlers.RestartJobHandler,
+	"/jobs/revert_scores":    handlers.RevertJobScoresHandler,
+	"/save_model_response":   handlers.SaveModelResponseHandler,
+	"/generate/all":          handlers.GenerateAllHandler,
+	"/generate/model":        handlers.GenerateModelHandler,
+	"/model_config":          handlers.ModelConfigHandler,
+	"/battle":                handlers.BattleHandler,
+	"/battle/judge":          handlers.JudgeBattlesHandler,
+	"/agreement":             handlers.AgreementHandler,
+	"/agreement/json":        handlers.AgreementJSONHandler,
+	"/calibration":           handlers.CalibrationHandler,
+	"/calibration/json":      handlers.CalibrationJSONHandler,
+	"/costs":                 handlers.CostsHandler,
+	"/costs/json":            handlers.CostsJSONHandler,
+	"/judges":                handlers.JudgesHandler,
+	"/judges/save":           handlers.SaveJudgeHandler,
+	"/judges/delete":         handlers.DeleteJudgeHandler,
+	"/panels/save":           handlers.SavePanelHandler,
+	"/panels/delete":         handlers.DeletePanelHandler,
+	"/panels/assign":         handlers.AssignPanelsHandler,
+	"/system_prompts":        handlers.SystemPromptsHandler,
+	"/system_prompts/save":   handlers.SaveSystemPromptHandler,
+	"/system_prompts/delete": handlers.DeleteSystemPromptHandler,
+	"/system_prompts/import": handlers.ImportSystemPromptsHandler,
+	"/system_prompts/assign": handlers.AssignSystemPromptsHandler,
+	"/snapshots":             handlers.SnapshotsHandler,
+	"/snapshots/create":      handlers.CreateSnapshotHandler,
+	"/snapshots/restore":     handlers.RestoreSnapshotHandler,
+	"/snapshots/delete":      handlers.DeleteSnapshotHandler,
 }
 
 func router(w http.ResponseWriter, r *http.Request) {
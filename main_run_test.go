@@ -246,6 +246,37 @@ func TestRun_ServePath_ListenAndServeError_Returns1(t *testing.T) {
 	}
 }
 
+func TestRun_ShutsDownEvaluatorBeforeClosingDB(t *testing.T) {
+	var calls []string
+
+	deps := runDeps{
+		initDB: func(string) error {
+			return nil
+		},
+		closeDB: func() error {
+			calls = append(calls, "closeDB")
+			return nil
+		},
+		initEvaluator: func(*sql.DB) {},
+		shutdownEvaluator: func() {
+			calls = append(calls, "shutdownEvaluator")
+		},
+		getDB: func() *sql.DB { return nil },
+		listenAndServe: func(string, http.Handler) error {
+			calls = append(calls, "listenAndServe")
+			return nil
+		},
+	}
+
+	if exitCode := run([]string{"-db", "db.sqlite"}, deps); exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", exitCode)
+	}
+	want := []string{"listenAndServe", "shutdownEvaluator", "closeDB"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected calls %v, got %v", want, calls)
+	}
+}
+
 func TestDefaultRunDeps_HasRequiredDeps(t *testing.T) {
 	deps := defaultRunDeps()
 
@@ -256,6 +287,7 @@ func TestDefaultRunDeps_HasRequiredDeps(t *testing.T) {
 		deps.getCurrentSuiteName == nil ||
 		deps.writeResults == nil ||
 		deps.initEvaluator == nil ||
+		deps.shutdownEvaluator == nil ||
 		deps.getDB == nil ||
 		deps.listenAndServe == nil {
 		t.Fatalf("expected all default run deps to be non-nil: %#v", deps)
@@ -51,6 +51,8 @@ func TestRoutes_AllRoutesDefined(t *testing.T) {
 		"/export_results",
 		"/update_mock_results",
 		"/evaluate",
+		"/score_history",
+		"/score_history/revert",
 		"/profiles",
 		"/add_profile",
 		"/edit_profile",
@@ -65,6 +67,41 @@ func TestRoutes_AllRoutesDefined(t *testing.T) {
 		"/evaluate/prompt",
 		"/evaluation/progress",
 		"/evaluation/cancel",
+		"/evaluation/remove",
+		"/jobs",
+		"/jobs/json",
+		"/jobs/detail",
+		"/jobs/detail/json",
+		"/jobs/retry_failed",
+		"/jobs/resume",
+		"/jobs/restart",
+		"/jobs/revert_scores",
+		"/generate/all",
+		"/generate/model",
+		"/model_config",
+		"/battle",
+		"/battle/judge",
+		"/agreement",
+		"/agreement/json",
+		"/calibration",
+		"/calibration/json",
+		"/costs",
+		"/costs/json",
+		"/judges",
+		"/judges/save",
+		"/judges/delete",
+		"/panels/save",
+		"/panels/delete",
+		"/panels/assign",
+		"/system_prompts",
+		"/system_prompts/save",
+		"/system_prompts/delete",
+		"/system_prompts/import",
+		"/system_prompts/assign",
+		"/snapshots",
+		"/snapshots/create",
+		"/snapshots/restore",
+		"/snapshots/delete",
 	}
 
 	for _, route := range expectedRoutes {
@@ -128,7 +165,7 @@ func TestRouter_RootRoute_Redirects(t *testing.T) {
 
 func TestRoutesCount(t *testing.T) {
 	// Ensure we have the expected number of routes
-	expectedCount := 43
+	expectedCount := 80
 	if len(routes) != expectedCount {
 		t.Errorf("expected %d routes, got %d", expectedCount, len(routes))
 	}
@@ -178,7 +215,25 @@ func TestRouter_POSTRoutes(t *testing.T) {
 		"/evaluate/model",
 		"/evaluate/prompt",
 		"/evaluation/cancel",
+		"/evaluation/remove",
+		"/jobs/retry_failed",
+		"/jobs/resume",
+		"/jobs/restart",
+		"/jobs/revert_scores",
+		"/score_history/revert",
 		"/settings/update",
+		"/judges/save",
+		"/judges/delete",
+		"/panels/save",
+		"/panels/delete",
+		"/panels/assign",
+		"/system_prompts/save",
+		"/system_prompts/delete",
+		"/system_prompts/import",
+		"/system_prompts/assign",
+		"/snapshots/create",
+		"/snapshots/restore",
+		"/snapshots/delete",
 	}
 
 	for _, route := range postRoutes {
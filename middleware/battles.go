@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Battle outcomes, from model A's point of view
+const (
+	BattleWinA = "a"
+	BattleWinB = "b"
+	BattleTie  = "tie"
+)
+
+// BattleJudgeHuman marks battles decided on the battle page
+const BattleJudgeHuman = "human"
+
+// Battle is a pairwise comparison of two models' responses to the same prompt
+type Battle struct {
+	ID        int       `json:"id"`
+	PromptID  int       `json:"prompt_id"` // 0 when the prompt has since been deleted
+	ModelA    string    `json:"model_a"`
+	ModelB    string    `json:"model_b"`
+	Outcome   string    `json:"outcome"` // 'a', 'b' or 'tie'
+	Judge     string    `json:"judge"`   // 'human' or the judge name
+	JobID     int       `json:"job_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BattleCandidate is a prompt with two anonymised responses to compare
+type BattleCandidate struct {
+	PromptID  int
+	Prompt    string
+	Solution  string
+	ModelA    string
+	ResponseA string
+	ModelB    string
+	ResponseB string
+}
+
+// IsBattleOutcome reports whether outcome is a valid battle outcome
+func IsBattleOutcome(outcome string) bool {
+	return outcome == BattleWinA || outcome == BattleWinB || outcome == BattleTie
+}
+
+// RecordBattle stores the outcome of a battle between two models in a suite
+func RecordBattle(suiteName string, b Battle) error {
+	if !IsBattleOutcome(b.Outcome) {
+		return fmt.Errorf("invalid battle outcome: %q", b.Outcome)
+	}
+	if b.ModelA == b.ModelB {
+		return fmt.Errorf("a model cannot battle itself")
+	}
+	if b.Judge == "" {
+		b.Judge = BattleJudgeHuman
+	}
+
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return fmt.Errorf("failed to get suite ID: %w", err)
+	}
+	modelAID, err := GetModelID(suiteName, b.ModelA)
+	if err != nil {
+		return err
+	}
+	modelBID, err := GetModelID(suiteName, b.ModelB)
+	if err != nil {
+		return err
+	}
+
+	var promptID, jobID interface{}
+	if b.PromptID > 0 {
+		promptID = b.PromptID
+	}
+	if b.JobID > 0 {
+		jobID = b.JobID
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO battles (suite_id, prompt_id, model_a_id, model_b_id, outcome, judge, job_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, suiteID, promptID, modelAID, modelBID, b.Outcome, b.Judge, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to record battle: %w", err)
+	}
+
+	return nil
+}
+
+// ListBattles returns all battles in a suite, oldest first
+func ListBattles(suiteName string) ([]Battle, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT b.id, b.prompt_id, ma.name, mb.name, b.outcome, b.judge, b.job_id, b.created_at
+		FROM battles b
+		JOIN models ma ON ma.id = b.model_a_id
+		JOIN models mb ON mb.id = b.model_b_id
+		WHERE b.suite_id = ?
+		ORDER BY b.id
+	`, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query battles: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var battles []Battle
+	for rows.Next() {
+		var b Battle
+		var promptID, jobID sql.NullInt64
+		if err := rows.Scan(&b.ID, &promptID, &b.ModelA, &b.ModelB, &b.Outcome, &b.Judge, &jobID, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan battle: %w", err)
+		}
+		b.PromptID = int(promptID.Int64)
+		b.JobID = int(jobID.Int64)
+		battles = append(battles, b)
+	}
+
+	return battles, rows.Err()
+}
+
+// GetBattleCandidate picks a random prompt with at least two stored responses and
+// two of its responses in random order. It returns nil when no prompt qualifies.
+func GetBattleCandidate(suiteName string) (*BattleCandidate, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT p.id, p.text, COALESCE(p.solution, ''), m.name, r.response_text
+		FROM model_responses r
+		JOIN models m ON m.id = r.model_id
+		JOIN prompts p ON p.id = r.prompt_id
+		WHERE p.suite_id = ? AND r.response_text IS NOT NULL AND r.response_text != ''
+		ORDER BY p.display_order, m.name
+	`, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query responses: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	type response struct {
+		model string
+		text  string
+	}
+	prompts := make(map[int]*BattleCandidate)
+	responses := make(map[int][]response)
+	var eligible []int
+	for rows.Next() {
+		var c BattleCandidate
+		var r response
+		if err := rows.Scan(&c.PromptID, &c.Prompt, &c.Solution, &r.model, &r.text); err != nil {
+			return nil, fmt.Errorf("failed to scan response: %w", err)
+		}
+		if _, ok := prompts[c.PromptID]; !ok {
+			prompts[c.PromptID] = &c
+		}
+		responses[c.PromptID] = append(responses[c.PromptID], r)
+		if len(responses[c.PromptID]) == 2 {
+			eligible = append(eligible, c.PromptID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(eligible) == 0 {
+		return nil, nil
+	}
+
+	promptID := eligible[rand.Intn(len(eligible))]
+	candidate := prompts[promptID]
+	pair := rand.Perm(len(responses[promptID]))[:2]
+	a, b := responses[promptID][pair[0]], responses[promptID][pair[1]]
+	candidate.ModelA, candidate.ResponseA = a.model, a.text
+	candidate.ModelB, candidate.ResponseB = b.model, b.text
+
+	return candidate, nil
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import "testing"
+
+// setupBattleModels initialises the database with two models and one prompt
+func setupBattleModels(t *testing.T) func() {
+	t.Helper()
+	dbPath, cleanup := setupTestDB(t)
+	if err := InitDB(dbPath); err != nil {
+		cleanup()
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	_, err := db.Exec(`
+		INSERT INTO models (name, suite_id) VALUES ('alpha', 1), ('beta', 1);
+		INSERT INTO prompts (id, text, suite_id, display_order) VALUES (1, 'Q', 1, 0);
+	`)
+	if err != nil {
+		cleanup()
+		t.Fatalf("failed to seed: %v", err)
+	}
+	return cleanup
+}
+
+func TestRecordAndListBattles(t *testing.T) {
+	cleanup := setupBattleModels(t)
+	defer cleanup()
+
+	if err := RecordBattle("default", Battle{PromptID: 1, ModelA: "alpha", ModelB: "beta", Outcome: BattleWinA}); err != nil {
+		t.Fatalf("RecordBattle failed: %v", err)
+	}
+	if err := RecordBattle("default", Battle{ModelA: "beta", ModelB: "alpha", Outcome: BattleTie, Judge: "gpt_5.2"}); err != nil {
+		t.Fatalf("RecordBattle failed: %v", err)
+	}
+
+	battles, err := ListBattles("default")
+	if err != nil {
+		t.Fatalf("ListBattles failed: %v", err)
+	}
+	if len(battles) != 2 {
+		t.Fatalf("expected 2 battles, got %d", len(battles))
+	}
+	if battles[0].ModelA != "alpha" || battles[0].Outcome != BattleWinA || battles[0].Judge != BattleJudgeHuman || battles[0].PromptID != 1 {
+		t.Errorf("unexpected first battle: %+v", battles[0])
+	}
+	if battles[1].Judge != "gpt_5.2" || battles[1].PromptID != 0 {
+		t.Errorf("unexpected second battle: %+v", battles[1])
+	}
+}
+
+func TestRecordBattle_Validation(t *testing.T) {
+	cleanup := setupBattleModels(t)
+	defer cleanup()
+
+	tests := []Battle{
+		{ModelA: "alpha", ModelB: "beta", Outcome: "draw"},
+		{ModelA: "alpha", ModelB: "alpha", Outcome: BattleTie},
+		{ModelA: "alpha", ModelB: "missing", Outcome: BattleWinA},
+	}
+	for _, b := range tests {
+		if err := RecordBattle("default", b); err == nil {
+			t.Errorf("expected error for %+v", b)
+		}
+	}
+}
+
+func TestGetBattleCandidate(t *testing.T) {
+	cleanup := setupBattleModels(t)
+	defer cleanup()
+
+	candidate, err := GetBattleCandidate("default")
+	if err != nil || candidate != nil {
+		t.Fatalf("expected no candidate without responses, got %+v, %v", candidate, err)
+	}
+
+	_, err = db.Exec(`INSERT INTO model_responses (model_id, prompt_id, response_text)
+		SELECT id, 1, 'answer from ' || name FROM models`)
+	if err != nil {
+		t.Fatalf("failed to insert responses: %v", err)
+	}
+
+	candidate, err = GetBattleCandidate("default")
+	if err != nil || candidate == nil {
+		t.Fatalf("expected a candidate, got %+v, %v", candidate, err)
+	}
+	if candidate.PromptID != 1 || candidate.Prompt != "Q" {
+		t.Errorf("unexpected prompt: %+v", candidate)
+	}
+	if candidate.ModelA == candidate.ModelB {
+		t.Error("candidate models must differ")
+	}
+	if candidate.ResponseA != "answer from "+candidate.ModelA || candidate.ResponseB != "answer from "+candidate.ModelB {
+		t.Errorf("responses do not match models: %+v", candidate)
+	}
+}
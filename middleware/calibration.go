@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Calibration methods a suite can apply to judge scores before consensus
+const (
+	CalibrationOff      = ""
+	CalibrationLinear   = "linear"
+	CalibrationIsotonic = "isotonic"
+)
+
+// IsCalibrationMethod reports whether method is a valid calibration setting
+func IsCalibrationMethod(method string) bool {
+	return method == CalibrationOff || method == CalibrationLinear || method == CalibrationIsotonic
+}
+
+// CalibrationSample pairs a judge's latest score for a cell with the manual (gold) score
+type CalibrationSample struct {
+	Judge      string `json:"judge"`
+	JudgeScore int    `json:"judge_score"`
+	GoldScore  int    `json:"gold_score"`
+	PromptType string `json:"prompt_type"`
+}
+
+// SaveGoldScore records a manually graded cell as a gold label for judge calibration.
+// promptIndex is the prompt's position in the suite, as used by the results grid.
+func SaveGoldScore(suiteName, modelName string, promptIndex, score int) error {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return fmt.Errorf("failed to get suite ID: %w", err)
+	}
+	modelID, err := GetModelID(suiteName, modelName)
+	if err != nil {
+		return err
+	}
+
+	var promptID int
+	err = db.QueryRow(
+		"SELECT id FROM prompts WHERE suite_id = ? ORDER BY display_order, id LIMIT 1 OFFSET ?",
+		suiteID, promptIndex,
+	).Scan(&promptID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("prompt %d not found in suite %s", promptIndex, suiteName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get prompt ID: %w", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO gold_scores (model_id, prompt_id, score, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(model_id, prompt_id) DO UPDATE SET
+			score = excluded.score,
+			updated_at = excluded.updated_at
+	`, modelID, promptID, score)
+	if err != nil {
+		return fmt.Errorf("failed to save gold score: %w", err)
+	}
+	return nil
+}
+
+// ListCalibrationSamples returns every judge score in a suite that has a gold label,
+// using each judge's most recent score for the cell
+func ListCalibrationSamples(suiteName string) ([]CalibrationSample, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT h.judge_name, COALESCE(h.judge_score, 0), g.score, COALESCE(p.type, '')
+		FROM evaluation_history h
+		JOIN gold_scores g ON g.model_id = h.model_id AND g.prompt_id = h.prompt_id
+		JOIN prompts p ON p.id = h.prompt_id
+		WHERE p.suite_id = ? AND h.id IN (
+			SELECT MAX(id) FROM evaluation_history GROUP BY judge_name, model_id, prompt_id
+		)
+		ORDER BY h.judge_name, h.id
+	`, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query calibration samples: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var samples []CalibrationSample
+	for rows.Next() {
+		var s CalibrationSample
+		if err := rows.Scan(&s.Judge, &s.JudgeScore, &s.GoldScore, &s.PromptType); err != nil {
+			return nil, fmt.Errorf("failed to scan calibration sample: %w", err)
+		}
+		samples = append(samples, s)
+	}
+
+	return samples, rows.Err()
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import "testing"
+
+func TestSaveGoldScore_AndListCalibrationSamples(t *testing.T) {
+	cleanup := setupBattleModels(t)
+	defer cleanup()
+
+	_, err := db.Exec(`
+		INSERT INTO prompts (id, text, suite_id, display_order, type) VALUES (2, 'R', 1, 1, 'creative');
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all'), (2, 1, 'all');
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, judge_confidence)
+		VALUES (1, 1, 2, 'claude', 40, 1),
+		       (2, 1, 2, 'claude', 80, 1),
+		       (1, 1, 2, 'gpt', 60, 1),
+		       (1, 2, 2, 'claude', 20, 1);
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	// Prompt index 1 is the second prompt in display order
+	if err := SaveGoldScore("default", "alpha", 1, 60); err != nil {
+		t.Fatalf("SaveGoldScore failed: %v", err)
+	}
+	if err := SaveGoldScore("default", "alpha", 1, 100); err != nil {
+		t.Fatalf("SaveGoldScore update failed: %v", err)
+	}
+
+	samples, err := ListCalibrationSamples("default")
+	if err != nil {
+		t.Fatalf("ListCalibrationSamples failed: %v", err)
+	}
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples (beta has no gold label), got %+v", samples)
+	}
+	want := CalibrationSample{Judge: "claude", JudgeScore: 80, GoldScore: 100, PromptType: "creative"}
+	if samples[0] != want {
+		t.Errorf("expected %+v, got %+v", want, samples[0])
+	}
+	if samples[1].Judge != "gpt" || samples[1].GoldScore != 100 {
+		t.Errorf("unexpected second sample: %+v", samples[1])
+	}
+
+	if err := SaveGoldScore("default", "alpha", 5, 60); err == nil {
+		t.Error("expected error for a prompt index outside the suite")
+	}
+}
+
+func TestIsCalibrationMethod(t *testing.T) {
+	for _, method := range []string{CalibrationOff, CalibrationLinear, CalibrationIsotonic} {
+		if !IsCalibrationMethod(method) {
+			t.Errorf("expected %q to be valid", method)
+		}
+	}
+	if IsCalibrationMethod("platt") {
+		t.Error("expected platt to be invalid")
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Roles a conversation turn can take
+const (
+	TurnRoleSystem    = "system"
+	TurnRoleUser      = "user"
+	TurnRoleAssistant = "assistant"
+)
+
+// Turn is one message of a conversation prompt. A conversation prompt's turns come
+// before its text, which is the user's final message; the model's reply to it is the
+// assistant turn that is scored.
+type Turn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ValidateTurns checks that a conversation can be continued by the prompt's text:
+// optional system turns, then user and assistant turns taking turns, starting with
+// the user and ending with an assistant reply
+func ValidateTurns(turns []Turn) error {
+	next := TurnRoleUser
+	for i, turn := range turns {
+		if strings.TrimSpace(turn.Content) == "" {
+			return fmt.Errorf("turn %d has no content", i+1)
+		}
+		switch turn.Role {
+		case TurnRoleSystem:
+			if next != TurnRoleUser || (i > 0 && turns[i-1].Role != TurnRoleSystem) {
+				return fmt.Errorf("turn %d: system turns must come first", i+1)
+			}
+		case TurnRoleUser, TurnRoleAssistant:
+			if turn.Role != next {
+				return fmt.Errorf("turn %d: expected a %s turn, got %s", i+1, next, turn.Role)
+			}
+			if next == TurnRoleUser {
+				next = TurnRoleAssistant
+			} else {
+				next = TurnRoleUser
+			}
+		default:
+			return fmt.Errorf("turn %d: unknown role %q", i+1, turn.Role)
+		}
+	}
+	if next != TurnRoleUser {
+		return fmt.Errorf("the conversation must end with an assistant turn before the final user message")
+	}
+	return nil
+}
+
+// readSuiteTurns returns the conversation turns of every prompt in a suite, by prompt ID
+func readSuiteTurns(suiteID int) (map[int][]Turn, error) {
+	rows, err := db.Query(`
+		SELECT t.prompt_id, t.role, t.content
+		FROM prompt_turns t
+		JOIN prompts p ON p.id = t.prompt_id
+		WHERE p.suite_id = ?
+		ORDER BY t.prompt_id, t.position
+	`, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt turns: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	turns := make(map[int][]Turn)
+	for rows.Next() {
+		var promptID int
+		var turn Turn
+		if err := rows.Scan(&promptID, &turn.Role, &turn.Content); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt turn: %w", err)
+		}
+		turns[promptID] = append(turns[promptID], turn)
+	}
+	return turns, rows.Err()
+}
+
+// writePromptTurns stores the conversation turns of a newly inserted prompt
+func writePromptTurns(tx *sql.Tx, promptID int64, turns []Turn) error {
+	for i, turn := range turns {
+		_, err := tx.Exec("INSERT INTO prompt_turns (prompt_id, position, role, content) VALUES (?, ?, ?, ?)",
+			promptID, i, turn.Role, turn.Content)
+		if err != nil {
+			return fmt.Errorf("failed to insert prompt turn: %w", err)
+		}
+	}
+	return nil
+}
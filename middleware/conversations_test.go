@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateTurns(t *testing.T) {
+	sys := Turn{Role: TurnRoleSystem, Content: "Answer in French"}
+	user := Turn{Role: TurnRoleUser, Content: "Hello"}
+	asst := Turn{Role: TurnRoleAssistant, Content: "Bonjour"}
+
+	tests := []struct {
+		name  string
+		turns []Turn
+		ok    bool
+	}{
+		{"single turn", nil, true},
+		{"one exchange", []Turn{user, asst}, true},
+		{"system first", []Turn{sys, sys, user, asst, user, asst}, true},
+		{"system only", []Turn{sys}, true},
+		{"ends with user", []Turn{user, asst, user}, false},
+		{"starts with assistant", []Turn{asst}, false},
+		{"two user turns", []Turn{user, user, asst}, false},
+		{"late system turn", []Turn{user, asst, sys}, false},
+		{"unknown role", []Turn{{Role: "tool", Content: "x"}}, false},
+		{"empty content", []Turn{{Role: TurnRoleUser, Content: " "}, asst}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateTurns(tt.turns); (err == nil) != tt.ok {
+			t.Errorf("%s: expected ok=%v, got %v", tt.name, tt.ok, err)
+		}
+	}
+}
+
+func TestPromptTurnsRoundTrip(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	turns := []Turn{
+		{Role: TurnRoleSystem, Content: "You are terse."},
+		{Role: TurnRoleUser, Content: "My name is Ada."},
+		{Role: TurnRoleAssistant, Content: "Noted."},
+	}
+	if err := WritePromptSuite("default", []Prompt{{Text: "single"}, {Text: "What is my name?", Turns: turns}}); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	prompts, err := ReadPromptSuite("default")
+	if err != nil {
+		t.Fatalf("ReadPromptSuite failed: %v", err)
+	}
+	if len(prompts) != 2 || prompts[0].Turns != nil || !reflect.DeepEqual(prompts[1].Turns, turns) {
+		t.Fatalf("expected only the second prompt to be a conversation, got %+v", prompts)
+	}
+
+	// Rewriting the suite replaces the turns rather than adding to them
+	if err := WritePromptSuite("default", prompts[1:]); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM prompt_turns").Scan(&count); err != nil || count != len(turns) {
+		t.Errorf("expected %d stored turns, got %d (%v)", len(turns), count, err)
+	}
+}
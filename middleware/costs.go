@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+)
+
+// CostRow is the spend of one suite, judge or day on the cost dashboard
+type CostRow struct {
+	Label       string  `json:"label"`
+	CostUSD     float64 `json:"cost_usd"`
+	Evaluations int     `json:"evaluations"`
+}
+
+// CostSummary breaks down judge spend since a given day. Per-suite rows cover every
+// suite; per-day and per-judge rows cover only the requested suite.
+type CostSummary struct {
+	Since          string    `json:"since"` // First day included, YYYY-MM-DD
+	SuiteTotalUSD  float64   `json:"suite_total_usd"`
+	MonthToDateUSD float64   `json:"month_to_date_usd"` // Suite spend in the current calendar month
+	BySuite        []CostRow `json:"by_suite"`
+	ByDay          []CostRow `json:"by_day"`
+	ByJudge        []CostRow `json:"by_judge"`
+}
+
+// GetCostSummary reads the spend recorded in cost_tracking since the given day, plus the
+// judge costs in evaluation_history, which break the spend down by judge
+func GetCostSummary(suiteName string, since time.Time) (CostSummary, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return CostSummary{}, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	summary := CostSummary{Since: since.Format(time.DateOnly)}
+
+	summary.BySuite, err = queryCostRows(`
+		SELECT s.name, SUM(c.total_cost_usd), SUM(c.evaluation_count)
+		FROM cost_tracking c
+		JOIN suites s ON s.id = c.suite_id
+		WHERE c.date >= ?
+		GROUP BY s.id
+		ORDER BY SUM(c.total_cost_usd) DESC, s.name
+	`, summary.Since)
+	if err != nil {
+		return CostSummary{}, fmt.Errorf("failed to query spend per suite: %w", err)
+	}
+
+	summary.ByDay, err = queryCostRows(`
+		SELECT CAST(date AS TEXT), total_cost_usd, evaluation_count
+		FROM cost_tracking
+		WHERE suite_id = ? AND date >= ?
+		ORDER BY date DESC
+	`, suiteID, summary.Since)
+	if err != nil {
+		return CostSummary{}, fmt.Errorf("failed to query spend per day: %w", err)
+	}
+	for _, day := range summary.ByDay {
+		summary.SuiteTotalUSD += day.CostUSD
+	}
+
+	summary.ByJudge, err = queryCostRows(`
+		SELECT h.judge_name, COALESCE(SUM(h.cost_usd), 0), COUNT(*)
+		FROM evaluation_history h
+		JOIN prompts p ON p.id = h.prompt_id
+		WHERE p.suite_id = ? AND h.created_at >= ?
+		GROUP BY h.judge_name
+		ORDER BY SUM(h.cost_usd) DESC, h.judge_name
+	`, suiteID, summary.Since)
+	if err != nil {
+		return CostSummary{}, fmt.Errorf("failed to query spend per judge: %w", err)
+	}
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	err = db.QueryRow(`
+		SELECT COALESCE(SUM(total_cost_usd), 0)
+		FROM cost_tracking
+		WHERE suite_id = ? AND date >= ?
+	`, suiteID, monthStart.Format(time.DateOnly)).Scan(&summary.MonthToDateUSD)
+	if err != nil {
+		return CostSummary{}, fmt.Errorf("failed to query spend this month: %w", err)
+	}
+
+	return summary, nil
+}
+
+// queryCostRows runs a query selecting label, cost and evaluation count
+func queryCostRows(query string, args ...interface{}) ([]CostRow, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var result []CostRow
+	for rows.Next() {
+		var r CostRow
+		if err := rows.Scan(&r.Label, &r.CostUSD, &r.Evaluations); err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetCostSummary(t *testing.T) {
+	cleanup := setupBattleModels(t)
+	defer cleanup()
+
+	now := time.Now()
+	today := now.Format(time.DateOnly)
+	old := now.AddDate(0, 0, -40).Format(time.DateOnly)
+	_, err := db.Exec(`
+		INSERT INTO suites (id, name) VALUES (2, 'other');
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all');
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, cost_usd)
+		VALUES (1, 1, 1, 'claude', 80, 0.25),
+		       (1, 2, 1, 'claude', 60, 0.25),
+		       (1, 1, 1, 'gpt', 40, 0.5);
+		INSERT INTO cost_tracking (suite_id, date, total_cost_usd, evaluation_count)
+		VALUES (1, ?, 1.0, 2), (1, ?, 3.0, 6), (2, ?, 2.0, 4);
+	`, today, old, today)
+	if err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	summary, err := GetCostSummary("default", now.AddDate(0, 0, -29))
+	if err != nil {
+		t.Fatalf("GetCostSummary failed: %v", err)
+	}
+	if summary.SuiteTotalUSD != 1.0 {
+		t.Errorf("expected $1 in the window, got %f", summary.SuiteTotalUSD)
+	}
+	if len(summary.ByDay) != 1 || summary.ByDay[0].Label != today || summary.ByDay[0].Evaluations != 2 {
+		t.Errorf("expected only today's row, got %+v", summary.ByDay)
+	}
+	if len(summary.BySuite) != 2 || summary.BySuite[0].Label != "other" || summary.BySuite[1].CostUSD != 1.0 {
+		t.Errorf("expected both suites, most expensive first, got %+v", summary.BySuite)
+	}
+	if len(summary.ByJudge) != 2 || summary.ByJudge[0].Label != "claude" || summary.ByJudge[0].Evaluations != 2 || summary.ByJudge[1].CostUSD != 0.5 {
+		t.Errorf("unexpected judges: %+v", summary.ByJudge)
+	}
+	if summary.MonthToDateUSD < 1.0 {
+		t.Errorf("expected at least today's $1 this month, got %f", summary.MonthToDateUSD)
+	}
+}
@@ -41,8 +41,10 @@ func InitDB(dbPath string) error {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
+	// Evaluation jobs write from several goroutines; wait for the lock instead of failing.
+	// Foreign keys are a per-connection setting, so the DSN enables them on every connection.
 	var err error
-	db, err = sqlOpen("sqlite3", dbPath)
+	db, err = sqlOpen("sqlite3", dbPath+"?_busy_timeout=5000&_foreign_keys=on")
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -82,6 +84,7 @@ func createTables() error {
 		name TEXT NOT NULL,
 		description TEXT,
 		suite_id INTEGER NOT NULL,
+		rubric TEXT NOT NULL DEFAULT '',
 		FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE,
 		UNIQUE(name, suite_id)
 	);
@@ -94,11 +97,26 @@ func createTables() error {
 		suite_id INTEGER NOT NULL,
 		display_order INTEGER NOT NULL,
 		type TEXT NOT NULL DEFAULT 'objective',
+		checker TEXT NOT NULL DEFAULT '',
+		checker_config TEXT NOT NULL DEFAULT '',
+		language TEXT NOT NULL DEFAULT '',
+		test_cases TEXT NOT NULL DEFAULT '',
+		rubric TEXT NOT NULL DEFAULT '',
 		FOREIGN KEY (profile_id) REFERENCES profiles(id) ON DELETE SET NULL,
 		FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE,
 		UNIQUE(text, suite_id)
 	);
 
+	CREATE TABLE IF NOT EXISTS prompt_turns (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		prompt_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE,
+		UNIQUE(prompt_id, position)
+	);
+
 	CREATE TABLE IF NOT EXISTS models (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT NOT NULL,
@@ -107,6 +125,24 @@ func createTables() error {
 		UNIQUE(name, suite_id)
 	);
 
+	CREATE TABLE IF NOT EXISTS model_configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		model_id INTEGER NOT NULL UNIQUE,
+		base_url TEXT NOT NULL DEFAULT '',
+		model_name TEXT NOT NULL DEFAULT '',
+		api_key TEXT NOT NULL DEFAULT '',
+		provider TEXT NOT NULL DEFAULT 'openai',
+		temperature REAL,
+		top_p REAL,
+		max_tokens INTEGER NOT NULL DEFAULT 0,
+		system_prompt TEXT NOT NULL DEFAULT '',
+		system_prompt_name TEXT NOT NULL DEFAULT '',
+		stop_sequences TEXT NOT NULL DEFAULT '[]',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE
+	);
+
 	CREATE TABLE IF NOT EXISTS scores (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		model_id INTEGER NOT NULL,
@@ -117,6 +153,19 @@ func createTables() error {
 		UNIQUE(model_id, prompt_id)
 	);
 
+	CREATE TABLE IF NOT EXISTS criterion_scores (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		model_id INTEGER NOT NULL,
+		prompt_id INTEGER NOT NULL,
+		criterion TEXT NOT NULL,
+		score INTEGER NOT NULL,
+		weight REAL NOT NULL DEFAULT 1,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
+		FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE,
+		UNIQUE(model_id, prompt_id, criterion)
+	);
+
 	CREATE TABLE IF NOT EXISTS settings (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		key TEXT UNIQUE NOT NULL,
@@ -136,6 +185,11 @@ func createTables() error {
 		estimated_cost_usd REAL DEFAULT 0.0,
 		actual_cost_usd REAL DEFAULT 0.0,
 		error_message TEXT,
+		force_refresh INTEGER NOT NULL DEFAULT 0,
+		cache_hits INTEGER NOT NULL DEFAULT 0,
+		cache_misses INTEGER NOT NULL DEFAULT 0,
+		cache_saved_usd REAL NOT NULL DEFAULT 0,
+		judge_panel TEXT NOT NULL DEFAULT '',
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		started_at TIMESTAMP,
 		completed_at TIMESTAMP,
@@ -149,6 +203,7 @@ func createTables() error {
 		response_text TEXT,
 		response_source TEXT NOT NULL DEFAULT 'manual',
 		api_config TEXT,
+		system_prompt_version_id INTEGER,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
@@ -166,6 +221,10 @@ func createTables() error {
 		judge_confidence REAL,
 		judge_reasoning TEXT,
 		cost_usd REAL DEFAULT 0.0,
+		test_results TEXT NOT NULL DEFAULT '',
+		cache_key TEXT NOT NULL DEFAULT '',
+		cache_hit INTEGER NOT NULL DEFAULT 0,
+		criteria TEXT NOT NULL DEFAULT '',
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (job_id) REFERENCES evaluation_jobs(id) ON DELETE CASCADE,
 		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
@@ -182,6 +241,198 @@ func createTables() error {
 		UNIQUE(suite_id, date)
 	);
 
+	CREATE TABLE IF NOT EXISTS suite_settings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		suite_id INTEGER NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE,
+		UNIQUE(suite_id, key)
+	);
+
+	CREATE TABLE IF NOT EXISTS evaluation_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id INTEGER NOT NULL,
+		model_id INTEGER NOT NULL,
+		prompt_id INTEGER NOT NULL,
+		score INTEGER NOT NULL,
+		raw_score INTEGER NOT NULL,
+		consensus_strategy TEXT NOT NULL,
+		calibration TEXT NOT NULL DEFAULT '',
+		judge_count INTEGER NOT NULL DEFAULT 0,
+		checker TEXT NOT NULL DEFAULT '',
+		judge_panel TEXT NOT NULL DEFAULT '',
+		system_prompt_version_id INTEGER,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (job_id) REFERENCES evaluation_jobs(id) ON DELETE CASCADE,
+		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
+		FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS evaluation_errors (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id INTEGER NOT NULL,
+		model_id INTEGER NOT NULL,
+		prompt_id INTEGER NOT NULL,
+		error_kind TEXT NOT NULL,
+		error_message TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (job_id) REFERENCES evaluation_jobs(id) ON DELETE CASCADE,
+		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
+		FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS evaluation_job_pairs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id INTEGER NOT NULL,
+		model_id INTEGER NOT NULL,
+		prompt_id INTEGER NOT NULL,
+		outcome TEXT NOT NULL DEFAULT 'pending',
+		cost_usd REAL NOT NULL DEFAULT 0.0,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (job_id) REFERENCES evaluation_jobs(id) ON DELETE CASCADE,
+		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
+		FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE,
+		UNIQUE(job_id, model_id, prompt_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS gold_scores (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		model_id INTEGER NOT NULL,
+		prompt_id INTEGER NOT NULL,
+		score INTEGER NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
+		FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE,
+		UNIQUE(model_id, prompt_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS battles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		suite_id INTEGER NOT NULL,
+		prompt_id INTEGER,
+		model_a_id INTEGER NOT NULL,
+		model_b_id INTEGER NOT NULL,
+		outcome TEXT NOT NULL,
+		judge TEXT NOT NULL DEFAULT 'human',
+		job_id INTEGER,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE,
+		FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE SET NULL,
+		FOREIGN KEY (model_a_id) REFERENCES models(id) ON DELETE CASCADE,
+		FOREIGN KEY (model_b_id) REFERENCES models(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS battle_job_pairs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id INTEGER NOT NULL,
+		prompt_id INTEGER NOT NULL,
+		model_a_id INTEGER NOT NULL,
+		model_b_id INTEGER NOT NULL,
+		cost_usd REAL NOT NULL DEFAULT 0.0,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (job_id) REFERENCES evaluation_jobs(id) ON DELETE CASCADE,
+		FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE,
+		FOREIGN KEY (model_a_id) REFERENCES models(id) ON DELETE CASCADE,
+		FOREIGN KEY (model_b_id) REFERENCES models(id) ON DELETE CASCADE,
+		UNIQUE(job_id, prompt_id, model_a_id, model_b_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS judges (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		provider TEXT NOT NULL DEFAULT 'backend',
+		model TEXT NOT NULL DEFAULT '',
+		base_url TEXT NOT NULL DEFAULT '',
+		temperature REAL,
+		max_tokens INTEGER NOT NULL DEFAULT 0,
+		weight REAL NOT NULL DEFAULT 1
+	);
+
+	CREATE TABLE IF NOT EXISTS judge_panels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS judge_panel_members (
+		panel_id INTEGER NOT NULL,
+		judge_id INTEGER NOT NULL,
+		PRIMARY KEY (panel_id, judge_id),
+		FOREIGN KEY (panel_id) REFERENCES judge_panels(id) ON DELETE CASCADE,
+		FOREIGN KEY (judge_id) REFERENCES judges(id) ON DELETE CASCADE
+	);
+
+	-- Profiles are rewritten on every edit, so overrides are keyed by profile name
+	CREATE TABLE IF NOT EXISTS profile_judge_panels (
+		suite_id INTEGER NOT NULL,
+		profile_name TEXT NOT NULL,
+		panel_name TEXT NOT NULL,
+		PRIMARY KEY (suite_id, profile_name),
+		FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS system_prompts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		description TEXT NOT NULL DEFAULT ''
+	);
+
+	-- Versions are never edited, so stored responses and results can point at the exact text used
+	CREATE TABLE IF NOT EXISTS system_prompt_versions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		system_prompt_id INTEGER NOT NULL,
+		version INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (system_prompt_id) REFERENCES system_prompts(id) ON DELETE CASCADE,
+		UNIQUE(system_prompt_id, version)
+	);
+
+	CREATE TABLE IF NOT EXISTS profile_system_prompts (
+		suite_id INTEGER NOT NULL,
+		profile_name TEXT NOT NULL,
+		system_prompt_name TEXT NOT NULL,
+		PRIMARY KEY (suite_id, profile_name),
+		FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE
+	);
+
+	-- Append-only: every change of a grid score with where it came from. The history
+	-- outlives the cell's model and prompt, whose names are kept with each change.
+	CREATE TABLE IF NOT EXISTS score_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		model_id INTEGER, -- NULL once the model is deleted
+		prompt_id INTEGER, -- NULL once the prompt is deleted
+		model_name TEXT NOT NULL DEFAULT '',
+		prompt_text TEXT NOT NULL DEFAULT '',
+		old_score INTEGER, -- NULL when the cell was unscored
+		new_score INTEGER, -- NULL when the change cleared the cell
+		source TEXT NOT NULL,
+		job_id INTEGER,
+		note TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE SET NULL,
+		FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE SET NULL,
+		FOREIGN KEY (job_id) REFERENCES evaluation_jobs(id) ON DELETE SET NULL
+	);
+
+	-- Named or automatic copies of a suite's rows as JSON, keyed by table
+	CREATE TABLE IF NOT EXISTS suite_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		suite_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		auto INTEGER NOT NULL DEFAULT 0,
+		prompt_count INTEGER NOT NULL DEFAULT 0,
+		model_count INTEGER NOT NULL DEFAULT 0,
+		score_count INTEGER NOT NULL DEFAULT 0,
+		response_count INTEGER NOT NULL DEFAULT 0,
+		data TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE
+	);
+
 	-- Create indexes
 	CREATE INDEX IF NOT EXISTS idx_settings_key ON settings(key);
 	CREATE INDEX IF NOT EXISTS idx_evaluation_jobs_status ON evaluation_jobs(status);
@@ -190,6 +441,14 @@ func createTables() error {
 	CREATE INDEX IF NOT EXISTS idx_evaluation_history_job ON evaluation_history(job_id);
 	CREATE INDEX IF NOT EXISTS idx_evaluation_history_lookup ON evaluation_history(model_id, prompt_id);
 	CREATE INDEX IF NOT EXISTS idx_cost_tracking_suite_date ON cost_tracking(suite_id, date);
+	CREATE INDEX IF NOT EXISTS idx_battles_suite ON battles(suite_id);
+	CREATE INDEX IF NOT EXISTS idx_evaluation_results_lookup ON evaluation_results(model_id, prompt_id);
+	CREATE INDEX IF NOT EXISTS idx_evaluation_results_job ON evaluation_results(job_id);
+	CREATE INDEX IF NOT EXISTS idx_evaluation_errors_job ON evaluation_errors(job_id);
+	CREATE INDEX IF NOT EXISTS idx_evaluation_jobs_created ON evaluation_jobs(created_at);
+	CREATE INDEX IF NOT EXISTS idx_score_history_cell ON score_history(model_id, prompt_id);
+	CREATE INDEX IF NOT EXISTS idx_score_history_job ON score_history(job_id);
+	CREATE INDEX IF NOT EXISTS idx_suite_snapshots_suite ON suite_snapshots(suite_id);
 
 	-- Add the default suite if it doesn't exist
 	INSERT OR IGNORE INTO suites (name, is_current) VALUES ('default', 1);
@@ -201,13 +460,134 @@ func createTables() error {
 		('api_key_google', ''),
 		('cost_alert_threshold_usd', '100.0'),
 		('auto_evaluate_new_models', 'false'),
-		('python_service_url', 'http://localhost:8001');
+		('python_service_url', 'http://localhost:8001'),
+		('judge_backend', 'python'),
+		('judge_openai_base_url', 'https://api.openai.com/v1'),
+		('judge_openai_model', 'gpt-5.2'),
+		('judge_anthropic_base_url', 'https://api.anthropic.com/v1'),
+		('judge_anthropic_model', 'claude-opus-4-5');
 	`
 
-	_, err := db.Exec(schema)
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+
+	if err := addMissingColumns(); err != nil {
+		return err
+	}
+	if err := dropUnscoredZeros(); err != nil {
+		return err
+	}
+	if err := seedJudgePanels(); err != nil {
+		return err
+	}
+
+	// Indexes on migrated columns can only be created once the columns exist
+	_, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_evaluation_history_cache ON evaluation_history(cache_key, cache_hit)`)
 	return err
 }
 
+// columnMigration adds a column to a table created by an older schema version
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// columnMigrations lists columns added after their table was first released
+var columnMigrations = []columnMigration{
+	{"model_configs", "provider", "TEXT NOT NULL DEFAULT 'openai'"},
+	{"model_configs", "top_p", "REAL"},
+	{"model_configs", "system_prompt", "TEXT NOT NULL DEFAULT ''"},
+	{"model_configs", "stop_sequences", "TEXT NOT NULL DEFAULT '[]'"},
+	{"evaluation_results", "calibration", "TEXT NOT NULL DEFAULT ''"},
+	{"prompts", "checker", "TEXT NOT NULL DEFAULT ''"},
+	{"prompts", "checker_config", "TEXT NOT NULL DEFAULT ''"},
+	{"evaluation_results", "checker", "TEXT NOT NULL DEFAULT ''"},
+	{"prompts", "language", "TEXT NOT NULL DEFAULT ''"},
+	{"prompts", "test_cases", "TEXT NOT NULL DEFAULT ''"},
+	{"evaluation_history", "test_results", "TEXT NOT NULL DEFAULT ''"},
+	{"evaluation_history", "cache_key", "TEXT NOT NULL DEFAULT ''"},
+	{"evaluation_history", "cache_hit", "INTEGER NOT NULL DEFAULT 0"},
+	{"evaluation_jobs", "force_refresh", "INTEGER NOT NULL DEFAULT 0"},
+	{"evaluation_jobs", "cache_hits", "INTEGER NOT NULL DEFAULT 0"},
+	{"evaluation_jobs", "cache_misses", "INTEGER NOT NULL DEFAULT 0"},
+	{"evaluation_jobs", "cache_saved_usd", "REAL NOT NULL DEFAULT 0"},
+	{"evaluation_jobs", "judge_panel", "TEXT NOT NULL DEFAULT ''"},
+	{"evaluation_results", "judge_panel", "TEXT NOT NULL DEFAULT ''"},
+	{"prompts", "rubric", "TEXT NOT NULL DEFAULT ''"},
+	{"profiles", "rubric", "TEXT NOT NULL DEFAULT ''"},
+	{"evaluation_history", "criteria", "TEXT NOT NULL DEFAULT ''"},
+	{"model_configs", "system_prompt_name", "TEXT NOT NULL DEFAULT ''"},
+	{"model_responses", "system_prompt_version_id", "INTEGER"},
+	{"evaluation_results", "system_prompt_version_id", "INTEGER"},
+}
+
+// addMissingColumns applies columnMigrations to databases created before the columns existed
+func addMissingColumns() error {
+	for _, m := range columnMigrations {
+		exists, err := columnExists(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+	}
+	return nil
+}
+
+// unscoredZerosDropped is the setting that records dropUnscoredZeros has run
+const unscoredZerosDropped = "unscored_zeros_dropped"
+
+// dropUnscoredZeros deletes, once per database, the zero scores older versions wrote
+// for cells nobody had scored: they showed a zero and a missing score alike, while
+// unscored cells now have no row. Zeros a judge or a hand grade produced are kept.
+func dropUnscoredZeros() error {
+	if done, err := GetSetting(unscoredZerosDropped); err != nil || done != "" {
+		return err
+	}
+	_, err := db.Exec(`
+		DELETE FROM scores
+		WHERE score = 0
+			AND NOT EXISTS (SELECT 1 FROM evaluation_results r WHERE r.model_id = scores.model_id AND r.prompt_id = scores.prompt_id)
+			AND NOT EXISTS (SELECT 1 FROM gold_scores g WHERE g.model_id = scores.model_id AND g.prompt_id = scores.prompt_id)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to drop unscored zeros: %w", err)
+	}
+	return SetSetting(unscoredZerosDropped, "true")
+}
+
+// columnExists reports whether a table has the named column
+func columnExists(table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name, kind string
+			notNull    int
+			dflt       sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &kind, &notNull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // GetSuiteID returns the ID of the specified suite or creates it if it doesn't exist
 func GetSuiteID(suiteName string) (int, error) {
 	if suiteName == "" {
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"database/sql"
 	"os"
 	"path/filepath"
@@ -647,6 +648,36 @@ func TestForeignKeyConstraints(t *testing.T) {
 	}
 }
 
+func TestForeignKeyConstraints_EveryConnection(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	// Holding connections makes the pool open new ones
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("failed to get connection %d: %v", i, err)
+		}
+		defer func() { _ = conn.Close() }()
+
+		var enabled int
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+			t.Fatalf("failed to read foreign_keys on connection %d: %v", i, err)
+		}
+		if enabled != 1 {
+			t.Errorf("expected foreign keys on connection %d", i)
+		}
+	}
+	if open := db.Stats().OpenConnections; open < 4 {
+		t.Errorf("expected at least 4 open connections, got %d", open)
+	}
+}
+
 func TestCascadeDelete(t *testing.T) {
 	dbPath, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -1,5 +1,7 @@
 package middleware
 
+import "time"
+
 // DataStore defines the interface for data persistence operations
 type DataStore interface {
 	// Suite operations
@@ -24,10 +26,45 @@ type DataStore interface {
 	// Results operations
 	ReadResults() map[string]Result
 	WriteResults(suiteName string, results map[string]Result) error
+	WriteResultsWithChange(suiteName string, results map[string]Result, change ScoreChange) error
+	SetScoreScale(suiteName string, from, to ScoreScale, convert bool) error
+
+	// Score history
+	ListScoreHistory(suiteName, modelName string, promptIndex int) ([]ScoreHistoryEntry, error)
+	ListJobScoreChanges(jobID int) ([]ScoreHistoryEntry, error)
+	RevertScoreChange(id int) error
+	RevertJobScores(jobID int) (ScoreRevert, error)
+
+	// Suite snapshots
+	CreateSuiteSnapshot(suiteName, name string, auto bool) (int, error)
+	ListSuiteSnapshots(suiteName string) ([]SuiteSnapshot, error)
+	CompareSuiteSnapshots(id, otherID int) (*SnapshotDiff, error)
+	RestoreSuiteSnapshot(id int) error
+	DeleteSuiteSnapshot(id int) error
+
+	// Model configuration operations
+	GetModelConfig(suiteName, modelName string) (*ModelConfig, error)
+	SaveModelConfig(suiteName, modelName string, cfg ModelConfig) error
+
+	// Battle operations
+	RecordBattle(suiteName string, b Battle) error
+	ListBattles(suiteName string) ([]Battle, error)
+	GetBattleCandidate(suiteName string) (*BattleCandidate, error)
+
+	// Judge analytics
+	ListJudgeScores(suiteName string) ([]JudgeScore, error)
+	ListCriterionScores(suiteName string) ([]CriterionScore, error)
+	SaveGoldScore(suiteName, modelName string, promptIndex, score int) error
+	ListCalibrationSamples(suiteName string) ([]CalibrationSample, error)
+
+	// Spend
+	GetCostSummary(suiteName string, since time.Time) (CostSummary, error)
 
 	// Settings operations
 	GetSetting(key string) (string, error)
 	SetSetting(key, value string) error
+	GetSuiteSetting(suiteName, key string) (string, error)
+	SetSuiteSetting(suiteName, key, value string) error
 	GetAPIKey(provider string) (string, error)
 	SetAPIKey(provider, key string) error
 	GetMaskedAPIKeys() (map[string]string, error)
@@ -114,6 +151,111 @@ func (s *SQLiteDataStore) WriteResults(suiteName string, results map[string]Resu
 	return WriteResults(suiteName, results)
 }
 
+// WriteResultsWithChange delegates to the package-level function
+func (s *SQLiteDataStore) WriteResultsWithChange(suiteName string, results map[string]Result, change ScoreChange) error {
+	return WriteResultsWithChange(suiteName, results, change)
+}
+
+// SetScoreScale delegates to the package-level function
+func (s *SQLiteDataStore) SetScoreScale(suiteName string, from, to ScoreScale, convert bool) error {
+	return SetSuiteScoreScale(suiteName, from, to, convert)
+}
+
+// ListScoreHistory delegates to the package-level function
+func (s *SQLiteDataStore) ListScoreHistory(suiteName, modelName string, promptIndex int) ([]ScoreHistoryEntry, error) {
+	return ListScoreHistory(suiteName, modelName, promptIndex)
+}
+
+// ListJobScoreChanges delegates to the package-level function
+func (s *SQLiteDataStore) ListJobScoreChanges(jobID int) ([]ScoreHistoryEntry, error) {
+	return ListJobScoreChanges(jobID)
+}
+
+// RevertScoreChange delegates to the package-level function
+func (s *SQLiteDataStore) RevertScoreChange(id int) error {
+	return RevertScoreChange(id)
+}
+
+// RevertJobScores delegates to the package-level function
+func (s *SQLiteDataStore) RevertJobScores(jobID int) (ScoreRevert, error) {
+	return RevertJobScores(jobID)
+}
+
+// CreateSuiteSnapshot delegates to the package-level function
+func (s *SQLiteDataStore) CreateSuiteSnapshot(suiteName, name string, auto bool) (int, error) {
+	return CreateSuiteSnapshot(suiteName, name, auto)
+}
+
+// ListSuiteSnapshots delegates to the package-level function
+func (s *SQLiteDataStore) ListSuiteSnapshots(suiteName string) ([]SuiteSnapshot, error) {
+	return ListSuiteSnapshots(suiteName)
+}
+
+// CompareSuiteSnapshots delegates to the package-level function
+func (s *SQLiteDataStore) CompareSuiteSnapshots(id, otherID int) (*SnapshotDiff, error) {
+	return CompareSuiteSnapshots(id, otherID)
+}
+
+// RestoreSuiteSnapshot delegates to the package-level function
+func (s *SQLiteDataStore) RestoreSuiteSnapshot(id int) error {
+	return RestoreSuiteSnapshot(id)
+}
+
+// DeleteSuiteSnapshot delegates to the package-level function
+func (s *SQLiteDataStore) DeleteSuiteSnapshot(id int) error {
+	return DeleteSuiteSnapshot(id)
+}
+
+// GetModelConfig delegates to the package-level function
+func (s *SQLiteDataStore) GetModelConfig(suiteName, modelName string) (*ModelConfig, error) {
+	return GetModelConfig(suiteName, modelName)
+}
+
+// SaveModelConfig delegates to the package-level function
+func (s *SQLiteDataStore) SaveModelConfig(suiteName, modelName string, cfg ModelConfig) error {
+	return SaveModelConfig(suiteName, modelName, cfg)
+}
+
+// RecordBattle delegates to the package-level function
+func (s *SQLiteDataStore) RecordBattle(suiteName string, b Battle) error {
+	return RecordBattle(suiteName, b)
+}
+
+// ListBattles delegates to the package-level function
+func (s *SQLiteDataStore) ListBattles(suiteName string) ([]Battle, error) {
+	return ListBattles(suiteName)
+}
+
+// GetBattleCandidate delegates to the package-level function
+func (s *SQLiteDataStore) GetBattleCandidate(suiteName string) (*BattleCandidate, error) {
+	return GetBattleCandidate(suiteName)
+}
+
+// ListJudgeScores delegates to the package-level function
+func (s *SQLiteDataStore) ListJudgeScores(suiteName string) ([]JudgeScore, error) {
+	return ListJudgeScores(suiteName)
+}
+
+// ListCriterionScores delegates to the package-level function
+func (s *SQLiteDataStore) ListCriterionScores(suiteName string) ([]CriterionScore, error) {
+	return ListCriterionScores(suiteName)
+}
+
+// SaveGoldScore delegates to the package-level function
+func (s *SQLiteDataStore) SaveGoldScore(suiteName, modelName string, promptIndex, score int) error {
+	return SaveGoldScore(suiteName, modelName, promptIndex, score)
+}
+
+// ListCalibrationSamples delegates to the package-level function
+func (s *SQLiteDataStore) ListCalibrationSamples(suiteName string) ([]CalibrationSample, error) {
+	return ListCalibrationSamples(suiteName)
+}
+
+// GetCostSummary delegates to the package-level function
+func (s *SQLiteDataStore) GetCostSummary(suiteName string, since time.Time) (CostSummary, error) {
+	return GetCostSummary(suiteName, since)
+}
+
 // GetSetting delegates to the package-level function
 func (s *SQLiteDataStore) GetSetting(key string) (string, error) {
 	return GetSetting(key)
@@ -124,6 +266,16 @@ func (s *SQLiteDataStore) SetSetting(key, value string) error {
 	return SetSetting(key, value)
 }
 
+// GetSuiteSetting delegates to the package-level function
+func (s *SQLiteDataStore) GetSuiteSetting(suiteName, key string) (string, error) {
+	return GetSuiteSetting(suiteName, key)
+}
+
+// SetSuiteSetting delegates to the package-level function
+func (s *SQLiteDataStore) SetSuiteSetting(suiteName, key, value string) error {
+	return SetSuiteSetting(suiteName, key, value)
+}
+
 // GetAPIKey delegates to the package-level function
 func (s *SQLiteDataStore) GetAPIKey(provider string) (string, error) {
 	return GetAPIKey(provider)
@@ -3,6 +3,7 @@ package middleware
 import (
 	"errors"
 	"testing"
+	"time"
 )
 
 func TestDefaultDataStore_IsSet(t *testing.T) {
@@ -34,27 +35,39 @@ func TestDefaultDataStore_CanBeSwapped(t *testing.T) {
 
 // MockDataStore implements DataStore for testing
 type MockDataStore struct {
-	GetCurrentSuiteIDFunc   func() (int, error)
-	GetCurrentSuiteNameFunc func() string
-	ListSuitesFunc          func() ([]string, error)
-	SetCurrentSuiteFunc     func(name string) error
-	SuiteExistsFunc         func(name string) bool
-	ReadPromptsFunc         func() []Prompt
-	WritePromptsFunc        func(prompts []Prompt) error
-	ReadPromptSuiteFunc     func(suiteName string) ([]Prompt, error)
-	WritePromptSuiteFunc    func(suiteName string, prompts []Prompt) error
-	ListPromptSuitesFunc    func() ([]string, error)
-	UpdatePromptsOrderFunc  func(order []int)
-	ReadProfilesFunc        func() []Profile
-	WriteProfilesFunc       func(profiles []Profile) error
-	ReadResultsFunc         func() map[string]Result
-	WriteResultsFunc        func(suiteName string, results map[string]Result) error
-	GetSettingFunc          func(key string) (string, error)
-	SetSettingFunc          func(key, value string) error
-	GetAPIKeyFunc           func(provider string) (string, error)
-	SetAPIKeyFunc           func(provider, key string) error
-	GetMaskedAPIKeysFunc    func() (map[string]string, error)
-	BroadcastResultsFunc    func()
+	GetCurrentSuiteIDFunc      func() (int, error)
+	GetCurrentSuiteNameFunc    func() string
+	ListSuitesFunc             func() ([]string, error)
+	SetCurrentSuiteFunc        func(name string) error
+	SuiteExistsFunc            func(name string) bool
+	ReadPromptsFunc            func() []Prompt
+	WritePromptsFunc           func(prompts []Prompt) error
+	ReadPromptSuiteFunc        func(suiteName string) ([]Prompt, error)
+	WritePromptSuiteFunc       func(suiteName string, prompts []Prompt) error
+	ListPromptSuitesFunc       func() ([]string, error)
+	UpdatePromptsOrderFunc     func(order []int)
+	ReadProfilesFunc           func() []Profile
+	WriteProfilesFunc          func(profiles []Profile) error
+	ReadResultsFunc            func() map[string]Result
+	WriteResultsFunc           func(suiteName string, results map[string]Result) error
+	GetSettingFunc             func(key string) (string, error)
+	SetSettingFunc             func(key, value string) error
+	GetSuiteSettingFunc        func(suiteName, key string) (string, error)
+	SetSuiteSettingFunc        func(suiteName, key, value string) error
+	GetAPIKeyFunc              func(provider string) (string, error)
+	SetAPIKeyFunc              func(provider, key string) error
+	GetMaskedAPIKeysFunc       func() (map[string]string, error)
+	GetModelConfigFunc         func(suiteName, modelName string) (*ModelConfig, error)
+	SaveModelConfigFunc        func(suiteName, modelName string, cfg ModelConfig) error
+	RecordBattleFunc           func(suiteName string, b Battle) error
+	ListBattlesFunc            func(suiteName string) ([]Battle, error)
+	GetBattleCandidateFunc     func(suiteName string) (*BattleCandidate, error)
+	ListJudgeScoresFunc        func(suiteName string) ([]JudgeScore, error)
+	ListCriterionScoresFunc    func(suiteName string) ([]CriterionScore, error)
+	SaveGoldScoreFunc          func(suiteName, modelName string, promptIndex, score int) error
+	ListCalibrationSamplesFunc func(suiteName string) ([]CalibrationSample, error)
+	GetCostSummaryFunc         func(suiteName string, since time.Time) (CostSummary, error)
+	BroadcastResultsFunc       func()
 
 	Err      error
 	Prompts  []Prompt
@@ -198,6 +211,50 @@ func (m *MockDataStore) WriteResults(suiteName string, results map[string]Result
 	return nil
 }
 
+func (m *MockDataStore) WriteResultsWithChange(suiteName string, results map[string]Result, change ScoreChange) error {
+	return m.Err
+}
+
+func (m *MockDataStore) SetScoreScale(suiteName string, from, to ScoreScale, convert bool) error {
+	return m.Err
+}
+
+func (m *MockDataStore) ListScoreHistory(suiteName, modelName string, promptIndex int) ([]ScoreHistoryEntry, error) {
+	return nil, m.Err
+}
+
+func (m *MockDataStore) ListJobScoreChanges(jobID int) ([]ScoreHistoryEntry, error) {
+	return nil, m.Err
+}
+
+func (m *MockDataStore) RevertScoreChange(id int) error {
+	return m.Err
+}
+
+func (m *MockDataStore) RevertJobScores(jobID int) (ScoreRevert, error) {
+	return ScoreRevert{}, m.Err
+}
+
+func (m *MockDataStore) CreateSuiteSnapshot(suiteName, name string, auto bool) (int, error) {
+	return 0, m.Err
+}
+
+func (m *MockDataStore) ListSuiteSnapshots(suiteName string) ([]SuiteSnapshot, error) {
+	return nil, m.Err
+}
+
+func (m *MockDataStore) CompareSuiteSnapshots(id, otherID int) (*SnapshotDiff, error) {
+	return nil, m.Err
+}
+
+func (m *MockDataStore) RestoreSuiteSnapshot(id int) error {
+	return m.Err
+}
+
+func (m *MockDataStore) DeleteSuiteSnapshot(id int) error {
+	return m.Err
+}
+
 func (m *MockDataStore) GetSetting(key string) (string, error) {
 	if m.GetSettingFunc != nil {
 		return m.GetSettingFunc(key)
@@ -225,6 +282,20 @@ func (m *MockDataStore) SetSetting(key, value string) error {
 	return nil
 }
 
+func (m *MockDataStore) GetSuiteSetting(suiteName, key string) (string, error) {
+	if m.GetSuiteSettingFunc != nil {
+		return m.GetSuiteSettingFunc(suiteName, key)
+	}
+	return "", m.Err
+}
+
+func (m *MockDataStore) SetSuiteSetting(suiteName, key, value string) error {
+	if m.SetSuiteSettingFunc != nil {
+		return m.SetSuiteSettingFunc(suiteName, key, value)
+	}
+	return m.Err
+}
+
 func (m *MockDataStore) GetAPIKey(provider string) (string, error) {
 	if m.GetAPIKeyFunc != nil {
 		return m.GetAPIKeyFunc(provider)
@@ -252,6 +323,76 @@ func (m *MockDataStore) GetMaskedAPIKeys() (map[string]string, error) {
 	return map[string]string{}, nil
 }
 
+func (m *MockDataStore) GetModelConfig(suiteName, modelName string) (*ModelConfig, error) {
+	if m.GetModelConfigFunc != nil {
+		return m.GetModelConfigFunc(suiteName, modelName)
+	}
+	return nil, m.Err
+}
+
+func (m *MockDataStore) SaveModelConfig(suiteName, modelName string, cfg ModelConfig) error {
+	if m.SaveModelConfigFunc != nil {
+		return m.SaveModelConfigFunc(suiteName, modelName, cfg)
+	}
+	return m.Err
+}
+
+func (m *MockDataStore) RecordBattle(suiteName string, b Battle) error {
+	if m.RecordBattleFunc != nil {
+		return m.RecordBattleFunc(suiteName, b)
+	}
+	return m.Err
+}
+
+func (m *MockDataStore) ListBattles(suiteName string) ([]Battle, error) {
+	if m.ListBattlesFunc != nil {
+		return m.ListBattlesFunc(suiteName)
+	}
+	return nil, m.Err
+}
+
+func (m *MockDataStore) GetBattleCandidate(suiteName string) (*BattleCandidate, error) {
+	if m.GetBattleCandidateFunc != nil {
+		return m.GetBattleCandidateFunc(suiteName)
+	}
+	return nil, m.Err
+}
+
+func (m *MockDataStore) ListJudgeScores(suiteName string) ([]JudgeScore, error) {
+	if m.ListJudgeScoresFunc != nil {
+		return m.ListJudgeScoresFunc(suiteName)
+	}
+	return nil, m.Err
+}
+
+func (m *MockDataStore) ListCriterionScores(suiteName string) ([]CriterionScore, error) {
+	if m.ListCriterionScoresFunc != nil {
+		return m.ListCriterionScoresFunc(suiteName)
+	}
+	return nil, m.Err
+}
+
+func (m *MockDataStore) SaveGoldScore(suiteName, modelName string, promptIndex, score int) error {
+	if m.SaveGoldScoreFunc != nil {
+		return m.SaveGoldScoreFunc(suiteName, modelName, promptIndex, score)
+	}
+	return m.Err
+}
+
+func (m *MockDataStore) ListCalibrationSamples(suiteName string) ([]CalibrationSample, error) {
+	if m.ListCalibrationSamplesFunc != nil {
+		return m.ListCalibrationSamplesFunc(suiteName)
+	}
+	return nil, m.Err
+}
+
+func (m *MockDataStore) GetCostSummary(suiteName string, since time.Time) (CostSummary, error) {
+	if m.GetCostSummaryFunc != nil {
+		return m.GetCostSummaryFunc(suiteName, since)
+	}
+	return CostSummary{}, m.Err
+}
+
 func (m *MockDataStore) BroadcastResults() {
 	if m.BroadcastResultsFunc != nil {
 		m.BroadcastResultsFunc()
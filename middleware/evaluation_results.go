@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// EvaluationResult records how an automated evaluation arrived at a stored score
+type EvaluationResult struct {
+	ID                    int       `json:"id"`
+	JobID                 int       `json:"job_id"`
+	ModelID               int       `json:"model_id"`
+	PromptID              int       `json:"prompt_id"`
+	Score                 int       `json:"score"`     // Score written to the results grid
+	RawScore              int       `json:"raw_score"` // Strategy output before snapping to a valid score
+	ConsensusStrategy     string    `json:"consensus_strategy"`
+	Calibration           string    `json:"calibration"` // Judge correction applied before consensus ("" = none)
+	JudgeCount            int       `json:"judge_count"`
+	Checker               string    `json:"checker"`                  // Checker that scored the pair instead of the judges; "code:<language>" for hidden tests
+	JudgePanel            string    `json:"judge_panel"`              // Panel that judged the pair ("" = the built-in judges)
+	SystemPromptVersionID int       `json:"system_prompt_version_id"` // Library system prompt version shown to the judges (0 = none)
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// GetLatestEvaluationResult returns the most recent automated result for a model/prompt pair,
+// or nil when the pair has never been evaluated
+func GetLatestEvaluationResult(modelID, promptID int) (*EvaluationResult, error) {
+	var r EvaluationResult
+	err := db.QueryRow(`
+		SELECT id, job_id, model_id, prompt_id, score, raw_score, consensus_strategy, calibration, judge_count, checker, judge_panel,
+			COALESCE(system_prompt_version_id, 0), created_at
+		FROM evaluation_results
+		WHERE model_id = ? AND prompt_id = ?
+		ORDER BY id DESC
+		LIMIT 1
+	`, modelID, promptID).Scan(&r.ID, &r.JobID, &r.ModelID, &r.PromptID, &r.Score, &r.RawScore, &r.ConsensusStrategy, &r.Calibration, &r.JudgeCount, &r.Checker, &r.JudgePanel, &r.SystemPromptVersionID, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query evaluation result: %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import "testing"
+
+func TestGetLatestEvaluationResult(t *testing.T) {
+	cleanup := setupBattleModels(t)
+	defer cleanup()
+
+	result, err := GetLatestEvaluationResult(1, 1)
+	if err != nil {
+		t.Fatalf("GetLatestEvaluationResult failed: %v", err)
+	}
+	if result != nil {
+		t.Fatalf("expected nil before any evaluation, got %+v", result)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all'), (2, 1, 'all');
+		INSERT INTO evaluation_results (job_id, model_id, prompt_id, score, raw_score, consensus_strategy, judge_count)
+		VALUES (1, 1, 1, 60, 64, 'weighted_mean', 3), (2, 1, 1, 40, 45, 'strict', 3);
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	result, err = GetLatestEvaluationResult(1, 1)
+	if err != nil {
+		t.Fatalf("GetLatestEvaluationResult failed: %v", err)
+	}
+	if result == nil || result.JobID != 2 || result.ConsensusStrategy != "strict" || result.RawScore != 45 || result.Score != 40 {
+		t.Errorf("unexpected latest result: %+v", result)
+	}
+
+	// Checker-scored pairs record the checker instead of a consensus strategy
+	_, err = db.Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (3, 1, 'model');
+		INSERT INTO evaluation_results (job_id, model_id, prompt_id, score, raw_score, consensus_strategy, checker)
+		VALUES (3, 1, 1, 100, 100, '', 'exact');
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed checker result: %v", err)
+	}
+	result, err = GetLatestEvaluationResult(1, 1)
+	if err != nil {
+		t.Fatalf("GetLatestEvaluationResult failed: %v", err)
+	}
+	if result == nil || result.Checker != "exact" || result.JudgeCount != 0 {
+		t.Errorf("expected checker result, got %+v", result)
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import "fmt"
+
+// JudgeScore is one judge's latest score for a model/prompt cell
+type JudgeScore struct {
+	Judge      string  `json:"judge"`
+	Score      int     `json:"score"`
+	Confidence float64 `json:"confidence"`
+	ModelID    int     `json:"model_id"`
+	Model      string  `json:"model"`
+	PromptID   int     `json:"prompt_id"`
+	Prompt     string  `json:"prompt"`
+	PromptType string  `json:"prompt_type"`
+	Profile    string  `json:"profile"` // "" for prompts without a profile
+}
+
+// ListJudgeScores returns each judge's most recent score for every evaluated cell in a suite.
+// Re-evaluations replace earlier scores so a cell never counts a judge twice.
+func ListJudgeScores(suiteName string) ([]JudgeScore, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT h.judge_name, COALESCE(h.judge_score, 0), COALESCE(h.judge_confidence, 0),
+			m.id, m.name, p.id, p.text, COALESCE(p.type, ''), COALESCE(pr.name, '')
+		FROM evaluation_history h
+		JOIN models m ON m.id = h.model_id
+		JOIN prompts p ON p.id = h.prompt_id
+		LEFT JOIN profiles pr ON pr.id = p.profile_id
+		WHERE p.suite_id = ? AND h.id IN (
+			SELECT MAX(id) FROM evaluation_history GROUP BY judge_name, model_id, prompt_id
+		)
+		ORDER BY p.display_order, p.id, m.name, h.judge_name
+	`, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query judge scores: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var scores []JudgeScore
+	for rows.Next() {
+		var s JudgeScore
+		if err := rows.Scan(&s.Judge, &s.Score, &s.Confidence, &s.ModelID, &s.Model, &s.PromptID, &s.Prompt, &s.PromptType, &s.Profile); err != nil {
+			return nil, fmt.Errorf("failed to scan judge score: %w", err)
+		}
+		scores = append(scores, s)
+	}
+
+	return scores, rows.Err()
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import "testing"
+
+func TestListJudgeScores_LatestPerJudge(t *testing.T) {
+	cleanup := setupBattleModels(t)
+	defer cleanup()
+
+	_, err := db.Exec(`
+		INSERT INTO profiles (id, name, suite_id) VALUES (1, 'Math', 1);
+		UPDATE prompts SET profile_id = 1, type = 'objective' WHERE id = 1;
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all'), (2, 1, 'all');
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score, judge_confidence)
+		VALUES (1, 1, 1, 'claude', 40, 0.5),
+		       (1, 1, 1, 'gpt', 60, 0.8),
+		       (2, 1, 1, 'claude', 80, 0.9);
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	scores, err := ListJudgeScores("default")
+	if err != nil {
+		t.Fatalf("ListJudgeScores failed: %v", err)
+	}
+	if len(scores) != 2 {
+		t.Fatalf("expected 2 scores (latest per judge), got %d: %+v", len(scores), scores)
+	}
+	if scores[0].Judge != "claude" || scores[0].Score != 80 || scores[0].Confidence != 0.9 {
+		t.Errorf("expected claude's re-evaluated score, got %+v", scores[0])
+	}
+	if scores[0].Model != "alpha" || scores[0].Profile != "Math" || scores[0].PromptType != "objective" || scores[0].Prompt != "Q" {
+		t.Errorf("unexpected cell details: %+v", scores[0])
+	}
+
+	other, err := ListJudgeScores("other")
+	if err != nil {
+		t.Fatalf("ListJudgeScores failed: %v", err)
+	}
+	if len(other) != 0 {
+		t.Errorf("expected no scores in another suite, got %d", len(other))
+	}
+}
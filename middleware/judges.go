@@ -0,0 +1,284 @@
+package middleware
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+)
+
+// Judge providers. Backend judges are passed by name to the configured judge backend
+// (the Python service, or the native clients judge_backend selects); openai,
+// anthropic and gemini judges are called directly with their own model and parameters.
+const (
+	JudgeProviderBackend   = "backend"
+	JudgeProviderOpenAI    = "openai"
+	JudgeProviderAnthropic = "anthropic"
+	JudgeProviderGemini    = "gemini"
+)
+
+// JudgeProviders lists the providers a judge can use
+var JudgeProviders = []string{JudgeProviderBackend, JudgeProviderOpenAI, JudgeProviderAnthropic, JudgeProviderGemini}
+
+// DefaultJudgePanel judges suites and profiles that have not been assigned a panel
+const DefaultJudgePanel = "default"
+
+// Judge is a named judge that panels can include
+type Judge struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"` // Recorded in evaluation_history
+	Provider    string   `json:"provider"`
+	Model       string   `json:"model"`       // Provider model identifier; unused by backend judges
+	BaseURL     string   `json:"base_url"`    // API root; "" uses the provider default
+	Temperature *float64 `json:"temperature"` // nil uses the provider default
+	MaxTokens   int      `json:"max_tokens"`
+	Weight      float64  `json:"weight"` // Relative say in the consensus score
+}
+
+// JudgePanel is a named set of judges
+type JudgePanel struct {
+	Name   string  `json:"name"`
+	Judges []Judge `json:"judges"`
+}
+
+// ListJudges returns every judge ordered by name
+func ListJudges() ([]Judge, error) {
+	rows, err := db.Query(`
+		SELECT id, name, provider, model, base_url, temperature, max_tokens, weight
+		FROM judges
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query judges: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var judges []Judge
+	for rows.Next() {
+		var j Judge
+		var temperature sql.NullFloat64
+		if err := rows.Scan(&j.ID, &j.Name, &j.Provider, &j.Model, &j.BaseURL, &temperature, &j.MaxTokens, &j.Weight); err != nil {
+			return nil, fmt.Errorf("failed to scan judge: %w", err)
+		}
+		if temperature.Valid {
+			j.Temperature = &temperature.Float64
+		}
+		judges = append(judges, j)
+	}
+	return judges, rows.Err()
+}
+
+// SaveJudge creates a judge or updates the one with the same name
+func SaveJudge(j Judge) error {
+	if j.Name == "" {
+		return fmt.Errorf("judge name is required")
+	}
+	if j.Provider == "" {
+		j.Provider = JudgeProviderBackend
+	}
+	if !slices.Contains(JudgeProviders, j.Provider) {
+		return fmt.Errorf("unknown judge provider: %s", j.Provider)
+	}
+	if j.Provider != JudgeProviderBackend && j.Model == "" {
+		return fmt.Errorf("judge %s needs a model for the %s provider", j.Name, j.Provider)
+	}
+	if j.Weight <= 0 {
+		return fmt.Errorf("judge weight must be positive")
+	}
+	if (j.Temperature != nil && *j.Temperature < 0) || j.MaxTokens < 0 {
+		return fmt.Errorf("temperature and max tokens cannot be negative")
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO judges (name, provider, model, base_url, temperature, max_tokens, weight)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			provider = excluded.provider,
+			model = excluded.model,
+			base_url = excluded.base_url,
+			temperature = excluded.temperature,
+			max_tokens = excluded.max_tokens,
+			weight = excluded.weight
+	`, j.Name, j.Provider, j.Model, j.BaseURL, j.Temperature, j.MaxTokens, j.Weight)
+	if err != nil {
+		return fmt.Errorf("failed to save judge: %w", err)
+	}
+	return nil
+}
+
+// DeleteJudge removes a judge from the library and from every panel
+func DeleteJudge(name string) error {
+	result, err := db.Exec("DELETE FROM judges WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete judge: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("judge %q not found", name)
+	}
+	return nil
+}
+
+// ListJudgePanels returns every panel with its judges, ordered by name
+func ListJudgePanels() ([]JudgePanel, error) {
+	rows, err := db.Query(`
+		SELECT p.name, j.id, j.name, j.provider, j.model, j.base_url, j.temperature, j.max_tokens, j.weight
+		FROM judge_panels p
+		LEFT JOIN judge_panel_members m ON m.panel_id = p.id
+		LEFT JOIN judges j ON j.id = m.judge_id
+		ORDER BY p.name, j.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query judge panels: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var panels []JudgePanel
+	for rows.Next() {
+		var name string
+		var id, maxTokens sql.NullInt64
+		var judgeName, provider, model, baseURL sql.NullString
+		var temperature, weight sql.NullFloat64
+		if err := rows.Scan(&name, &id, &judgeName, &provider, &model, &baseURL, &temperature, &maxTokens, &weight); err != nil {
+			return nil, fmt.Errorf("failed to scan judge panel: %w", err)
+		}
+		if len(panels) == 0 || panels[len(panels)-1].Name != name {
+			panels = append(panels, JudgePanel{Name: name})
+		}
+		if id.Valid {
+			panel := &panels[len(panels)-1]
+			judge := Judge{
+				ID: int(id.Int64), Name: judgeName.String, Provider: provider.String, Model: model.String,
+				BaseURL: baseURL.String, MaxTokens: int(maxTokens.Int64), Weight: weight.Float64,
+			}
+			if temperature.Valid {
+				judge.Temperature = &temperature.Float64
+			}
+			panel.Judges = append(panel.Judges, judge)
+		}
+	}
+	return panels, rows.Err()
+}
+
+// SaveJudgePanel creates a panel or replaces the judges of the one with the same name
+func SaveJudgePanel(name string, judgeNames []string) error {
+	if name == "" {
+		return fmt.Errorf("panel name is required")
+	}
+	if len(judgeNames) == 0 {
+		return fmt.Errorf("panel %s needs at least one judge", name)
+	}
+
+	tx, err := dbBegin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec("INSERT OR IGNORE INTO judge_panels (name) VALUES (?)", name); err != nil {
+		return fmt.Errorf("failed to save panel: %w", err)
+	}
+	var panelID int
+	if err = tx.QueryRow("SELECT id FROM judge_panels WHERE name = ?", name).Scan(&panelID); err != nil {
+		return fmt.Errorf("failed to get panel: %w", err)
+	}
+	if _, err = tx.Exec("DELETE FROM judge_panel_members WHERE panel_id = ?", panelID); err != nil {
+		return fmt.Errorf("failed to clear panel: %w", err)
+	}
+	for _, judge := range judgeNames {
+		var judgeID int
+		if err = tx.QueryRow("SELECT id FROM judges WHERE name = ?", judge).Scan(&judgeID); err != nil {
+			err = fmt.Errorf("unknown judge %q", judge)
+			return err
+		}
+		if _, err = tx.Exec("INSERT OR IGNORE INTO judge_panel_members (panel_id, judge_id) VALUES (?, ?)", panelID, judgeID); err != nil {
+			return fmt.Errorf("failed to add judge to panel: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteJudgePanel removes a panel. Suites and profiles assigned to it fall back to
+// the default panel.
+func DeleteJudgePanel(name string) error {
+	result, err := db.Exec("DELETE FROM judge_panels WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete panel: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("panel %q not found", name)
+	}
+	if _, err := db.Exec("DELETE FROM suite_settings WHERE key = ? AND value = ?", SuiteSettingJudgePanel, name); err != nil {
+		return fmt.Errorf("failed to clear suite panels: %w", err)
+	}
+	if _, err := db.Exec("DELETE FROM profile_judge_panels WHERE panel_name = ?", name); err != nil {
+		return fmt.Errorf("failed to clear profile panels: %w", err)
+	}
+	return nil
+}
+
+// GetProfileJudgePanels returns the panel overrides of a suite's profiles, keyed by profile name
+func GetProfileJudgePanels(suiteName string) (map[string]string, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	rows, err := db.Query("SELECT profile_name, panel_name FROM profile_judge_panels WHERE suite_id = ?", suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profile panels: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	panels := make(map[string]string)
+	for rows.Next() {
+		var profile, panel string
+		if err := rows.Scan(&profile, &panel); err != nil {
+			return nil, fmt.Errorf("failed to scan profile panel: %w", err)
+		}
+		panels[profile] = panel
+	}
+	return panels, rows.Err()
+}
+
+// SetProfileJudgePanel makes a panel judge the prompts of a profile; "" removes the override
+func SetProfileJudgePanel(suiteName, profile, panel string) error {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	if panel == "" {
+		_, err = db.Exec("DELETE FROM profile_judge_panels WHERE suite_id = ? AND profile_name = ?", suiteID, profile)
+	} else {
+		_, err = db.Exec(`
+			INSERT INTO profile_judge_panels (suite_id, profile_name, panel_name)
+			VALUES (?, ?, ?)
+			ON CONFLICT(suite_id, profile_name) DO UPDATE SET panel_name = excluded.panel_name
+		`, suiteID, profile, panel)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set profile panel: %w", err)
+	}
+	return nil
+}
+
+// seedJudgePanels adds the built-in judges and a default panel of them to a database
+// that has no judges yet
+func seedJudgePanels() error {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM judges").Scan(&count); err != nil || count > 0 {
+		return err
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO judges (name) VALUES ('claude_opus_4.5'), ('gpt_5.2'), ('gemini_3_pro');
+		INSERT OR IGNORE INTO judge_panels (name) VALUES (?);
+		INSERT OR IGNORE INTO judge_panel_members (panel_id, judge_id)
+		SELECT p.id, j.id FROM judge_panels p, judges j WHERE p.name = ?;
+	`, DefaultJudgePanel, DefaultJudgePanel)
+	return err
+}
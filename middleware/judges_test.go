@@ -0,0 +1,168 @@
+package middleware
+
+import "testing"
+
+func setupJudgesTest(t *testing.T) func() {
+	t.Helper()
+	dbPath, cleanup := setupTestDB(t)
+	if err := InitDB(dbPath); err != nil {
+		cleanup()
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	return cleanup
+}
+
+func TestSeedJudgePanels(t *testing.T) {
+	defer setupJudgesTest(t)()
+
+	panels, err := ListJudgePanels()
+	if err != nil {
+		t.Fatalf("ListJudgePanels failed: %v", err)
+	}
+	if len(panels) != 1 || panels[0].Name != DefaultJudgePanel || len(panels[0].Judges) != 3 {
+		t.Fatalf("expected a default panel of the three built-in judges, got %+v", panels)
+	}
+	for _, j := range panels[0].Judges {
+		if j.Provider != JudgeProviderBackend || j.Weight != 1 {
+			t.Errorf("expected an unweighted backend judge, got %+v", j)
+		}
+	}
+
+	// Seeding again leaves an edited library alone
+	if err := DeleteJudge("gpt_5.2"); err != nil {
+		t.Fatalf("DeleteJudge failed: %v", err)
+	}
+	if err := seedJudgePanels(); err != nil {
+		t.Fatalf("seedJudgePanels failed: %v", err)
+	}
+	judges, _ := ListJudges()
+	if len(judges) != 2 {
+		t.Errorf("expected the deleted judge to stay deleted, got %+v", judges)
+	}
+}
+
+func TestSaveJudge(t *testing.T) {
+	defer setupJudgesTest(t)()
+
+	negative, temperature := -1.0, 0.0
+	invalid := []Judge{
+		{Provider: JudgeProviderBackend, Weight: 1},
+		{Name: "x", Provider: "cohere", Weight: 1},
+		{Name: "x", Provider: JudgeProviderOpenAI, Weight: 1},
+		{Name: "x", Weight: 0},
+		{Name: "x", Weight: 1, Temperature: &negative},
+	}
+	for _, j := range invalid {
+		if err := SaveJudge(j); err == nil {
+			t.Errorf("expected %+v to be rejected", j)
+		}
+	}
+
+	judge := Judge{Name: "mini", Provider: JudgeProviderOpenAI, Model: "gpt-5-mini", Temperature: &temperature, MaxTokens: 512, Weight: 0.5}
+	if err := SaveJudge(judge); err != nil {
+		t.Fatalf("SaveJudge failed: %v", err)
+	}
+	judge.Weight = 2
+	if err := SaveJudge(judge); err != nil {
+		t.Fatalf("SaveJudge update failed: %v", err)
+	}
+
+	judges, err := ListJudges()
+	if err != nil {
+		t.Fatalf("ListJudges failed: %v", err)
+	}
+	var found []Judge
+	for _, j := range judges {
+		if j.Name == "mini" {
+			found = append(found, j)
+		}
+	}
+	if len(found) != 1 || found[0].Weight != 2 || found[0].Model != "gpt-5-mini" || found[0].MaxTokens != 512 {
+		t.Fatalf("expected one updated judge, got %+v", found)
+	}
+	// An explicit zero temperature is kept rather than read back as the provider default
+	if found[0].Temperature == nil || *found[0].Temperature != 0 {
+		t.Errorf("expected temperature 0, got %v", found[0].Temperature)
+	}
+}
+
+func TestSaveJudgePanel(t *testing.T) {
+	defer setupJudgesTest(t)()
+
+	if err := SaveJudgePanel("strict", nil); err == nil {
+		t.Error("expected a panel without judges to be rejected")
+	}
+	if err := SaveJudgePanel("strict", []string{"gpt_5.2", "nobody"}); err == nil {
+		t.Error("expected an unknown judge to be rejected")
+	}
+	if err := SaveJudgePanel("strict", []string{"gpt_5.2", "gemini_3_pro"}); err != nil {
+		t.Fatalf("SaveJudgePanel failed: %v", err)
+	}
+	// Saving again replaces the members
+	if err := SaveJudgePanel("strict", []string{"claude_opus_4.5"}); err != nil {
+		t.Fatalf("SaveJudgePanel update failed: %v", err)
+	}
+
+	panels, err := ListJudgePanels()
+	if err != nil {
+		t.Fatalf("ListJudgePanels failed: %v", err)
+	}
+	if len(panels) != 2 || panels[1].Name != "strict" || len(panels[1].Judges) != 1 || panels[1].Judges[0].Name != "claude_opus_4.5" {
+		t.Errorf("expected strict to hold only claude_opus_4.5, got %+v", panels)
+	}
+
+	// Deleting a judge removes it from its panels
+	if err := DeleteJudge("claude_opus_4.5"); err != nil {
+		t.Fatalf("DeleteJudge failed: %v", err)
+	}
+	panels, _ = ListJudgePanels()
+	if len(panels[1].Judges) != 0 || len(panels[0].Judges) != 2 {
+		t.Errorf("expected the judge gone from every panel, got %+v", panels)
+	}
+}
+
+func TestProfileJudgePanels(t *testing.T) {
+	defer setupJudgesTest(t)()
+
+	if err := SaveJudgePanel("strict", []string{"gpt_5.2"}); err != nil {
+		t.Fatalf("SaveJudgePanel failed: %v", err)
+	}
+	if err := SetProfileJudgePanel("default", "Math", "strict"); err != nil {
+		t.Fatalf("SetProfileJudgePanel failed: %v", err)
+	}
+	if err := SetSuiteSetting("default", SuiteSettingJudgePanel, "strict"); err != nil {
+		t.Fatalf("SetSuiteSetting failed: %v", err)
+	}
+
+	panels, err := GetProfileJudgePanels("default")
+	if err != nil {
+		t.Fatalf("GetProfileJudgePanels failed: %v", err)
+	}
+	if panels["Math"] != "strict" {
+		t.Errorf("expected Math to use strict, got %v", panels)
+	}
+
+	// Deleting the panel drops every assignment to it
+	if err := DeleteJudgePanel("strict"); err != nil {
+		t.Fatalf("DeleteJudgePanel failed: %v", err)
+	}
+	if panels, _ := GetProfileJudgePanels("default"); len(panels) != 0 {
+		t.Errorf("expected no profile overrides left, got %v", panels)
+	}
+	if value, _ := GetSuiteSetting("default", SuiteSettingJudgePanel); value != "" {
+		t.Errorf("expected the suite panel cleared, got %q", value)
+	}
+	if err := DeleteJudgePanel("strict"); err == nil {
+		t.Error("expected deleting a missing panel to fail")
+	}
+
+	if err := SetProfileJudgePanel("default", "Math", "default"); err != nil {
+		t.Fatalf("SetProfileJudgePanel failed: %v", err)
+	}
+	if err := SetProfileJudgePanel("default", "Math", ""); err != nil {
+		t.Fatalf("clearing the override failed: %v", err)
+	}
+	if panels, _ := GetProfileJudgePanels("default"); len(panels) != 0 {
+		t.Errorf("expected the override removed, got %v", panels)
+	}
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAPIKeyConflict rejects a config that sets a new API key and clears the stored one
+var ErrAPIKeyConflict = errors.New("enter a new API key or clear the stored one, not both")
+
+// ModelProviders lists the supported endpoint kinds for model configs
+var ModelProviders = []string{"openai", "anthropic"}
+
+// ModelConfig describes how to reproduce a model's responses: endpoint, sampling and credentials
+type ModelConfig struct {
+	ModelID          int      `json:"model_id"`
+	Provider         string   `json:"provider"` // 'openai' (any OpenAI-compatible server) or 'anthropic'
+	BaseURL          string   `json:"base_url"`
+	ModelName        string   `json:"model_name"`
+	APIKey           string   `json:"api_key,omitempty"` // Plaintext on input only; never returned
+	HasAPIKey        bool     `json:"has_api_key"`
+	ClearAPIKey      bool     `json:"clear_api_key,omitempty"` // On input: remove the stored key; not allowed with a new APIKey
+	Temperature      *float64 `json:"temperature"`
+	TopP             *float64 `json:"top_p"`
+	MaxTokens        int      `json:"max_tokens"`
+	SystemPrompt     string   `json:"system_prompt"`
+	SystemPromptName string   `json:"system_prompt_name"` // Library system prompt used instead of SystemPrompt ("" = none)
+	StopSequences    []string `json:"stop_sequences"`
+
+	encryptedKey string // Stored credential, carried over when a config is copied to another model
+}
+
+// GetModelID returns the ID of a model in a suite
+func GetModelID(suiteName, modelName string) (int, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	var modelID int
+	err = db.QueryRow("SELECT id FROM models WHERE name = ? AND suite_id = ?", modelName, suiteID).Scan(&modelID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get model %q: %w", modelName, err)
+	}
+	return modelID, nil
+}
+
+// GetModelConfig retrieves the configuration of a model in a suite (nil when none is stored)
+func GetModelConfig(suiteName, modelName string) (*ModelConfig, error) {
+	modelID, err := GetModelID(suiteName, modelName)
+	if err != nil {
+		return nil, err
+	}
+	return GetModelConfigByID(modelID)
+}
+
+// SaveModelConfig creates or updates the configuration of a model in a suite
+func SaveModelConfig(suiteName, modelName string, cfg ModelConfig) error {
+	modelID, err := GetModelID(suiteName, modelName)
+	if err != nil {
+		return err
+	}
+	cfg.ModelID = modelID
+	return SaveModelConfigByID(cfg)
+}
+
+// GetModelConfigByID retrieves a model's configuration (nil when none is stored).
+// The API key is not decrypted; HasAPIKey reports whether one is set.
+func GetModelConfigByID(modelID int) (*ModelConfig, error) {
+	cfg := &ModelConfig{ModelID: modelID}
+	var temperature, topP sql.NullFloat64
+	var stopSequences string
+
+	err := db.QueryRow(`
+		SELECT provider, base_url, model_name, api_key, temperature, top_p, max_tokens, system_prompt, system_prompt_name, stop_sequences
+		FROM model_configs
+		WHERE model_id = ?
+	`, modelID).Scan(&cfg.Provider, &cfg.BaseURL, &cfg.ModelName, &cfg.encryptedKey, &temperature, &topP,
+		&cfg.MaxTokens, &cfg.SystemPrompt, &cfg.SystemPromptName, &stopSequences)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get model config: %w", err)
+	}
+
+	cfg.HasAPIKey = cfg.encryptedKey != ""
+	if temperature.Valid {
+		cfg.Temperature = &temperature.Float64
+	}
+	if topP.Valid {
+		cfg.TopP = &topP.Float64
+	}
+	if stopSequences != "" {
+		if err := json.Unmarshal([]byte(stopSequences), &cfg.StopSequences); err != nil {
+			return nil, fmt.Errorf("failed to decode stop sequences: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// SaveModelConfigByID creates or updates a model's configuration.
+// A non-empty APIKey is encrypted; an empty one keeps the stored (or copied) credential
+// unless ClearAPIKey asks for it to be removed. Setting both is ErrAPIKeyConflict.
+func SaveModelConfigByID(cfg ModelConfig) error {
+	if cfg.Provider == "" {
+		cfg.Provider = "openai"
+	}
+	if !isModelProvider(cfg.Provider) {
+		return fmt.Errorf("unknown model provider: %s", cfg.Provider)
+	}
+
+	if cfg.ClearAPIKey && cfg.APIKey != "" {
+		return ErrAPIKeyConflict
+	}
+
+	encrypted := cfg.encryptedKey
+	if cfg.ClearAPIKey {
+		encrypted = ""
+	} else if cfg.APIKey != "" {
+		var err error
+		encrypted, err = EncryptAPIKey(cfg.APIKey)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt API key: %w", err)
+		}
+	}
+
+	if cfg.StopSequences == nil {
+		cfg.StopSequences = []string{}
+	}
+	stopSequences, _ := json.Marshal(cfg.StopSequences)
+
+	_, err := db.Exec(`
+		INSERT INTO model_configs (model_id, provider, base_url, model_name, api_key, temperature, top_p,
+			max_tokens, system_prompt, system_prompt_name, stop_sequences, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(model_id) DO UPDATE SET
+			provider = excluded.provider,
+			base_url = excluded.base_url,
+			model_name = excluded.model_name,
+			api_key = CASE WHEN excluded.api_key = '' AND NOT ? THEN model_configs.api_key ELSE excluded.api_key END,
+			temperature = excluded.temperature,
+			top_p = excluded.top_p,
+			max_tokens = excluded.max_tokens,
+			system_prompt = excluded.system_prompt,
+			system_prompt_name = excluded.system_prompt_name,
+			stop_sequences = excluded.stop_sequences,
+			updated_at = excluded.updated_at
+	`, cfg.ModelID, cfg.Provider, cfg.BaseURL, cfg.ModelName, encrypted, cfg.Temperature, cfg.TopP,
+		cfg.MaxTokens, cfg.SystemPrompt, cfg.SystemPromptName, string(stopSequences), time.Now(), cfg.ClearAPIKey)
+	if err != nil {
+		return fmt.Errorf("failed to save model config: %w", err)
+	}
+
+	return nil
+}
+
+// isModelProvider reports whether provider is a supported endpoint kind
+func isModelProvider(provider string) bool {
+	for _, p := range ModelProviders {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
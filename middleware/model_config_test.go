@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestModelConfig_SaveAndGet(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+	t.Setenv("ENCRYPTION_KEY", "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	res, err := db.Exec("INSERT INTO models (name, suite_id) VALUES ('m1', 1)")
+	if err != nil {
+		t.Fatalf("failed to insert model: %v", err)
+	}
+	modelID, _ := res.LastInsertId()
+
+	cfg, err := GetModelConfigByID(int(modelID))
+	if err != nil || cfg != nil {
+		t.Fatalf("expected no config, got %+v, %v", cfg, err)
+	}
+
+	temperature := 0.2
+	err = SaveModelConfigByID(ModelConfig{
+		ModelID:     int(modelID),
+		BaseURL:     "http://localhost:8081/v1",
+		ModelName:   "qwen",
+		APIKey:      "secret",
+		Temperature: &temperature,
+		MaxTokens:   512,
+	})
+	if err != nil {
+		t.Fatalf("SaveModelConfigByID failed: %v", err)
+	}
+
+	// Saving again without a key keeps the stored credential
+	err = SaveModelConfigByID(ModelConfig{ModelID: int(modelID), BaseURL: "http://localhost:9000/v1", ModelName: "qwen"})
+	if err != nil {
+		t.Fatalf("SaveModelConfigByID update failed: %v", err)
+	}
+
+	cfg, err = GetModelConfigByID(int(modelID))
+	if err != nil {
+		t.Fatalf("GetModelConfigByID failed: %v", err)
+	}
+	if cfg.BaseURL != "http://localhost:9000/v1" || cfg.ModelName != "qwen" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if !cfg.HasAPIKey || cfg.APIKey != "" {
+		t.Errorf("expected stored but hidden API key, got %+v", cfg)
+	}
+	if cfg.Temperature != nil {
+		t.Errorf("expected temperature to be cleared, got %v", *cfg.Temperature)
+	}
+
+	var stored string
+	if err := db.QueryRow("SELECT api_key FROM model_configs WHERE model_id = ?", modelID).Scan(&stored); err != nil {
+		t.Fatalf("failed to read stored key: %v", err)
+	}
+	if stored == "secret" {
+		t.Error("API key should be stored encrypted")
+	}
+	if plain, err := DecryptAPIKey(stored); err != nil || plain != "secret" {
+		t.Errorf("expected stored key to decrypt to 'secret', got %q, %v", plain, err)
+	}
+
+	// A new key and a clear together are ambiguous and change nothing
+	err = SaveModelConfigByID(ModelConfig{ModelID: int(modelID), BaseURL: "http://localhost:9000/v1", ModelName: "qwen", APIKey: "other", ClearAPIKey: true})
+	if !errors.Is(err, ErrAPIKeyConflict) {
+		t.Fatalf("expected ErrAPIKeyConflict, got %v", err)
+	}
+	if cfg, err = GetModelConfigByID(int(modelID)); err != nil || !cfg.HasAPIKey {
+		t.Errorf("expected the stored key kept, got %+v, %v", cfg, err)
+	}
+
+	// Clearing removes the stored credential
+	err = SaveModelConfigByID(ModelConfig{ModelID: int(modelID), BaseURL: "http://localhost:9000/v1", ModelName: "qwen", ClearAPIKey: true})
+	if err != nil {
+		t.Fatalf("SaveModelConfigByID clear failed: %v", err)
+	}
+	if cfg, err = GetModelConfigByID(int(modelID)); err != nil || cfg.HasAPIKey {
+		t.Errorf("expected the API key removed, got %+v, %v", cfg, err)
+	}
+}
+
+func TestSaveModelConfig_EncryptionError(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+	t.Setenv("ENCRYPTION_KEY", "")
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	if err := SaveModelConfigByID(ModelConfig{ModelID: 1, APIKey: "secret"}); err == nil {
+		t.Fatal("expected error without ENCRYPTION_KEY")
+	}
+}
+
+func TestModelConfig_ByName(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO models (name, suite_id) VALUES ('m1', 1)"); err != nil {
+		t.Fatalf("failed to insert model: %v", err)
+	}
+
+	topP := 0.95
+	err := SaveModelConfig("default", "m1", ModelConfig{
+		Provider:      "anthropic",
+		BaseURL:       "https://api.anthropic.com/v1",
+		ModelName:     "claude-test",
+		TopP:          &topP,
+		SystemPrompt:  "Be concise.",
+		StopSequences: []string{"###", "END"},
+	})
+	if err != nil {
+		t.Fatalf("SaveModelConfig failed: %v", err)
+	}
+
+	cfg, err := GetModelConfig("default", "m1")
+	if err != nil {
+		t.Fatalf("GetModelConfig failed: %v", err)
+	}
+	if cfg.Provider != "anthropic" || cfg.SystemPrompt != "Be concise." {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.TopP == nil || *cfg.TopP != 0.95 {
+		t.Errorf("expected top_p 0.95, got %v", cfg.TopP)
+	}
+	if len(cfg.StopSequences) != 2 || cfg.StopSequences[1] != "END" {
+		t.Errorf("unexpected stop sequences: %v", cfg.StopSequences)
+	}
+
+	if _, err := GetModelConfig("default", "missing"); err == nil {
+		t.Error("expected error for unknown model")
+	}
+	if err := SaveModelConfig("default", "m1", ModelConfig{Provider: "bogus"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+func TestModelConfig_CopyKeepsCredential(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+	t.Setenv("ENCRYPTION_KEY", "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO models (name, suite_id) VALUES ('base', 1), ('variant', 1)"); err != nil {
+		t.Fatalf("failed to insert models: %v", err)
+	}
+	if err := SaveModelConfig("default", "base", ModelConfig{BaseURL: "http://x/v1", APIKey: "secret"}); err != nil {
+		t.Fatalf("SaveModelConfig failed: %v", err)
+	}
+
+	cfg, err := GetModelConfig("default", "base")
+	if err != nil {
+		t.Fatalf("GetModelConfig failed: %v", err)
+	}
+	if err := SaveModelConfig("default", "variant", *cfg); err != nil {
+		t.Fatalf("SaveModelConfig for variant failed: %v", err)
+	}
+
+	variant, err := GetModelConfig("default", "variant")
+	if err != nil {
+		t.Fatalf("GetModelConfig failed: %v", err)
+	}
+	if !variant.HasAPIKey || variant.BaseURL != "http://x/v1" {
+		t.Errorf("expected copied config with credential, got %+v", variant)
+	}
+}
+
+func TestAddMissingColumns_UpgradesLegacyModelConfigs(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	legacy, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	_, err = legacy.Exec(`CREATE TABLE model_configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		model_id INTEGER NOT NULL UNIQUE,
+		base_url TEXT NOT NULL DEFAULT '',
+		model_name TEXT NOT NULL DEFAULT '',
+		api_key TEXT NOT NULL DEFAULT '',
+		temperature REAL,
+		max_tokens INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_ = legacy.Close()
+	if err != nil {
+		t.Fatalf("failed to create legacy table: %v", err)
+	}
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	for _, column := range []string{"provider", "top_p", "system_prompt", "stop_sequences"} {
+		exists, err := columnExists("model_configs", column)
+		if err != nil {
+			t.Fatalf("columnExists failed: %v", err)
+		}
+		if !exists {
+			t.Errorf("expected column %s to be added", column)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RubricCriterion is one named aspect of a response that judges score separately,
+// such as correctness, clarity or format
+type RubricCriterion struct {
+	Name       string  `json:"name"`
+	Weight     float64 `json:"weight"`
+	Descriptor string  `json:"descriptor,omitempty"` // What the judges should look for
+}
+
+// ValidateRubric checks that every criterion has a unique name and a positive weight
+func ValidateRubric(rubric []RubricCriterion) error {
+	seen := make(map[string]bool, len(rubric))
+	for _, c := range rubric {
+		name := strings.TrimSpace(c.Name)
+		if name == "" {
+			return fmt.Errorf("rubric criteria need a name")
+		}
+		if seen[name] {
+			return fmt.Errorf("rubric criterion %q is listed twice", name)
+		}
+		seen[name] = true
+		if c.Weight <= 0 {
+			return fmt.Errorf("rubric criterion %q needs a positive weight", name)
+		}
+	}
+	return nil
+}
+
+// ParseRubric decodes and validates a rubric stored or submitted as JSON; "" is no rubric
+func ParseRubric(data string) ([]RubricCriterion, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+	var rubric []RubricCriterion
+	if err := json.Unmarshal([]byte(data), &rubric); err != nil {
+		return nil, fmt.Errorf("invalid rubric JSON: %w", err)
+	}
+	for i := range rubric {
+		rubric[i].Name = strings.TrimSpace(rubric[i].Name)
+	}
+	if err := ValidateRubric(rubric); err != nil {
+		return nil, err
+	}
+	return rubric, nil
+}
+
+// encodeRubric stores a rubric as JSON; an empty rubric is stored as ""
+func encodeRubric(rubric []RubricCriterion) (string, error) {
+	if len(rubric) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(rubric)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode rubric: %w", err)
+	}
+	return string(data), nil
+}
+
+// CriterionScore is the judges' consensus on one rubric criterion of a model/prompt cell
+type CriterionScore struct {
+	ModelID   int     `json:"model_id"`
+	Model     string  `json:"model"`
+	PromptID  int     `json:"prompt_id"`
+	Prompt    string  `json:"prompt"`
+	Criterion string  `json:"criterion"`
+	Score     int     `json:"score"`
+	Weight    float64 `json:"weight"`
+}
+
+// ListCriterionScores returns the per-criterion scores of every evaluated cell in a
+// suite, in prompt order
+func ListCriterionScores(suiteName string) ([]CriterionScore, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT m.id, m.name, p.id, p.text, c.criterion, c.score, c.weight
+		FROM criterion_scores c
+		JOIN models m ON m.id = c.model_id
+		JOIN prompts p ON p.id = c.prompt_id
+		WHERE p.suite_id = ?
+		ORDER BY p.display_order, p.id, m.name, c.id
+	`, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query criterion scores: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var scores []CriterionScore
+	for rows.Next() {
+		var s CriterionScore
+		if err := rows.Scan(&s.ModelID, &s.Model, &s.PromptID, &s.Prompt, &s.Criterion, &s.Score, &s.Weight); err != nil {
+			return nil, fmt.Errorf("failed to scan criterion score: %w", err)
+		}
+		scores = append(scores, s)
+	}
+
+	return scores, rows.Err()
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRubric(t *testing.T) {
+	invalid := map[string]string{
+		"not json":        "invalid rubric JSON",
+		`[{"weight": 1}]`: "need a name",
+		`[{"name": "a", "weight": 1}, {"name": " a", "weight": 2}]`: "listed twice",
+		`[{"name": "a"}]`: "positive weight",
+	}
+	for data, want := range invalid {
+		if _, err := ParseRubric(data); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error containing %q, got %v", data, want, err)
+		}
+	}
+
+	if rubric, err := ParseRubric("  "); err != nil || rubric != nil {
+		t.Errorf("expected a blank rubric to be no rubric, got %+v (%v)", rubric, err)
+	}
+	rubric, err := ParseRubric(`[{"name": " clarity ", "weight": 0.5, "descriptor": "Easy to follow"}]`)
+	if err != nil || len(rubric) != 1 || rubric[0].Name != "clarity" || rubric[0].Descriptor != "Easy to follow" {
+		t.Errorf("expected a trimmed criterion, got %+v (%v)", rubric, err)
+	}
+}
+
+func TestRubricsRoundTrip(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	profileRubric := []RubricCriterion{{Name: "correctness", Weight: 3, Descriptor: "Matches the solution"}, {Name: "clarity", Weight: 1}}
+	if err := WriteProfileSuite("default", []Profile{{Name: "Math", Rubric: profileRubric}, {Name: "Poetry"}}); err != nil {
+		t.Fatalf("WriteProfileSuite failed: %v", err)
+	}
+	promptRubric := []RubricCriterion{{Name: "format", Weight: 1}}
+	if err := WritePromptSuite("default", []Prompt{{Text: "p1", Profile: "Math"}, {Text: "p2", Rubric: promptRubric}}); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+
+	profiles, err := ReadProfileSuite("default")
+	if err != nil {
+		t.Fatalf("ReadProfileSuite failed: %v", err)
+	}
+	if len(profiles[0].Rubric) != 2 || profiles[0].Rubric[0] != profileRubric[0] || profiles[1].Rubric != nil {
+		t.Errorf("expected only Math to have a rubric, got %+v", profiles)
+	}
+	prompts, err := ReadPromptSuite("default")
+	if err != nil {
+		t.Fatalf("ReadPromptSuite failed: %v", err)
+	}
+	if prompts[0].Rubric != nil || len(prompts[1].Rubric) != 1 || prompts[1].Rubric[0].Name != "format" {
+		t.Errorf("expected only p2 to have its own rubric, got %+v", prompts)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO models (name, suite_id) VALUES ('m1', 1);
+		INSERT INTO criterion_scores (model_id, prompt_id, criterion, score, weight)
+		SELECT m.id, p.id, 'correctness', 80, 3 FROM models m, prompts p WHERE p.text = 'p1';
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed criterion scores: %v", err)
+	}
+	scores, err := ListCriterionScores("default")
+	if err != nil {
+		t.Fatalf("ListCriterionScores failed: %v", err)
+	}
+	if len(scores) != 1 || scores[0].Model != "m1" || scores[0].Prompt != "p1" || scores[0].Score != 80 || scores[0].Weight != 3 {
+		t.Errorf("unexpected criterion scores: %+v", scores)
+	}
+}
@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"database/sql"
+	"fmt"
+	"llm-tournament/templates"
+	"log"
+	"math"
+	"slices"
+)
+
+// Scoring scales a suite can grade on
+const (
+	ScoreScaleFifths     = "fifths"
+	ScoreScaleBinary     = "binary"
+	ScoreScaleLikert     = "likert"
+	ScoreScaleTen        = "ten"
+	ScoreScaleContinuous = "continuous"
+)
+
+// DefaultScoreScale is used by suites that have not picked a scale
+const DefaultScoreScale = ScoreScaleFifths
+
+// ScoreScale is the range a suite's grid scores are given on, stored in the scale's own
+// units. Judges, checkers and gold labels stay on 0-100: a score's position between the
+// scale's minimum and maximum is its percentage.
+type ScoreScale struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Min   int    `json:"min"`
+	Max   int    `json:"max"`
+	Step  int    `json:"step"`
+}
+
+var scoreScales = []ScoreScale{
+	{Name: ScoreScaleFifths, Label: "0–100 in steps of 20", Min: 0, Max: 100, Step: 20},
+	{Name: ScoreScaleBinary, Label: "Pass/fail", Min: 0, Max: 1, Step: 1},
+	{Name: ScoreScaleLikert, Label: "1–5 Likert", Min: 1, Max: 5, Step: 1},
+	{Name: ScoreScaleTen, Label: "1–10", Min: 1, Max: 10, Step: 1},
+	{Name: ScoreScaleContinuous, Label: "Continuous 0–100", Min: 0, Max: 100, Step: 1},
+}
+
+// UnscoredColor is the grid colour of a cell without a score
+const UnscoredColor = "#808080"
+
+// scoreColors are the grid colours of each fifth of a scale
+var scoreColors = []string{"#ffa500", "#ffd700", "#00bfff", "#a77bff", "#7cff6b"}
+
+// ScoreScales lists the available scales, the default first
+func ScoreScales() []ScoreScale {
+	return slices.Clone(scoreScales)
+}
+
+// GetScoreScale looks a scale up by name; "" is the default scale
+func GetScoreScale(name string) (ScoreScale, error) {
+	if name == "" {
+		name = DefaultScoreScale
+	}
+	for _, s := range scoreScales {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return ScoreScale{}, fmt.Errorf("unknown score scale: %s", name)
+}
+
+// ResolveScoreScale returns the named scale, or the default when the name is no
+// longer known
+func ResolveScoreScale(name string) ScoreScale {
+	scale, err := GetScoreScale(name)
+	if err != nil {
+		log.Printf("%v, using %s", err, DefaultScoreScale)
+		scale, _ = GetScoreScale(DefaultScoreScale)
+	}
+	return scale
+}
+
+// SuiteScoreScale returns the scale a suite grades on
+func SuiteScoreScale(suiteName string) ScoreScale {
+	name, err := GetSuiteSetting(suiteName, SuiteSettingScoreScale)
+	if err != nil {
+		log.Printf("Failed to read score scale of suite %s: %v", suiteName, err)
+	}
+	return ResolveScoreScale(name)
+}
+
+// Points lists every score on the scale in ascending order
+func (s ScoreScale) Points() []int {
+	points := make([]int, 0, (s.Max-s.Min)/s.Step+1)
+	for p := s.Min; p <= s.Max; p += s.Step {
+		points = append(points, p)
+	}
+	return points
+}
+
+// Continuous reports whether the scale has too many points to offer as buttons
+func (s ScoreScale) Continuous() bool {
+	return (s.Max-s.Min)/s.Step > 10
+}
+
+// Snap clamps score to the scale and rounds it to the nearest point, ties going down
+func (s ScoreScale) Snap(score int) int {
+	score = max(s.Min, min(s.Max, score))
+	offset := score - s.Min
+	steps := offset / s.Step
+	if 2*(offset%s.Step) > s.Step {
+		steps++
+	}
+	return s.Min + steps*s.Step
+}
+
+// FromPercent maps a 0-100 score, such as a judge's, onto the scale, 0 being its
+// minimum and 100 its maximum
+func (s ScoreScale) FromPercent(percent float64) int {
+	return s.Snap(s.Min + int(math.Round(percent*float64(s.Max-s.Min)/100)))
+}
+
+// Percent maps a score on the scale onto 0-100, its minimum being 0 and its maximum 100
+func (s ScoreScale) Percent(score int) float64 {
+	return math.Max(0, math.Min(100, float64(score-s.Min)*100/float64(s.Max-s.Min)))
+}
+
+// MinTotal is the lowest total a model can reach over promptCount prompts
+func (s ScoreScale) MinTotal(promptCount int) int {
+	return promptCount * s.Min
+}
+
+// MaxTotal is the highest total a model can reach over promptCount prompts
+func (s ScoreScale) MaxTotal(promptCount int) int {
+	return promptCount * s.Max
+}
+
+// PointLabel names a score on the scale
+func (s ScoreScale) PointLabel(score int) string {
+	switch s.Name {
+	case ScoreScaleFifths:
+		for label, value := range templates.ScoreOptions {
+			if value == score {
+				return label
+			}
+		}
+	case ScoreScaleBinary:
+		if score > 0 {
+			return "Pass"
+		}
+		return "Fail"
+	}
+	return fmt.Sprintf("%d/%d", score, s.Max)
+}
+
+// Color is the grid colour of a score, one colour per fifth of the scale. The lowest
+// score shares the first fifth's colour; UnscoredColor marks cells without a score.
+func (s ScoreScale) Color(score int) string {
+	fifth := int(math.Ceil(s.Percent(score) / 20))
+	return scoreColors[max(1, min(5, fifth))-1]
+}
+
+// ScoreOption is a score offered when grading a cell by hand
+type ScoreOption struct {
+	Label string
+	Value int
+	Color string
+}
+
+// Options lists the scores to pick from when grading by hand, or nil for a continuous
+// scale, which takes a typed score instead
+func (s ScoreScale) Options() []ScoreOption {
+	if s.Continuous() {
+		return nil
+	}
+	points := s.Points()
+	options := make([]ScoreOption, len(points))
+	for i, p := range points {
+		options[i] = ScoreOption{Label: s.PointLabel(p), Value: p, Color: s.Color(p)}
+	}
+	return options
+}
+
+// ScoreBucket groups the scores from Low to High in the stats histogram
+type ScoreBucket struct {
+	Label string `json:"label"`
+	Low   int    `json:"low"`
+	High  int    `json:"high"`
+	Color string `json:"color"`
+}
+
+// Buckets splits the scale's scores for histograms: one bucket per point, or tenths of
+// a continuous scale, the first of which also holds its minimum
+func (s ScoreScale) Buckets() []ScoreBucket {
+	var buckets []ScoreBucket
+	if s.Continuous() {
+		width := (s.Max - s.Min) / 10
+		for high := s.Min + width; high <= s.Max; high += width {
+			low := high - width + 1
+			if len(buckets) == 0 {
+				low = s.Min
+			}
+			buckets = append(buckets, ScoreBucket{Label: fmt.Sprintf("%d–%d", low, high), Low: low, High: high, Color: s.Color(high)})
+		}
+		return buckets
+	}
+	for _, p := range s.Points() {
+		buckets = append(buckets, ScoreBucket{Label: s.PointLabel(p), Low: p, High: p, Color: s.Color(p)})
+	}
+	return buckets
+}
+
+// ConvertScore maps a score from one scale onto another by its percentage
+func ConvertScore(score int, from, to ScoreScale) int {
+	return to.FromPercent(from.Percent(score))
+}
+
+// SetSuiteScoreScale moves a suite from one scale onto another. With convert its grid
+// scores are rewritten onto the new scale in the same transaction, so the scores and
+// the scale they are read on never disagree; unscored cells have no row and stay
+// unscored.
+func SetSuiteScoreScale(suiteName string, from, to ScoreScale, convert bool) error {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	tx, err := dbBegin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if convert {
+		if err := convertSuiteScores(tx, suiteID, from, to); err != nil {
+			return err
+		}
+	}
+	if err := setSuiteSetting(tx, suiteID, SuiteSettingScoreScale, to.Name); err != nil {
+		return fmt.Errorf("failed to set score scale: %w", err)
+	}
+	return tx.Commit()
+}
+
+// convertSuiteScores rewrites a suite's grid scores from one scale onto another
+func convertSuiteScores(tx *sql.Tx, suiteID int, from, to ScoreScale) error {
+	rows, err := tx.Query(`
+		SELECT s.model_id, s.prompt_id, s.score FROM scores s
+		JOIN models m ON m.id = s.model_id
+		WHERE m.suite_id = ?
+	`, suiteID)
+	if err != nil {
+		return fmt.Errorf("failed to query scores: %w", err)
+	}
+	type cell struct{ modelID, promptID, score int }
+	var cells []cell
+	for rows.Next() {
+		var c cell
+		if err := rows.Scan(&c.modelID, &c.promptID, &c.score); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to scan score: %w", err)
+		}
+		cells = append(cells, c)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("failed to read scores: %w", err)
+	}
+
+	change := ScoreChange{Source: ScoreSourceConvert, Note: fmt.Sprintf("%s to %s", from.Label, to.Label)}
+	for _, c := range cells {
+		if err := WriteScore(tx, c.modelID, c.promptID, ConvertScore(c.score, from, to), change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScoreScale_SnapAndPercent(t *testing.T) {
+	tests := []struct {
+		scale   string
+		score   int
+		snapped int
+		percent float64
+	}{
+		{ScoreScaleFifths, 50, 40, 40}, // Ties go down, as in RoundToValidScore
+		{ScoreScaleFifths, 51, 60, 60},
+		{ScoreScaleFifths, 150, 100, 100},
+		{ScoreScaleBinary, 7, 1, 100},
+		{ScoreScaleLikert, 0, 1, 0}, // The minimum is 0%, whatever its value
+		{ScoreScaleLikert, 4, 4, 75},
+		{ScoreScaleTen, 11, 10, 100},
+		{ScoreScaleTen, 1, 1, 0},
+		{ScoreScaleContinuous, 73, 73, 73},
+	}
+	for _, tt := range tests {
+		scale, err := GetScoreScale(tt.scale)
+		if err != nil {
+			t.Fatalf("GetScoreScale(%q) failed: %v", tt.scale, err)
+		}
+		snapped := scale.Snap(tt.score)
+		if snapped != tt.snapped {
+			t.Errorf("%s: Snap(%d) = %d, want %d", tt.scale, tt.score, snapped, tt.snapped)
+		}
+		if got := scale.Percent(snapped); got != tt.percent {
+			t.Errorf("%s: Percent(%d) = %v, want %v", tt.scale, snapped, got, tt.percent)
+		}
+	}
+
+	likert := ResolveScoreScale(ScoreScaleLikert)
+	for percent, want := range map[float64]int{0: 1, 70: 4, 80: 4, 100: 5} {
+		if got := likert.FromPercent(percent); got != want {
+			t.Errorf("likert: FromPercent(%v) = %d, want %d", percent, got, want)
+		}
+	}
+}
+
+func TestGetScoreScale(t *testing.T) {
+	if scale, err := GetScoreScale(""); err != nil || scale.Name != DefaultScoreScale {
+		t.Errorf("expected the default scale for an empty name, got %+v (%v)", scale, err)
+	}
+	if _, err := GetScoreScale("stars"); err == nil {
+		t.Error("expected an error for an unknown scale")
+	}
+	if scale := ResolveScoreScale("stars"); scale.Name != DefaultScoreScale {
+		t.Errorf("expected an unknown scale to resolve to the default, got %q", scale.Name)
+	}
+	if scales := ScoreScales(); len(scales) != 5 || scales[0].Name != DefaultScoreScale {
+		t.Errorf("expected five scales with the default first, got %+v", scales)
+	}
+}
+
+func TestConvertScore(t *testing.T) {
+	fifths := ResolveScoreScale(ScoreScaleFifths)
+	binary := ResolveScoreScale(ScoreScaleBinary)
+	likert := ResolveScoreScale(ScoreScaleLikert)
+	ten := ResolveScoreScale(ScoreScaleTen)
+
+	tests := []struct {
+		score    int
+		from, to ScoreScale
+		want     int
+	}{
+		{0, fifths, likert, 1}, // 0/5 is a real score, the bottom of the Likert scale
+		{0, binary, ten, 1},
+		{80, fifths, likert, 4},
+		{4, likert, fifths, 80},
+		{1, binary, fifths, 100},
+		{40, fifths, binary, 0},
+		{60, fifths, binary, 1},
+		{7, ten, likert, 4},
+		{3, likert, ten, 6},
+	}
+	for _, tt := range tests {
+		if got := ConvertScore(tt.score, tt.from, tt.to); got != tt.want {
+			t.Errorf("ConvertScore(%d, %s, %s) = %d, want %d", tt.score, tt.from.Name, tt.to.Name, got, tt.want)
+		}
+	}
+}
+
+func TestConvertScore_RoundTrip(t *testing.T) {
+	for _, from := range ScoreScales() {
+		for _, to := range ScoreScales() {
+			points := from.Points()
+			if got := ConvertScore(from.Min, from, to); got != to.Min {
+				t.Errorf("ConvertScore(%d, %s, %s) = %d, want the minimum %d", from.Min, from.Name, to.Name, got, to.Min)
+			}
+			if got := ConvertScore(from.Max, from, to); got != to.Max {
+				t.Errorf("ConvertScore(%d, %s, %s) = %d, want the maximum %d", from.Max, from.Name, to.Name, got, to.Max)
+			}
+
+			// Converting never reorders scores, and a scale with at least as many
+			// points brings every score back unchanged
+			previous := to.Min
+			for _, p := range points {
+				converted := ConvertScore(p, from, to)
+				if converted < previous {
+					t.Errorf("%s to %s: %d converts to %d, below the previous point's %d", from.Name, to.Name, p, converted, previous)
+				}
+				previous = converted
+				if len(to.Points()) < len(points) {
+					continue
+				}
+				if back := ConvertScore(converted, to, from); back != p {
+					t.Errorf("%s to %s and back: %d became %d via %d", from.Name, to.Name, p, back, converted)
+				}
+			}
+		}
+	}
+}
+
+func TestScoreScale_OptionsAndBuckets(t *testing.T) {
+	fifths := ResolveScoreScale(ScoreScaleFifths)
+	options := fifths.Options()
+	if len(options) != 6 || options[0].Value != 0 || options[5].Value != 100 {
+		t.Fatalf("expected six options from 0 to 100, got %+v", options)
+	}
+	if options[5].Label != "5/5 (100)" || options[0].Color != "#ffa500" || options[5].Color != "#7cff6b" {
+		t.Errorf("expected the fifths options to keep their labels and colours, got %+v", options)
+	}
+
+	binary := ResolveScoreScale(ScoreScaleBinary)
+	var labels []string
+	for _, o := range binary.Options() {
+		labels = append(labels, o.Label)
+	}
+	if !reflect.DeepEqual(labels, []string{"Fail", "Pass"}) {
+		t.Errorf("expected Fail and Pass, got %v", labels)
+	}
+	// A Fail is a score like any other, so it has its own colour and bucket
+	if options := binary.Options(); options[0].Color == UnscoredColor {
+		t.Errorf("expected Fail to be coloured as a score, got %+v", options)
+	}
+	if buckets := binary.Buckets(); len(buckets) != 2 || buckets[0].Label != "Fail" || buckets[1].Label != "Pass" {
+		t.Errorf("expected Fail and Pass buckets, got %+v", buckets)
+	}
+
+	if buckets := ResolveScoreScale(ScoreScaleTen).Buckets(); len(buckets) != 10 || buckets[0].Label != "1/10" {
+		t.Errorf("expected a bucket per point of the ten scale, got %+v", buckets)
+	}
+
+	continuous := ResolveScoreScale(ScoreScaleContinuous)
+	if continuous.Options() != nil {
+		t.Error("expected no options for a continuous scale")
+	}
+	buckets := continuous.Buckets()
+	if len(buckets) != 10 || buckets[0].Low != 0 || buckets[0].High != 10 || buckets[9].High != 100 {
+		t.Errorf("expected tenths of the continuous scale, got %+v", buckets)
+	}
+}
+
+func TestSetSuiteScoreScale(t *testing.T) {
+	defer setupJudgesTest(t)()
+
+	if err := WritePromptSuite("default", []Prompt{{Text: "one"}, {Text: "two"}}); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	if err := WriteResults("default", map[string]Result{"llama": {Scores: []int{80, 0}}}); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+	if scale := SuiteScoreScale("default"); scale.Name != DefaultScoreScale {
+		t.Errorf("expected a suite without a setting on the default scale, got %q", scale.Name)
+	}
+
+	fifths, likert := ResolveScoreScale(ScoreScaleFifths), ResolveScoreScale(ScoreScaleLikert)
+	if err := SetSuiteScoreScale("default", fifths, likert, true); err != nil {
+		t.Fatalf("SetSuiteScoreScale failed: %v", err)
+	}
+	if scores := ReadResults()["llama"].Scores; !reflect.DeepEqual(scores, []int{4, 0}) {
+		t.Errorf("expected the scores converted to likert, got %v", scores)
+	}
+	if scale := SuiteScoreScale("default"); scale.Name != ScoreScaleLikert {
+		t.Errorf("expected the suite on the likert scale, got %q", scale.Name)
+	}
+
+	if err := SetSuiteScoreScale("default", likert, fifths, false); err != nil {
+		t.Fatalf("SetSuiteScoreScale failed: %v", err)
+	}
+	if scores := ReadResults()["llama"].Scores; !reflect.DeepEqual(scores, []int{4, 0}) {
+		t.Errorf("expected the scores kept without converting, got %v", scores)
+	}
+
+	// A failed setting write rolls the conversion back with it
+	if _, err := db.Exec("DROP TABLE suite_settings"); err != nil {
+		t.Fatalf("failed to drop suite settings: %v", err)
+	}
+	if err := SetSuiteScoreScale("default", fifths, likert, true); err == nil {
+		t.Fatal("expected an error when the scale cannot be saved")
+	}
+	if scores := ReadResults()["llama"].Scores; !reflect.DeepEqual(scores, []int{4, 0}) {
+		t.Errorf("expected the scores left unconverted, got %v", scores)
+	}
+}
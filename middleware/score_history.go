@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Where a score change came from
+const (
+	ScoreSourceManual    = "manual"
+	ScoreSourceJudge     = "judge"
+	ScoreSourceImport    = "import"
+	ScoreSourceRandomize = "randomize"
+	ScoreSourceConvert   = "convert"
+	ScoreSourceRevert    = "revert"
+	ScoreSourceRestore   = "restore"
+)
+
+// ScoreChange is the provenance recorded with every change of a grid score
+type ScoreChange struct {
+	Source string
+	JobID  int // Evaluation job that made the change, or 0
+	Note   string
+}
+
+// ScoreHistoryEntry is one recorded change of a grid score. The history is append-only:
+// reverting a change records another one. OldScore is nil when the cell was unscored
+// before the change and NewScore when the change cleared it. ModelID or PromptID is 0
+// once the model or prompt has been deleted.
+type ScoreHistoryEntry struct {
+	ID        int       `json:"id"`
+	ModelID   int       `json:"model_id"`
+	PromptID  int       `json:"prompt_id"`
+	Model     string    `json:"model"`
+	Prompt    string    `json:"prompt"`
+	OldScore  *int      `json:"old_score"`
+	NewScore  *int      `json:"new_score"`
+	Source    string    `json:"source"`
+	JobID     int       `json:"job_id,omitempty"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ScoreRevert counts the cells a job revert put back and those it left alone because
+// they changed again after the job
+type ScoreRevert struct {
+	Reverted int `json:"reverted"`
+	Skipped  int `json:"skipped"`
+}
+
+// ScoreWriter is the *sql.DB or *sql.Tx a score change is written through
+type ScoreWriter interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// WriteScore sets one grid cell and records the change in its history. Writing the
+// score a cell already has records nothing.
+func WriteScore(q ScoreWriter, modelID, promptID, score int, change ScoreChange) error {
+	old, err := readScore(q, modelID, promptID)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO scores (model_id, prompt_id, score) VALUES (?, ?, ?)
+		ON CONFLICT(model_id, prompt_id) DO UPDATE SET score = excluded.score
+	`, modelID, promptID, score)
+	if err != nil {
+		return fmt.Errorf("failed to write score: %w", err)
+	}
+	return recordScoreChange(q, modelID, promptID, old, &score, change)
+}
+
+// ClearScore makes one grid cell unscored and records the change in its history
+func ClearScore(q ScoreWriter, modelID, promptID int, change ScoreChange) error {
+	old, err := readScore(q, modelID, promptID)
+	if err != nil || old == nil {
+		return err
+	}
+	if _, err := q.Exec("DELETE FROM scores WHERE model_id = ? AND prompt_id = ?", modelID, promptID); err != nil {
+		return fmt.Errorf("failed to clear score: %w", err)
+	}
+	return recordScoreChange(q, modelID, promptID, old, nil, change)
+}
+
+// setScore writes score to a cell, or clears the cell when score is nil
+func setScore(q ScoreWriter, modelID, promptID int, score *int, change ScoreChange) error {
+	if score == nil {
+		return ClearScore(q, modelID, promptID, change)
+	}
+	return WriteScore(q, modelID, promptID, *score, change)
+}
+
+// readScore returns a cell's score, or nil when it is unscored
+func readScore(q ScoreWriter, modelID, promptID int) (*int, error) {
+	var score int
+	err := q.QueryRow("SELECT score FROM scores WHERE model_id = ? AND prompt_id = ?", modelID, promptID).Scan(&score)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read score: %w", err)
+	}
+	return &score, nil
+}
+
+// recordScoreChange appends a change to a cell's history unless the score stayed the
+// same. A nil score is an unscored cell.
+func recordScoreChange(q ScoreWriter, modelID, promptID int, old, score *int, change ScoreChange) error {
+	if sameScore(old, score) {
+		return nil
+	}
+	var jobID interface{}
+	if change.JobID > 0 {
+		jobID = change.JobID
+	}
+	_, err := q.Exec(`
+		INSERT INTO score_history (model_id, prompt_id, model_name, prompt_text, old_score, new_score, source, job_id, note)
+		VALUES (?, ?, COALESCE((SELECT name FROM models WHERE id = ?), ''), COALESCE((SELECT text FROM prompts WHERE id = ?), ''),
+			?, ?, ?, ?, ?)
+	`, modelID, promptID, modelID, promptID, old, score, change.Source, jobID, change.Note)
+	if err != nil {
+		return fmt.Errorf("failed to record score change: %w", err)
+	}
+	return nil
+}
+
+// sameScore reports whether two cell scores are equal, nil being unscored
+func sameScore(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// scoreHistoryQuery reads changes with the current names of their model and prompt,
+// or the names recorded with the change once those are deleted
+const scoreHistoryQuery = `
+	SELECT h.id, COALESCE(h.model_id, 0), COALESCE(h.prompt_id, 0), COALESCE(m.name, h.model_name),
+	       COALESCE(p.text, h.prompt_text), h.old_score, h.new_score, h.source, COALESCE(h.job_id, 0), h.note, h.created_at
+	FROM score_history h
+	LEFT JOIN models m ON m.id = h.model_id
+	LEFT JOIN prompts p ON p.id = h.prompt_id
+`
+
+func queryScoreHistory(where string, args ...any) ([]ScoreHistoryEntry, error) {
+	rows, err := db.Query(scoreHistoryQuery+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query score history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []ScoreHistoryEntry
+	for rows.Next() {
+		var e ScoreHistoryEntry
+		if err := rows.Scan(&e.ID, &e.ModelID, &e.PromptID, &e.Model, &e.Prompt, &e.OldScore, &e.NewScore,
+			&e.Source, &e.JobID, &e.Note, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan score change: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// ListScoreHistory returns the changes of one grid cell, newest first
+func ListScoreHistory(suiteName, modelName string, promptIndex int) ([]ScoreHistoryEntry, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+	modelID, err := GetModelID(suiteName, modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	var promptID int
+	err = db.QueryRow(
+		"SELECT id FROM prompts WHERE suite_id = ? ORDER BY display_order, id LIMIT 1 OFFSET ?",
+		suiteID, promptIndex,
+	).Scan(&promptID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("prompt %d not found in suite %s", promptIndex, suiteName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt ID: %w", err)
+	}
+
+	return queryScoreHistory("WHERE h.model_id = ? AND h.prompt_id = ? ORDER BY h.id DESC", modelID, promptID)
+}
+
+// ListJobScoreChanges returns the score changes an evaluation job made, oldest first
+func ListJobScoreChanges(jobID int) ([]ScoreHistoryEntry, error) {
+	return queryScoreHistory("WHERE h.job_id = ? ORDER BY h.id", jobID)
+}
+
+// RevertScoreChange puts a cell back to the score it had before the given change,
+// clearing it when it was unscored
+func RevertScoreChange(id int) error {
+	var modelID, promptID sql.NullInt64
+	var old *int
+	err := db.QueryRow("SELECT model_id, prompt_id, old_score FROM score_history WHERE id = ?", id).Scan(&modelID, &promptID, &old)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("score change %d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read score change: %w", err)
+	}
+	if !modelID.Valid || !promptID.Valid {
+		return fmt.Errorf("score change %d belongs to a deleted model or prompt", id)
+	}
+	return setScore(db, int(modelID.Int64), int(promptID.Int64), old, ScoreChange{
+		Source: ScoreSourceRevert,
+		Note:   fmt.Sprintf("Reverted change #%d", id),
+	})
+}
+
+// RevertJobScores puts every cell an evaluation job changed back to its score from
+// before the job. Cells changed again after the job are left alone, and cells whose
+// model or prompt has been deleted are gone.
+func RevertJobScores(jobID int) (ScoreRevert, error) {
+	var revert ScoreRevert
+
+	tx, err := dbBegin()
+	if err != nil {
+		return revert, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// The first change of each cell holds its score from before the job, the last one
+	// tells whether anything touched the cell since
+	rows, err := tx.Query(`
+		SELECT c.model_id, c.prompt_id, c.last_id, h.old_score
+		FROM (
+			SELECT model_id, prompt_id, MIN(id) AS first_id, MAX(id) AS last_id
+			FROM score_history
+			WHERE job_id = ? AND model_id IS NOT NULL AND prompt_id IS NOT NULL
+			GROUP BY model_id, prompt_id
+		) c
+		JOIN score_history h ON h.id = c.first_id
+	`, jobID)
+	if err != nil {
+		return revert, fmt.Errorf("failed to query job score changes: %w", err)
+	}
+	type cell struct {
+		modelID, promptID, lastID int
+		old                       *int
+	}
+	var cells []cell
+	for rows.Next() {
+		var c cell
+		if err := rows.Scan(&c.modelID, &c.promptID, &c.lastID, &c.old); err != nil {
+			_ = rows.Close()
+			return revert, fmt.Errorf("failed to scan job score change: %w", err)
+		}
+		cells = append(cells, c)
+	}
+	if err := rows.Close(); err != nil {
+		return revert, fmt.Errorf("failed to read job score changes: %w", err)
+	}
+
+	change := ScoreChange{Source: ScoreSourceRevert, Note: fmt.Sprintf("Reverted job #%d", jobID)}
+	for _, c := range cells {
+		var later int
+		err := tx.QueryRow("SELECT COUNT(*) FROM score_history WHERE model_id = ? AND prompt_id = ? AND id > ?",
+			c.modelID, c.promptID, c.lastID).Scan(&later)
+		if err != nil {
+			return revert, fmt.Errorf("failed to check later score changes: %w", err)
+		}
+		if later > 0 {
+			revert.Skipped++
+			continue
+		}
+		if err := setScore(tx, c.modelID, c.promptID, c.old, change); err != nil {
+			return revert, err
+		}
+		revert.Reverted++
+	}
+	return revert, tx.Commit()
+}
@@ -0,0 +1,268 @@
+package middleware
+
+import (
+	"reflect"
+	"testing"
+)
+
+func setupScoreHistoryTest(t *testing.T) func() {
+	t.Helper()
+	cleanup := setupJudgesTest(t)
+	if err := WritePromptSuite("default", []Prompt{{Text: "one"}, {Text: "two"}}); err != nil {
+		cleanup()
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	if err := WriteResults("default", map[string]Result{"llama": {Scores: []int{20, 40}}}); err != nil {
+		cleanup()
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+	return cleanup
+}
+
+// scoreIs reports whether a recorded score is set to want
+func scoreIs(score *int, want int) bool {
+	return score != nil && *score == want
+}
+
+func scoreHistoryCell(t *testing.T, promptIndex int) []ScoreHistoryEntry {
+	t.Helper()
+	entries, err := ListScoreHistory("default", "llama", promptIndex)
+	if err != nil {
+		t.Fatalf("ListScoreHistory failed: %v", err)
+	}
+	return entries
+}
+
+func TestWriteResults_RecordsOnlyChanges(t *testing.T) {
+	defer setupScoreHistoryTest(t)()
+
+	err := WriteResultsWithChange("default", map[string]Result{"llama": {Scores: []int{20, 60}}},
+		ScoreChange{Source: ScoreSourceImport, Note: "results.json"})
+	if err != nil {
+		t.Fatalf("WriteResultsWithChange failed: %v", err)
+	}
+
+	if entries := scoreHistoryCell(t, 0); len(entries) != 1 || entries[0].Source != ScoreSourceManual {
+		t.Errorf("expected only the initial manual change of an unchanged cell, got %+v", entries)
+	}
+
+	entries := scoreHistoryCell(t, 1)
+	if len(entries) != 2 {
+		t.Fatalf("expected two changes, got %+v", entries)
+	}
+	latest := entries[0]
+	if !scoreIs(latest.OldScore, 40) || !scoreIs(latest.NewScore, 60) || latest.Source != ScoreSourceImport || latest.Note != "results.json" {
+		t.Errorf("expected the import first, got %+v", latest)
+	}
+	if latest.Model != "llama" || latest.Prompt != "two" || latest.JobID != 0 {
+		t.Errorf("expected the cell's model and prompt without a job, got %+v", latest)
+	}
+	if _, err := ListScoreHistory("default", "llama", 5); err == nil {
+		t.Error("expected an error for a prompt past the end of the suite")
+	}
+}
+
+func TestWriteResults_UnscoredCells(t *testing.T) {
+	defer setupScoreHistoryTest(t)()
+
+	// A zero marked as scored is a real score, such as a Fail on the binary scale;
+	// a cell marked as unscored loses its row
+	var result Result
+	result.SetScore(0, 0)
+	result.Scores = append(result.Scores, 0)
+	result.Scored = append(result.Scored, false)
+	if err := WriteResults("default", map[string]Result{"llama": result}); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+
+	read := ReadResults()["llama"]
+	if !read.IsScored(0) || read.Scores[0] != 0 || read.IsScored(1) {
+		t.Errorf("expected a scored zero and an unscored cell, got %+v", read)
+	}
+	var rows int
+	if err := db.QueryRow("SELECT COUNT(*) FROM scores").Scan(&rows); err != nil {
+		t.Fatalf("failed to count scores: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("expected only the scored cell stored, got %d rows", rows)
+	}
+
+	cleared := scoreHistoryCell(t, 1)[0]
+	if !scoreIs(cleared.OldScore, 40) || cleared.NewScore != nil {
+		t.Fatalf("expected the cleared cell recorded without a new score, got %+v", cleared)
+	}
+	if err := RevertScoreChange(cleared.ID); err != nil {
+		t.Fatalf("RevertScoreChange failed: %v", err)
+	}
+	if read := ReadResults()["llama"]; !read.IsScored(1) || read.Scores[1] != 40 {
+		t.Errorf("expected the revert to restore the cleared score, got %+v", read)
+	}
+}
+
+func TestWritePromptSuite_KeepsScoresAndHistory(t *testing.T) {
+	defer setupScoreHistoryTest(t)()
+
+	// Adding a prompt leaves the rows of the others, and so their scores, alone
+	prompts, err := ReadPromptSuite("default")
+	if err != nil {
+		t.Fatalf("ReadPromptSuite failed: %v", err)
+	}
+	if err := WritePromptSuite("default", append(prompts, Prompt{Text: "three"})); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	read := ReadResults()["llama"]
+	if !reflect.DeepEqual(read.Scores, []int{20, 40, 0}) || read.IsScored(2) {
+		t.Errorf("expected the scores kept and the new prompt unscored, got %+v", read)
+	}
+	if entries := scoreHistoryCell(t, 1); len(entries) != 1 || !scoreIs(entries[0].NewScore, 40) {
+		t.Errorf("expected the history kept, got %+v", entries)
+	}
+
+	// Moving prompts takes their scores along
+	prompts, _ = ReadPromptSuite("default")
+	prompts[0], prompts[1] = prompts[1], prompts[0]
+	if err := WritePromptSuite("default", prompts); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	if scores := ReadResults()["llama"].Scores; !reflect.DeepEqual(scores, []int{40, 20, 0}) {
+		t.Errorf("expected the scores moved with their prompts, got %v", scores)
+	}
+
+	// A deleted prompt takes its score but not its history
+	change := scoreHistoryCell(t, 0)[0]
+	prompts, _ = ReadPromptSuite("default")
+	if err := WritePromptSuite("default", prompts[1:]); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	if scores := ReadResults()["llama"].Scores; !reflect.DeepEqual(scores, []int{20, 0}) {
+		t.Errorf("expected only the deleted prompt's score gone, got %v", scores)
+	}
+	var entries []ScoreHistoryEntry
+	if entries, err = queryScoreHistory("WHERE h.id = ?", change.ID); err != nil {
+		t.Fatalf("queryScoreHistory failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].PromptID != 0 || entries[0].Prompt != "two" || entries[0].Model != "llama" {
+		t.Errorf("expected the change kept with the deleted prompt's text, got %+v", entries)
+	}
+	if err := RevertScoreChange(change.ID); err == nil {
+		t.Error("expected an error reverting a change of a deleted prompt")
+	}
+}
+
+func TestRevertScoreChange(t *testing.T) {
+	defer setupScoreHistoryTest(t)()
+
+	if err := WriteResults("default", map[string]Result{"llama": {Scores: []int{80, 40}}}); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+	change := scoreHistoryCell(t, 0)[0]
+
+	if err := RevertScoreChange(change.ID); err != nil {
+		t.Fatalf("RevertScoreChange failed: %v", err)
+	}
+	if scores := ReadResults()["llama"].Scores; !reflect.DeepEqual(scores, []int{20, 40}) {
+		t.Errorf("expected the first score back at 20, got %v", scores)
+	}
+	revert := scoreHistoryCell(t, 0)[0]
+	if revert.Source != ScoreSourceRevert || !scoreIs(revert.OldScore, 80) || !scoreIs(revert.NewScore, 20) {
+		t.Errorf("expected the revert recorded as a change of its own, got %+v", revert)
+	}
+
+	if err := RevertScoreChange(9999); err == nil {
+		t.Error("expected an error for an unknown change")
+	}
+}
+
+func TestRevertJobScores(t *testing.T) {
+	defer setupScoreHistoryTest(t)()
+
+	if _, err := db.Exec("INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all')"); err != nil {
+		t.Fatalf("failed to seed job: %v", err)
+	}
+	modelID, err := GetModelID("default", "llama")
+	if err != nil {
+		t.Fatalf("GetModelID failed: %v", err)
+	}
+	var promptIDs []int
+	rows, err := db.Query("SELECT id FROM prompts ORDER BY display_order, id")
+	if err != nil {
+		t.Fatalf("failed to read prompts: %v", err)
+	}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("failed to scan prompt: %v", err)
+		}
+		promptIDs = append(promptIDs, id)
+	}
+	_ = rows.Close()
+
+	judge := ScoreChange{Source: ScoreSourceJudge, JobID: 1}
+	for _, promptID := range promptIDs {
+		if err := WriteScore(db, modelID, promptID, 100, judge); err != nil {
+			t.Fatalf("WriteScore failed: %v", err)
+		}
+	}
+	if changes, err := ListJobScoreChanges(1); err != nil || len(changes) != 2 || changes[0].Prompt != "one" {
+		t.Fatalf("expected the job's two changes oldest first, got %+v (%v)", changes, err)
+	}
+
+	// Someone fixes the second cell by hand after the job
+	if err := WriteScore(db, modelID, promptIDs[1], 80, ScoreChange{Source: ScoreSourceManual}); err != nil {
+		t.Fatalf("WriteScore failed: %v", err)
+	}
+
+	revert, err := RevertJobScores(1)
+	if err != nil {
+		t.Fatalf("RevertJobScores failed: %v", err)
+	}
+	if revert != (ScoreRevert{Reverted: 1, Skipped: 1}) {
+		t.Errorf("expected one cell reverted and one kept, got %+v", revert)
+	}
+	if scores := ReadResults()["llama"].Scores; !reflect.DeepEqual(scores, []int{20, 80}) {
+		t.Errorf("expected the job's score reverted and the manual fix kept, got %v", scores)
+	}
+
+	// Reverting again finds every cell changed since
+	if revert, err := RevertJobScores(1); err != nil || revert.Reverted != 0 {
+		t.Errorf("expected nothing left to revert, got %+v (%v)", revert, err)
+	}
+}
+
+func TestDropUnscoredZeros(t *testing.T) {
+	defer setupScoreHistoryTest(t)()
+
+	// Older versions stored unscored cells as zero; a graded zero has a gold score
+	if _, err := db.Exec("UPDATE scores SET score = 0"); err != nil {
+		t.Fatalf("failed to zero scores: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO gold_scores (model_id, prompt_id, score)
+		SELECT model_id, prompt_id, 0 FROM scores ORDER BY prompt_id LIMIT 1`); err != nil {
+		t.Fatalf("failed to seed gold score: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM settings WHERE key = ?", unscoredZerosDropped); err != nil {
+		t.Fatalf("failed to reset migration: %v", err)
+	}
+
+	if err := dropUnscoredZeros(); err != nil {
+		t.Fatalf("dropUnscoredZeros failed: %v", err)
+	}
+	read := ReadResults()["llama"]
+	if !read.IsScored(0) || read.IsScored(1) {
+		t.Errorf("expected only the graded zero kept, got %+v", read)
+	}
+
+	// The cleanup runs once, so zeros written since are real scores
+	var result Result
+	result.SetScore(0, 0)
+	result.SetScore(1, 0)
+	if err := WriteResults("default", map[string]Result{"llama": result}); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+	if err := dropUnscoredZeros(); err != nil {
+		t.Fatalf("dropUnscoredZeros failed: %v", err)
+	}
+	if read := ReadResults()["llama"]; !read.IsScored(1) {
+		t.Errorf("expected a second run to keep new zeros, got %+v", read)
+	}
+}
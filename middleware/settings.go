@@ -90,3 +90,49 @@ func GetMaskedAPIKeys() (map[string]string, error) {
 
 	return apiKeys, nil
 }
+
+// Per-suite setting keys
+const (
+	SuiteSettingConsensusStrategy = "consensus_strategy"
+	SuiteSettingCalibration       = "judge_calibration"
+	SuiteSettingMonthlyBudget     = "monthly_budget_usd" // Hard cap on the suite's spend per calendar month
+	SuiteSettingJudgePanel        = "judge_panel"        // Panel judging prompts whose profile has no override
+	SuiteSettingScoreScale        = "score_scale"        // Scale the suite's grid scores are given on
+)
+
+// GetSuiteSetting retrieves a setting scoped to one suite ("" when unset)
+func GetSuiteSetting(suiteName, key string) (string, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	var value string
+	err = db.QueryRow("SELECT value FROM suite_settings WHERE suite_id = ? AND key = ?", suiteID, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetSuiteSetting updates or inserts a setting scoped to one suite
+func SetSuiteSetting(suiteName, key, value string) error {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	return setSuiteSetting(db, suiteID, key, value)
+}
+
+// setSuiteSetting writes a suite setting through q, which may be a transaction
+func setSuiteSetting(q ScoreWriter, suiteID int, key, value string) error {
+	_, err := q.Exec(`
+		INSERT INTO suite_settings (suite_id, key, value, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(suite_id, key) DO UPDATE SET
+			value = excluded.value,
+			updated_at = excluded.updated_at
+	`, suiteID, key, value, time.Now())
+	return err
+}
@@ -304,3 +304,41 @@ func TestGetMaskedAPIKeys_Empty(t *testing.T) {
 		}
 	}
 }
+
+func TestSuiteSetting_RoundTrip(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	value, err := GetSuiteSetting("default", SuiteSettingConsensusStrategy)
+	if err != nil {
+		t.Fatalf("GetSuiteSetting failed: %v", err)
+	}
+	if value != "" {
+		t.Errorf("expected empty value for unset setting, got %q", value)
+	}
+
+	if err := SetSuiteSetting("default", SuiteSettingConsensusStrategy, "median"); err != nil {
+		t.Fatalf("SetSuiteSetting failed: %v", err)
+	}
+	if err := SetSuiteSetting("default", SuiteSettingConsensusStrategy, "strict"); err != nil {
+		t.Fatalf("SetSuiteSetting update failed: %v", err)
+	}
+
+	value, _ = GetSuiteSetting("default", SuiteSettingConsensusStrategy)
+	if value != "strict" {
+		t.Errorf("expected 'strict', got %q", value)
+	}
+
+	// Settings are scoped to their suite
+	if _, err := db.Exec("INSERT INTO suites (name) VALUES ('other')"); err != nil {
+		t.Fatalf("failed to create suite: %v", err)
+	}
+	value, _ = GetSuiteSetting("other", SuiteSettingConsensusStrategy)
+	if value != "" {
+		t.Errorf("expected other suite to be unset, got %q", value)
+	}
+}
@@ -0,0 +1,610 @@
+package middleware
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxAutoSnapshots is how many automatic snapshots a suite keeps; older ones are pruned
+// when another is taken. Named snapshots are never pruned.
+const maxAutoSnapshots = 20
+
+// SuiteSnapshot describes a stored copy of a suite, without its rows
+type SuiteSnapshot struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Auto      bool      `json:"auto"`
+	Prompts   int       `json:"prompts"`
+	Models    int       `json:"models"`
+	Scores    int       `json:"scores"`
+	Responses int       `json:"responses"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SnapshotDiff compares two states of a suite, matching models by name and prompts by text
+type SnapshotDiff struct {
+	From           string                `json:"from"`
+	To             string                `json:"to"`
+	PromptsAdded   []string              `json:"prompts_added"`
+	PromptsRemoved []string              `json:"prompts_removed"`
+	ModelsAdded    []string              `json:"models_added"`
+	ModelsRemoved  []string              `json:"models_removed"`
+	Totals         []SnapshotModelTotal  `json:"totals"`
+	Cells          []SnapshotScoreChange `json:"cells"`
+}
+
+// SnapshotModelTotal is a model's total score on both sides of a comparison
+type SnapshotModelTotal struct {
+	Model string `json:"model"`
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+}
+
+// SnapshotScoreChange is a cell whose score differs between the two sides of a comparison
+type SnapshotScoreChange struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+}
+
+// snapshotTable is a table copied into suite snapshots. scope selects the suite's rows
+// given its id.
+type snapshotTable struct {
+	name  string
+	scope string
+	// Rows of a job deleted since the snapshot are dropped on restore; in other
+	// tables their job is cleared
+	jobRequired bool
+	// Rows are never cleared on restore: the snapshot's rows are written back over
+	// the current ones, linking them again to the models and prompts restored
+	appendOnly bool
+}
+
+const (
+	suiteScope       = "suite_id = ?"
+	suiteModelsScope = "model_id IN (SELECT id FROM models WHERE suite_id = ?)"
+)
+
+// snapshotTables lists parents before children: a restore inserts in this order and
+// deletes in the reverse one
+var snapshotTables = []snapshotTable{
+	{name: "profiles", scope: suiteScope},
+	{name: "prompts", scope: suiteScope},
+	{name: "prompt_turns", scope: "prompt_id IN (SELECT id FROM prompts WHERE suite_id = ?)"},
+	{name: "models", scope: suiteScope},
+	{name: "model_configs", scope: suiteModelsScope},
+	{name: "scores", scope: suiteModelsScope},
+	{name: "criterion_scores", scope: suiteModelsScope},
+	{name: "gold_scores", scope: suiteModelsScope},
+	{name: "model_responses", scope: suiteModelsScope},
+	{name: "evaluation_results", scope: suiteModelsScope, jobRequired: true},
+	{name: "evaluation_history", scope: suiteModelsScope, jobRequired: true},
+	{name: "evaluation_errors", scope: suiteModelsScope, jobRequired: true},
+	{name: "evaluation_job_pairs", scope: suiteModelsScope, jobRequired: true},
+	{name: "score_history", scope: suiteModelsScope, appendOnly: true},
+	{name: "battles", scope: suiteScope},
+	{name: "battle_job_pairs", scope: "prompt_id IN (SELECT id FROM prompts WHERE suite_id = ?)", jobRequired: true},
+	{name: "suite_settings", scope: suiteScope},
+	{name: "profile_judge_panels", scope: suiteScope},
+	{name: "profile_system_prompts", scope: suiteScope},
+}
+
+// snapshotData holds a suite's rows by table, each row keyed by column name
+type snapshotData map[string][]map[string]any
+
+// snapshotQuerier is the *sql.DB or *sql.Tx a snapshot is read through
+type snapshotQuerier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// captureSuite copies every row of the suite out of the snapshot tables
+func captureSuite(q snapshotQuerier, suiteID int) (snapshotData, error) {
+	data := snapshotData{}
+	for _, t := range snapshotTables {
+		rows, err := q.Query("SELECT * FROM "+t.name+" WHERE "+t.scope+" ORDER BY rowid", suiteID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", t.name, err)
+		}
+		columns, err := rows.Columns()
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to read %s columns: %w", t.name, err)
+		}
+		for rows.Next() {
+			values := make([]any, len(columns))
+			ptrs := make([]any, len(columns))
+			for i := range values {
+				ptrs[i] = &values[i]
+			}
+			if err := rows.Scan(ptrs...); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("failed to scan %s: %w", t.name, err)
+			}
+			row := make(map[string]any, len(columns))
+			for i, c := range columns {
+				row[c] = snapshotValue(values[i])
+			}
+			data[t.name] = append(data[t.name], row)
+		}
+		if err := rows.Close(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", t.name, err)
+		}
+	}
+	return data, nil
+}
+
+// snapshotValue stores timestamps the way SQLite's CURRENT_TIMESTAMP writes them, so
+// restored rows sort and compare like the originals
+func snapshotValue(v any) any {
+	switch v := v.(type) {
+	case time.Time:
+		if v.Nanosecond() == 0 && v.Location() == time.UTC {
+			return v.Format(time.DateTime)
+		}
+		return v.Format("2006-01-02 15:04:05.999999999-07:00")
+	case []byte:
+		return string(v)
+	}
+	return v
+}
+
+func decodeSnapshot(raw string) (snapshotData, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.UseNumber()
+	var data snapshotData
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	for _, rows := range data {
+		for _, row := range rows {
+			for c, v := range row {
+				if n, ok := v.(json.Number); ok {
+					if i, err := n.Int64(); err == nil {
+						row[c] = i
+					} else {
+						row[c], _ = n.Float64()
+					}
+				}
+			}
+		}
+	}
+	return data, nil
+}
+
+// snapshotInt reads an integer column of a captured or decoded row
+func snapshotInt(v any) int {
+	switch v := v.(type) {
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// storeSnapshot captures the suite and stores it under name, pruning old automatic
+// snapshots
+func storeSnapshot(tx *sql.Tx, suiteID int, name string, auto bool) (int, error) {
+	data, err := captureSuite(tx, suiteID)
+	if err != nil {
+		return 0, err
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO suite_snapshots (suite_id, name, auto, prompt_count, model_count, score_count, response_count, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, suiteID, name, auto, len(data["prompts"]), len(data["models"]), len(data["scores"]), len(data["model_responses"]), string(raw))
+	if err != nil {
+		return 0, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	id, err := lastInsertID(res)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get snapshot ID: %w", err)
+	}
+
+	if auto {
+		_, err = tx.Exec(`
+			DELETE FROM suite_snapshots
+			WHERE suite_id = ? AND auto = 1 AND id NOT IN (
+				SELECT id FROM suite_snapshots WHERE suite_id = ? AND auto = 1 ORDER BY id DESC LIMIT ?
+			)
+		`, suiteID, suiteID, maxAutoSnapshots)
+		if err != nil {
+			return 0, fmt.Errorf("failed to prune snapshots: %w", err)
+		}
+	}
+	return int(id), nil
+}
+
+// CreateSuiteSnapshot stores a copy of the suite's profiles, prompts, models, scores,
+// responses and judge history. Automatic snapshots are taken before destructive
+// operations and only the latest maxAutoSnapshots of them are kept.
+func CreateSuiteSnapshot(suiteName, name string, auto bool) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("snapshot name is required")
+	}
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	tx, err := dbBegin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	id, err := storeSnapshot(tx, suiteID, name, auto)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// ListSuiteSnapshots returns the suite's snapshots, newest first
+func ListSuiteSnapshots(suiteName string) ([]SuiteSnapshot, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+	rows, err := db.Query(`
+		SELECT id, name, auto, prompt_count, model_count, score_count, response_count, created_at
+		FROM suite_snapshots WHERE suite_id = ? ORDER BY id DESC
+	`, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snapshots: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var snapshots []SuiteSnapshot
+	for rows.Next() {
+		var s SuiteSnapshot
+		if err := rows.Scan(&s.ID, &s.Name, &s.Auto, &s.Prompts, &s.Models, &s.Scores, &s.Responses, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
+
+// DeleteSuiteSnapshot removes a snapshot
+func DeleteSuiteSnapshot(id int) error {
+	res, err := db.Exec("DELETE FROM suite_snapshots WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("snapshot %d not found", id)
+	}
+	return nil
+}
+
+type snapshotRecord struct {
+	suiteID   int
+	name      string
+	createdAt time.Time
+	data      snapshotData
+}
+
+func loadSnapshot(q snapshotQuerier, id int) (*snapshotRecord, error) {
+	var s snapshotRecord
+	var raw string
+	err := q.QueryRow("SELECT suite_id, name, created_at, data FROM suite_snapshots WHERE id = ?", id).
+		Scan(&s.suiteID, &s.name, &s.createdAt, &raw)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("snapshot %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if s.data, err = decodeSnapshot(raw); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// RestoreSuiteSnapshot puts the snapshot's suite back to the state it was captured in.
+// The state being replaced is kept as an automatic snapshot first, and every score the
+// restore changes is recorded in the score history.
+func RestoreSuiteSnapshot(id int) error {
+	tx, err := dbBegin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	snapshot, err := loadSnapshot(tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := storeSnapshot(tx, snapshot.suiteID, fmt.Sprintf("Before restoring %q", snapshot.name), true); err != nil {
+		return err
+	}
+
+	before, err := suiteScores(tx, snapshot.suiteID)
+	if err != nil {
+		return err
+	}
+
+	for i := len(snapshotTables) - 1; i >= 0; i-- {
+		t := snapshotTables[i]
+		if t.appendOnly {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM "+t.name+" WHERE "+t.scope, snapshot.suiteID); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", t.name, err)
+		}
+	}
+
+	jobs, err := existingJobs(tx)
+	if err != nil {
+		return err
+	}
+	for _, t := range snapshotTables {
+		if err := restoreTable(tx, t, snapshot.data[t.name], jobs); err != nil {
+			return err
+		}
+	}
+
+	after, err := suiteScores(tx, snapshot.suiteID)
+	if err != nil {
+		return err
+	}
+	change := ScoreChange{Source: ScoreSourceRestore, Note: fmt.Sprintf("Restored snapshot %q", snapshot.name)}
+	for c, score := range after {
+		var old *int
+		if b, ok := before[c]; ok {
+			old = &b
+		}
+		if err := recordScoreChange(tx, c.modelID, c.promptID, old, &score, change); err != nil {
+			return err
+		}
+	}
+	// Cells the snapshot had no score for are unscored again; a cell whose model or
+	// prompt went with the restore has nothing left to record the change against
+	for c, score := range before {
+		if _, ok := after[c]; ok {
+			continue
+		}
+		var kept int
+		err := tx.QueryRow(`
+			SELECT COUNT(*) FROM models m, prompts p WHERE m.id = ? AND p.id = ?
+		`, c.modelID, c.promptID).Scan(&kept)
+		if err != nil {
+			return fmt.Errorf("failed to check restored cell: %w", err)
+		}
+		if kept == 0 {
+			continue
+		}
+		if err := recordScoreChange(tx, c.modelID, c.promptID, &score, nil, change); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type scoreCell struct{ modelID, promptID int }
+
+func suiteScores(tx *sql.Tx, suiteID int) (map[scoreCell]int, error) {
+	rows, err := tx.Query("SELECT model_id, prompt_id, score FROM scores WHERE "+suiteModelsScope, suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scores: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	scores := map[scoreCell]int{}
+	for rows.Next() {
+		var c scoreCell
+		var score int
+		if err := rows.Scan(&c.modelID, &c.promptID, &score); err != nil {
+			return nil, fmt.Errorf("failed to scan score: %w", err)
+		}
+		scores[c] = score
+	}
+	return scores, rows.Err()
+}
+
+func existingJobs(tx *sql.Tx) (map[int64]bool, error) {
+	rows, err := tx.Query("SELECT id FROM evaluation_jobs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	jobs := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs[id] = true
+	}
+	return jobs, rows.Err()
+}
+
+// restoreTable inserts a table's snapshot rows with their original ids. Columns the
+// table no longer has are left out.
+func restoreTable(tx *sql.Tx, t snapshotTable, rows []map[string]any, jobs map[int64]bool) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	current, err := tx.Query("SELECT * FROM " + t.name + " LIMIT 0")
+	if err != nil {
+		return fmt.Errorf("failed to read %s columns: %w", t.name, err)
+	}
+	tableColumns, err := current.Columns()
+	_ = current.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read %s columns: %w", t.name, err)
+	}
+
+	var columns []string
+	for _, c := range tableColumns {
+		if _, ok := rows[0][c]; ok {
+			columns = append(columns, c)
+		}
+	}
+	insert := "INSERT"
+	if t.appendOnly {
+		insert = "INSERT OR REPLACE"
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("%s INTO %s (%s) VALUES (%s)", insert,
+		t.name, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")))
+	if err != nil {
+		return fmt.Errorf("failed to prepare %s restore: %w", t.name, err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, row := range rows {
+		if job, ok := row["job_id"].(int64); ok && !jobs[job] {
+			if t.jobRequired {
+				continue
+			}
+			row["job_id"] = nil
+		}
+		args := make([]any, len(columns))
+		for i, c := range columns {
+			args[i] = row[c]
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", t.name, err)
+		}
+	}
+	return nil
+}
+
+// CompareSuiteSnapshots compares snapshot id with snapshot otherID, or with the suite's
+// current state when otherID is 0
+func CompareSuiteSnapshots(id, otherID int) (*SnapshotDiff, error) {
+	from, err := loadSnapshot(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var to snapshotData
+	var label string
+	if otherID == 0 {
+		if to, err = captureSuite(db, from.suiteID); err != nil {
+			return nil, err
+		}
+		label = "Current state"
+	} else {
+		other, err := loadSnapshot(db, otherID)
+		if err != nil {
+			return nil, err
+		}
+		to, label = other.data, snapshotLabel(other)
+	}
+	return diffSnapshots(snapshotLabel(from), label, from.data, to), nil
+}
+
+func snapshotLabel(s *snapshotRecord) string {
+	return fmt.Sprintf("%s (%s)", s.name, s.createdAt.Local().Format("2006-01-02 15:04"))
+}
+
+// snapshotGrid is the part of a snapshot a comparison looks at
+type snapshotGrid struct {
+	prompts []string // Texts in display order
+	models  []string
+	scores  map[string]map[string]int // Model name to prompt text to score
+}
+
+func gridOf(data snapshotData) snapshotGrid {
+	prompts := append([]map[string]any(nil), data["prompts"]...)
+	sort.SliceStable(prompts, func(i, j int) bool {
+		return snapshotInt(prompts[i]["display_order"]) < snapshotInt(prompts[j]["display_order"])
+	})
+
+	g := snapshotGrid{scores: map[string]map[string]int{}}
+	promptText := map[int]string{}
+	for _, p := range prompts {
+		text, _ := p["text"].(string)
+		promptText[snapshotInt(p["id"])] = text
+		g.prompts = append(g.prompts, text)
+	}
+	modelName := map[int]string{}
+	for _, m := range data["models"] {
+		name, _ := m["name"].(string)
+		modelName[snapshotInt(m["id"])] = name
+		g.models = append(g.models, name)
+		g.scores[name] = map[string]int{}
+	}
+	sort.Strings(g.models)
+	for _, s := range data["scores"] {
+		model, ok := modelName[snapshotInt(s["model_id"])]
+		prompt, found := promptText[snapshotInt(s["prompt_id"])]
+		if ok && found {
+			g.scores[model][prompt] = snapshotInt(s["score"])
+		}
+	}
+	return g
+}
+
+func diffSnapshots(fromLabel, toLabel string, fromData, toData snapshotData) *SnapshotDiff {
+	from, to := gridOf(fromData), gridOf(toData)
+	diff := &SnapshotDiff{From: fromLabel, To: toLabel}
+
+	fromPrompts, toPrompts := map[string]bool{}, map[string]bool{}
+	for _, p := range from.prompts {
+		fromPrompts[p] = true
+	}
+	for _, p := range to.prompts {
+		toPrompts[p] = true
+		if !fromPrompts[p] {
+			diff.PromptsAdded = append(diff.PromptsAdded, p)
+		}
+	}
+	for _, p := range from.prompts {
+		if !toPrompts[p] {
+			diff.PromptsRemoved = append(diff.PromptsRemoved, p)
+		}
+	}
+
+	for _, m := range to.models {
+		if _, ok := from.scores[m]; !ok {
+			diff.ModelsAdded = append(diff.ModelsAdded, m)
+		}
+	}
+	for _, m := range from.models {
+		if _, ok := to.scores[m]; !ok {
+			diff.ModelsRemoved = append(diff.ModelsRemoved, m)
+		}
+	}
+
+	models := append(append([]string(nil), from.models...), diff.ModelsAdded...)
+	sort.Strings(models)
+	for _, m := range models {
+		total := SnapshotModelTotal{Model: m}
+		for _, score := range from.scores[m] {
+			total.From += score
+		}
+		for _, score := range to.scores[m] {
+			total.To += score
+		}
+		diff.Totals = append(diff.Totals, total)
+
+		_, inFrom := from.scores[m]
+		_, inTo := to.scores[m]
+		if !inFrom || !inTo {
+			continue
+		}
+		for _, p := range to.prompts {
+			if fromPrompts[p] && from.scores[m][p] != to.scores[m][p] {
+				diff.Cells = append(diff.Cells, SnapshotScoreChange{Model: m, Prompt: p, From: from.scores[m][p], To: to.scores[m][p]})
+			}
+		}
+	}
+	return diff
+}
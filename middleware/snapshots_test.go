@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuiteSnapshot_RestoreBringsBackTheSuite(t *testing.T) {
+	defer setupScoreHistoryTest(t)()
+
+	if _, err := db.Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all');
+		INSERT INTO model_responses (model_id, prompt_id, response_text) SELECT id, (SELECT MIN(id) FROM prompts), 'answer' FROM models;
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score)
+		SELECT 1, id, (SELECT MIN(id) FROM prompts), 'claude', 20 FROM models;
+	`); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+	if err := SetSuiteSetting("default", SuiteSettingScoreScale, ScoreScaleFifths); err != nil {
+		t.Fatalf("SetSuiteSetting failed: %v", err)
+	}
+
+	id, err := CreateSuiteSnapshot("default", " before cleanup ", false)
+	if err != nil {
+		t.Fatalf("CreateSuiteSnapshot failed: %v", err)
+	}
+	if _, err := CreateSuiteSnapshot("default", "  ", false); err == nil {
+		t.Error("expected an unnamed snapshot to be rejected")
+	}
+
+	// Wreck the suite: a prompt and the model go, along with their responses and judge history
+	if err := WritePromptSuite("default", []Prompt{{Text: "two"}, {Text: "three"}}); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	if err := WriteResults("default", map[string]Result{"mistral": {Scores: []int{100, 0}}}); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+
+	diff, err := CompareSuiteSnapshots(id, 0)
+	if err != nil {
+		t.Fatalf("CompareSuiteSnapshots failed: %v", err)
+	}
+	if !reflect.DeepEqual(diff.PromptsAdded, []string{"three"}) || !reflect.DeepEqual(diff.PromptsRemoved, []string{"one"}) {
+		t.Errorf("expected prompt three added and one removed, got %+v / %+v", diff.PromptsAdded, diff.PromptsRemoved)
+	}
+	if !reflect.DeepEqual(diff.ModelsAdded, []string{"mistral"}) || !reflect.DeepEqual(diff.ModelsRemoved, []string{"llama"}) {
+		t.Errorf("expected mistral added and llama removed, got %+v / %+v", diff.ModelsAdded, diff.ModelsRemoved)
+	}
+	if diff.To != "Current state" || !strings.HasPrefix(diff.From, "before cleanup (") {
+		t.Errorf("expected the snapshot compared with the current state, got %q / %q", diff.From, diff.To)
+	}
+
+	if err := RestoreSuiteSnapshot(id); err != nil {
+		t.Fatalf("RestoreSuiteSnapshot failed: %v", err)
+	}
+
+	if results := ReadResults(); len(results) != 1 || !reflect.DeepEqual(results["llama"].Scores, []int{20, 40}) {
+		t.Errorf("expected llama back with its scores, got %+v", results)
+	}
+	prompts, _ := ReadPromptSuite("default")
+	if len(prompts) != 2 || prompts[0].Text != "one" || prompts[1].Text != "two" {
+		t.Errorf("expected prompts one and two back in order, got %+v", prompts)
+	}
+	var responses, history int
+	_ = db.QueryRow("SELECT COUNT(*) FROM model_responses").Scan(&responses)
+	_ = db.QueryRow("SELECT COUNT(*) FROM evaluation_history WHERE job_id = 1").Scan(&history)
+	if responses != 1 || history != 1 {
+		t.Errorf("expected the response and judge history back, got %d and %d", responses, history)
+	}
+	if entries := scoreHistoryCell(t, 0); len(entries) != 2 || entries[0].Source != ScoreSourceRestore || !scoreIs(entries[0].NewScore, 20) {
+		t.Errorf("expected the restore recorded on top of the restored history, got %+v", entries)
+	}
+
+	snapshots, err := ListSuiteSnapshots("default")
+	if err != nil {
+		t.Fatalf("ListSuiteSnapshots failed: %v", err)
+	}
+	if len(snapshots) != 2 || !snapshots[0].Auto || snapshots[0].Name != `Before restoring "before cleanup"` {
+		t.Fatalf("expected the replaced state kept as an automatic snapshot, got %+v", snapshots)
+	}
+	if s := snapshots[1]; s.Name != "before cleanup" || s.Auto || s.Prompts != 2 || s.Models != 1 || s.Scores != 2 || s.Responses != 1 {
+		t.Errorf("expected the named snapshot with its counts, got %+v", s)
+	}
+
+	// Restoring the automatic snapshot undoes the restore
+	if err := RestoreSuiteSnapshot(snapshots[0].ID); err != nil {
+		t.Fatalf("RestoreSuiteSnapshot failed: %v", err)
+	}
+	if results := ReadResults(); len(results) != 1 || !reflect.DeepEqual(results["mistral"].Scores, []int{100, 0}) {
+		t.Errorf("expected the wrecked state back, got %+v", results)
+	}
+}
+
+func TestSuiteSnapshot_DropsRowsOfDeletedJobs(t *testing.T) {
+	defer setupScoreHistoryTest(t)()
+
+	if _, err := db.Exec(`
+		INSERT INTO evaluation_jobs (id, suite_id, job_type) VALUES (1, 1, 'all');
+		INSERT INTO evaluation_history (job_id, model_id, prompt_id, judge_name, judge_score)
+		SELECT 1, id, (SELECT MIN(id) FROM prompts), 'claude', 20 FROM models;
+		UPDATE score_history SET job_id = 1;
+	`); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+	id, err := CreateSuiteSnapshot("default", "with job", false)
+	if err != nil {
+		t.Fatalf("CreateSuiteSnapshot failed: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM evaluation_history; DELETE FROM evaluation_jobs"); err != nil {
+		t.Fatalf("failed to delete job: %v", err)
+	}
+
+	if err := RestoreSuiteSnapshot(id); err != nil {
+		t.Fatalf("RestoreSuiteSnapshot failed: %v", err)
+	}
+	var history, withJob, changes int
+	_ = db.QueryRow("SELECT COUNT(*) FROM evaluation_history").Scan(&history)
+	_ = db.QueryRow("SELECT COUNT(*) FROM score_history WHERE job_id IS NOT NULL").Scan(&withJob)
+	_ = db.QueryRow("SELECT COUNT(*) FROM score_history").Scan(&changes)
+	if history != 0 || withJob != 0 || changes != 2 {
+		t.Errorf("expected judge history dropped and score changes kept without their job, got %d, %d, %d", history, withJob, changes)
+	}
+}
+
+func TestSuiteSnapshot_PrunesAutoSnapshots(t *testing.T) {
+	defer setupScoreHistoryTest(t)()
+
+	if _, err := CreateSuiteSnapshot("default", "keep", false); err != nil {
+		t.Fatalf("CreateSuiteSnapshot failed: %v", err)
+	}
+	for i := 0; i < maxAutoSnapshots+3; i++ {
+		if _, err := CreateSuiteSnapshot("default", "Before reset", true); err != nil {
+			t.Fatalf("CreateSuiteSnapshot failed: %v", err)
+		}
+	}
+
+	snapshots, err := ListSuiteSnapshots("default")
+	if err != nil {
+		t.Fatalf("ListSuiteSnapshots failed: %v", err)
+	}
+	if len(snapshots) != maxAutoSnapshots+1 || snapshots[len(snapshots)-1].Name != "keep" {
+		t.Errorf("expected %d automatic snapshots and the named one, got %d", maxAutoSnapshots, len(snapshots))
+	}
+
+	if err := DeleteSuiteSnapshot(snapshots[0].ID); err != nil {
+		t.Fatalf("DeleteSuiteSnapshot failed: %v", err)
+	}
+	if err := DeleteSuiteSnapshot(snapshots[0].ID); err == nil {
+		t.Error("expected an error deleting a missing snapshot")
+	}
+	if err := RestoreSuiteSnapshot(snapshots[0].ID); err == nil {
+		t.Error("expected an error restoring a missing snapshot")
+	}
+}
@@ -106,6 +106,7 @@ func BroadcastResults() {
 	var orderedPrompts []struct {
 		Index       int    `json:"index"`
 		Text        string `json:"text"`
+		Type        string `json:"type"`
 		ProfileID   string `json:"profileId"`
 		ProfileName string `json:"profileName"`
 	}
@@ -153,11 +154,13 @@ func BroadcastResults() {
 		orderedPrompts = append(orderedPrompts, struct {
 			Index       int    `json:"index"`
 			Text        string `json:"text"`
+			Type        string `json:"type"`
 			ProfileID   string `json:"profileId"`
 			ProfileName string `json:"profileName"`
 		}{
 			Index:       i,
 			Text:        prompt.Text,
+			Type:        prompt.Type,
 			ProfileID:   group.ID,
 			ProfileName: profileName,
 		})
@@ -195,7 +198,7 @@ func BroadcastResults() {
 			Results:         results,
 			Models:          models,
 			TotalScores:     modelTotalScores,
-			PassPercentages: calculatePassPercentages(results, len(prompts)),
+			PassPercentages: calculatePassPercentages(results, len(prompts), SuiteScoreScale(suiteName)),
 			Prompts:         promptsToStringArray(prompts),
 			SuiteName:       suiteName,
 			ProfileGroups:   profileGroups,
@@ -223,14 +226,14 @@ func promptsToStringArray(prompts []Prompt) []string {
 	return promptsTexts
 }
 
-func calculatePassPercentages(results map[string]Result, promptCount int) map[string]float64 {
+func calculatePassPercentages(results map[string]Result, promptCount int, scale ScoreScale) map[string]float64 {
 	passPercentages := make(map[string]float64)
 	for model, result := range results {
 		totalScore := 0
 		for _, score := range result.Scores {
 			totalScore += score
 		}
-		passPercentages[model] = float64(totalScore) / float64(promptCount*100) * 100
+		passPercentages[model] = float64(totalScore) / float64(scale.MaxTotal(promptCount)) * 100
 	}
 	return passPercentages
 }
@@ -71,7 +71,7 @@ func TestCalculatePassPercentages(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := calculatePassPercentages(tt.results, tt.promptCount)
+			got := calculatePassPercentages(tt.results, tt.promptCount, ResolveScoreScale(""))
 
 			if len(got) != len(tt.want) {
 				t.Errorf("expected %d results, got %d", len(tt.want), len(got))
@@ -91,7 +91,7 @@ func TestCalculatePassPercentages_ZeroPrompts(t *testing.T) {
 		"Model A": {Scores: []int{}},
 	}
 
-	got := calculatePassPercentages(results, 0)
+	got := calculatePassPercentages(results, 0, ResolveScoreScale(""))
 
 	// With zero prompts, percentage is NaN due to division by zero
 	if !math.IsNaN(got["Model A"]) {
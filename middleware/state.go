@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 )
@@ -12,18 +13,70 @@ var (
 )
 
 type Prompt struct {
-	Text     string `json:"text"`
-	Solution string `json:"solution"`
-	Profile  string `json:"profile"`
+	ID            int               `json:"-"` // Row of a prompt read from a suite; 0 for a new prompt
+	Text          string            `json:"text"`
+	Solution      string            `json:"solution"`
+	Profile       string            `json:"profile"`
+	Type          string            `json:"type,omitempty"`           // Kind of prompt, which decides how it is evaluated ("" = DefaultPromptType)
+	Checker       string            `json:"checker,omitempty"`        // Deterministic checker scoring the prompt locally ("" = judges)
+	CheckerConfig string            `json:"checker_config,omitempty"` // Checker option, e.g. a regex or numeric tolerance
+	Language      string            `json:"language,omitempty"`       // Language of the code the hidden tests run
+	Tests         []TestCase        `json:"tests,omitempty"`          // Hidden tests; never sent to the models or judges
+	Rubric        []RubricCriterion `json:"rubric,omitempty"`         // Criteria the judges score separately; overrides the profile's
+	Turns         []Turn            `json:"turns,omitempty"`          // Earlier turns of a conversation prompt; Text is the final user message
 }
 
+// DefaultPromptType is the type of prompts that do not name one
+const DefaultPromptType = "objective"
+
+// TestCase is a hidden test for a programming prompt. The test passes when the
+// program exits 0 and, if Expected is set, prints it (surrounding whitespace ignored).
+type TestCase struct {
+	Name     string `json:"name,omitempty"`
+	Input    string `json:"input,omitempty"`    // Fed to the program on stdin
+	Expected string `json:"expected,omitempty"` // Expected stdout
+	Code     string `json:"code,omitempty"`     // Test code appended to the program (a separate file in Go)
+}
+
+// Result is a model's row of grid scores. Scored marks the cells that hold a score,
+// which are stored as rows of the scores table; the other cells are unscored. A Result
+// without Scored, such as one from an export that predates it, has zero for unscored.
 type Result struct {
-	Scores []int `json:"scores"`
+	Scores []int  `json:"scores"`
+	Scored []bool `json:"scored,omitempty"`
+}
+
+// IsScored reports whether the cell at index i holds a score
+func (r Result) IsScored(i int) bool {
+	if i < 0 || i >= len(r.Scores) {
+		return false
+	}
+	if r.Scored == nil {
+		return r.Scores[i] != 0
+	}
+	return i < len(r.Scored) && r.Scored[i]
+}
+
+// SetScore scores the cell at index i, growing the row to reach it
+func (r *Result) SetScore(i, score int) {
+	if n := max(len(r.Scores), i+1); r.Scored == nil || len(r.Scored) < n {
+		scored := make([]bool, n)
+		for j := range r.Scores {
+			scored[j] = r.IsScored(j)
+		}
+		r.Scored = scored
+	}
+	for len(r.Scores) <= i {
+		r.Scores = append(r.Scores, 0)
+	}
+	r.Scores[i] = score
+	r.Scored[i] = true
 }
 
 type Profile struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Rubric      []RubricCriterion `json:"rubric,omitempty"` // Default rubric of the profile's prompts
 }
 
 // Read profiles from database for current suite
@@ -46,7 +99,7 @@ func ReadProfileSuite(suiteName string) ([]Profile, error) {
 		return nil, fmt.Errorf("failed to get suite ID: %w", err)
 	}
 
-	rows, err := db.Query("SELECT name, description FROM profiles WHERE suite_id = ? ORDER BY id", suiteID)
+	rows, err := db.Query("SELECT name, description, rubric FROM profiles WHERE suite_id = ? ORDER BY id", suiteID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query profiles: %w", err)
 	}
@@ -55,9 +108,13 @@ func ReadProfileSuite(suiteName string) ([]Profile, error) {
 	var profiles []Profile
 	for rows.Next() {
 		var p Profile
-		if err := rows.Scan(&p.Name, &p.Description); err != nil {
+		var rubric string
+		if err := rows.Scan(&p.Name, &p.Description, &rubric); err != nil {
 			return nil, fmt.Errorf("failed to scan profile: %w", err)
 		}
+		if p.Rubric, err = ParseRubric(rubric); err != nil {
+			log.Printf("Warning: ignoring unreadable rubric for profile %q: %v", p.Name, err)
+		}
 		profiles = append(profiles, p)
 	}
 
@@ -90,14 +147,18 @@ func WriteProfileSuite(suiteName string, profiles []Profile) error {
 
 	// Insert new profiles
 	if len(profiles) > 0 {
-		stmt, err := tx.Prepare("INSERT INTO profiles (name, description, suite_id) VALUES (?, ?, ?)")
+		stmt, err := tx.Prepare("INSERT INTO profiles (name, description, suite_id, rubric) VALUES (?, ?, ?, ?)")
 		if err != nil {
 			return fmt.Errorf("failed to prepare profile insert: %w", err)
 		}
 		defer func() { _ = stmt.Close() }()
 
 		for _, profile := range profiles {
-			_, err = stmt.Exec(profile.Name, profile.Description, suiteID)
+			rubric, err := encodeRubric(profile.Rubric)
+			if err != nil {
+				return err
+			}
+			_, err = stmt.Exec(profile.Name, profile.Description, suiteID, rubric)
 			if err != nil {
 				return fmt.Errorf("failed to insert profile: %w", err)
 			}
@@ -166,8 +227,9 @@ func ReadResults() map[string]Result {
 			continue
 		}
 
-		// Initialize scores array
+		// Initialize scores array; cells without a row stay unscored
 		scores := make([]int, promptCount)
+		scored := make([]bool, promptCount)
 
 		// Get scores for this model
 		scoreQuery := `
@@ -192,11 +254,12 @@ func ReadResults() map[string]Result {
 
 			if promptOrder >= 0 && promptOrder < promptCount {
 				scores[promptOrder] = score
+				scored[promptOrder] = true
 			}
 		}
 		_ = scoreRows.Close()
 
-		results[modelName] = Result{Scores: scores}
+		results[modelName] = Result{Scores: scores, Scored: scored}
 	}
 
 	return results
@@ -211,7 +274,8 @@ func ReadPromptSuite(suiteName string) ([]Prompt, error) {
 
 	// Query to get prompts with profile names - ensure distinct results
 	query := `
-	SELECT p.text, p.solution, COALESCE(pr.name, '') as profile_name, p.display_order
+	SELECT p.id, p.text, p.solution, COALESCE(pr.name, '') as profile_name, p.type, p.display_order, p.checker, p.checker_config,
+	       p.language, p.test_cases, p.rubric
 	FROM prompts p
 	LEFT JOIN profiles pr ON p.profile_id = pr.id
 	WHERE p.suite_id = ?
@@ -225,18 +289,30 @@ func ReadPromptSuite(suiteName string) ([]Prompt, error) {
 	defer func() { _ = rows.Close() }()
 
 	var prompts []Prompt
+	var promptIDs []int
 	seenTexts := make(map[string]bool) // Track unique prompts by text content
 
 	for rows.Next() {
 		var p Prompt
-		var displayOrder int
-		if err := rows.Scan(&p.Text, &p.Solution, &p.Profile, &displayOrder); err != nil {
+		var id, displayOrder int
+		var tests, rubric string
+		if err := rows.Scan(&id, &p.Text, &p.Solution, &p.Profile, &p.Type, &displayOrder, &p.Checker, &p.CheckerConfig, &p.Language, &tests, &rubric); err != nil {
 			return nil, fmt.Errorf("failed to scan prompt: %w", err)
 		}
+		if tests != "" {
+			if err := json.Unmarshal([]byte(tests), &p.Tests); err != nil {
+				log.Printf("Warning: ignoring unreadable test cases for prompt %q: %v", p.Text[:min(20, len(p.Text))], err)
+			}
+		}
+		if p.Rubric, err = ParseRubric(rubric); err != nil {
+			log.Printf("Warning: ignoring unreadable rubric for prompt %q: %v", p.Text[:min(20, len(p.Text))], err)
+		}
 
 		// Ensure we don't add duplicates
+		p.ID = id
 		if !seenTexts[p.Text] {
 			prompts = append(prompts, p)
+			promptIDs = append(promptIDs, id)
 			seenTexts[p.Text] = true
 		} else {
 			log.Printf("Warning: Skipped duplicate prompt with text: %s", p.Text[:min(20, len(p.Text))])
@@ -248,9 +324,32 @@ func ReadPromptSuite(suiteName string) ([]Prompt, error) {
 		return nil, fmt.Errorf("error iterating prompt rows: %w", err)
 	}
 
+	turns, err := readSuiteTurns(suiteID)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range promptIDs {
+		prompts[i].Turns = turns[id]
+	}
+
 	return prompts, nil
 }
 
+// GetPromptID returns the ID of the prompt at a position of a suite's prompt list
+func GetPromptID(suiteName string, index int) (int, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	var promptID int
+	err = db.QueryRow("SELECT id FROM prompts WHERE suite_id = ? AND display_order = ?", suiteID, index).Scan(&promptID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get prompt %d: %w", index, err)
+	}
+	return promptID, nil
+}
+
 // min returns the smaller of two integers
 func min(a, b int) int {
 	if a < b {
@@ -271,53 +370,149 @@ func WritePromptSuite(suiteName string, prompts []Prompt) error {
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
+	defer func() { _ = tx.Rollback() }()
+
+	// Prompts keep their rows, and with them their scores, responses and history: a
+	// prompt read from the suite by its ID, one without an ID, such as an imported
+	// prompt, by its text. Rows no prompt claims are deleted.
+	existing, err := suitePromptRows(tx, suiteID)
+	if err != nil {
+		return err
+	}
+	rowIDs := make([]int, len(prompts))
+	claimed := make(map[int]bool)
+	for i, prompt := range prompts {
+		if _, ok := existing[prompt.ID]; ok && prompt.ID != 0 && !claimed[prompt.ID] {
+			rowIDs[i] = prompt.ID
+			claimed[prompt.ID] = true
 		}
-	}()
+	}
+	for i, prompt := range prompts {
+		if rowIDs[i] != 0 {
+			continue
+		}
+		for id, text := range existing {
+			if text == prompt.Text && !claimed[id] {
+				rowIDs[i] = id
+				claimed[id] = true
+				break
+			}
+		}
+	}
+	for id := range existing {
+		if claimed[id] {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM prompts WHERE id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete prompt: %w", err)
+		}
+	}
 
-	// Delete existing prompts for this suite
-	_, err = tx.Exec("DELETE FROM prompts WHERE suite_id = ?", suiteID)
+	if len(prompts) == 0 {
+		return tx.Commit()
+	}
+	insertStmt, err := tx.Prepare(`
+		INSERT INTO prompts (text, solution, profile_id, suite_id, display_order, type, checker, checker_config, language, test_cases, rubric)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare prompt insert: %w", err)
+	}
+	defer func() { _ = insertStmt.Close() }()
+	updateStmt, err := tx.Prepare(`
+		UPDATE prompts SET text = ?, solution = ?, profile_id = ?, display_order = ?, type = ?, checker = ?,
+			checker_config = ?, language = ?, test_cases = ?, rubric = ?
+		WHERE id = ?
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to delete prompts: %w", err)
+		return fmt.Errorf("failed to prepare prompt update: %w", err)
 	}
+	defer func() { _ = updateStmt.Close() }()
 
-	// Insert new prompts
-	if len(prompts) > 0 {
-		stmt, err := tx.Prepare(`
-		INSERT INTO prompts (text, solution, profile_id, suite_id, display_order) 
-		VALUES (?, ?, ?, ?, ?)
-		`)
-		if err != nil {
-			return fmt.Errorf("failed to prepare prompt insert: %w", err)
+	for i, prompt := range prompts {
+		// Get profile ID if a profile is specified
+		var profileID sql.NullInt64
+		if prompt.Profile != "" {
+			id, exists, err := GetProfileID(prompt.Profile, suiteID)
+			if err != nil {
+				return fmt.Errorf("failed to get profile ID: %w", err)
+			}
+			if exists {
+				profileID.Int64 = int64(id)
+				profileID.Valid = true
+			}
 		}
-		defer func() { _ = stmt.Close() }()
 
-		for i, prompt := range prompts {
-			// Get profile ID if a profile is specified
-			var profileID sql.NullInt64
-			if prompt.Profile != "" {
-				id, exists, err := GetProfileID(prompt.Profile, suiteID)
-				if err != nil {
-					return fmt.Errorf("failed to get profile ID: %w", err)
-				}
-				if exists {
-					profileID.Int64 = int64(id)
-					profileID.Valid = true
-				}
+		tests := ""
+		if len(prompt.Tests) > 0 {
+			data, err := json.Marshal(prompt.Tests)
+			if err != nil {
+				return fmt.Errorf("failed to encode test cases: %w", err)
 			}
+			tests = string(data)
+		}
 
-			_, err = stmt.Exec(prompt.Text, prompt.Solution, profileID, suiteID, i)
+		rubric, err := encodeRubric(prompt.Rubric)
+		if err != nil {
+			return err
+		}
+
+		promptType := prompt.Type
+		if promptType == "" {
+			promptType = DefaultPromptType
+		}
+
+		promptID := int64(rowIDs[i])
+		if promptID != 0 {
+			_, err := updateStmt.Exec(prompt.Text, prompt.Solution, profileID, i, promptType, prompt.Checker, prompt.CheckerConfig,
+				prompt.Language, tests, rubric, promptID)
+			if err != nil {
+				return fmt.Errorf("failed to update prompt: %w", err)
+			}
+			if _, err := tx.Exec("DELETE FROM prompt_turns WHERE prompt_id = ?", promptID); err != nil {
+				return fmt.Errorf("failed to delete prompt turns: %w", err)
+			}
+		} else {
+			result, err := insertStmt.Exec(prompt.Text, prompt.Solution, profileID, suiteID, i, promptType, prompt.Checker,
+				prompt.CheckerConfig, prompt.Language, tests, rubric)
 			if err != nil {
 				return fmt.Errorf("failed to insert prompt: %w", err)
 			}
+			if len(prompt.Turns) == 0 {
+				continue
+			}
+			if promptID, err = lastInsertID(result); err != nil {
+				return fmt.Errorf("failed to get prompt ID: %w", err)
+			}
+		}
+		if err := writePromptTurns(tx, promptID, prompt.Turns); err != nil {
+			return err
 		}
 	}
 
 	return tx.Commit()
 }
 
+// suitePromptRows returns the text of each of a suite's prompts by ID
+func suitePromptRows(tx *sql.Tx, suiteID int) (map[int]string, error) {
+	rows, err := tx.Query("SELECT id, text FROM prompts WHERE suite_id = ?", suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	texts := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var text string
+		if err := rows.Scan(&id, &text); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt: %w", err)
+		}
+		texts[id] = text
+	}
+	return texts, rows.Err()
+}
+
 // List all prompt suites
 func ListPromptSuites() ([]string, error) {
 	return ListSuites()
@@ -359,8 +554,15 @@ func GetCurrentSuiteName() string {
 	return name
 }
 
-// Write results to database
+// Write results to database, recording hand edits in the score history
 func WriteResults(suiteName string, results map[string]Result) error {
+	return WriteResultsWithChange(suiteName, results, ScoreChange{Source: ScoreSourceManual})
+}
+
+// WriteResultsWithChange writes results to the database. Only cells whose score
+// changed are written, and each of them is recorded in the score history with change
+// as its provenance.
+func WriteResultsWithChange(suiteName string, results map[string]Result, change ScoreChange) error {
 	suiteID, err := GetSuiteID(suiteName)
 	if err != nil {
 		return fmt.Errorf("failed to get suite ID: %w", err)
@@ -371,11 +573,7 @@ func WriteResults(suiteName string, results map[string]Result) error {
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
-		}
-	}()
+	defer func() { _ = tx.Rollback() }()
 
 	// Get all prompt IDs for this suite
 	promptRows, err := tx.Query("SELECT id FROM prompts WHERE suite_id = ? ORDER BY display_order", suiteID)
@@ -425,14 +623,41 @@ func WriteResults(suiteName string, results map[string]Result) error {
 		}
 	}
 
-	// Clear existing scores for this suite
-	_, err = tx.Exec(`
-		DELETE FROM scores 
-		WHERE model_id IN (SELECT id FROM models WHERE suite_id = ?)
+	scoreStmt, err := tx.Prepare(`
+		INSERT INTO scores (model_id, prompt_id, score) VALUES (?, ?, ?)
+		ON CONFLICT(model_id, prompt_id) DO UPDATE SET score = excluded.score
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare score insert: %w", err)
+	}
+	defer func() { _ = scoreStmt.Close() }()
+	clearStmt, err := tx.Prepare("DELETE FROM scores WHERE model_id = ? AND prompt_id = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare score delete: %w", err)
+	}
+	defer func() { _ = clearStmt.Close() }()
+
+	// Read the suite's current scores so unchanged cells can be left alone
+	type cell struct{ modelID, promptID int }
+	current := make(map[cell]int)
+	scoreRows, err := tx.Query(`
+		SELECT s.model_id, s.prompt_id, s.score FROM scores s
+		JOIN models m ON m.id = s.model_id
+		WHERE m.suite_id = ?
 	`, suiteID)
 	if err != nil {
-		return fmt.Errorf("failed to delete scores: %w", err)
+		return fmt.Errorf("failed to query scores: %w", err)
+	}
+	for scoreRows.Next() {
+		var c cell
+		var score int
+		if err := scoreRows.Scan(&c.modelID, &c.promptID, &score); err != nil {
+			_ = scoreRows.Close()
+			return fmt.Errorf("failed to scan score: %w", err)
+		}
+		current[c] = score
 	}
+	_ = scoreRows.Close()
 
 	// Process each model
 	for modelName, result := range results {
@@ -454,23 +679,36 @@ func WriteResults(suiteName string, results map[string]Result) error {
 			return fmt.Errorf("failed to query model: %w", err)
 		}
 
-		// Insert scores
-		if len(result.Scores) > 0 {
-			scoreStmt, err := tx.Prepare("INSERT INTO scores (model_id, prompt_id, score) VALUES (?, ?, ?)")
-			if err != nil {
-				return fmt.Errorf("failed to prepare score insert: %w", err)
+		// Write the cells that changed. Unscored cells, including prompts past the end
+		// of the scores, lose their row.
+		for i, promptID := range promptIDs {
+			old, exists := current[cell{modelID, promptID}]
+			var before *int
+			if exists {
+				before = &old
 			}
-
-			for i, score := range result.Scores {
-				if i < len(promptIDs) {
-					_, err = scoreStmt.Exec(modelID, promptIDs[i], score)
-					if err != nil {
-						_ = scoreStmt.Close()
-						return fmt.Errorf("failed to insert score: %w", err)
-					}
+			if !result.IsScored(i) {
+				if !exists {
+					continue
+				}
+				if _, err := clearStmt.Exec(modelID, promptID); err != nil {
+					return fmt.Errorf("failed to clear score: %w", err)
 				}
+				if err := recordScoreChange(tx, modelID, promptID, before, nil, change); err != nil {
+					return err
+				}
+				continue
+			}
+			score := result.Scores[i]
+			if exists && old == score {
+				continue
+			}
+			if _, err := scoreStmt.Exec(modelID, promptID, score); err != nil {
+				return fmt.Errorf("failed to insert score: %w", err)
+			}
+			if err := recordScoreChange(tx, modelID, promptID, before, &score, change); err != nil {
+				return err
 			}
-			_ = scoreStmt.Close()
 		}
 	}
 
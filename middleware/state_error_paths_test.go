@@ -274,7 +274,13 @@ func TestReadPromptSuite_ErrorBranches(t *testing.T) {
 			solution TEXT DEFAULT '',
 			profile_id INTEGER,
 			suite_id INTEGER NOT NULL,
-			display_order TEXT
+			type TEXT DEFAULT 'objective',
+			display_order TEXT,
+			checker TEXT DEFAULT '',
+			checker_config TEXT DEFAULT '',
+			language TEXT DEFAULT '',
+			test_cases TEXT DEFAULT '',
+			rubric TEXT DEFAULT ''
 		)`); err != nil {
 			t.Fatalf("create prompts: %v", err)
 		}
@@ -308,7 +314,13 @@ func TestReadPromptSuite_ErrorBranches(t *testing.T) {
 			solution TEXT DEFAULT '',
 			profile_id INTEGER,
 			suite_id INTEGER NOT NULL,
-			display_order INTEGER DEFAULT 0
+			type TEXT DEFAULT 'objective',
+			display_order INTEGER DEFAULT 0,
+			checker TEXT DEFAULT '',
+			checker_config TEXT DEFAULT '',
+			language TEXT DEFAULT '',
+			test_cases TEXT DEFAULT '',
+			rubric TEXT DEFAULT ''
 		)`); err != nil {
 			t.Fatalf("create prompts: %v", err)
 		}
@@ -346,7 +358,7 @@ func TestWritePromptSuite_ErrorBranches(t *testing.T) {
 		}
 	})
 
-	t.Run("missing prompts table fails delete", func(t *testing.T) {
+	t.Run("missing prompts table fails query", func(t *testing.T) {
 		dbPath, cleanup := setupTestDB(t)
 		defer cleanup()
 
@@ -362,8 +374,8 @@ func TestWritePromptSuite_ErrorBranches(t *testing.T) {
 		if err == nil {
 			t.Fatalf("expected WritePromptSuite to return an error when prompts table is missing")
 		}
-		if !strings.Contains(err.Error(), "failed to delete prompts") {
-			t.Fatalf("expected delete prompts error, got %v", err)
+		if !strings.Contains(err.Error(), "failed to query prompts") {
+			t.Fatalf("expected query prompts error, got %v", err)
 		}
 	})
 
@@ -598,7 +610,7 @@ func TestWriteResults_ErrorBranches(t *testing.T) {
 		}
 	})
 
-	t.Run("missing scores table fails delete scores", func(t *testing.T) {
+	t.Run("missing scores table fails score insert", func(t *testing.T) {
 		dbPath, cleanup := setupTestDB(t)
 		defer cleanup()
 
@@ -614,8 +626,8 @@ func TestWriteResults_ErrorBranches(t *testing.T) {
 		if err == nil {
 			t.Fatalf("expected error when scores table is missing")
 		}
-		if !strings.Contains(err.Error(), "failed to delete scores") {
-			t.Fatalf("expected delete scores error, got %v", err)
+		if !strings.Contains(err.Error(), "failed to prepare score insert") {
+			t.Fatalf("expected prepare score insert error, got %v", err)
 		}
 	})
 
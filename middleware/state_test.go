@@ -179,6 +179,82 @@ func TestWritePromptSuite_WithProfile(t *testing.T) {
 	}
 }
 
+func TestWritePromptSuite_WithChecker(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	prompts := []Prompt{
+		{Text: "Prompt 1", Solution: "3.14", Checker: "numeric", CheckerConfig: "0.01"},
+		{Text: "Prompt 2", Solution: "Solution 2"},
+	}
+	if err := WritePromptSuite("default", prompts); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+
+	readPrompts, err := ReadPromptSuite("default")
+	if err != nil {
+		t.Fatalf("ReadPromptSuite failed: %v", err)
+	}
+	if len(readPrompts) != 2 {
+		t.Fatalf("expected 2 prompts, got %d", len(readPrompts))
+	}
+	if readPrompts[0].Checker != "numeric" || readPrompts[0].CheckerConfig != "0.01" {
+		t.Errorf("expected numeric checker with config 0.01, got %q / %q", readPrompts[0].Checker, readPrompts[0].CheckerConfig)
+	}
+	if readPrompts[1].Checker != "" {
+		t.Errorf("expected no checker on second prompt, got %q", readPrompts[1].Checker)
+	}
+}
+
+func TestWritePromptSuite_WithType(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	if err := WritePromptSuite("default", []Prompt{{Text: "Prompt 1", Type: "translation"}, {Text: "Prompt 2"}}); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	readPrompts, err := ReadPromptSuite("default")
+	if err != nil {
+		t.Fatalf("ReadPromptSuite failed: %v", err)
+	}
+	if len(readPrompts) != 2 || readPrompts[0].Type != "translation" || readPrompts[1].Type != DefaultPromptType {
+		t.Errorf("expected translation and the default type, got %+v", readPrompts)
+	}
+}
+
+func TestWritePromptSuite_WithHiddenTests(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	tests := []TestCase{{Name: "adds", Input: "2 3", Expected: "5"}, {Code: "assert add(1, 1) == 2"}}
+	if err := WritePromptSuite("default", []Prompt{{Text: "Add two numbers", Language: "python", Tests: tests}}); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+
+	readPrompts, err := ReadPromptSuite("default")
+	if err != nil {
+		t.Fatalf("ReadPromptSuite failed: %v", err)
+	}
+	if readPrompts[0].Language != "python" || len(readPrompts[0].Tests) != 2 {
+		t.Fatalf("expected python prompt with 2 tests, got %+v", readPrompts[0])
+	}
+	if readPrompts[0].Tests[0] != tests[0] || readPrompts[0].Tests[1] != tests[1] {
+		t.Errorf("expected tests to round-trip, got %+v", readPrompts[0].Tests)
+	}
+}
+
 func TestReadResults_Empty(t *testing.T) {
 	dbPath, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -433,6 +509,33 @@ func TestUpdatePromptsOrder(t *testing.T) {
 	}
 }
 
+func TestGetPromptID(t *testing.T) {
+	dbPath, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(dbPath); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	if err := WritePromptSuite("default", []Prompt{{Text: "Prompt 1"}, {Text: "Prompt 2"}}); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+
+	promptID, err := GetPromptID("default", 1)
+	if err != nil {
+		t.Fatalf("GetPromptID failed: %v", err)
+	}
+	var text string
+	if err := db.QueryRow("SELECT text FROM prompts WHERE id = ?", promptID).Scan(&text); err != nil {
+		t.Fatalf("failed to read prompt: %v", err)
+	}
+	if text != "Prompt 2" {
+		t.Errorf("expected the second prompt, got %q", text)
+	}
+	if _, err := GetPromptID("default", 2); err == nil {
+		t.Error("expected an error past the last prompt")
+	}
+}
+
 func TestReadProfiles(t *testing.T) {
 	dbPath, cleanup := setupTestDB(t)
 	defer cleanup()
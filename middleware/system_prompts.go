@@ -0,0 +1,437 @@
+package middleware
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BundledSystemPromptPattern matches the system prompt files shipped with the repository
+const BundledSystemPromptPattern = "system_prompt_*.xml"
+
+// SystemPrompt is a named entry of the system prompt library with its latest version.
+// Every change of content adds a version; responses and evaluation results record the
+// version they were produced with.
+type SystemPrompt struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	VersionID   int       `json:"version_id"` // ID of the latest version
+	Version     int       `json:"version"`    // Latest version number, starting at 1
+	Content     string    `json:"content"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SystemPromptVersion is one stored revision of a library system prompt
+type SystemPromptVersion struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListSystemPrompts returns every library prompt at its latest version, ordered by name
+func ListSystemPrompts() ([]SystemPrompt, error) {
+	rows, err := db.Query(`
+		SELECT s.id, s.name, s.description, v.id, v.version, v.content, v.created_at
+		FROM system_prompts s
+		JOIN system_prompt_versions v ON v.system_prompt_id = s.id
+		WHERE v.version = (SELECT MAX(version) FROM system_prompt_versions WHERE system_prompt_id = s.id)
+		ORDER BY s.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query system prompts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var prompts []SystemPrompt
+	for rows.Next() {
+		var p SystemPrompt
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.VersionID, &p.Version, &p.Content, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan system prompt: %w", err)
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts, rows.Err()
+}
+
+// GetSystemPrompt returns a library prompt at its latest version, or nil when it does not exist
+func GetSystemPrompt(name string) (*SystemPrompt, error) {
+	var p SystemPrompt
+	err := db.QueryRow(`
+		SELECT s.id, s.name, s.description, v.id, v.version, v.content, v.created_at
+		FROM system_prompts s
+		JOIN system_prompt_versions v ON v.system_prompt_id = s.id
+		WHERE s.name = ?
+		ORDER BY v.version DESC
+		LIMIT 1
+	`, name).Scan(&p.ID, &p.Name, &p.Description, &p.VersionID, &p.Version, &p.Content, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system prompt: %w", err)
+	}
+	return &p, nil
+}
+
+// ListSystemPromptVersions returns the versions of a library prompt, newest first
+func ListSystemPromptVersions(name string) ([]SystemPromptVersion, error) {
+	rows, err := db.Query(`
+		SELECT v.id, s.name, v.version, v.content, v.created_at
+		FROM system_prompt_versions v
+		JOIN system_prompts s ON s.id = v.system_prompt_id
+		WHERE s.name = ?
+		ORDER BY v.version DESC
+	`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query system prompt versions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var versions []SystemPromptVersion
+	for rows.Next() {
+		var v SystemPromptVersion
+		if err := rows.Scan(&v.ID, &v.Name, &v.Version, &v.Content, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan system prompt version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetSystemPromptVersion returns a stored version by ID, or nil when it does not exist
+func GetSystemPromptVersion(id int) (*SystemPromptVersion, error) {
+	var v SystemPromptVersion
+	err := db.QueryRow(`
+		SELECT v.id, s.name, v.version, v.content, v.created_at
+		FROM system_prompt_versions v
+		JOIN system_prompts s ON s.id = v.system_prompt_id
+		WHERE v.id = ?
+	`, id).Scan(&v.ID, &v.Name, &v.Version, &v.Content, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system prompt version: %w", err)
+	}
+	return &v, nil
+}
+
+// SaveSystemPrompt creates a library prompt or updates the one with the same name.
+// New content is stored as the next version; saving unchanged content only updates
+// the description. It returns the number of the prompt's latest version.
+func SaveSystemPrompt(name, description, content string) (int, error) {
+	if name == "" {
+		return 0, fmt.Errorf("system prompt name is required")
+	}
+	if strings.TrimSpace(content) == "" {
+		return 0, fmt.Errorf("system prompt %s has no content", name)
+	}
+
+	tx, err := dbBegin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	_, err = tx.Exec(`
+		INSERT INTO system_prompts (name, description) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET description = excluded.description
+	`, name, description)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save system prompt: %w", err)
+	}
+	var promptID int
+	if err = tx.QueryRow("SELECT id FROM system_prompts WHERE name = ?", name).Scan(&promptID); err != nil {
+		return 0, fmt.Errorf("failed to get system prompt: %w", err)
+	}
+
+	var version int
+	var latest string
+	err = tx.QueryRow(`
+		SELECT version, content FROM system_prompt_versions
+		WHERE system_prompt_id = ?
+		ORDER BY version DESC
+		LIMIT 1
+	`, promptID).Scan(&version, &latest)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get latest system prompt version: %w", err)
+	}
+	if err == sql.ErrNoRows || latest != content {
+		version++
+		_, err = tx.Exec("INSERT INTO system_prompt_versions (system_prompt_id, version, content) VALUES (?, ?, ?)",
+			promptID, version, content)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save system prompt version: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit system prompt: %w", err)
+	}
+	return version, nil
+}
+
+// DeleteSystemPrompt removes a library prompt and its assignments. Prompts whose
+// versions were used by stored responses or evaluation results are kept so the
+// results can still be traced to them.
+func DeleteSystemPrompt(name string) error {
+	var promptID, used int
+	if err := db.QueryRow("SELECT id FROM system_prompts WHERE name = ?", name).Scan(&promptID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("system prompt %q not found", name)
+		}
+		return fmt.Errorf("failed to get system prompt: %w", err)
+	}
+	err := db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM model_responses WHERE system_prompt_version_id IN (SELECT id FROM system_prompt_versions WHERE system_prompt_id = ?1))
+			+ (SELECT COUNT(*) FROM evaluation_results WHERE system_prompt_version_id IN (SELECT id FROM system_prompt_versions WHERE system_prompt_id = ?1))
+	`, promptID).Scan(&used)
+	if err != nil {
+		return fmt.Errorf("failed to check system prompt use: %w", err)
+	}
+	if used > 0 {
+		return fmt.Errorf("system prompt %q was used by %d stored responses or results and cannot be deleted", name, used)
+	}
+
+	if _, err := db.Exec("DELETE FROM system_prompts WHERE id = ?", promptID); err != nil {
+		return fmt.Errorf("failed to delete system prompt: %w", err)
+	}
+	if _, err := db.Exec("DELETE FROM profile_system_prompts WHERE system_prompt_name = ?", name); err != nil {
+		return fmt.Errorf("failed to clear profile system prompts: %w", err)
+	}
+	if _, err := db.Exec("UPDATE model_configs SET system_prompt_name = '' WHERE system_prompt_name = ?", name); err != nil {
+		return fmt.Errorf("failed to clear model system prompts: %w", err)
+	}
+	return nil
+}
+
+// ImportSystemPromptXML stores an XML system prompt in the library under name. The
+// document is kept verbatim, since prompts written for models are often not strictly
+// well-formed (the bundled general prompt has a bare "<200"); it only has to start
+// with an element. Its first <description> element, if any, becomes the prompt's
+// description.
+func ImportSystemPromptXML(name string, data []byte) (int, error) {
+	description, err := xmlDescription(data)
+	if err != nil {
+		return 0, fmt.Errorf("invalid system prompt XML: %w", err)
+	}
+	return SaveSystemPrompt(name, description, strings.TrimSpace(string(data)))
+}
+
+// ImportBundledSystemPrompts imports the system_prompt_<name>.xml files found in dir
+// and returns the names they were stored under
+func ImportBundledSystemPrompts(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, BundledSystemPromptPattern))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no %s files found in %s", BundledSystemPromptPattern, dir)
+	}
+
+	var names []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return names, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		name := SystemPromptNameFromFile(path)
+		if _, err := ImportSystemPromptXML(name, data); err != nil {
+			return names, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// SystemPromptNameFromFile derives a library name from a file name:
+// system_prompt_programming.xml becomes "programming"
+func SystemPromptNameFromFile(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if trimmed := strings.TrimPrefix(name, "system_prompt_"); trimmed != "" {
+		name = trimmed
+	}
+	return name
+}
+
+// xmlDescription checks that data opens with an XML element and returns the text of
+// its first <description> element with whitespace collapsed. Markup errors after the
+// first element end the search instead of failing it.
+func xmlDescription(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var description strings.Builder
+	inDescription, sawElement := false, false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if !sawElement {
+				if errors.Is(err, io.EOF) {
+					err = fmt.Errorf("no root element")
+				}
+				return "", err
+			}
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			sawElement = true
+			inDescription = t.Name.Local == "description"
+		case xml.EndElement:
+			if inDescription {
+				return strings.Join(strings.Fields(description.String()), " "), nil
+			}
+		case xml.CharData:
+			if inDescription {
+				description.Write(t)
+			} else if !sawElement && len(bytes.TrimSpace(t)) > 0 {
+				return "", fmt.Errorf("text before the root element")
+			}
+		}
+	}
+	return "", nil
+}
+
+// GetProfileSystemPrompts returns the system prompts assigned to a suite's profiles, keyed by profile name
+func GetProfileSystemPrompts(suiteName string) (map[string]string, error) {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	rows, err := db.Query("SELECT profile_name, system_prompt_name FROM profile_system_prompts WHERE suite_id = ?", suiteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query profile system prompts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	prompts := make(map[string]string)
+	for rows.Next() {
+		var profile, prompt string
+		if err := rows.Scan(&profile, &prompt); err != nil {
+			return nil, fmt.Errorf("failed to scan profile system prompt: %w", err)
+		}
+		prompts[profile] = prompt
+	}
+	return prompts, rows.Err()
+}
+
+// SetProfileSystemPrompt makes a library prompt the system prompt of a profile's
+// prompts; "" removes the assignment
+func SetProfileSystemPrompt(suiteName, profile, prompt string) error {
+	suiteID, err := GetSuiteID(suiteName)
+	if err != nil {
+		return fmt.Errorf("failed to get suite ID: %w", err)
+	}
+
+	if prompt == "" {
+		_, err = db.Exec("DELETE FROM profile_system_prompts WHERE suite_id = ? AND profile_name = ?", suiteID, profile)
+	} else {
+		_, err = db.Exec(`
+			INSERT INTO profile_system_prompts (suite_id, profile_name, system_prompt_name)
+			VALUES (?, ?, ?)
+			ON CONFLICT(suite_id, profile_name) DO UPDATE SET system_prompt_name = excluded.system_prompt_name
+		`, suiteID, profile, prompt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set profile system prompt: %w", err)
+	}
+	return nil
+}
+
+// ResolvedSystemPrompt is the system prompt a model is given for a prompt
+type ResolvedSystemPrompt struct {
+	Source  string               // "response", "profile", "model" or "inline"; "" when there is none
+	Content string               // Text sent as the system prompt
+	Version *SystemPromptVersion // Library version used; nil for a model's inline prompt
+}
+
+// Sources of a resolved system prompt
+const (
+	SystemPromptSourceResponse = "response" // Recorded when the stored response was generated
+	SystemPromptSourceProfile  = "profile"
+	SystemPromptSourceModel    = "model"
+	SystemPromptSourceInline   = "inline"
+)
+
+// ResolveSystemPrompt returns the system prompt a model is given for a prompt: the
+// library prompt assigned to the prompt's profile, else the one assigned to the
+// model's configuration, else the model's own inline system prompt. Assignments to
+// library prompts that no longer exist are skipped.
+func ResolveSystemPrompt(modelID, promptID int) (*ResolvedSystemPrompt, error) {
+	var profilePrompt, modelPrompt, inline string
+	err := db.QueryRow(`
+		SELECT COALESCE((
+			SELECT ps.system_prompt_name
+			FROM prompts p
+			JOIN profiles pr ON pr.id = p.profile_id
+			JOIN profile_system_prompts ps ON ps.suite_id = p.suite_id AND ps.profile_name = pr.name
+			WHERE p.id = ?
+		), ''),
+		COALESCE((SELECT system_prompt_name FROM model_configs WHERE model_id = ?), ''),
+		COALESCE((SELECT system_prompt FROM model_configs WHERE model_id = ?), '')
+	`, promptID, modelID, modelID).Scan(&profilePrompt, &modelPrompt, &inline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve system prompt: %w", err)
+	}
+
+	for _, candidate := range []struct{ source, name string }{
+		{SystemPromptSourceProfile, profilePrompt},
+		{SystemPromptSourceModel, modelPrompt},
+	} {
+		if candidate.name == "" {
+			continue
+		}
+		sp, err := GetSystemPrompt(candidate.name)
+		if err != nil {
+			return nil, err
+		}
+		if sp != nil {
+			return &ResolvedSystemPrompt{
+				Source:  candidate.source,
+				Content: sp.Content,
+				Version: &SystemPromptVersion{ID: sp.VersionID, Name: sp.Name, Version: sp.Version, Content: sp.Content, CreatedAt: sp.UpdatedAt},
+			}, nil
+		}
+	}
+
+	if inline != "" {
+		return &ResolvedSystemPrompt{Source: SystemPromptSourceInline, Content: inline}, nil
+	}
+	return &ResolvedSystemPrompt{}, nil
+}
+
+// ResponseSystemPrompt returns the system prompt a stored response was produced under:
+// the library version recorded when it was generated, else what the model would be
+// given for the prompt now
+func ResponseSystemPrompt(modelID, promptID int) (*ResolvedSystemPrompt, error) {
+	var versionID sql.NullInt64
+	err := db.QueryRow("SELECT system_prompt_version_id FROM model_responses WHERE model_id = ? AND prompt_id = ?",
+		modelID, promptID).Scan(&versionID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get response system prompt: %w", err)
+	}
+	if versionID.Valid {
+		v, err := GetSystemPromptVersion(int(versionID.Int64))
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			return &ResolvedSystemPrompt{Source: SystemPromptSourceResponse, Content: v.Content, Version: v}, nil
+		}
+	}
+	return ResolveSystemPrompt(modelID, promptID)
+}
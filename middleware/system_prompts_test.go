@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSaveSystemPrompt_Versions(t *testing.T) {
+	defer setupJudgesTest(t)()
+
+	for _, step := range []struct {
+		content string
+		want    int
+	}{
+		{"Be terse.", 1},
+		{"Be terse.", 1}, // Unchanged content keeps the version
+		{"Be terse and polite.", 2},
+	} {
+		version, err := SaveSystemPrompt("terse", "Short answers", step.content)
+		if err != nil || version != step.want {
+			t.Fatalf("saving %q: expected version %d, got %d (%v)", step.content, step.want, version, err)
+		}
+	}
+
+	prompts, err := ListSystemPrompts()
+	if err != nil || len(prompts) != 1 {
+		t.Fatalf("expected one library prompt, got %+v (%v)", prompts, err)
+	}
+	if p := prompts[0]; p.Version != 2 || p.Content != "Be terse and polite." || p.Description != "Short answers" {
+		t.Errorf("expected the latest version listed, got %+v", p)
+	}
+	versions, err := ListSystemPromptVersions("terse")
+	if err != nil || len(versions) != 2 || versions[1].Content != "Be terse." {
+		t.Errorf("expected both versions kept, newest first, got %+v (%v)", versions, err)
+	}
+
+	if _, err := SaveSystemPrompt("", "", "x"); err == nil {
+		t.Error("expected an error for a missing name")
+	}
+	if _, err := SaveSystemPrompt("empty", "", "  "); err == nil {
+		t.Error("expected an error for empty content")
+	}
+}
+
+func TestImportBundledSystemPrompts(t *testing.T) {
+	defer setupJudgesTest(t)()
+
+	names, err := ImportBundledSystemPrompts("..")
+	if err != nil {
+		t.Fatalf("ImportBundledSystemPrompts failed: %v", err)
+	}
+	if strings.Join(names, ",") != "general,programming,translation" {
+		t.Fatalf("expected the three bundled prompts, got %v", names)
+	}
+	general, err := GetSystemPrompt("general")
+	if err != nil || general == nil {
+		t.Fatalf("expected the general prompt stored, got %v", err)
+	}
+	if !strings.HasPrefix(general.Content, "<system_prompt>") || !strings.HasPrefix(general.Description, "You are an advanced AI assistant") {
+		t.Errorf("expected the XML kept verbatim and its description extracted, got %+v", general)
+	}
+
+	// Re-importing unchanged files adds no versions
+	if _, err := ImportBundledSystemPrompts(".."); err != nil {
+		t.Fatalf("re-import failed: %v", err)
+	}
+	if versions, _ := ListSystemPromptVersions("general"); len(versions) != 1 {
+		t.Errorf("expected a single version after re-importing, got %d", len(versions))
+	}
+
+	for _, doc := range []string{"", "plain text", `{"role": "translator"}`} {
+		if _, err := ImportSystemPromptXML("broken", []byte(doc)); err == nil {
+			t.Errorf("expected %q to be rejected", doc)
+		}
+	}
+	if _, err := ImportSystemPromptXML("loose", []byte("<role>keep files small (<200 lines)</role>")); err != nil {
+		t.Errorf("expected loosely written XML prompts to be accepted, got %v", err)
+	}
+	if _, err := ImportBundledSystemPrompts(t.TempDir()); err == nil {
+		t.Error("expected an error when no bundled files exist")
+	}
+	if name := SystemPromptNameFromFile("/tmp/reviewer.xml"); name != "reviewer" {
+		t.Errorf("expected files without the prefix named after the file, got %q", name)
+	}
+}
+
+func TestResolveSystemPrompt(t *testing.T) {
+	defer setupJudgesTest(t)()
+
+	if err := WriteProfileSuite("default", []Profile{{Name: "coding"}}); err != nil {
+		t.Fatalf("WriteProfileSuite failed: %v", err)
+	}
+	if err := WritePromptSuite("default", []Prompt{{Text: "Sort a list", Profile: "coding"}, {Text: "Say hi"}}); err != nil {
+		t.Fatalf("WritePromptSuite failed: %v", err)
+	}
+	if err := WriteResults("default", map[string]Result{"llama": {Scores: []int{0, 0}}}); err != nil {
+		t.Fatalf("WriteResults failed: %v", err)
+	}
+	modelID, _ := GetModelID("default", "llama")
+	var coding, greeting int
+	_ = db.QueryRow("SELECT id FROM prompts WHERE text = 'Sort a list'").Scan(&coding)
+	_ = db.QueryRow("SELECT id FROM prompts WHERE text = 'Say hi'").Scan(&greeting)
+
+	resolved, err := ResolveSystemPrompt(modelID, coding)
+	if err != nil || resolved.Source != "" || resolved.Content != "" {
+		t.Fatalf("expected no system prompt before any is configured, got %+v (%v)", resolved, err)
+	}
+
+	if err := SaveModelConfigByID(ModelConfig{ModelID: modelID, SystemPrompt: "Be brief."}); err != nil {
+		t.Fatalf("SaveModelConfigByID failed: %v", err)
+	}
+	_, _ = SaveSystemPrompt("assistant", "", "You are helpful.")
+	_, _ = SaveSystemPrompt("programming", "", "You are a programmer.")
+
+	tests := []struct {
+		setup   func() error
+		prompt  int
+		source  string
+		content string
+	}{
+		{func() error { return nil }, coding, SystemPromptSourceInline, "Be brief."},
+		{func() error {
+			return SaveModelConfigByID(ModelConfig{ModelID: modelID, SystemPrompt: "Be brief.", SystemPromptName: "assistant"})
+		}, coding, SystemPromptSourceModel, "You are helpful."},
+		{func() error { return SetProfileSystemPrompt("default", "coding", "programming") }, coding, SystemPromptSourceProfile, "You are a programmer."},
+		{func() error { return nil }, greeting, SystemPromptSourceModel, "You are helpful."},
+	}
+	for i, tt := range tests {
+		if err := tt.setup(); err != nil {
+			t.Fatalf("step %d: setup failed: %v", i, err)
+		}
+		resolved, err := ResolveSystemPrompt(modelID, tt.prompt)
+		if err != nil || resolved.Source != tt.source || resolved.Content != tt.content {
+			t.Errorf("step %d: expected %q from %s, got %+v (%v)", i, tt.content, tt.source, resolved, err)
+		}
+	}
+
+	// A generated response keeps the version it was produced with after the prompt changes
+	resolved, _ = ResolveSystemPrompt(modelID, coding)
+	if _, err := db.Exec("INSERT INTO model_responses (model_id, prompt_id, response_text, system_prompt_version_id) VALUES (?, ?, 'sorted', ?)",
+		modelID, coding, resolved.Version.ID); err != nil {
+		t.Fatalf("failed to insert response: %v", err)
+	}
+	_, _ = SaveSystemPrompt("programming", "", "You are a senior programmer.")
+	recorded, err := ResponseSystemPrompt(modelID, coding)
+	if err != nil || recorded.Source != SystemPromptSourceResponse || recorded.Version.Version != 1 || recorded.Content != "You are a programmer." {
+		t.Errorf("expected the recorded first version, got %+v (%v)", recorded, err)
+	}
+	if err := DeleteSystemPrompt("programming"); err == nil || !strings.Contains(err.Error(), "cannot be deleted") {
+		t.Errorf("expected a used system prompt to be kept, got %v", err)
+	}
+
+	// Deleting an unused prompt clears its assignments
+	if err := DeleteSystemPrompt("assistant"); err != nil {
+		t.Fatalf("DeleteSystemPrompt failed: %v", err)
+	}
+	if cfg, _ := GetModelConfigByID(modelID); cfg.SystemPromptName != "" {
+		t.Errorf("expected the model's assignment cleared, got %q", cfg.SystemPromptName)
+	}
+	if resolved, _ := ResolveSystemPrompt(modelID, greeting); resolved.Source != SystemPromptSourceInline {
+		t.Errorf("expected the inline prompt after deleting the model's library prompt, got %+v", resolved)
+	}
+}
@@ -5,6 +5,66 @@ from typing import Dict, List
 import os
 
 
+def format_rubric(rubric: List[Dict] = None) -> str:
+    """
+    Format the rubric section of a judge prompt; empty without a rubric.
+
+    Must match the RUBRIC block of the Go templates in evaluator/prompts.
+    """
+    if not rubric:
+        return ""
+    lines = [
+        "**RUBRIC:**",
+        "Also score each criterion below on the same 0-100 scale. "
+        "Your overall score must be the weighted average of the criterion scores.",
+    ]
+    for criterion in rubric:
+        line = f"- **{criterion['name']}** (weight {criterion['weight']:g})"
+        if criterion.get("descriptor"):
+            line += f": {criterion['descriptor']}"
+        lines.append(line)
+    lines.append("")
+    lines.append('Add a "criteria" object to the JSON mapping each criterion name to its integer score.')
+    return "\n".join(lines) + "\n\n"
+
+
+def format_conversation(turns: List[Dict] = None) -> str:
+    """
+    Format the earlier turns of a conversation prompt; empty for single-turn prompts.
+
+    Must match the CONVERSATION block of the Go templates in evaluator/prompts.
+    """
+    if not turns:
+        return ""
+    lines = ["**CONVERSATION SO FAR:**"]
+    for turn in turns:
+        lines.append(f"[{turn['role']}]")
+        lines.append(turn["content"])
+        lines.append("")
+    lines.append(
+        "The prompt below is the user's latest message in this conversation and the model "
+        "response is the assistant's reply to it. Judge the reply in context: it must stay "
+        "consistent with the earlier turns and keep following the instructions given in them."
+    )
+    return "\n".join(lines) + "\n\n"
+
+
+def format_system_prompt(system_prompt: str = None) -> str:
+    """
+    Format the system prompt the model answered under; empty when it had none.
+
+    Must match the SYSTEM PROMPT block of the Go templates in evaluator/prompts.
+    """
+    if not system_prompt:
+        return ""
+    return (
+        "**SYSTEM PROMPT GIVEN TO THE MODEL:**\n"
+        f"{system_prompt}\n\n"
+        "The model answered with this system prompt in effect. Responses that follow its "
+        "instructions should be preferred over responses that ignore them.\n\n"
+    )
+
+
 class BaseEvaluator(ABC):
     """Base class for all evaluators."""
 
@@ -23,7 +83,15 @@ class BaseEvaluator(ABC):
         with open(template_path, 'r', encoding='utf-8') as f:
             return f.read()
 
-    def format_judge_prompt(self, prompt: str, response: str, solution: str = None) -> str:
+    def format_judge_prompt(
+        self,
+        prompt: str,
+        response: str,
+        solution: str = None,
+        rubric: List[Dict] = None,
+        turns: List[Dict] = None,
+        system_prompt: str = None
+    ) -> str:
         """
         Format the judge prompt with the given parameters.
 
@@ -31,6 +99,9 @@ class BaseEvaluator(ABC):
             prompt: The original prompt given to the model
             response: The model's response to evaluate
             solution: Expected solution (optional, used for objective evaluation)
+            rubric: Weighted criteria to score separately (optional)
+            turns: Earlier turns of a conversation prompt, ending before the prompt (optional)
+            system_prompt: System prompt the model was given (optional)
 
         Returns:
             Formatted prompt string for the judge
@@ -38,7 +109,10 @@ class BaseEvaluator(ABC):
         return self.prompt_template.format(
             prompt=prompt,
             response=response,
-            solution=solution or "N/A"
+            solution=solution or "N/A",
+            rubric=format_rubric(rubric),
+            conversation=format_conversation(turns),
+            system_prompt=format_system_prompt(system_prompt)
         )
 
     @abstractmethod
@@ -48,7 +122,10 @@ class BaseEvaluator(ABC):
         response: str,
         solution: str,
         judges: List[Dict],
-        api_keys: Dict[str, str]
+        api_keys: Dict[str, str],
+        rubric: List[Dict] = None,
+        turns: List[Dict] = None,
+        system_prompt: str = None
     ) -> List[Dict]:
         """
         Evaluate a response using multiple judges.
@@ -59,8 +136,12 @@ class BaseEvaluator(ABC):
             solution: Expected solution (may be None for creative tasks)
             judges: List of judge configurations
             api_keys: Dictionary of API keys by provider
+            rubric: Weighted criteria the judges also score (optional)
+            turns: Earlier turns of a conversation prompt (optional)
+            system_prompt: System prompt the model was given (optional)
 
         Returns:
-            List of judge results with score, confidence, reasoning
+            List of judge results with score, confidence, reasoning and
+            per-criterion scores when a rubric is given
         """
         pass
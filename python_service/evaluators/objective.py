@@ -26,7 +26,10 @@ class ObjectiveEvaluator(BaseEvaluator):
         response: str,
         solution: str,
         judges: List[str],
-        api_keys: Dict[str, str]
+        api_keys: Dict[str, str],
+        rubric: List[Dict] = None,
+        turns: List[Dict] = None,
+        system_prompt: str = None
     ) -> List[Dict]:
         """
         Evaluate response using multiple judges in parallel.
@@ -37,12 +40,15 @@ class ObjectiveEvaluator(BaseEvaluator):
             solution: Expected solution
             judges: List of judge names to use
             api_keys: Dictionary of API keys
+            rubric: Weighted criteria the judges also score
+            turns: Earlier turns of a conversation prompt
+            system_prompt: System prompt the model was given
 
         Returns:
             List of judge results
         """
         # Format the judge prompt
-        judge_prompt = self.format_judge_prompt(prompt, response, solution)
+        judge_prompt = self.format_judge_prompt(prompt, response, solution, rubric=rubric, turns=turns, system_prompt=system_prompt)
 
         # Initialize judges
         judge_instances = []
@@ -59,6 +59,7 @@ class GeminiJudge:
                     "score": int(result["score"]),
                     "confidence": float(result["confidence"]),
                     "reasoning": str(result["reasoning"]),
+                    "criteria": {str(k): int(v) for k, v in result.get("criteria", {}).items()},
                     "cost_usd": round(cost, 4),
                     "tokens": {
                         "input": input_tokens,
@@ -4,6 +4,7 @@ from fastapi import FastAPI, HTTPException
 from fastapi.middleware.cors import CORSMiddleware
 from pydantic import BaseModel, Field
 from typing import List, Dict, Optional
+import hashlib
 import logging
 
 from config import config
@@ -36,8 +37,27 @@ app.add_middleware(
 objective_evaluator = ObjectiveEvaluator()
 creative_evaluator = CreativeEvaluator()
 
+# Version of the judge prompt templates; the Go server keys its evaluation cache by
+# it, so editing a template stops cached verdicts from being reused
+PROMPT_VERSION = hashlib.sha256(
+    (objective_evaluator.prompt_template + "\0" + creative_evaluator.prompt_template).encode("utf-8")
+).hexdigest()[:12]
+
 
 # Request/Response models
+class RubricCriterion(BaseModel):
+    """A weighted criterion the judges score separately."""
+    name: str
+    weight: float = 1.0
+    descriptor: str = ""
+
+
+class Turn(BaseModel):
+    """An earlier message of a conversation prompt."""
+    role: str
+    content: str
+
+
 class EvaluationRequest(BaseModel):
     """Request model for evaluation endpoint."""
     prompt: str = Field(..., description="The original prompt")
@@ -49,6 +69,9 @@ class EvaluationRequest(BaseModel):
         description="List of judges to use"
     )
     api_keys: Dict[str, str] = Field(..., description="API keys by provider")
+    rubric: List[RubricCriterion] = Field(default=[], description="Criteria scored separately")
+    turns: List[Turn] = Field(default=[], description="Conversation before the prompt, the final user message")
+    system_prompt: str = Field("", description="System prompt the model answered under")
 
 
 class JudgeResult(BaseModel):
@@ -59,6 +82,7 @@ class JudgeResult(BaseModel):
     reasoning: str
     cost_usd: float
     error: Optional[str] = None
+    criteria: Dict[str, int] = {}
 
 
 class EvaluationResponse(BaseModel):
@@ -87,7 +111,7 @@ class CostEstimateResponse(BaseModel):
 @app.get("/health")
 async def health_check():
     """Health check endpoint."""
-    return {"status": "healthy", "service": "llm-evaluation"}
+    return {"status": "healthy", "service": "llm-evaluation", "prompt_version": PROMPT_VERSION}
 
 
 @app.post("/evaluate", response_model=EvaluationResponse)
@@ -113,7 +137,10 @@ async def evaluate(request: EvaluationRequest):
             response=request.response,
             solution=request.solution or "",
             judges=request.judges,
-            api_keys=request.api_keys
+            api_keys=request.api_keys,
+            rubric=[c.model_dump() for c in request.rubric],
+            turns=[t.model_dump() for t in request.turns],
+            system_prompt=request.system_prompt
         )
 
         # Calculate metrics
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"io"
@@ -9,6 +10,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 type runDeps struct {
@@ -19,6 +23,7 @@ type runDeps struct {
 	getCurrentSuiteName func() string
 	writeResults        func(string, map[string]middleware.Result) error
 	initEvaluator       func(*sql.DB)
+	shutdownEvaluator   func()
 	getDB               func() *sql.DB
 	listenAndServe      func(string, http.Handler) error
 }
@@ -34,8 +39,9 @@ func defaultRunDeps() runDeps {
 		getCurrentSuiteName: middleware.GetCurrentSuiteName,
 		writeResults:        middleware.WriteResults,
 		initEvaluator:       handlers.InitEvaluator,
+		shutdownEvaluator:   handlers.ShutdownEvaluator,
 		getDB:               middleware.GetDB,
-		listenAndServe:      http.ListenAndServe,
+		listenAndServe:      listenUntilSignal,
 	}
 }
 
@@ -73,6 +79,9 @@ func run(args []string, deps runDeps) int {
 
 	log.Println("Initializing evaluator...")
 	deps.initEvaluator(deps.getDB())
+	if deps.shutdownEvaluator != nil {
+		defer deps.shutdownEvaluator()
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", router)
@@ -89,6 +98,29 @@ func run(args []string, deps runDeps) int {
 	return 0
 }
 
+// listenUntilSignal serves until SIGINT or SIGTERM, then stops accepting connections
+// and gives in-flight requests a few seconds to finish. Running evaluation jobs are
+// cancelled by the shutdownEvaluator dependency once run returns.
+func listenUntilSignal(addr string, handler http.Handler) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: addr, Handler: handler}
+	errc := make(chan error, 1)
+	go func() { errc <- server.ListenAndServe() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
 func main() {
 	osExit(run(os.Args[1:], defaultRunDeps()))
 }
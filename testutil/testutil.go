@@ -118,6 +118,7 @@ func createTestSchema(db *sql.DB) error {
 			name TEXT NOT NULL,
 			description TEXT DEFAULT '',
 			suite_id INTEGER NOT NULL,
+			rubric TEXT DEFAULT '',
 			FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE,
 			UNIQUE(name, suite_id)
 		);
@@ -130,10 +131,25 @@ func createTestSchema(db *sql.DB) error {
 			suite_id INTEGER NOT NULL,
 			display_order INTEGER DEFAULT 0,
 			type TEXT DEFAULT 'objective',
+			checker TEXT DEFAULT '',
+			checker_config TEXT DEFAULT '',
+			language TEXT DEFAULT '',
+			test_cases TEXT DEFAULT '',
+			rubric TEXT DEFAULT '',
 			FOREIGN KEY (suite_id) REFERENCES suites(id) ON DELETE CASCADE,
 			FOREIGN KEY (profile_id) REFERENCES profiles(id) ON DELETE SET NULL
 		);
 
+		CREATE TABLE IF NOT EXISTS prompt_turns (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			prompt_id INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			content TEXT NOT NULL,
+			FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE,
+			UNIQUE(prompt_id, position)
+		);
+
 		CREATE TABLE IF NOT EXISTS models (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			name TEXT NOT NULL,
@@ -152,6 +168,35 @@ func createTestSchema(db *sql.DB) error {
 			UNIQUE(model_id, prompt_id)
 		);
 
+		CREATE TABLE IF NOT EXISTS score_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			model_id INTEGER,
+			prompt_id INTEGER,
+			model_name TEXT NOT NULL DEFAULT '',
+			prompt_text TEXT NOT NULL DEFAULT '',
+			old_score INTEGER,
+			new_score INTEGER,
+			source TEXT NOT NULL,
+			job_id INTEGER,
+			note TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE SET NULL,
+			FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE SET NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS criterion_scores (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			model_id INTEGER NOT NULL,
+			prompt_id INTEGER NOT NULL,
+			criterion TEXT NOT NULL,
+			score INTEGER NOT NULL,
+			weight REAL DEFAULT 1,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
+			FOREIGN KEY (prompt_id) REFERENCES prompts(id) ON DELETE CASCADE,
+			UNIQUE(model_id, prompt_id, criterion)
+		);
+
 		CREATE TABLE IF NOT EXISTS settings (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			key TEXT UNIQUE NOT NULL,
@@ -171,6 +216,11 @@ func createTestSchema(db *sql.DB) error {
 			estimated_cost_usd REAL DEFAULT 0,
 			actual_cost_usd REAL DEFAULT 0,
 			error_message TEXT DEFAULT '',
+			force_refresh INTEGER NOT NULL DEFAULT 0,
+			cache_hits INTEGER NOT NULL DEFAULT 0,
+			cache_misses INTEGER NOT NULL DEFAULT 0,
+			cache_saved_usd REAL NOT NULL DEFAULT 0,
+			judge_panel TEXT NOT NULL DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			started_at DATETIME,
 			completed_at DATETIME,
@@ -184,6 +234,7 @@ func createTestSchema(db *sql.DB) error {
 			response_text TEXT DEFAULT '',
 			response_source TEXT DEFAULT '',
 			api_config TEXT DEFAULT '',
+			system_prompt_version_id INTEGER,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
@@ -200,6 +251,10 @@ func createTestSchema(db *sql.DB) error {
 			judge_confidence REAL DEFAULT 0,
 			judge_reasoning TEXT DEFAULT '',
 			cost_usd REAL DEFAULT 0,
+			test_results TEXT DEFAULT '',
+			cache_key TEXT NOT NULL DEFAULT '',
+			cache_hit INTEGER NOT NULL DEFAULT 0,
+			criteria TEXT DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (job_id) REFERENCES evaluation_jobs(id) ON DELETE CASCADE,
 			FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
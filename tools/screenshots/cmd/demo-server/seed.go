@@ -67,6 +67,11 @@ func seedDemoData() error {
 			Profile:  "programming",
 			Text:     "Write a Go function that returns the max of two ints.",
 			Solution: "func Max(a, b int) int { if a > b { return a }; return b }",
+			Language: "go",
+			Tests: []middleware.TestCase{
+				{Name: "larger second", Code: "import \"fmt\"\n\nfunc main() { fmt.Println(Max(3, 7)) }", Expected: "7"},
+				{Name: "negatives", Code: "import \"fmt\"\n\nfunc main() { fmt.Println(Max(-1, -5)) }", Expected: "-1"},
+			},
 		},
 		{
 			Profile:  "programming",